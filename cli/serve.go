@@ -23,11 +23,15 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/yomorun/yomo"
 	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/pkg/check"
 	pkgconfig "github.com/yomorun/yomo/pkg/config"
 	"github.com/yomorun/yomo/pkg/log"
 	"github.com/yomorun/yomo/pkg/trace"
 )
 
+// serveCheck validates the config and the environment instead of serving.
+var serveCheck bool
+
 // serveCmd represents the serve command
 var serveCmd = &cobra.Command{
 	Use:   "serve",
@@ -39,7 +43,6 @@ var serveCmd = &cobra.Command{
 			return
 		}
 
-		log.InfoStatusEvent(os.Stdout, "Running YoMo-Zipper...")
 		// config
 		conf, err := pkgconfig.ParseConfigFile(config)
 		if err != nil {
@@ -47,6 +50,18 @@ var serveCmd = &cobra.Command{
 			return
 		}
 		ctx := context.Background()
+
+		if serveCheck {
+			log.InfoStatusEvent(os.Stdout, "Checking YoMo-Zipper...")
+			report := check.Run(ctx, conf)
+			report.Print(os.Stdout)
+			if report.Failed() {
+				os.Exit(1)
+			}
+			return
+		}
+
+		log.InfoStatusEvent(os.Stdout, "Running YoMo-Zipper...")
 		// trace
 		tp, shutdown, err := trace.NewTracerProvider("yomo-zipper")
 		if err == nil {
@@ -62,6 +77,9 @@ var serveCmd = &cobra.Command{
 				options = append(options, yomo.WithAuth("token", tokenString))
 			}
 		}
+		if conf.Codec != "" {
+			options = append(options, yomo.WithZipperCodecName(conf.Codec))
+		}
 
 		zipper, err := yomo.NewZipper(conf.Name, router.Default(), nil, conf.Mesh, options...)
 		if err != nil {
@@ -82,4 +100,5 @@ func init() {
 	rootCmd.AddCommand(serveCmd)
 
 	serveCmd.Flags().StringVarP(&config, "config", "c", "", "config file")
+	serveCmd.Flags().BoolVar(&serveCheck, "check", false, "check the config and the environment (TLS, UDP port, auth, downstreams and clock), then exit")
 }
@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 Allegro Networks
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yomorun/yomo/pkg/log"
+	"github.com/yomorun/yomo/pkg/simulator"
+)
+
+// simulateCmd represents the simulate command
+var simulateCmd = &cobra.Command{
+	Use:   "simulate [simulation.yaml]",
+	Short: "Simulate a deployment for capacity planning",
+	Long:  "Simulate the queue depths, bandwidth and latency of a topology with per-tag rate/size profiles",
+	Run: func(cmd *cobra.Command, args []string) {
+		specFile := "simulation.yaml"
+		if len(args) >= 1 && args[0] != "" {
+			specFile = args[0]
+		}
+
+		spec, err := simulator.ParseSpecFile(specFile)
+		if err != nil {
+			log.FailureStatusEvent(os.Stdout, err.Error())
+			return
+		}
+		log.InfoStatusEvent(os.Stdout, "Simulating %s of %s", spec.Duration, specFile)
+		fmt.Print(simulator.Run(spec).String())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(simulateCmd)
+}
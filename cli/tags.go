@@ -0,0 +1,70 @@
+/*
+Copyright © 2021 Allegro Networks
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cli
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yomorun/yomo/pkg/file"
+	"github.com/yomorun/yomo/pkg/log"
+	"github.com/yomorun/yomo/pkg/tags"
+)
+
+var tagsOutput string
+
+// tagsCmd represents the tags command
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "Manage the tags of YoMo",
+	Long:  "Manage the tags of YoMo",
+}
+
+// tagsGenCmd represents the tags gen command
+var tagsGenCmd = &cobra.Command{
+	Use:   "gen [tags.yaml]",
+	Short: "Generate Go constants from a tag registry file",
+	Long:  "Generate Go constants and a reverse-lookup map from a tag registry file",
+	Run: func(cmd *cobra.Command, args []string) {
+		registryFile := "tags.yaml"
+		if len(args) >= 1 && args[0] != "" {
+			registryFile = args[0]
+		}
+
+		reg, err := tags.ParseRegistryFile(registryFile)
+		if err != nil {
+			log.FailureStatusEvent(os.Stdout, err.Error())
+			return
+		}
+		code, err := reg.Generate()
+		if err != nil {
+			log.FailureStatusEvent(os.Stdout, err.Error())
+			return
+		}
+		if err := file.PutContents(tagsOutput, code); err != nil {
+			log.FailureStatusEvent(os.Stdout, "Write tags into %s failure with the error: %v", tagsOutput, err)
+			return
+		}
+		log.SuccessStatusEvent(os.Stdout, "Generated %d tags into %s", len(reg.Tags), tagsOutput)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagsCmd)
+	tagsCmd.AddCommand(tagsGenCmd)
+
+	tagsGenCmd.Flags().StringVarP(&tagsOutput, "output", "o", "tags_gen.go", "output file")
+}
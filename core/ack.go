@@ -0,0 +1,179 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+// ErrAckTimeout is reported if the DataFrames are not acknowledged by zipper after all the retransmissions,
+// they are given up.
+var ErrAckTimeout = yerr.New(yerr.CodeNetwork, "yomo: ack timeout")
+
+// protocolVersionAck is the protocol version since which zipper acknowledges the DataFrames carrying an AckID.
+const protocolVersionAck uint32 = 3
+
+// DefaultAckMaxPending is the max number of the DataFrames waiting for the ack, writing blocks once it is reached.
+const DefaultAckMaxPending = 4096
+
+// pendingAck is a DataFrame waiting for the AckFrame.
+type pendingAck struct {
+	frame   *frame.DataFrame
+	sent    time.Time
+	retries int
+}
+
+// ackTracker tracks the written DataFrames until they are acknowledged, the unacknowledged ones
+// are retransmitted after the timeout, at most maxRetries times.
+type ackTracker struct {
+	timeout    time.Duration
+	maxRetries int
+	lastID     atomic.Uint64
+	now        func() time.Time
+
+	slots chan struct{} // a slot is taken by every pending DataFrame
+	done  <-chan struct{}
+
+	mu      sync.Mutex
+	pending map[uint64]*pendingAck
+}
+
+// newAckTracker returns the ackTracker of at most maxPending DataFrames, the tracking stops blocking once done is closed.
+func newAckTracker(timeout time.Duration, maxRetries int, maxPending int, done <-chan struct{}) *ackTracker {
+	return &ackTracker{
+		timeout:    timeout,
+		maxRetries: maxRetries,
+		now:        time.Now,
+		slots:      make(chan struct{}, maxPending),
+		done:       done,
+		pending:    make(map[uint64]*pendingAck),
+	}
+}
+
+// track assigns an AckID to the DataFrame and waits for its AckFrame, it blocks while maxPending DataFrames
+// are waiting, until one of them is acknowledged or given up, or ctx is done.
+func (t *ackTracker) track(ctx context.Context, df *frame.DataFrame) error {
+	select {
+	case t.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.done:
+		return context.Canceled
+	}
+	if df.AckID == 0 {
+		df.AckID = t.lastID.Add(1)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending[df.AckID] = &pendingAck{frame: df, sent: t.now()}
+	return nil
+}
+
+// ack removes the acknowledged DataFrame, it reports whether the DataFrame is waiting for the ack.
+func (t *ackTracker) ack(id uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, ok := t.pending[id]
+	if ok {
+		delete(t.pending, id)
+		<-t.slots
+	}
+	return ok
+}
+
+// reset gives up all the DataFrames waiting for the ack, and returns the number of them.
+func (t *ackTracker) reset() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := len(t.pending)
+	for id := range t.pending {
+		delete(t.pending, id)
+		<-t.slots
+	}
+	return n
+}
+
+// due returns the DataFrames to be retransmitted as they are not acknowledged in the timeout,
+// and the number of the DataFrames given up after maxRetries retransmissions.
+func (t *ackTracker) due() ([]*frame.DataFrame, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var (
+		now     = t.now()
+		resend  []*frame.DataFrame
+		expired int
+	)
+	for id, p := range t.pending {
+		if now.Sub(p.sent) < t.timeout {
+			continue
+		}
+		if p.retries >= t.maxRetries {
+			delete(t.pending, id)
+			<-t.slots
+			expired++
+			continue
+		}
+		p.retries++
+		p.sent = now
+		resend = append(resend, p.frame)
+	}
+	return resend, expired
+}
+
+// len returns the number of the DataFrames waiting for the ack.
+func (t *ackTracker) len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.pending)
+}
+
+// retransmit writes the DataFrames that are not acknowledged in the ack timeout again, until the client is closed.
+func (c *Client) retransmit() {
+	ticker := time.NewTicker(c.acks.timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		resend, expired := c.acks.due()
+		for _, df := range resend {
+			if err := c.enqueueChunks(c.ctx, df); err != nil {
+				c.Logger.Debug("failed to retransmit frame", "tag", df.Tag, "ack_id", df.AckID, "err", err)
+			}
+		}
+		if expired > 0 {
+			c.Logger.Warn("frames are not acknowledged", "count", expired, "retries", c.acks.maxRetries)
+			c.reportError(fmt.Errorf("%w: %d frames given up", ErrAckTimeout, expired))
+		}
+	}
+}
+
+// ackNegotiated reports whether zipper acknowledges the DataFrames, it is assumed before connecting to zipper.
+func (c *Client) ackNegotiated() bool {
+	version := c.protocolVersion.Load()
+	return version == 0 || version >= protocolVersionAck
+}
+
+// Unacked returns the number of the DataFrames waiting for the ack of zipper,
+// it is always zero if the ack is disabled, see WithAck.
+func (c *Client) Unacked() int {
+	if c.acks == nil {
+		return 0
+	}
+	return c.acks.len()
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/router"
+)
+
+func TestAckTracker(t *testing.T) {
+	now := time.Now()
+	tracker := newAckTracker(time.Second, 1, 2, nil)
+	tracker.now = func() time.Time { return now }
+
+	acked, lost := &frame.DataFrame{Tag: 1}, &frame.DataFrame{Tag: 2}
+	assert.NoError(t, tracker.track(context.TODO(), acked))
+	assert.NoError(t, tracker.track(context.TODO(), lost))
+	assert.Equal(t, uint64(1), acked.AckID)
+	assert.Equal(t, uint64(2), lost.AckID)
+	assert.Equal(t, 2, tracker.len())
+
+	// the pending DataFrames are bounded.
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, tracker.track(ctx, &frame.DataFrame{Tag: 3}), context.DeadlineExceeded)
+
+	assert.True(t, tracker.ack(acked.AckID))
+	assert.False(t, tracker.ack(acked.AckID))
+
+	resend, expired := tracker.due()
+	assert.Empty(t, resend)
+	assert.Equal(t, 0, expired)
+
+	now = now.Add(time.Second)
+	resend, expired = tracker.due()
+	assert.Equal(t, []*frame.DataFrame{lost}, resend)
+	assert.Equal(t, 0, expired)
+
+	now = now.Add(time.Second)
+	resend, expired = tracker.due()
+	assert.Empty(t, resend)
+	assert.Equal(t, 1, expired)
+	assert.Equal(t, 0, tracker.len())
+	assert.Len(t, tracker.slots, 0)
+}
+
+func TestAckNegotiated(t *testing.T) {
+	client := NewClient("source", testaddr, ClientTypeSource,
+		WithLogger(discardingLogger), WithAck(time.Second, 3), WithWriteBufferSize(10))
+	defer client.Close()
+
+	// the DataFrame of the caller is not changed.
+	df := &frame.DataFrame{Tag: 1}
+	assert.NoError(t, client.WriteFrame(df))
+	assert.Zero(t, df.AckID)
+	assert.Equal(t, 1, client.Unacked())
+
+	// zipper speaking an older protocol never acknowledges.
+	client.protocolVersion.Store(protocolVersionAck - 1)
+	assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: 1}))
+	assert.Equal(t, 1, client.Unacked())
+}
+
+func TestAck(t *testing.T) {
+	t.Parallel()
+
+	const ackAddr = "127.0.0.1:19974"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	server.ConfigRouter(router.Default())
+	go server.ListenAndServe(context.TODO(), ackAddr)
+	defer server.Close()
+
+	received := make(chan *frame.DataFrame, 2)
+	sfn := NewClient("sfn", ackAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	sfn.SetObserveDataTags(1)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df })
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	source := NewClient(
+		"source", ackAddr, ClientTypeSource,
+		WithLogger(discardingLogger), WithAck(time.Second, 3), WithMaxFrameSize(1024),
+	)
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+	large := make([]byte, 4096)
+	for _, payload := range [][]byte{[]byte("hello"), large} {
+		assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: payload}))
+	}
+
+	for _, want := range [][]byte{[]byte("hello"), large} {
+		select {
+		case df := <-received:
+			assert.Equal(t, want, df.Payload)
+			// the ack id is not routed to the sfn.
+			assert.Zero(t, df.AckID)
+		case <-time.After(3 * time.Second):
+			t.Fatal("the sfn does not receive the data")
+		}
+	}
+	assert.Eventually(t, func() bool { return source.Unacked() == 0 }, 3*time.Second, 10*time.Millisecond)
+	assert.Zero(t, sfn.Unacked())
+}
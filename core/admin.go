@@ -0,0 +1,97 @@
+//go:build !yomo_noadmin
+
+package core
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+)
+
+// TopologyHandler returns a http.Handler that exports the topology of the server,
+// it responds DOT if the query `format=dot` is given, otherwise JSON.
+func TopologyHandler(s *Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t := s.Topology()
+
+		if r.URL.Query().Get("format") == "dot" {
+			w.Header().Set("Content-Type", "text/vnd.graphviz")
+			_, _ = w.Write([]byte(t.DOT()))
+			return
+		}
+
+		b, err := t.JSON()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+	})
+}
+
+// ReloadHandler returns a http.Handler that reloads the configuration of the server by `POST`,
+// see Server.Reload. It is not authenticated by itself, see WithAdminToken.
+func ReloadHandler(s *Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := s.Reload(); err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, ErrReloadUnsupported) {
+				code = http.StatusNotImplemented
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// adminHandler returns the handler of the admin endpoints, including the ones of WithAdminHandler.
+func (s *Server) adminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/topology", TopologyHandler(s))
+	mux.Handle("/reload", ReloadHandler(s))
+	for pattern, handler := range s.opts.adminHandlers {
+		mux.Handle(pattern, handler)
+	}
+	if s.opts.adminToken == "" {
+		return mux
+	}
+	return bearerAuth(s.opts.adminToken, mux)
+}
+
+// bearerAuth returns the handler that serves the requests bearing the token only.
+func bearerAuth(token string, next http.Handler) http.Handler {
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveAdmin serves the admin endpoints until the context is done.
+func (s *Server) serveAdmin(ctx context.Context, addr string) {
+	srv := &http.Server{Addr: addr, Handler: s.adminHandler()}
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-s.ctx.Done():
+		}
+		_ = srv.Close()
+	}()
+
+	s.logger.Info("admin is up and running", "admin_addr", addr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		s.logger.Error("failed to serve admin", "err", err)
+	}
+}
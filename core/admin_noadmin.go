@@ -0,0 +1,10 @@
+//go:build yomo_noadmin
+
+package core
+
+import "context"
+
+// serveAdmin does nothing, the admin http server is excluded by the build tag `yomo_noadmin`.
+func (s *Server) serveAdmin(_ context.Context, addr string) {
+	s.logger.Warn("admin is excluded from the build, the admin_addr is ignored", "admin_addr", addr)
+}
@@ -0,0 +1,98 @@
+//go:build !yomo_noadmin
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopologyHandler(t *testing.T) {
+	s := NewServer("zipper")
+	s.AddDownstreamServer(&recordDownstream{})
+
+	s.flows.add(1, "source", "sfn")
+	s.flows.add(1, "source", "sfn")
+	s.flows.add(2, "source", "record")
+
+	topo := s.Topology()
+
+	t.Run("json", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		TopologyHandler(s).ServeHTTP(w, httptest.NewRequest("GET", "/topology", nil))
+
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var got Topology
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, topo.Downstreams, got.Downstreams)
+		assert.Len(t, got.Flows, 2)
+	})
+
+	t.Run("dot", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		TopologyHandler(s).ServeHTTP(w, httptest.NewRequest("GET", "/topology?format=dot", nil))
+
+		dot := w.Body.String()
+		assert.True(t, strings.HasPrefix(dot, `digraph "zipper" {`))
+		assert.Contains(t, dot, `"zipper" -> "record" [style=dashed];`)
+		assert.Contains(t, dot, `"source" -> "sfn" [label="tag 1`)
+	})
+}
+
+func TestReloadHandler(t *testing.T) {
+	reload := func(s *Server, method string) int {
+		w := httptest.NewRecorder()
+		ReloadHandler(s).ServeHTTP(w, httptest.NewRequest(method, "/reload", nil))
+		return w.Code
+	}
+
+	s := NewServer("zipper")
+	assert.Equal(t, http.StatusNotImplemented, reload(s, http.MethodPost))
+
+	s = NewServer("zipper", WithReloadFunc(func(*Server) error { return nil }))
+	assert.Equal(t, http.StatusMethodNotAllowed, reload(s, http.MethodGet))
+	assert.Equal(t, http.StatusNoContent, reload(s, http.MethodPost))
+
+	s = NewServer("zipper", WithReloadFunc(func(*Server) error { return errors.New("bad config") }))
+	assert.Equal(t, http.StatusInternalServerError, reload(s, http.MethodPost))
+}
+
+func TestAdminHandler(t *testing.T) {
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("yomo_zipper_frames_total 1\n"))
+	})
+	s := NewServer("zipper", WithAdminHandler("/metrics", metrics))
+
+	w := httptest.NewRecorder()
+	s.adminHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "yomo_zipper_frames_total 1\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	s.adminHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/topology", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAdminToken(t *testing.T) {
+	s := NewServer("zipper", WithAdminToken("secret"), WithReloadFunc(func(*Server) error { return nil }))
+
+	reload := func(authorization string) int {
+		r := httptest.NewRequest(http.MethodPost, "/reload", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		s.adminHandler().ServeHTTP(w, r)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, reload(""))
+	assert.Equal(t, http.StatusUnauthorized, reload("Bearer wrong"))
+	assert.Equal(t, http.StatusNoContent, reload("Bearer secret"))
+}
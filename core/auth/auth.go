@@ -22,6 +22,14 @@ type Authentication interface {
 	Name() string
 }
 
+// ExpiringAuthentication is the Authentication whose credentials expire, the client of an expired
+// credential is told to refresh the credential instead of being rejected as invalid.
+type ExpiringAuthentication interface {
+	Authentication
+	// Expired reports whether the credential was valid but is expired.
+	Expired(payload string) bool
+}
+
 // Register register authentication
 func Register(authentication Authentication) {
 	auths[authentication.Name()] = authentication
@@ -83,3 +91,13 @@ func Authenticate(auths map[string]Authentication, obj *frame.HandshakeFrame) (m
 
 	return auth.Authenticate(obj.AuthPayload)
 }
+
+// Expired reports whether the credential of the Object is refused because it is expired,
+// see ExpiringAuthentication.
+func Expired(auths map[string]Authentication, obj *frame.HandshakeFrame) bool {
+	if obj == nil {
+		return false
+	}
+	auth, ok := auths[obj.AuthName].(ExpiringAuthentication)
+	return ok && auth.Expired(obj.AuthPayload)
+}
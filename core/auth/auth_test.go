@@ -87,6 +87,20 @@ func TestAuthenticate(t *testing.T) {
 	}
 }
 
+// mockExpiringAuth implement `ExpiringAuthentication` interface, every credential is expired.
+type mockExpiringAuth struct{ mockAuth }
+
+func (auth mockExpiringAuth) Expired(payload string) bool { return true }
+
+func TestExpired(t *testing.T) {
+	obj := &frame.HandshakeFrame{AuthName: "mock", AuthPayload: "mock_payload"}
+
+	assert.False(t, Expired(nil, obj))
+	assert.False(t, Expired(map[string]Authentication{"mock": mockAuth{}}, obj))
+	assert.False(t, Expired(map[string]Authentication{"mock": mockExpiringAuth{}}, nil))
+	assert.True(t, Expired(map[string]Authentication{"mock": mockExpiringAuth{}}, obj))
+}
+
 func TestNewCredential(t *testing.T) {
 	type args struct {
 		payload string
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ReconnectBackoff is the backoff policy used by the client when reconnecting to zipper.
+// The delay of the n-th attempt is `Initial * Multiplier^n`, capped by `Max`, then jittered
+// randomly within `[delay*(1-Jitter), delay*(1+Jitter)]`.
+type ReconnectBackoff struct {
+	// Initial is the delay of the first reconnection.
+	Initial time.Duration
+	// Max caps the delay of reconnection, zero means no cap.
+	Max time.Duration
+	// Multiplier is the factor by which the delay grows after each attempt,
+	// values less than 1 are treated as 1.
+	Multiplier float64
+	// Jitter is the randomization factor in [0, 1], it spreads reconnections of
+	// many clients so that they don't hammer the zipper in lockstep.
+	Jitter float64
+	// MaxAttempts is the maximum number of consecutive reconnect attempts,
+	// zero means reconnecting forever.
+	MaxAttempts int
+}
+
+// DefaultReconnectBackoff reconnects every second forever.
+var DefaultReconnectBackoff = ReconnectBackoff{
+	Initial:    time.Second,
+	Max:        time.Second,
+	Multiplier: 1,
+}
+
+// ExponentialReconnectBackoff returns a jittered exponential backoff policy
+// that starts from 1s and grows up to 1min.
+func ExponentialReconnectBackoff() ReconnectBackoff {
+	return ReconnectBackoff{
+		Initial:    time.Second,
+		Max:        time.Minute,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+}
+
+// Exhausted reports whether the attempt exceeds MaxAttempts.
+func (b ReconnectBackoff) Exhausted(attempt int) bool {
+	return b.MaxAttempts > 0 && attempt >= b.MaxAttempts
+}
+
+// Duration returns the delay before the given attempt, the attempt starts from 0.
+func (b ReconnectBackoff) Duration(attempt int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(b.Initial) * math.Pow(multiplier, float64(attempt))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+
+	if jitter := math.Min(b.Jitter, 1); jitter > 0 {
+		delay = delay * (1 - jitter + 2*jitter*rand.Float64())
+	}
+
+	return time.Duration(delay)
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconnectBackoff(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, time.Second, DefaultReconnectBackoff.Duration(i))
+		}
+		assert.False(t, DefaultReconnectBackoff.Exhausted(1000))
+	})
+
+	t.Run("exponential", func(t *testing.T) {
+		b := ReconnectBackoff{Initial: time.Second, Max: 5 * time.Second, Multiplier: 2, MaxAttempts: 3}
+		assert.Equal(t, time.Second, b.Duration(0))
+		assert.Equal(t, 2*time.Second, b.Duration(1))
+		assert.Equal(t, 4*time.Second, b.Duration(2))
+		assert.Equal(t, 5*time.Second, b.Duration(3))
+		assert.False(t, b.Exhausted(2))
+		assert.True(t, b.Exhausted(3))
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		b := ReconnectBackoff{Initial: time.Second, Multiplier: 1, Jitter: 0.5}
+		for i := 0; i < 100; i++ {
+			d := b.Duration(i)
+			assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+			assert.LessOrEqual(t, d, 1500*time.Millisecond)
+		}
+	})
+}
+
+func TestHandleConnectResultBackoff(t *testing.T) {
+	client := NewClient(
+		"source", testaddr, ClientTypeSource,
+		WithLogger(discardingLogger),
+		WithReconnectBackoff(ReconnectBackoff{Initial: time.Millisecond, MaxAttempts: 2}),
+	)
+	connErr := errors.New("mock connect error")
+
+	reconnect, err := client.handleConnectResult(context.TODO(), connErr, true, 1)
+	assert.True(t, reconnect)
+	assert.NoError(t, err)
+
+	reconnect, err = client.handleConnectResult(context.TODO(), connErr, true, 2)
+	assert.False(t, reconnect)
+	assert.Equal(t, connErr, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client.opts.backoff.Initial = time.Hour
+	reconnect, err = client.handleConnectResult(ctx, connErr, true, 0)
+	assert.False(t, reconnect)
+	assert.Equal(t, context.Canceled, err)
+}
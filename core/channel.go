@@ -0,0 +1,65 @@
+package core
+
+import (
+	"sync/atomic"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// ChannelStats is the flow accounting of a channel, the logical stream of DataFrames.
+type ChannelStats struct {
+	// WrittenFrames is the number of frames accepted by WriteFrame.
+	WrittenFrames int64
+	// WrittenBytes is the payload bytes of the written frames.
+	WrittenBytes int64
+	// ReadFrames is the number of frames read from zipper.
+	ReadFrames int64
+	// ReadBytes is the payload bytes of the read frames.
+	ReadBytes int64
+}
+
+type channelCounter struct {
+	wrFrames, wrBytes atomic.Int64
+	rdFrames, rdBytes atomic.Int64
+}
+
+// countChannel accounts the DataFrame to its channel, the default channel is not accounted.
+func (c *Client) countChannel(df *frame.DataFrame, written bool) {
+	if df.Channel == 0 {
+		return
+	}
+	v, ok := c.channels.Load(df.Channel)
+	if !ok {
+		v, _ = c.channels.LoadOrStore(df.Channel, new(channelCounter))
+	}
+	counter := v.(*channelCounter)
+	if written {
+		counter.wrFrames.Add(1)
+		counter.wrBytes.Add(int64(len(df.Payload)))
+	} else {
+		counter.rdFrames.Add(1)
+		counter.rdBytes.Add(int64(len(df.Payload)))
+	}
+}
+
+// ChannelStats returns the flow accounting of the channels that have been written or read.
+func (c *Client) ChannelStats() map[uint32]ChannelStats {
+	stats := make(map[uint32]ChannelStats)
+	c.channels.Range(func(key, value any) bool {
+		counter := value.(*channelCounter)
+		stats[key.(uint32)] = ChannelStats{
+			WrittenFrames: counter.wrFrames.Load(),
+			WrittenBytes:  counter.wrBytes.Load(),
+			ReadFrames:    counter.rdFrames.Load(),
+			ReadBytes:     counter.rdBytes.Load(),
+		}
+		return true
+	})
+	return stats
+}
+
+// ResetChannel drops the flow accounting of the channel, it should be called once the logical stream
+// is finished, e.g. the device goes offline, so the accounting of a short-lived channel is not kept forever.
+func (c *Client) ResetChannel(channel uint32) {
+	c.channels.Delete(channel)
+}
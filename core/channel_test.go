@@ -0,0 +1,27 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestChannelStats(t *testing.T) {
+	client := NewClient("source", testaddr, ClientTypeSource, WithLogger(discardingLogger), WithWriteBufferSize(10))
+	client.SetDataFrameObserver(func(*frame.DataFrame) {})
+
+	assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("hello"), Channel: 7}))
+	assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("yomo"), Channel: 7}))
+	assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("default")}))
+	client.handleFrame(&frame.DataFrame{Tag: 2, Payload: []byte("ack"), Channel: 7})
+	client.handleFrame(&frame.DataFrame{Tag: 2, Payload: []byte("hi"), Channel: 8})
+
+	assert.Equal(t, map[uint32]ChannelStats{
+		7: {WrittenFrames: 2, WrittenBytes: 9, ReadFrames: 1, ReadBytes: 3},
+		8: {ReadFrames: 1, ReadBytes: 2},
+	}, client.ChannelStats())
+
+	client.ResetChannel(7)
+	assert.Equal(t, map[uint32]ChannelStats{8: {ReadFrames: 1, ReadBytes: 2}}, client.ChannelStats())
+}
@@ -0,0 +1,29 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/frame-codec/y3codec"
+)
+
+// isCorrupted reports whether the error is caused by a frame whose checksum mismatches, the frame
+// is dropped and the connection keeps working, because the frame is read as a whole.
+func isCorrupted(err error) bool {
+	return errors.Is(err, y3codec.ErrChecksumMismatch)
+}
+
+// isMalformed reports whether the error is caused by a frame that cannot be decoded or exceeds the limits,
+// the frame is dropped and the connection keeps working, because the packet is read or discarded as a whole.
+func isMalformed(err error) bool {
+	me := new(frame.ErrMalformed)
+	return errors.As(err, &me)
+}
+
+// codecOptions returns the options of the y3 codec.
+func codecOptions(checksum bool) []y3codec.Option {
+	if checksum {
+		return []y3codec.Option{y3codec.WithChecksum()}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+// DefaultChunkTimeout is the time to wait for the next chunk of a payload, the received
+// chunks are dropped once it elapses, e.g. the writer is disconnected while writing the chunks.
+const DefaultChunkTimeout = time.Minute
+
+// The bounds of the chunks buffered by the reader, the payloads exceeding them are dropped.
+const (
+	// maxChunkPartials is the max number of the payloads being reassembled.
+	maxChunkPartials = 1024
+	// maxChunkBytes is the max bytes of the chunks buffered.
+	maxChunkBytes = 256 << 20
+)
+
+// ErrChunkLost is reported if a chunk of a payload is lost, the payload is dropped once the chunk timeout elapses.
+var ErrChunkLost = yerr.New(yerr.CodeProtocol, "yomo: chunk lost")
+
+// ErrChunkOverflow is reported if the chunks of a payload exceed the bounds of the buffered chunks,
+// the payload is dropped.
+var ErrChunkOverflow = yerr.New(yerr.CodeOverload, "yomo: too many chunks buffered")
+
+// splitChunks splits the payload of the DataFrame into the chunks of at most size bytes,
+// every chunk carries the metadata of the DataFrame, and the last one carries the AckID.
+func splitChunks(df *frame.DataFrame, id uint32, size int) []*frame.DataFrame {
+	total := (len(df.Payload) + size - 1) / size
+	chunks := make([]*frame.DataFrame, 0, total)
+	for seq := 0; seq < total; seq++ {
+		end := (seq + 1) * size
+		if end > len(df.Payload) {
+			end = len(df.Payload)
+		}
+		chunks = append(chunks, &frame.DataFrame{
+			Tag:        df.Tag,
+			Metadata:   df.Metadata,
+			Payload:    df.Payload[seq*size : end],
+			Priority:   df.Priority,
+			Channel:    df.Channel,
+			Chunk:      frame.Chunk{ID: id, Seq: uint32(seq), Total: uint32(total)},
+			Extensions: df.Extensions,
+		})
+	}
+	chunks[total-1].AckID = df.AckID
+	return chunks
+}
+
+// chunkKey identifies the chunks of a payload, the chunk IDs are unique in the writer only.
+type chunkKey struct {
+	sourceID string
+	tag      frame.Tag
+	id       uint32
+}
+
+// partialPayload is a payload whose chunks are being received.
+type partialPayload struct {
+	first   frame.DataFrame // the properties of the payload, its payload is not kept
+	chunks  map[uint32][]byte
+	size    int
+	updated time.Time
+}
+
+// chunkAssembler reassembles the chunks of the payloads, the chunks of a payload may arrive out of order,
+// e.g. they are written on the streams chosen in turn.
+type chunkAssembler struct {
+	timeout time.Duration
+	now     func() time.Time
+
+	mu       sync.Mutex
+	partials map[chunkKey]*partialPayload
+	size     int // the bytes of the chunks buffered
+}
+
+func newChunkAssembler(timeout time.Duration) *chunkAssembler {
+	return &chunkAssembler{
+		timeout:  timeout,
+		now:      time.Now,
+		partials: make(map[chunkKey]*partialPayload),
+	}
+}
+
+// add adds the chunk, it returns the reassembled DataFrame once all the chunks are added,
+// or nil if more chunks are expected. The error reports the payloads dropped, that are the ones
+// waiting for the chunks longer than the timeout, or the payload of the chunk exceeding the bounds.
+func (a *chunkAssembler) add(df *frame.DataFrame) (*frame.DataFrame, error) {
+	key := chunkKey{tag: df.Tag, id: df.Chunk.ID}
+	if md, err := metadata.Decode(df.Metadata); err == nil {
+		key.sourceID = GetSourceIDFromMetadata(md)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	var err error
+	if lost := a.expire(now); lost > 0 {
+		err = fmt.Errorf("%w: %d payloads are not completed in %s", ErrChunkLost, lost, a.timeout)
+	}
+
+	p, ok := a.partials[key]
+	if !ok {
+		if len(a.partials) >= maxChunkPartials {
+			return nil, fmt.Errorf("%w: tag=%d, id=%d, partial payloads=%d", ErrChunkOverflow, df.Tag, df.Chunk.ID, len(a.partials))
+		}
+		p = &partialPayload{first: *df, chunks: make(map[uint32][]byte)}
+		p.first.Payload = nil
+		a.partials[key] = p
+	}
+	p.updated = now
+	if _, ok := p.chunks[df.Chunk.Seq]; ok || df.Chunk.Seq >= p.first.Chunk.Total {
+		// the chunk is retransmitted or bogus.
+		return nil, err
+	}
+	if a.size+len(df.Payload) > maxChunkBytes {
+		a.drop(key, p)
+		return nil, fmt.Errorf("%w: tag=%d, id=%d, buffered bytes=%d", ErrChunkOverflow, df.Tag, df.Chunk.ID, a.size)
+	}
+	// the chunk is copied, the buffer grows as the chunks arrive.
+	p.chunks[df.Chunk.Seq] = append([]byte(nil), df.Payload...)
+	p.size += len(df.Payload)
+	a.size += len(df.Payload)
+
+	if len(p.chunks) < int(p.first.Chunk.Total) {
+		return nil, err
+	}
+	a.drop(key, p)
+
+	payload := make([]byte, 0, p.size)
+	for seq := uint32(0); seq < p.first.Chunk.Total; seq++ {
+		payload = append(payload, p.chunks[seq]...)
+	}
+	return &frame.DataFrame{
+		Tag:        p.first.Tag,
+		Metadata:   p.first.Metadata,
+		Payload:    payload,
+		Priority:   p.first.Priority,
+		Channel:    p.first.Channel,
+		Extensions: p.first.Extensions,
+	}, err
+}
+
+// drop drops the partial payload.
+func (a *chunkAssembler) drop(key chunkKey, p *partialPayload) {
+	delete(a.partials, key)
+	a.size -= p.size
+}
+
+// expire drops the partial payloads that wait for the next chunk longer than the timeout,
+// and returns the number of them.
+func (a *chunkAssembler) expire(now time.Time) int {
+	var expired int
+	for key, p := range a.partials {
+		if now.Sub(p.updated) > a.timeout {
+			a.drop(key, p)
+			expired++
+		}
+	}
+	return expired
+}
+
+// pending returns the number of the partial payloads.
+func (a *chunkAssembler) pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.partials)
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/router"
+)
+
+func TestChunkAssembler(t *testing.T) {
+	df := &frame.DataFrame{Tag: 1, Payload: []byte("hello yomo"), Channel: 2}
+	chunks := splitChunks(df, 7, 4)
+	assert.Len(t, chunks, 3)
+	assert.Equal(t, []byte("mo"), chunks[2].Payload)
+	assert.Equal(t, frame.Chunk{ID: 7, Seq: 2, Total: 3}, chunks[2].Chunk)
+
+	t.Run("reassemble", func(t *testing.T) {
+		a := newChunkAssembler(time.Minute)
+		for _, chunk := range chunks[:2] {
+			assembled, err := a.add(chunk)
+			assert.NoError(t, err)
+			assert.Nil(t, assembled)
+		}
+		assembled, err := a.add(chunks[2])
+		assert.NoError(t, err)
+		assert.Equal(t, df, assembled)
+		assert.Equal(t, 0, a.pending())
+	})
+
+	t.Run("out of order", func(t *testing.T) {
+		a := newChunkAssembler(time.Minute)
+		for _, chunk := range []*frame.DataFrame{chunks[2], chunks[0], chunks[2]} {
+			assembled, err := a.add(chunk)
+			assert.NoError(t, err)
+			assert.Nil(t, assembled)
+		}
+		assembled, err := a.add(chunks[1])
+		assert.NoError(t, err)
+		assert.Equal(t, df, assembled)
+		assert.Equal(t, 0, a.pending())
+		assert.Zero(t, a.size)
+	})
+
+	t.Run("lost", func(t *testing.T) {
+		now := time.Now()
+		a := newChunkAssembler(time.Minute)
+		a.now = func() time.Time { return now }
+
+		_, err := a.add(chunks[0])
+		assert.NoError(t, err)
+		assert.Equal(t, 1, a.pending())
+
+		// the payload missing chunks is dropped once the timeout elapses.
+		now = now.Add(2 * time.Minute)
+		_, err = a.add(&frame.DataFrame{Tag: 1, Payload: []byte("yomo"), Chunk: frame.Chunk{ID: 8, Total: 2}})
+		assert.True(t, errors.Is(err, ErrChunkLost))
+		assert.Equal(t, 1, a.pending())
+		assert.Equal(t, 4, a.size)
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		a := newChunkAssembler(time.Minute)
+		for id := uint32(0); id < maxChunkPartials; id++ {
+			_, err := a.add(&frame.DataFrame{Tag: 1, Payload: []byte("yomo"), Chunk: frame.Chunk{ID: id, Total: 2}})
+			assert.NoError(t, err)
+		}
+		_, err := a.add(&frame.DataFrame{Tag: 1, Payload: []byte("yomo"), Chunk: frame.Chunk{ID: maxChunkPartials, Total: 2}})
+		assert.True(t, errors.Is(err, ErrChunkOverflow))
+		assert.Equal(t, maxChunkPartials, a.pending())
+	})
+}
+
+func TestMaxFrameSize(t *testing.T) {
+	t.Parallel()
+
+	const chunkAddr = "127.0.0.1:19976"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	server.ConfigRouter(router.Default())
+	go server.ListenAndServe(context.TODO(), chunkAddr)
+	defer server.Close()
+
+	received := make(chan *frame.DataFrame, 2)
+	sfn := NewClient("sfn", chunkAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	sfn.SetObserveDataTags(1)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df })
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	source := NewClient("source", chunkAddr, ClientTypeSource, WithLogger(discardingLogger), WithMaxFrameSize(1024))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	payload := make([]byte, 10*1024+1)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+	// the payload is written in 11 chunks.
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: payload, Channel: 3}))
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("small"), Channel: 3}))
+
+	for _, want := range [][]byte{payload, []byte("small")} {
+		select {
+		case df := <-received:
+			assert.Equal(t, want, df.Payload)
+			assert.False(t, df.IsChunked())
+		case <-time.After(3 * time.Second):
+			t.Fatal("the sfn does not receive the data")
+		}
+	}
+	assert.Equal(t, ChannelStats{WrittenFrames: 12, WrittenBytes: int64(len(payload) + 5)}, source.ChannelStats()[3])
+}
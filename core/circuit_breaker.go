@@ -0,0 +1,138 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+// ErrCircuitOpen is returned by WriteFrame if the circuit breaker is open,
+// the frame is dropped at once rather than queued to be lost in an outage.
+var ErrCircuitOpen = yerr.New(yerr.CodeOverload, "yomo: circuit breaker is open")
+
+// BreakerState is the state of the circuit breaker of writing.
+type BreakerState int32
+
+const (
+	// BreakerClosed lets the frames be written, it is the initial state.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fast-fails the writing until the cooldown elapses.
+	BreakerOpen
+	// BreakerHalfOpen lets the frames be written after the cooldown, the breaker is closed by
+	// the next successful write and opened again by the next failure.
+	BreakerHalfOpen
+)
+
+var breakerStateStrings = map[BreakerState]string{
+	BreakerClosed:   "Closed",
+	BreakerOpen:     "Open",
+	BreakerHalfOpen: "HalfOpen",
+}
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	if str, ok := breakerStateStrings[s]; ok {
+		return str
+	}
+	return "Unknown"
+}
+
+// BreakerHandler is called when the state of the circuit breaker changes, it should not block.
+type BreakerHandler func(from, to BreakerState)
+
+// circuitBreaker opens after the threshold of consecutive write failures, and stays open for the cooldown.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	handler   BreakerHandler
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, handler BreakerHandler) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		handler:   handler,
+		now:       time.Now,
+	}
+}
+
+// allow reports whether a frame can be written, the open breaker turns half-open once the cooldown elapses.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	if b.state != BreakerOpen {
+		b.mu.Unlock()
+		return true
+	}
+	if b.now().Sub(b.openedAt) < b.cooldown {
+		b.mu.Unlock()
+		return false
+	}
+	b.transit(BreakerHalfOpen)
+	return true
+}
+
+// success records a successful write, it closes the breaker.
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	b.failures = 0
+	if b.state == BreakerClosed {
+		b.mu.Unlock()
+		return
+	}
+	b.transit(BreakerClosed)
+}
+
+// failure records a failed write, it opens the breaker if the failures reach the threshold,
+// or if the breaker is half-open.
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	b.failures++
+	if b.state == BreakerOpen || (b.state == BreakerClosed && b.failures < b.threshold) {
+		b.mu.Unlock()
+		return
+	}
+	b.openedAt = b.now()
+	b.transit(BreakerOpen)
+}
+
+// transit changes the state and unlocks b.mu, the handler is called without the lock.
+func (b *circuitBreaker) transit(to BreakerState) {
+	from := b.state
+	b.state = to
+	b.mu.Unlock()
+
+	if b.handler != nil {
+		b.handler(from, to)
+	}
+}
+
+// BreakerState returns the state of the circuit breaker, it is BreakerClosed if WithCircuitBreaker is not set.
+func (c *Client) BreakerState() BreakerState {
+	if c.breaker == nil {
+		return BreakerClosed
+	}
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+
+	return c.breaker.state
+}
+
+// recordWrite records the result of writing frames to the connection in the circuit breaker.
+func (c *Client) recordWrite(err error) error {
+	if c.breaker == nil {
+		return err
+	}
+	if err != nil {
+		c.breaker.failure()
+	} else {
+		c.breaker.success()
+	}
+	return err
+}
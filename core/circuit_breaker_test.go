@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var transitions []string
+	b := newCircuitBreaker(3, time.Second, func(from, to BreakerState) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	// the success resets the consecutive failures.
+	b.failure()
+	b.failure()
+	b.success()
+	b.failure()
+	b.failure()
+	assert.True(t, b.allow())
+
+	b.failure()
+	assert.False(t, b.allow())
+
+	now = now.Add(time.Second)
+	assert.True(t, b.allow())
+	b.failure()
+	assert.False(t, b.allow())
+
+	now = now.Add(time.Second)
+	assert.True(t, b.allow())
+	b.success()
+	assert.True(t, b.allow())
+
+	assert.Equal(t, []string{
+		"Closed->Open", "Open->HalfOpen", "HalfOpen->Open", "Open->HalfOpen", "HalfOpen->Closed",
+	}, transitions)
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	states := make(chan BreakerState, 10)
+	client := NewClient("source", testaddr, ClientTypeSource,
+		WithLogger(discardingLogger),
+		WithWriteBufferSize(1),
+		WithWriteOverflowPolicy(WriteOverflowError),
+		WithCircuitBreaker(2, time.Hour, func(_, to BreakerState) { states <- to }),
+	)
+	assert.Equal(t, BreakerClosed, client.BreakerState())
+
+	// the client is not connected, the buffer is full after the first frame.
+	ctx := context.TODO()
+	assert.NoError(t, client.WriteFrameContext(ctx, &frame.DataFrame{Tag: 1}))
+	assert.ErrorIs(t, client.WriteFrameContext(ctx, &frame.DataFrame{Tag: 1}), ErrWriteBufferFull)
+	assert.ErrorIs(t, client.WriteFrameContext(ctx, &frame.DataFrame{Tag: 1}), ErrWriteBufferFull)
+
+	assert.Equal(t, BreakerOpen, <-states)
+	assert.Equal(t, BreakerOpen, client.BreakerState())
+	assert.ErrorIs(t, client.WriteFrameContext(ctx, &frame.DataFrame{Tag: 1}), ErrCircuitOpen)
+	assert.Equal(t, map[frame.Tag]int64{1: 3}, client.DroppedFrames())
+
+	assert.Equal(t, "HalfOpen", BreakerHalfOpen.String())
+	assert.Equal(t, "Unknown", BreakerState(9).String())
+}
@@ -3,16 +3,18 @@ package core
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
-	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/yomorun/yomo/core/frame"
-	"github.com/yomorun/yomo/pkg/frame-codec/y3codec"
+	"github.com/yomorun/yomo/core/yerr"
 	"github.com/yomorun/yomo/pkg/id"
-	yquic "github.com/yomorun/yomo/pkg/listener/quic"
 	oteltrace "go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/slog"
 )
@@ -20,26 +22,68 @@ import (
 // Client is the abstraction of a YoMo-Client. a YoMo-Client can be
 // Source, Upstream Zipper or StreamFunction.
 type Client struct {
-	zipperAddr     string
-	name           string                 // name of the client
-	clientID       string                 // id of the client
-	reconnCounter  uint                   // counter for reconnection
-	clientType     ClientType             // type of the client
-	processor      func(*frame.DataFrame) // function to invoke when data arrived
-	errorfn        func(error)            // function to invoke when error occured
-	opts           *clientOptions
-	Logger         *slog.Logger
-	tracerProvider oteltrace.TracerProvider
+	zipperAddr         string
+	zipperAddrs        []string                  // the zipper address and the fallback zipper addresses
+	zipperAddrIdx      int                       // index of the zipper address in zipperAddrs
+	name               string                    // name of the client
+	clientID           string                    // id of the client
+	reconnCounter      uint                      // counter for reconnection
+	compression        string                    // payload compression confirmed by zipper in handshake
+	clientType         ClientType                // type of the client
+	processor          func(*frame.DataFrame)    // function to invoke when data arrived
+	errorfn            func(error)               // function to invoke when error occured
+	errorFrameObserver func(*frame.ErrorFrame)   // function to invoke when an ErrorFrame arrived
+	requestObserver    func(*frame.RequestFrame) // function to invoke when a RequestFrame arrived
+	requests           sync.Map                  // the Request calls waiting for the responses, request id -> chan *frame.ResponseFrame
+	errCounts          sync.Map                  // the number of the reported errors, yerr.Code -> *atomic.Int64
+	wrInterceptors     []WriteInterceptor        // functions to invoke before writing data frames
+	rdInterceptors     []ReadInterceptor         // functions to invoke before processing data frames
+	rateLimiter        *rateLimiter              // limits the rate of writing data frames
+	breaker            *circuitBreaker           // fast-fails the writing after consecutive failures
+	chunks             *chunkAssembler           // reassembles the chunks of the oversized payloads
+	chunkID            atomic.Uint32             // the ID of the last chunked payload
+	acks               *ackTracker               // tracks the DataFrames waiting for the ack, it is nil if the ack is disabled
+	channels           sync.Map                  // the flow accounting of channels, frame.Channel -> *channelCounter
+	opts               *clientOptions
+	Logger             *slog.Logger
+	tracerProvider     oteltrace.TracerProvider
+	pings              pinger                         // measures the round-trip time by heartbeat and Ping
+	protocolVersion    atomic.Uint32                  // the protocol version negotiated with zipper
+	state              atomic.Int32                   // the ConnState of the client
+	stateCounts        [StateProbing + 1]atomic.Int64 // the times of entering each ConnState
+	observeMu          sync.Mutex                     // protects opts.observeDataTags
+	drops              *dropCounter                   // the DataFrames dropped by the writing or the read queue
+	transportIdx       atomic.Int32                   // the index of the transport that succeeded last time
+	ackExts            atomic.Value                   // the extensions answered by zipper in the last handshake, map[string][]byte
+	walOffset          atomic.Uint64                  // the offset of the last journaled DataFrame handled, see WithReplayFrom
+	resources          resourceTracker                // the goroutines and the read queue owned by the client
 
 	// ctx and ctxCancel manage the lifecycle of client.
 	ctx       context.Context
 	ctxCancel context.CancelCauseFunc
 
-	done chan struct{}
-	wrCh chan frame.Frame
-	rdCh chan readOut
+	done      chan struct{}
+	wrCh      chan frame.Frame // the frames with normal priority, every priority has a buffer of wrBufferSize
+	wrHighCh  chan frame.Frame // the frames with high priority
+	wrLowCh   chan frame.Frame // the frames with low priority
+	rdCh      chan readOut
+	rdQueue   chan *frame.DataFrame // the DataFrames waiting for the processor, it is nil if the read queue is disabled
+	drainCh   chan drainRequest     // requests to say goodbye and flush the queued frames
+	conn      atomic.Value          // the current connection, it is a connHolder
+	closeOnce sync.Once
 }
 
+type connHolder struct{ frame.Conn }
+
+// WriteInterceptor intercepts every outgoing DataFrame before it is written,
+// it can mutate the frame, or abort the writing by returning an error.
+type WriteInterceptor func(*frame.DataFrame) error
+
+// ReadInterceptor intercepts every incoming DataFrame before it is processed,
+// it can mutate the frame (eg. decrypt, decompress), or veto it by returning an error,
+// the vetoed frame will be dropped and the error will be passed to the error handler.
+type ReadInterceptor func(*frame.DataFrame) error
+
 type readOut struct {
 	err   error
 	frame frame.Frame
@@ -63,8 +107,29 @@ func NewClient(appName, zipperAddr string, clientType ClientType, opts ...Client
 
 	ctx, ctxCancel := context.WithCancelCause(context.Background())
 
+	var limiter *rateLimiter
+	if option.writeRPS > 0 {
+		limiter = newRateLimiter(option.writeRPS, option.writeBurst)
+	}
+
+	var breaker *circuitBreaker
+	if option.breakerThreshold > 0 {
+		breaker = newCircuitBreaker(option.breakerThreshold, option.breakerCooldown, option.breakerHandler)
+	}
+
+	var acks *ackTracker
+	if option.ackTimeout > 0 {
+		acks = newAckTracker(option.ackTimeout, option.ackRetries, DefaultAckMaxPending, ctx.Done())
+	}
+
+	var rdQueue chan *frame.DataFrame
+	if option.rdQueueSize > 0 {
+		rdQueue = make(chan *frame.DataFrame, option.rdQueueSize)
+	}
+
 	return &Client{
 		zipperAddr:     zipperAddr,
+		zipperAddrs:    append([]string{zipperAddr}, option.fallbackZippers...),
 		name:           appName,
 		clientID:       clientID,
 		processor:      func(df *frame.DataFrame) { logger.Warn("the processor has not been set") },
@@ -72,38 +137,58 @@ func NewClient(appName, zipperAddr string, clientType ClientType, opts ...Client
 		opts:           option,
 		Logger:         logger,
 		tracerProvider: option.tracerProvider,
+		rateLimiter:    limiter,
+		breaker:        breaker,
+		drops:          newDropCounter(),
+		chunks:         newChunkAssembler(DefaultChunkTimeout),
+		acks:           acks,
 		ctx:            ctx,
 		ctxCancel:      ctxCancel,
 
-		done: make(chan struct{}),
-		wrCh: make(chan frame.Frame),
-		rdCh: make(chan readOut),
+		done:     make(chan struct{}),
+		wrCh:     make(chan frame.Frame, option.wrBufferSize),
+		wrHighCh: make(chan frame.Frame, option.wrBufferSize),
+		wrLowCh:  make(chan frame.Frame, option.wrBufferSize),
+		rdCh:     make(chan readOut),
+		drainCh:  make(chan drainRequest),
+		rdQueue:  rdQueue,
 	}
 }
 
 // Connect connect client to server.
 func (c *Client) Connect(ctx context.Context) error {
-CONNECT:
-	fconn, err := c.connect(ctx, c.zipperAddr)
-	reconnect, err := c.handleConnectResult(err, c.opts.reconnect)
-	if err != nil {
-		return err
-	}
-	if reconnect {
-		goto CONNECT
-	}
-	go c.runBackground(fconn)
+	c.setState(StateConnecting)
 
-	return nil
+	for attempt := 0; ; attempt++ {
+		fconn, err := c.connect(ctx, c.zipperAddr)
+		reconnect, err := c.handleConnectResult(ctx, err, c.opts.reconnect, attempt)
+		if err != nil {
+			c.setState(StateIdle)
+			return err
+		}
+		if reconnect {
+			c.retryState(StateConnecting)
+			continue
+		}
+		c.resources.Go(func() { c.runBackground(fconn) })
+		if c.acks != nil {
+			c.resources.Go(c.retransmit)
+		}
+
+		return nil
+	}
 }
 
-func (c *Client) handleConnectResult(err error, alwaysReconnect bool) (reconnect bool, se error) {
+// handleConnectResult handles the result of connecting, if reconnection is needed,
+// it waits for the backoff duration of the attempt.
+func (c *Client) handleConnectResult(ctx context.Context, err error, alwaysReconnect bool, attempt int) (reconnect bool, se error) {
 	if err == nil {
 		c.Logger.Info("connected to zipper")
 		return false, nil
 	}
-	if e := new(ErrRejected); errors.As(err, &e) {
-		c.Logger.Info("handshake be rejected", "err", e.Message)
+	// the draining zipper is retried as the other connecting errors.
+	if e := new(ErrRejected); errors.As(err, &e) && e.Reason != ReasonServerDraining {
+		c.Logger.Info("handshake be rejected", "err", e.Message, "reason", e.Reason)
 		return false, err
 	}
 	if e := new(ErrConnectTo); errors.As(err, &e) {
@@ -111,45 +196,101 @@ func (c *Client) handleConnectResult(err error, alwaysReconnect bool) (reconnect
 		c.Logger.Info("connect to new endpoint", "endpoint", e.Endpoint)
 		return true, nil
 	}
+	if c.failover() {
+		c.Logger.Info("failed to connect to zipper, failover to the next zipper", "err", err, "next_zipper_addr", c.zipperAddr)
+		return true, nil
+	}
 	if alwaysReconnect {
-		c.Logger.Error("failed to connect to zipper, trying to reconnect", "err", err)
-		time.Sleep(time.Second)
+		if c.opts.backoff.Exhausted(attempt) {
+			c.Logger.Error("failed to connect to zipper, reconnect attempts exhausted", "err", err, "attempts", attempt)
+			return false, err
+		}
+		backoff := c.opts.backoff.Duration(attempt)
+		if c.opts.probeThreshold > 0 && attempt+1 >= c.opts.probeThreshold {
+			// the connect breaker is open, probe zipper at most once per probe interval.
+			if backoff < c.opts.probeInterval {
+				backoff = c.opts.probeInterval
+			}
+			c.setState(StateProbing)
+		}
+		c.Logger.Error("failed to connect to zipper, trying to reconnect", "err", err, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(backoff):
+		}
 		return true, nil
 	}
 	c.Logger.Error("cannot connect to zipper", "err", err)
 	return false, err
 }
 
+// failover switches the zipper address to the next one, it returns false
+// if all the zipper addresses have been tried in this round.
+func (c *Client) failover() bool {
+	if len(c.zipperAddrs) <= 1 {
+		return false
+	}
+	c.zipperAddrIdx = (c.zipperAddrIdx + 1) % len(c.zipperAddrs)
+	c.zipperAddr = c.zipperAddrs[c.zipperAddrIdx]
+
+	return c.zipperAddrIdx != 0
+}
+
+// runBackground serves the conn, and reconnects to zipper once the conn is lost,
+// until the client is closed or the reconnect attempts are exhausted.
 func (c *Client) runBackground(conn frame.Conn) {
-	if closed := c.handleConn(conn); closed {
-		return
+	defer close(c.done)
+	defer c.setState(StateClosed)
+
+	if c.opts.dropReportInterval > 0 {
+		c.resources.Go(func() { c.reportDrops(c.opts.dropReportInterval) })
+	}
+	if c.rdQueue != nil {
+		c.resources.Go(c.processReadQueue)
 	}
 
-	// try reconnect to zipper.
-	var err error
-	for {
+	for attempt := 0; ; {
+		if conn != nil {
+			if closed := c.handleConn(conn); closed {
+				return
+			}
+			attempt = 0
+		}
+		c.retryState(StateReconnecting)
+
+		var err error
 		conn, err = c.connect(c.ctx, c.zipperAddr)
-		reconnect, err := c.handleConnectResult(err, true)
+		reconnect, err := c.handleConnectResult(c.ctx, err, true, attempt)
 		if err != nil {
 			return
 		}
 		if reconnect {
-			time.Sleep(time.Second)
-			continue
-		}
-		if closed := c.handleConn(conn); closed {
-			return
+			conn = nil
+			attempt++
 		}
 	}
 }
 
 func (c *Client) handleConn(conn frame.Conn) (closed bool) {
-	if err := c.serveConn(conn); err != nil {
-		if c.errorfn != nil {
-			c.errorfn(err)
-		} else {
+	c.conn.Store(connHolder{conn})
+	defer c.conn.Store(connHolder{})
+
+	var ctrl frame.ControlConn
+	if c.opts.controlStream {
+		ctrl, _ = conn.(frame.ControlConn)
+	}
+
+	err := c.serveConn(newCompressConn(newDeadlineConn(conn, c.opts.streamWrTimeout), c.compression, c.opts.compressMinSize, c.protocolVersion.Load()), ctrl)
+	// the client is closed by calling Close.
+	if c.ctx.Err() != nil {
+		return true
+	}
+	if err != nil {
+		if c.errorfn == nil {
 			c.Logger.Error("handle frame failed", "err", err)
 		}
+		c.reportError(err)
 		// Exit client program if the connection has be closed.
 		if se := new(frame.ErrConnClosed); errors.As(err, &se) {
 			if se.Remote {
@@ -161,10 +302,65 @@ func (c *Client) handleConn(conn frame.Conn) (closed bool) {
 	return false
 }
 
+// connect connects to zipper in the phases: resolve, dial and handshake, every phase has its own timeout.
 func (c *Client) connect(ctx context.Context, addr string) (frame.Conn, error) {
-	conn, err := yquic.DialAddr(ctx, addr, y3codec.Codec(), y3codec.PacketReadWriter(), c.opts.tlsConfig, c.opts.quicConfig)
-	if err != nil {
-		return conn, err
+	// the custom dialer and transports resolve the address by themselves, unless a resolver is set.
+	dialAddrs := withServerNames([]string{addr}, serverNameOf(addr))
+	if c.opts.customResolver || (!c.opts.customDialer && len(c.opts.transports) == 0) {
+		resolved, err := c.resolve(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		dialAddrs = resolved
+	}
+
+	tlsConfig := c.opts.tlsConfig
+	if c.opts.tlsLoader != nil {
+		tc, err := c.opts.tlsLoader()
+		if err != nil {
+			return nil, &ConnectError{Phase: PhaseDial, Addr: addr, Err: fmt.Errorf("load tls config: %w", err)}
+		}
+		if tc != nil {
+			tlsConfig = tc
+		}
+	}
+
+	// the resolved addresses are dialed in order until one succeeds.
+	var (
+		conn frame.Conn
+		errs []error
+	)
+	for _, dialAddr := range dialAddrs {
+		dialed, err := c.dial(ctx, dialAddr.addr, withServerName(tlsConfig, dialAddr.serverName))
+		if err == nil {
+			conn = dialed
+			break
+		}
+		errs = append(errs, err)
+	}
+	if conn == nil {
+		err := errs[0]
+		if len(errs) > 1 {
+			err = errors.Join(errs...)
+		}
+		return nil, &ConnectError{Phase: PhaseDial, Addr: addr, Err: err}
+	}
+	c.setState(StateAuthenticating)
+
+	// the handshake is aborted by closing the connection once the timeout elapses.
+	var expired atomic.Bool
+	if c.opts.handshakeTimeout > 0 {
+		timer := time.AfterFunc(c.opts.handshakeTimeout, func() {
+			expired.Store(true)
+			_ = conn.CloseWithError("yomo: handshake timeout")
+		})
+		defer timer.Stop()
+	}
+	handshakeErr := func(err error) error {
+		if expired.Load() {
+			err = context.DeadlineExceeded
+		}
+		return &ConnectError{Phase: PhaseHandshake, Addr: addr, Err: err}
 	}
 
 	// refresh client id in order to avoid id conflicts on the server-side
@@ -175,26 +371,55 @@ func (c *Client) connect(ctx context.Context, addr string) (frame.Conn, error) {
 		Name:            c.name,
 		ID:              clientID,
 		ClientType:      byte(c.clientType),
-		ObserveDataTags: c.opts.observeDataTags,
+		ObserveDataTags: c.ObserveDataTags(),
 		AuthName:        c.opts.credential.Name(),
 		AuthPayload:     c.opts.credential.Payload(),
 		Version:         Version,
+		ProtocolVersion: ProtocolVersion,
+		SchemaVersions:  c.opts.schemaVersions,
+		Extensions:      c.handshakeExtensions(),
+	}
+	if c.opts.compressMinSize > 0 {
+		hf.Compressions = supportedCompressions
 	}
 
 	if err := conn.WriteFrame(hf); err != nil {
-		return conn, err
+		return nil, handshakeErr(err)
 	}
 
 	received, err := conn.ReadFrame()
 	if err != nil {
-		return nil, err
+		return nil, handshakeErr(err)
 	}
 
 	switch received.Type() {
 	case frame.TypeHandshakeAckFrame:
+		ack := received.(*frame.HandshakeAckFrame)
+		c.compression = ack.Compression
+		c.protocolVersion.Store(ack.ProtocolVersion)
+		if c.acks != nil && !c.ackNegotiated() {
+			if n := c.acks.reset(); n > 0 {
+				c.Logger.Warn("zipper does not acknowledge frames, the frames waiting for the ack are given up", "count", n)
+			}
+		}
+		c.ackExts.Store(ack.Extensions)
+		if opener, ok := conn.(controlStreamOpener); ok && c.opts.controlStream {
+			if err := opener.OpenControlStream(); err != nil {
+				_ = conn.CloseWithError(err.Error())
+				return nil, handshakeErr(err)
+			}
+		}
+		if opener, ok := conn.(dataStreamsOpener); ok && c.opts.streamCount > 1 {
+			if err := opener.OpenDataStreams(c.opts.streamCount, c.opts.streamSelector); err != nil {
+				_ = conn.CloseWithError(err.Error())
+				return nil, handshakeErr(err)
+			}
+		}
+		c.setState(StateConnected)
 		return conn, nil
 	case frame.TypeRejectedFrame:
-		err := &ErrRejected{Message: received.(*frame.RejectedFrame).Message}
+		rf := received.(*frame.RejectedFrame)
+		err := rejectedError(rf.Message, rf.Reason)
 		_ = conn.CloseWithError(err.Error())
 		return nil, err
 	case frame.TypeConnectToFrame:
@@ -211,129 +436,872 @@ func (c *Client) connect(ctx context.Context, addr string) (frame.Conn, error) {
 	}
 }
 
+// ErrWriteBufferFull is returned by WriteFrame if the write buffer is full and
+// the overflow policy is WriteOverflowError.
+var ErrWriteBufferFull = yerr.New(yerr.CodeOverload, "yomo: write buffer full")
+
+// WriteOverflowPolicy decides what WriteFrame does when the write buffer is full.
+type WriteOverflowPolicy int
+
+const (
+	// WriteOverflowBlock blocks WriteFrame until the buffer has space, this is the default policy.
+	WriteOverflowBlock WriteOverflowPolicy = iota
+	// WriteOverflowDropOldest drops the oldest frame in the buffer to make room for the new one.
+	WriteOverflowDropOldest
+	// WriteOverflowError makes WriteFrame return ErrWriteBufferFull immediately.
+	WriteOverflowError
+)
+
+// ErrWriteTimeout is returned if the frame is not written before the deadline,
+// the frame is not sent to zipper, so the caller can retry writing it.
+type ErrWriteTimeout struct {
+	// Cause is the reason why the writing is timeout, it is usually the context error.
+	Cause error
+}
+
+// Error implements the error interface.
+func (e *ErrWriteTimeout) Error() string {
+	return fmt.Sprintf("yomo: write frame timeout: %v", e.Cause)
+}
+
+// Unwrap returns the cause of the timeout.
+func (e *ErrWriteTimeout) Unwrap() error { return e.Cause }
+
+// Timeout reports the error is a timeout, it makes ErrWriteTimeout compatible with net.Error.
+func (e *ErrWriteTimeout) Timeout() bool { return true }
+
+// ErrorCode implements the yerr.Coder interface.
+func (e *ErrWriteTimeout) ErrorCode() yerr.Code { return yerr.CodeOverload }
+
 // WriteFrame write frame to client.
 func (c *Client) WriteFrame(f frame.Frame) error {
-	if c.opts.nonBlockWrite {
-		return c.nonBlockWriteFrame(f)
+	return c.WriteFrameContext(context.Background(), f)
+}
+
+// ErrNoWriterTag is returned by Client.Write if the tag is not set by `WithWriterTag`.
+var ErrNoWriterTag = yerr.New(yerr.CodeInvalid, "yomo: the tag of Client.Write is not set, use WithWriterTag to set it")
+
+var _ io.Writer = &Client{}
+
+// Write implements io.Writer, it writes p as the payload of a DataFrame with the tag set by `WithWriterTag`,
+// so the client can be used where an io.Writer is expected.
+func (c *Client) Write(p []byte) (int, error) {
+	if !c.opts.hasWriterTag {
+		return 0, ErrNoWriterTag
+	}
+
+	md, endFn := SourceMetadata(
+		c.clientID, id.New(), c.name, c.tracerProvider, c.Logger,
+		TagTraceAttrs(c.opts.tagNamer, c.opts.writerTag),
+	)
+	defer endFn()
+
+	mdBytes, err := md.Encode()
+	if err != nil {
+		return 0, err
+	}
+
+	// io.Writer must not retain p.
+	payload := make([]byte, len(p))
+	copy(payload, p)
+
+	f := &frame.DataFrame{
+		Tag:      c.opts.writerTag,
+		Metadata: mdBytes,
+		Payload:  payload,
+	}
+	if err := c.WriteFrame(f); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteFrameContext writes frame to client, in block mode, it returns ErrWriteTimeout
+// if the ctx is done before the frame is written.
+func (c *Client) WriteFrameContext(ctx context.Context, f frame.Frame) error {
+	if df, ok := f.(*frame.DataFrame); ok {
+		for _, intercept := range c.wrInterceptors {
+			if err := intercept(df); err != nil {
+				return err
+			}
+		}
+	}
+	f, err := c.interceptFrame(DirectionOutbound, f)
+	if err != nil || f == nil {
+		return err
 	}
-	return c.blockWriteFrame(f)
+	if df, ok := f.(*frame.DataFrame); ok {
+		if err := c.waitRateLimit(ctx); err != nil {
+			if rl := new(ErrRateLimited); errors.As(err, &rl) {
+				c.recordDrop(df)
+			}
+			return err
+		}
+	}
+	if c.breaker == nil {
+		return c.enqueueWrite(ctx, f)
+	}
+	if !c.breaker.allow() {
+		c.recordDrop(f)
+		return ErrCircuitOpen
+	}
+	// the frame failed to be queued is a write failure, the queued one is judged once it is written to zipper.
+	if err := c.enqueueWrite(ctx, f); err != nil {
+		if c.ctx.Err() == nil {
+			c.breaker.failure()
+		}
+		return err
+	}
+	return nil
+}
+
+// enqueueWrite queues the frame to be written to zipper by the policy of writing,
+// the DataFrame waits for the ack of zipper if the ack is enabled and zipper speaks it.
+func (c *Client) enqueueWrite(ctx context.Context, f frame.Frame) error {
+	df, ok := f.(*frame.DataFrame)
+	if !ok || c.acks == nil || !c.ackNegotiated() {
+		return c.enqueueChunks(ctx, f)
+	}
+	// the AckID is set to a copy, the DataFrame of the caller is not changed.
+	tracked := *df
+	df = &tracked
+	if err := c.acks.track(ctx, df); err != nil {
+		if c.ctx.Err() != nil {
+			return c.ctx.Err()
+		}
+		return &ErrWriteTimeout{Cause: err}
+	}
+	if err := c.enqueueChunks(ctx, df); err != nil {
+		c.acks.ack(df.AckID)
+		return err
+	}
+	return nil
+}
+
+// enqueueChunks queues the frame by the policy of writing,
+// the DataFrame whose payload exceeds the max frame size is queued in chunks.
+func (c *Client) enqueueChunks(ctx context.Context, f frame.Frame) error {
+	df, ok := f.(*frame.DataFrame)
+	if !ok || c.opts.maxFrameSize <= 0 || len(df.Payload) <= c.opts.maxFrameSize {
+		return c.enqueueFrame(ctx, f)
+	}
+	for _, chunk := range splitChunks(df, c.chunkID.Add(1), c.opts.maxFrameSize) {
+		if err := c.enqueueFrame(ctx, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueueFrame queues a frame by the policy of writing.
+func (c *Client) enqueueFrame(ctx context.Context, f frame.Frame) error {
+	var err error
+	switch {
+	case c.opts.nonBlockWrite:
+		err = c.nonBlockWriteFrame(f)
+	case c.opts.wrOverflow == WriteOverflowDropOldest:
+		err = c.dropOldestWriteFrame(f)
+	case c.opts.wrOverflow == WriteOverflowError:
+		err = c.tryWriteFrame(f)
+	default:
+		err = c.blockWriteFrame(ctx, f)
+	}
+	if df, ok := f.(*frame.DataFrame); ok && err == nil {
+		c.countChannel(df, true)
+	}
+	return err
 }
 
 // blockWriteFrame writes frames in block mode, guaranteeing that frames are not lost.
-func (c *Client) blockWriteFrame(f frame.Frame) error {
+func (c *Client) blockWriteFrame(ctx context.Context, f frame.Frame) error {
+	if c.opts.wrTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.wrTimeout)
+		defer cancel()
+	}
 	select {
 	case <-c.ctx.Done():
 		return c.ctx.Err()
-	case c.wrCh <- f:
+	case <-ctx.Done():
+		return &ErrWriteTimeout{Cause: ctx.Err()}
+	case c.writeChan(f) <- f:
 	}
 	return nil
 }
 
+// writeChan returns the write channel according to the priority of the frame.
+func (c *Client) writeChan(f frame.Frame) chan frame.Frame {
+	if df, ok := f.(*frame.DataFrame); ok {
+		switch {
+		case df.Priority > frame.PriorityNormal:
+			return c.wrHighCh
+		case df.Priority < frame.PriorityNormal:
+			return c.wrLowCh
+		}
+	}
+	return c.wrCh
+}
+
+// dropOldestWriteFrame writes frames without blocking, it drops the oldest frame if the buffer is full.
+func (c *Client) dropOldestWriteFrame(f frame.Frame) error {
+	wrCh := c.writeChan(f)
+	for {
+		select {
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		case wrCh <- f:
+			return nil
+		default:
+		}
+		select {
+		case dropped := <-wrCh:
+			c.Logger.Debug("write buffer full, drop the oldest frame", "frame_type", dropped.Type().String())
+			c.recordDrop(dropped)
+		default:
+			// the unbuffered channel has no oldest frame, so the frame itself is dropped.
+			if cap(wrCh) == 0 {
+				c.Logger.Debug("write buffer full, drop the frame", "frame_type", f.Type().String())
+				c.recordDrop(f)
+				return nil
+			}
+		}
+	}
+}
+
+// waitRateLimit waits until the frame can be written under the write rate limit,
+// it returns ErrRateLimited instead of waiting if the client is not in the blocking mode.
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	if c.rateLimiter == nil {
+		return nil
+	}
+	blocking := !c.opts.nonBlockWrite && c.opts.wrOverflow == WriteOverflowBlock
+
+	for {
+		delay, ok := c.rateLimiter.take(time.Now())
+		if ok {
+			return nil
+		}
+		if !blocking {
+			return &ErrRateLimited{RetryAfter: delay}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &ErrWriteTimeout{Cause: context.Cause(ctx)}
+		case <-c.ctx.Done():
+			timer.Stop()
+			return context.Cause(c.ctx)
+		case <-timer.C:
+		}
+	}
+}
+
+// tryWriteFrame writes frames without blocking, it returns ErrWriteBufferFull if the buffer is full.
+func (c *Client) tryWriteFrame(f frame.Frame) error {
+	select {
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	case c.writeChan(f) <- f:
+		return nil
+	default:
+		c.recordDrop(f)
+		return ErrWriteBufferFull
+	}
+}
+
 // nonBlockWriteFrame writes frames in non-blocking mode, without guaranteeing that frames will not be lost.
 func (c *Client) nonBlockWriteFrame(f frame.Frame) error {
 	select {
 	case <-c.ctx.Done():
 		return c.ctx.Err()
-	case c.wrCh <- f:
+	case c.writeChan(f) <- f:
 		return nil
 	case <-time.After(time.Second):
-		return errors.New("yomo: non-block write frame timeout")
+		c.recordDrop(f)
+		return &ErrWriteTimeout{Cause: context.DeadlineExceeded}
 	}
 }
 
 // Close close the client.
 func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() { err = c.close() })
+	return err
+}
+
+// ErrDrainTimeout is returned by Close if the queued frames are not flushed within the drain timeout.
+var ErrDrainTimeout = yerr.New(yerr.CodeNetwork, "yomo: drain timeout")
+
+// ErrFramesAbandoned is returned by Close if there are queued frames that are not written to zipper.
+type ErrFramesAbandoned struct {
+	// Count is the number of the abandoned frames.
+	Count int
+}
+
+// Error implements the error interface.
+func (e *ErrFramesAbandoned) Error() string {
+	return fmt.Sprintf("yomo: %d queued frames abandoned", e.Count)
+}
+
+// ErrorCode implements the yerr.Coder interface.
+func (e *ErrFramesAbandoned) ErrorCode() yerr.Code { return yerr.CodeNetwork }
+
+func (c *Client) close() error {
+	var errs []error
+
+	cause := fmt.Errorf("%s: shutdown", c.clientType.String())
+
+	// say goodbye to zipper before draining, so zipper stops routing to the departing client.
+	if c.opts.drainTimeout > 0 {
+		if err := c.drain(c.opts.drainTimeout, cause.Error(), true); err != nil {
+			errs = append(errs, err)
+		}
+	} else {
+		// the goodbye is best effort, zipper cleans the client up after the connection is closed anyway.
+		_ = c.drain(goodbyeTimeout, cause.Error(), false)
+	}
+
 	// break runBackgroud() for-loop.
-	c.ctxCancel(fmt.Errorf("%s: shutdown", c.clientType.String()))
+	c.ctxCancel(cause)
+	c.setState(StateClosed)
+
+	// close the connection directly, so the writing to a stalled peer is unblocked.
+	if h, ok := c.conn.Load().(connHolder); ok && h.Conn != nil {
+		_ = h.CloseWithError(cause.Error())
+	}
+
+	if n := c.abandonFrames(); n > 0 {
+		errs = append(errs, &ErrFramesAbandoned{Count: n})
+	}
+
+	return errors.Join(errs...)
+}
+
+// goodbyeTimeout is the time to wait for the GoodbyeFrame to be written if the drain is disabled.
+const goodbyeTimeout = 100 * time.Millisecond
+
+// drainRequest requests the writing goroutine to write a GoodbyeFrame with the message,
+// then flush the queued frames if flush is set.
+type drainRequest struct {
+	message string
+	flush   bool
+	resp    chan error
+}
+
+// drain says goodbye to zipper and flushes the queued frames to the current connection within the timeout.
+func (c *Client) drain(timeout time.Duration, message string, flush bool) error {
+	if h, ok := c.conn.Load().(connHolder); !ok || h.Conn == nil {
+		return nil
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	resp := make(chan error, 1)
+	select {
+	case c.drainCh <- drainRequest{message: message, flush: flush, resp: resp}:
+	case <-timer.C:
+		return ErrDrainTimeout
+	}
+	select {
+	case err := <-resp:
+		return err
+	case <-timer.C:
+		return ErrDrainTimeout
+	}
+}
 
+// goodbye writes a GoodbyeFrame to the connection, then flushes the queued frames if requested.
+func (c *Client) goodbye(conn frame.Conn, req drainRequest) error {
+	if err := conn.WriteFrame(&frame.GoodbyeFrame{Message: req.message}); err != nil {
+		return err
+	}
+	if !req.flush {
+		return nil
+	}
+	return c.flush(conn)
+}
+
+// flush writes the queued frames to the connection, the frames with higher priority are written first.
+func (c *Client) flush(conn frame.Conn) error {
+	for _, wrCh := range []chan frame.Frame{c.wrHighCh, c.wrCh, c.wrLowCh} {
+	FLUSH:
+		for {
+			select {
+			case f := <-wrCh:
+				if err := conn.WriteFrame(f); err != nil {
+					return err
+				}
+			default:
+				break FLUSH
+			}
+		}
+	}
 	return nil
 }
 
+// writePending writes the frames queued in the channels at the moment, it makes the frames with
+// higher priority be written before the frame that has been dequeued.
+func (c *Client) writePending(conn frame.Conn, chs ...chan frame.Frame) error {
+	for _, wrCh := range chs {
+		for n := len(wrCh); n > 0; n-- {
+			select {
+			case f := <-wrCh:
+				if err := conn.WriteFrame(f); err != nil {
+					return err
+				}
+			default:
+			}
+		}
+	}
+	return nil
+}
+
+// abandonFrames discards the queued frames and returns the number of them.
+func (c *Client) abandonFrames() int {
+	n := 0
+	for _, wrCh := range []chan frame.Frame{c.wrHighCh, c.wrCh, c.wrLowCh} {
+	ABANDON:
+		for {
+			select {
+			case <-wrCh:
+				n++
+			default:
+				break ABANDON
+			}
+		}
+	}
+	return n
+}
+
+// Resources returns the goroutines and the read queue owned by the client,
+// both of them return to zero after the client is closed.
+func (c *Client) Resources() ResourceUsage {
+	return c.resources.usage()
+}
+
 // Wait waits client returning.
 func (c *Client) Wait() {
 	<-c.done
 }
 
-func (c *Client) serveConn(conn frame.Conn) error {
-	go func() {
+// serveConn serves the conn, the heartbeat is served on the ctrl if it is not nil.
+func (c *Client) serveConn(conn frame.Conn, ctrl frame.ControlConn) error {
+	c.resources.Go(func() {
+		// the serve loop stops reading once the client is closed.
+		send := func(out readOut) bool {
+			select {
+			case c.rdCh <- out:
+				return true
+			case <-c.ctx.Done():
+				return false
+			}
+		}
 		for {
 			f, err := conn.ReadFrame()
 			if err != nil {
-				c.rdCh <- readOut{err: err}
+				// the connection keeps working if a data stream is canceled or a frame is corrupted or malformed.
+				if send(readOut{err: err}) && (isStreamCanceled(err) || isCorrupted(err) || isMalformed(err)) {
+					continue
+				}
+				return
+			}
+			if df, ok := f.(*frame.DataFrame); ok && c.rdQueue != nil {
+				c.enqueueRead(df)
+				// the serve loop is notified of the reading for the idle detection only.
+				if c.opts.idleTimeout <= 0 {
+					continue
+				}
+				f = nil
+			}
+			if !send(readOut{frame: f}) {
 				return
 			}
-			c.rdCh <- readOut{frame: f}
 		}
-	}()
+	})
+
+	var heartbeat <-chan time.Time
+	if c.opts.heartbeat > 0 {
+		if ctrl != nil {
+			done := make(chan struct{})
+			defer close(done)
+			c.resources.Go(func() { c.serveControl(ctrl, done) })
+		} else {
+			ticker := time.NewTicker(c.opts.heartbeat)
+			defer ticker.Stop()
+			heartbeat = ticker.C
+		}
+	}
+
+	// the idle timer probes the connection with a PingFrame if no frame is read in the idle timeout,
+	// and the connection is dropped if still no frame is read in another idle timeout.
+	var (
+		idle      <-chan time.Time
+		idleTimer *time.Timer
+		probing   bool
+	)
+	if c.opts.idleTimeout > 0 {
+		idleTimer = time.NewTimer(c.opts.idleTimeout)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
 
 	for {
 		select {
 		case <-c.ctx.Done():
 			conn.CloseWithError(context.Cause(c.ctx).Error())
-			c.done <- struct{}{}
+			return nil
+		case <-idle:
+			if probing {
+				c.Logger.Warn("no frame is read in the idle timeout, drop the connection", "idle_timeout", c.opts.idleTimeout.String())
+				conn.CloseWithError(ErrIdleTimeout.Error())
+				c.discardRead()
+				return ErrIdleTimeout
+			}
+			probing = true
+			if err := conn.WriteFrame(newPingFrame(time.Now())); err != nil {
+				return err
+			}
+			idleTimer.Reset(c.opts.idleTimeout)
+		case req := <-c.drainCh:
+			req.resp <- c.goodbye(conn, req)
+		case now := <-heartbeat:
+			if err := conn.WriteFrame(newPingFrame(now)); err != nil {
+				return err
+			}
+		case f := <-c.wrHighCh:
+			if err := c.connErr(c.recordWrite(c.writeFrames(conn, f, heartbeat))); err != nil {
+				return err
+			}
 		case f := <-c.wrCh:
-			if err := conn.WriteFrame(f); err != nil {
+			if err := c.connErr(c.recordWrite(c.writePending(conn, c.wrHighCh))); err != nil {
 				return err
 			}
-		case out := <-c.rdCh:
-			if err := out.err; err != nil {
+			if err := c.connErr(c.recordWrite(c.writeFrames(conn, f, heartbeat))); err != nil {
 				return err
 			}
-			func() {
-				defer func() {
-					if e := recover(); e != nil {
-						const size = 64 << 10
-						buf := make([]byte, size)
-						buf = buf[:runtime.Stack(buf, false)]
+		case f := <-c.wrLowCh:
+			if err := c.connErr(c.recordWrite(c.writePending(conn, c.wrHighCh, c.wrCh))); err != nil {
+				return err
+			}
+			if err := c.connErr(c.recordWrite(c.writeFrames(conn, f, heartbeat))); err != nil {
+				return err
+			}
+		case out := <-c.rdCh:
+			if out.err != nil {
+				if err := c.connErr(out.err); err != nil {
+					return err
+				}
+				break
+			}
+			if idleTimer != nil {
+				probing = false
+				resetTimer(idleTimer, c.opts.idleTimeout)
+			}
+			// the draining zipper evicts the connection, reconnect rather than close the client.
+			if gf, ok := out.frame.(*frame.GoawayFrame); ok && RejectReason(gf.Reason) == ReasonServerDraining {
+				c.Logger.Info("zipper is draining, reconnect", "err", gf.Message)
+				conn.CloseWithError(gf.Message)
+				c.discardRead()
+				return goawayError(gf)
+			}
+			// zipper measures the liveness of the client, the PongFrame is written in the serving loop.
+			if pf, ok := out.frame.(*frame.PingFrame); ok {
+				if err := conn.WriteFrame(&frame.PongFrame{Payload: pf.Payload}); err != nil {
+					return err
+				}
+				break
+			}
+			// the frame is nil if it is put into the read queue.
+			if out.frame != nil {
+				c.handleFrameSafely(out.frame)
+			}
+		}
+	}
+}
+
+// ErrIdleTimeout is passed to the error handler if the connection is dropped by the idle detection,
+// the client reconnects to zipper after that.
+var ErrIdleTimeout = yerr.New(yerr.CodeNetwork, "yomo: idle timeout")
+
+// discardRead discards the frames read from the dropped connection until the reading fails,
+// so they are not taken by the next connection.
+func (c *Client) discardRead() {
+	for out := range c.rdCh {
+		if out.err != nil {
+			return
+		}
+	}
+}
+
+// resetTimer resets the timer which may have fired without being received.
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
+
+// serveControl sends PingFrames and handles PongFrames on the control stream until done is closed,
+// it runs apart from the data frames, so the heartbeat is not blocked behind large data writes.
+func (c *Client) serveControl(ctrl frame.ControlConn, done <-chan struct{}) {
+	c.resources.Go(func() {
+		for {
+			f, err := ctrl.ReadControlFrame()
+			if err != nil {
+				return
+			}
+			c.handleFrame(f)
+		}
+	})
+
+	ticker := time.NewTicker(c.opts.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			if err := ctrl.WriteControlFrame(newPingFrame(now)); err != nil {
+				c.Logger.Debug("failed to write ping frame", "err", err)
+				return
+			}
+		}
+	}
+}
+
+func newPingFrame(now time.Time) *frame.PingFrame {
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint64(payload, uint64(now.UnixNano()))
+	return &frame.PingFrame{Payload: payload}
+}
+
+// writeFrames writes the frame to conn, the following frames in the write channel are coalesced
+// into a single write if the write coalescing is enabled. The coalescing stops once the client is closed,
+// or the heartbeat ticks, then the PingFrame is written with the batch.
+func (c *Client) writeFrames(conn frame.Conn, f frame.Frame, heartbeat <-chan time.Time) error {
+	bw, ok := conn.(frame.BatchWriter)
+	if !ok || c.opts.coalesceBytes <= 0 {
+		return conn.WriteFrame(f)
+	}
+
+	var (
+		batch = []frame.Frame{f}
+		size  = frameSize(f)
+		timer = time.NewTimer(c.opts.coalesceDelay)
+	)
+	defer timer.Stop()
 
-						perr := fmt.Errorf("%v", e)
-						c.Logger.Error("stream panic", "err", perr)
-						c.errorfn(fmt.Errorf("yomo: stream panic: %v\n%s", perr, buf))
-					}
-				}()
-				c.handleFrame(out.frame)
-			}()
+COLLECT:
+	for size < c.opts.coalesceBytes {
+		select {
+		case f := <-c.wrHighCh:
+			batch = append(batch, f)
+			size += frameSize(f)
+		case f := <-c.wrCh:
+			batch = append(batch, f)
+			size += frameSize(f)
+		case now := <-heartbeat:
+			batch = append(batch, newPingFrame(now))
+			break COLLECT
+		case <-c.ctx.Done():
+			break COLLECT
+		case <-timer.C:
+			break COLLECT
 		}
 	}
+
+	if len(batch) == 1 {
+		return conn.WriteFrame(f)
+	}
+	return bw.WriteFrames(batch...)
+}
+
+func frameSize(f frame.Frame) int {
+	if df, ok := f.(*frame.DataFrame); ok {
+		return len(df.Metadata) + len(df.Payload)
+	}
+	return 0
 }
 
 func (c *Client) handleFrame(f frame.Frame) {
+	intercepted, err := c.interceptFrame(DirectionInbound, f)
+	if err != nil {
+		c.Logger.Debug("frame vetoed by frame interceptor", "frame_type", f.Type().String(), "err", err)
+		c.reportError(err)
+		return
+	}
+	if intercepted == nil {
+		return
+	}
+	f = intercepted
+
 	switch ff := f.(type) {
 	case *frame.GoawayFrame:
-		c.Logger.Error("goaway error", "err", ff.Message)
+		c.Logger.Error("goaway error", "err", ff.Message, "reason", ff.Reason)
+		c.reportError(goawayError(ff))
 		_ = c.Close()
 	case *frame.RejectedFrame:
-		c.Logger.Error("rejected error", "err", ff.Message)
+		c.Logger.Error("rejected error", "err", ff.Message, "reason", ff.Reason)
+		c.reportError(rejectedError(ff.Message, ff.Reason))
 		_ = c.Close()
+	case *frame.AckFrame:
+		if c.acks != nil {
+			c.acks.ack(ff.ID)
+		}
+	case *frame.PongFrame:
+		c.pings.pong(ff.Payload, time.Now())
+	case *frame.ErrorFrame:
+		c.Logger.Debug("received error frame", "tid", ff.TID, "tag", ff.Tag, "code", ff.Code, "err", ff.Message)
+		if c.errorFrameObserver != nil {
+			c.errorFrameObserver(ff)
+		}
+	case *frame.ResponseFrame:
+		c.handleResponseFrame(ff)
+	case *frame.RequestFrame:
+		if c.requestObserver == nil {
+			c.Logger.Warn("received request frame, but no request observer", "request_id", ff.ID, "tag", ff.Tag)
+			return
+		}
+		c.requestObserver(ff)
 	case *frame.DataFrame:
+		c.countChannel(ff, false)
+		if ff.IsChunked() {
+			assembled, err := c.chunks.add(ff)
+			if err != nil {
+				c.Logger.Debug("drop chunked payload", "tag", ff.Tag, "err", err)
+				c.reportError(err)
+			}
+			if assembled == nil {
+				return
+			}
+			ff = assembled
+		}
+		for _, intercept := range c.rdInterceptors {
+			if err := intercept(ff); err != nil {
+				c.handleReadInterceptorError(ff, err)
+				return
+			}
+		}
+		offset := c.walOffsetOf(ff)
 		c.processor(ff)
+		if offset > 0 {
+			c.handled(offset)
+		}
+	case *frame.ExtensionFrame:
+		c.opts.extensions.handle(c, ff, c.Logger)
 	default:
 		c.Logger.Warn("received unexpected frame", "frame_type", f.Type().String())
 	}
 }
 
+func (c *Client) handleReadInterceptorError(df *frame.DataFrame, err error) {
+	c.Logger.Debug("data frame vetoed by read interceptor", "tag", df.Tag, "err", err)
+	c.reportError(err)
+}
+
+// AckExtensions returns the extensions answered by zipper in the last handshake, it is nil if
+// the client has not connected or zipper answers nothing.
+func (c *Client) AckExtensions() map[string][]byte {
+	exts, _ := c.ackExts.Load().(map[string][]byte)
+	return exts
+}
+
+// ProtocolVersion returns the protocol version negotiated with zipper in the last handshake, it is zero
+// if the client has not connected or zipper is older than the protocol version negotiation.
+func (c *Client) ProtocolVersion() uint32 {
+	return c.protocolVersion.Load()
+}
+
 // SetDataFrameObserver sets the data frame handler.
 func (c *Client) SetDataFrameObserver(fn func(*frame.DataFrame)) {
 	c.processor = fn
 }
 
+// UseWriteInterceptor appends interceptors that are applied to every outgoing DataFrame in order.
+// It should be called before Connect.
+func (c *Client) UseWriteInterceptor(fns ...WriteInterceptor) {
+	c.wrInterceptors = append(c.wrInterceptors, fns...)
+}
+
+// UseReadInterceptor appends interceptors that are applied to every incoming DataFrame in order.
+// It should be called before Connect.
+func (c *Client) UseReadInterceptor(fns ...ReadInterceptor) {
+	c.rdInterceptors = append(c.rdInterceptors, fns...)
+}
+
 // SetObserveDataTags set the data tag list that will be observed.
 func (c *Client) SetObserveDataTags(tag ...frame.Tag) {
+	c.observeMu.Lock()
+	defer c.observeMu.Unlock()
+
 	c.opts.observeDataTags = tag
 }
 
+// ObserveDataTags returns the data tag list that is observed.
+func (c *Client) ObserveDataTags() []frame.Tag {
+	c.observeMu.Lock()
+	defer c.observeMu.Unlock()
+
+	return c.opts.observeDataTags
+}
+
+// UpdateObserveDataTags subscribes and unsubscribes the data tags after connecting, zipper updates
+// the route rules without reconnecting. The updated tags are observed after reconnecting as well.
+func (c *Client) UpdateObserveDataTags(subscribe, unsubscribe []frame.Tag) error {
+	c.observeMu.Lock()
+	c.opts.observeDataTags = updateTags(c.opts.observeDataTags, subscribe, unsubscribe)
+	c.observeMu.Unlock()
+
+	return c.WriteFrame(&frame.ObserveUpdateFrame{Subscribe: subscribe, Unsubscribe: unsubscribe})
+}
+
 // SetErrorHandler set error handler
 func (c *Client) SetErrorHandler(fn func(err error)) {
 	c.errorfn = fn
 	c.Logger.Debug("the error handler has been set")
 }
 
+// reportError counts the error by its code and passes it to the error handler.
+func (c *Client) reportError(err error) {
+	code := yerr.CodeOf(err)
+	v, ok := c.errCounts.Load(code)
+	if !ok {
+		v, _ = c.errCounts.LoadOrStore(code, new(atomic.Int64))
+	}
+	v.(*atomic.Int64).Add(1)
+
+	if c.errorfn != nil {
+		c.errorfn(err)
+	}
+}
+
+// ErrorCounts returns the number of the errors reported to the error handler by code,
+// use yerr.CodeOf to get the code of an error.
+func (c *Client) ErrorCounts() map[yerr.Code]int64 {
+	counts := make(map[yerr.Code]int64)
+	c.errCounts.Range(func(key, value any) bool {
+		counts[key.(yerr.Code)] = value.(*atomic.Int64).Load()
+		return true
+	})
+	return counts
+}
+
 // ClientID returns the ID of client.
 func (c *Client) ClientID() string { return c.clientID }
 
 // Name returns the name of client.
 func (c *Client) Name() string { return c.name }
 
+// TagNamer returns the tag namer of client, it returns nil if the tag namer has not been set.
+func (c *Client) TagNamer() TagNamer { return c.opts.tagNamer }
+
 // Downstream represents a frame writer that can connect to an addr.
 type Downstream interface {
 	frame.Writer
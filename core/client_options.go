@@ -15,6 +15,7 @@ import (
 	"github.com/yomorun/yomo/core/auth"
 	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/ylog"
+	yquic "github.com/yomorun/yomo/pkg/listener/quic"
 	pkgtls "github.com/yomorun/yomo/pkg/tls"
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/slog"
@@ -25,14 +26,63 @@ type ClientOption func(*clientOptions)
 
 // clientOptions are the options for YoMo client.
 type clientOptions struct {
-	observeDataTags []frame.Tag
-	quicConfig      *quic.Config
-	tlsConfig       *tls.Config
-	credential      *auth.Credential
-	reconnect       bool
-	nonBlockWrite   bool
-	logger          *slog.Logger
-	tracerProvider  trace.TracerProvider
+	observeDataTags    []frame.Tag
+	schemaVersions     map[frame.Tag][]string
+	handshakeExts      map[string][]byte
+	replayFrom         *uint64
+	replayFromFunc     func() uint64
+	checksum           bool
+	codecName          string
+	quicConfig         *quic.Config
+	tlsConfig          *tls.Config
+	tlsLoader          func() (*tls.Config, error)
+	transports         []Transport
+	dialer             yquic.Dialer
+	credential         *auth.Credential
+	reconnect          bool
+	fallbackZippers    []string
+	backoff            ReconnectBackoff
+	nonBlockWrite      bool
+	wrBufferSize       int
+	wrOverflow         WriteOverflowPolicy
+	wrTimeout          time.Duration
+	rdQueueSize        int
+	rdQueuePolicy      ReadQueuePolicy
+	streamWrTimeout    time.Duration
+	compressMinSize    int
+	controlStream      bool
+	streamCount        int
+	streamSelector     yquic.StreamSelector
+	stateHandler       StateHandler
+	customDialer       bool
+	resolver           Resolver
+	customResolver     bool
+	resolveTimeout     time.Duration
+	dialTimeout        time.Duration
+	handshakeTimeout   time.Duration
+	heartbeat          time.Duration
+	idleTimeout        time.Duration
+	coalesceDelay      time.Duration
+	coalesceBytes      int
+	writeRPS           float64
+	writeBurst         int
+	drainTimeout       time.Duration
+	breakerThreshold   int
+	breakerCooldown    time.Duration
+	breakerHandler     BreakerHandler
+	probeThreshold     int
+	probeInterval      time.Duration
+	maxFrameSize       int
+	ackTimeout         time.Duration
+	ackRetries         int
+	extensions         extensions
+	dropReportInterval time.Duration
+	writerTag          frame.Tag
+	hasWriterTag       bool
+	logger             *slog.Logger
+	tracerProvider     trace.TracerProvider
+	tagNamer           TagNamer
+	frameInterceptors  []FrameInterceptor
 }
 
 // DefaultClientQuicConfig be used when the `quicConfig` of client is nil.
@@ -52,9 +102,16 @@ func defaultClientOption() *clientOptions {
 	opts := &clientOptions{
 		observeDataTags: make([]frame.Tag, 0),
 		quicConfig:      DefaultClientQuicConfig,
+		dialer:          quic.DialAddr,
 		tlsConfig:       pkgtls.MustCreateClientTLSConfig(),
 		credential:      auth.NewCredential(""),
+		backoff:         DefaultReconnectBackoff,
 		logger:          ylog.Default(),
+
+		resolveTimeout:   DefaultResolveTimeout,
+		resolver:         &DNSResolver{},
+		dialTimeout:      DefaultDialTimeout,
+		handshakeTimeout: DefaultHandshakeTimeout,
 	}
 
 	return opts
@@ -67,6 +124,47 @@ func WithCredential(payload string) ClientOption {
 	}
 }
 
+// WithSchemaVersions declares the payload schema versions of the tag supported by the client, zipper
+// routes the frames of other versions to the client only if they can be up-converted.
+func WithSchemaVersions(tag frame.Tag, versions ...string) ClientOption {
+	return func(o *clientOptions) {
+		if o.schemaVersions == nil {
+			o.schemaVersions = make(map[frame.Tag][]string)
+		}
+		o.schemaVersions[tag] = versions
+	}
+}
+
+// WithHandshakeExtension piggybacks the opaque data on the handshake, the zipper answers it by the
+// HandshakeExtensionHandler, and the answer is returned by Client.AckExtensions.
+func WithHandshakeExtension(key string, value []byte) ClientOption {
+	return func(o *clientOptions) {
+		if o.handshakeExts == nil {
+			o.handshakeExts = make(map[string][]byte)
+		}
+		o.handshakeExts[key] = value
+	}
+}
+
+// WithChecksum makes the client append the checksum to the DataFrames it writes, it is for the lossy
+// links with middleboxes that deliver garbage. The corrupted frames are dropped and reported to the
+// error handler instead of breaking the connection. It takes effect on the default QUIC transport.
+func WithChecksum() ClientOption {
+	return func(o *clientOptions) {
+		o.checksum = true
+	}
+}
+
+// WithCodecName selects the codec registered by the name to encode the frames, e.g. "cbor",
+// the zipper must be configured with the same codec. The default is the y3 codec. It takes effect
+// on the default QUIC transport, and the connecting fails if the codec is not registered.
+// The built-in codecs, "y3" and "cbor", are always registered.
+func WithCodecName(name string) ClientOption {
+	return func(o *clientOptions) {
+		o.codecName = name
+	}
+}
+
 // WithClientTLSConfig sets tls config for the client.
 func WithClientTLSConfig(tc *tls.Config) ClientOption {
 	return func(o *clientOptions) {
@@ -76,6 +174,15 @@ func WithClientTLSConfig(tc *tls.Config) ClientOption {
 	}
 }
 
+// WithTLSConfigLoader sets the function that loads the tls config on every connecting, including the
+// reconnections, so the long-running clients rotate the certificates without restarting.
+// It overrides WithClientTLSConfig, and the default tls config is used if the loader returns nil.
+func WithTLSConfigLoader(loader func() (*tls.Config, error)) ClientOption {
+	return func(o *clientOptions) {
+		o.tlsLoader = loader
+	}
+}
+
 // WithClientQuicConfig sets quic config for the client.
 func WithClientQuicConfig(qc *quic.Config) ClientOption {
 	return func(o *clientOptions) {
@@ -90,6 +197,21 @@ func WithReConnect() ClientOption {
 	}
 }
 
+// WithFallbackZippers sets the fallback zipper addresses for the client, if the client fails
+// to connect to a zipper, it tries the next one in order.
+func WithFallbackZippers(addrs ...string) ClientOption {
+	return func(o *clientOptions) {
+		o.fallbackZippers = append(o.fallbackZippers, addrs...)
+	}
+}
+
+// WithReconnectBackoff sets the backoff policy of reconnection for the client.
+func WithReconnectBackoff(b ReconnectBackoff) ClientOption {
+	return func(o *clientOptions) {
+		o.backoff = b
+	}
+}
+
 // WithNonBlockWrite makes client WriteFrame non-blocking.
 func WithNonBlockWrite() ClientOption {
 	return func(o *clientOptions) {
@@ -97,6 +219,272 @@ func WithNonBlockWrite() ClientOption {
 	}
 }
 
+// WithWriteBufferSize sets the size of write buffer for the client, the buffer absorbs
+// short bursts of writing. The default size is 0, which means WriteFrame is unbuffered.
+// Every priority of frame.Priority has its own buffer of the size, so up to 3*n frames are buffered
+// if the frames of all the priorities are written.
+func WithWriteBufferSize(n int) ClientOption {
+	return func(o *clientOptions) {
+		if n >= 0 {
+			o.wrBufferSize = n
+		}
+	}
+}
+
+// WithWriteOverflowPolicy sets what the client does when the write buffer is full.
+// It does not take effect if WithNonBlockWrite is set.
+func WithWriteOverflowPolicy(p WriteOverflowPolicy) ClientOption {
+	return func(o *clientOptions) {
+		o.wrOverflow = p
+	}
+}
+
+// WithReadQueue puts the DataFrames read from zipper into a queue of the size, the processor takes
+// them from the queue in another goroutine, so a slow processor does not stall the connection.
+// The policy decides what to do when the queue is full, the dropped frames are counted in DroppedFrames.
+// The default size is 0, which means the DataFrames are processed in the reading loop.
+func WithReadQueue(size int, policy ReadQueuePolicy) ClientOption {
+	return func(o *clientOptions) {
+		if size >= 0 {
+			o.rdQueueSize = size
+		}
+		o.rdQueuePolicy = policy
+	}
+}
+
+// WithWriteTimeout sets the timeout of writing a frame in block mode,
+// WriteFrame returns ErrWriteTimeout if the frame cannot be written in time.
+func WithWriteTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.wrTimeout = timeout
+	}
+}
+
+// WithStreamWriteTimeout bounds every write to the stream with the timeout, so a stalled zipper
+// cannot block the client indefinitely. If a write times out, ErrWriteTimeout is passed to the
+// error handler, the connection is closed and the client reconnects to zipper.
+func WithStreamWriteTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.streamWrTimeout = timeout
+	}
+}
+
+// WithClientCompression makes the client offer the payload compression in handshake, once zipper
+// confirms it, the payloads of DataFrames larger than minSize are compressed transparently on write
+// and decompressed on read. minSize <= 0 means DefaultCompressMinSize.
+func WithClientCompression(minSize int) ClientOption {
+	return func(o *clientOptions) {
+		if minSize <= 0 {
+			minSize = DefaultCompressMinSize
+		}
+		o.compressMinSize = minSize
+	}
+}
+
+// WithControlStream makes the client transmit the control frames (e.g. the heartbeat) on a dedicated
+// stream served by a separate goroutine, the control frames are never blocked behind large data writes.
+func WithControlStream() ClientOption {
+	return func(o *clientOptions) {
+		o.controlStream = true
+	}
+}
+
+// WithStreamCount makes the client write the DataFrames on n data streams, the stream of each DataFrame
+// is chosen by the StreamSelector, which pins each tag to a stream by default. The head-of-line blocking
+// in one heavy tag doesn't delay the others then.
+func WithStreamCount(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.streamCount = n
+	}
+}
+
+// WithStreamSelector sets the policy that chooses the data stream of DataFrames, it takes effect with
+// WithStreamCount, e.g. yquic.TagAffinity, yquic.PriorityAffinity, yquic.ChannelAffinity or yquic.RoundRobin().
+func WithStreamSelector(selector yquic.StreamSelector) ClientOption {
+	return func(o *clientOptions) {
+		o.streamSelector = selector
+	}
+}
+
+// WithStateHandler sets the handler that is called when the connection state of the client changes.
+func WithStateHandler(fn StateHandler) ClientOption {
+	return func(o *clientOptions) {
+		o.stateHandler = fn
+	}
+}
+
+// WithConnectTimeouts sets the timeouts of the connecting phases: resolving the zipper address,
+// dialing the QUIC connection and the yomo handshake with authentication. A zero timeout keeps the
+// default one (DefaultResolveTimeout, DefaultDialTimeout and DefaultHandshakeTimeout), and a negative
+// timeout disables the timeout of the phase. The failed phase is reported by ConnectError.
+func WithConnectTimeouts(resolve, dial, handshake time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		if resolve != 0 {
+			o.resolveTimeout = resolve
+		}
+		if dial != 0 {
+			o.dialTimeout = dial
+		}
+		if handshake != 0 {
+			o.handshakeTimeout = handshake
+		}
+	}
+}
+
+// WithHeartbeat makes the client send PingFrame to zipper every interval,
+// the round-trip time can be retrieved by `Client.RTT()`.
+func WithHeartbeat(interval time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.heartbeat = interval
+	}
+}
+
+// WithIdleTimeout makes the client ping zipper if no frame is read in the timeout, and reconnect if
+// still no frame is read in another timeout. Without it, a silently dead path is detected only
+// when the next write fails.
+func WithIdleTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.idleTimeout = timeout
+	}
+}
+
+// WithWriteCoalescing makes the client coalesce multiple frames into a single write, the frames are
+// collected until maxDelay elapsed or the collected payload exceeds maxBytes. maxBytes <= 0 means 32KB.
+// It reduces the syscall and packet overhead for the clients emitting many tiny frames.
+func WithWriteCoalescing(maxDelay time.Duration, maxBytes int) ClientOption {
+	return func(o *clientOptions) {
+		if maxBytes <= 0 {
+			maxBytes = 32 << 10
+		}
+		o.coalesceDelay = maxDelay
+		o.coalesceBytes = maxBytes
+	}
+}
+
+// WithResolver sets the resolver of the zipper address, the client dials the resolved addresses in order.
+// The default DNSResolver resolves `host:port` and the SRV names like `_yomo._udp.example.com`.
+// The resolver also applies to the custom dialer and transports if it is set.
+func WithResolver(r Resolver) ClientOption {
+	return func(o *clientOptions) {
+		o.resolver = r
+		o.customResolver = true
+	}
+}
+
+// WithDialer sets the dialer for the client, it controls how the QUIC connection is established,
+// e.g. through a proxy or a custom PacketConn, see `yquic.PacketConnDialer`.
+func WithDialer(dialer yquic.Dialer) ClientOption {
+	return func(o *clientOptions) {
+		o.dialer = dialer
+		o.customDialer = true
+	}
+}
+
+// WithTransports sets the transports that carry the frames to zipper, the client dials with them in order
+// and falls back to the next one if the dialing fails, e.g. NewWebTransportTransport if the raw QUIC is blocked.
+// The default transport is NewQUICTransport with the dialer and quic config of the client.
+func WithTransports(transports ...Transport) ClientOption {
+	return func(o *clientOptions) {
+		o.transports = transports
+	}
+}
+
+// WithWriteRateLimit limits the client to write at most rps DataFrames per second with bursts of
+// at most burst frames. When the limit is hit, the writing blocks in blocking mode, otherwise it
+// returns ErrRateLimited, the mode is non-blocking if `WithNonBlockWrite()` is set or the write
+// overflow policy is not WriteOverflowBlock.
+func WithWriteRateLimit(rps float64, burst int) ClientOption {
+	return func(o *clientOptions) {
+		o.writeRPS = rps
+		o.writeBurst = burst
+	}
+}
+
+// WithDrainTimeout makes Close flush the queued frames within the timeout before closing the connection,
+// the frames not flushed are abandoned and reported by ErrFramesAbandoned.
+func WithDrainTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.drainTimeout = timeout
+	}
+}
+
+// WithCircuitBreaker opens the circuit breaker of writing after the threshold of consecutive write failures,
+// e.g. write timeouts, full buffer or broken streams. WriteFrame fast-fails with ErrCircuitOpen while it is
+// open, and the frames are written again after the cooldown. The handler is called when the state of the
+// breaker changes, it can be nil.
+func WithCircuitBreaker(threshold int, cooldown time.Duration, handler BreakerHandler) ClientOption {
+	return func(o *clientOptions) {
+		o.breakerThreshold = threshold
+		o.breakerCooldown = cooldown
+		o.breakerHandler = handler
+	}
+}
+
+// WithConnectBreaker opens the connect breaker after the threshold of consecutive failures of connecting
+// or authenticating, then the client enters StateProbing and probes zipper at most once per probe interval,
+// instead of hammering the unhealthy zipper by the backoff policy. The breaker is closed once a probe
+// connects, and the state transitions are passed to the StateHandler.
+func WithConnectBreaker(threshold int, probeInterval time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.probeThreshold = threshold
+		o.probeInterval = probeInterval
+	}
+}
+
+// WithMaxFrameSize splits the payload larger than size into the chunks of at most size bytes, the chunks
+// are written as separate DataFrames, so an oversized payload does not starve the other frames.
+// The chunks are reassembled by the reading clients transparently.
+func WithMaxFrameSize(size int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxFrameSize = size
+	}
+}
+
+// WithAck enables the at-least-once delivery of the DataFrames: zipper acknowledges every DataFrame
+// it has received, and the DataFrame not acknowledged in the timeout is written again, at most retries
+// times, then ErrAckTimeout is reported to the error handler. The retransmitted DataFrames may be
+// received more than once, so the readers should be idempotent. At most DefaultAckMaxPending DataFrames
+// wait for the ack, writing blocks once it is reached. The ack is negotiated by the protocol version,
+// the DataFrames are not tracked if zipper does not speak it.
+func WithAck(timeout time.Duration, retries int) ClientOption {
+	return func(o *clientOptions) {
+		o.ackTimeout = timeout
+		o.ackRetries = retries
+	}
+}
+
+// WithExtension registers the handler of the extension frame type, the frames of the unregistered
+// types are skipped. The builtin frame types cannot be extended.
+func WithExtension(t frame.Type, handler ExtensionHandler) ClientOption {
+	return func(o *clientOptions) {
+		o.extensions.register(t, handler)
+	}
+}
+
+// WithDropReportInterval makes the client pass a DropReport to the error handler every interval if
+// the non-blocking writing or the overflow policy drops DataFrames, instead of losing them silently.
+func WithDropReportInterval(interval time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.dropReportInterval = interval
+	}
+}
+
+// WithWriterTag sets the tag of the DataFrames written by `Client.Write`.
+func WithWriterTag(tag frame.Tag) ClientOption {
+	return func(o *clientOptions) {
+		o.writerTag = tag
+		o.hasWriterTag = true
+	}
+}
+
+// WithFrameInterceptor appends the interceptor that is applied to every frame written by WriteFrame
+// and read from zipper, the interceptors are applied in the order they are appended.
+func WithFrameInterceptor(fn FrameInterceptor) ClientOption {
+	return func(o *clientOptions) {
+		o.frameInterceptors = append(o.frameInterceptors, fn)
+	}
+}
+
 // WithLogger sets logger for the client.
 func WithLogger(logger *slog.Logger) ClientOption {
 	return func(o *clientOptions) {
@@ -111,6 +499,13 @@ func WithTracerProvider(tp trace.TracerProvider) ClientOption {
 	}
 }
 
+// WithTagNamer sets the tag namer for the client, the tag names are displayed in logs and traces.
+func WithTagNamer(namer TagNamer) ClientOption {
+	return func(o *clientOptions) {
+		o.tagNamer = namer
+	}
+}
+
 // qlog helps developers to debug quic protocol.
 // See more: https://github.com/quic-go/quic-go?tab=readme-ov-file#quic-event-logging-using-qlog
 func qlogTraceEnabled() bool {
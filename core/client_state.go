@@ -0,0 +1,109 @@
+package core
+
+import "golang.org/x/exp/slices"
+
+// ConnState is the state of the connection between the client and zipper.
+type ConnState int32
+
+const (
+	// StateIdle is the initial state, or the state after Connect failed.
+	StateIdle ConnState = iota
+	// StateConnecting means the client is dialing zipper by calling Connect.
+	StateConnecting
+	// StateAuthenticating means the connection is established and the handshake is in progress.
+	StateAuthenticating
+	// StateConnected means the handshake is acknowledged by zipper.
+	StateConnected
+	// StateReconnecting means the connection is lost and the client is dialing zipper again.
+	StateReconnecting
+	// StateClosed means the client is closed, it is the final state.
+	StateClosed
+	// StateProbing means connecting to zipper has failed repeatedly, the connect breaker is open and
+	// the client probes zipper at the capped rate, see WithConnectBreaker.
+	StateProbing
+)
+
+var connStateStrings = map[ConnState]string{
+	StateIdle:           "Idle",
+	StateConnecting:     "Connecting",
+	StateAuthenticating: "Authenticating",
+	StateConnected:      "Connected",
+	StateReconnecting:   "Reconnecting",
+	StateClosed:         "Closed",
+	StateProbing:        "Probing",
+}
+
+// String returns the name of the state.
+func (s ConnState) String() string {
+	if str, ok := connStateStrings[s]; ok {
+		return str
+	}
+	return "Unknown"
+}
+
+// connStateTransitions lists the valid transitions of the connection states,
+// every state can transit to StateClosed except itself.
+var connStateTransitions = map[ConnState][]ConnState{
+	StateIdle:           {StateConnecting},
+	StateConnecting:     {StateAuthenticating, StateIdle, StateProbing},
+	StateAuthenticating: {StateConnected, StateConnecting, StateReconnecting, StateIdle, StateProbing},
+	StateConnected:      {StateReconnecting},
+	StateReconnecting:   {StateAuthenticating, StateProbing},
+	StateProbing:        {StateAuthenticating, StateIdle},
+}
+
+// StateHandler is called when the connection state of the client changes.
+// It is called synchronously in the goroutine that changes the state, so it should not block.
+type StateHandler func(from, to ConnState)
+
+// State returns the current connection state of the client.
+func (c *Client) State() ConnState {
+	return ConnState(c.state.Load())
+}
+
+// StateTransitions returns the number of times the client has entered each state,
+// e.g. the count of StateReconnecting is the number of reconnections.
+func (c *Client) StateTransitions() map[ConnState]int64 {
+	result := make(map[ConnState]int64, len(c.stateCounts))
+	for i := range c.stateCounts {
+		if n := c.stateCounts[i].Load(); n > 0 {
+			result[ConnState(i)] = n
+		}
+	}
+	return result
+}
+
+// retryState transits the client to the state of retrying connecting,
+// the client keeps probing if the connect breaker is open.
+func (c *Client) retryState(to ConnState) {
+	if c.State() != StateProbing {
+		c.setState(to)
+	}
+}
+
+// setState transits the client to the state, it returns false if the transition is invalid.
+// Staying in the same state is a no-op.
+func (c *Client) setState(to ConnState) bool {
+	for {
+		from := c.State()
+		if from == to {
+			return true
+		}
+		if to != StateClosed && !slices.Contains(connStateTransitions[from], to) {
+			c.Logger.Debug("invalid connection state transition", "from", from.String(), "to", to.String())
+			return false
+		}
+		if from == StateClosed {
+			return false
+		}
+		if !c.state.CompareAndSwap(int32(from), int32(to)) {
+			continue
+		}
+		c.stateCounts[to].Add(1)
+		c.Logger.Debug("connection state changed", "from", from.String(), "to", to.String())
+		if c.opts.stateHandler != nil {
+			c.opts.stateHandler(from, to)
+		}
+		return true
+	}
+}
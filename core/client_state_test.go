@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stateRecorder struct {
+	mu     sync.Mutex
+	states []ConnState
+}
+
+func (r *stateRecorder) handle(from, to ConnState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = append(r.states, to)
+}
+
+func (r *stateRecorder) get() []ConnState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ConnState(nil), r.states...)
+}
+
+func TestConnStateString(t *testing.T) {
+	assert.Equal(t, "Reconnecting", StateReconnecting.String())
+	assert.Equal(t, "Unknown", ConnState(100).String())
+}
+
+func TestSetState(t *testing.T) {
+	recorder := &stateRecorder{}
+	client := NewClient("source", testaddr, ClientTypeSource, WithLogger(discardingLogger), WithStateHandler(recorder.handle))
+	assert.Equal(t, StateIdle, client.State())
+
+	// invalid transitions are rejected.
+	assert.False(t, client.setState(StateConnected))
+	assert.Equal(t, StateIdle, client.State())
+
+	assert.True(t, client.setState(StateConnecting))
+	assert.True(t, client.setState(StateConnecting))
+	assert.True(t, client.setState(StateAuthenticating))
+	assert.True(t, client.setState(StateConnected))
+	assert.True(t, client.setState(StateReconnecting))
+	assert.True(t, client.setState(StateAuthenticating))
+	assert.True(t, client.setState(StateReconnecting))
+
+	// closed is the final state.
+	assert.True(t, client.setState(StateClosed))
+	assert.False(t, client.setState(StateConnecting))
+
+	assert.Equal(t, []ConnState{
+		StateConnecting, StateAuthenticating, StateConnected,
+		StateReconnecting, StateAuthenticating, StateReconnecting, StateClosed,
+	}, recorder.get())
+	assert.Equal(t, map[ConnState]int64{
+		StateConnecting: 1, StateAuthenticating: 2, StateConnected: 1, StateReconnecting: 2, StateClosed: 1,
+	}, client.StateTransitions())
+}
+
+func TestConnectStates(t *testing.T) {
+	t.Run("dial nothing", func(t *testing.T) {
+		recorder := &stateRecorder{}
+		client := NewClient("source", "127.0.0.1:0", ClientTypeSource, WithLogger(discardingLogger), WithStateHandler(recorder.handle))
+
+		assert.Error(t, client.Connect(context.TODO()))
+		assert.Equal(t, []ConnState{StateConnecting, StateIdle}, recorder.get())
+	})
+
+	t.Run("connect and close", func(t *testing.T) {
+		server := NewServer("zipper", WithServerLogger(discardingLogger))
+		go server.ListenAndServe(context.TODO(), "127.0.0.1:19990")
+		defer server.Close()
+
+		recorder := &stateRecorder{}
+		client := NewClient("source", "127.0.0.1:19990", ClientTypeSource,
+			WithLogger(discardingLogger), WithReConnect(), WithStateHandler(recorder.handle))
+
+		assert.NoError(t, client.Connect(context.TODO()))
+		assert.Equal(t, StateConnected, client.State())
+
+		assert.NoError(t, client.Close())
+		client.Wait()
+
+		assert.Equal(t, []ConnState{StateConnecting, StateAuthenticating, StateConnected, StateClosed}, recorder.get())
+	})
+}
+
+func TestConnectBreaker(t *testing.T) {
+	const breakerAddr = "127.0.0.1:19979"
+
+	recorder := &stateRecorder{}
+	client := NewClient("source", breakerAddr, ClientTypeSource,
+		WithLogger(discardingLogger), WithReConnect(), WithStateHandler(recorder.handle),
+		WithConnectTimeouts(0, 50*time.Millisecond, 50*time.Millisecond),
+		WithReconnectBackoff(ReconnectBackoff{Initial: time.Millisecond, Max: time.Millisecond}),
+		WithConnectBreaker(2, 100*time.Millisecond),
+	)
+
+	connected := make(chan error)
+	go func() { connected <- client.Connect(context.TODO()) }()
+
+	// the client probes the unavailable zipper after 2 failures.
+	assert.Eventually(t, func() bool { return client.State() == StateProbing }, 3*time.Second, 10*time.Millisecond)
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	go server.ListenAndServe(context.TODO(), breakerAddr)
+	defer server.Close()
+
+	assert.NoError(t, <-connected)
+	defer client.Close()
+
+	assert.Equal(t, []ConnState{StateConnecting, StateProbing, StateAuthenticating, StateConnected}, recorder.get())
+}
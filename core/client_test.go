@@ -3,19 +3,27 @@ package core
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"os"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/quic-go/quic-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/metadata"
 	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/core/yerr"
 	"github.com/yomorun/yomo/core/ylog"
 	"github.com/yomorun/yomo/pkg/frame-codec/y3codec"
+	yquic "github.com/yomorun/yomo/pkg/listener/quic"
+	pkgtls "github.com/yomorun/yomo/pkg/tls"
 )
 
 const (
@@ -99,6 +107,7 @@ func TestFrameRoundTrip(t *testing.T) {
 	illegalTokenSource := NewClient("source", testaddr, ClientTypeSource, WithCredential("token:error-token"), WithLogger(discardingLogger))
 	err := illegalTokenSource.Connect(ctx)
 	assert.Equal(t, "authentication failed: client credential type is token", err.Error())
+	assert.ErrorIs(t, err, ErrAuthenticateFailed)
 
 	source := NewClient(
 		"source",
@@ -330,3 +339,608 @@ func assertDownstreamDataFrame(t *testing.T, tag uint32, md metadata.M, payload
 	assert.Equal(t, recordMD, md)
 	assert.Equal(t, recordPayload, payload)
 }
+
+func TestWriteInterceptor(t *testing.T) {
+	client := NewClient("source", testaddr, ClientTypeSource, WithLogger(discardingLogger))
+	client.Close()
+
+	var written int
+	client.UseWriteInterceptor(
+		func(df *frame.DataFrame) error {
+			written += len(df.Payload)
+			df.Tag++
+			return nil
+		},
+		func(df *frame.DataFrame) error {
+			if len(df.Payload) > 4 {
+				return errors.New("payload too large")
+			}
+			return nil
+		},
+	)
+
+	df := &frame.DataFrame{Tag: 0x33, Payload: []byte("yomo")}
+	assert.ErrorIs(t, client.WriteFrame(df), context.Canceled)
+	assert.Equal(t, frame.Tag(0x34), df.Tag)
+
+	assert.EqualError(t, client.WriteFrame(&frame.DataFrame{Payload: []byte("hello")}), "payload too large")
+	assert.Equal(t, 9, written)
+
+	// the interceptors only apply to DataFrame.
+	assert.ErrorIs(t, client.WriteFrame(&frame.GoawayFrame{}), context.Canceled)
+}
+
+func TestReadInterceptor(t *testing.T) {
+	client := NewClient("sfn", testaddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+
+	var (
+		processed []string
+		vetoErr   = errors.New("veto")
+		handled   error
+	)
+	client.SetDataFrameObserver(func(df *frame.DataFrame) { processed = append(processed, string(df.Payload)) })
+	client.SetErrorHandler(func(err error) { handled = err })
+	client.UseReadInterceptor(
+		func(df *frame.DataFrame) error {
+			if df.Tag == 0x00 {
+				return vetoErr
+			}
+			return nil
+		},
+		func(df *frame.DataFrame) error {
+			df.Payload = bytes.ToUpper(df.Payload)
+			return nil
+		},
+	)
+
+	client.handleFrame(&frame.DataFrame{Tag: 0x00, Payload: []byte("dropped")})
+	client.handleFrame(&frame.DataFrame{Tag: 0x01, Payload: []byte("yomo")})
+
+	assert.Equal(t, []string{"YOMO"}, processed)
+	assert.Equal(t, vetoErr, handled)
+}
+
+func TestWriteBuffer(t *testing.T) {
+	t.Run("drop oldest", func(t *testing.T) {
+		client := NewClient("source", testaddr, ClientTypeSource,
+			WithLogger(discardingLogger), WithWriteBufferSize(2), WithWriteOverflowPolicy(WriteOverflowDropOldest))
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: frame.Tag(i)}))
+		}
+		assert.Equal(t, frame.Tag(1), (<-client.wrCh).(*frame.DataFrame).Tag)
+		assert.Equal(t, frame.Tag(2), (<-client.wrCh).(*frame.DataFrame).Tag)
+	})
+
+	t.Run("drop unbuffered", func(t *testing.T) {
+		client := NewClient("source", testaddr, ClientTypeSource,
+			WithLogger(discardingLogger), WithWriteOverflowPolicy(WriteOverflowDropOldest))
+
+		assert.NoError(t, client.WriteFrame(&frame.DataFrame{}))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		client := NewClient("source", testaddr, ClientTypeSource,
+			WithLogger(discardingLogger), WithWriteBufferSize(1), WithWriteOverflowPolicy(WriteOverflowError))
+
+		assert.NoError(t, client.WriteFrame(&frame.DataFrame{}))
+		assert.Equal(t, ErrWriteBufferFull, client.WriteFrame(&frame.DataFrame{}))
+	})
+
+	t.Run("block", func(t *testing.T) {
+		client := NewClient("source", testaddr, ClientTypeSource, WithLogger(discardingLogger), WithWriteBufferSize(1))
+
+		assert.NoError(t, client.WriteFrame(&frame.DataFrame{}))
+		time.AfterFunc(100*time.Millisecond, func() { client.Close() })
+		assert.ErrorIs(t, client.WriteFrame(&frame.DataFrame{}), context.Canceled)
+	})
+}
+
+func TestWriteTimeout(t *testing.T) {
+	t.Run("option", func(t *testing.T) {
+		client := NewClient("source", testaddr, ClientTypeSource, WithLogger(discardingLogger), WithWriteTimeout(10*time.Millisecond))
+
+		err := client.WriteFrame(&frame.DataFrame{})
+		e := new(ErrWriteTimeout)
+		assert.ErrorAs(t, err, &e)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.True(t, e.Timeout())
+	})
+
+	t.Run("context", func(t *testing.T) {
+		client := NewClient("source", testaddr, ClientTypeSource, WithLogger(discardingLogger))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := client.WriteFrameContext(ctx, &frame.DataFrame{})
+		assert.EqualError(t, err, "yomo: write frame timeout: context deadline exceeded")
+	})
+}
+
+func TestFallbackZippers(t *testing.T) {
+	client := NewClient("source", "a:9000", ClientTypeSource, WithLogger(discardingLogger), WithFallbackZippers("b:9000", "c:9000"))
+	connErr := errors.New("mock connect error")
+
+	for _, next := range []string{"b:9000", "c:9000"} {
+		reconnect, err := client.handleConnectResult(context.TODO(), connErr, false, 0)
+		assert.True(t, reconnect)
+		assert.NoError(t, err)
+		assert.Equal(t, next, client.zipperAddr)
+	}
+
+	// all the zippers have been tried.
+	reconnect, err := client.handleConnectResult(context.TODO(), connErr, false, 0)
+	assert.False(t, reconnect)
+	assert.Equal(t, connErr, err)
+	assert.Equal(t, "a:9000", client.zipperAddr)
+}
+
+func TestHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	const heartbeatAddr = "127.0.0.1:19995"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	go server.ListenAndServe(context.TODO(), heartbeatAddr)
+	defer server.Close()
+
+	source := NewClient("source", heartbeatAddr, ClientTypeSource,
+		WithLogger(discardingLogger), WithReConnect(), WithHeartbeat(50*time.Millisecond))
+	assert.Equal(t, time.Duration(0), source.RTT())
+
+	err := source.Connect(context.TODO())
+	assert.NoError(t, err)
+	defer source.Close()
+
+	assert.Eventually(t, func() bool { return source.RTT() > 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestStreamCount(t *testing.T) {
+	t.Parallel()
+
+	const streamsAddr = "127.0.0.1:19992"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	server.ConfigRouter(router.Default())
+	server.ConfigVersionNegotiateFunc(DefaultVersionNegotiateFunc)
+	go server.ListenAndServe(context.TODO(), streamsAddr)
+	defer server.Close()
+
+	received := make(chan frame.Tag, 3)
+	sfn := NewClient("sfn", streamsAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	sfn.SetObserveDataTags(1, 2, 3)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df.Tag })
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	source := NewClient("source", streamsAddr, ClientTypeSource, WithLogger(discardingLogger), WithStreamCount(3))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	md, _ := metadata.M{}.Encode()
+	for tag := frame.Tag(1); tag <= 3; tag++ {
+		assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: tag, Metadata: md}))
+	}
+
+	tags := []frame.Tag{}
+	for len(tags) < 3 {
+		select {
+		case tag := <-received:
+			tags = append(tags, tag)
+		case <-time.After(3 * time.Second):
+			t.Fatal("the sfn does not receive the data")
+		}
+	}
+	assert.ElementsMatch(t, []frame.Tag{1, 2, 3}, tags)
+}
+
+func TestUpdateObserveDataTags(t *testing.T) {
+	t.Parallel()
+
+	const observeAddr = "127.0.0.1:19991"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	server.ConfigRouter(router.Default())
+	server.ConfigVersionNegotiateFunc(DefaultVersionNegotiateFunc)
+	go server.ListenAndServe(context.TODO(), observeAddr)
+	defer server.Close()
+
+	received := make(chan frame.Tag, 2)
+	sfn := NewClient("sfn", observeAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	sfn.SetObserveDataTags(0x41)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df.Tag })
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	assert.NoError(t, sfn.UpdateObserveDataTags([]frame.Tag{0x42}, []frame.Tag{0x41}))
+	assert.Equal(t, []frame.Tag{0x42}, sfn.ObserveDataTags())
+
+	// zipper updates the route rules without reconnecting.
+	assert.Eventually(t, func() bool {
+		return len(server.router.Route(0x42, nil)) == 1 && len(server.router.Route(0x41, nil)) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	source := NewClient("source", observeAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	md, _ := metadata.M{}.Encode()
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 0x41, Metadata: md}))
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 0x42, Metadata: md}))
+
+	select {
+	case tag := <-received:
+		assert.Equal(t, frame.Tag(0x42), tag)
+	case <-time.After(3 * time.Second):
+		t.Fatal("the sfn does not receive the data")
+	}
+}
+
+func TestControlStream(t *testing.T) {
+	t.Parallel()
+
+	const controlAddr = "127.0.0.1:19993"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	go server.ListenAndServe(context.TODO(), controlAddr)
+	defer server.Close()
+
+	source := NewClient("source", controlAddr, ClientTypeSource,
+		WithLogger(discardingLogger), WithHeartbeat(50*time.Millisecond), WithControlStream())
+
+	err := source.Connect(context.TODO())
+	assert.NoError(t, err)
+	defer source.Close()
+
+	// the heartbeat is served on the control stream.
+	assert.Eventually(t, func() bool { return source.RTT() > 0 }, time.Second, 10*time.Millisecond)
+}
+
+type batchConn struct {
+	frame.Conn
+	mu      sync.Mutex
+	batches [][]frame.Frame
+}
+
+func (c *batchConn) WriteFrame(f frame.Frame) error { return c.WriteFrames(f) }
+
+func (c *batchConn) WriteFrames(fs ...frame.Frame) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batches = append(c.batches, fs)
+	return nil
+}
+
+func TestWriteCoalescing(t *testing.T) {
+	client := NewClient("source", testaddr, ClientTypeSource, WithWriteBufferSize(10), WithWriteCoalescing(time.Hour, 10))
+
+	for i := 0; i < 7; i++ {
+		assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("ab")}))
+	}
+
+	conn := &batchConn{}
+
+	// the first batch is written once it exceeds max bytes.
+	assert.NoError(t, client.writeFrames(conn, <-client.wrCh, nil))
+	assert.Len(t, conn.batches, 1)
+	assert.Len(t, conn.batches[0], 5)
+
+	// the second batch is written once max delay elapsed.
+	client.opts.coalesceDelay = 10 * time.Millisecond
+	assert.NoError(t, client.writeFrames(conn, <-client.wrCh, nil))
+	assert.Len(t, conn.batches, 2)
+	assert.Len(t, conn.batches[1], 2)
+
+	// the batch is written with the PingFrame once the heartbeat ticks.
+	client.opts.coalesceDelay = time.Hour
+	assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("ab")}))
+	heartbeat := make(chan time.Time, 1)
+	heartbeat <- time.Now()
+	assert.NoError(t, client.writeFrames(conn, <-client.wrCh, heartbeat))
+	assert.Len(t, conn.batches, 3)
+	assert.Len(t, conn.batches[2], 2)
+	assert.Equal(t, frame.TypePingFrame, conn.batches[2][1].Type())
+
+	// the batch is written at once if the client is closed.
+	assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("ab")}))
+	f := <-client.wrCh
+	client.ctxCancel(nil)
+	assert.NoError(t, client.writeFrames(conn, f, nil))
+	assert.Len(t, conn.batches, 4)
+}
+
+func TestDialer(t *testing.T) {
+	dialErr := errors.New("mock dial error")
+
+	var dialed string
+	dialer := func(ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config) (quic.Connection, error) {
+		dialed = addr
+		return nil, dialErr
+	}
+
+	client := NewClient("source", "a:9000", ClientTypeSource, WithLogger(discardingLogger), WithDialer(dialer))
+
+	err := client.Connect(context.TODO())
+	assert.ErrorIs(t, err, dialErr)
+	assert.Equal(t, "a:9000", dialed)
+}
+
+// stalledConn is a frame.Conn whose WriteFrame blocks until it is closed.
+type stalledConn struct {
+	frame.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newStalledConn() *stalledConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &stalledConn{ctx: ctx, cancel: cancel}
+}
+
+func (c *stalledConn) ReadFrame() (frame.Frame, error) {
+	<-c.ctx.Done()
+	return nil, frame.NewErrConnClosed(false, "closed")
+}
+
+func (c *stalledConn) WriteFrame(frame.Frame) error {
+	<-c.ctx.Done()
+	return frame.NewErrConnClosed(false, "closed")
+}
+
+func (c *stalledConn) CloseWithError(string) error {
+	c.cancel()
+	return nil
+}
+
+func (c *stalledConn) Err() error { return c.ctx.Err() }
+
+func TestClose(t *testing.T) {
+	t.Run("abandon", func(t *testing.T) {
+		client := NewClient("source", testaddr, ClientTypeSource, WithLogger(discardingLogger), WithWriteBufferSize(10))
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: 1}))
+		}
+
+		err := client.Close()
+		e := new(ErrFramesAbandoned)
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, 3, e.Count)
+
+		// close twice has no effect.
+		assert.NoError(t, client.Close())
+	})
+
+	t.Run("drain timeout", func(t *testing.T) {
+		client := NewClient(
+			"source", testaddr, ClientTypeSource,
+			WithLogger(discardingLogger), WithWriteBufferSize(10), WithDrainTimeout(50*time.Millisecond),
+		)
+		go client.runBackground(newStalledConn())
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: 1}))
+		}
+		assert.Eventually(t, func() bool {
+			h, ok := client.conn.Load().(connHolder)
+			return ok && h.Conn != nil
+		}, time.Second, time.Millisecond)
+
+		start := time.Now()
+		err := client.Close()
+		assert.ErrorIs(t, err, ErrDrainTimeout)
+		assert.Less(t, time.Since(start), time.Second)
+
+		// the client is not stuck in writing to the stalled peer.
+		client.Wait()
+	})
+}
+
+func TestClientWrite(t *testing.T) {
+	client := NewClient("source", testaddr, ClientTypeSource)
+	_, err := client.Write([]byte("yomo"))
+	assert.Equal(t, ErrNoWriterTag, err)
+
+	client = NewClient("source", testaddr, ClientTypeSource, WithWriteBufferSize(1), WithWriterTag(0x33))
+
+	p := []byte("hello yomo")
+	n, err := client.Write(p)
+	assert.NoError(t, err)
+	assert.Equal(t, len(p), n)
+
+	// the payload is not retained.
+	p[0] = 'H'
+
+	df := (<-client.wrCh).(*frame.DataFrame)
+	assert.Equal(t, frame.Tag(0x33), df.Tag)
+	assert.Equal(t, []byte("hello yomo"), df.Payload)
+
+	md, err := metadata.Decode(df.Metadata)
+	assert.NoError(t, err)
+	assert.Equal(t, client.ClientID(), GetSourceIDFromMetadata(md))
+}
+
+// recordConn is a frame.Conn that records the written frames.
+type recordConn struct {
+	*stalledConn
+	mu     sync.Mutex
+	frames []frame.Frame
+}
+
+func (c *recordConn) WriteFrame(f frame.Frame) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frames = append(c.frames, f)
+	return nil
+}
+
+func (c *recordConn) priorities() []frame.Priority {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make([]frame.Priority, len(c.frames))
+	for i, f := range c.frames {
+		result[i] = f.(*frame.DataFrame).Priority
+	}
+	return result
+}
+
+func TestWritePriority(t *testing.T) {
+	client := NewClient("source", testaddr, ClientTypeSource, WithLogger(discardingLogger), WithWriteBufferSize(10))
+
+	for _, p := range []frame.Priority{frame.PriorityLow, frame.PriorityNormal, frame.PriorityHigh} {
+		assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: 1, Priority: p}))
+	}
+
+	conn := &recordConn{stalledConn: newStalledConn()}
+	go client.runBackground(conn)
+
+	want := []frame.Priority{frame.PriorityHigh, frame.PriorityNormal, frame.PriorityLow}
+	assert.Eventually(t, func() bool { return len(conn.priorities()) == 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, want, conn.priorities())
+
+	assert.NoError(t, client.Close())
+}
+
+// stalledDeadlineConn is a stalledConn that supports write deadline.
+type stalledDeadlineConn struct {
+	*stalledConn
+	deadline time.Time
+}
+
+func (c *stalledDeadlineConn) SetWriteDeadline(t time.Time) error {
+	c.deadline = t
+	return nil
+}
+
+func (c *stalledDeadlineConn) WriteFrame(frame.Frame) error {
+	select {
+	case <-time.After(time.Until(c.deadline)):
+		return os.ErrDeadlineExceeded
+	case <-c.ctx.Done():
+		return frame.NewErrConnClosed(false, "closed")
+	}
+}
+
+func TestStreamWriteTimeout(t *testing.T) {
+	for name, conn := range map[string]interface {
+		frame.Conn
+		Err() error
+	}{
+		"close on timeout": newStalledConn(),
+		"write deadline":   &stalledDeadlineConn{stalledConn: newStalledConn()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := NewClient(
+				"source", testaddr, ClientTypeSource,
+				WithLogger(discardingLogger), WithWriteBufferSize(1), WithStreamWriteTimeout(50*time.Millisecond),
+			)
+			var handled error
+			client.SetErrorHandler(func(err error) { handled = err })
+
+			assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: 1}))
+
+			// the client is not closed, it reconnects to zipper.
+			assert.False(t, client.handleConn(conn))
+
+			e := new(ErrWriteTimeout)
+			assert.True(t, errors.As(handled, &e))
+			assert.ErrorIs(t, handled, os.ErrDeadlineExceeded)
+			// the stalled connection is closed.
+			assert.Error(t, conn.Err())
+		})
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	const idleAddr = "127.0.0.1:19988"
+
+	// the zipper acks the handshake, then never replies, like a silently dead path.
+	listener, err := yquic.ListenAddr(idleAddr, y3codec.Codec(), y3codec.PacketReadWriter(),
+		pkgtls.MustCreateServerTLSConfig(idleAddr), DefaultQuicConfig)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	pings := make(chan struct{}, 10)
+	go func() {
+		for {
+			fconn, err := listener.Accept(context.TODO())
+			if err != nil {
+				return
+			}
+			go func() {
+				for {
+					f, err := fconn.ReadFrame()
+					if err != nil {
+						return
+					}
+					switch f.(type) {
+					case *frame.HandshakeFrame:
+						_ = fconn.WriteFrame(&frame.HandshakeAckFrame{})
+					case *frame.PingFrame:
+						pings <- struct{}{}
+					}
+				}
+			}()
+		}
+	}()
+
+	recorder := &stateRecorder{}
+	errs := make(chan error, 10)
+	client := NewClient("source", idleAddr, ClientTypeSource,
+		WithLogger(discardingLogger),
+		WithIdleTimeout(100*time.Millisecond),
+		WithStateHandler(recorder.handle),
+	)
+	client.SetErrorHandler(func(err error) { errs <- err })
+	assert.NoError(t, client.Connect(context.TODO()))
+	defer client.Close()
+
+	select {
+	case <-pings:
+	case <-time.After(time.Second):
+		t.Fatal("the client does not ping the idle connection")
+	}
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, ErrIdleTimeout)
+	case <-time.After(time.Second):
+		t.Fatal("the client does not drop the idle connection")
+	}
+
+	assert.Eventually(t, func() bool {
+		return client.State() == StateConnected && client.StateTransitions()[StateReconnecting] >= 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		err  error
+		code yerr.Code
+	}{
+		{ErrAuthExpired, yerr.CodeAuth},
+		{&ErrRejected{Reason: ReasonServerDraining}, yerr.CodeRouting},
+		{DefaultVersionNegotiateFunc("1", "2"), yerr.CodeProtocol},
+		{&ErrConnectTo{Endpoint: "zipper:9000"}, yerr.CodeRouting},
+		{&ErrWriteTimeout{Cause: context.Canceled}, yerr.CodeOverload},
+		{&ErrRateLimited{}, yerr.CodeOverload},
+		{frame.NewErrConnClosed(true, "bye"), yerr.CodeClosed},
+		{&ConnectError{Phase: PhaseDial, Err: io.EOF}, yerr.CodeNetwork},
+		{&ConnectError{Phase: PhaseHandshake, Err: ErrAuthenticateFailed}, yerr.CodeAuth},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, yerr.CodeOf(tt.err), tt.err.Error())
+	}
+
+	client := NewClient("source", testaddr, ClientTypeSource, WithLogger(discardingLogger))
+	var reported []error
+	client.SetErrorHandler(func(err error) { reported = append(reported, err) })
+
+	client.reportError(ErrIdleTimeout)
+	client.reportError(&ErrRejected{Reason: ReasonAuthExpired})
+	client.reportError(ErrIdleTimeout)
+	client.reportError(errors.New("unknown"))
+
+	assert.Len(t, reported, 4)
+	assert.Equal(t, map[yerr.Code]int64{yerr.CodeNetwork: 2, yerr.CodeAuth: 1, yerr.CodeUnknown: 1}, client.ErrorCounts())
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"golang.org/x/exp/slices"
+)
+
+// CompressionGzip is the gzip payload compression algorithm.
+const CompressionGzip = contentEncodingGzip
+
+// DefaultCompressMinSize is the default payload size threshold of compression,
+// the payloads smaller than it are not compressed.
+const DefaultCompressMinSize = 512
+
+// supportedCompressions is the payload compression algorithms supported, in order of preference.
+var supportedCompressions = []string{CompressionGzip}
+
+// negotiateCompression returns the first compression algorithm offered by the client that
+// is supported, it returns empty string if there is no one supported.
+func negotiateCompression(offered []string) string {
+	for _, c := range offered {
+		if slices.Contains(supportedCompressions, c) {
+			return c
+		}
+	}
+	return ""
+}
+
+// protocolVersionFrameCompression is the protocol version since which the compression of the payload
+// is flagged in the DataFrame, the older peers find it in the metadata.
+const protocolVersionFrameCompression uint32 = 2
+
+// compressDataFrame returns a copy of the DataFrame whose payload is gzip compressed, it returns
+// the DataFrame itself if the payload is smaller than minSize, has been encoded or does not shrink.
+// The compression is flagged in the DataFrame if flagged is true, otherwise in the metadata.
+func compressDataFrame(df *frame.DataFrame, minSize int, flagged bool) *frame.DataFrame {
+	if len(df.Payload) < minSize || df.Compression != frame.CompressionNone {
+		return df
+	}
+	var md metadata.M
+	if !flagged {
+		var err error
+		if md, err = metadata.Decode(df.Metadata); err != nil {
+			return df
+		}
+		if _, ok := md.Get(MetadataContentEncodingKey); ok {
+			return df
+		}
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(df.Payload); err != nil {
+		return df
+	}
+	if err := zw.Close(); err != nil {
+		return df
+	}
+	if buf.Len() >= len(df.Payload) {
+		return df
+	}
+
+	compressed := &frame.DataFrame{
+		Tag:        df.Tag,
+		Metadata:   df.Metadata,
+		Payload:    buf.Bytes(),
+		Priority:   df.Priority,
+		Channel:    df.Channel,
+		Chunk:      df.Chunk,
+		AckID:      df.AckID,
+		Extensions: df.Extensions,
+	}
+	if flagged {
+		compressed.Compression = frame.CompressionGzip
+		return compressed
+	}
+
+	md.Set(MetadataContentEncodingKey, contentEncodingGzip)
+	mdBytes, err := md.Encode()
+	if err != nil {
+		return df
+	}
+	compressed.Metadata = mdBytes
+	return compressed
+}
+
+// decompressDataFrame decompresses the payload of the DataFrame by the algorithm flagged in it.
+func decompressDataFrame(df *frame.DataFrame) error {
+	switch df.Compression {
+	case frame.CompressionNone:
+		return nil
+	case frame.CompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(df.Payload))
+		if err != nil {
+			return err
+		}
+		payload, err := io.ReadAll(zr)
+		if err != nil {
+			return err
+		}
+		df.Payload = payload
+		df.Compression = frame.CompressionNone
+		return nil
+	default:
+		return fmt.Errorf("yomo: unsupported compression of data frame: %d", df.Compression)
+	}
+}
+
+// compressConn compresses the payload of the DataFrames written to the conn, and decompresses
+// the payload of the DataFrames read from the conn, the compression is transparent to the
+// reader and the writer.
+type compressConn struct {
+	frame.Conn
+	minSize int
+	flagged bool // the compression is flagged in the DataFrame rather than the metadata
+}
+
+// newCompressConn returns a conn that compresses the payloads with the negotiated compression,
+// it returns the conn itself if no compression is negotiated. The compression is flagged in the
+// DataFrame if the negotiated protocol version supports it.
+func newCompressConn(conn frame.Conn, compression string, minSize int, protocolVersion uint32) frame.Conn {
+	if compression == "" {
+		return conn
+	}
+	return &compressConn{
+		Conn:    conn,
+		minSize: minSize,
+		flagged: protocolVersion >= protocolVersionFrameCompression,
+	}
+}
+
+// ReadFrame reads a frame and decompresses the payload if it is a compressed DataFrame.
+func (c *compressConn) ReadFrame() (frame.Frame, error) {
+	f, err := c.Conn.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+	df, ok := f.(*frame.DataFrame)
+	if !ok {
+		return f, nil
+	}
+	if df.Compression != frame.CompressionNone {
+		if err := decompressDataFrame(df); err != nil {
+			return nil, err
+		}
+		return df, nil
+	}
+	md, err := metadata.Decode(df.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := md.Get(MetadataContentEncodingKey); !ok {
+		return df, nil
+	}
+	if err := decodeContentEncoding(df, md); err != nil {
+		return nil, err
+	}
+	if df.Metadata, err = md.Encode(); err != nil {
+		return nil, err
+	}
+	return df, nil
+}
+
+// WriteFrame compresses the payload if the frame is a DataFrame and writes it.
+func (c *compressConn) WriteFrame(f frame.Frame) error {
+	return c.Conn.WriteFrame(c.compress(f))
+}
+
+// WriteFrames compresses the payloads of the DataFrames and writes them.
+func (c *compressConn) WriteFrames(fs ...frame.Frame) error {
+	compressed := make([]frame.Frame, len(fs))
+	for i, f := range fs {
+		compressed[i] = c.compress(f)
+	}
+	if bw, ok := c.Conn.(frame.BatchWriter); ok {
+		return bw.WriteFrames(compressed...)
+	}
+	for _, f := range compressed {
+		if err := c.Conn.WriteFrame(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *compressConn) compress(f frame.Frame) frame.Frame {
+	if df, ok := f.(*frame.DataFrame); ok {
+		return compressDataFrame(df, c.minSize, c.flagged)
+	}
+	return f
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
+)
+
+func TestNegotiateCompression(t *testing.T) {
+	assert.Equal(t, CompressionGzip, negotiateCompression([]string{"zstd", "gzip"}))
+	assert.Equal(t, "", negotiateCompression([]string{"zstd"}))
+	assert.Equal(t, "", negotiateCompression(nil))
+}
+
+// loopConn is a frame.Conn that reads the frames written to it.
+type loopConn struct {
+	frame.Conn
+	frames chan frame.Frame
+}
+
+func (c *loopConn) WriteFrame(f frame.Frame) error {
+	c.frames <- f
+	return nil
+}
+
+func (c *loopConn) ReadFrame() (frame.Frame, error) {
+	return <-c.frames, nil
+}
+
+func TestCompressConn(t *testing.T) {
+	assert.IsType(t, &loopConn{}, newCompressConn(&loopConn{}, "", 16, ProtocolVersion))
+
+	raw := &loopConn{frames: make(chan frame.Frame, 10)}
+	conn := newCompressConn(raw, CompressionGzip, 16, 1)
+
+	md, _ := metadata.M{"foo": "bar"}.Encode()
+	large := bytes.Repeat([]byte("yomo"), 100)
+
+	assert.NoError(t, conn.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: large}))
+	assert.NoError(t, conn.WriteFrame(&frame.DataFrame{Tag: 2, Metadata: md, Payload: []byte("small")}))
+
+	// the large payload is compressed on the wire, the small one is not.
+	wire := (<-raw.frames).(*frame.DataFrame)
+	assert.Less(t, len(wire.Payload), len(large))
+	wmd, err := metadata.Decode(wire.Metadata)
+	assert.NoError(t, err)
+	assert.Equal(t, metadata.M{"foo": "bar", MetadataContentEncodingKey: CompressionGzip}, wmd)
+
+	small := <-raw.frames
+	assert.Equal(t, []byte("small"), small.(*frame.DataFrame).Payload)
+
+	// the reader gets the original payload and metadata.
+	raw.frames <- wire
+	f, err := conn.ReadFrame()
+	assert.NoError(t, err)
+	assert.Equal(t, large, f.(*frame.DataFrame).Payload)
+	rmd, err := metadata.Decode(f.(*frame.DataFrame).Metadata)
+	assert.NoError(t, err)
+	assert.Equal(t, metadata.M{"foo": "bar"}, rmd)
+}
+
+func TestCompressConnFlagged(t *testing.T) {
+	raw := &loopConn{frames: make(chan frame.Frame, 10)}
+	conn := newCompressConn(raw, CompressionGzip, 16, protocolVersionFrameCompression)
+
+	md, _ := metadata.M{"foo": "bar"}.Encode()
+	large := bytes.Repeat([]byte("yomo"), 100)
+
+	// the compression is flagged in the frame, the metadata is written as is.
+	assert.NoError(t, conn.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: large}))
+	wire := (<-raw.frames).(*frame.DataFrame)
+	assert.Less(t, len(wire.Payload), len(large))
+	assert.Equal(t, frame.CompressionGzip, wire.Compression)
+	assert.Equal(t, md, wire.Metadata)
+
+	raw.frames <- wire
+	f, err := conn.ReadFrame()
+	assert.NoError(t, err)
+	assert.Equal(t, large, f.(*frame.DataFrame).Payload)
+	assert.Equal(t, frame.CompressionNone, f.(*frame.DataFrame).Compression)
+
+	// the unknown algorithm fails the reading.
+	raw.frames <- &frame.DataFrame{Tag: 1, Payload: []byte("yomo"), Compression: 0xff}
+	_, err = conn.ReadFrame()
+	assert.Error(t, err)
+}
+
+func TestCompression(t *testing.T) {
+	t.Parallel()
+
+	const compressionAddr = "127.0.0.1:19994"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger), WithServerCompression(16))
+	server.ConfigRouter(router.Default())
+	server.ConfigVersionNegotiateFunc(DefaultVersionNegotiateFunc)
+	go server.ListenAndServe(context.TODO(), compressionAddr)
+	defer server.Close()
+
+	received := make(chan []byte, 1)
+	sfn := NewClient("sfn", compressionAddr, ClientTypeStreamFunction, WithLogger(discardingLogger), WithClientCompression(16))
+	sfn.SetObserveDataTags(0x21)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df.Payload })
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+	assert.Equal(t, CompressionGzip, sfn.compression)
+
+	source := NewClient("source", compressionAddr, ClientTypeSource, WithLogger(discardingLogger), WithClientCompression(16))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+	assert.Equal(t, CompressionGzip, source.compression)
+
+	// the client without compression option is not compressed.
+	plain := NewClient("plain", compressionAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.NoError(t, plain.Connect(context.TODO()))
+	defer plain.Close()
+	assert.Equal(t, "", plain.compression)
+
+	payload := bytes.Repeat([]byte(`{"temperature":21.5}`), 50)
+	md, _ := metadata.M{}.Encode()
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 0x21, Metadata: md, Payload: payload}))
+
+	select {
+	case got := <-received:
+		assert.Equal(t, payload, got)
+	case <-time.After(3 * time.Second):
+		t.Fatal("the sfn does not receive the data")
+	}
+}
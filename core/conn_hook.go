@@ -0,0 +1,36 @@
+package core
+
+// ConnectHook is called after a client passes the handshake, e.g. to keep an inventory of the clients
+// or to emit an audit event. The client is rejected if it returns an error, the reason of an ErrRejected
+// is sent to the client, e.g. ReasonDuplicateClient for the duplicated client names.
+// It is the shorthand of a Plugin that only implements OnConnection, see WithConnectHook.
+type ConnectHook func(conn ConnectionInfo) error
+
+// DisconnectHook is called after the connection of a client accepted by the plugins and the ConnectHooks
+// is closed and removed from the server.
+type DisconnectHook func(conn ConnectionInfo)
+
+// connHooks are the connection event hooks of the server, the ConnectHooks are registered as plugins.
+type connHooks struct {
+	disconnect []DisconnectHook
+}
+
+// onDisconnect calls the DisconnectHooks in order.
+func (h connHooks) onDisconnect(conn ConnectionInfo) {
+	for _, hook := range h.disconnect {
+		hook(conn)
+	}
+}
+
+var _ Plugin = connectHookPlugin{}
+
+// connectHookPlugin is the Plugin of a ConnectHook, so the clients are rejected in a single path.
+type connectHookPlugin struct {
+	hook ConnectHook
+}
+
+func (p connectHookPlugin) Name() string                        { return "connect_hook" }
+func (p connectHookPlugin) OnStart(*Server) error               { return nil }
+func (p connectHookPlugin) OnConnection(conn *Connection) error { return p.hook(conn) }
+func (p connectHookPlugin) OnFrame(*Context) error              { return nil }
+func (p connectHookPlugin) OnClose(*Server)                     {}
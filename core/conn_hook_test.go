@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnHooks(t *testing.T) {
+	t.Parallel()
+
+	const hookAddr = "127.0.0.1:19963"
+
+	// the inventory denies the duplicated client names.
+	var (
+		mu        sync.Mutex
+		inventory = map[string]ClientType{}
+	)
+	connectHook := func(conn ConnectionInfo) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := inventory[conn.Name()]; ok {
+			return &ErrRejected{Message: "duplicated client name " + conn.Name(), Reason: ReasonDuplicateClient}
+		}
+		inventory[conn.Name()] = conn.ClientType()
+		return nil
+	}
+	disconnectHook := func(conn ConnectionInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(inventory, conn.Name())
+	}
+	registered := func(name string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		_, ok := inventory[name]
+		return ok
+	}
+
+	server := NewServer("zipper",
+		WithServerLogger(discardingLogger),
+		WithConnectHook(connectHook),
+		WithDisconnectHook(disconnectHook),
+	)
+	go server.ListenAndServe(context.TODO(), hookAddr)
+	defer server.Close()
+
+	source := NewClient("source", hookAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.NoError(t, source.Connect(context.TODO()))
+	assert.True(t, registered("source"))
+
+	duplicated := NewClient("source", hookAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.ErrorIs(t, duplicated.Connect(context.TODO()), ErrDuplicateClient)
+
+	assert.NoError(t, source.Close())
+	assert.Eventually(t, func() bool { return !registered("source") }, 3*time.Second, 10*time.Millisecond)
+
+	source = NewClient("source", hookAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+	assert.True(t, registered("source"))
+}
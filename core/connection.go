@@ -1,8 +1,12 @@
 package core
 
 import (
+	"sync"
+	"sync/atomic"
+
 	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/metadata"
+	"golang.org/x/exp/slices"
 	"golang.org/x/exp/slog"
 )
 
@@ -27,8 +31,19 @@ type Connection struct {
 	id              string
 	clientType      ClientType
 	metadata        metadata.M
+	mu              sync.RWMutex
 	observeDataTags []uint32
+	schemaVersions  map[frame.Tag][]string
+	handshakeExts   map[string][]byte
+	protocolVersion uint32
 	fconn           frame.Conn
+	resources       resourceTracker
+	departing       atomic.Bool
+	outstanding     atomic.Int64 // the frames being written to the connection
+	pings           pinger
+	quota           *quotaLimiter  // nil if the client is unlimited
+	consumer        *consumerQueue // nil if the frames are written to the stream function directly
+	errorFrames     *rateLimiter   // limits the ErrorFrames written to the connection
 	Logger          *slog.Logger
 }
 
@@ -46,6 +61,7 @@ func newConnection(
 		metadata:        md,
 		observeDataTags: tags,
 		fconn:           fconn,
+		errorFrames:     newRateLimiter(errorFramesPerSecond, errorFramesPerSecond),
 		Logger:          logger,
 	}
 }
@@ -65,11 +81,66 @@ func (c *Connection) Name() string {
 	return c.name
 }
 
+// SchemaVersions returns the payload schema versions supported by the connection per data tag.
+func (c *Connection) SchemaVersions() map[frame.Tag][]string {
+	return c.schemaVersions
+}
+
+// HandshakeExtensions returns the extensions piggybacked on the handshake of the connection.
+func (c *Connection) HandshakeExtensions() map[string][]byte {
+	return c.handshakeExts
+}
+
+// ProtocolVersion returns the protocol version negotiated with the client.
+func (c *Connection) ProtocolVersion() uint32 {
+	return c.protocolVersion
+}
+
+// Departing reports whether the client has said goodbye, the connection is closing
+// and no frame is routed to it anymore.
+func (c *Connection) Departing() bool {
+	return c.departing.Load()
+}
+
+// Outstanding returns the number of the frames being written to the connection, the writing blocks
+// if the client consumes slowly, see DispatchLeastOutstanding.
+func (c *Connection) Outstanding() int64 {
+	return c.outstanding.Load()
+}
+
 // ObserveDataTags returns the observed data tags.
 func (c *Connection) ObserveDataTags() []uint32 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.observeDataTags
+}
+
+// updateObserveDataTags applies the ObserveUpdateFrame to the observed data tags and returns the result.
+func (c *Connection) updateObserveDataTags(f *frame.ObserveUpdateFrame) []uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.observeDataTags = updateTags(c.observeDataTags, f.Subscribe, f.Unsubscribe)
 	return c.observeDataTags
 }
 
+// updateTags returns a new tag list that removes the unsubscribe tags and adds the subscribe tags.
+func updateTags(tags, subscribe, unsubscribe []frame.Tag) []frame.Tag {
+	result := make([]frame.Tag, 0, len(tags)+len(subscribe))
+	for _, tag := range tags {
+		if !slices.Contains(unsubscribe, tag) {
+			result = append(result, tag)
+		}
+	}
+	for _, tag := range subscribe {
+		if !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
 func (c *Connection) ClientType() ClientType {
 	return c.clientType
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/metadata"
 	"github.com/yomorun/yomo/core/ylog"
 )
@@ -26,6 +27,12 @@ func TestConnection(t *testing.T) {
 		assert.Equal(t, md, connection.Metadata())
 		assert.Equal(t, observed, connection.ObserveDataTags())
 	})
+
+	t.Run("UpdateObserveDataTags", func(t *testing.T) {
+		tags := connection.updateObserveDataTags(&frame.ObserveUpdateFrame{Subscribe: []uint32{3, 4}, Unsubscribe: []uint32{1}})
+		assert.Equal(t, []uint32{2, 3, 4}, tags)
+		assert.Equal(t, tags, connection.ObserveDataTags())
+	})
 }
 
 func TestClientTypeString(t *testing.T) {
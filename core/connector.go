@@ -2,12 +2,13 @@ package core
 
 import (
 	"context"
-	"errors"
 	"sync"
+
+	"github.com/yomorun/yomo/core/yerr"
 )
 
 // ErrConnectorClosed will be returned if the Connector has been closed.
-var ErrConnectorClosed = errors.New("yomo: connector closed")
+var ErrConnectorClosed = yerr.New(yerr.CodeClosed, "yomo: connector closed")
 
 // Connector manages connections and provides a centralized way for getting and setting streams.
 type Connector struct {
@@ -17,6 +18,12 @@ type Connector struct {
 
 	// connections stores data connections.
 	connections sync.Map
+
+	// mu protects the index.
+	mu sync.RWMutex
+	// clients indexes the connections by the client id, a client may have several connections
+	// while it is reconnecting, see clientIDOf.
+	clients map[string]map[string]*Connection
 }
 
 // NewConnector returns an initial Connector.
@@ -26,6 +33,7 @@ func NewConnector(ctx context.Context) *Connector {
 	return &Connector{
 		ctx:       ctx,
 		ctxCancel: ctxCancel,
+		clients:   make(map[string]map[string]*Connection),
 	}
 }
 
@@ -39,11 +47,37 @@ func (c *Connector) Store(connID string, conn *Connection) error {
 	default:
 	}
 
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.connections.Store(connID, conn)
+	c.index(connID, conn)
 
 	return nil
 }
 
+// LoadOrStore stores the connection to the Connector if no connection has the connID,
+// otherwise it returns the stored connection and true.
+// If Connector be closed, The function will return ErrConnectorClosed.
+func (c *Connector) LoadOrStore(connID string, conn *Connection) (*Connection, bool, error) {
+	select {
+	case <-c.ctx.Done():
+		return nil, false, ErrConnectorClosed
+	default:
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if v, ok := c.connections.Load(connID); ok {
+		return v.(*Connection), true, nil
+	}
+	c.connections.Store(connID, conn)
+	c.index(connID, conn)
+
+	return conn, false, nil
+}
+
 // Remove removes the connection with the specified connID.
 // If the Connector does not have a connection with the given connID, no action is taken.
 // If Connector be closed, The function will return ErrConnectorClosed.
@@ -54,7 +88,11 @@ func (c *Connector) Remove(connID string) error {
 	default:
 	}
 
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.connections.Delete(connID)
+	c.unindex(connID)
 
 	return nil
 }
@@ -77,6 +115,45 @@ func (c *Connector) Get(connID string) (*Connection, bool, error) {
 	return v.(*Connection), true, nil
 }
 
+// ClientConnections returns the connections of the client, see clientIDOf.
+// If Connector be closed, The function will return ErrConnectorClosed.
+func (c *Connector) ClientConnections(clientID string) ([]*Connection, error) {
+	select {
+	case <-c.ctx.Done():
+		return []*Connection{}, ErrConnectorClosed
+	default:
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	conns := make([]*Connection, 0, len(c.clients[clientID]))
+	for _, conn := range c.clients[clientID] {
+		conns = append(conns, conn)
+	}
+	return conns, nil
+}
+
+// index adds the connection to the index, it must be called with mu held.
+func (c *Connector) index(connID string, conn *Connection) {
+	clientID := clientIDOf(connID)
+	conns := c.clients[clientID]
+	if conns == nil {
+		conns = make(map[string]*Connection)
+		c.clients[clientID] = conns
+	}
+	conns[connID] = conn
+}
+
+// unindex removes the connection from the index, it must be called with mu held.
+func (c *Connector) unindex(connID string) {
+	clientID := clientIDOf(connID)
+	delete(c.clients[clientID], connID)
+	if len(c.clients[clientID]) == 0 {
+		delete(c.clients, clientID)
+	}
+}
+
 // FindConnectionFunc is used to search for a specific connection within the Connector.
 type FindConnectionFunc func(ConnectionInfo) bool
 
@@ -132,10 +209,14 @@ func (c *Connector) Close() error {
 
 	c.ctxCancel()
 
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.connections.Range(func(key, value any) bool {
 		c.connections.Delete(key)
 		return true
 	})
+	c.clients = make(map[string]map[string]*Connection)
 
 	return nil
 }
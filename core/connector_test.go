@@ -43,6 +43,23 @@ func TestConnector(t *testing.T) {
 		assert.False(t, ok)
 	})
 
+	t.Run("LoadOrStore", func(t *testing.T) {
+		conn1 := mockConn(connID, "name-1")
+		actual, loaded, err := connector.LoadOrStore(connID, conn1)
+		assert.NoError(t, err)
+		assert.False(t, loaded)
+		assert.Equal(t, conn1, actual)
+
+		// the stored connection is kept.
+		conn2 := mockConn(connID, "name-2")
+		actual, loaded, err = connector.LoadOrStore(connID, conn2)
+		assert.NoError(t, err)
+		assert.True(t, loaded)
+		assert.Equal(t, conn1, actual)
+
+		assert.NoError(t, connector.Remove(connID))
+	})
+
 	t.Run("Find", func(t *testing.T) {
 		conn1 := mockConn(connID, "name-1")
 		err := connector.Store(connID, conn1)
@@ -75,6 +92,27 @@ func TestConnector(t *testing.T) {
 		assert.Equal(t, map[string]string{"id-1": "name-1", "id-2": "name-2"}, got)
 	})
 
+	t.Run("ClientConnections", func(t *testing.T) {
+		connector := NewConnector(context.Background())
+
+		// the client is reconnecting, its old connection is not removed yet.
+		old := newConnection("source", "source-id-1", ClientTypeSource, nil, nil, nil, ylog.Default())
+		conn := newConnection("source", "source-id-2", ClientTypeSource, nil, nil, nil, ylog.Default())
+		other := newConnection("source", "other-1", ClientTypeSource, nil, nil, nil, ylog.Default())
+		for _, c := range []*Connection{old, conn, other} {
+			assert.NoError(t, connector.Store(c.ID(), c))
+		}
+
+		conns, err := connector.ClientConnections("source-id")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*Connection{old, conn}, conns)
+
+		assert.NoError(t, connector.Remove(old.ID()))
+		conns, err = connector.ClientConnections("source-id")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*Connection{conn}, conns)
+	})
+
 	t.Run("Close", func(t *testing.T) {
 		connector := NewConnector(context.Background())
 
@@ -27,6 +27,10 @@ type Context struct {
 	Keys map[string]any
 	// Using Logger to log in connection handler scope, Logger is frame-level logger.
 	Logger *slog.Logger
+	// unacked is true if the frame is dropped by zipper before routing, so it is not acknowledged.
+	unacked bool
+	// routed is true if the frame passes the checks of zipper and is routed.
+	routed bool
 }
 
 // Set is used to store a new key/value pair exclusively for this context.
@@ -52,6 +56,10 @@ func (c *Context) Get(key string) (any, bool) {
 	return value, ok
 }
 
+// Routed reports whether the frame is routed by zipper, it is false if the frame is dropped,
+// e.g. it is expired or exceeds the quota. The middlewares read it after the next handler returns.
+func (c *Context) Routed() bool { return c.routed }
+
 var _ context.Context = &Context{}
 
 // Done returns nil (chan which will wait forever) when c.Connection.Context() has no Context.
@@ -91,6 +99,14 @@ func newContext(conn *Connection, df *frame.DataFrame) (c *Context, err error) {
 		return nil, err
 	}
 
+	// decode the payload compressed by the upstream zipper.
+	if err := decodeContentEncoding(df, fmd); err != nil {
+		return nil, err
+	}
+
+	// the tenant written by the client is replaced by the tenant of the connection.
+	stampTenant(conn, fmd)
+
 	// merge connection metadata.
 	conn.Metadata().Range(func(k, v string) bool {
 		fmd.Set(k, v)
@@ -139,7 +155,21 @@ func (c *Context) reset() {
 	c.Frame = nil
 	c.FrameMetadata = nil
 	c.Logger = nil
+	c.unacked = false
+	c.routed = false
 	for k := range c.Keys {
 		delete(c.Keys, k)
 	}
 }
+
+// stampTenant sets the tenant of the connection to the frame metadata, the tenant written by the client
+// is dropped, so the client cannot forge a tenant. The frames relayed by the upstream zippers keep their
+// tenants unless the mesh link itself is authenticated as a tenant.
+func stampTenant(conn *Connection, md metadata.M) {
+	if conn.ClientType() != ClientTypeUpstreamZipper {
+		delete(md, MetadataTenantIDKey)
+	}
+	if tenantID := GetTenantIDFromMetadata(conn.Metadata()); tenantID != "" {
+		md.Set(MetadataTenantIDKey, tenantID)
+	}
+}
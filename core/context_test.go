@@ -0,0 +1,38 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/ylog"
+)
+
+func TestContextTenant(t *testing.T) {
+	forged, err := metadata.M{MetadataTenantIDKey: "globex", MetadataTIDKey: "tid"}.Encode()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		clientType ClientType
+		md         metadata.M
+		want       string
+	}{
+		{"the tenant of the source is stamped", ClientTypeSource, metadata.M{MetadataTenantIDKey: "acme"}, "acme"},
+		{"the forged tenant is dropped", ClientTypeSource, metadata.M{}, ""},
+		{"the tenant of the sfn is stamped", ClientTypeStreamFunction, metadata.M{MetadataTenantIDKey: "acme"}, "acme"},
+		{"the relayed tenant is kept", ClientTypeUpstreamZipper, metadata.M{}, "globex"},
+		{"the tenant of the mesh link wins", ClientTypeUpstreamZipper, metadata.M{MetadataTenantIDKey: "acme"}, "acme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := newConnection("client", "client-id", tt.clientType, tt.md, nil, nil, ylog.Default())
+
+			c, err := newContext(conn, &frame.DataFrame{Tag: 1, Metadata: forged})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, GetTenantIDFromMetadata(c.FrameMetadata))
+			assert.Equal(t, "tid", GetTIDFromMetadata(c.FrameMetadata))
+		})
+	}
+}
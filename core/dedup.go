@@ -0,0 +1,69 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/pkg/id"
+)
+
+// MetadataFrameIDKey is the metadata key of the frame ID, the frame ID is stamped by the zipper
+// that dispatches the frame to downstreams first, and the receiving zippers deduplicate frames by it.
+const MetadataFrameIDKey = "yomo-frame-id"
+
+// GetFrameIDFromMetadata gets frame ID from metadata.
+func GetFrameIDFromMetadata(m metadata.M) string {
+	fid, _ := m.Get(MetadataFrameIDKey)
+	return fid
+}
+
+// frameDeduper remembers the frame IDs seen in the window.
+type frameDeduper struct {
+	window    time.Duration
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+func newFrameDeduper(window time.Duration) *frameDeduper {
+	return &frameDeduper{
+		window:    window,
+		seen:      make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// Seen reports whether the frame ID has been seen in the window, the frame ID is recorded if not.
+func (d *frameDeduper) Seen(fid string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.lastSweep) > d.window {
+		for k, t := range d.seen {
+			if now.Sub(t) > d.window {
+				delete(d.seen, k)
+			}
+		}
+		d.lastSweep = now
+	}
+
+	if t, ok := d.seen[fid]; ok && now.Sub(t) <= d.window {
+		return true
+	}
+	d.seen[fid] = now
+
+	return false
+}
+
+// stamp sets a new frame ID to the metadata if it does not have one or renew is true, and records the frame ID.
+// The frames written by the stream functions are renewed, they inherit the frame ID of the frames they handle
+// but they are new frames, and the zippers have seen the inherited one.
+func (d *frameDeduper) stamp(md metadata.M, renew bool) {
+	fid := GetFrameIDFromMetadata(md)
+	if fid == "" || renew {
+		fid = id.New()
+		md.Set(MetadataFrameIDKey, fid)
+	}
+	d.Seen(fid, time.Now())
+}
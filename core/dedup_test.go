@@ -0,0 +1,76 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/ylog"
+)
+
+func TestFrameDeduper(t *testing.T) {
+	d := newFrameDeduper(time.Minute)
+	now := time.Now()
+
+	assert.False(t, d.Seen("a", now))
+	assert.True(t, d.Seen("a", now.Add(time.Second)))
+	assert.False(t, d.Seen("b", now))
+
+	// expired after the window.
+	assert.False(t, d.Seen("a", now.Add(2*time.Minute)))
+	_, ok := d.seen["b"]
+	assert.False(t, ok)
+}
+
+func TestDispatchWithFrameDedup(t *testing.T) {
+	s := NewServer("zipper", WithFrameDedup(time.Minute))
+	ds := &recordDownstream{}
+	s.AddDownstreamServer(ds)
+
+	c := &Context{
+		Connection:    newConnection("upstream", "upstream-id", ClientTypeUpstreamZipper, metadata.M{}, nil, nil, ylog.Default()),
+		Frame:         &frame.DataFrame{Tag: 1, Payload: []byte("yomo")},
+		FrameMetadata: metadata.M{},
+		Logger:        ylog.Default(),
+	}
+
+	// the frames from upstream zippers are dispatched with a frame ID.
+	assert.NoError(t, s.dispatchToDownstreams(c))
+	assert.Equal(t, 1, ds.len())
+
+	md, err := metadata.Decode(ds.frames[0].Metadata)
+	assert.NoError(t, err)
+	fid := GetFrameIDFromMetadata(md)
+	assert.NotEmpty(t, fid)
+
+	// the frame comes back from another path.
+	assert.True(t, s.deduper.Seen(fid, time.Now()))
+}
+
+func TestDispatchSfnOutputWithFrameDedup(t *testing.T) {
+	s := NewServer("zipper", WithFrameDedup(time.Minute))
+	ds := &recordDownstream{}
+	s.AddDownstreamServer(ds)
+
+	// the zipper has dispatched the frame that the sfn handles.
+	s.deduper.Seen("fid", time.Now())
+
+	c := &Context{
+		Connection:    newConnection("sfn", "sfn-id", ClientTypeStreamFunction, metadata.M{}, nil, nil, ylog.Default()),
+		Frame:         &frame.DataFrame{Tag: 2, Payload: []byte("yomo")},
+		FrameMetadata: metadata.M{MetadataFrameIDKey: "fid"},
+		Logger:        ylog.Default(),
+	}
+
+	// the output of the sfn inherits the frame ID, it is dispatched with a new one.
+	assert.NoError(t, s.dispatchToDownstreams(c))
+	assert.Equal(t, 1, ds.len())
+
+	md, err := metadata.Decode(ds.frames[0].Metadata)
+	assert.NoError(t, err)
+	fid := GetFrameIDFromMetadata(md)
+	assert.NotEmpty(t, fid)
+	assert.NotEqual(t, "fid", fid)
+}
@@ -0,0 +1,238 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+// The default timeouts of connecting phases, they are tuned for the edge networks,
+// which have slow DNS servers and lossy links.
+const (
+	DefaultResolveTimeout   = 5 * time.Second
+	DefaultDialTimeout      = 15 * time.Second
+	DefaultHandshakeTimeout = 10 * time.Second
+)
+
+// ConnectPhase is the phase of connecting to zipper.
+type ConnectPhase string
+
+const (
+	// PhaseResolve resolves the zipper address.
+	PhaseResolve ConnectPhase = "resolve"
+	// PhaseDial establishes the QUIC connection.
+	PhaseDial ConnectPhase = "dial"
+	// PhaseHandshake does the yomo handshake and authentication.
+	PhaseHandshake ConnectPhase = "handshake"
+)
+
+// ConnectError is returned if connecting to zipper fails in a phase.
+type ConnectError struct {
+	// Phase is the phase in which the connecting fails.
+	Phase ConnectPhase
+	// Addr is the zipper address.
+	Addr string
+	// Err is the cause.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ConnectError) Error() string {
+	return fmt.Sprintf("yomo: %s %s: %v", e.Phase, e.Addr, e.Err)
+}
+
+// Unwrap returns the cause.
+func (e *ConnectError) Unwrap() error { return e.Err }
+
+// ErrorCode implements the yerr.Coder interface, it is the code of the cause, or CodeNetwork.
+func (e *ConnectError) ErrorCode() yerr.Code {
+	if code := yerr.CodeOf(e.Err); code != yerr.CodeUnknown {
+		return code
+	}
+	return yerr.CodeNetwork
+}
+
+// Timeout reports whether the phase is timeout.
+func (e *ConnectError) Timeout() bool {
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return true
+	}
+	ne, ok := e.Err.(net.Error)
+	return ok && ne.Timeout()
+}
+
+// withPhaseTimeout returns a context with the timeout of the phase, timeout <= 0 means no timeout.
+func withPhaseTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Resolver resolves the zipper address to the addresses to dial in order of preference, it allows
+// the clients to discover zippers from a service registry, e.g. the DNS SRV records in Kubernetes or Consul.
+type Resolver interface {
+	Resolve(ctx context.Context, addr string) ([]string, error)
+}
+
+// ResolverFunc is an adapter to allow the use of ordinary functions as Resolver.
+type ResolverFunc func(ctx context.Context, addr string) ([]string, error)
+
+// Resolve calls f(ctx, addr).
+func (f ResolverFunc) Resolve(ctx context.Context, addr string) ([]string, error) {
+	return f(ctx, addr)
+}
+
+// DNSResolver resolves the zipper address by DNS, this is the default Resolver.
+// The address is `host:port`, or a SRV name without port like `_yomo._udp.example.com`,
+// the targets of the SRV records are resolved in the order of priority and weight.
+type DNSResolver struct {
+	// DNS is the resolver to look up, net.DefaultResolver is used if it is nil.
+	DNS *net.Resolver
+}
+
+// Resolve implements the Resolver interface.
+func (r *DNSResolver) Resolve(ctx context.Context, addr string) ([]string, error) {
+	resolved, err := r.resolveNames(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, len(resolved))
+	for i, ra := range resolved {
+		addrs[i] = ra.addr
+	}
+	return addrs, nil
+}
+
+// resolveNames implements the nameResolver interface, the server name of the addresses of a SRV record
+// is its target.
+func (r *DNSResolver) resolveNames(ctx context.Context, addr string) ([]resolvedAddr, error) {
+	dns := r.DNS
+	if dns == nil {
+		dns = net.DefaultResolver
+	}
+	if isSRVName(addr) {
+		_, srvs, err := dns.LookupSRV(ctx, "", "", addr)
+		if err != nil {
+			return nil, err
+		}
+		var result []resolvedAddr
+		for _, srv := range srvs {
+			host := strings.TrimSuffix(srv.Target, ".")
+			addrs, err := lookupHostPort(ctx, dns, net.JoinHostPort(host, strconv.Itoa(int(srv.Port))))
+			if err != nil {
+				continue
+			}
+			for _, a := range addrs {
+				result = append(result, resolvedAddr{addr: a, serverName: host})
+			}
+		}
+		if len(result) == 0 {
+			return nil, fmt.Errorf("no available target of SRV %s", addr)
+		}
+		return result, nil
+	}
+	addrs, err := lookupHostPort(ctx, dns, addr)
+	if err != nil {
+		return nil, err
+	}
+	return withServerNames(addrs, serverNameOf(addr)), nil
+}
+
+// resolvedAddr is an address resolved from the zipper address, and the TLS server name of the zipper at it.
+type resolvedAddr struct {
+	addr       string
+	serverName string
+}
+
+// nameResolver is implemented by the resolvers knowing the server name of every address they resolve,
+// e.g. the targets of the SRV records. The server name of the other resolvers is the host of the zipper address.
+type nameResolver interface {
+	resolveNames(ctx context.Context, addr string) ([]resolvedAddr, error)
+}
+
+func withServerNames(addrs []string, serverName string) []resolvedAddr {
+	result := make([]resolvedAddr, len(addrs))
+	for i, a := range addrs {
+		result[i] = resolvedAddr{addr: a, serverName: serverName}
+	}
+	return result
+}
+
+// isSRVName reports whether the addr is a SRV name without port, e.g. `_yomo._udp.example.com`.
+func isSRVName(addr string) bool {
+	return strings.HasPrefix(addr, "_") && !strings.Contains(addr, ":")
+}
+
+// lookupHostPort resolves the host of addr, it returns addr itself if the host is an IP.
+func lookupHostPort(ctx context.Context, dns *net.Resolver, addr string) ([]string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" || net.ParseIP(host) != nil {
+		return []string{addr}, nil
+	}
+	ips, err := dns.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.JoinHostPort(ip.IP.String(), port)
+	}
+	return addrs, nil
+}
+
+// resolve resolves addr by the resolver within the resolve timeout.
+func (c *Client) resolve(ctx context.Context, addr string) ([]resolvedAddr, error) {
+	ctx, cancel := withPhaseTimeout(ctx, c.opts.resolveTimeout)
+	defer cancel()
+
+	var (
+		resolved []resolvedAddr
+		err      error
+	)
+	if r, ok := c.opts.resolver.(nameResolver); ok {
+		resolved, err = r.resolveNames(ctx, addr)
+	} else {
+		var addrs []string
+		addrs, err = c.opts.resolver.Resolve(ctx, addr)
+		resolved = withServerNames(addrs, serverNameOf(addr))
+	}
+	if err == nil && len(resolved) == 0 {
+		err = errors.New("no address resolved")
+	}
+	if err != nil {
+		return nil, &ConnectError{Phase: PhaseResolve, Addr: addr, Err: err}
+	}
+	return resolved, nil
+}
+
+// serverNameOf returns the TLS server name of the zipper address, it is the host of addr,
+// or empty if the host is an IP or addr is a SRV name.
+func serverNameOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return ""
+	}
+	return host
+}
+
+// withServerName returns the TLS config verifying the zipper as serverName, the config is cloned
+// if its server name is not set, because the resolved address dialed is not the name of the zipper.
+func withServerName(tlsConfig *tls.Config, serverName string) *tls.Config {
+	if tlsConfig == nil || tlsConfig.ServerName != "" || serverName == "" {
+		return tlsConfig
+	}
+	tc := tlsConfig.Clone()
+	tc.ServerName = serverName
+	return tc
+}
@@ -0,0 +1,227 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/pkg/frame-codec/y3codec"
+	yquic "github.com/yomorun/yomo/pkg/listener/quic"
+	pkgtls "github.com/yomorun/yomo/pkg/tls"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestResolve(t *testing.T) {
+	client := NewClient("source", testaddr, ClientTypeSource, WithLogger(discardingLogger))
+
+	addrs, err := client.resolve(context.TODO(), "127.0.0.1:9000")
+	assert.NoError(t, err)
+	assert.Equal(t, []resolvedAddr{{addr: "127.0.0.1:9000"}}, addrs)
+
+	addrs, err = client.resolve(context.TODO(), "localhost:9000")
+	assert.NoError(t, err)
+	host, _, _ := net.SplitHostPort(addrs[0].addr)
+	assert.True(t, net.ParseIP(host).IsLoopback())
+	assert.Equal(t, "localhost", addrs[0].serverName)
+
+	_, err = client.resolve(context.TODO(), "no-port")
+	e := new(ConnectError)
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, PhaseResolve, e.Phase)
+}
+
+func TestResolvedServerName(t *testing.T) {
+	var dialed []string
+	client := NewClient("source", "zipper.example.com:9000", ClientTypeSource,
+		WithLogger(discardingLogger),
+		WithResolver(ResolverFunc(func(ctx context.Context, addr string) ([]string, error) {
+			return []string{"127.0.0.1:9000"}, nil
+		})),
+		WithDialer(func(ctx context.Context, addr string, tc *tls.Config, qc *quic.Config) (quic.Connection, error) {
+			dialed = append(dialed, addr+" "+tc.ServerName)
+			return nil, errors.New("unreachable")
+		}),
+	)
+
+	// the resolved IP is dialed, the zipper is verified by its name.
+	assert.Error(t, client.Connect(context.TODO()))
+	assert.Equal(t, []string{"127.0.0.1:9000 zipper.example.com"}, dialed)
+	assert.Empty(t, client.opts.tlsConfig.ServerName)
+}
+
+func TestConnectTimeouts(t *testing.T) {
+	t.Run("dial", func(t *testing.T) {
+		client := NewClient("source", "127.0.0.1:0", ClientTypeSource, WithLogger(discardingLogger))
+
+		err := client.Connect(context.TODO())
+		e := new(ConnectError)
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, PhaseDial, e.Phase)
+	})
+
+	t.Run("handshake", func(t *testing.T) {
+		const handshakeAddr = "127.0.0.1:19989"
+
+		// the listener accepts the connection but never acks the handshake.
+		listener, err := yquic.ListenAddr(handshakeAddr, y3codec.Codec(), y3codec.PacketReadWriter(),
+			pkgtls.MustCreateServerTLSConfig(handshakeAddr), DefaultQuicConfig)
+		assert.NoError(t, err)
+		defer listener.Close()
+		go listener.Accept(context.TODO())
+
+		client := NewClient("source", handshakeAddr, ClientTypeSource,
+			WithLogger(discardingLogger), WithConnectTimeouts(0, 0, 100*time.Millisecond))
+
+		start := time.Now()
+		err = client.Connect(context.TODO())
+		assert.Less(t, time.Since(start), 3*time.Second)
+
+		e := new(ConnectError)
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, PhaseHandshake, e.Phase)
+		assert.True(t, e.Timeout())
+		assert.Equal(t, "yomo: handshake 127.0.0.1:19989: context deadline exceeded", e.Error())
+	})
+}
+
+func TestTLSConfigLoader(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		client := NewClient("source", testaddr, ClientTypeSource, WithLogger(discardingLogger),
+			WithTLSConfigLoader(func() (*tls.Config, error) { return nil, errors.New("no cert") }))
+
+		err := client.Connect(context.TODO())
+		e := new(ConnectError)
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, PhaseDial, e.Phase)
+		assert.EqualError(t, err, "yomo: dial 127.0.0.1:19999: load tls config: no cert")
+	})
+
+	t.Run("every connecting", func(t *testing.T) {
+		var (
+			loaded int
+			used   []*tls.Config
+		)
+		client := NewClient("source", "127.0.0.1:0", ClientTypeSource, WithLogger(discardingLogger),
+			WithTLSConfigLoader(func() (*tls.Config, error) {
+				loaded++
+				return pkgtls.MustCreateClientTLSConfig(), nil
+			}),
+			WithDialer(func(ctx context.Context, addr string, tc *tls.Config, qc *quic.Config) (quic.Connection, error) {
+				used = append(used, tc)
+				return nil, errors.New("unreachable")
+			}),
+		)
+
+		assert.Error(t, client.Connect(context.TODO()))
+		assert.Error(t, client.Connect(context.TODO()))
+		assert.Equal(t, 2, loaded)
+		assert.Len(t, used, 2)
+		assert.NotSame(t, used[0], used[1])
+	})
+}
+
+// serveSRV serves the DNS queries on a UDP conn, it answers the SRV query of name with the records,
+// the targets of the records are resolved to 127.0.0.1.
+func serveSRV(t *testing.T, name string, srvs ...dnsmessage.SRVResource) *net.Resolver {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { pc.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var req dnsmessage.Message
+			if err := req.Unpack(buf[:n]); err != nil || len(req.Questions) == 0 {
+				continue
+			}
+			q := req.Questions[0]
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: req.ID, Response: true, Authoritative: true},
+				Questions: req.Questions,
+			}
+			hdr := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}
+			switch {
+			case q.Type == dnsmessage.TypeSRV && q.Name.String() == name:
+				for i := range srvs {
+					resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: hdr, Body: &srvs[i]})
+				}
+			case q.Type == dnsmessage.TypeA:
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: hdr, Body: &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}}})
+			}
+			b, _ := resp.Pack()
+			_, _ = pc.WriteTo(b, addr)
+		}
+	}()
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "udp", pc.LocalAddr().String())
+		},
+	}
+}
+
+func TestDNSResolver(t *testing.T) {
+	dns := serveSRV(t, "_yomo._udp.example.com.",
+		dnsmessage.SRVResource{Priority: 20, Weight: 1, Port: 9001, Target: dnsmessage.MustNewName("zipper-b.example.com.")},
+		dnsmessage.SRVResource{Priority: 10, Weight: 1, Port: 9000, Target: dnsmessage.MustNewName("zipper-a.example.com.")},
+	)
+	r := &DNSResolver{DNS: dns}
+
+	addrs, err := r.Resolve(context.TODO(), "_yomo._udp.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:9000", "127.0.0.1:9001"}, addrs)
+
+	// the zippers are verified by the targets of the SRV records.
+	resolved, err := r.resolveNames(context.TODO(), "_yomo._udp.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []resolvedAddr{
+		{addr: "127.0.0.1:9000", serverName: "zipper-a.example.com"},
+		{addr: "127.0.0.1:9001", serverName: "zipper-b.example.com"},
+	}, resolved)
+
+	addrs, err = r.Resolve(context.TODO(), "10.0.0.1:9000")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:9000"}, addrs)
+
+	_, err = r.Resolve(context.TODO(), "_yomo._udp.unknown.com")
+	assert.Error(t, err)
+}
+
+func TestResolverDialInOrder(t *testing.T) {
+	var resolved []string
+	client := NewClient("source", "zipper.service.consul", ClientTypeSource,
+		WithLogger(discardingLogger),
+		WithConnectTimeouts(0, 100*time.Millisecond, 0),
+		WithResolver(ResolverFunc(func(ctx context.Context, addr string) ([]string, error) {
+			resolved = append(resolved, addr)
+			return []string{"127.0.0.1:0", "127.0.0.1:1"}, nil
+		})),
+	)
+
+	err := client.Connect(context.TODO())
+	e := new(ConnectError)
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, PhaseDial, e.Phase)
+	assert.Equal(t, "zipper.service.consul", e.Addr)
+	assert.Equal(t, []string{"zipper.service.consul"}, resolved)
+	// both addresses are dialed.
+	assert.Len(t, e.Err.(interface{ Unwrap() []error }).Unwrap(), 2)
+
+	client = NewClient("source", "zipper", ClientTypeSource,
+		WithLogger(discardingLogger),
+		WithResolver(ResolverFunc(func(ctx context.Context, addr string) ([]string, error) { return nil, nil })),
+	)
+	err = client.Connect(context.TODO())
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, PhaseResolve, e.Phase)
+}
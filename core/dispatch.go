@@ -0,0 +1,245 @@
+package core
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+)
+
+// DispatchPolicy decides which of the sfns observing the tag receive a DataFrame,
+// the policies other than DispatchBroadcast scale the stateless sfns horizontally.
+type DispatchPolicy uint8
+
+const (
+	// DispatchBroadcast dispatches the DataFrame to all the sfns, it is the default policy.
+	DispatchBroadcast DispatchPolicy = iota
+	// DispatchRoundRobin dispatches the DataFrames to the sfns in turn.
+	DispatchRoundRobin
+	// DispatchLeastOutstanding dispatches the DataFrame to the sfn with the fewest frames being written,
+	// so the slow sfns receive less, see Connection.Outstanding.
+	DispatchLeastOutstanding
+	// DispatchConsistentHash dispatches the DataFrames of a partition key or, if it is not set, of a TID
+	// to the same sfn as long as it is connected, the DataFrames without both are dispatched in turn.
+	// A key may move to a newly connected sfn, see DispatchSticky.
+	DispatchConsistentHash
+	// DispatchSticky dispatches the DataFrames of a partition key to the sfn that receives the first
+	// of them, until the key is idle for the sticky TTL or the sfn leaves, the newly connected sfns take the
+	// new keys only, so the stateful sfns keep their sessions, see WithStickyRouting.
+	// The DataFrames without a partition key are dispatched by their TID as DispatchConsistentHash does.
+	DispatchSticky
+)
+
+// DefaultStickyTTL is the default time that a key of DispatchSticky keeps its sfn after its last frame.
+const DefaultStickyTTL = 10 * time.Minute
+
+// String returns the name of the policy.
+func (p DispatchPolicy) String() string {
+	switch p {
+	case DispatchBroadcast:
+		return "broadcast"
+	case DispatchRoundRobin:
+		return "round_robin"
+	case DispatchLeastOutstanding:
+		return "least_outstanding"
+	case DispatchConsistentHash:
+		return "consistent_hash"
+	case DispatchSticky:
+		return "sticky"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseDispatchPolicy parses the policy from its name, e.g. "round_robin".
+func ParseDispatchPolicy(name string) (DispatchPolicy, error) {
+	for _, p := range []DispatchPolicy{DispatchBroadcast, DispatchRoundRobin, DispatchLeastOutstanding, DispatchConsistentHash, DispatchSticky} {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return DispatchBroadcast, fmt.Errorf("yomo: unknown dispatch policy %q", name)
+}
+
+// dispatchPolicies are the dispatch policies of the server.
+type dispatchPolicies struct {
+	all       DispatchPolicy
+	tags      map[frame.Tag]DispatchPolicy
+	stickyTTL time.Duration
+}
+
+func (p dispatchPolicies) of(tag frame.Tag) DispatchPolicy {
+	if policy, ok := p.tags[tag]; ok {
+		return policy
+	}
+	return p.all
+}
+
+// dispatcher chooses the sfns of the DataFrames by the policies.
+type dispatcher struct {
+	policies atomic.Pointer[dispatchPolicies]
+	seqs     sync.Map // frame.Tag -> *atomic.Uint64
+	sticky   stickyTable
+}
+
+func newDispatcher(policies dispatchPolicies) *dispatcher {
+	d := &dispatcher{
+		sticky: stickyTable{entries: make(map[string]stickyEntry), now: time.Now},
+	}
+	d.setPolicies(policies)
+	return d
+}
+
+// setPolicies replaces the policies, the keys of DispatchSticky keep their sfns.
+func (d *dispatcher) setPolicies(policies dispatchPolicies) {
+	ttl := policies.stickyTTL
+	if ttl <= 0 {
+		ttl = DefaultStickyTTL
+	}
+	d.sticky.mu.Lock()
+	d.sticky.ttl = ttl
+	d.sticky.mu.Unlock()
+
+	d.policies.Store(&policies)
+}
+
+// dispatch returns the connections that receive the DataFrame among the candidates.
+// The chunks of a payload are dispatched to the same sfn, so the sfn reassembles them.
+func (d *dispatcher) dispatch(df *frame.DataFrame, md metadata.M, candidates []*Connection) []*Connection {
+	policy := d.policies.Load().of(df.Tag)
+	// the sticky keys are bound even if there is a single sfn, so they stay when the others connect.
+	if policy == DispatchBroadcast || len(candidates) == 0 || (len(candidates) == 1 && policy != DispatchSticky) {
+		return candidates
+	}
+
+	// the connections from the router are in random order.
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID() < candidates[j].ID() })
+
+	if df.IsChunked() {
+		key := GetSourceIDFromMetadata(md) + "#" + strconv.FormatUint(uint64(df.Chunk.ID), 10)
+		return []*Connection{hashConnection(key, candidates)}
+	}
+
+	switch policy {
+	case DispatchConsistentHash:
+		if key := partitionKey(md); key != "" {
+			return []*Connection{hashConnection(key, candidates)}
+		}
+	case DispatchSticky:
+		if key := GetPartitionKeyFromMetadata(md); key != "" {
+			return []*Connection{d.sticky.choose(strconv.FormatUint(uint64(df.Tag), 10)+"/"+key, candidates)}
+		}
+		// a TID is hashed but not remembered, every write of a source has a new TID,
+		// so remembering them grows the table by a key per frame.
+		if tid := GetTIDFromMetadata(md); tid != "" {
+			return []*Connection{hashConnection(tid, candidates)}
+		}
+	case DispatchLeastOutstanding:
+		// the scanning starts in turn, so the idle sfns receive the frames evenly.
+		start := d.next(df.Tag)
+		chosen := candidates[start%uint64(len(candidates))]
+		for i := range candidates {
+			conn := candidates[(start+uint64(i))%uint64(len(candidates))]
+			if conn.Outstanding() < chosen.Outstanding() {
+				chosen = conn
+			}
+		}
+		return []*Connection{chosen}
+	}
+	return []*Connection{candidates[d.next(df.Tag)%uint64(len(candidates))]}
+}
+
+// next returns the sequence of the tag, it increases every call.
+func (d *dispatcher) next(tag frame.Tag) uint64 {
+	v, ok := d.seqs.Load(tag)
+	if !ok {
+		v, _ = d.seqs.LoadOrStore(tag, new(atomic.Uint64))
+	}
+	return v.(*atomic.Uint64).Add(1) - 1
+}
+
+// partitionKey returns the partition key of the DataFrame, the TID is the key if it is not set.
+func partitionKey(md metadata.M) string {
+	if key := GetPartitionKeyFromMetadata(md); key != "" {
+		return key
+	}
+	return GetTIDFromMetadata(md)
+}
+
+// stickyTable remembers the sfns of the keys of DispatchSticky.
+type stickyTable struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]stickyEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type stickyEntry struct {
+	clientID string
+	seen     time.Time
+}
+
+// choose returns the sfn of the key, the key is bound to a sfn by rendezvous hashing if it is new,
+// or its sfn is not a candidate anymore.
+func (t *stickyTable) choose(key string, candidates []*Connection) *Connection {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.sweep(now)
+
+	var chosen *Connection
+	if e, ok := t.entries[key]; ok && now.Sub(e.seen) < t.ttl {
+		for _, conn := range candidates {
+			if clientIDOf(conn.ID()) == e.clientID {
+				chosen = conn
+				break
+			}
+		}
+	}
+	if chosen == nil {
+		chosen = hashConnection(key, candidates)
+	}
+	t.entries[key] = stickyEntry{clientID: clientIDOf(chosen.ID()), seen: now}
+	return chosen
+}
+
+// sweep removes the idle keys once per ttl.
+func (t *stickyTable) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < t.ttl {
+		return
+	}
+	t.lastSweep = now
+	for key, e := range t.entries {
+		if now.Sub(e.seen) >= t.ttl {
+			delete(t.entries, key)
+		}
+	}
+}
+
+// hashConnection chooses the connection of the key by rendezvous hashing, the key keeps its connection
+// while the connections stay, and only about 1/n of the keys move when the n sfns scale.
+// The client id is hashed instead of the connection id, so the key keeps its sfn after reconnecting.
+func hashConnection(key string, candidates []*Connection) *Connection {
+	var (
+		chosen *Connection
+		max    uint64
+	)
+	for _, conn := range candidates {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(clientIDOf(conn.ID())))
+		if sum := h.Sum64(); chosen == nil || sum > max {
+			chosen, max = conn, sum
+		}
+	}
+	return chosen
+}
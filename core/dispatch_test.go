@@ -0,0 +1,137 @@
+package core
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+)
+
+func TestParseDispatchPolicy(t *testing.T) {
+	for _, p := range []DispatchPolicy{DispatchBroadcast, DispatchRoundRobin, DispatchLeastOutstanding, DispatchConsistentHash, DispatchSticky} {
+		got, err := ParseDispatchPolicy(p.String())
+		assert.NoError(t, err)
+		assert.Equal(t, p, got)
+	}
+	_, err := ParseDispatchPolicy("random")
+	assert.Error(t, err)
+}
+
+func TestDispatcher(t *testing.T) {
+	newConns := func() []*Connection {
+		conns := make([]*Connection, 3)
+		for i, id := range []string{"c-0", "a-0", "b-0"} {
+			conns[i] = newConnection("sfn", id, ClientTypeStreamFunction, nil, []frame.Tag{1}, nil, discardingLogger)
+		}
+		return conns
+	}
+	ids := func(conns []*Connection) []string {
+		result := make([]string, len(conns))
+		for i, conn := range conns {
+			result[i] = conn.ID()
+		}
+		return result
+	}
+	df := &frame.DataFrame{Tag: 1}
+
+	t.Run("broadcast", func(t *testing.T) {
+		d := newDispatcher(dispatchPolicies{tags: map[frame.Tag]DispatchPolicy{2: DispatchRoundRobin}})
+		assert.Len(t, d.dispatch(df, metadata.M{}, newConns()), 3)
+	})
+
+	t.Run("round robin", func(t *testing.T) {
+		d := newDispatcher(dispatchPolicies{tags: map[frame.Tag]DispatchPolicy{1: DispatchRoundRobin}})
+		var got []string
+		for i := 0; i < 4; i++ {
+			got = append(got, ids(d.dispatch(df, metadata.M{}, newConns()))...)
+		}
+		assert.Equal(t, []string{"a-0", "b-0", "c-0", "a-0"}, got)
+	})
+
+	t.Run("least outstanding", func(t *testing.T) {
+		d := newDispatcher(dispatchPolicies{all: DispatchLeastOutstanding})
+		conns := newConns()
+		c, a, b := conns[0], conns[1], conns[2]
+		a.outstanding.Add(2)
+		b.outstanding.Add(1)
+		assert.Equal(t, []string{"c-0"}, ids(d.dispatch(df, metadata.M{}, conns)))
+
+		c.outstanding.Add(3)
+		assert.Equal(t, []string{"b-0"}, ids(d.dispatch(df, metadata.M{}, conns)))
+	})
+
+	t.Run("consistent hash", func(t *testing.T) {
+		d := newDispatcher(dispatchPolicies{all: DispatchConsistentHash})
+		md := NewMetadata("source", "tid-1", "", "", false)
+		chosen := ids(d.dispatch(df, md, newConns()))
+		assert.Len(t, chosen, 1)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, chosen, ids(d.dispatch(df, md, newConns())))
+		}
+
+		// the tid keeps the sfn after the sfn reconnects.
+		conns := newConns()
+		for i, conn := range conns {
+			if conn.ID() == chosen[0] {
+				conns[i] = newConnection("sfn", chosen[0][:1]+"-1", ClientTypeStreamFunction, nil, nil, nil, discardingLogger)
+			}
+		}
+		assert.Equal(t, chosen[0][:1]+"-1", ids(d.dispatch(df, md, conns))[0])
+
+		// the frames without tid are dispatched in turn.
+		assert.NotEqual(t,
+			ids(d.dispatch(df, metadata.M{}, newConns())),
+			ids(d.dispatch(df, metadata.M{}, newConns())),
+		)
+	})
+
+	t.Run("chunks", func(t *testing.T) {
+		d := newDispatcher(dispatchPolicies{all: DispatchRoundRobin})
+		md := NewMetadata("source", "tid-1", "", "", false)
+		chosen := ids(d.dispatch(&frame.DataFrame{Tag: 1, Chunk: frame.Chunk{ID: 7, Seq: 0, Total: 3}}, md, newConns()))
+		for seq := uint32(1); seq < 3; seq++ {
+			chunk := &frame.DataFrame{Tag: 1, Chunk: frame.Chunk{ID: 7, Seq: seq, Total: 3}}
+			assert.Equal(t, chosen, ids(d.dispatch(chunk, md, newConns())))
+		}
+	})
+	t.Run("sticky", func(t *testing.T) {
+		now := time.Now()
+		d := newDispatcher(dispatchPolicies{all: DispatchSticky, stickyTTL: time.Minute})
+		d.sticky.now = func() time.Time { return now }
+
+		conns := newConns()
+		md := NewMetadata("source", "tid-1", "", "", false)
+		md.Set(MetadataPartitionKey, "session-1")
+
+		// the key is bound to the only sfn, and stays when the others connect.
+		assert.Equal(t, []string{"c-0"}, ids(d.dispatch(df, md, conns[:1])))
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, []string{"c-0"}, ids(d.dispatch(df, md, newConns())))
+		}
+
+		// the other tids share the partition.
+		md.Set(MetadataTIDKey, "tid-2")
+		assert.Equal(t, []string{"c-0"}, ids(d.dispatch(df, md, newConns())))
+
+		// the key moves if its sfn leaves.
+		moved := ids(d.dispatch(df, md, newConns()[1:]))
+		assert.NotEqual(t, []string{"c-0"}, moved)
+		assert.Equal(t, moved, ids(d.dispatch(df, md, newConns())))
+
+		// the tids are not remembered.
+		for i := 0; i < 3; i++ {
+			d.dispatch(df, NewMetadata("source", "tid-"+strconv.Itoa(i+3), "", "", false), newConns())
+		}
+		assert.Len(t, d.sticky.entries, 1)
+
+		// the idle key is removed.
+		now = now.Add(2 * time.Minute)
+		md.Set(MetadataPartitionKey, "session-2")
+		d.dispatch(df, md, newConns())
+		assert.Len(t, d.sticky.entries, 1)
+		assert.Contains(t, d.sticky.entries, "1/session-2")
+	})
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DownstreamPinger can be implemented by a Downstream that is probed by the health check, it measures
+// the round-trip time to the downstream zipper, e.g. by Client.Ping. See WithDownstreamHealthCheck.
+type DownstreamPinger interface {
+	// Ping probes the downstream zipper and returns the round-trip time.
+	Ping(ctx context.Context) (time.Duration, error)
+}
+
+// downstreamHealthCheck is the config of the health check of the downstreams.
+type downstreamHealthCheck struct {
+	interval  time.Duration
+	timeout   time.Duration
+	threshold int
+}
+
+// downstreamHealth is the health of a downstream, the downstream is healthy until the threshold of
+// consecutive probes fail, and it is healthy again once a probe succeeds.
+type downstreamHealth struct {
+	failures int // only accessed by the health check goroutine.
+	down     atomic.Bool
+}
+
+// downstreamHealthy reports whether the downstream is in rotation.
+func (s *Server) downstreamHealthy(id string) bool {
+	v, ok := s.downstreamHealths.Load(id)
+	return !ok || !v.(*downstreamHealth).down.Load()
+}
+
+// checkDownstreams probes the downstreams periodically until ctx is done.
+func (s *Server) checkDownstreams(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.healthCheck.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.probeDownstreams(ctx)
+		}
+	}
+}
+
+// probeDownstreams probes the downstreams implementing DownstreamPinger concurrently, and marks them
+// out of rotation or back in rotation.
+func (s *Server) probeDownstreams(ctx context.Context) {
+	s.mu.Lock()
+	pingers := make(map[string]DownstreamPinger, len(s.downstreams))
+	names := make(map[string]string, len(s.downstreams))
+	for id, ds := range s.downstreams {
+		if p, ok := ds.(DownstreamPinger); ok {
+			pingers[id] = p
+			names[id] = ds.LocalName()
+		}
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for id, p := range pingers {
+		wg.Add(1)
+		go func(id string, p DownstreamPinger) {
+			defer wg.Done()
+
+			pctx, cancel := context.WithTimeout(ctx, s.opts.healthCheck.timeout)
+			_, err := p.Ping(pctx)
+			cancel()
+
+			v, _ := s.downstreamHealths.LoadOrStore(id, new(downstreamHealth))
+			h := v.(*downstreamHealth)
+			if err == nil {
+				h.failures = 0
+				if h.down.CompareAndSwap(true, false) {
+					s.logger.Info("downstream is healthy again", "downstream_id", id, "downstream_name", names[id])
+				}
+				return
+			}
+			h.failures++
+			if h.failures >= s.opts.healthCheck.threshold && h.down.CompareAndSwap(false, true) {
+				s.logger.Warn("downstream is unhealthy", "downstream_id", id, "downstream_name", names[id], "err", err)
+			}
+		}(id, p)
+	}
+	wg.Wait()
+}
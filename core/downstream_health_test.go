@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/ylog"
+)
+
+// pingDownstream is a namedDownstream whose probes fail while down is set.
+type pingDownstream struct {
+	namedDownstream
+	down atomic.Bool
+}
+
+func (d *pingDownstream) Ping(ctx context.Context) (time.Duration, error) {
+	if d.down.Load() {
+		return 0, errors.New("unreachable")
+	}
+	return time.Millisecond, nil
+}
+
+func TestDownstreamHealthCheck(t *testing.T) {
+	s := NewServer("zipper", WithDownstreamHealthCheck(time.Second, 0, 2))
+	ds := &pingDownstream{namedDownstream: namedDownstream{name: "a"}}
+	s.AddDownstreamServer(ds)
+
+	source := newConnection("source", "source-id", ClientTypeSource, metadata.M{}, nil, nil, ylog.Default())
+	dispatch := func() {
+		c := &Context{
+			Connection:    source,
+			Frame:         &frame.DataFrame{Tag: 1, Payload: []byte("yomo")},
+			FrameMetadata: metadata.M{},
+			Logger:        ylog.Default(),
+		}
+		assert.NoError(t, s.dispatchToDownstreams(c))
+	}
+
+	// the downstream is healthy before the first probe.
+	dispatch()
+	assert.Equal(t, 1, ds.len())
+
+	// the downstream is in rotation until the threshold of failures.
+	ds.down.Store(true)
+	s.probeDownstreams(context.Background())
+	assert.True(t, s.downstreamHealthy("a"))
+	s.probeDownstreams(context.Background())
+	assert.False(t, s.downstreamHealthy("a"))
+	assert.True(t, s.Topology().Downstreams[0].Unhealthy)
+
+	dispatch()
+	assert.Equal(t, 1, ds.len())
+
+	// the downstream is back once a probe succeeds.
+	ds.down.Store(false)
+	s.probeDownstreams(context.Background())
+	assert.True(t, s.downstreamHealthy("a"))
+
+	dispatch()
+	assert.Equal(t, 2, ds.len())
+}
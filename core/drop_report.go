@@ -0,0 +1,126 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/yerr"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// DropReport aggregates the DataFrames dropped by the non-blocking writing in an interval, it is passed
+// to the error handler periodically instead of an error per frame, see `WithDropReportInterval`.
+type DropReport struct {
+	// Since and Until are the interval of the report.
+	Since, Until time.Time
+	// Drops is the number of the dropped frames per tag.
+	Drops map[frame.Tag]int64
+}
+
+// Total returns the number of the dropped frames in the interval.
+func (r *DropReport) Total() int64 {
+	var total int64
+	for _, n := range r.Drops {
+		total += n
+	}
+	return total
+}
+
+// ErrorCode implements the yerr.Coder interface.
+func (r *DropReport) ErrorCode() yerr.Code { return yerr.CodeOverload }
+
+// Error implements the error interface.
+func (r *DropReport) Error() string {
+	tags := maps.Keys(r.Drops)
+	slices.Sort(tags)
+
+	counts := make([]string, len(tags))
+	for i, tag := range tags {
+		counts[i] = fmt.Sprintf("tag %d: %d", tag, r.Drops[tag])
+	}
+	return fmt.Sprintf("yomo: %d frames dropped in %s, %s", r.Total(), r.Until.Sub(r.Since).Round(time.Millisecond), strings.Join(counts, ", "))
+}
+
+// dropCounter counts the dropped frames per tag, both in the current interval and in total.
+type dropCounter struct {
+	mu       sync.Mutex
+	since    time.Time
+	interval map[frame.Tag]int64
+	total    map[frame.Tag]int64
+}
+
+func newDropCounter() *dropCounter {
+	return &dropCounter{
+		since:    time.Now(),
+		interval: make(map[frame.Tag]int64),
+		total:    make(map[frame.Tag]int64),
+	}
+}
+
+func (d *dropCounter) add(tag frame.Tag) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.interval[tag]++
+	d.total[tag]++
+}
+
+// flush returns the report of the current interval and starts a new one, it returns nil if no frame is dropped.
+func (d *dropCounter) flush(now time.Time) *DropReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	since := d.since
+	d.since = now
+	if len(d.interval) == 0 {
+		return nil
+	}
+	report := &DropReport{Since: since, Until: now, Drops: d.interval}
+	d.interval = make(map[frame.Tag]int64)
+
+	return report
+}
+
+func (d *dropCounter) totals() map[frame.Tag]int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return maps.Clone(d.total)
+}
+
+// DroppedFrames returns the number of the DataFrames dropped by the non-blocking writing,
+// the write overflow policy or the read queue per tag since the client is created.
+func (c *Client) DroppedFrames() map[frame.Tag]int64 {
+	return c.drops.totals()
+}
+
+// recordDrop counts the frame as dropped if it is a DataFrame.
+func (c *Client) recordDrop(f frame.Frame) {
+	if df, ok := f.(*frame.DataFrame); ok {
+		c.drops.add(df.Tag)
+	}
+}
+
+// reportDrops passes the DropReport to the error handler every interval until the client is closed.
+func (c *Client) reportDrops(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case now := <-ticker.C:
+			report := c.drops.flush(now)
+			if report == nil {
+				continue
+			}
+			c.Logger.Warn("frames dropped", "total", report.Total(), "interval", interval.String())
+			c.reportError(report)
+		}
+	}
+}
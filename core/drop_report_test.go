@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestDropCounter(t *testing.T) {
+	d := newDropCounter()
+	start := d.since
+
+	assert.Nil(t, d.flush(start.Add(time.Second)))
+
+	d.add(0x21)
+	d.add(0x21)
+	d.add(0x22)
+
+	report := d.flush(start.Add(2 * time.Second))
+	assert.Equal(t, map[frame.Tag]int64{0x21: 2, 0x22: 1}, report.Drops)
+	assert.Equal(t, int64(3), report.Total())
+	assert.Equal(t, "yomo: 3 frames dropped in 1s, tag 33: 2, tag 34: 1", report.Error())
+
+	d.add(0x21)
+	assert.Equal(t, map[frame.Tag]int64{0x21: 1}, d.flush(start.Add(3*time.Second)).Drops)
+	assert.Equal(t, map[frame.Tag]int64{0x21: 3, 0x22: 1}, d.totals())
+}
+
+func TestDropReport(t *testing.T) {
+	client := NewClient("source", testaddr, ClientTypeSource,
+		WithLogger(discardingLogger),
+		WithWriteOverflowPolicy(WriteOverflowError),
+		WithDropReportInterval(50*time.Millisecond),
+	)
+	defer client.Close()
+
+	reports := make(chan error, 10)
+	client.SetErrorHandler(func(err error) { reports <- err })
+	go client.reportDrops(client.opts.dropReportInterval)
+
+	// the client is not connected, the unbuffered writing drops the frames.
+	for i := 0; i < 3; i++ {
+		assert.ErrorIs(t, client.WriteFrameContext(context.TODO(), &frame.DataFrame{Tag: 0x21}), ErrWriteBufferFull)
+	}
+	assert.ErrorIs(t, client.WriteFrameContext(context.TODO(), &frame.DataFrame{Tag: 0x22}), ErrWriteBufferFull)
+
+	select {
+	case err := <-reports:
+		report := new(DropReport)
+		assert.True(t, errors.As(err, &report))
+		assert.Equal(t, map[frame.Tag]int64{0x21: 3, 0x22: 1}, report.Drops)
+	case <-time.After(time.Second):
+		t.Fatal("the drop report is not passed to the error handler")
+	}
+
+	// no report if nothing is dropped.
+	select {
+	case err := <-reports:
+		t.Fatalf("unexpected report: %v", err)
+	case <-time.After(120 * time.Millisecond):
+	}
+
+	assert.Equal(t, map[frame.Tag]int64{0x21: 3, 0x22: 1}, client.DroppedFrames())
+}
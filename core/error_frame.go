@@ -0,0 +1,154 @@
+package core
+
+import (
+	"strings"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+)
+
+// The codes of the ErrorFrames and the ResponseFrames emitted by yomo, the other codes are free for the applications.
+const (
+	// ErrorCodeUnroutable means zipper finds no sfn observing the tag of the DataFrame.
+	ErrorCodeUnroutable uint32 = 1
+	// ErrorCodeHandlerFailed means the handler of the sfn panics while handling the DataFrame.
+	ErrorCodeHandlerFailed uint32 = 2
+	// ErrorCodeDeadlineExceeded means the RequestFrame expires before it is handled.
+	ErrorCodeDeadlineExceeded uint32 = 3
+	// ErrorCodeInvalidPayload means the payload of the DataFrame is rejected by the validator of the tag at zipper.
+	ErrorCodeInvalidPayload uint32 = 4
+	// ErrorCodeQuotaExceeded means the DataFrame is dropped because the source writes faster than its quota at zipper.
+	ErrorCodeQuotaExceeded uint32 = 5
+	// ErrorCodeRejected means the DataFrame is rejected by a DataFrameFunc at zipper, see DataFrameMiddleware.
+	ErrorCodeRejected uint32 = 6
+)
+
+// NewErrorFrame returns the ErrorFrame reporting the failure of the DataFrame, md is the metadata of the DataFrame.
+// It returns nil if the DataFrame is not written by a source, e.g. a signal without metadata.
+func NewErrorFrame(df *frame.DataFrame, md metadata.M, code uint32, message string) *frame.ErrorFrame {
+	sourceID := GetSourceIDFromMetadata(md)
+	if sourceID == "" {
+		return nil
+	}
+	return &frame.ErrorFrame{
+		SourceID: sourceID,
+		TID:      GetTIDFromMetadata(md),
+		Tag:      df.Tag,
+		Code:     code,
+		Message:  message,
+	}
+}
+
+// SetErrorFrameObserver sets the function to invoke when an ErrorFrame arrives, the ErrorFrames report
+// the failures of the DataFrames written by the client, see frame.ErrorFrame.
+func (c *Client) SetErrorFrameObserver(fn func(*frame.ErrorFrame)) {
+	c.errorFrameObserver = fn
+}
+
+// errorFramesPerSecond bounds the rate of the ErrorFrames written to a connection, so a flood of failing
+// DataFrames does not turn into a flood of ErrorFrames, the ErrorFrames exceeding it are dropped.
+const errorFramesPerSecond = 100
+
+// DownstreamErrorFrameObserver can be implemented by a Downstream that receives the ErrorFrames relayed
+// back by the downstream zipper, e.g. by Client.SetErrorFrameObserver. The ErrorFrames are delivered to
+// the sources connected to this zipper.
+type DownstreamErrorFrameObserver interface {
+	// SetErrorFrameObserver sets the function to invoke when an ErrorFrame arrives.
+	SetErrorFrameObserver(func(*frame.ErrorFrame))
+}
+
+// sendErrorFrame sends the ErrorFrame back to the source that writes the failed DataFrame, it is relayed
+// to the upstream zippers if the source is not connected to this zipper, and the upstream zipper that
+// the source is connected to delivers it, the ErrorFrame is relayed one hop only.
+// tenantID is the tenant of the failed DataFrame, the source of another tenant does not get the ErrorFrame.
+func (s *Server) sendErrorFrame(ef *frame.ErrorFrame, tenantID string) {
+	if ef == nil {
+		return
+	}
+	if s.writeToSource(ef.SourceID, tenantFindConnectionFunc(tenantID), ef) {
+		return
+	}
+	conns, _ := s.connector.Find(func(conn ConnectionInfo) bool { return conn.ClientType() == ClientTypeUpstreamZipper })
+	for _, conn := range conns {
+		s.writeErrorFrame(conn, ef)
+	}
+}
+
+// deliverErrorFrame delivers the ErrorFrame relayed by a downstream zipper to the source connected to
+// this zipper, it is dropped otherwise. The relayed ErrorFrame carries no tenant, the mesh links are
+// trusted like they are for the tenants of the relayed DataFrames.
+func (s *Server) deliverErrorFrame(ef *frame.ErrorFrame) {
+	if !s.writeToSource(ef.SourceID, func(ConnectionInfo) bool { return true }, ef) {
+		s.logger.Debug("drop relayed error frame, the source is not found", "source_id", ef.SourceID, "tag", ef.Tag)
+	}
+}
+
+// writeToSource writes the frame to the connections of the source, it reports whether the source
+// is connected to this zipper. The frame is dropped if the source is not found by inTenant, so a client
+// cannot write to the sources of the other tenants by forging the source ID.
+func (s *Server) writeToSource(sourceID string, inTenant FindConnectionFunc, f frame.Frame) bool {
+	conns, err := s.connector.ClientConnections(sourceID)
+	if err != nil || len(conns) == 0 {
+		s.logger.Debug("source is not found", "type", f.Type().String(), "source_id", sourceID)
+		return false
+	}
+	isSource := sourceIDFindConnectionFunc(sourceID)
+	found := false
+	for _, conn := range conns {
+		if !isSource(conn) {
+			continue
+		}
+		found = true
+		if !inTenant(conn) {
+			conn.Logger.Warn("drop frame to the source of another tenant", "type", f.Type().String(), "source_id", sourceID)
+			continue
+		}
+		if ef, ok := f.(*frame.ErrorFrame); ok {
+			s.writeErrorFrame(conn, ef)
+			continue
+		}
+		if err := conn.FrameConn().WriteFrame(f); err != nil {
+			conn.Logger.Info("failed to write frame to source", "type", f.Type().String(), "err", err)
+		}
+	}
+	return found
+}
+
+// writeErrorFrame writes the ErrorFrame to the connection within errorFramesPerSecond.
+func (s *Server) writeErrorFrame(conn *Connection, ef *frame.ErrorFrame) {
+	if _, ok := conn.errorFrames.take(time.Now()); !ok {
+		conn.Logger.Debug("drop error frame exceeding the rate", "tag", ef.Tag, "code", ef.Code)
+		return
+	}
+	if err := conn.FrameConn().WriteFrame(ef); err != nil {
+		conn.Logger.Info("failed to write error frame", "err", err)
+	}
+}
+
+// sourceIDFindConnectionFunc creates a FindConnectionFunc that finds the source connections of the client,
+// the connection id is the client id suffixed by the reconnection counter, see Client.connect.
+func sourceIDFindConnectionFunc(sourceID string) FindConnectionFunc {
+	return func(conn ConnectionInfo) bool {
+		if conn.ClientType() != ClientTypeSource {
+			return false
+		}
+		return clientIDOf(conn.ID()) == sourceID
+	}
+}
+
+// tenantFindConnectionFunc creates a FindConnectionFunc that finds the connections of the tenant,
+// an empty tenantID finds the connections without tenant.
+func tenantFindConnectionFunc(tenantID string) FindConnectionFunc {
+	return func(conn ConnectionInfo) bool {
+		return GetTenantIDFromMetadata(conn.Metadata()) == tenantID
+	}
+}
+
+// clientIDOf returns the client id of the connection id, it is stable across the reconnections.
+func clientIDOf(connID string) string {
+	if i := strings.LastIndexByte(connID, '-'); i >= 0 {
+		return connID[:i]
+	}
+	return connID
+}
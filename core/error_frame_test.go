@@ -0,0 +1,243 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
+)
+
+func TestNewErrorFrame(t *testing.T) {
+	df := &frame.DataFrame{Tag: 1}
+
+	md := NewMetadata("source", "tid", "", "", false)
+	ef := NewErrorFrame(df, md, ErrorCodeUnroutable, "unroutable")
+	assert.Equal(t, &frame.ErrorFrame{SourceID: "source", TID: "tid", Tag: 1, Code: ErrorCodeUnroutable, Message: "unroutable"}, ef)
+
+	// there is no source to report to.
+	assert.Nil(t, NewErrorFrame(df, NewMetadata("", "tid", "", "", false), ErrorCodeUnroutable, "unroutable"))
+}
+
+func TestSourceIDFindConnectionFunc(t *testing.T) {
+	findFunc := sourceIDFindConnectionFunc("hello")
+
+	assert.True(t, findFunc(&mockConnectionInfo{id: "hello-0", clientType: ClientTypeSource}))
+	assert.True(t, findFunc(&mockConnectionInfo{id: "hello-12", clientType: ClientTypeSource}))
+	assert.False(t, findFunc(&mockConnectionInfo{id: "olleh-0", clientType: ClientTypeSource}))
+	assert.False(t, findFunc(&mockConnectionInfo{id: "hello-0", clientType: ClientTypeStreamFunction}))
+}
+
+func TestErrorFrame(t *testing.T) {
+	t.Parallel()
+
+	const errorFrameAddr = "127.0.0.1:19972"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	server.ConfigRouter(router.Default())
+	go server.ListenAndServe(context.TODO(), errorFrameAddr)
+	defer server.Close()
+
+	sfn := NewClient("sfn", errorFrameAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	sfn.SetObserveDataTags(1)
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	errorFrames := make(chan *frame.ErrorFrame, 1)
+	source := NewClient("source", errorFrameAddr, ClientTypeSource, WithLogger(discardingLogger))
+	source.SetErrorFrameObserver(func(ef *frame.ErrorFrame) { errorFrames <- ef })
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	receive := func() *frame.ErrorFrame {
+		select {
+		case ef := <-errorFrames:
+			return ef
+		case <-time.After(3 * time.Second):
+			t.Fatal("the source does not receive the error frame")
+			return nil
+		}
+	}
+
+	t.Run("unroutable", func(t *testing.T) {
+		md, _ := NewMetadata(source.clientID, "tid-1", "", "", false).Encode()
+		assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 2, Metadata: md, Payload: []byte("hello")}))
+
+		ef := receive()
+		assert.Equal(t, source.clientID, ef.SourceID)
+		assert.Equal(t, "tid-1", ef.TID)
+		assert.Equal(t, frame.Tag(2), ef.Tag)
+		assert.Equal(t, ErrorCodeUnroutable, ef.Code)
+	})
+
+	t.Run("forwarded from sfn", func(t *testing.T) {
+		want := &frame.ErrorFrame{SourceID: source.clientID, TID: "tid-2", Tag: 1, Code: 100, Message: "bad input"}
+		assert.NoError(t, sfn.WriteFrame(want))
+		assert.Equal(t, want, receive())
+	})
+}
+
+// clientDownstream is a Client used as the Downstream of a zipper.
+type clientDownstream struct{ *Client }
+
+func (d clientDownstream) ID() string         { return d.ClientID() }
+func (d clientDownstream) LocalName() string  { return d.Name() }
+func (d clientDownstream) RemoteName() string { return d.Name() }
+
+func TestErrorFrameRelay(t *testing.T) {
+	t.Parallel()
+
+	const (
+		upstreamAddr   = "127.0.0.1:19962"
+		downstreamAddr = "127.0.0.1:19961"
+	)
+
+	downstream := NewServer("downstream", WithServerLogger(discardingLogger))
+	downstream.ConfigRouter(router.Default())
+	go downstream.ListenAndServe(context.TODO(), downstreamAddr)
+	defer downstream.Close()
+
+	sfn := NewClient("sfn", downstreamAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	sfn.SetObserveDataTags(1)
+	received := make(chan *frame.DataFrame, 1)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df })
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	upstream := NewServer("upstream", WithServerLogger(discardingLogger))
+	upstream.ConfigRouter(router.Default())
+	upstream.AddDownstreamServer(clientDownstream{
+		NewClient("upstream", downstreamAddr, ClientTypeUpstreamZipper, WithLogger(discardingLogger)),
+	})
+	go upstream.ListenAndServe(context.TODO(), upstreamAddr)
+	defer upstream.Close()
+
+	errorFrames := make(chan *frame.ErrorFrame, 1)
+	source := NewClient("source", upstreamAddr, ClientTypeSource, WithLogger(discardingLogger))
+	source.SetErrorFrameObserver(func(ef *frame.ErrorFrame) { errorFrames <- ef })
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	// the source is connected to the upstream zipper, the downstream zipper relays the error frame back.
+	md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+	assert.Eventually(t, func() bool {
+		assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("hello")}))
+		select {
+		case <-received:
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 3*time.Second, 10*time.Millisecond)
+
+	want := &frame.ErrorFrame{SourceID: source.clientID, TID: "tid", Tag: 1, Code: 100, Message: "bad input"}
+	assert.NoError(t, sfn.WriteFrame(want))
+	select {
+	case ef := <-errorFrames:
+		assert.Equal(t, want, ef)
+	case <-time.After(3 * time.Second):
+		t.Fatal("the source does not receive the relayed error frame")
+	}
+}
+
+func TestWriteErrorFrameRate(t *testing.T) {
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	w := &recordConn{}
+	conn := newConnection("source", "source-id-1", ClientTypeSource, nil, nil, w, discardingLogger)
+
+	for i := 0; i < 2*errorFramesPerSecond; i++ {
+		server.writeErrorFrame(conn, &frame.ErrorFrame{SourceID: "source-id", Tag: 1})
+	}
+	// the limiter refills while writing.
+	assert.InDelta(t, errorFramesPerSecond, len(w.frames), 1)
+}
+
+// tenantTokenAuth accepts any token as the tenant of the client.
+type tenantTokenAuth struct{}
+
+func (tenantTokenAuth) Init(...string) {}
+func (tenantTokenAuth) Authenticate(payload string) (metadata.M, bool) {
+	return metadata.M{MetadataTenantIDKey: payload}, true
+}
+func (tenantTokenAuth) Name() string { return "tenant-token" }
+
+func TestWriteToSourceTenant(t *testing.T) {
+	t.Parallel()
+
+	const tenantAddr = "127.0.0.1:19958"
+
+	auth.Register(tenantTokenAuth{})
+
+	server := NewServer("zipper", WithAuth("tenant-token"), WithServerLogger(discardingLogger))
+	server.ConfigRouter(router.Default())
+	go server.ListenAndServe(context.TODO(), tenantAddr)
+	defer server.Close()
+
+	requests := make(chan *frame.RequestFrame, 1)
+	sfn := NewClient("sfn", tenantAddr, ClientTypeStreamFunction, WithCredential("tenant-token:acme"), WithLogger(discardingLogger))
+	sfn.SetObserveDataTags(1)
+	sfn.SetRequestObserver(func(rf *frame.RequestFrame) { requests <- rf })
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	// the sfn of globex forges the source ID of the source of acme.
+	intruder := NewClient("intruder", tenantAddr, ClientTypeStreamFunction, WithCredential("tenant-token:globex"), WithLogger(discardingLogger))
+	intruder.SetObserveDataTags(1)
+	assert.NoError(t, intruder.Connect(context.TODO()))
+	defer intruder.Close()
+
+	errorFrames := make(chan *frame.ErrorFrame, 2)
+	source := NewClient("source", tenantAddr, ClientTypeSource, WithCredential("tenant-token:acme"), WithLogger(discardingLogger))
+	source.SetErrorFrameObserver(func(ef *frame.ErrorFrame) { errorFrames <- ef })
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	t.Run("response", func(t *testing.T) {
+		type result struct {
+			res *frame.ResponseFrame
+			err error
+		}
+		results := make(chan result, 1)
+		go func() {
+			md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+			res, err := source.Request(context.Background(), &frame.RequestFrame{Tag: 1, Metadata: md})
+			results <- result{res, err}
+		}()
+
+		var rf *frame.RequestFrame
+		select {
+		case rf = <-requests:
+		case <-time.After(3 * time.Second):
+			t.Fatal("the sfn does not receive the request")
+		}
+
+		assert.NoError(t, intruder.WriteFrame(&frame.ResponseFrame{ID: rf.ID, SourceID: source.clientID, Payload: []byte("forged")}))
+		time.Sleep(100 * time.Millisecond)
+		assert.NoError(t, sfn.WriteFrame(&frame.ResponseFrame{ID: rf.ID, SourceID: source.clientID, Payload: []byte("genuine")}))
+
+		select {
+		case r := <-results:
+			assert.NoError(t, r.err)
+			assert.Equal(t, []byte("genuine"), r.res.Payload)
+		case <-time.After(3 * time.Second):
+			t.Fatal("the source does not receive the response")
+		}
+	})
+
+	t.Run("error frame", func(t *testing.T) {
+		assert.NoError(t, intruder.WriteFrame(&frame.ErrorFrame{SourceID: source.clientID, Tag: 1, Code: 100, Message: "forged"}))
+		time.Sleep(100 * time.Millisecond)
+		assert.NoError(t, sfn.WriteFrame(&frame.ErrorFrame{SourceID: source.clientID, Tag: 1, Code: 101, Message: "genuine"}))
+
+		select {
+		case ef := <-errorFrames:
+			assert.Equal(t, "genuine", ef.Message)
+		case <-time.After(3 * time.Second):
+			t.Fatal("the source does not receive the error frame")
+		}
+	})
+}
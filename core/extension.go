@@ -0,0 +1,35 @@
+package core
+
+import (
+	"github.com/yomorun/yomo/core/frame"
+	"golang.org/x/exp/slog"
+)
+
+// ExtensionHandler handles the ExtensionFrame of the registered type, w writes the frames back to the peer.
+// It is called in the reading goroutine, so it should not block.
+type ExtensionHandler func(w frame.Writer, f *frame.ExtensionFrame)
+
+// extensions are the handlers of the extension frame types.
+type extensions map[frame.Type]ExtensionHandler
+
+// handle passes the frame to the handler of its type, the frame of the unregistered type is skipped,
+// so the peers that speak a newer protocol are still served.
+func (e extensions) handle(w frame.Writer, f *frame.ExtensionFrame, logger *slog.Logger) {
+	handler, ok := e[f.FrameType]
+	if !ok {
+		logger.Debug("skip unknown frame", "frame_type", byte(f.FrameType), "length", len(f.Body))
+		return
+	}
+	handler(w, f)
+}
+
+// register registers the handler, the builtin frame types cannot be extended.
+func (e *extensions) register(t frame.Type, handler ExtensionHandler) {
+	if frame.IsBuiltin(t) {
+		return
+	}
+	if *e == nil {
+		*e = make(extensions)
+	}
+	(*e)[t] = handler
+}
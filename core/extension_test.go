@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestExtension(t *testing.T) {
+	t.Parallel()
+
+	const extensionAddr = "127.0.0.1:19983"
+
+	// the zipper echos the body of the frame 0x50 in the frame 0x51.
+	server := NewServer("zipper", WithServerLogger(discardingLogger),
+		WithServerExtension(0x50, func(w frame.Writer, f *frame.ExtensionFrame) {
+			_ = w.WriteFrame(&frame.ExtensionFrame{FrameType: 0x51, Body: f.Body})
+		}),
+		// the builtin frames cannot be extended.
+		WithServerExtension(frame.TypePingFrame, func(frame.Writer, *frame.ExtensionFrame) {
+			t.Error("the builtin frame is extended")
+		}),
+	)
+	go server.ListenAndServe(context.TODO(), extensionAddr)
+	defer server.Close()
+
+	echoed := make(chan []byte, 1)
+	source := NewClient("source", extensionAddr, ClientTypeSource, WithLogger(discardingLogger),
+		WithExtension(0x51, func(_ frame.Writer, f *frame.ExtensionFrame) { echoed <- f.Body }),
+		WithHeartbeat(10*time.Millisecond),
+	)
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	// the unregistered frame is skipped, the connection keeps working.
+	assert.NoError(t, source.WriteFrame(&frame.ExtensionFrame{FrameType: 0x52, Body: []byte("skipped")}))
+	assert.NoError(t, source.WriteFrame(&frame.ExtensionFrame{FrameType: 0x50, Body: []byte("hello")}))
+
+	select {
+	case body := <-echoed:
+		assert.Equal(t, []byte("hello"), body)
+	case <-time.After(3 * time.Second):
+		t.Fatal("the extension frame is not echoed")
+	}
+	assert.Equal(t, StateConnected, source.State())
+}
+
+func TestHandshakeExtensions(t *testing.T) {
+	t.Parallel()
+
+	const handshakeExtAddr = "127.0.0.1:19982"
+
+	// the zipper answers the group, and rejects the client without a group.
+	server := NewServer("zipper", WithServerLogger(discardingLogger),
+		WithHandshakeExtensionHandler(func(hf *frame.HandshakeFrame) (map[string][]byte, error) {
+			group, ok := hf.Extensions["group"]
+			if !ok {
+				return nil, &ErrRejected{Message: "no group"}
+			}
+			return map[string][]byte{"group": append([]byte("joined "), group...)}, nil
+		}),
+	)
+	go server.ListenAndServe(context.TODO(), handshakeExtAddr)
+	defer server.Close()
+
+	source := NewClient("source", handshakeExtAddr, ClientTypeSource, WithLogger(discardingLogger),
+		WithHandshakeExtension("group", []byte("edge")))
+	assert.Nil(t, source.AckExtensions())
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+	assert.Equal(t, map[string][]byte{"group": []byte("joined edge")}, source.AckExtensions())
+
+	conn, ok, _ := server.connector.Get(source.clientID + "-0")
+	assert.True(t, ok)
+	assert.Equal(t, map[string][]byte{"group": []byte("edge")}, conn.HandshakeExtensions())
+
+	rejected := NewClient("rejected", handshakeExtAddr, ClientTypeSource, WithLogger(discardingLogger))
+	err := rejected.Connect(context.TODO())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no group")
+}
@@ -0,0 +1,63 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+// ExtensionUserMin is the lowest key of DataFrame.Extensions that is free for the applications,
+// the lower keys are reserved for the future fields of yomo, e.g. TTL or schema ID.
+const ExtensionUserMin uint8 = 0x80
+
+var errMalformedExtensions = errors.New("frame: malformed extensions")
+
+// AppendExtensions appends the extensions to dst in TLV form, that is the key, the uvarint length
+// and the value of every extension in ascending order of the keys, and returns the extended buffer.
+func AppendExtensions(dst []byte, extensions map[uint8][]byte) []byte {
+	keys := make([]uint8, 0, len(extensions))
+	for key := range extensions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	for _, key := range keys {
+		dst = append(dst, key)
+		dst = binary.AppendUvarint(dst, uint64(len(extensions[key])))
+		dst = append(dst, extensions[key]...)
+	}
+	return dst
+}
+
+// ExtensionsSize returns the size of the extensions in TLV form.
+func ExtensionsSize(extensions map[uint8][]byte) int {
+	var buf [binary.MaxVarintLen64]byte
+	size := 0
+	for _, value := range extensions {
+		size += 1 + binary.PutUvarint(buf[:], uint64(len(value))) + len(value)
+	}
+	return size
+}
+
+// ParseExtensions parses the extensions in TLV form, the values refer to data without copying.
+func ParseExtensions(data []byte) (map[uint8][]byte, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	extensions := make(map[uint8][]byte)
+	for len(data) > 0 {
+		key := data[0]
+		n, size := binary.Uvarint(data[1:])
+		if size <= 0 || n > uint64(len(data)-1-size) {
+			return nil, errMalformedExtensions
+		}
+		data = data[1+size:]
+		if n > 0 {
+			extensions[key] = data[:n:n]
+		} else {
+			extensions[key] = nil
+		}
+		data = data[n:]
+	}
+	return extensions, nil
+}
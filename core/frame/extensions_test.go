@@ -0,0 +1,30 @@
+package frame
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtensions(t *testing.T) {
+	extensions := map[uint8][]byte{0xff: []byte("user"), 0x01: make([]byte, 200), 0x02: nil}
+
+	data := AppendExtensions(nil, extensions)
+	assert.Equal(t, ExtensionsSize(extensions), len(data))
+	// the keys are in ascending order.
+	assert.Equal(t, []byte{0x01, 0xc8, 0x01}, data[:3])
+
+	got, err := ParseExtensions(data)
+	assert.NoError(t, err)
+	assert.Equal(t, extensions, got)
+
+	got, err = ParseExtensions(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	// the value beyond the data is malformed.
+	_, err = ParseExtensions(data[:len(data)-1])
+	assert.Error(t, err)
+	_, err = ParseExtensions([]byte{0x01})
+	assert.Error(t, err)
+}
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"time"
+
+	"github.com/yomorun/yomo/core/yerr"
 )
 
 // Frame is the minimum unit required for Yomo to run.
@@ -18,6 +21,14 @@ import (
 //  4. RejectedFrame
 //  5. GoawayFrame
 //  6. ConnectToFrame
+//  7. PingFrame
+//  8. PongFrame
+//  9. ObserveUpdateFrame
+//  10. GoodbyeFrame
+//  11. AckFrame
+//  12. ErrorFrame
+//  13. RequestFrame
+//  14. ResponseFrame
 //
 // Read frame comments to understand the role of the frame.
 type Frame interface {
@@ -36,9 +47,65 @@ type DataFrame struct {
 	// Tag is used for data router.
 	Tag Tag
 	// Payload is the data to transmit.
+	// The Metadata and Payload of the decoded DataFrame refer to the packet read from the stream
+	// without copying, the packet is owned by the DataFrame and never reused, so they can be retained.
 	Payload []byte
+	// Priority is the priority of the DataFrame, the client writes the frames with higher priority first.
+	Priority Priority
+	// Channel is the logical stream of the DataFrame, e.g. a channel per device. The channels share the
+	// bounded data streams of the connection, and the frames of a channel keep the order if the streams
+	// are chosen by channel. 0 is the default channel.
+	Channel uint32
+	// Chunk is the position of the DataFrame in the chunks of an oversized payload,
+	// the zero value means the DataFrame is not chunked.
+	Chunk Chunk
+	// AckID identifies the DataFrame to be acknowledged by an AckFrame, it is unique in the writer,
+	// the zero value means the DataFrame is not acknowledged.
+	AckID uint64
+	// Compression is the algorithm that the Payload is compressed by, the algorithm is negotiated
+	// in the handshake. The zero value means the Payload is not compressed.
+	Compression Compression
+	// Extensions are the optional fields keyed by a byte, they are encoded in TLV form, so the fields
+	// added later are carried by the older peers without being understood. See ExtensionUserMin.
+	Extensions map[uint8][]byte
+}
+
+// Compression identifies the compression algorithm of the DataFrame payload.
+type Compression uint8
+
+const (
+	// CompressionNone means the payload is not compressed.
+	CompressionNone Compression = 0
+	// CompressionGzip means the payload is compressed by gzip.
+	CompressionGzip Compression = 1
+)
+
+// Chunk locates a chunk of an oversized payload, the payload is split into the chunks by the writer
+// and reassembled by the reader, see `core.WithMaxFrameSize`.
+type Chunk struct {
+	// ID identifies the chunks of a payload, it is unique in the writer.
+	ID uint32
+	// Seq is the sequence number of the chunk, it starts from 0.
+	Seq uint32
+	// Total is the number of the chunks of the payload.
+	Total uint32
 }
 
+// IsChunked reports whether the DataFrame is a chunk of a payload.
+func (f *DataFrame) IsChunked() bool { return f.Chunk.Total > 0 }
+
+// Priority is the priority of DataFrame.
+type Priority int8
+
+const (
+	// PriorityLow is for bulk data that can be delayed.
+	PriorityLow Priority = -1
+	// PriorityNormal is the default priority.
+	PriorityNormal Priority = 0
+	// PriorityHigh is for control or alarm data that should be written ahead of the others.
+	PriorityHigh Priority = 1
+)
+
 // Type returns the type of DataFrame.
 func (f *DataFrame) Type() Type { return TypeDataFrame }
 
@@ -60,6 +127,17 @@ type HandshakeFrame struct {
 	AuthPayload string
 	// Version is used by the source/sfn to communicate their spec version to the server.
 	Version string
+	// ProtocolVersion is the highest version of the frame protocol spoken by the client,
+	// zero means the client is older than the protocol version negotiation.
+	ProtocolVersion uint32
+	// Compressions is the payload compression algorithms supported by the client, in order of preference.
+	Compressions []string
+	// SchemaVersions is the payload schema versions supported by the client per observed data tag,
+	// the tags not in it accept all versions.
+	SchemaVersions map[Tag][]string
+	// Extensions is the opaque data piggybacked on the handshake by the applications or the features,
+	// e.g. groups or capabilities, the server answers them in HandshakeAckFrame.Extensions.
+	Extensions map[string][]byte
 }
 
 // Type returns the type of HandshakeFrame.
@@ -67,7 +145,16 @@ func (f *HandshakeFrame) Type() Type { return TypeHandshakeFrame }
 
 // HandshakeAckFrame is used to ack handshake, If handshake successful, The server will
 // send HandshakeAckFrame to the client.
-type HandshakeAckFrame struct{}
+type HandshakeAckFrame struct {
+	// Compression is the payload compression algorithm confirmed by the server,
+	// empty means the payload is not compressed.
+	Compression string
+	// Extensions is the opaque data answered to the HandshakeFrame.Extensions.
+	Extensions map[string][]byte
+	// ProtocolVersion is the version of the frame protocol that the server will speak,
+	// zero means the server is older than the protocol version negotiation.
+	ProtocolVersion uint32
+}
 
 // Type returns the type of HandshakeAckFrame.
 func (f *HandshakeAckFrame) Type() Type { return TypeHandshakeAckFrame }
@@ -76,6 +163,8 @@ func (f *HandshakeAckFrame) Type() Type { return TypeHandshakeAckFrame }
 type RejectedFrame struct {
 	// Message encapsulates the rationale behind the rejection of the request.
 	Message string
+	// Reason is the machine-readable reason of the rejection, e.g. `auth_expired`, it is empty if not given.
+	Reason string
 }
 
 // Type returns the type of RejectedFrame.
@@ -85,6 +174,8 @@ func (f *RejectedFrame) Type() Type { return TypeRejectedFrame }
 type GoawayFrame struct {
 	// Message contains the reason why the connection be evicted.
 	Message string
+	// Reason is the machine-readable reason of the eviction, e.g. `server_draining`, it is empty if not given.
+	Reason string
 }
 
 // Type returns the type of GoawayFrame.
@@ -99,6 +190,125 @@ type ConnectToFrame struct {
 // Type returns the type of ConnectToFrame.
 func (f *ConnectToFrame) Type() Type { return TypeConnectToFrame }
 
+// PingFrame is used to measure the liveness and latency of the application layer,
+// the receiver should respond a PongFrame with the same payload.
+type PingFrame struct {
+	// Payload is echoed by PongFrame, the sender usually puts a timestamp in it.
+	Payload []byte
+}
+
+// Type returns the type of PingFrame.
+func (f *PingFrame) Type() Type { return TypePingFrame }
+
+// PongFrame is the response of PingFrame.
+type PongFrame struct {
+	// Payload is the payload of the PingFrame be responded.
+	Payload []byte
+}
+
+// Type returns the type of PongFrame.
+func (f *PongFrame) Type() Type { return TypePongFrame }
+
+// ObserveUpdateFrame is used by an connected sfn to update its observed data tags at runtime,
+// the tags in Unsubscribe are removed before the tags in Subscribe are added.
+type ObserveUpdateFrame struct {
+	// Subscribe is the data tags to be observed.
+	Subscribe []Tag
+	// Unsubscribe is the data tags not to be observed anymore.
+	Unsubscribe []Tag
+}
+
+// Type returns the type of ObserveUpdateFrame.
+func (f *ObserveUpdateFrame) Type() Type { return TypeObserveUpdateFrame }
+
+// GoodbyeFrame is sent by the client before it closes the connection intentionally,
+// so zipper tells the shutdown from the network failure and stops routing to the client at once.
+type GoodbyeFrame struct {
+	// Message is the reason of the shutdown.
+	Message string
+}
+
+// Type returns the type of GoodbyeFrame.
+func (f *GoodbyeFrame) Type() Type { return TypeGoodbyeFrame }
+
+// AckFrame acknowledges that zipper has received the DataFrame, see DataFrame.AckID.
+type AckFrame struct {
+	// ID is the AckID of the acknowledged DataFrame.
+	ID uint64
+}
+
+// Type returns the type of AckFrame.
+func (f *AckFrame) Type() Type { return TypeAckFrame }
+
+// ErrorFrame reports that a DataFrame fails to be processed, e.g. the handler of the sfn fails or zipper
+// cannot route it, zipper sends it back to the source that writes the DataFrame.
+type ErrorFrame struct {
+	// SourceID is the ID of the source that writes the DataFrame, zipper routes the ErrorFrame by it.
+	SourceID string
+	// TID is the transaction ID of the DataFrame.
+	TID string
+	// Tag is the tag of the DataFrame.
+	Tag Tag
+	// Code is the code of the error, e.g. `core.ErrorCodeUnroutable`.
+	Code uint32
+	// Message describes the error.
+	Message string
+}
+
+// Type returns the type of ErrorFrame.
+func (f *ErrorFrame) Type() Type { return TypeErrorFrame }
+
+// RequestFrame is a call of the source to the sfn that observes the tag, zipper routes it to one of
+// the sfns, and the sfn answers it by a ResponseFrame of the same ID.
+type RequestFrame struct {
+	// ID correlates the request and the response, it is unique in the source.
+	ID string
+	// Tag is used for request router.
+	Tag Tag
+	// ReplyTo is the tag of the result, the data that the sfn writes to it is the response.
+	ReplyTo Tag
+	// Deadline is the unix time in milliseconds that the request expires at, the expired request is
+	// not handled any more. 0 means no deadline.
+	Deadline int64
+	// Metadata is the metadata of the request, the same as the Metadata of DataFrame.
+	Metadata []byte
+	// Payload is the data of the request.
+	Payload []byte
+}
+
+// Type returns the type of RequestFrame.
+func (f *RequestFrame) Type() Type { return TypeRequestFrame }
+
+// ResponseFrame answers a RequestFrame, zipper sends it back to the source that writes the request.
+type ResponseFrame struct {
+	// ID is the ID of the RequestFrame.
+	ID string
+	// SourceID is the ID of the source that writes the RequestFrame, zipper routes the ResponseFrame by it.
+	SourceID string
+	// Payload is the result of the request.
+	Payload []byte
+	// Code is the code of the error, 0 means the request succeeds, e.g. `core.ErrorCodeUnroutable`.
+	Code uint32
+	// Message describes the error.
+	Message string
+}
+
+// Type returns the type of ResponseFrame.
+func (f *ResponseFrame) Type() Type { return TypeResponseFrame }
+
+// ExtensionFrame is a frame of the type that yomo doesn't know, it carries the encoded body as is,
+// so the extensions of the protocol are passed to the registered handlers, or skipped by the peers
+// that don't know them, instead of breaking the connection.
+type ExtensionFrame struct {
+	// FrameType is the type of the frame, it must not be the type of a builtin frame.
+	FrameType Type
+	// Body is the encoded body of the frame, it is opaque to yomo.
+	Body []byte
+}
+
+// Type returns the type of ExtensionFrame.
+func (f *ExtensionFrame) Type() Type { return f.FrameType }
+
 const (
 	TypeDataFrame         Type = 0x3F // TypeDataFrame is the type of DataFrame.
 	TypeHandshakeFrame    Type = 0x31 // TypeHandshakeFrame is the type of HandshakeFrame.
@@ -106,6 +316,15 @@ const (
 	TypeRejectedFrame     Type = 0x39 // TypeRejectedFrame is the type of RejectedFrame.
 	TypeGoawayFrame       Type = 0x2E // TypeGoawayFrame is the type of GoawayFrame.
 	TypeConnectToFrame    Type = 0x3E // TypeConnectToFrame is the type of ConnectToFrame.
+	TypePingFrame         Type = 0x3C // TypePingFrame is the type of PingFrame.
+	TypePongFrame         Type = 0x3D // TypePongFrame is the type of PongFrame.
+
+	TypeObserveUpdateFrame Type = 0x3B // TypeObserveUpdateFrame is the type of ObserveUpdateFrame.
+	TypeGoodbyeFrame       Type = 0x2F // TypeGoodbyeFrame is the type of GoodbyeFrame.
+	TypeAckFrame           Type = 0x3A // TypeAckFrame is the type of AckFrame.
+	TypeErrorFrame         Type = 0x38 // TypeErrorFrame is the type of ErrorFrame.
+	TypeRequestFrame       Type = 0x37 // TypeRequestFrame is the type of RequestFrame.
+	TypeResponseFrame      Type = 0x36 // TypeResponseFrame is the type of ResponseFrame.
 )
 
 var frameTypeStringMap = map[Type]string{
@@ -115,6 +334,15 @@ var frameTypeStringMap = map[Type]string{
 	TypeRejectedFrame:     "RejectedFrame",
 	TypeGoawayFrame:       "GoawayFrame",
 	TypeConnectToFrame:    "ConnectToFrame",
+	TypePingFrame:         "PingFrame",
+	TypePongFrame:         "PongFrame",
+
+	TypeObserveUpdateFrame: "ObserveUpdateFrame",
+	TypeGoodbyeFrame:       "GoodbyeFrame",
+	TypeAckFrame:           "AckFrame",
+	TypeErrorFrame:         "ErrorFrame",
+	TypeRequestFrame:       "RequestFrame",
+	TypeResponseFrame:      "ResponseFrame",
 }
 
 // String returns a human-readable string which represents the frame type.
@@ -134,15 +362,30 @@ var frameTypeNewFuncMap = map[Type]func() Frame{
 	TypeRejectedFrame:     func() Frame { return new(RejectedFrame) },
 	TypeGoawayFrame:       func() Frame { return new(GoawayFrame) },
 	TypeConnectToFrame:    func() Frame { return new(ConnectToFrame) },
+	TypePingFrame:         func() Frame { return new(PingFrame) },
+	TypePongFrame:         func() Frame { return new(PongFrame) },
+
+	TypeObserveUpdateFrame: func() Frame { return new(ObserveUpdateFrame) },
+	TypeGoodbyeFrame:       func() Frame { return new(GoodbyeFrame) },
+	TypeAckFrame:           func() Frame { return new(AckFrame) },
+	TypeErrorFrame:         func() Frame { return new(ErrorFrame) },
+	TypeRequestFrame:       func() Frame { return new(RequestFrame) },
+	TypeResponseFrame:      func() Frame { return new(ResponseFrame) },
 }
 
-// NewFrame creates a new frame from Type.
+// NewFrame creates a new frame from Type, the unknown type is created as an ExtensionFrame.
 func NewFrame(f Type) (Frame, error) {
 	newFunc, ok := frameTypeNewFuncMap[f]
 	if ok {
 		return newFunc(), nil
 	}
-	return nil, fmt.Errorf("frame: cannot new a frame from %c", f)
+	return &ExtensionFrame{FrameType: f}, nil
+}
+
+// IsBuiltin reports whether the type is the type of a builtin frame.
+func IsBuiltin(f Type) bool {
+	_, ok := frameTypeNewFuncMap[f]
+	return ok
 }
 
 // PacketReadWriter reads packets from the io.Reader and writes packets to the io.Writer.
@@ -160,6 +403,20 @@ type Codec interface {
 	Encode(Frame) ([]byte, error)
 }
 
+// EncoderTo is implemented by the Codec that encodes the frame into the writer directly,
+// it writes the same bytes as Encode without allocating them for every frame.
+type EncoderTo interface {
+	// EncodeTo encodes the frame and writes it to w.
+	EncodeTo(w io.Writer, f Frame) error
+}
+
+// PacketEncoder is implemented by the PacketReadWriter that writes the frame encoded by the codec as
+// a packet directly, so the frame is not encoded into a byte array before being written.
+type PacketEncoder interface {
+	// EncodePacket encodes the frame by the codec and writes it to w as a packet.
+	EncodePacket(w io.Writer, codec Codec, f Frame) error
+}
+
 // Tag tags data and can be used for data routing.
 type Tag = uint32
 
@@ -198,6 +455,58 @@ type Conn interface {
 	CloseWithError(string) error
 }
 
+// BatchWriter is implemented by the Conn that is able to write multiple frames in a single write,
+// it reduces the syscall and packet overhead of writing many small frames.
+type BatchWriter interface {
+	// WriteFrames writes the frames to connection in a single write.
+	WriteFrames(...Frame) error
+}
+
+// ControlConn is implemented by the Conn that transmits the control frames (e.g. PingFrame and PongFrame)
+// on a dedicated stream, the control frames are never blocked behind the data frames.
+type ControlConn interface {
+	// ReadControlFrame reads a frame from the control stream.
+	ReadControlFrame() (Frame, error)
+	// WriteControlFrame writes a frame to the control stream.
+	WriteControlFrame(Frame) error
+}
+
+// StreamCanceler is implemented by the Conn that transmits the data frames on multiple streams,
+// either endpoint can cancel a data stream without tearing down the connection.
+type StreamCanceler interface {
+	// DataStreamIDs returns the IDs of the data streams that can be canceled.
+	DataStreamIDs() []int64
+	// CancelStream cancels the data stream with the application error code,
+	// the peer gets ErrStreamCanceled from reading or writing the stream.
+	CancelStream(id int64, code uint64) error
+}
+
+// ErrStreamCanceled is returned when a data stream is canceled by remote or local,
+// the connection and its other streams keep working.
+type ErrStreamCanceled struct {
+	StreamID int64
+	Code     uint64
+	Remote   bool
+}
+
+// Error implements the error interface.
+func (e *ErrStreamCanceled) Error() string {
+	if e.Remote {
+		return fmt.Sprintf("remote stream %d canceled: code=%d", e.StreamID, e.Code)
+	}
+	return fmt.Sprintf("local stream %d canceled: code=%d", e.StreamID, e.Code)
+}
+
+// ErrorCode implements the yerr.Coder interface.
+func (e *ErrStreamCanceled) ErrorCode() yerr.Code { return yerr.CodeClosed }
+
+// WriteDeadliner is implemented by the Conn that supports write deadline.
+type WriteDeadliner interface {
+	// SetWriteDeadline sets the deadline for future WriteFrame calls and any currently-blocked
+	// WriteFrame call, a zero value for t means WriteFrame will not time out.
+	SetWriteDeadline(t time.Time) error
+}
+
 // ErrConnClosed is returned when the connection be closed by remote or local.
 // The ReadFrame() and WriteFrame() should return this error after calling CloseWithError().
 type ErrConnClosed struct {
@@ -213,6 +522,9 @@ func (e *ErrConnClosed) Error() string {
 	return fmt.Sprintf("local conn closed: %s", e.ErrorMessage)
 }
 
+// ErrorCode implements the yerr.Coder interface.
+func (e *ErrConnClosed) ErrorCode() yerr.Code { return yerr.CodeClosed }
+
 // NewErrConnClosed returns an ErrConnClosed.
 func NewErrConnClosed(remote bool, errMsg string) *ErrConnClosed {
 	return &ErrConnClosed{
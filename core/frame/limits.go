@@ -0,0 +1,158 @@
+package frame
+
+import (
+	"fmt"
+
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+// Limits bounds the sizes of the frames read from the peers, so a garbage or hostile packet
+// is dropped before it balloons the memory. A zero field takes the value of DefaultLimits.
+type Limits struct {
+	// MaxPacketSize is the max size of a packet, the larger packet is discarded without being buffered.
+	MaxPacketSize int
+	// MaxMetadataSize is the max size of the metadata of DataFrame, and the total size of the extensions.
+	MaxMetadataSize int
+	// MaxStringSize is the max size of a string field, e.g. the name and the auth payload of HandshakeFrame,
+	// and the payload of PingFrame and PongFrame.
+	MaxStringSize int
+	// MaxListLength is the max number of the elements of a list or a map field, e.g. the observed data tags.
+	MaxListLength int
+}
+
+// DefaultLimits is the limits that the codecs apply by default, the payload larger than MaxPacketSize
+// should be chunked by the writer, see `core.WithMaxFrameSize`.
+var DefaultLimits = Limits{
+	MaxPacketSize:   64 << 20,
+	MaxMetadataSize: 1 << 20,
+	MaxStringSize:   16 << 10,
+	MaxListLength:   4096,
+}
+
+// OrDefault returns the limits whose zero fields are replaced by the fields of DefaultLimits.
+func (l Limits) OrDefault() Limits {
+	if l.MaxPacketSize <= 0 {
+		l.MaxPacketSize = DefaultLimits.MaxPacketSize
+	}
+	if l.MaxMetadataSize <= 0 {
+		l.MaxMetadataSize = DefaultLimits.MaxMetadataSize
+	}
+	if l.MaxStringSize <= 0 {
+		l.MaxStringSize = DefaultLimits.MaxStringSize
+	}
+	if l.MaxListLength <= 0 {
+		l.MaxListLength = DefaultLimits.MaxListLength
+	}
+	return l
+}
+
+// Check checks the decoded frame against the limits, it returns *ErrMalformed if a field exceeds them.
+// The payload of DataFrame and the body of ExtensionFrame are bounded by MaxPacketSize only.
+func (l Limits) Check(f Frame) error {
+	c := &limitChecker{limits: l.OrDefault()}
+	switch ff := f.(type) {
+	case *DataFrame:
+		c.size("metadata", len(ff.Metadata), c.limits.MaxMetadataSize)
+		c.size("extensions", ExtensionsSize(ff.Extensions), c.limits.MaxMetadataSize)
+	case *HandshakeFrame:
+		c.strings("name", ff.Name)
+		c.strings("id", ff.ID)
+		c.strings("auth name", ff.AuthName)
+		c.strings("auth payload", ff.AuthPayload)
+		c.strings("version", ff.Version)
+		c.size("observed data tags", len(ff.ObserveDataTags), c.limits.MaxListLength)
+		c.size("compressions", len(ff.Compressions), c.limits.MaxListLength)
+		c.strings("compression", ff.Compressions...)
+		c.size("schema versions", len(ff.SchemaVersions), c.limits.MaxListLength)
+		for _, versions := range ff.SchemaVersions {
+			c.size("schema versions", len(versions), c.limits.MaxListLength)
+			c.strings("schema version", versions...)
+		}
+		c.extensions(ff.Extensions)
+	case *HandshakeAckFrame:
+		c.strings("compression", ff.Compression)
+		c.extensions(ff.Extensions)
+	case *RejectedFrame:
+		c.strings("message", ff.Message)
+		c.strings("reason", ff.Reason)
+	case *GoawayFrame:
+		c.strings("message", ff.Message)
+		c.strings("reason", ff.Reason)
+	case *ConnectToFrame:
+		c.strings("endpoint", ff.Endpoint)
+	case *PingFrame:
+		c.size("payload", len(ff.Payload), c.limits.MaxStringSize)
+	case *PongFrame:
+		c.size("payload", len(ff.Payload), c.limits.MaxStringSize)
+	case *ObserveUpdateFrame:
+		c.size("tags", len(ff.Subscribe)+len(ff.Unsubscribe), c.limits.MaxListLength)
+	case *GoodbyeFrame:
+		c.strings("message", ff.Message)
+	case *ErrorFrame:
+		c.strings("source id", ff.SourceID)
+		c.strings("tid", ff.TID)
+		c.strings("message", ff.Message)
+	case *RequestFrame:
+		c.strings("id", ff.ID)
+		c.size("metadata", len(ff.Metadata), c.limits.MaxMetadataSize)
+	case *ResponseFrame:
+		c.strings("id", ff.ID)
+		c.strings("source id", ff.SourceID)
+		c.strings("message", ff.Message)
+	}
+	if c.err != nil {
+		return NewErrMalformed(f.Type(), fmt.Errorf("frame: %s: %w", f.Type(), c.err))
+	}
+	return nil
+}
+
+// limitChecker keeps the first field that exceeds the limits.
+type limitChecker struct {
+	limits Limits
+	err    error
+}
+
+func (c *limitChecker) size(field string, n, max int) {
+	if c.err == nil && n > max {
+		c.err = fmt.Errorf("%s is too large: %d > %d", field, n, max)
+	}
+}
+
+func (c *limitChecker) strings(field string, ss ...string) {
+	for _, s := range ss {
+		c.size(field, len(s), c.limits.MaxStringSize)
+	}
+}
+
+func (c *limitChecker) extensions(extensions map[string][]byte) {
+	c.size("extensions", len(extensions), c.limits.MaxListLength)
+	total := 0
+	for key, value := range extensions {
+		total += len(key) + len(value)
+	}
+	c.size("extensions", total, c.limits.MaxMetadataSize)
+}
+
+// ErrMalformed is returned by the codecs if a frame cannot be decoded or it exceeds the Limits,
+// e.g. the garbage sent by an untrusted client. The packet is read as a whole, so the frame
+// is dropped and the connection keeps working.
+type ErrMalformed struct {
+	// FrameType is the type of the frame.
+	FrameType Type
+	// Err is the cause, e.g. the error of the codec.
+	Err error
+}
+
+// Error implements the error interface, it is the message of the cause.
+func (e *ErrMalformed) Error() string { return e.Err.Error() }
+
+// Unwrap returns the cause.
+func (e *ErrMalformed) Unwrap() error { return e.Err }
+
+// ErrorCode implements the yerr.Coder interface.
+func (e *ErrMalformed) ErrorCode() yerr.Code { return yerr.CodeProtocol }
+
+// NewErrMalformed returns an ErrMalformed.
+func NewErrMalformed(ftyp Type, err error) *ErrMalformed {
+	return &ErrMalformed{FrameType: ftyp, Err: err}
+}
@@ -0,0 +1,47 @@
+package frame
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+func TestLimits(t *testing.T) {
+	assert.Equal(t, DefaultLimits, Limits{}.OrDefault())
+	assert.Equal(t, 10, Limits{MaxListLength: 10}.OrDefault().MaxListLength)
+
+	limits := Limits{MaxMetadataSize: 8, MaxStringSize: 4, MaxListLength: 2}
+	tests := []struct {
+		f   Frame
+		err string
+	}{
+		{&DataFrame{Metadata: []byte("metadata"), Payload: make([]byte, 100)}, ""},
+		{&DataFrame{Metadata: []byte("metadata!")}, "frame: DataFrame: metadata is too large: 9 > 8"},
+		{&DataFrame{Extensions: map[uint8][]byte{1: []byte("1234"), 2: []byte("5")}}, "frame: DataFrame: extensions is too large: 9 > 8"},
+		{&HandshakeFrame{Name: "sfn", AuthPayload: "token"}, "frame: HandshakeFrame: auth payload is too large: 5 > 4"},
+		{&HandshakeFrame{ObserveDataTags: []Tag{1, 2, 3}}, "frame: HandshakeFrame: observed data tags is too large: 3 > 2"},
+		{&HandshakeFrame{SchemaVersions: map[Tag][]string{1: {"v1", "v10000"}}}, "frame: HandshakeFrame: schema version is too large: 6 > 4"},
+		{&HandshakeAckFrame{Extensions: map[string][]byte{"key": []byte("values")}}, "frame: HandshakeAckFrame: extensions is too large: 9 > 8"},
+		{&RejectedFrame{Message: "nope", Reason: "expired"}, "frame: RejectedFrame: reason is too large: 7 > 4"},
+		{&PingFrame{Payload: []byte("12345")}, "frame: PingFrame: payload is too large: 5 > 4"},
+		{&ObserveUpdateFrame{Subscribe: []Tag{1, 2}, Unsubscribe: []Tag{3}}, "frame: ObserveUpdateFrame: tags is too large: 3 > 2"},
+		{&RequestFrame{ID: "id", Metadata: []byte("metadata!")}, "frame: RequestFrame: metadata is too large: 9 > 8"},
+		{&ResponseFrame{ID: "id", SourceID: "source"}, "frame: ResponseFrame: source id is too large: 6 > 4"},
+		{&ExtensionFrame{FrameType: 0x50, Body: []byte(strings.Repeat("x", 100))}, ""},
+	}
+	for _, tt := range tests {
+		err := limits.Check(tt.f)
+		if tt.err == "" {
+			assert.NoError(t, err)
+			continue
+		}
+		me := new(ErrMalformed)
+		assert.True(t, errors.As(err, &me))
+		assert.Equal(t, tt.f.Type(), me.FrameType)
+		assert.Equal(t, yerr.CodeProtocol, yerr.CodeOf(err))
+		assert.Equal(t, tt.err, err.Error())
+	}
+}
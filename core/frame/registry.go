@@ -0,0 +1,56 @@
+package frame
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// registeredCodec is a codec and the PacketReadWriter that frames its packets.
+type registeredCodec struct {
+	codec Codec
+	prw   PacketReadWriter
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = make(map[string]registeredCodec)
+)
+
+// RegisterCodec makes the codec available by the name, so the clients and the servers select it by
+// configuration, e.g. `core.WithCodecName("cbor")`. It is usually called in the init function of the
+// codec package, and it panics if the name is registered twice or the codec is nil.
+func RegisterCodec(name string, c Codec, prw PacketReadWriter) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	if c == nil || prw == nil {
+		panic("frame: RegisterCodec codec is nil")
+	}
+	if _, dup := codecs[name]; dup {
+		panic(fmt.Sprintf("frame: RegisterCodec called twice for codec %q", name))
+	}
+	codecs[name] = registeredCodec{codec: c, prw: prw}
+}
+
+// LookupCodec returns the codec registered by the name, ok is false if it is not registered.
+func LookupCodec(name string) (c Codec, prw PacketReadWriter, ok bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	rc, ok := codecs[name]
+	return rc.codec, rc.prw, ok
+}
+
+// CodecNames returns the sorted names of the registered codecs.
+func CodecNames() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
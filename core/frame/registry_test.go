@@ -0,0 +1,29 @@
+package frame
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nopCodec struct {
+	Codec
+	PacketReadWriter
+}
+
+func TestRegisterCodec(t *testing.T) {
+	c := &nopCodec{}
+	RegisterCodec("test-nop", c, c)
+
+	got, prw, ok := LookupCodec("test-nop")
+	assert.True(t, ok)
+	assert.Equal(t, c, got)
+	assert.Equal(t, c, prw)
+	assert.Contains(t, CodecNames(), "test-nop")
+
+	_, _, ok = LookupCodec("test-unknown")
+	assert.False(t, ok)
+
+	assert.Panics(t, func() { RegisterCodec("test-nop", c, c) })
+	assert.Panics(t, func() { RegisterCodec("test-nil", nil, c) })
+}
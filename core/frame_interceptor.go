@@ -0,0 +1,43 @@
+package core
+
+import "github.com/yomorun/yomo/core/frame"
+
+// Direction is the direction of the frame passing through the FrameInterceptor.
+type Direction int
+
+const (
+	// DirectionOutbound is the frame written to zipper.
+	DirectionOutbound Direction = iota
+	// DirectionInbound is the frame read from zipper.
+	DirectionInbound
+)
+
+// String returns the name of the direction.
+func (d Direction) String() string {
+	switch d {
+	case DirectionOutbound:
+		return "Outbound"
+	case DirectionInbound:
+		return "Inbound"
+	default:
+		return "Unknown"
+	}
+}
+
+// FrameInterceptor intercepts the frames of all types written to and read from zipper, e.g. for logging,
+// metric tagging, payload validation or transparent encryption. It returns the frame to pass on, which
+// can be mutated or replaced, a nil frame drops the frame silently.
+// Returning an error drops the frame as well, the error is returned by WriteFrame for the outbound frame,
+// and is passed to the error handler for the inbound frame.
+type FrameInterceptor func(dir Direction, f frame.Frame) (frame.Frame, error)
+
+// interceptFrame passes the frame through the frame interceptors in order.
+func (c *Client) interceptFrame(dir Direction, f frame.Frame) (frame.Frame, error) {
+	for _, intercept := range c.opts.frameInterceptors {
+		var err error
+		if f, err = intercept(dir, f); err != nil || f == nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestFrameInterceptor(t *testing.T) {
+	var seen []string
+	logging := func(dir Direction, f frame.Frame) (frame.Frame, error) {
+		seen = append(seen, dir.String()+" "+f.Type().String())
+		return f, nil
+	}
+	validating := func(dir Direction, f frame.Frame) (frame.Frame, error) {
+		df, ok := f.(*frame.DataFrame)
+		if !ok {
+			return f, nil
+		}
+		switch df.Tag {
+		case 0x22:
+			return nil, errors.New("invalid payload")
+		case 0x23:
+			return nil, nil
+		}
+		return &frame.DataFrame{Tag: df.Tag, Payload: bytes.ToUpper(df.Payload)}, nil
+	}
+
+	client := NewClient("sfn", testaddr, ClientTypeStreamFunction,
+		WithLogger(discardingLogger),
+		WithWriteBufferSize(10),
+		WithFrameInterceptor(logging),
+		WithFrameInterceptor(validating),
+	)
+
+	// outbound.
+	assert.NoError(t, client.WriteFrameContext(context.TODO(), &frame.DataFrame{Tag: 0x21, Payload: []byte("hello")}))
+	assert.EqualError(t, client.WriteFrameContext(context.TODO(), &frame.DataFrame{Tag: 0x22}), "invalid payload")
+	assert.NoError(t, client.WriteFrameContext(context.TODO(), &frame.DataFrame{Tag: 0x23}))
+
+	assert.Len(t, client.wrCh, 1)
+	assert.Equal(t, []byte("HELLO"), (<-client.wrCh).(*frame.DataFrame).Payload)
+
+	// inbound.
+	var (
+		received []*frame.DataFrame
+		errs     []error
+	)
+	client.SetDataFrameObserver(func(df *frame.DataFrame) { received = append(received, df) })
+	client.SetErrorHandler(func(err error) { errs = append(errs, err) })
+
+	client.handleFrame(&frame.DataFrame{Tag: 0x21, Payload: []byte("world")})
+	client.handleFrame(&frame.DataFrame{Tag: 0x22})
+	client.handleFrame(&frame.DataFrame{Tag: 0x23})
+
+	assert.Len(t, received, 1)
+	assert.Equal(t, []byte("WORLD"), received[0].Payload)
+	assert.Len(t, errs, 1)
+
+	assert.Equal(t, []string{
+		"Outbound DataFrame", "Outbound DataFrame", "Outbound DataFrame",
+		"Inbound DataFrame", "Inbound DataFrame", "Inbound DataFrame",
+	}, seen)
+}
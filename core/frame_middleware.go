@@ -0,0 +1,68 @@
+package core
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+)
+
+// DataFrameFunc mutates, enriches, filters or rejects the DataFrames at zipper before they are routed,
+// e.g. stamping the region to the metadata or dropping the PII fields of the payload.
+// It returns the DataFrame to route, which can be mutated or replaced, a nil DataFrame drops it silently.
+// Returning an error rejects the DataFrame, the source connected to the zipper is reported by the ErrorFrame
+// with ErrorCodeRejected.
+// The ctx is the *Context of the DataFrame, the metadata of the DataFrame is the merged FrameMetadata.
+type DataFrameFunc func(ctx context.Context, df *frame.DataFrame) (*frame.DataFrame, error)
+
+// DataFrameMiddleware returns the FrameMiddleware that passes the DataFrames through the fn, e.g.
+//
+//	core.WithFrameMiddleware(core.DataFrameMiddleware(func(ctx context.Context, df *frame.DataFrame) (*frame.DataFrame, error) {
+//		md, _ := metadata.Decode(df.Metadata)
+//		md.Set("region", "eu")
+//		df.Metadata, _ = md.Encode()
+//		return df, nil
+//	}))
+func DataFrameMiddleware(fn DataFrameFunc) FrameMiddleware {
+	return func(next FrameHandler) FrameHandler {
+		return func(c *Context) {
+			mdBytes, err := c.FrameMetadata.Encode()
+			if err != nil {
+				c.Logger.Error("failed to encode metadata", "tag", c.Frame.Tag, "err", err)
+				return
+			}
+			c.Frame.Metadata = mdBytes
+
+			df, err := fn(c, c.Frame)
+			if err != nil {
+				c.Logger.Debug("frame middleware rejects frame", "tag", c.Frame.Tag, "err", err)
+				// the source writing the DataFrame is the connection, unless it is relayed by an upstream zipper.
+				ef := NewErrorFrame(c.Frame, c.FrameMetadata, ErrorCodeRejected, err.Error())
+				if ef != nil && c.Connection.ClientType() == ClientTypeSource {
+					if err := c.Connection.FrameConn().WriteFrame(ef); err != nil {
+						c.Logger.Info("failed to write frame to source", "type", ef.Type().String(), "err", err)
+					}
+				}
+				return
+			}
+			if df == nil {
+				c.Logger.Debug("frame middleware drops frame", "tag", c.Frame.Tag)
+				return
+			}
+
+			// the metadata is decoded again if the fn changes it.
+			if !bytes.Equal(df.Metadata, mdBytes) {
+				md, err := metadata.Decode(df.Metadata)
+				if err != nil {
+					c.Logger.Error("frame middleware returns invalid metadata", "tag", df.Tag, "err", err)
+					return
+				}
+				c.FrameMetadata = md
+			}
+			c.Frame = df
+
+			next(c)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/ylog"
+)
+
+func TestDataFrameMiddleware(t *testing.T) {
+	fconn := &recordConn{stalledConn: newStalledConn()}
+	conn := newConnection("source", "source-id", ClientTypeSource, metadata.M{}, nil, fconn, ylog.Default())
+
+	var routed *Context
+	handle := func(fn DataFrameFunc, payload string) {
+		routed = nil
+		md := NewMetadata("source-id", "tid", "", "", false)
+		c := &Context{Connection: conn, Frame: &frame.DataFrame{Tag: 1, Payload: []byte(payload)}, FrameMetadata: md, Logger: ylog.Default()}
+		DataFrameMiddleware(fn)(func(c *Context) { routed = c })(c)
+	}
+
+	t.Run("enrich", func(t *testing.T) {
+		handle(func(ctx context.Context, df *frame.DataFrame) (*frame.DataFrame, error) {
+			md, err := metadata.Decode(df.Metadata)
+			assert.NoError(t, err)
+			assert.Equal(t, "tid", GetTIDFromMetadata(md))
+
+			md.Set("region", "eu")
+			df.Metadata, _ = md.Encode()
+			df.Payload = []byte("redacted")
+			return df, nil
+		}, "pii")
+
+		region, _ := routed.FrameMetadata.Get("region")
+		assert.Equal(t, "eu", region)
+		assert.Equal(t, "redacted", string(routed.Frame.Payload))
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		handle(func(context.Context, *frame.DataFrame) (*frame.DataFrame, error) { return nil, nil }, "yomo")
+		assert.Nil(t, routed)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		handle(func(context.Context, *frame.DataFrame) (*frame.DataFrame, error) {
+			return nil, errors.New("pii is not allowed")
+		}, "pii")
+		assert.Nil(t, routed)
+
+		fconn.mu.Lock()
+		defer fconn.mu.Unlock()
+		assert.Len(t, fconn.frames, 1)
+		ef := fconn.frames[0].(*frame.ErrorFrame)
+		assert.Equal(t, ErrorCodeRejected, ef.Code)
+		assert.Equal(t, "pii is not allowed", ef.Message)
+		assert.Equal(t, "tid", ef.TID)
+	})
+}
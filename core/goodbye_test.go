@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/router"
+)
+
+func TestGoodbye(t *testing.T) {
+	t.Parallel()
+
+	const goodbyeAddr = "127.0.0.1:19975"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	server.ConfigRouter(router.Default())
+	go server.ListenAndServe(context.TODO(), goodbyeAddr)
+	defer server.Close()
+
+	received := make(chan *frame.DataFrame, 2)
+	sfn := NewClient("sfn", goodbyeAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	sfn.SetObserveDataTags(1)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df })
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	source := NewClient("source", goodbyeAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("hello")}))
+	select {
+	case df := <-received:
+		assert.Equal(t, []byte("hello"), df.Payload)
+	case <-time.After(3 * time.Second):
+		t.Fatal("the sfn does not receive the data")
+	}
+
+	// the departing sfn is not routed to, though the connection is still open.
+	assert.NoError(t, sfn.WriteFrame(&frame.GoodbyeFrame{Message: "bye"}))
+	assert.Eventually(t, func() bool {
+		conn, ok, _ := server.connector.Get(sfn.clientID + "-0")
+		return ok && conn.Departing()
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Empty(t, server.router.Route(1, nil))
+
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("dropped")}))
+	select {
+	case df := <-received:
+		t.Fatalf("the departing sfn receives the data: %s", df.Payload)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// the source says goodbye on closing.
+	sourceID := source.clientID + "-0"
+	assert.NoError(t, source.Close())
+	assert.Eventually(t, func() bool {
+		_, ok, _ := server.connector.Get(sourceID)
+		return !ok
+	}, 3*time.Second, 10*time.Millisecond)
+}
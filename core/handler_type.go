@@ -8,5 +8,7 @@ import (
 // AsyncHandler is the request-response mode (asnyc)
 type AsyncHandler func(ctx serverless.Context)
 
-// PipeHandler is the bidirectional stream mode (blocking).
-type PipeHandler func(in <-chan []byte, out chan<- *frame.DataFrame)
+// PipeHandler is the bidirectional stream mode (blocking), it is a long-lived processing loop
+// that receives the observed DataFrames from in and writes the results to out,
+// the batching and scheduling are up to the handler.
+type PipeHandler func(in <-chan *frame.DataFrame, out chan<- *frame.DataFrame)
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ResourceUsage is the goroutines and the buffered bytes owned by a connection,
+// both of them return to zero after the connection is closed.
+type ResourceUsage struct {
+	Goroutines int64
+	Bytes      int64
+}
+
+// IsZero reports whether the connection owns nothing.
+func (u ResourceUsage) IsZero() bool {
+	return u.Goroutines == 0 && u.Bytes == 0
+}
+
+// resourceTracker accounts the goroutines and buffers owned by a connection.
+type resourceTracker struct {
+	goroutines atomic.Int64
+	bytes      atomic.Int64
+}
+
+// Go runs fn in a goroutine owned by the connection.
+func (t *resourceTracker) Go(fn func()) {
+	t.goroutines.Add(1)
+	go func() {
+		defer t.goroutines.Add(-1)
+		fn()
+	}()
+}
+
+func (t *resourceTracker) acquire(n int) { t.bytes.Add(int64(n)) }
+
+func (t *resourceTracker) release(n int) { t.bytes.Add(-int64(n)) }
+
+func (t *resourceTracker) usage() ResourceUsage {
+	return ResourceUsage{Goroutines: t.goroutines.Load(), Bytes: t.bytes.Load()}
+}
+
+// Resources returns the goroutines and buffers owned by the connection.
+func (c *Connection) Resources() ResourceUsage {
+	return c.resources.usage()
+}
+
+// checkLeaks waits for the resources of the closed connection to return to zero,
+// it reports a leak if they do not within the grace period.
+func (s *Server) checkLeaks(conn *Connection, grace time.Duration) {
+	const interval = 10 * time.Millisecond
+
+	deadline := time.Now().Add(grace)
+	for {
+		usage := conn.Resources()
+		if usage.IsZero() {
+			return
+		}
+		if time.Now().After(deadline) {
+			s.leakedConns.Add(1)
+			conn.Logger.Warn("connection leaks resources after closed", "goroutines", usage.Goroutines, "bytes", usage.Bytes)
+			return
+		}
+		time.Sleep(interval)
+	}
+}
+
+// LeakedConnections returns the number of closed connections that leak resources,
+// it is always zero if the leak detection is disabled, see WithLeakDetection.
+func (s *Server) LeakedConnections() int64 {
+	return s.leakedConns.Load()
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/router"
+)
+
+func TestLeakDetection(t *testing.T) {
+	t.Parallel()
+
+	const leakAddr = "127.0.0.1:19980"
+
+	server := NewServer("zipper",
+		WithServerLogger(discardingLogger),
+		WithLeakDetection(time.Second),
+		WithSlowConsumerPolicy(SlowConsumerBlock, 4),
+		WithSpillDir(t.TempDir()),
+		WithSpill(2, 1<<10),
+	)
+	go server.ListenAndServe(context.TODO(), leakAddr)
+	defer server.Close()
+
+	server.ConfigRouter(router.Default())
+
+	// the sfn owns a slow consumer queue, a spill queue and a read queue.
+	sfn := NewClient("sfn", leakAddr, ClientTypeStreamFunction, WithLogger(discardingLogger), WithReadQueue(4, ReadQueueBlock))
+	sfn.SetObserveDataTags(1, 2)
+	sfn.SetDataFrameObserver(func(*frame.DataFrame) {})
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	assert.NotZero(t, sfn.Resources().Goroutines)
+
+	source := NewClient("source", leakAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.NoError(t, source.Connect(context.TODO()))
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("hello")}))
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 2, Payload: []byte("hello")}))
+	assert.Eventually(t, func() bool { return server.StatsCounter() == 2 }, 3*time.Second, 10*time.Millisecond)
+
+	conn, ok, _ := server.connector.Get(source.clientID + "-0")
+	assert.True(t, ok)
+	assert.NotZero(t, conn.Resources().Goroutines)
+
+	sfnConn, ok, _ := server.connector.Get(sfn.clientID + "-0")
+	assert.True(t, ok)
+
+	assert.NoError(t, source.Close())
+	assert.NoError(t, sfn.Close())
+	for _, r := range []interface{ Resources() ResourceUsage }{conn, sfnConn, source, sfn} {
+		assert.Eventually(t, func() bool { return r.Resources().IsZero() }, 3*time.Second, 10*time.Millisecond)
+	}
+	assert.Equal(t, int64(0), server.LeakedConnections())
+
+	t.Run("leak", func(t *testing.T) {
+		leaked := &Connection{Logger: discardingLogger}
+		leaked.resources.acquire(10)
+		server.checkLeaks(leaked, 20*time.Millisecond)
+		assert.Equal(t, ResourceUsage{Bytes: 10}, leaked.Resources())
+		assert.Equal(t, int64(1), server.LeakedConnections())
+	})
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/yomorun/yomo/core/metadata"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// MetadataHopsKey is the metadata key of the number of the zippers that the frame is relayed by.
+	MetadataHopsKey = "yomo-hops"
+	// MetadataZipperPathKey is the metadata key of the names of the zippers that the frame passes,
+	// they are joined by commas in order, see WithMeshRelay.
+	MetadataZipperPathKey = "yomo-zipper-path"
+)
+
+// DefaultMaxHops is the default max number of the zippers relaying a frame, see WithMeshRelay.
+const DefaultMaxHops = 8
+
+// GetZipperPathFromMetadata gets the names of the zippers that the frame passes from metadata.
+func GetZipperPathFromMetadata(m metadata.M) []string {
+	path, _ := m.Get(MetadataZipperPathKey)
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ",")
+}
+
+// GetHopsFromMetadata gets the number of the zippers that the frame is relayed by from metadata.
+func GetHopsFromMetadata(m metadata.M) int {
+	hops, _ := m.GetInt64(MetadataHopsKey)
+	return int(hops)
+}
+
+// relayed reports whether the frame from an upstream zipper has passed this zipper before,
+// the frame comes back through a loop of the mesh, it has been routed here and is dropped.
+func (s *Server) relayed(c *Context) bool {
+	if s.opts.maxHops <= 0 || c.Connection.ClientType() != ClientTypeUpstreamZipper {
+		return false
+	}
+	return slices.Contains(GetZipperPathFromMetadata(c.FrameMetadata), s.name)
+}
+
+// relayPath appends this zipper to the path of the frame and counts the hop, it returns the path
+// that the frame must not be relayed to, and false if the frame has been relayed by too many zippers.
+func (s *Server) relayPath(md metadata.M) ([]string, bool) {
+	hops := GetHopsFromMetadata(md)
+	if hops >= s.opts.maxHops {
+		return nil, false
+	}
+	path := append(GetZipperPathFromMetadata(md), s.name)
+	md.Set(MetadataZipperPathKey, strings.Join(path, ","))
+	md.SetInt64(MetadataHopsKey, int64(hops+1))
+	return path, true
+}
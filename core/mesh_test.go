@@ -0,0 +1,65 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/ylog"
+)
+
+// namedDownstream is a recordDownstream named by the zipper it forwards to.
+type namedDownstream struct {
+	recordDownstream
+	name string
+}
+
+func (d *namedDownstream) ID() string        { return d.name }
+func (d *namedDownstream) LocalName() string { return d.name }
+
+func TestMeshRelay(t *testing.T) {
+	s := NewServer("b", WithMeshRelay(2))
+	toA, toC := &namedDownstream{name: "a"}, &namedDownstream{name: "c"}
+	s.AddDownstreamServer(toA)
+	s.AddDownstreamServer(toC)
+
+	upstream := newConnection("a", "a-id", ClientTypeUpstreamZipper, metadata.M{}, nil, nil, ylog.Default())
+	newContext := func(md metadata.M) *Context {
+		return &Context{
+			Connection:    upstream,
+			Frame:         &frame.DataFrame{Tag: 1, Payload: []byte("yomo")},
+			FrameMetadata: md,
+			Logger:        ylog.Default(),
+		}
+	}
+
+	// the frame from a is relayed to c, not back to a.
+	c := newContext(metadata.M{MetadataZipperPathKey: "a", MetadataHopsKey: "1"})
+	assert.False(t, s.relayed(c))
+	assert.NoError(t, s.dispatchToDownstreams(c))
+	assert.Equal(t, 0, toA.len())
+	assert.Equal(t, 1, toC.len())
+
+	md, err := metadata.Decode(toC.frames[0].Metadata)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, GetZipperPathFromMetadata(md))
+	assert.Equal(t, 2, GetHopsFromMetadata(md))
+
+	// the frame has been relayed by too many zippers.
+	c = newContext(metadata.M{MetadataZipperPathKey: "x,a", MetadataHopsKey: "2"})
+	assert.NoError(t, s.dispatchToDownstreams(c))
+	assert.Equal(t, 1, toC.len())
+
+	// the frame comes back through a loop.
+	assert.True(t, s.relayed(newContext(metadata.M{MetadataZipperPathKey: "b,c,a"})))
+
+	// the frames from upstream zippers are not relayed by default.
+	s = NewServer("b")
+	toC = &namedDownstream{name: "c"}
+	s.AddDownstreamServer(toC)
+	c = newContext(metadata.M{})
+	assert.False(t, s.relayed(c))
+	assert.NoError(t, s.dispatchToDownstreams(c))
+	assert.Equal(t, 0, toC.len())
+}
@@ -1,6 +1,9 @@
 package core
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/yomorun/yomo/core/metadata"
 	"github.com/yomorun/yomo/pkg/id"
 	"github.com/yomorun/yomo/pkg/trace"
@@ -12,6 +15,17 @@ const (
 	// the keys for yomo working.
 	MetadataSourceIDKey = "yomo-source-id"
 	MetadataTIDKey      = "yomo-tid"
+	MetadataTargetKey   = metadata.TargetKey
+	MetadataExpireKey   = "yomo-expire"
+	MetadataZipperKey   = "yomo-zipper"
+
+	// MetadataPartitionKey is the key of the data partition, the zipper dispatches the data of a partition
+	// to the same sfn, see DispatchSticky.
+	MetadataPartitionKey = "yomo-partition-key"
+
+	// MetadataTenantIDKey is the key of the tenant, it is stamped by the zipper from the connection
+	// metadata, the frames are only routed between the clients of the same tenant.
+	MetadataTenantIDKey = metadata.TenantIDKey
 
 	// the keys for tracing.
 	MetadataTraceIDKey = "yomo-trace-id"
@@ -42,10 +56,43 @@ func GetTIDFromMetadata(m metadata.M) string {
 	return tid
 }
 
+// GetTargetFromMetadata gets target from metadata, the target is the name of the stream function
+// that the data is routed to exclusively.
+func GetTargetFromMetadata(m metadata.M) string {
+	target, _ := m.Get(MetadataTargetKey)
+	return target
+}
+
+// GetPartitionKeyFromMetadata gets the partition key from metadata.
+func GetPartitionKeyFromMetadata(m metadata.M) string {
+	key, _ := m.Get(MetadataPartitionKey)
+	return key
+}
+
+// GetTenantIDFromMetadata gets the tenant ID from metadata, it is empty if the client has no tenant.
+func GetTenantIDFromMetadata(m metadata.M) string {
+	tenantID, _ := m.Get(MetadataTenantIDKey)
+	return tenantID
+}
+
+// SetExpireToMetadata sets the expiration time to metadata.
+func SetExpireToMetadata(m metadata.M, expire time.Time) {
+	m.Set(MetadataExpireKey, strconv.FormatInt(expire.UnixMilli(), 10))
+}
+
+// GetExpireFromMetadata gets the expiration time from metadata, ok is false if it is not set.
+func GetExpireFromMetadata(m metadata.M) (expire time.Time, ok bool) {
+	ms, ok := m.GetInt64(MetadataExpireKey)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
 // GetTracedFromMetadata gets traced from metadata.
 func GetTracedFromMetadata(m metadata.M) bool {
-	tracedString, _ := m.Get(MetaTracedKey)
-	return tracedString == "true"
+	traced, _ := m.GetBool(MetaTracedKey)
+	return traced
 }
 
 // SourceMetadata generates source metadata with trace information.
@@ -53,6 +100,7 @@ func SourceMetadata(
 	sourceID, tid string,
 	spanName string, // the span name usually is the source name.
 	tp oteltrace.TracerProvider, logger *slog.Logger,
+	attrs ...map[string]string, // the span attributes.
 ) (metadata.M, func()) {
 	var (
 		traceID string
@@ -61,7 +109,7 @@ func SourceMetadata(
 		endFn   = func() {}
 	)
 	if tp != nil {
-		span, err := trace.NewSpan(tp, "Source", spanName, "", "")
+		span, err := trace.NewSpanWithAttrs(tp, "Source", spanName, "", "", false, attrs...)
 		if err != nil {
 			logger.Debug("trace error", "tracer_name", "Source", "span_name", spanName, "err", err)
 		} else {
@@ -95,6 +143,7 @@ func ExtendTraceMetadata(
 	tracerName string, // the tracer name is `StreamFunction` or `Zipper`.
 	spanName string, // the span name usually is the sfn name.
 	tp oteltrace.TracerProvider, logger *slog.Logger,
+	attrs ...map[string]string, // the span attributes.
 ) (metadata.M, func()) {
 	var (
 		traceID, _   = md.Get(MetadataTraceIDKey)
@@ -108,9 +157,9 @@ func ExtendTraceMetadata(
 		var err error
 		// set parent span, if not traced, use empty string
 		if parentTraced {
-			span, err = trace.NewSpan(tp, string(tracerName), spanName, traceID, spanID)
+			span, err = trace.NewSpanWithAttrs(tp, string(tracerName), spanName, traceID, spanID, false, attrs...)
 		} else {
-			span, err = trace.NewSpan(tp, string(tracerName), spanName, "", "")
+			span, err = trace.NewSpanWithAttrs(tp, string(tracerName), spanName, "", "", false, attrs...)
 		}
 		if err != nil {
 			logger.Debug("trace error", "tracer_name", tracerName, "span_name", spanName, "err", err)
@@ -148,15 +197,17 @@ func ExtendTraceMetadata(
 }
 
 // SfnTraceMetadata extends metadata for StreamFunction.
-func SfnTraceMetadata(md metadata.M, sfnName string, tp oteltrace.TracerProvider, logger *slog.Logger) (metadata.M, func()) {
-	return ExtendTraceMetadata(md, "StreamFunction", sfnName, tp, logger)
+func SfnTraceMetadata(md metadata.M, sfnName string, tp oteltrace.TracerProvider, logger *slog.Logger, attrs ...map[string]string) (metadata.M, func()) {
+	return ExtendTraceMetadata(md, "StreamFunction", sfnName, tp, logger, attrs...)
 }
 
 // ZipperTraceMetadata extends metadata for Zipper.
-func ZipperTraceMetadata(md metadata.M, tp oteltrace.TracerProvider, logger *slog.Logger) (metadata.M, func()) {
-	return ExtendTraceMetadata(md, "Zipper", "zipper endpoint", tp, logger)
+func ZipperTraceMetadata(md metadata.M, tp oteltrace.TracerProvider, logger *slog.Logger, attrs ...map[string]string) (metadata.M, func()) {
+	return ExtendTraceMetadata(md, "Zipper", "zipper endpoint", tp, logger, attrs...)
 }
 
+// tracedString is the string form of traced, it is kept instead of the typed bool,
+// so the peers that compare the value with "true" still read it.
 func tracedString(traced bool) string {
 	if traced {
 		return "true"
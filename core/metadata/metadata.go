@@ -2,6 +2,8 @@
 package metadata
 
 import (
+	"bytes"
+
 	"github.com/vmihailenco/msgpack/v5"
 )
 
@@ -14,6 +16,25 @@ import (
 // the main responsibility of Metadata is to route messages to connection handler.
 type M map[string]string
 
+// TenantIDKey is the key of the tenant of the client, it is returned by `Authentication.Authenticate()`,
+// the zipper stamps it into the frames written by the client and routes the frames within the tenant.
+const TenantIDKey = "yomo-tenant-id"
+
+// TargetKey is the key of the stream function that the frame is routed to exclusively,
+// it only addresses the frame written with it, the frames derived from the frame do not inherit it.
+const TargetKey = "yomo-target"
+
+// SchemaVersionKey is the key of the payload schema version, it only describes the payload written with it,
+// the frames derived from the frame do not inherit it.
+const SchemaVersionKey = "yomo-schema-version"
+
+// WALOffsetKey is the key of the offset of the frame in the write-ahead log of the zipper, it is stamped
+// by the zipper into the journaled frame only, the frames derived from the frame do not inherit it.
+const WALOffsetKey = "yomo-wal-offset"
+
+// ReservedPrefix is the prefix of the keys reserved for yomo working.
+const ReservedPrefix = "yomo-"
+
 // New creates an M from a given key-values map.
 func New(mds ...map[string]string) M {
 	m := M{}
@@ -72,10 +93,31 @@ func (m M) Clone() M {
 	return m2
 }
 
-// Encode encodes the metadata to byte array.
+// Encode encodes the metadata to byte array, the typed values are encoded as msgpack bin,
+// so the str values are valid UTF-8 and the peers knowing strings only still decode them.
 func (m M) Encode() ([]byte, error) {
 	if len(m) == 0 {
 		return nil, nil
 	}
-	return msgpack.Marshal(m)
+
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	if err := enc.EncodeMapLen(len(m)); err != nil {
+		return nil, err
+	}
+	for k, v := range m {
+		if err := enc.EncodeString(k); err != nil {
+			return nil, err
+		}
+		var err error
+		if isTyped(v) {
+			err = enc.EncodeBytes([]byte(v))
+		} else {
+			err = enc.EncodeString(v)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
 }
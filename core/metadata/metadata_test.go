@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 func Test(t *testing.T) {
@@ -92,3 +93,73 @@ func Test(t *testing.T) {
 		})
 	})
 }
+
+func TestTyped(t *testing.T) {
+	md := M{}
+	md.SetBool("bool", true)
+	md.SetInt64("int64", -42)
+	md.SetFloat64("float64", 3.14)
+	md.SetBytes("bytes", []byte{0x00, 0xff})
+	md.Set("string", "hello")
+
+	b, err := md.Encode()
+	assert.NoError(t, err)
+	md, err = Decode(b)
+	assert.NoError(t, err)
+
+	v, ok := md.GetBool("bool")
+	assert.True(t, ok)
+	assert.True(t, v)
+
+	i, ok := md.GetInt64("int64")
+	assert.True(t, ok)
+	assert.Equal(t, int64(-42), i)
+
+	f, ok := md.GetFloat64("float64")
+	assert.True(t, ok)
+	assert.Equal(t, 3.14, f)
+
+	bs, ok := md.GetBytes("bytes")
+	assert.True(t, ok)
+	assert.Equal(t, []byte{0x00, 0xff}, bs)
+
+	bs, ok = md.GetBytes("string")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("hello"), bs)
+
+	t.Run("mismatched kind", func(t *testing.T) {
+		_, ok := md.GetInt64("bool")
+		assert.False(t, ok)
+		_, ok = md.GetBytes("float64")
+		assert.False(t, ok)
+		_, ok = md.GetBool("string")
+		assert.False(t, ok)
+		_, ok = md.GetFloat64("missing")
+		assert.False(t, ok)
+	})
+
+	t.Run("wire types", func(t *testing.T) {
+		// the typed values are bin, and the plain strings are str.
+		var raw map[string]any
+		assert.NoError(t, msgpack.Unmarshal(b, &raw))
+		assert.IsType(t, []byte{}, raw["bool"])
+		assert.IsType(t, []byte{}, raw["bytes"])
+		assert.Equal(t, "hello", raw["string"])
+	})
+
+	t.Run("plain strings", func(t *testing.T) {
+		md := M{"bool": "true", "int64": "42", "float64": "0.5"}
+
+		v, ok := md.GetBool("bool")
+		assert.True(t, ok)
+		assert.True(t, v)
+
+		i, ok := md.GetInt64("int64")
+		assert.True(t, ok)
+		assert.Equal(t, int64(42), i)
+
+		f, ok := md.GetFloat64("float64")
+		assert.True(t, ok)
+		assert.Equal(t, 0.5, f)
+	})
+}
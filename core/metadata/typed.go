@@ -0,0 +1,132 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+)
+
+// The typed values are stored in M as the strings of a binary form, which starts with typedMark
+// and the kind of the value, the plain strings never start with typedMark, so they are read as before.
+// The binary form is not UTF-8, so it is encoded as msgpack bin rather than str, see M.Encode.
+// The typed getters also parse the plain strings, e.g. "true" or "42", so the metadata written by the
+// peers that only know strings is still read by the typed getters.
+const typedMark = 0x00
+
+const (
+	kindBool    byte = 'b'
+	kindInt64   byte = 'i'
+	kindFloat64 byte = 'f'
+	kindBytes   byte = 'y'
+)
+
+// SetBool sets the bool value of the given key.
+func (m M) SetBool(k string, v bool) {
+	b := byte(0)
+	if v {
+		b = 1
+	}
+	m.Set(k, string([]byte{typedMark, kindBool, b}))
+}
+
+// GetBool returns the bool value of the given key, ok is false if the key is not set or the value is not a bool.
+func (m M) GetBool(k string) (v bool, ok bool) {
+	s, ok := m.Get(k)
+	if !ok {
+		return false, false
+	}
+	if data, typed, match := typedValue(s, kindBool); typed {
+		if !match || len(data) != 1 {
+			return false, false
+		}
+		return data[0] == 1, true
+	}
+	v, err := strconv.ParseBool(s)
+	return v, err == nil
+}
+
+// SetInt64 sets the int64 value of the given key, it is encoded as a varint.
+func (m M) SetInt64(k string, v int64) {
+	b := make([]byte, 2, 2+binary.MaxVarintLen64)
+	b[0], b[1] = typedMark, kindInt64
+	m.Set(k, string(binary.AppendVarint(b, v)))
+}
+
+// GetInt64 returns the int64 value of the given key, ok is false if the key is not set or the value is not an int64.
+func (m M) GetInt64(k string) (v int64, ok bool) {
+	s, ok := m.Get(k)
+	if !ok {
+		return 0, false
+	}
+	if data, typed, match := typedValue(s, kindInt64); typed {
+		if !match {
+			return 0, false
+		}
+		v, n := binary.Varint([]byte(data))
+		return v, n > 0 && n == len(data)
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	return v, err == nil
+}
+
+// SetFloat64 sets the float64 value of the given key.
+func (m M) SetFloat64(k string, v float64) {
+	b := make([]byte, 2, 10)
+	b[0], b[1] = typedMark, kindFloat64
+	m.Set(k, string(binary.BigEndian.AppendUint64(b, math.Float64bits(v))))
+}
+
+// GetFloat64 returns the float64 value of the given key, ok is false if the key is not set or the value is not a float64.
+func (m M) GetFloat64(k string) (v float64, ok bool) {
+	s, ok := m.Get(k)
+	if !ok {
+		return 0, false
+	}
+	if data, typed, match := typedValue(s, kindFloat64); typed {
+		if !match || len(data) != 8 {
+			return 0, false
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64([]byte(data))), true
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
+
+// SetBytes sets the bytes value of the given key, the bytes are copied.
+func (m M) SetBytes(k string, v []byte) {
+	b := make([]byte, 0, 2+len(v))
+	b = append(b, typedMark, kindBytes)
+	m.Set(k, string(append(b, v...)))
+}
+
+// GetBytes returns the bytes value of the given key, the plain string value is returned as bytes.
+func (m M) GetBytes(k string) (v []byte, ok bool) {
+	s, ok := m.Get(k)
+	if !ok {
+		return nil, false
+	}
+	if data, typed, match := typedValue(s, kindBytes); typed {
+		if !match {
+			return nil, false
+		}
+		return []byte(data), true
+	}
+	return []byte(s), true
+}
+
+// isTyped reports whether s is the binary form of a typed value.
+func isTyped(s string) bool {
+	return len(s) >= 2 && s[0] == typedMark
+}
+
+// typedValue returns the binary data of the typed value s, typed is false if s is a plain string,
+// and match is false if s is a typed value of another kind.
+func typedValue(s string, kind byte) (data string, typed, match bool) {
+	if !isTyped(s) {
+		return "", false, false
+	}
+	if s[1] != kind {
+		return "", true, false
+	}
+	return s[2:], true, true
+}
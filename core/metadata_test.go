@@ -2,6 +2,7 @@ package core
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -12,4 +13,14 @@ func TestMetadata(t *testing.T) {
 	assert.Equal(t, "source", GetSourceIDFromMetadata(md))
 	assert.Equal(t, "tid", GetTIDFromMetadata(md))
 	assert.Equal(t, true, GetTracedFromMetadata(md))
+	assert.Equal(t, "", GetTargetFromMetadata(md))
+
+	_, ok := GetExpireFromMetadata(md)
+	assert.False(t, ok)
+
+	expire := time.UnixMilli(time.Now().UnixMilli())
+	SetExpireToMetadata(md, expire)
+	got, ok := GetExpireFromMetadata(md)
+	assert.True(t, ok)
+	assert.Equal(t, expire, got)
 }
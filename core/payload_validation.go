@@ -0,0 +1,49 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// PayloadValidator validates the payloads of a tag against its schema at zipper, so the invalid data
+// is rejected where it enters instead of propagating through the pipeline of sfns.
+// See package pkg/schema for the validators of JSON Schema and protobuf descriptors.
+type PayloadValidator interface {
+	// Validate returns an error if the payload does not conform to the schema.
+	Validate(payload []byte) error
+}
+
+// PayloadValidatorFunc is an adapter to use a function as a PayloadValidator.
+type PayloadValidatorFunc func(payload []byte) error
+
+// Validate calls f(payload).
+func (f PayloadValidatorFunc) Validate(payload []byte) error { return f(payload) }
+
+// SetPayloadValidator registers the validator of the payloads of the tag at runtime, it replaces
+// the validator registered before, and a nil validator removes it.
+// The DataFrames failing the validation are dropped, and the sources are reported by the ErrorFrames
+// with ErrorCodeInvalidPayload. The chunked DataFrames of the tag are rejected as well, because a chunk
+// is a part of the payload and zipper does not reassemble them, so they can not be validated.
+func (s *Server) SetPayloadValidator(tag frame.Tag, validator PayloadValidator) {
+	if validator == nil {
+		s.payloadValidators.Delete(tag)
+		return
+	}
+	s.payloadValidators.Store(tag, validator)
+}
+
+// validatePayload validates the payload of the frame by the validator of its tag.
+func (s *Server) validatePayload(c *Context) error {
+	v, ok := s.payloadValidators.Load(c.Frame.Tag)
+	if !ok {
+		return nil
+	}
+	if c.Frame.IsChunked() {
+		return fmt.Errorf("invalid payload of tag %d: the chunked payload can not be validated", c.Frame.Tag)
+	}
+	if err := v.(PayloadValidator).Validate(c.Frame.Payload); err != nil {
+		return fmt.Errorf("invalid payload of tag %d: %w", c.Frame.Tag, err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/router"
+)
+
+func TestPayloadValidator(t *testing.T) {
+	t.Parallel()
+
+	const validatorAddr = "127.0.0.1:19969"
+
+	notEmpty := PayloadValidatorFunc(func(payload []byte) error {
+		if len(payload) == 0 {
+			return errors.New("empty payload")
+		}
+		return nil
+	})
+	server := NewServer("zipper", WithServerLogger(discardingLogger), WithPayloadValidator(1, notEmpty))
+	server.ConfigRouter(router.Default())
+	go server.ListenAndServe(context.TODO(), validatorAddr)
+	defer server.Close()
+
+	received := make(chan *frame.DataFrame, 2)
+	sfn := NewClient("sfn", validatorAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	sfn.SetObserveDataTags(1)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df })
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	errorFrames := make(chan *frame.ErrorFrame, 2)
+	source := NewClient("source", validatorAddr, ClientTypeSource, WithLogger(discardingLogger))
+	source.SetErrorFrameObserver(func(ef *frame.ErrorFrame) { errorFrames <- ef })
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	receiveError := func() *frame.ErrorFrame {
+		select {
+		case ef := <-errorFrames:
+			return ef
+		case <-time.After(3 * time.Second):
+			t.Fatal("the source does not receive the error frame")
+			return nil
+		}
+	}
+
+	md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md}))
+
+	ef := receiveError()
+	assert.Equal(t, ErrorCodeInvalidPayload, ef.Code)
+	assert.Equal(t, "invalid payload of tag 1: empty payload", ef.Message)
+
+	// the validator is replaced at runtime.
+	server.SetPayloadValidator(1, PayloadValidatorFunc(func(payload []byte) error {
+		if !bytes.Equal(payload, []byte("yomo")) {
+			return errors.New("not yomo")
+		}
+		return nil
+	}))
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("hello")}))
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("yomo")}))
+
+	select {
+	case df := <-received:
+		assert.Equal(t, []byte("yomo"), df.Payload)
+	case <-time.After(3 * time.Second):
+		t.Fatal("the sfn does not receive the data")
+	}
+	assert.Equal(t, "invalid payload of tag 1: not yomo", receiveError().Message)
+
+	// the chunks can not be validated.
+	chunk := frame.Chunk{ID: 1, Seq: 0, Total: 2}
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("yomo"), Chunk: chunk}))
+	assert.Equal(t, "invalid payload of tag 1: the chunked payload can not be validated", receiveError().Message)
+
+	// the validator is removed.
+	server.SetPayloadValidator(1, nil)
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md}))
+	select {
+	case df := <-received:
+		assert.Empty(t, df.Payload)
+	case <-time.After(3 * time.Second):
+		t.Fatal("the sfn does not receive the data")
+	}
+}
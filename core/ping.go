@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+// pinger measures the round-trip time of the application layer by PingFrames. The payload of the
+// PingFrame is the sending time, followed by a sequence number if a Ping call waits for the PongFrame,
+// the peer echoes the payload as is.
+type pinger struct {
+	rtt     atomic.Int64 // the last round-trip time, in nanoseconds
+	seq     atomic.Uint64
+	waiters sync.Map // the Ping calls waiting for the PongFrames, seq -> chan time.Duration
+}
+
+// ping writes a PingFrame by write and waits for the PongFrame until the ctx or done is done.
+func (p *pinger) ping(ctx context.Context, done <-chan struct{}, write func(*frame.PingFrame) error) (time.Duration, error) {
+	seq := p.seq.Add(1)
+	pong := make(chan time.Duration, 1)
+	p.waiters.Store(seq, pong)
+	defer p.waiters.Delete(seq)
+
+	payload := make([]byte, 16)
+	binary.BigEndian.PutUint64(payload, uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint64(payload[8:], seq)
+	if err := write(&frame.PingFrame{Payload: payload}); err != nil {
+		return 0, err
+	}
+
+	select {
+	case rtt := <-pong:
+		return rtt, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-done:
+		return 0, ErrPingCanceled
+	}
+}
+
+// pong records the round-trip time of the PongFrame and wakes up the Ping call waiting for it.
+func (p *pinger) pong(payload []byte, now time.Time) {
+	if len(payload) < 8 {
+		return
+	}
+	rtt := now.Sub(time.Unix(0, int64(binary.BigEndian.Uint64(payload))))
+	p.rtt.Store(int64(rtt))
+
+	if len(payload) < 16 {
+		return
+	}
+	if v, ok := p.waiters.Load(binary.BigEndian.Uint64(payload[8:])); ok {
+		select {
+		case v.(chan time.Duration) <- rtt:
+		default:
+		}
+	}
+}
+
+// ErrPingCanceled is returned by Client.Ping if the client is closed before the PongFrame arrives.
+var ErrPingCanceled = yerr.New(yerr.CodeClosed, "yomo: ping canceled, the client is closed")
+
+// Ping sends a PingFrame to zipper and waits for the PongFrame, it returns the round-trip time of
+// the application layer, which includes the time that the frames are queued in the client and zipper.
+// The measured round-trip time is also returned by RTT afterwards.
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	return c.pings.ping(ctx, c.ctx.Done(), func(f *frame.PingFrame) error {
+		return c.WriteFrameContext(ctx, f)
+	})
+}
+
+// RTT returns the round-trip time between the client and zipper which is measured by heartbeat or Ping,
+// it returns 0 if the heartbeat is not enabled and Ping has not been called.
+func (c *Client) RTT() time.Duration {
+	return time.Duration(c.pings.rtt.Load())
+}
+
+// Ping sends a PingFrame to the client and waits for the PongFrame, it returns the round-trip time
+// of the application layer. The clients older than the ping of zipper never answer, so the ctx
+// should have a deadline.
+func (c *Connection) Ping(ctx context.Context) (time.Duration, error) {
+	return c.pings.ping(ctx, nil, func(f *frame.PingFrame) error {
+		return c.fconn.WriteFrame(f)
+	})
+}
+
+// RTT returns the round-trip time between zipper and the client which is measured by Ping,
+// it returns 0 if Ping has not been called.
+func (c *Connection) RTT() time.Duration {
+	return time.Duration(c.pings.rtt.Load())
+}
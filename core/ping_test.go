@@ -0,0 +1,47 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+
+	const pingAddr = "127.0.0.1:19973"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	go server.ListenAndServe(context.TODO(), pingAddr)
+	defer server.Close()
+
+	source := NewClient("source", pingAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	// the client pings zipper.
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rtt, err := source.Ping(ctx)
+	assert.NoError(t, err)
+	assert.Greater(t, rtt, time.Duration(0))
+	assert.Equal(t, rtt, source.RTT())
+
+	// zipper pings the client.
+	conn, ok, err := server.connector.Get(source.clientID + "-0")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	rtt, err = conn.Ping(ctx)
+	assert.NoError(t, err)
+	assert.Greater(t, rtt, time.Duration(0))
+	assert.Equal(t, rtt, conn.RTT())
+
+	// the Ping waiting for the PongFrame is canceled by closing the client.
+	assert.NoError(t, source.Close())
+	_, err = source.Ping(ctx)
+	assert.Error(t, err)
+}
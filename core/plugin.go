@@ -0,0 +1,57 @@
+package core
+
+import "fmt"
+
+// Plugin adds custom behaviors to the zipper, e.g. billing, custom routing and protocol bridges.
+// The plugins are compiled in and registered by WithPlugin, or loaded from Go plugins by LoadPlugin
+// if the build tag `yomo_plugin` is set.
+// The hooks are called in the order of registration.
+type Plugin interface {
+	// Name returns the name of the plugin, it is used in logs.
+	Name() string
+	// OnStart is called when the zipper starts serving, the zipper stops if it returns an error.
+	OnStart(s *Server) error
+	// OnConnection is called after a client passes the handshake, the client is rejected if it returns an error.
+	OnConnection(conn *Connection) error
+	// OnFrame is called for every DataFrame before routing, the frame is dropped if it returns an error.
+	OnFrame(c *Context) error
+	// OnClose is called when the zipper stops serving.
+	OnClose(s *Server)
+}
+
+// plugins are the registered plugins of the server.
+type plugins []Plugin
+
+func (ps plugins) start(s *Server) error {
+	for _, p := range ps {
+		if err := p.OnStart(s); err != nil {
+			return fmt.Errorf("yomo: plugin %s failed to start: %w", p.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (ps plugins) connection(conn *Connection) error {
+	for _, p := range ps {
+		if err := p.OnConnection(conn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// frame calls the OnFrame hooks, it returns the name of plugin that drops the frame.
+func (ps plugins) frame(c *Context) (string, error) {
+	for _, p := range ps {
+		if err := p.OnFrame(c); err != nil {
+			return p.Name(), err
+		}
+	}
+	return "", nil
+}
+
+func (ps plugins) close(s *Server) {
+	for i := len(ps) - 1; i >= 0; i-- {
+		ps[i].OnClose(s)
+	}
+}
@@ -0,0 +1,31 @@
+//go:build yomo_plugin
+
+package core
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// PluginSymbol is the symbol that a Go plugin exports for LoadPlugin,
+// it is a variable whose type implements the Plugin interface.
+const PluginSymbol = "Plugin"
+
+// LoadPlugin loads the Plugin exported as `PluginSymbol` from the Go plugin at path.
+func LoadPlugin(path string) (Plugin, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, err
+	}
+	switch v := sym.(type) {
+	case Plugin:
+		return v, nil
+	case *Plugin:
+		return *v, nil
+	}
+	return nil, fmt.Errorf("yomo: symbol %s of plugin %s is %T, not a Plugin", PluginSymbol, path, sym)
+}
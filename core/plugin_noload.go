@@ -0,0 +1,15 @@
+//go:build !yomo_plugin
+
+package core
+
+import "errors"
+
+// PluginSymbol is the symbol that a Go plugin exports for LoadPlugin,
+// it is a variable whose type implements the Plugin interface.
+const PluginSymbol = "Plugin"
+
+// LoadPlugin returns an error, loading the Go plugins is excluded by default because it requires cgo,
+// set the build tag `yomo_plugin` to include it.
+func LoadPlugin(path string) (Plugin, error) {
+	return nil, errors.New("yomo: loading plugin is excluded from the build, set the build tag yomo_plugin to include it")
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// testPlugin rejects the client named "rejected" and drops the frames of tag 2.
+type testPlugin struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (p *testPlugin) record(event string) {
+	p.mu.Lock()
+	p.events = append(p.events, event)
+	p.mu.Unlock()
+}
+
+func (p *testPlugin) Events() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string{}, p.events...)
+}
+
+func (p *testPlugin) Name() string { return "test" }
+
+func (p *testPlugin) OnStart(s *Server) error {
+	p.record("start " + s.Name())
+	return nil
+}
+
+func (p *testPlugin) OnConnection(conn *Connection) error {
+	if conn.Name() == "rejected" {
+		return &ErrRejected{Message: "rejected by plugin"}
+	}
+	p.record("connection " + conn.Name())
+	return nil
+}
+
+func (p *testPlugin) OnFrame(c *Context) error {
+	if c.Frame.Tag == 2 {
+		return errors.New("tag 2 is dropped")
+	}
+	return nil
+}
+
+func (p *testPlugin) OnClose(s *Server) { p.record("close " + s.Name()) }
+
+func TestPlugin(t *testing.T) {
+	t.Parallel()
+
+	const pluginAddr = "127.0.0.1:19981"
+
+	plugin := &testPlugin{}
+	server := NewServer("zipper", WithServerLogger(discardingLogger), WithPlugin(plugin))
+	go server.ListenAndServe(context.TODO(), pluginAddr)
+
+	source := NewClient("source", pluginAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	for _, tag := range []frame.Tag{1, 2, 1} {
+		assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: tag, Payload: []byte("hello")}))
+	}
+	assert.Eventually(t, func() bool { return server.StatsCounter() == 2 }, 3*time.Second, 10*time.Millisecond)
+
+	rejected := NewClient("rejected", pluginAddr, ClientTypeSource, WithLogger(discardingLogger))
+	err := rejected.Connect(context.TODO())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected by plugin")
+	_, ok, _ := server.connector.Get(rejected.clientID + "-0")
+	assert.False(t, ok)
+
+	assert.NoError(t, server.Close())
+	assert.Eventually(t, func() bool { return len(plugin.Events()) == 3 }, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"start zipper", "connection source", "close zipper"}, plugin.Events())
+	assert.Equal(t, int64(2), server.StatsCounter())
+}
+
+func TestLoadPlugin(t *testing.T) {
+	_, err := LoadPlugin("testdata/not-exist.so")
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// Quota bounds the rate of the DataFrames that a client writes to the zipper, so a runaway client
+// does not starve the others. See WithQuota, WithClientQuota and WithCredentialQuota.
+type Quota struct {
+	// FramesPerSecond is the rate of the DataFrames, 0 means unlimited.
+	FramesPerSecond float64
+	// FrameBurst is the max number of the DataFrames in a burst, it is FramesPerSecond if it is 0.
+	FrameBurst int
+	// BytesPerSecond is the rate of the bytes of the payloads, 0 means unlimited.
+	BytesPerSecond float64
+	// ByteBurst is the max number of the bytes in a burst, it is BytesPerSecond if it is 0.
+	ByteBurst int
+	// Disconnect disconnects the client exceeding the quota by a GoawayFrame with ReasonQuotaExceeded,
+	// otherwise the DataFrames exceeding the quota are dropped, and the clients are throttled by
+	// the ErrorFrames with ErrorCodeQuotaExceeded, at most one every quotaErrorInterval.
+	// The dropped DataFrames are not acknowledged, so the clients with WithAck write them again.
+	Disconnect bool
+}
+
+// quotaErrorInterval is the min interval of the ErrorFrames throttling a connection.
+const quotaErrorInterval = time.Second
+
+// quotas are the quotas of the server, the quota of the credential takes precedence over the quota
+// of the client name, which takes precedence over the default quota.
+type quotas struct {
+	all         *Quota
+	names       map[string]Quota
+	credentials map[string]Quota
+}
+
+// of returns the quota of the client, ok is false if the client is unlimited.
+// The default quota is not applied to the upstream zippers, because they relay the frames of many clients.
+func (q quotas) of(hf *frame.HandshakeFrame) (Quota, bool) {
+	if quota, ok := q.credentials[hf.AuthName+":"+hf.AuthPayload]; ok {
+		return quota, true
+	}
+	if quota, ok := q.names[hf.Name]; ok {
+		return quota, true
+	}
+	if q.all != nil && ClientType(hf.ClientType) != ClientTypeUpstreamZipper {
+		return *q.all, true
+	}
+	return Quota{}, false
+}
+
+// quotaLimiter enforces the quota of a connection.
+type quotaLimiter struct {
+	frames     *rateLimiter
+	bytes      *rateLimiter
+	disconnect bool
+	evicted    atomic.Bool  // the connection is being disconnected
+	lastError  atomic.Int64 // the unix nano time of the last ErrorFrame throttling the connection
+}
+
+// newQuotaLimiter returns the limiter of the quota, it returns nil if the quota is unlimited.
+func newQuotaLimiter(q Quota) *quotaLimiter {
+	l := &quotaLimiter{disconnect: q.Disconnect}
+	if q.FramesPerSecond > 0 {
+		l.frames = newRateLimiter(q.FramesPerSecond, burstOf(q.FrameBurst, q.FramesPerSecond))
+	}
+	if q.BytesPerSecond > 0 {
+		l.bytes = newRateLimiter(q.BytesPerSecond, burstOf(q.ByteBurst, q.BytesPerSecond))
+	}
+	if l.frames == nil && l.bytes == nil {
+		return nil
+	}
+	return l
+}
+
+func burstOf(burst int, rate float64) int {
+	if burst > 0 {
+		return burst
+	}
+	return int(math.Ceil(rate))
+}
+
+// allow reports whether the DataFrame of size bytes is in the quota, if it is not,
+// it returns the duration after which the client is in the quota again.
+// Both the frame and the byte budgets are taken, or neither is, so a rejected DataFrame costs nothing.
+func (l *quotaLimiter) allow(now time.Time, size int) (time.Duration, bool) {
+	if l.frames != nil {
+		if delay, ok := l.frames.peekN(now, 1); !ok {
+			return delay, false
+		}
+	}
+	if l.bytes != nil {
+		if delay, ok := l.bytes.takeN(now, float64(size)); !ok {
+			return delay, false
+		}
+	}
+	if l.frames != nil {
+		l.frames.take(now)
+	}
+	return 0, true
+}
+
+// throttle reports whether the client should be told that it is throttled, it is told at most once
+// every quotaErrorInterval, so a flood of dropped frames does not turn into a flood of ErrorFrames.
+func (l *quotaLimiter) throttle(now time.Time) bool {
+	last := l.lastError.Load()
+	if last != 0 && now.UnixNano()-last < int64(quotaErrorInterval) {
+		return false
+	}
+	return l.lastError.CompareAndSwap(last, now.UnixNano())
+}
+
+// checkQuota checks the DataFrame against the quota of its connection, the DataFrame exceeding the quota
+// is dropped without being acknowledged, then the client is throttled or disconnected.
+func (s *Server) checkQuota(c *Context) bool {
+	l := c.Connection.quota
+	if l == nil {
+		return true
+	}
+	if l.evicted.Load() {
+		c.unacked = true
+		return false
+	}
+	now := time.Now()
+	delay, ok := l.allow(now, len(c.Frame.Payload))
+	if ok {
+		return true
+	}
+	c.unacked = true
+
+	message := fmt.Sprintf("quota exceeded, retry after %s", delay)
+	if l.disconnect {
+		if l.evicted.CompareAndSwap(false, true) {
+			c.Logger.Warn("disconnect client exceeding quota", "tag", c.Frame.Tag, "retry_after", delay)
+			goaway(c.Connection.FrameConn(), message, ReasonQuotaExceeded)
+		}
+		return false
+	}
+	c.Logger.Debug("drop frame exceeding quota", "tag", c.Frame.Tag, "retry_after", delay)
+	if !l.throttle(now) {
+		return false
+	}
+	// the ErrorFrame is written to the throttled client, which is not the source of the DataFrame
+	// if it is written by a stream function.
+	s.writeErrorFrame(c.Connection, &frame.ErrorFrame{
+		SourceID: GetSourceIDFromMetadata(c.FrameMetadata),
+		TID:      GetTIDFromMetadata(c.FrameMetadata),
+		Tag:      c.Frame.Tag,
+		Code:     ErrorCodeQuotaExceeded,
+		Message:  message,
+	})
+	return false
+}
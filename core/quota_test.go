@@ -0,0 +1,193 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/router"
+)
+
+func TestQuotas(t *testing.T) {
+	opts := defaultServerOptions()
+	WithQuota(Quota{FramesPerSecond: 100})(opts)
+	WithClientQuota(Quota{FramesPerSecond: 10}, "slow")(opts)
+	WithCredentialQuota(Quota{FramesPerSecond: 1}, "token:runaway")(opts)
+
+	quota, ok := opts.quotas.of(&frame.HandshakeFrame{Name: "source", ClientType: byte(ClientTypeSource)})
+	assert.True(t, ok)
+	assert.Equal(t, 100.0, quota.FramesPerSecond)
+
+	quota, _ = opts.quotas.of(&frame.HandshakeFrame{Name: "slow", ClientType: byte(ClientTypeSource)})
+	assert.Equal(t, 10.0, quota.FramesPerSecond)
+
+	quota, _ = opts.quotas.of(&frame.HandshakeFrame{Name: "slow", AuthName: "token", AuthPayload: "runaway"})
+	assert.Equal(t, 1.0, quota.FramesPerSecond)
+
+	_, ok = opts.quotas.of(&frame.HandshakeFrame{Name: "zipper", ClientType: byte(ClientTypeUpstreamZipper)})
+	assert.False(t, ok)
+
+	assert.Nil(t, newQuotaLimiter(Quota{}))
+}
+
+func TestQuotaLimiter(t *testing.T) {
+	l := newQuotaLimiter(Quota{FramesPerSecond: 10, BytesPerSecond: 100})
+	now := time.Now()
+	l.frames.last, l.bytes.last = now, now
+
+	// the frame larger than the burst is taken from a full bucket.
+	_, ok := l.allow(now, 150)
+	assert.True(t, ok)
+
+	delay, ok := l.allow(now.Add(500*time.Millisecond), 10)
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, delay)
+
+	_, ok = l.allow(now.Add(2*time.Second), 10)
+	assert.True(t, ok)
+
+	// the frames are limited by the frame rate.
+	for i := 0; i < 9; i++ {
+		_, ok = l.allow(now.Add(2*time.Second), 0)
+		assert.True(t, ok)
+	}
+	delay, ok = l.allow(now.Add(2*time.Second), 0)
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, delay)
+
+	// the frame rejected on the bytes does not take the frame budget.
+	l = newQuotaLimiter(Quota{FramesPerSecond: 10, FrameBurst: 1, BytesPerSecond: 100})
+	l.frames.last, l.bytes.last = now, now
+	_, ok = l.allow(now, 150)
+	assert.True(t, ok)
+	_, ok = l.allow(now.Add(100*time.Millisecond), 10)
+	assert.False(t, ok)
+	assert.Equal(t, 1.0, l.frames.tokens)
+
+	// the client is told that it is throttled once every interval.
+	assert.True(t, l.throttle(now))
+	assert.False(t, l.throttle(now.Add(quotaErrorInterval/2)))
+	assert.True(t, l.throttle(now.Add(quotaErrorInterval)))
+}
+
+func TestQuota(t *testing.T) {
+	t.Parallel()
+
+	const quotaAddr = "127.0.0.1:19968"
+
+	server := NewServer(
+		"zipper",
+		WithServerLogger(discardingLogger),
+		WithQuota(Quota{FramesPerSecond: 0.01, FrameBurst: 1}),
+		WithClientQuota(Quota{FramesPerSecond: 0.01, FrameBurst: 1, Disconnect: true}, "runaway"),
+	)
+	server.ConfigRouter(router.Default())
+	go server.ListenAndServe(context.TODO(), quotaAddr)
+	defer server.Close()
+
+	received := make(chan *frame.DataFrame, 4)
+	sfn := NewClient("sfn", quotaAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	sfn.SetObserveDataTags(1)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df })
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	receive := func() {
+		select {
+		case <-received:
+		case <-time.After(3 * time.Second):
+			t.Fatal("the sfn does not receive the data")
+		}
+	}
+
+	t.Run("throttle", func(t *testing.T) {
+		errorFrames := make(chan *frame.ErrorFrame, 4)
+		source := NewClient("source", quotaAddr, ClientTypeSource, WithLogger(discardingLogger), WithAck(time.Minute, 1))
+		source.SetErrorFrameObserver(func(ef *frame.ErrorFrame) { errorFrames <- ef })
+		assert.NoError(t, source.Connect(context.TODO()))
+		defer source.Close()
+
+		md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+		assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("yomo")}))
+		receive()
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("yomo")}))
+		}
+		select {
+		case ef := <-errorFrames:
+			assert.Equal(t, ErrorCodeQuotaExceeded, ef.Code)
+		case <-time.After(3 * time.Second):
+			t.Fatal("the source does not receive the error frame")
+		}
+
+		// the source is throttled once, and the dropped frames are not acknowledged.
+		pending := func() int {
+			source.acks.mu.Lock()
+			defer source.acks.mu.Unlock()
+			return len(source.acks.pending)
+		}
+		assert.Eventually(t, func() bool { return pending() == 3 }, 3*time.Second, 10*time.Millisecond)
+		assert.Empty(t, errorFrames)
+	})
+
+	t.Run("throttle stream function", func(t *testing.T) {
+		sourceErrorFrames := make(chan *frame.ErrorFrame, 4)
+		source := NewClient("source", quotaAddr, ClientTypeSource, WithLogger(discardingLogger))
+		source.SetErrorFrameObserver(func(ef *frame.ErrorFrame) { sourceErrorFrames <- ef })
+		assert.NoError(t, source.Connect(context.TODO()))
+		defer source.Close()
+
+		errorFrames := make(chan *frame.ErrorFrame, 4)
+		writer := NewClient("sfn-writer", quotaAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+		writer.SetObserveDataTags(2)
+		writer.SetErrorFrameObserver(func(ef *frame.ErrorFrame) { errorFrames <- ef })
+		assert.NoError(t, writer.Connect(context.TODO()))
+		defer writer.Close()
+
+		// the output of the sfn carries the metadata of the source.
+		md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+		assert.NoError(t, writer.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("yomo")}))
+		receive()
+
+		assert.NoError(t, writer.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("yomo")}))
+		select {
+		case ef := <-errorFrames:
+			assert.Equal(t, ErrorCodeQuotaExceeded, ef.Code)
+		case <-time.After(3 * time.Second):
+			t.Fatal("the sfn does not receive the error frame")
+		}
+		assert.Empty(t, sourceErrorFrames)
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		errs := make(chan error, 8)
+		source := NewClient("runaway", quotaAddr, ClientTypeSource, WithLogger(discardingLogger))
+		source.SetErrorHandler(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		})
+		assert.NoError(t, source.Connect(context.TODO()))
+		defer source.Close()
+
+		assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("yomo")}))
+		receive()
+
+		assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("yomo")}))
+		for {
+			select {
+			case err := <-errs:
+				if errors.Is(err, ErrQuotaExceeded) {
+					return
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatal("the source is not disconnected")
+			}
+		}
+	})
+}
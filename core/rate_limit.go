@@ -0,0 +1,89 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+// ErrRateLimited is returned by WriteFrame in non-blocking mode if the write rate limit is hit.
+type ErrRateLimited struct {
+	// RetryAfter is the duration after which the next frame can be written.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *ErrRateLimited) Error() string {
+	return fmt.Sprintf("yomo: write rate limited, retry after %s", e.RetryAfter)
+}
+
+// ErrorCode implements the yerr.Coder interface.
+func (e *ErrRateLimited) ErrorCode() yerr.Code { return yerr.CodeOverload }
+
+// rateLimiter is a token bucket, it allows rps frames per second with bursts of at most burst frames.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rps    float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rps:    rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// take takes a token, if no token is available, it returns the duration to wait for the next token.
+func (l *rateLimiter) take(now time.Time) (time.Duration, bool) {
+	return l.takeN(now, 1)
+}
+
+// takeN takes n tokens, if they are not available, it returns the duration to wait for them.
+// The n larger than burst is taken from a full bucket, and the next tokens wait for the debt to be paid.
+func (l *rateLimiter) takeN(now time.Time, n float64) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if delay, ok := l.wait(now, n); !ok {
+		return delay, false
+	}
+	l.tokens -= n
+	return 0, true
+}
+
+// peekN reports whether n tokens are available without taking them, if they are not,
+// it returns the duration to wait for them.
+func (l *rateLimiter) peekN(now time.Time, n float64) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.wait(now, n)
+}
+
+// wait refills the bucket and reports whether n tokens are available, it must be called with mu held.
+func (l *rateLimiter) wait(now time.Time, n float64) (time.Duration, bool) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += elapsed.Seconds() * l.rps
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+	}
+
+	need := math.Min(n, l.burst)
+	if l.tokens >= need {
+		return 0, true
+	}
+	return time.Duration((need - l.tokens) / l.rps * float64(time.Second)), false
+}
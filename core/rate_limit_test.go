@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(10, 2)
+	now := l.last
+
+	for i := 0; i < 2; i++ {
+		_, ok := l.take(now)
+		assert.True(t, ok)
+	}
+
+	delay, ok := l.take(now)
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, delay)
+
+	_, ok = l.take(now.Add(100 * time.Millisecond))
+	assert.True(t, ok)
+
+	// the tokens are capped by burst.
+	for i := 0; i < 2; i++ {
+		_, ok := l.take(now.Add(time.Hour))
+		assert.True(t, ok)
+	}
+	_, ok = l.take(now.Add(time.Hour))
+	assert.False(t, ok)
+}
+
+func TestWriteRateLimit(t *testing.T) {
+	t.Run("blocking", func(t *testing.T) {
+		client := NewClient("source", testaddr, ClientTypeSource, WithWriteBufferSize(10), WithWriteRateLimit(20, 1))
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: 1}))
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := client.WriteFrameContext(ctx, &frame.DataFrame{Tag: 1})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("non-blocking", func(t *testing.T) {
+		client := NewClient(
+			"source", testaddr, ClientTypeSource,
+			WithWriteBufferSize(10), WithWriteOverflowPolicy(WriteOverflowError), WithWriteRateLimit(1, 1),
+		)
+
+		assert.NoError(t, client.WriteFrame(&frame.DataFrame{Tag: 1}))
+
+		err := client.WriteFrame(&frame.DataFrame{Tag: 1})
+		e := new(ErrRateLimited)
+		assert.True(t, errors.As(err, &e))
+		assert.Greater(t, e.RetryAfter, time.Duration(0))
+	})
+}
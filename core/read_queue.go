@@ -0,0 +1,124 @@
+package core
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// ReadQueuePolicy decides what the client does when the read queue is full.
+type ReadQueuePolicy int
+
+const (
+	// ReadQueueBlock stops reading until the processor takes a frame from the queue, this is the default policy.
+	ReadQueueBlock ReadQueuePolicy = iota
+	// ReadQueueDropNewest drops the incoming frame.
+	ReadQueueDropNewest
+	// ReadQueueDropOldest drops the oldest frame in the queue to make room for the incoming one.
+	ReadQueueDropOldest
+)
+
+// String returns the name of the policy.
+func (p ReadQueuePolicy) String() string {
+	switch p {
+	case ReadQueueBlock:
+		return "block"
+	case ReadQueueDropNewest:
+		return "drop-newest"
+	case ReadQueueDropOldest:
+		return "drop-oldest"
+	default:
+		return fmt.Sprintf("ReadQueuePolicy(%d)", int(p))
+	}
+}
+
+// enqueueRead puts the DataFrame read from zipper into the read queue with the policy of the queue,
+// the dropped frames are counted in DroppedFrames, and the queued frames are accounted to the resources.
+func (c *Client) enqueueRead(df *frame.DataFrame) {
+	c.resources.acquire(frameSize(df))
+	// the frame queued after the client is closed is abandoned here, the processor has stopped.
+	defer func() {
+		select {
+		case <-c.ctx.Done():
+			c.drainReadQueue()
+		default:
+		}
+	}()
+
+	switch c.opts.rdQueuePolicy {
+	case ReadQueueDropNewest:
+		select {
+		case c.rdQueue <- df:
+		default:
+			c.Logger.Debug("read queue full, drop the newest frame", "tag", df.Tag)
+			c.resources.release(frameSize(df))
+			c.recordDrop(df)
+		}
+	case ReadQueueDropOldest:
+		for {
+			select {
+			case c.rdQueue <- df:
+				return
+			default:
+			}
+			select {
+			case dropped := <-c.rdQueue:
+				c.Logger.Debug("read queue full, drop the oldest frame", "tag", dropped.Tag)
+				c.resources.release(frameSize(dropped))
+				c.recordDrop(dropped)
+			default:
+			}
+		}
+	default:
+		select {
+		case c.rdQueue <- df:
+		case <-c.ctx.Done():
+			c.resources.release(frameSize(df))
+		}
+	}
+}
+
+// processReadQueue passes the DataFrames in the read queue to the processor until the client is closed,
+// so a slow processor does not stall the connection.
+func (c *Client) processReadQueue() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			c.drainReadQueue()
+			return
+		case df := <-c.rdQueue:
+			size := frameSize(df)
+			c.handleFrameSafely(df)
+			c.resources.release(size)
+		}
+	}
+}
+
+// drainReadQueue abandons the frames in the read queue of the closed client.
+func (c *Client) drainReadQueue() {
+	for {
+		select {
+		case df := <-c.rdQueue:
+			c.resources.release(frameSize(df))
+		default:
+			return
+		}
+	}
+}
+
+// handleFrameSafely handles the frame, the panic of handling is recovered and passed to the error handler.
+func (c *Client) handleFrameSafely(f frame.Frame) {
+	defer func() {
+		if e := recover(); e != nil {
+			const size = 64 << 10
+			buf := make([]byte, size)
+			buf = buf[:runtime.Stack(buf, false)]
+
+			perr := fmt.Errorf("%v", e)
+			c.Logger.Error("stream panic", "err", perr)
+			c.reportError(fmt.Errorf("yomo: stream panic: %v\n%s", perr, buf))
+		}
+	}()
+	c.handleFrame(f)
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/frame-codec/y3codec"
+	yquic "github.com/yomorun/yomo/pkg/listener/quic"
+	pkgtls "github.com/yomorun/yomo/pkg/tls"
+)
+
+func TestReadQueuePolicy(t *testing.T) {
+	queued := func(c *Client) []byte {
+		var payloads []byte
+		for len(c.rdQueue) > 0 {
+			payloads = append(payloads, (<-c.rdQueue).Payload...)
+		}
+		return payloads
+	}
+
+	t.Run("drop newest", func(t *testing.T) {
+		c := NewClient("sfn", testaddr, ClientTypeStreamFunction, WithLogger(discardingLogger), WithReadQueue(2, ReadQueueDropNewest))
+		for i := byte(0); i < 4; i++ {
+			c.enqueueRead(&frame.DataFrame{Tag: 1, Payload: []byte{i}})
+		}
+		assert.Equal(t, []byte{0, 1}, queued(c))
+		assert.Equal(t, map[frame.Tag]int64{1: 2}, c.DroppedFrames())
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		c := NewClient("sfn", testaddr, ClientTypeStreamFunction, WithLogger(discardingLogger), WithReadQueue(2, ReadQueueDropOldest))
+		for i := byte(0); i < 4; i++ {
+			c.enqueueRead(&frame.DataFrame{Tag: 1, Payload: []byte{i}})
+		}
+		assert.Equal(t, []byte{2, 3}, queued(c))
+		assert.Equal(t, map[frame.Tag]int64{1: 2}, c.DroppedFrames())
+	})
+
+	t.Run("block", func(t *testing.T) {
+		c := NewClient("sfn", testaddr, ClientTypeStreamFunction, WithLogger(discardingLogger), WithReadQueue(1, ReadQueueBlock))
+		c.enqueueRead(&frame.DataFrame{Tag: 1, Payload: []byte{0}})
+
+		done := make(chan struct{})
+		go func() {
+			c.enqueueRead(&frame.DataFrame{Tag: 1, Payload: []byte{1}})
+			close(done)
+		}()
+		select {
+		case <-done:
+			t.Fatal("the full queue does not block")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		assert.Equal(t, []byte{0}, (<-c.rdQueue).Payload)
+		<-done
+		assert.Equal(t, []byte{1}, queued(c))
+		assert.Empty(t, c.DroppedFrames())
+	})
+
+	assert.Equal(t, "drop-oldest", ReadQueueDropOldest.String())
+}
+
+func TestReadQueueSlowProcessor(t *testing.T) {
+	const readQueueAddr = "127.0.0.1:19984"
+
+	// the zipper writes 10 DataFrames after the handshake.
+	listener, err := yquic.ListenAddr(readQueueAddr, y3codec.Codec(), y3codec.PacketReadWriter(),
+		pkgtls.MustCreateServerTLSConfig(readQueueAddr), DefaultQuicConfig)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		fconn, err := listener.Accept(context.TODO())
+		if err != nil {
+			return
+		}
+		for {
+			f, err := fconn.ReadFrame()
+			if err != nil {
+				return
+			}
+			if _, ok := f.(*frame.HandshakeFrame); ok {
+				_ = fconn.WriteFrame(&frame.HandshakeAckFrame{})
+				for i := byte(0); i < 10; i++ {
+					_ = fconn.WriteFrame(&frame.DataFrame{Tag: 0x21, Payload: []byte{i}})
+				}
+			}
+		}
+	}()
+
+	gate := make(chan struct{})
+	received := make(chan byte, 10)
+	sfn := NewClient("sfn", readQueueAddr, ClientTypeStreamFunction,
+		WithLogger(discardingLogger),
+		WithReadQueue(2, ReadQueueDropNewest),
+	)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) {
+		<-gate
+		received <- df.Payload[0]
+	})
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	// the processor holds a frame, the queue holds at most 2 frames and the others are dropped.
+	assert.Eventually(t, func() bool {
+		return sfn.DroppedFrames()[0x21]+int64(len(sfn.rdQueue)) == 9
+	}, time.Second, 10*time.Millisecond)
+	dropped := sfn.DroppedFrames()[0x21]
+	assert.GreaterOrEqual(t, dropped, int64(7))
+
+	close(gate)
+	got := []byte{}
+	for i := int64(0); i < 10-dropped; i++ {
+		got = append(got, <-received)
+	}
+	assert.Equal(t, byte(0), got[0])
+	assert.IsIncreasing(t, got)
+}
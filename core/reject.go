@@ -0,0 +1,76 @@
+package core
+
+import (
+	"strings"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+// RejectReason is the machine-readable reason why the zipper rejects a handshake or evicts a connection,
+// it is carried by RejectedFrame and GoawayFrame.
+type RejectReason string
+
+const (
+	// ReasonAuthFailed means the credential is invalid.
+	ReasonAuthFailed RejectReason = "auth_failed"
+	// ReasonAuthExpired means the credential is expired, the client should refresh the credential,
+	// see auth.ExpiringAuthentication.
+	ReasonAuthExpired RejectReason = "auth_expired"
+	// ReasonServerDraining means the zipper is shutting down, the client should reconnect later or to another zipper.
+	ReasonServerDraining RejectReason = "server_draining"
+	// ReasonDuplicateClient means a client with the same ID is connected.
+	ReasonDuplicateClient RejectReason = "duplicate_client"
+	// ReasonVersionUnsupported means the zipper does not speak the protocol version of the client,
+	// the client should be upgraded.
+	ReasonVersionUnsupported RejectReason = "version_unsupported"
+	// ReasonQuotaExceeded means the client writes faster than its quota, see WithQuota.
+	ReasonQuotaExceeded RejectReason = "quota_exceeded"
+)
+
+// The typed errors of the reasons, use errors.Is to check the ErrRejected, e.g.
+//
+//	errors.Is(err, core.ErrAuthExpired)
+var (
+	ErrAuthenticateFailed = yerr.New(yerr.CodeAuth, "yomo: authenticate failed")
+	ErrAuthExpired        = yerr.New(yerr.CodeAuth, "yomo: auth expired")
+	ErrServerDraining     = yerr.New(yerr.CodeRouting, "yomo: server draining")
+	ErrDuplicateClient    = yerr.New(yerr.CodeAuth, "yomo: duplicate client")
+	ErrVersionUnsupported = yerr.New(yerr.CodeProtocol, "yomo: protocol version unsupported")
+	ErrQuotaExceeded      = yerr.New(yerr.CodeOverload, "yomo: quota exceeded")
+)
+
+var reasonErrors = map[RejectReason]error{
+	ReasonAuthFailed:         ErrAuthenticateFailed,
+	ReasonAuthExpired:        ErrAuthExpired,
+	ReasonServerDraining:     ErrServerDraining,
+	ReasonDuplicateClient:    ErrDuplicateClient,
+	ReasonVersionUnsupported: ErrVersionUnsupported,
+	ReasonQuotaExceeded:      ErrQuotaExceeded,
+}
+
+// rejectedError returns the ErrRejected of the message and reason from zipper.
+func rejectedError(message, reason string) *ErrRejected {
+	r := RejectReason(reason)
+	// the zippers that don't know the reason reject the invalid credential with the message only.
+	if r == "" && strings.HasPrefix(message, "authentication failed") {
+		r = ReasonAuthFailed
+	}
+	return &ErrRejected{Message: message, Reason: r}
+}
+
+// goawayError returns the ErrRejected of the GoawayFrame.
+func goawayError(f *frame.GoawayFrame) *ErrRejected {
+	return rejectedError(f.Message, f.Reason)
+}
+
+// goawayGrace is the time for the client to read the GoawayFrame before the connection is closed.
+const goawayGrace = time.Second
+
+// goaway evicts the connection by a GoawayFrame of the reason, the client closes the connection
+// once it reads the GoawayFrame, otherwise the connection is closed after goawayGrace.
+func goaway(fconn frame.Conn, message string, reason RejectReason) {
+	_ = fconn.WriteFrame(&frame.GoawayFrame{Message: message, Reason: string(reason)})
+	time.AfterFunc(goawayGrace, func() { _ = fconn.CloseWithError(message) })
+}
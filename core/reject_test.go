@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/pkg/frame-codec/y3codec"
+	yquic "github.com/yomorun/yomo/pkg/listener/quic"
+	pkgtls "github.com/yomorun/yomo/pkg/tls"
+)
+
+func TestRejectedError(t *testing.T) {
+	tests := []struct {
+		message string
+		reason  string
+		want    error
+	}{
+		{"bad token", "auth_failed", ErrAuthenticateFailed},
+		{"token expired", "auth_expired", ErrAuthExpired},
+		{"bye", "server_draining", ErrServerDraining},
+		{"client-1 is connected", "duplicate_client", ErrDuplicateClient},
+		{"authentication failed: client credential type is token", "", ErrAuthenticateFailed},
+	}
+	for _, tt := range tests {
+		err := rejectedError(tt.message, tt.reason)
+		assert.Equal(t, tt.message, err.Error())
+		assert.ErrorIs(t, err, tt.want)
+	}
+
+	err := rejectedError("version negotiation failed", "")
+	assert.Nil(t, errors.Unwrap(err))
+	assert.False(t, errors.Is(err, ErrAuthenticateFailed))
+
+	w := &mockFrameWriter{}
+	assert.ErrorIs(t, rejectHandshake(w, &ErrRejected{Message: "token expired", Reason: ReasonAuthExpired}), ErrAuthExpired)
+	assert.Equal(t, &frame.RejectedFrame{Message: "token expired", Reason: "auth_expired"}, w.f)
+}
+
+func TestGoawayDraining(t *testing.T) {
+	const drainingAddr = "127.0.0.1:19985"
+
+	// the zipper evicts the first connection as draining, and keeps the next one.
+	listener, err := yquic.ListenAddr(drainingAddr, y3codec.Codec(), y3codec.PacketReadWriter(),
+		pkgtls.MustCreateServerTLSConfig(drainingAddr), DefaultQuicConfig)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	var accepted atomic.Int32
+	go func() {
+		for {
+			fconn, err := listener.Accept(context.TODO())
+			if err != nil {
+				return
+			}
+			n := accepted.Add(1)
+			go func() {
+				for {
+					f, err := fconn.ReadFrame()
+					if err != nil {
+						return
+					}
+					if _, ok := f.(*frame.HandshakeFrame); ok {
+						_ = fconn.WriteFrame(&frame.HandshakeAckFrame{})
+						if n == 1 {
+							_ = fconn.WriteFrame(&frame.GoawayFrame{Message: "bye", Reason: string(ReasonServerDraining)})
+						}
+					}
+				}
+			}()
+		}
+	}()
+
+	errs := make(chan error, 10)
+	client := NewClient("source", drainingAddr, ClientTypeSource, WithLogger(discardingLogger))
+	client.SetErrorHandler(func(err error) { errs <- err })
+	assert.NoError(t, client.Connect(context.TODO()))
+	defer client.Close()
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, ErrServerDraining)
+	case <-time.After(time.Second):
+		t.Fatal("the client does not handle the draining goaway")
+	}
+
+	assert.Eventually(t, func() bool {
+		return accepted.Load() == 2 && client.State() == StateConnected
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+// expiringAuth accepts the token "valid" and refuses the token "expired" as expired.
+type expiringAuth struct{}
+
+func (expiringAuth) Init(...string) {}
+func (expiringAuth) Authenticate(payload string) (metadata.M, bool) {
+	return metadata.M{}, payload == "valid"
+}
+func (expiringAuth) Name() string                { return "expiring" }
+func (expiringAuth) Expired(payload string) bool { return payload == "expired" }
+
+func TestRejectReasons(t *testing.T) {
+	const rejectAddr = "127.0.0.1:19960"
+
+	auth.Register(expiringAuth{})
+
+	server := NewServer("zipper", WithAuth("expiring"), WithServerLogger(discardingLogger))
+	go server.ListenAndServe(context.TODO(), rejectAddr)
+	defer server.Close()
+
+	t.Run("auth expired", func(t *testing.T) {
+		client := NewClient("source", rejectAddr, ClientTypeSource,
+			WithCredential("expiring:expired"), WithLogger(discardingLogger))
+		assert.ErrorIs(t, client.Connect(context.TODO()), ErrAuthExpired)
+
+		client = NewClient("source", rejectAddr, ClientTypeSource,
+			WithCredential("expiring:invalid"), WithLogger(discardingLogger))
+		assert.ErrorIs(t, client.Connect(context.TODO()), ErrAuthenticateFailed)
+	})
+
+	t.Run("duplicate client", func(t *testing.T) {
+		client := NewClient("source", rejectAddr, ClientTypeSource,
+			WithCredential("expiring:valid"), WithLogger(discardingLogger))
+		assert.NoError(t, client.Connect(context.TODO()))
+		defer client.Close()
+
+		duplicated := NewClient("source", rejectAddr, ClientTypeSource,
+			WithCredential("expiring:valid"), WithLogger(discardingLogger))
+		duplicated.clientID = client.clientID
+		assert.ErrorIs(t, duplicated.Connect(context.TODO()), ErrDuplicateClient)
+
+		// the connection of the client is kept.
+		assert.Equal(t, map[string]int{"Source": 1}, server.StatsConnections())
+	})
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"errors"
+	"time"
+
+	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// ErrReloadUnsupported is returned by Server.Reload if the server has no ReloadFunc.
+var ErrReloadUnsupported = errors.New("yomo: the zipper does not support reloading, see WithReloadFunc")
+
+// ReloadFunc reloads the configuration of the server, e.g. it parses the config file again and applies the
+// changes by Server.SetAuths, Server.SetDispatchPolicies, Server.AddDownstreamServer and Server.RemoveDownstreamServer.
+// The connected clients stay connected while the server is reloaded.
+type ReloadFunc func(s *Server) error
+
+// Reload reloads the configuration of the server by the ReloadFunc, it is called on SIGHUP
+// or by `POST /reload` of the admin.
+func (s *Server) Reload() error {
+	if s.opts.reloadFunc == nil {
+		s.logger.Warn("failed to reload", "err", ErrReloadUnsupported)
+		return ErrReloadUnsupported
+	}
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	if err := s.opts.reloadFunc(s); err != nil {
+		s.logger.Error("failed to reload", "err", err)
+		return err
+	}
+	s.logger.Info("reloaded", "auth_name", s.authNames(), "downstreams", s.Downstreams())
+	return nil
+}
+
+// SetAuths replaces the authentication methods of the server, the key is the name of the registered
+// authentication and the value is its arguments, see WithAuth. An empty auths disables the authentication.
+// The new methods authenticate the new connections, the connected clients are not authenticated again.
+func (s *Server) SetAuths(auths map[string][]string) {
+	s.authMu.Lock()
+	defer s.authMu.Unlock()
+
+	s.opts.auths = make(map[string]auth.Authentication, len(auths))
+	for name, args := range auths {
+		WithAuth(name, args...)(s.opts)
+	}
+}
+
+// SetDispatchPolicies replaces the dispatch policies of the server, policy applies to the tags not in tags,
+// and stickyTTL is the TTL of DispatchSticky, see WithDispatchPolicy and WithStickyRouting.
+// The frames routed after it returns are dispatched by the new policies, the sticky keys keep their sfns.
+func (s *Server) SetDispatchPolicies(policy DispatchPolicy, tags map[frame.Tag]DispatchPolicy, stickyTTL time.Duration) {
+	s.dispatcher.setPolicies(dispatchPolicies{all: policy, tags: tags, stickyTTL: stickyTTL})
+}
+
+// RemoveDownstreamServer closes the downstream of the id and removes it from the server,
+// it does nothing if the downstream does not exist.
+func (s *Server) RemoveDownstreamServer(id string) {
+	s.mu.Lock()
+	ds, ok := s.downstreams[id]
+	delete(s.downstreams, id)
+	s.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	s.downstreamHealths.Delete(id)
+	if err := ds.Close(); err != nil {
+		s.logger.Warn("failed to close downstream", "downstream_id", id, "downstream_name", ds.LocalName(), "err", err)
+	}
+}
+
+// snapshotDownstreams returns the downstreams, they may be added or removed while the server is serving.
+func (s *Server) snapshotDownstreams() []Downstream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	downstreams := make([]Downstream, 0, len(s.downstreams))
+	for _, ds := range s.downstreams {
+		downstreams = append(downstreams, ds)
+	}
+	return downstreams
+}
+
+// connectDownstream connects the downstream added while the server is serving.
+func (s *Server) connectDownstream(ds Downstream) {
+	if s.downstreamCtx != nil {
+		go ds.Connect(s.downstreamCtx)
+	}
+}
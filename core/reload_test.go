@@ -0,0 +1,64 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+)
+
+// argsAuth authenticates the payload that equals its first argument,
+// the registered token auth is shared by the tests, so it is not reinitialized.
+type argsAuth struct{ args []string }
+
+func (a *argsAuth) Init(args ...string) { a.args = args }
+func (a *argsAuth) Name() string        { return "args" }
+func (a *argsAuth) Authenticate(payload string) (metadata.M, bool) {
+	return metadata.M{}, len(a.args) > 0 && a.args[0] == payload
+}
+
+func TestReload(t *testing.T) {
+	auth.Register(&argsAuth{})
+
+	s := NewServer("zipper")
+	assert.ErrorIs(t, s.Reload(), ErrReloadUnsupported)
+
+	reloaded := 0
+	s = NewServer("zipper", WithAuth("args", "old"), WithReloadFunc(func(s *Server) error {
+		reloaded++
+		if reloaded > 1 {
+			return errors.New("bad config")
+		}
+		s.SetAuths(map[string][]string{"args": {"new"}})
+		s.SetDispatchPolicies(DispatchRoundRobin, map[frame.Tag]DispatchPolicy{2: DispatchSticky}, 0)
+		s.RemoveDownstreamServer("record")
+		return nil
+	}))
+	s.AddDownstreamServer(&recordDownstream{})
+
+	_, err := s.authenticate(&frame.HandshakeFrame{AuthName: "args", AuthPayload: "old"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, DispatchBroadcast, s.dispatcher.policies.Load().of(1))
+
+	assert.NoError(t, s.Reload())
+	assert.Empty(t, s.Downstreams())
+	assert.Equal(t, DispatchRoundRobin, s.dispatcher.policies.Load().of(1))
+	assert.Equal(t, DispatchSticky, s.dispatcher.policies.Load().of(2))
+
+	_, err = s.authenticate(&frame.HandshakeFrame{AuthName: "args", AuthPayload: "old"})
+	assert.Error(t, err)
+	_, err = s.authenticate(&frame.HandshakeFrame{AuthName: "args", AuthPayload: "new"})
+	assert.NoError(t, err)
+
+	assert.EqualError(t, s.Reload(), "bad config")
+
+	// the auth is disabled by empty auths.
+	s.SetAuths(nil)
+	assert.Equal(t, []string{"none"}, s.authNames())
+	_, err = s.authenticate(&frame.HandshakeFrame{})
+	assert.NoError(t, err)
+}
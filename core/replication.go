@@ -0,0 +1,319 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	// MetadataContentEncodingKey is the metadata key of the payload encoding,
+	// the zipper decodes the payload before routing if the key is set.
+	MetadataContentEncodingKey = "yomo-content-encoding"
+
+	contentEncodingGzip = "gzip"
+)
+
+// ReplicationOptions configures the link between zippers, it treats the WAN link as a managed resource.
+type ReplicationOptions struct {
+	// BatchSize is the max number of frames in a batch, the batch is flushed when it is full.
+	// BatchSize <= 1 disables batching.
+	BatchSize int
+	// BatchDelay is the max delay before a batch is flushed, the default is 10ms.
+	BatchDelay time.Duration
+	// Compress compresses the payload with gzip before replicating.
+	Compress bool
+	// CompressMinSize is the min payload size to be compressed, the default is 512 bytes.
+	CompressMinSize int
+	// LowPriorityTags are deferred until OffPeak returns true.
+	LowPriorityTags []frame.Tag
+	// OffPeak reports whether the link is off-peak at the moment,
+	// the low priority tags are replicated immediately if OffPeak is nil.
+	OffPeak func(time.Time) bool
+	// MaxDeferred bounds the number of deferred frames, the oldest frame will be dropped if
+	// the bound is exceeded. The default is 10000.
+	MaxDeferred int
+}
+
+// ReplicationStats is the statistics of a replication link.
+type ReplicationStats struct {
+	// Frames is the number of frames replicated.
+	Frames int64
+	// Bytes is the number of payload bytes replicated, before compression.
+	Bytes int64
+	// WireBytes is the number of payload bytes replicated, after compression.
+	WireBytes int64
+	// Deferred is the number of frames waiting for off-peak.
+	Deferred int
+	// Dropped is the number of frames dropped because the deferred queue is full.
+	Dropped int64
+	// Lag is the time that the latest replicated frame spent in the link.
+	Lag time.Duration
+	// Throughput is the average payload bytes replicated per second.
+	Throughput float64
+}
+
+// ReplicationLink is a Downstream that batches, compresses and schedules the DataFrames
+// written to the underlying Downstream.
+type ReplicationLink struct {
+	Downstream
+	opts        ReplicationOptions
+	lowPriority map[frame.Tag]struct{}
+	logger      *slog.Logger
+
+	queue  chan replicaItem
+	closed chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	deferred []replicaItem
+
+	started   time.Time
+	frames    atomic.Int64
+	bytes     atomic.Int64
+	wireBytes atomic.Int64
+	dropped   atomic.Int64
+	lag       atomic.Int64
+}
+
+type replicaItem struct {
+	f        *frame.DataFrame
+	size     int
+	enqueued time.Time
+}
+
+var _ Downstream = &ReplicationLink{}
+
+// NewReplicationLink returns a ReplicationLink that replicates DataFrames to the Downstream.
+func NewReplicationLink(ds Downstream, opts ReplicationOptions, logger *slog.Logger) *ReplicationLink {
+	if opts.BatchDelay <= 0 {
+		opts.BatchDelay = 10 * time.Millisecond
+	}
+	if opts.CompressMinSize <= 0 {
+		opts.CompressMinSize = DefaultCompressMinSize
+	}
+	if opts.MaxDeferred <= 0 {
+		opts.MaxDeferred = 10000
+	}
+
+	lowPriority := make(map[frame.Tag]struct{}, len(opts.LowPriorityTags))
+	for _, tag := range opts.LowPriorityTags {
+		lowPriority[tag] = struct{}{}
+	}
+
+	l := &ReplicationLink{
+		Downstream:  ds,
+		opts:        opts,
+		lowPriority: lowPriority,
+		logger:      logger.With("downstream_id", ds.ID(), "downstream_name", ds.LocalName()),
+		queue:       make(chan replicaItem, opts.BatchSize),
+		closed:      make(chan struct{}),
+		started:     time.Now(),
+	}
+
+	l.wg.Add(1)
+	go l.run()
+
+	return l
+}
+
+// WriteFrame writes the frame to the link, the DataFrames are replicated asynchronously.
+func (l *ReplicationLink) WriteFrame(f frame.Frame) error {
+	df, ok := f.(*frame.DataFrame)
+	if !ok {
+		return l.Downstream.WriteFrame(f)
+	}
+
+	item := replicaItem{f: df, size: len(df.Payload), enqueued: time.Now()}
+	if l.opts.Compress {
+		item.f = compressDataFrame(df, l.opts.CompressMinSize, false)
+	}
+
+	if _, ok := l.lowPriority[df.Tag]; ok && !l.offPeak(item.enqueued) {
+		l.deferFrame(item)
+		return nil
+	}
+
+	select {
+	case <-l.closed:
+		return ErrConnectorClosed
+	case l.queue <- item:
+		return nil
+	}
+}
+
+// AllowTag delegates to the underlying Downstream if it implements DownstreamTagFilter.
+func (l *ReplicationLink) AllowTag(tag frame.Tag) bool {
+	if filter, ok := l.Downstream.(DownstreamTagFilter); ok {
+		return filter.AllowTag(tag)
+	}
+	return true
+}
+
+// SetErrorFrameObserver delegates to the underlying Downstream if it implements DownstreamErrorFrameObserver.
+func (l *ReplicationLink) SetErrorFrameObserver(fn func(*frame.ErrorFrame)) {
+	if o, ok := l.Downstream.(DownstreamErrorFrameObserver); ok {
+		o.SetErrorFrameObserver(fn)
+	}
+}
+
+// Ping delegates to the underlying Downstream if it implements DownstreamPinger,
+// the link is considered healthy otherwise.
+func (l *ReplicationLink) Ping(ctx context.Context) (time.Duration, error) {
+	if pinger, ok := l.Downstream.(DownstreamPinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return 0, nil
+}
+
+// Close flushes the pending frames, including the frames deferred to off-peak, and closes the underlying Downstream.
+func (l *ReplicationLink) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	l.wg.Wait()
+	return l.Downstream.Close()
+}
+
+// Stats returns the statistics of the link.
+func (l *ReplicationLink) Stats() ReplicationStats {
+	l.mu.Lock()
+	deferred := len(l.deferred)
+	l.mu.Unlock()
+
+	stats := ReplicationStats{
+		Frames:    l.frames.Load(),
+		Bytes:     l.bytes.Load(),
+		WireBytes: l.wireBytes.Load(),
+		Deferred:  deferred,
+		Dropped:   l.dropped.Load(),
+		Lag:       time.Duration(l.lag.Load()),
+	}
+	if elapsed := time.Since(l.started).Seconds(); elapsed > 0 {
+		stats.Throughput = float64(stats.Bytes) / elapsed
+	}
+	return stats
+}
+
+func (l *ReplicationLink) offPeak(t time.Time) bool {
+	return l.opts.OffPeak == nil || l.opts.OffPeak(t)
+}
+
+func (l *ReplicationLink) deferFrame(item replicaItem) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.deferred) >= l.opts.MaxDeferred {
+		l.deferred = l.deferred[1:]
+		l.dropped.Add(1)
+	}
+	l.deferred = append(l.deferred, item)
+}
+
+func (l *ReplicationLink) takeDeferred() []replicaItem {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	items := l.deferred
+	l.deferred = nil
+	return items
+}
+
+func (l *ReplicationLink) run() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(l.opts.BatchDelay)
+	defer ticker.Stop()
+
+	batch := make([]replicaItem, 0, l.opts.BatchSize)
+	for {
+		select {
+		case <-l.closed:
+			for {
+				select {
+				case item := <-l.queue:
+					batch = append(batch, item)
+				default:
+					l.flush(batch)
+					l.flush(l.takeDeferred())
+					return
+				}
+			}
+		case item := <-l.queue:
+			batch = append(batch, item)
+			if len(batch) >= l.opts.BatchSize {
+				l.flush(batch)
+				batch = batch[:0]
+			}
+		case now := <-ticker.C:
+			l.flush(batch)
+			batch = batch[:0]
+			if l.offPeak(now) {
+				l.flush(l.takeDeferred())
+			}
+		}
+	}
+}
+
+// flush writes the items to the underlying Downstream, they are written in a single write
+// if the Downstream is a frame.BatchWriter.
+func (l *ReplicationLink) flush(items []replicaItem) {
+	if bw, ok := l.Downstream.(frame.BatchWriter); ok && len(items) > 1 {
+		fs := make([]frame.Frame, len(items))
+		for i, item := range items {
+			fs[i] = item.f
+		}
+		if err := bw.WriteFrames(fs...); err != nil {
+			l.logger.Error("failed to replicate", "err", err, "frames", len(items))
+			return
+		}
+		for _, item := range items {
+			l.replicated(item)
+		}
+		return
+	}
+	for _, item := range items {
+		if err := l.Downstream.WriteFrame(item.f); err != nil {
+			l.logger.Error("failed to replicate", "err", err, "tag", item.f.Tag)
+			continue
+		}
+		l.replicated(item)
+	}
+}
+
+func (l *ReplicationLink) replicated(item replicaItem) {
+	l.frames.Add(1)
+	l.bytes.Add(int64(item.size))
+	l.wireBytes.Add(int64(len(item.f.Payload)))
+	l.lag.Store(int64(time.Since(item.enqueued)))
+}
+
+// decodeContentEncoding decodes the payload of the DataFrame according to the content encoding
+// in metadata, the content encoding is removed from metadata after decoding.
+func decodeContentEncoding(df *frame.DataFrame, md metadata.M) error {
+	encoding, ok := md.Get(MetadataContentEncodingKey)
+	if !ok || encoding != contentEncodingGzip {
+		return nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(df.Payload))
+	if err != nil {
+		return err
+	}
+	payload, err := io.ReadAll(zr)
+	if err != nil {
+		return err
+	}
+
+	df.Payload = payload
+	delete(md, MetadataContentEncodingKey)
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/ylog"
+)
+
+type recordDownstream struct {
+	mu     sync.Mutex
+	frames []*frame.DataFrame
+}
+
+func (d *recordDownstream) Close() error                      { return nil }
+func (d *recordDownstream) Connect(ctx context.Context) error { return nil }
+func (d *recordDownstream) ID() string                        { return "record" }
+func (d *recordDownstream) LocalName() string                 { return "record" }
+func (d *recordDownstream) RemoteName() string                { return "record" }
+func (d *recordDownstream) WriteFrame(f frame.Frame) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.frames = append(d.frames, f.(*frame.DataFrame))
+	return nil
+}
+
+func (d *recordDownstream) len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.frames)
+}
+
+// batchDownstream is the recordDownstream that is a frame.BatchWriter.
+type batchDownstream struct {
+	recordDownstream
+	writes int
+}
+
+func (d *batchDownstream) WriteFrames(fs ...frame.Frame) error {
+	d.mu.Lock()
+	d.writes++
+	d.mu.Unlock()
+	for _, f := range fs {
+		_ = d.recordDownstream.WriteFrame(f)
+	}
+	return nil
+}
+
+func TestReplicationLink(t *testing.T) {
+	t.Run("batch", func(t *testing.T) {
+		ds := &recordDownstream{}
+		link := NewReplicationLink(ds, ReplicationOptions{BatchSize: 3, BatchDelay: time.Hour}, ylog.Default())
+
+		for i := 0; i < 2; i++ {
+			assert.NoError(t, link.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("a")}))
+		}
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, 0, ds.len())
+
+		assert.NoError(t, link.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("a")}))
+		assert.Eventually(t, func() bool { return ds.len() == 3 }, time.Second, 10*time.Millisecond)
+
+		stats := link.Stats()
+		assert.Equal(t, int64(3), stats.Frames)
+		assert.Equal(t, int64(3), stats.Bytes)
+
+		assert.NoError(t, link.Close())
+	})
+
+	t.Run("batch writer", func(t *testing.T) {
+		ds := &batchDownstream{}
+		link := NewReplicationLink(ds, ReplicationOptions{BatchSize: 3, BatchDelay: time.Hour}, ylog.Default())
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, link.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("a")}))
+		}
+		assert.NoError(t, link.Close())
+
+		assert.Equal(t, 3, ds.len())
+		assert.Equal(t, 1, ds.writes)
+		assert.Equal(t, int64(3), link.Stats().Frames)
+	})
+
+	t.Run("compress", func(t *testing.T) {
+		ds := &recordDownstream{}
+		link := NewReplicationLink(ds, ReplicationOptions{Compress: true}, ylog.Default())
+
+		payload := bytes.Repeat([]byte("yomo"), 1024)
+		md, _ := metadata.New().Encode()
+		assert.NoError(t, link.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: payload}))
+		assert.NoError(t, link.Close())
+
+		assert.Equal(t, 1, ds.len())
+		df := ds.frames[0]
+		assert.Less(t, len(df.Payload), len(payload))
+
+		fmd, err := metadata.Decode(df.Metadata)
+		assert.NoError(t, err)
+		assert.NoError(t, decodeContentEncoding(df, fmd))
+		assert.Equal(t, payload, df.Payload)
+		_, ok := fmd.Get(MetadataContentEncodingKey)
+		assert.False(t, ok)
+
+		stats := link.Stats()
+		assert.Equal(t, int64(len(payload)), stats.Bytes)
+		assert.Less(t, stats.WireBytes, stats.Bytes)
+	})
+
+	t.Run("off-peak", func(t *testing.T) {
+		var (
+			mu      sync.Mutex
+			offPeak bool
+		)
+		ds := &recordDownstream{}
+		link := NewReplicationLink(ds, ReplicationOptions{
+			BatchDelay:      10 * time.Millisecond,
+			LowPriorityTags: []frame.Tag{2},
+			MaxDeferred:     2,
+			OffPeak: func(time.Time) bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return offPeak
+			},
+		}, ylog.Default())
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, link.WriteFrame(&frame.DataFrame{Tag: 2, Payload: []byte{byte(i)}}))
+		}
+		assert.NoError(t, link.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("now")}))
+		assert.Eventually(t, func() bool { return ds.len() == 1 }, time.Second, 10*time.Millisecond)
+
+		stats := link.Stats()
+		assert.Equal(t, 2, stats.Deferred)
+		assert.Equal(t, int64(1), stats.Dropped)
+
+		mu.Lock()
+		offPeak = true
+		mu.Unlock()
+
+		assert.Eventually(t, func() bool { return ds.len() == 3 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, []byte{1}, ds.frames[1].Payload)
+		assert.Equal(t, []byte{2}, ds.frames[2].Payload)
+
+		assert.NoError(t, link.Close())
+	})
+
+	t.Run("close flushes deferred", func(t *testing.T) {
+		ds := &recordDownstream{}
+		link := NewReplicationLink(ds, ReplicationOptions{
+			LowPriorityTags: []frame.Tag{2},
+			OffPeak:         func(time.Time) bool { return false },
+		}, ylog.Default())
+
+		assert.NoError(t, link.WriteFrame(&frame.DataFrame{Tag: 2, Payload: []byte("later")}))
+		assert.Equal(t, 1, link.Stats().Deferred)
+
+		assert.NoError(t, link.Close())
+		assert.Equal(t, 1, ds.len())
+		assert.Equal(t, 0, link.Stats().Deferred)
+	})
+}
@@ -0,0 +1,193 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/yerr"
+	"github.com/yomorun/yomo/pkg/id"
+)
+
+// DefaultRequestTimeout is the timeout of Client.Request if the ctx has no deadline.
+const DefaultRequestTimeout = 30 * time.Second
+
+// requestExtensionKey is the handshake extension of the client answering the RequestFrames, zipper only routes
+// the requests to the sfns advertising it, see SetRequestObserver.
+const requestExtensionKey = "yomo-request"
+
+// ErrRequestCanceled is returned by Client.Request if the client is closed before the ResponseFrame arrives.
+var ErrRequestCanceled = yerr.New(yerr.CodeClosed, "yomo: request canceled, the client is closed")
+
+// ErrRequestFailed is returned by Client.Request if the ResponseFrame reports an error,
+// e.g. no sfn observes the tag of the request or the handler of the sfn panics.
+type ErrRequestFailed struct {
+	// Code is the code of the error, e.g. ErrorCodeUnroutable.
+	Code uint32
+	// Message describes the error.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *ErrRequestFailed) Error() string {
+	return fmt.Sprintf("yomo: request failed, code=%d: %s", e.Code, e.Message)
+}
+
+// ErrorCode implements the yerr.Coder interface.
+func (e *ErrRequestFailed) ErrorCode() yerr.Code {
+	if e.Code == ErrorCodeUnroutable {
+		return yerr.CodeRouting
+	}
+	return yerr.CodeUnknown
+}
+
+// Request writes the RequestFrame to zipper and waits for the ResponseFrame of the same ID until the ctx is done,
+// or DefaultRequestTimeout elapses if the ctx has no deadline. The ID of the request is generated if it is empty,
+// and the deadline of the ctx is the deadline of the request if the request has none, rf itself is not changed.
+// It returns *ErrRequestFailed if the response reports an error.
+func (c *Client) Request(ctx context.Context, rf *frame.RequestFrame) (*frame.ResponseFrame, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultRequestTimeout)
+		defer cancel()
+	}
+	req := *rf
+	rf = &req
+	if rf.ID == "" {
+		rf.ID = id.New()
+	}
+	if deadline, _ := ctx.Deadline(); rf.Deadline == 0 {
+		rf.Deadline = deadline.UnixMilli()
+	}
+
+	resp := make(chan *frame.ResponseFrame, 1)
+	c.requests.Store(rf.ID, resp)
+	defer c.requests.Delete(rf.ID)
+
+	if err := c.WriteFrameContext(ctx, rf); err != nil {
+		return nil, err
+	}
+
+	select {
+	case res := <-resp:
+		switch res.Code {
+		case 0:
+			return res, nil
+		case ErrorCodeDeadlineExceeded:
+			return nil, context.DeadlineExceeded
+		default:
+			return nil, &ErrRequestFailed{Code: res.Code, Message: res.Message}
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.ctx.Done():
+		return nil, ErrRequestCanceled
+	}
+}
+
+// SetRequestObserver sets the function to invoke when a RequestFrame arrives, the function answers it
+// by writing a ResponseFrame of the same ID, see NewResponseFrame. It is invoked in the reading goroutine,
+// so the request should be handled and answered in another goroutine. It must be set before connecting,
+// zipper only routes the requests to the clients that set it at the handshake.
+func (c *Client) SetRequestObserver(fn func(*frame.RequestFrame)) {
+	c.requestObserver = fn
+	WithHandshakeExtension(requestExtensionKey, []byte("1"))(c.opts)
+}
+
+// handleResponseFrame wakes up the Request call waiting for the ResponseFrame,
+// the response of the abandoned request is dropped.
+func (c *Client) handleResponseFrame(f *frame.ResponseFrame) {
+	v, ok := c.requests.Load(f.ID)
+	if !ok {
+		c.Logger.Debug("drop response frame, the request is not found", "request_id", f.ID)
+		return
+	}
+	select {
+	case v.(chan *frame.ResponseFrame) <- f:
+	default:
+	}
+}
+
+// NewResponseFrame returns the ResponseFrame answering the RequestFrame, md is the metadata of the request,
+// a non-zero code reports the failure of the request. It returns nil if the request is not written by a source.
+func NewResponseFrame(rf *frame.RequestFrame, md metadata.M, payload []byte, code uint32, message string) *frame.ResponseFrame {
+	sourceID := GetSourceIDFromMetadata(md)
+	if sourceID == "" {
+		return nil
+	}
+	return &frame.ResponseFrame{
+		ID:       rf.ID,
+		SourceID: sourceID,
+		Payload:  payload,
+		Code:     code,
+		Message:  message,
+	}
+}
+
+// RequestExpired reports whether the RequestFrame is expired at now.
+func RequestExpired(rf *frame.RequestFrame, now time.Time) bool {
+	return rf.Deadline > 0 && now.UnixMilli() >= rf.Deadline
+}
+
+// routingRequestFrame routes the RequestFrame written by the source to one of the sfns that observe its tag
+// and answer the requests, the sfns are chosen in turn. The request is answered by zipper at once if it cannot
+// be routed or it is expired. The requests are not dispatched to the downstream zippers, because the responses cannot return across the mesh.
+func (s *Server) routingRequestFrame(conn *Connection, rf *frame.RequestFrame) {
+	reply := func(code uint32, message string) {
+		res := &frame.ResponseFrame{ID: rf.ID, Code: code, Message: message}
+		if err := conn.FrameConn().WriteFrame(res); err != nil {
+			conn.Logger.Info("failed to write response frame", "request_id", rf.ID, "err", err)
+		}
+	}
+
+	if RequestExpired(rf, time.Now()) {
+		conn.Logger.Debug("drop expired request", "request_id", rf.ID, "tag", rf.Tag)
+		reply(ErrorCodeDeadlineExceeded, "the request is expired")
+		return
+	}
+
+	md, err := metadata.Decode(rf.Metadata)
+	if err != nil {
+		conn.Logger.Info("failed to decode the metadata of request", "request_id", rf.ID, "err", err)
+		reply(ErrorCodeUnroutable, "invalid metadata")
+		return
+	}
+
+	stampTenant(conn, md)
+	if rf.Metadata, err = md.Encode(); err != nil {
+		conn.Logger.Info("failed to encode the metadata of request", "request_id", rf.ID, "err", err)
+		reply(ErrorCodeUnroutable, "invalid metadata")
+		return
+	}
+
+	connIDs := s.router.Route(rf.Tag, md)
+	if target := GetTargetFromMetadata(md); target != "" {
+		connIDs = s.filterTarget(connIDs, target)
+	}
+	// the sfns not answering the requests are skipped.
+	candidates := make([]*Connection, 0, len(connIDs))
+	for _, toID := range connIDs {
+		to, ok, err := s.connector.Get(toID)
+		if err != nil || !ok || to.Departing() {
+			continue
+		}
+		if _, ok := to.HandshakeExtensions()[requestExtensionKey]; ok {
+			candidates = append(candidates, to)
+		}
+	}
+	start := s.requestSeq.Add(1)
+	for i := range candidates {
+		to := candidates[(start+uint64(i))%uint64(len(candidates))]
+		if err := to.FrameConn().WriteFrame(rf); err != nil {
+			conn.Logger.Error("failed to route request", "request_id", rf.ID, "tag", rf.Tag, "to_id", to.ID(), "err", err)
+			continue
+		}
+		conn.Logger.Info("request routing", "request_id", rf.ID, "tag", rf.Tag, "to_id", to.ID(), "to_name", to.Name())
+		return
+	}
+
+	conn.Logger.Info("no observed", "request_id", rf.ID, "tag", rf.Tag)
+	reply(ErrorCodeUnroutable, fmt.Sprintf("no sfn observes the tag %d", rf.Tag))
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
+)
+
+func TestRequestExpired(t *testing.T) {
+	now := time.Now()
+
+	assert.False(t, RequestExpired(&frame.RequestFrame{}, now))
+	assert.False(t, RequestExpired(&frame.RequestFrame{Deadline: now.Add(time.Second).UnixMilli()}, now))
+	assert.True(t, RequestExpired(&frame.RequestFrame{Deadline: now.UnixMilli()}, now))
+}
+
+func TestRequest(t *testing.T) {
+	t.Parallel()
+
+	const requestAddr = "127.0.0.1:19971"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	server.ConfigRouter(router.Default())
+	go server.ListenAndServe(context.TODO(), requestAddr)
+	defer server.Close()
+
+	sfn := NewClient("sfn", requestAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	sfn.SetObserveDataTags(1)
+	sfn.SetRequestObserver(func(rf *frame.RequestFrame) {
+		go func() {
+			md, _ := metadata.Decode(rf.Metadata)
+			sfn.WriteFrame(NewResponseFrame(rf, md, append([]byte("re: "), rf.Payload...), 0, ""))
+		}()
+	})
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	// the sfn not answering the requests is never routed to.
+	observer := NewClient("observer", requestAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	observer.SetObserveDataTags(1, 3)
+	assert.NoError(t, observer.Connect(context.TODO()))
+	defer observer.Close()
+
+	source := NewClient("source", requestAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	t.Run("ok", func(t *testing.T) {
+		md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+		for i := 0; i < 4; i++ {
+			rf := &frame.RequestFrame{Tag: 1, Metadata: md, Payload: []byte("hello")}
+			res, err := source.Request(context.Background(), rf)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("re: hello"), res.Payload)
+			// the request of the caller is not changed.
+			assert.Empty(t, rf.ID)
+			assert.Zero(t, rf.Deadline)
+		}
+	})
+
+	t.Run("unroutable", func(t *testing.T) {
+		md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+		for _, tag := range []frame.Tag{2, 3} {
+			_, err := source.Request(ctx, &frame.RequestFrame{Tag: tag, Metadata: md})
+			var failed *ErrRequestFailed
+			assert.ErrorAs(t, err, &failed)
+			assert.Equal(t, ErrorCodeUnroutable, failed.Code)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+		_, err := source.Request(ctx, &frame.RequestFrame{Tag: 1, Metadata: md, Deadline: 1})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
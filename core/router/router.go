@@ -10,6 +10,8 @@ import (
 
 // Router routes data that is written by source/sfn according to parameters be passed.
 // Users should define their own rules that tells zipper how to route data and how to store the rules.
+// The md of Add is the connection metadata and the md of Route is the frame metadata, both carry the
+// tenant as metadata.TenantIDKey, the Router should route the data within the tenant.
 type Router interface {
 	// Add adds the route rule to the router.
 	Add(connID string, observeDataTags []uint32, md metadata.M) error
@@ -21,6 +23,13 @@ type Router interface {
 	Release()
 }
 
+// Updater is implemented by the Router that can replace the route rule of a connection atomically,
+// the data routed to the tags observed before and after updating is never missed.
+type Updater interface {
+	// Update replaces the observed data tags of the connection.
+	Update(connID string, observeDataTags []uint32, md metadata.M) error
+}
+
 type defaultRouter struct {
 	// mu protects data.
 	mu sync.RWMutex
@@ -28,13 +37,17 @@ type defaultRouter struct {
 	// data stores tag and connID connection.
 	// The key is frame tag, The value is connID connection.
 	data map[frame.Tag]map[string]struct{}
+
+	// tenants stores the tenant of the connID, the connID without a tenant is not stored.
+	tenants map[string]string
 }
 
 // DefaultRouter provides a default implementation of `router`,
-// It routes data according to observed tag or connID.
+// It routes data according to observed tag or connID, the data is only routed to the connections of its tenant.
 func Default() *defaultRouter {
 	return &defaultRouter{
-		data: make(map[frame.Tag]map[string]struct{}),
+		data:    make(map[frame.Tag]map[string]struct{}),
+		tenants: make(map[string]string),
 	}
 }
 
@@ -50,17 +63,51 @@ func (r *defaultRouter) Add(connID string, ObserveDataTags []uint32, md metadata
 		}
 		r.data[tag][connID] = struct{}{}
 	}
+	r.setTenant(connID, md)
+
+	return nil
+}
+
+func (r *defaultRouter) Update(connID string, observeDataTags []uint32, md metadata.M) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, conns := range r.data {
+		delete(conns, connID)
+	}
+	for _, tag := range observeDataTags {
+		conns := r.data[tag]
+		if conns == nil {
+			conns = map[string]struct{}{}
+			r.data[tag] = conns
+		}
+		conns[connID] = struct{}{}
+	}
+	r.setTenant(connID, md)
 
 	return nil
 }
 
+func (r *defaultRouter) setTenant(connID string, md metadata.M) {
+	if tenantID, _ := md.Get(metadata.TenantIDKey); tenantID != "" {
+		r.tenants[connID] = tenantID
+	} else {
+		delete(r.tenants, connID)
+	}
+}
+
 func (r *defaultRouter) Route(dataTag uint32, md metadata.M) []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	tenantID, _ := md.Get(metadata.TenantIDKey)
+
 	var connID []string
 	if conns, ok := r.data[dataTag]; ok {
 		for k := range conns {
+			if r.tenants[k] != tenantID {
+				continue
+			}
 			connID = append(connID, k)
 		}
 	}
@@ -74,6 +121,7 @@ func (r *defaultRouter) Remove(connID string) {
 	for _, conns := range r.data {
 		delete(conns, connID)
 	}
+	delete(r.tenants, connID)
 }
 
 func (r *defaultRouter) Release() {
@@ -83,4 +131,7 @@ func (r *defaultRouter) Release() {
 	for key := range r.data {
 		delete(r.data, key)
 	}
+	for key := range r.tenants {
+		delete(r.tenants, key)
+	}
 }
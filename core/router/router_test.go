@@ -27,8 +27,35 @@ func TestRouter(t *testing.T) {
 	ids = router.Route(1, nil)
 	assert.ElementsMatch(t, []string{"conn-2", "conn-3"}, ids)
 
+	err = router.Update("conn-2", []uint32{2}, metadata.M{})
+	assert.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"conn-3"}, router.Route(1, nil))
+	assert.ElementsMatch(t, []string{"conn-2"}, router.Route(2, nil))
+
 	router.Release()
 
 	ids = router.Route(1, nil)
 	assert.Equal(t, []string(nil), ids)
 }
+
+func TestRouterTenant(t *testing.T) {
+	router := Default()
+
+	assert.NoError(t, router.Add("conn-1", []uint32{1}, metadata.M{metadata.TenantIDKey: "acme"}))
+	assert.NoError(t, router.Add("conn-2", []uint32{1}, metadata.M{metadata.TenantIDKey: "globex"}))
+	assert.NoError(t, router.Add("conn-3", []uint32{1}, metadata.M{}))
+
+	assert.ElementsMatch(t, []string{"conn-1"}, router.Route(1, metadata.M{metadata.TenantIDKey: "acme"}))
+	assert.ElementsMatch(t, []string{"conn-2"}, router.Route(1, metadata.M{metadata.TenantIDKey: "globex"}))
+	assert.ElementsMatch(t, []string{"conn-3"}, router.Route(1, metadata.M{}))
+	assert.Empty(t, router.Route(1, metadata.M{metadata.TenantIDKey: "initech"}))
+
+	// the tenant moves with the updated route rule.
+	assert.NoError(t, router.Update("conn-3", []uint32{1}, metadata.M{metadata.TenantIDKey: "acme"}))
+	assert.ElementsMatch(t, []string{"conn-1", "conn-3"}, router.Route(1, metadata.M{metadata.TenantIDKey: "acme"}))
+	assert.Empty(t, router.Route(1, nil))
+
+	router.Remove("conn-1")
+	assert.ElementsMatch(t, []string{"conn-3"}, router.Route(1, metadata.M{metadata.TenantIDKey: "acme"}))
+}
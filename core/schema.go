@@ -0,0 +1,69 @@
+package core
+
+import (
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"golang.org/x/exp/slices"
+)
+
+// MetadataSchemaVersionKey is the metadata key of the payload schema version.
+const MetadataSchemaVersionKey = metadata.SchemaVersionKey
+
+// GetSchemaVersionFromMetadata gets the payload schema version from metadata.
+func GetSchemaVersionFromMetadata(m metadata.M) string {
+	version, _ := m.Get(MetadataSchemaVersionKey)
+	return version
+}
+
+// SetSchemaVersionToMetadata sets the payload schema version to metadata.
+func SetSchemaVersionToMetadata(m metadata.M, version string) {
+	m.Set(MetadataSchemaVersionKey, version)
+}
+
+// SchemaConverter up-converts the payload from a schema version to another.
+type SchemaConverter func(payload []byte) ([]byte, error)
+
+type schemaConverterKey struct {
+	tag      frame.Tag
+	from, to string
+}
+
+// schemaFrame returns the DataFrame in the schema version supported by the connection, the payload is
+// up-converted if the connection does not support the version of the frame but a converter is registered.
+// It returns false if the connection cannot consume the frame.
+// The frames without version and the connections not declaring versions of the tag are always compatible.
+func (s *Server) schemaFrame(conn *Connection, df *frame.DataFrame, md metadata.M) (*frame.DataFrame, bool) {
+	version := GetSchemaVersionFromMetadata(md)
+	supported := conn.SchemaVersions()[df.Tag]
+	// the chunks are passed through, the payload of a chunk cannot be converted.
+	if version == "" || len(supported) == 0 || slices.Contains(supported, version) || df.IsChunked() {
+		return df, true
+	}
+
+	for _, to := range supported {
+		convert, ok := s.opts.schemaConverters[schemaConverterKey{tag: df.Tag, from: version, to: to}]
+		if !ok {
+			continue
+		}
+		payload, err := convert(df.Payload)
+		if err != nil {
+			conn.Logger.Warn("failed to convert schema", "tag", df.Tag, "from", version, "to", to, "err", err)
+			return nil, false
+		}
+		converted := md.Clone()
+		SetSchemaVersionToMetadata(converted, to)
+		mdBytes, err := converted.Encode()
+		if err != nil {
+			return nil, false
+		}
+		return &frame.DataFrame{
+			Tag:        df.Tag,
+			Metadata:   mdBytes,
+			Payload:    payload,
+			Priority:   df.Priority,
+			Channel:    df.Channel,
+			Extensions: df.Extensions,
+		}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
+)
+
+func upperConverter(payload []byte) ([]byte, error) { return bytes.ToUpper(payload), nil }
+
+func TestSchemaFrame(t *testing.T) {
+	server := NewServer("zipper",
+		WithServerLogger(discardingLogger),
+		WithSchemaConverter(0x21, "1", "2", upperConverter),
+		WithSchemaConverter(0x21, "1", "3", func([]byte) ([]byte, error) { return nil, errors.New("bad payload") }),
+	)
+
+	newConn := func(versions map[frame.Tag][]string) *Connection {
+		conn := newConnection("sfn", "sfn-id", ClientTypeStreamFunction, metadata.M{}, []uint32{0x21}, nil, discardingLogger)
+		conn.schemaVersions = versions
+		return conn
+	}
+	v1 := metadata.M{MetadataSchemaVersionKey: "1"}
+	df := &frame.DataFrame{Tag: 0x21, Payload: []byte("hello")}
+
+	// the frame without version and the connection without declaration are compatible.
+	got, ok := server.schemaFrame(newConn(map[frame.Tag][]string{0x21: {"2"}}), df, metadata.M{})
+	assert.True(t, ok)
+	assert.Same(t, df, got)
+
+	got, ok = server.schemaFrame(newConn(nil), df, v1)
+	assert.True(t, ok)
+	assert.Same(t, df, got)
+
+	got, ok = server.schemaFrame(newConn(map[frame.Tag][]string{0x21: {"1", "2"}}), df, v1)
+	assert.True(t, ok)
+	assert.Same(t, df, got)
+
+	// up-converted.
+	got, ok = server.schemaFrame(newConn(map[frame.Tag][]string{0x21: {"2"}}), df, v1)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("HELLO"), got.Payload)
+	md, err := metadata.Decode(got.Metadata)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", GetSchemaVersionFromMetadata(md))
+	assert.Equal(t, []byte("hello"), df.Payload)
+
+	// no converter, or the converting fails.
+	_, ok = server.schemaFrame(newConn(map[frame.Tag][]string{0x21: {"4"}}), df, v1)
+	assert.False(t, ok)
+	_, ok = server.schemaFrame(newConn(map[frame.Tag][]string{0x21: {"3"}}), df, v1)
+	assert.False(t, ok)
+}
+
+func TestSchemaVersionRouting(t *testing.T) {
+	t.Parallel()
+
+	const schemaAddr = "127.0.0.1:19987"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger), WithSchemaConverter(0x21, "1", "2", upperConverter))
+	server.ConfigRouter(router.Default())
+	server.ConfigVersionNegotiateFunc(DefaultVersionNegotiateFunc)
+	go server.ListenAndServe(context.TODO(), schemaAddr)
+	defer server.Close()
+
+	newSfn := func(name string, opts ...ClientOption) chan *frame.DataFrame {
+		received := make(chan *frame.DataFrame, 10)
+		sfn := NewClient(name, schemaAddr, ClientTypeStreamFunction, append(opts, WithLogger(discardingLogger))...)
+		sfn.SetObserveDataTags(0x21)
+		sfn.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df })
+		assert.NoError(t, sfn.Connect(context.TODO()))
+		t.Cleanup(func() { sfn.Close() })
+		return received
+	}
+	v2Only := newSfn("sfn-v2", WithSchemaVersions(0x21, "2"))
+	v3Only := newSfn("sfn-v3", WithSchemaVersions(0x21, "3"))
+	anyVersion := newSfn("sfn-any")
+
+	source := NewClient("source", schemaAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	md, _ := metadata.M{MetadataSchemaVersionKey: "1"}.Encode()
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 0x21, Metadata: md, Payload: []byte("hello")}))
+
+	for _, c := range []struct {
+		received chan *frame.DataFrame
+		payload  string
+	}{{v2Only, "HELLO"}, {anyVersion, "hello"}} {
+		select {
+		case df := <-c.received:
+			assert.Equal(t, c.payload, string(df.Payload))
+		case <-time.After(3 * time.Second):
+			t.Fatal("the sfn does not receive the data")
+		}
+	}
+
+	select {
+	case df := <-v3Only:
+		t.Fatalf("the incompatible sfn receives the data: %s", df.Payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -7,25 +7,30 @@ import (
 	"net"
 	"os"
 	"reflect"
+	"strconv"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/yomorun/yomo/core/auth"
 	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/metadata"
 	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/core/yerr"
+	"golang.org/x/exp/slices"
 	"golang.org/x/exp/slog"
 
 	// authentication implements, Currently, only token authentication is implemented
 	_ "github.com/yomorun/yomo/pkg/auth"
 	"github.com/yomorun/yomo/pkg/frame-codec/y3codec"
 	yquic "github.com/yomorun/yomo/pkg/listener/quic"
+	ywebtransport "github.com/yomorun/yomo/pkg/listener/webtransport"
 	pkgtls "github.com/yomorun/yomo/pkg/tls"
 	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 // ErrServerClosed is returned by the Server's Serve and ListenAndServe methods after a call to Shutdown or Close.
-var ErrServerClosed = errors.New("yomo: Server closed")
+var ErrServerClosed = yerr.New(yerr.CodeClosed, "yomo: Server closed")
 
 type (
 	// FrameHandler handles a frame.
@@ -52,7 +57,10 @@ type Server struct {
 	packetReadWriter     frame.PacketReadWriter
 	counterOfDataFrame   int64
 	downstreams          map[string]Downstream
+	downstreamCtx        context.Context // the context of connecting the downstreams once serving
 	mu                   sync.Mutex
+	authMu               sync.RWMutex
+	reloadMu             sync.Mutex
 	opts                 *serverOptions
 	frameHandler         FrameHandler
 	connHandler          ConnHandler
@@ -60,6 +68,16 @@ type Server struct {
 	logger               *slog.Logger
 	tracerProvider       oteltrace.TracerProvider
 	versionNegotiateFunc VersionNegotiateFunc
+	deduper              *frameDeduper
+	flows                tagFlows
+	spills               sync.Map // spillKey -> *spillQueue
+	leakedConns          atomic.Int64
+	requestSeq           atomic.Uint64 // chooses the sfn of the RequestFrame in turn
+	payloadValidators    sync.Map      // frame.Tag -> PayloadValidator
+	dispatcher           *dispatcher
+	downstreamHealths    sync.Map // downstream id -> *downstreamHealth
+	draining             atomic.Bool
+	authFailures         atomic.Int64
 }
 
 // NewServer create a Server instance.
@@ -82,10 +100,18 @@ func NewServer(name string, opts ...ServerOption) *Server {
 		downstreams:          make(map[string]Downstream),
 		logger:               logger,
 		tracerProvider:       options.tracerProvider,
-		codec:                y3codec.Codec(),
-		packetReadWriter:     y3codec.PacketReadWriter(),
+		codec:                y3codec.Codec(append(codecOptions(options.checksum), y3codec.WithLimits(options.frameLimits))...),
+		packetReadWriter:     y3codec.PacketReadWriter(y3codec.WithLimits(options.frameLimits)),
 		opts:                 options,
 		versionNegotiateFunc: DefaultVersionNegotiateFunc,
+		dispatcher:           newDispatcher(options.dispatchPolicies),
+	}
+
+	if options.dedupWindow > 0 {
+		s.deduper = newFrameDeduper(options.dedupWindow)
+	}
+	for tag, validator := range options.payloadValidators {
+		s.SetPayloadValidator(tag, validator)
 	}
 
 	// work with middleware.
@@ -106,16 +132,34 @@ func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
 		return err
 	}
 
-	// connect to all downstreams.
+	// connect to all downstreams, the downstreams added later are connected as they are added.
+	s.mu.Lock()
+	s.downstreamCtx = ctx
 	for _, client := range s.downstreams {
 		go client.Connect(ctx)
 	}
+	s.mu.Unlock()
+	if s.opts.healthCheck.interval > 0 {
+		go s.checkDownstreams(ctx)
+	}
+
+	if s.opts.adminAddr != "" {
+		go s.serveAdmin(ctx, s.opts.adminAddr)
+	}
 
 	return s.Serve(ctx, conn)
 }
 
 // Serve the server with a net.PacketConn.
 func (s *Server) Serve(ctx context.Context, conn net.PacketConn) error {
+	if name := s.opts.codecName; name != "" && name != y3codec.Name {
+		codec, prw, ok := frame.LookupCodec(name)
+		if !ok {
+			return fmt.Errorf("yomo: unknown codec %q, registered: %v", name, frame.CodecNames())
+		}
+		s.codec, s.packetReadWriter = codec, prw
+	}
+
 	s.connector = NewConnector(ctx)
 
 	tlsConfig := s.opts.tlsConfig
@@ -124,18 +168,36 @@ func (s *Server) Serve(ctx context.Context, conn net.PacketConn) error {
 	}
 
 	// listen the address
-	listener, err := yquic.Listen(conn, y3codec.Codec(), y3codec.PacketReadWriter(), tlsConfig, s.opts.quicConfig)
+	listener, err := yquic.Listen(conn, s.codec, s.packetReadWriter, tlsConfig, s.opts.quicConfig)
 	if err != nil {
 		s.logger.Error("failed to listen on quic", "err", err)
 		return err
 	}
 	s.listener = listener
 
+	if addr := s.opts.webTransportAddr; addr != "" {
+		wl, err := ywebtransport.ListenAddr(addr, s.opts.webTransportPath, s.codec, s.packetReadWriter, tlsConfig, s.opts.quicConfig)
+		if err != nil {
+			s.logger.Error("failed to listen on webtransport", "err", err)
+			listener.Close()
+			return err
+		}
+		defer wl.Close()
+		go s.acceptWebTransport(wl)
+		s.logger.Info("zipper accepts webtransport", "webtransport_addr", addr, "path", s.opts.webTransportPath)
+	}
+
 	s.logger.Info(
 		"zipper is up and running",
 		"zipper_addr", conn.LocalAddr().String(), "pid", os.Getpid(), "quic", s.opts.quicConfig.Versions, "auth_name", s.authNames())
 
-	defer closeServer(s.downstreams, s.connector, s.listener, s.router)
+	defer func() { closeServer(s.snapshotDownstreams(), s.connector, s.listener, s.router) }()
+
+	if err := s.opts.plugins.start(s); err != nil {
+		s.logger.Error("failed to start plugins", "err", err)
+		return err
+	}
+	defer s.opts.plugins.close(s)
 
 	for {
 		fconn, err := s.listener.Accept(s.ctx)
@@ -151,22 +213,52 @@ func (s *Server) Serve(ctx context.Context, conn net.PacketConn) error {
 	}
 }
 
+// acceptWebTransport accepts the conns of the WebTransport listener until the server is closed.
+func (s *Server) acceptWebTransport(listener frame.Listener) {
+	for {
+		fconn, err := listener.Accept(s.ctx)
+		if err != nil {
+			if s.ctx.Err() == nil {
+				s.logger.Error("accepted an error when accepting a webtransport connection", "err", err)
+			}
+			return
+		}
+		go s.handleFrameConn(fconn, s.logger)
+	}
+}
+
 func (s *Server) handleFrameConn(fconn frame.Conn, logger *slog.Logger) {
-	conn, err := s.handshake(fconn)
+	conn, ack, err := s.handshake(fconn)
 	if err != nil {
 		logger.Error("handshake failed", "err", err)
 		return
 	}
 
 	// ack handshake
-	_ = fconn.WriteFrame(&frame.HandshakeAckFrame{})
+	_ = fconn.WriteFrame(ack)
+
+	if cc, ok := fconn.(frame.ControlConn); ok {
+		conn.resources.Go(func() { s.serveControl(conn, cc) })
+	}
+	conn.resources.Go(func() { s.replay(conn) })
 
+	conn.resources.goroutines.Add(1)
 	s.connHandler(conn) // s.handleConn(conn) with middlewares
+	conn.resources.goroutines.Add(-1)
 
 	if conn.ClientType() == ClientTypeStreamFunction {
 		s.router.Remove(conn.ID())
 	}
 	_ = s.connector.Remove(conn.ID())
+	s.closeSpills(conn.ID())
+	if conn.consumer != nil {
+		conn.consumer.close()
+	}
+	s.opts.connHooks.onDisconnect(conn)
+
+	if s.opts.leakGrace > 0 {
+		go s.checkLeaks(conn, s.opts.leakGrace)
+	}
 }
 
 func rejectHandshake(w frame.Writer, err error) error {
@@ -174,6 +266,9 @@ func rejectHandshake(w frame.Writer, err error) error {
 		rf := &frame.RejectedFrame{
 			Message: err.Error(),
 		}
+		if e := new(ErrRejected); errors.As(err, &e) {
+			rf.Reason = string(e.Reason)
+		}
 		_ = w.WriteFrame(rf)
 	}
 
@@ -192,10 +287,10 @@ func connectToNewEndpoint(w frame.Writer, err *ErrConnectTo) error {
 	return err
 }
 
-func (s *Server) handshake(fconn frame.Conn) (*Connection, error) {
+func (s *Server) handshake(fconn frame.Conn) (*Connection, *frame.HandshakeAckFrame, error) {
 	first, err := fconn.ReadFrame()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	switch first.Type() {
@@ -203,34 +298,63 @@ func (s *Server) handshake(fconn frame.Conn) (*Connection, error) {
 
 		hf := first.(*frame.HandshakeFrame)
 
+		// 0. the draining server accepts no connection
+		if s.draining.Load() {
+			return nil, nil, rejectHandshake(fconn, &ErrRejected{Message: "the zipper is draining", Reason: ReasonServerDraining})
+		}
+
 		// 1. version negotiation
 		if err := s.versionNegotiateFunc(hf.Version, Version); err != nil {
 			if se := new(ErrConnectTo); errors.As(err, &se) {
-				return nil, connectToNewEndpoint(fconn, se)
+				return nil, nil, connectToNewEndpoint(fconn, se)
 			}
-			return nil, rejectHandshake(fconn, err)
+			return nil, nil, rejectHandshake(fconn, err)
 		}
 
 		// 2. authentication
 		md, err := s.authenticate(hf)
 		if err != nil {
-			return nil, rejectHandshake(fconn, err)
+			return nil, nil, rejectHandshake(fconn, err)
+		}
+
+		// 3. negotiate protocol version and compression
+		protocolVersion, err := negotiateProtocolVersion(hf.ProtocolVersion, s.opts.minProtocolVersion)
+		if err != nil {
+			return nil, nil, rejectHandshake(fconn, err)
+		}
+		ack := &frame.HandshakeAckFrame{ProtocolVersion: protocolVersion}
+		if s.opts.compressMinSize > 0 {
+			ack.Compression = negotiateCompression(hf.Compressions)
+		}
+		if s.opts.handshakeExtFunc != nil {
+			if ack.Extensions, err = s.opts.handshakeExtFunc(hf); err != nil {
+				return nil, nil, rejectHandshake(fconn, err)
+			}
 		}
 
-		// 3. create connection
-		conn, err := s.createConnection(hf, md, fconn)
+		// 4. create connection
+		conn, err := s.createConnection(hf, md, newCompressConn(fconn, ack.Compression, s.opts.compressMinSize, ack.ProtocolVersion))
 		if err != nil {
-			return nil, rejectHandshake(fconn, err)
+			return nil, nil, rejectHandshake(fconn, err)
 		}
+		conn.protocolVersion = ack.ProtocolVersion
 
-		// 4. add route rules
+		// 5. plugins, including the connect hooks
+		if err := s.opts.plugins.connection(conn); err != nil {
+			s.discardConnection(conn)
+			return nil, nil, rejectHandshake(fconn, err)
+		}
+
+		// 6. add route rules
 		if err := s.addSfnRouteRule(hf, conn.Metadata()); err != nil {
-			return nil, rejectHandshake(fconn, err)
+			s.discardConnection(conn)
+			s.opts.connHooks.onDisconnect(conn)
+			return nil, nil, rejectHandshake(fconn, err)
 		}
-		return conn, nil
+		return conn, ack, nil
 	default:
 		err = fmt.Errorf("yomo: handshake read unexpected frame, read: %s", first.Type().String())
-		return nil, rejectHandshake(fconn, err)
+		return nil, nil, rejectHandshake(fconn, err)
 	}
 }
 
@@ -240,20 +364,103 @@ func (s *Server) handleConn(conn *Connection) {
 	for {
 		f, err := conn.FrameConn().ReadFrame()
 		if err != nil {
+			// the connection keeps working if a data stream is canceled.
+			if isStreamCanceled(err) {
+				conn.Logger.Info("data stream canceled", "err", err)
+				continue
+			}
+			if isCorrupted(err) {
+				conn.Logger.Warn("corrupted frame dropped", "err", err)
+				continue
+			}
+			if isMalformed(err) {
+				conn.Logger.Warn("malformed frame dropped", "err", err)
+				continue
+			}
 			conn.Logger.Info("failed to read frame", "err", err)
 			return
 		}
+		if ef, ok := f.(*frame.ExtensionFrame); ok {
+			s.opts.extensions.handle(conn.FrameConn(), ef, conn.Logger)
+			continue
+		}
 		switch f.Type() {
 		case frame.TypeDataFrame:
-			c, err := newContext(conn, f.(*frame.DataFrame))
+			df := f.(*frame.DataFrame)
+			size := len(df.Metadata) + len(df.Payload)
+			conn.resources.acquire(size)
+
+			c, err := newContext(conn, df)
 			if err != nil {
+				conn.resources.release(size)
 				conn.Logger.Info("failed to new context", "err", err)
 				return
 			}
+			if name := TagName(s.opts.tagNamer, c.Frame.Tag); name != "" {
+				c.Logger = c.Logger.With("tag_name", name)
+			}
+
+			// the ack id is between the client and zipper, it is not routed.
+			ackID := df.AckID
+			df.AckID = 0
 
 			s.frameHandler(c) // s.handleFrame(c) with middlewares
+			unacked := c.unacked
 
 			c.Release()
+			conn.resources.release(size)
+
+			// acknowledge the frame after it is handled, so the frame lost in handling is retransmitted.
+			if ackID != 0 && !unacked && conn.ProtocolVersion() >= protocolVersionAck {
+				if err := conn.FrameConn().WriteFrame(&frame.AckFrame{ID: ackID}); err != nil {
+					conn.Logger.Info("failed to write ack frame", "err", err)
+					return
+				}
+			}
+		case frame.TypeGoodbyeFrame:
+			// the client is closing intentionally, the frames it drains are still routed.
+			conn.Logger.Info("client said goodbye", "message", f.(*frame.GoodbyeFrame).Message)
+			conn.departing.Store(true)
+			if conn.ClientType() == ClientTypeStreamFunction {
+				s.router.Remove(conn.ID())
+			}
+		case frame.TypeObserveUpdateFrame:
+			if conn.Departing() {
+				continue
+			}
+			if err := s.updateObserveDataTags(conn, f.(*frame.ObserveUpdateFrame)); err != nil {
+				conn.Logger.Info("failed to update observed data tags", "err", err)
+				return
+			}
+		case frame.TypePingFrame:
+			pong := &frame.PongFrame{Payload: f.(*frame.PingFrame).Payload}
+			if err := conn.FrameConn().WriteFrame(pong); err != nil {
+				conn.Logger.Info("failed to write pong frame", "err", err)
+				return
+			}
+		case frame.TypePongFrame:
+			conn.pings.pong(f.(*frame.PongFrame).Payload, time.Now())
+		case frame.TypeErrorFrame:
+			// the sfn reports the failure of the data written by a source.
+			if conn.ClientType() != ClientTypeStreamFunction {
+				conn.Logger.Info("unexpected error frame", "client_type", conn.ClientType().String())
+				continue
+			}
+			s.sendErrorFrame(f.(*frame.ErrorFrame), GetTenantIDFromMetadata(conn.Metadata()))
+		case frame.TypeRequestFrame:
+			if conn.ClientType() != ClientTypeSource {
+				conn.Logger.Info("unexpected request frame", "client_type", conn.ClientType().String())
+				continue
+			}
+			s.routingRequestFrame(conn, f.(*frame.RequestFrame))
+		case frame.TypeResponseFrame:
+			// the sfn answers the request of a source.
+			if conn.ClientType() != ClientTypeStreamFunction {
+				conn.Logger.Info("unexpected response frame", "client_type", conn.ClientType().String())
+				continue
+			}
+			rf := f.(*frame.ResponseFrame)
+			s.writeToSource(rf.SourceID, tenantFindConnectionFunc(GetTenantIDFromMetadata(conn.Metadata())), rf)
 		default:
 			conn.Logger.Info("unexpected frame", "type", f.Type().String())
 			return
@@ -261,21 +468,75 @@ func (s *Server) handleConn(conn *Connection) {
 	}
 }
 
+// serveControl replies the PingFrames received from the control stream, it runs apart from the
+// data frames, so the heartbeat of the client is not blocked behind routing.
+func (s *Server) serveControl(conn *Connection, cc frame.ControlConn) {
+	for {
+		f, err := cc.ReadControlFrame()
+		if err != nil {
+			return
+		}
+		switch ff := f.(type) {
+		case *frame.PingFrame:
+			if err := cc.WriteControlFrame(&frame.PongFrame{Payload: ff.Payload}); err != nil {
+				conn.Logger.Info("failed to write pong frame", "err", err)
+				return
+			}
+		default:
+			conn.Logger.Info("unexpected control frame", "type", f.Type().String())
+		}
+	}
+}
+
+// updateObserveDataTags updates the route rule of the sfn connection without reconnecting.
+func (s *Server) updateObserveDataTags(conn *Connection, f *frame.ObserveUpdateFrame) error {
+	if conn.ClientType() != ClientTypeStreamFunction {
+		return fmt.Errorf("yomo: %s cannot observe data tags", conn.ClientType().String())
+	}
+	tags := conn.updateObserveDataTags(f)
+
+	if u, ok := s.router.(router.Updater); ok {
+		return u.Update(conn.ID(), tags, conn.Metadata())
+	}
+	s.router.Remove(conn.ID())
+	return s.router.Add(conn.ID(), tags, conn.Metadata())
+}
+
 func (s *Server) authenticate(hf *frame.HandshakeFrame) (metadata.M, error) {
-	md, ok := auth.Authenticate(s.opts.auths, hf)
+	// SetAuths replaces the auths, so the snapshot is safe to use without the lock.
+	s.authMu.RLock()
+	auths := s.opts.auths
+	s.authMu.RUnlock()
+
+	md, ok := auth.Authenticate(auths, hf)
 	if !ok {
+		s.authFailures.Add(1)
 		s.logger.Warn(
 			"authentication failed",
 			"client_type", ClientType(hf.ClientType).String(),
 			"client_name", hf.Name,
 			"credential", hf.AuthName,
 		)
-		return nil, fmt.Errorf("authentication failed: client credential type is %s", hf.AuthName)
+		if auth.Expired(auths, hf) {
+			return nil, &ErrRejected{Message: "authentication failed: client credential is expired", Reason: ReasonAuthExpired}
+		}
+		return nil, &ErrRejected{
+			Message: fmt.Sprintf("authentication failed: client credential type is %s", hf.AuthName),
+			Reason:  ReasonAuthFailed,
+		}
 	}
 
 	return md, nil
 }
 
+// discardConnection removes the connection rejected after it is created.
+func (s *Server) discardConnection(conn *Connection) {
+	_ = s.connector.Remove(conn.ID())
+	if conn.consumer != nil {
+		conn.consumer.close()
+	}
+}
+
 func (s *Server) createConnection(hf *frame.HandshakeFrame, md metadata.M, fconn frame.Conn) (*Connection, error) {
 	conn := newConnection(
 		hf.Name,
@@ -286,8 +547,25 @@ func (s *Server) createConnection(hf *frame.HandshakeFrame, md metadata.M, fconn
 		fconn,
 		s.logger,
 	)
+	conn.schemaVersions = hf.SchemaVersions
+	conn.handshakeExts = hf.Extensions
+	if quota, ok := s.opts.quotas.of(hf); ok {
+		conn.quota = newQuotaLimiter(quota)
+	}
+	if sc, ok := s.opts.slowConsumers.of(hf.Name); ok && conn.ClientType() == ClientTypeStreamFunction {
+		conn.consumer = newConsumerQueue(conn, sc)
+	}
 
-	return conn, s.connector.Store(hf.ID, conn)
+	if _, loaded, err := s.connector.LoadOrStore(hf.ID, conn); err != nil || loaded {
+		if conn.consumer != nil {
+			conn.consumer.close()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, &ErrRejected{Message: fmt.Sprintf("client %s is connected", hf.ID), Reason: ReasonDuplicateClient}
+	}
+	return conn, nil
 }
 
 func (s *Server) addSfnRouteRule(hf *frame.HandshakeFrame, md metadata.M) error {
@@ -298,6 +576,48 @@ func (s *Server) addSfnRouteRule(hf *frame.HandshakeFrame, md metadata.M) error
 }
 
 func (s *Server) handleFrame(c *Context) {
+	// drop the expired frames.
+	if expire, ok := GetExpireFromMetadata(c.FrameMetadata); ok && time.Now().After(expire) {
+		c.Logger.Debug("drop expired frame", "tag", c.Frame.Tag, "expire", expire)
+		return
+	}
+
+	if !s.checkQuota(c) {
+		return
+	}
+
+	if name, err := s.opts.plugins.frame(c); err != nil {
+		c.Logger.Debug("plugin drops frame", "plugin", name, "tag", c.Frame.Tag, "err", err)
+		return
+	}
+
+	// drop the duplicate frames from redundant paths.
+	if s.deduper != nil && c.Connection.ClientType() == ClientTypeUpstreamZipper {
+		fid := GetFrameIDFromMetadata(c.FrameMetadata)
+		// the chunks of a payload share the frame ID.
+		if fid != "" && c.Frame.IsChunked() {
+			fid += "#" + strconv.FormatUint(uint64(c.Frame.Chunk.Seq), 10)
+		}
+		if fid != "" && s.deduper.Seen(fid, time.Now()) {
+			c.Logger.Debug("drop duplicate frame", "frame_id", fid, "tag", c.Frame.Tag)
+			return
+		}
+	}
+
+	if err := s.validatePayload(c); err != nil {
+		c.Logger.Debug("drop invalid frame", "tag", c.Frame.Tag, "err", err)
+		s.sendErrorFrame(NewErrorFrame(c.Frame, c.FrameMetadata, ErrorCodeInvalidPayload, err.Error()), GetTenantIDFromMetadata(c.FrameMetadata))
+		return
+	}
+
+	if s.relayed(c) {
+		c.Logger.Debug("drop looped frame", "tag", c.Frame.Tag, "zipper_path", GetZipperPathFromMetadata(c.FrameMetadata))
+		return
+	}
+
+	s.journal(c)
+	c.routed = true
+
 	// routing data frame.
 	if err := s.routingDataFrame(c); err != nil {
 		c.CloseWithError(fmt.Sprintf("handle dataFrame err: %v", err))
@@ -318,25 +638,44 @@ func (s *Server) routingDataFrame(c *Context) error {
 	// counter +1
 	atomic.AddInt64(&s.counterOfDataFrame, 1)
 
-	md, endFn := ZipperTraceMetadata(c.FrameMetadata, s.TracerProvider(), c.Logger)
+	md, endFn := ZipperTraceMetadata(c.FrameMetadata, s.TracerProvider(), c.Logger, TagTraceAttrs(s.opts.tagNamer, dataFrame.Tag))
 	defer endFn()
 
 	c.FrameMetadata = md
 
-	mdBytes, err := c.FrameMetadata.Encode()
-	if err != nil {
-		c.Logger.Error("encode metadata error", "err", err)
-		return err
+	// the signal frame without metadata is routed as is.
+	if len(md) > 0 {
+		// label the zipper that the frame enters.
+		if _, ok := md.Get(MetadataZipperKey); !ok && s.opts.originLabel {
+			md.Set(MetadataZipperKey, s.name)
+		}
+		mdBytes, err := c.FrameMetadata.Encode()
+		if err != nil {
+			c.Logger.Error("encode metadata error", "err", err)
+			return err
+		}
+		dataFrame.Metadata = mdBytes
 	}
-	dataFrame.Metadata = mdBytes
 
 	// find stream function ids from the router.
 	connIDs := s.router.Route(dataFrame.Tag, md)
+	if target := GetTargetFromMetadata(md); target != "" {
+		connIDs = s.filterTarget(connIDs, target)
+	}
 	if len(connIDs) == 0 {
 		c.Logger.Info("no observed", "tag", dataFrame.Tag, "data_length", data_length)
+		// the frame may be observed by the downstream zippers.
+		if len(s.snapshotDownstreams()) == 0 {
+			s.sendErrorFrame(NewErrorFrame(dataFrame, md, ErrorCodeUnroutable, fmt.Sprintf("no sfn observes the tag %d", dataFrame.Tag)), GetTenantIDFromMetadata(md))
+		}
+	}
+	// the snapshot iterates all the connections, it is only taken for debugging.
+	if c.Logger.Enabled(c, slog.LevelDebug) {
+		c.Logger.Debug("connector snapshot", "tag", dataFrame.Tag, "sfn_conn_ids", connIDs, "connector", s.connector.Snapshot())
 	}
-	c.Logger.Debug("connector snapshot", "tag", dataFrame.Tag, "sfn_conn_ids", connIDs, "connector", s.connector.Snapshot())
 
+	candidates := make([]*Connection, 0, len(connIDs))
+	frames := make(map[*Connection]*frame.DataFrame, len(connIDs))
 	for _, toID := range connIDs {
 		conn, ok, err := s.connector.Get(toID)
 		if err != nil {
@@ -346,14 +685,30 @@ func (s *Server) routingDataFrame(c *Context) error {
 			c.Logger.Error("can't find forward conn", "to_id", toID, "to_name", conn.Name())
 			continue
 		}
+		// the route of the departing sfn may be taken before it is removed.
+		if conn.Departing() {
+			continue
+		}
+		// the sfns not supporting the schema version are not candidates.
+		df, ok := s.schemaFrame(conn, dataFrame, md)
+		if !ok {
+			c.Logger.Debug("incompatible schema version", "tag", dataFrame.Tag, "to_id", toID, "to_name", conn.Name())
+			continue
+		}
+		candidates = append(candidates, conn)
+		frames[conn] = df
+	}
+
+	for _, conn := range s.dispatcher.dispatch(dataFrame, md, candidates) {
+		toID, df := conn.ID(), frames[conn]
 
-		// write data frame to conn
-		if err := conn.FrameConn().WriteFrame(dataFrame); err != nil {
+		if err := s.writeDataFrame(conn, df); err != nil {
 			c.Logger.Error(
 				"failed to route data", "err", err,
 				"tag", dataFrame.Tag, "data_length", data_length, "to_id", toID, "to_name", conn.Name(),
 			)
 		} else {
+			s.flows.add(dataFrame.Tag, c.Connection.Name(), conn.Name())
 			c.Logger.Info(
 				"data routing",
 				"tag", dataFrame.Tag, "data_length", data_length, "to_id", toID, "to_name", conn.Name(),
@@ -364,15 +719,63 @@ func (s *Server) routingDataFrame(c *Context) error {
 	return nil
 }
 
+// writeDataFrame writes the DataFrame to the conn, the frame is spilled to disk if the consumer of the tag is slow,
+// or it is buffered by the slow consumer policy of the conn.
+func (s *Server) writeDataFrame(conn *Connection, df *frame.DataFrame) error {
+	if limit, ok := s.opts.spillLimits[df.Tag]; ok {
+		conn.outstanding.Add(1)
+		defer conn.outstanding.Add(-1)
+		return s.spillFrame(conn, df, limit)
+	}
+	if conn.consumer != nil {
+		// the queued frame is written later, so it must not share the metadata
+		// the frame is given by dispatchToDownstreams after routing.
+		queued := *df
+		queued.Metadata = slices.Clone(df.Metadata)
+		return conn.consumer.push(&queued)
+	}
+	conn.outstanding.Add(1)
+	defer conn.outstanding.Add(-1)
+	return conn.FrameConn().WriteFrame(df)
+}
+
+// filterTarget filters the connections whose name is the target.
+func (s *Server) filterTarget(connIDs []string, target string) []string {
+	result := make([]string, 0, len(connIDs))
+	for _, connID := range connIDs {
+		conn, ok, err := s.connector.Get(connID)
+		if err != nil || !ok {
+			continue
+		}
+		if conn.Name() == target {
+			result = append(result, connID)
+		}
+	}
+	return result
+}
+
 // dispatch every DataFrames to all downstreams
 func (s *Server) dispatchToDownstreams(c *Context) error {
 	dataFrame := c.Frame
-	if c.Connection.ClientType() == ClientTypeUpstreamZipper {
+	if s.deduper != nil {
+		// loop protection by deduplication.
+		s.deduper.stamp(c.FrameMetadata, c.Connection.ClientType() == ClientTypeStreamFunction)
+	} else if c.Connection.ClientType() == ClientTypeUpstreamZipper && s.opts.maxHops <= 0 {
 		c.Logger.Debug("ignored client", "client_type", c.Connection.ClientType().String())
 		// loop protection
 		return nil
 	}
 
+	// loop protection by the zipper path.
+	var path []string
+	if s.opts.maxHops > 0 {
+		var ok bool
+		if path, ok = s.relayPath(c.FrameMetadata); !ok {
+			c.Logger.Debug("drop frame relayed by too many zippers", "tag", dataFrame.Tag, "max_hops", s.opts.maxHops)
+			return nil
+		}
+	}
+
 	mdBytes, err := c.FrameMetadata.Encode()
 	if err != nil {
 		c.Logger.Error("failed to dispatch to downstream", "err", err)
@@ -380,7 +783,21 @@ func (s *Server) dispatchToDownstreams(c *Context) error {
 	}
 	dataFrame.Metadata = mdBytes
 
-	for _, ds := range s.downstreams {
+	for _, ds := range s.snapshotDownstreams() {
+		if slices.Contains(path, ds.LocalName()) {
+			continue
+		}
+		if !s.downstreamHealthy(ds.ID()) {
+			c.Logger.Debug("downstream is out of rotation", "tag", dataFrame.Tag, "downstream_id", ds.ID(), "downstream_name", ds.LocalName())
+			continue
+		}
+		if filter, ok := ds.(DownstreamTagFilter); ok && !filter.AllowTag(dataFrame.Tag) {
+			c.Logger.Debug(
+				"tag filtered by downstream",
+				"tag", dataFrame.Tag, "downstream_id", ds.ID(), "downstream_name", ds.LocalName(),
+			)
+			continue
+		}
 
 		if err = ds.WriteFrame(dataFrame); err != nil {
 			c.Logger.Error(
@@ -390,6 +807,7 @@ func (s *Server) dispatchToDownstreams(c *Context) error {
 				"downstream_id", ds.ID(), "downstream_name", ds.LocalName(),
 			)
 		} else {
+			s.flows.add(dataFrame.Tag, c.Connection.Name(), ds.LocalName())
 			c.Logger.Info(
 				"dispatching to downstream",
 				"tag", dataFrame.Tag, "data_length", len(dataFrame.Payload),
@@ -401,7 +819,7 @@ func (s *Server) dispatchToDownstreams(c *Context) error {
 	return nil
 }
 
-func closeServer(downstreams map[string]Downstream, connector *Connector, listener frame.Listener, router router.Router) error {
+func closeServer(downstreams []Downstream, connector *Connector, listener frame.Listener, router router.Router) error {
 	for _, ds := range downstreams {
 		ds.Close()
 	}
@@ -434,6 +852,9 @@ func sourceIDTagFindConnectionFunc(sourceID string, tag frame.Tag) FindConnectio
 	}
 }
 
+// TagNamer returns the tag namer of server, it returns nil if the tag namer has not been set.
+func (s *Server) TagNamer() TagNamer { return s.opts.tagNamer }
+
 // StatsFunctions returns the sfn stats of server.
 func (s *Server) StatsFunctions() map[string]string {
 	return s.connector.Snapshot()
@@ -444,6 +865,24 @@ func (s *Server) StatsCounter() int64 {
 	return atomic.LoadInt64(&s.counterOfDataFrame)
 }
 
+// StatsAuthFailures returns how many handshakes fail the authentication.
+func (s *Server) StatsAuthFailures() int64 {
+	return s.authFailures.Load()
+}
+
+// StatsConnections returns the number of the connections by the client type, e.g. "Source".
+func (s *Server) StatsConnections() map[string]int {
+	result := make(map[string]int)
+	if s.connector == nil {
+		return result
+	}
+	conns, _ := s.connector.Find(func(ConnectionInfo) bool { return true })
+	for _, conn := range conns {
+		result[conn.ClientType().String()]++
+	}
+	return result
+}
+
 // Downstreams return all the downstream servers.
 func (s *Server) Downstreams() map[string]string {
 	s.mu.Lock()
@@ -456,6 +895,21 @@ func (s *Server) Downstreams() map[string]string {
 	return snapshotOfDownstream
 }
 
+// ReplicationStats returns the statistics of the downstreams that are replication links,
+// the key is the local name of the downstream.
+func (s *Server) ReplicationStats() map[string]ReplicationStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make(map[string]ReplicationStats)
+	for _, ds := range s.downstreams {
+		if link, ok := ds.(*ReplicationLink); ok {
+			stats[link.LocalName()] = link.Stats()
+		}
+	}
+	return stats
+}
+
 // ConfigRouter is used to set router by zipper
 func (s *Server) ConfigRouter(router router.Router) {
 	if router == nil {
@@ -478,10 +932,16 @@ func (s *Server) ConfigVersionNegotiateFunc(fn VersionNegotiateFunc) {
 }
 
 // AddDownstreamServer add a downstream server to this server. all the DataFrames will be
-// dispatch to all the downstreams.
+// dispatch to all the downstreams. The downstream added while the server is serving is connected at once.
+// The ErrorFrames relayed back by the downstream are delivered if it implements DownstreamErrorFrameObserver.
 func (s *Server) AddDownstreamServer(c Downstream) {
+	if o, ok := c.(DownstreamErrorFrameObserver); ok {
+		o.SetErrorFrameObserver(s.deliverErrorFrame)
+	}
+
 	s.mu.Lock()
 	s.downstreams[c.ID()] = c
+	s.connectDownstream(c)
 	s.mu.Unlock()
 }
 
@@ -497,6 +957,9 @@ func (s *Server) Close() error {
 }
 
 func (s *Server) authNames() []string {
+	s.authMu.RLock()
+	defer s.authMu.RUnlock()
+
 	if len(s.opts.auths) == 0 {
 		return []string{"none"}
 	}
@@ -2,10 +2,12 @@ package core
 
 import (
 	"crypto/tls"
+	"net/http"
 	"time"
 
 	"github.com/quic-go/quic-go"
 	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/ylog"
 	oteltrace "go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/slog"
@@ -29,13 +31,43 @@ type ServerOption func(*serverOptions)
 
 // serverOptions are the options for YoMo server.
 type serverOptions struct {
-	quicConfig       *quic.Config
-	tlsConfig        *tls.Config
-	auths            map[string]auth.Authentication
-	logger           *slog.Logger
-	tracerProvider   oteltrace.TracerProvider
-	connMiddlewares  []ConnMiddleware
-	frameMiddlewares []FrameMiddleware
+	quicConfig         *quic.Config
+	tlsConfig          *tls.Config
+	auths              map[string]auth.Authentication
+	logger             *slog.Logger
+	tracerProvider     oteltrace.TracerProvider
+	connMiddlewares    []ConnMiddleware
+	frameMiddlewares   []FrameMiddleware
+	tagNamer           TagNamer
+	dedupWindow        time.Duration
+	maxHops            int
+	healthCheck        downstreamHealthCheck
+	reloadFunc         ReloadFunc
+	connHooks          connHooks
+	drainTimeout       time.Duration
+	quotas             quotas
+	slowConsumers      slowConsumers
+	wal                *wal
+	originLabel        bool
+	adminAddr          string
+	webTransportAddr   string
+	webTransportPath   string
+	adminToken         string
+	adminHandlers      map[string]http.Handler
+	compressMinSize    int
+	schemaConverters   map[schemaConverterKey]SchemaConverter
+	payloadValidators  map[frame.Tag]PayloadValidator
+	dispatchPolicies   dispatchPolicies
+	spillDir           string
+	spillLimits        map[frame.Tag]int64
+	extensions         extensions
+	handshakeExtFunc   HandshakeExtensionHandler
+	checksum           bool
+	codecName          string
+	frameLimits        frame.Limits
+	plugins            plugins
+	leakGrace          time.Duration
+	minProtocolVersion uint32
 }
 
 func defaultServerOptions() *serverOptions {
@@ -77,6 +109,16 @@ func WithServerQuicConfig(qc *quic.Config) ServerOption {
 	}
 }
 
+// WithWebTransport makes the server accept the WebTransport sessions established at the path of addr as well,
+// e.g. ":443" and "/yomo", they are dialed by the clients with NewWebTransportTransport if the raw QUIC is blocked.
+// The sessions use the TLS and QUIC configurations of the server.
+func WithWebTransport(addr, path string) ServerOption {
+	return func(o *serverOptions) {
+		o.webTransportAddr = addr
+		o.webTransportPath = path
+	}
+}
+
 // WithServerLogger sets logger for the server.
 func WithServerLogger(logger *slog.Logger) ServerOption {
 	return func(o *serverOptions) {
@@ -104,3 +146,333 @@ func WithConnMiddleware(mws ...ConnMiddleware) ServerOption {
 		o.connMiddlewares = append(o.connMiddlewares, mws...)
 	}
 }
+
+// WithFrameDedup enables frame-ID based deduplication in the window, the frames from upstream zippers that
+// have been seen are dropped. It enables redundant paths in the mesh (e.g. A→C and A→B→C), the frames from
+// upstream zippers are dispatched to downstreams as well since the loops are broken by deduplication.
+func WithFrameDedup(window time.Duration) ServerOption {
+	return func(o *serverOptions) {
+		o.dedupWindow = window
+	}
+}
+
+// WithMeshRelay makes the zipper a relay of the mesh, the frames from the upstream zippers are dispatched to
+// the downstreams as well, so the frames cross the regions through the chains of zippers. The zippers that
+// a frame passes are recorded in its metadata, the frame is never relayed to them again and is dropped if it
+// comes back through a loop, and it is relayed by maxHops zippers at most, maxHops <= 0 means DefaultMaxHops.
+func WithMeshRelay(maxHops int) ServerOption {
+	return func(o *serverOptions) {
+		if maxHops <= 0 {
+			maxHops = DefaultMaxHops
+		}
+		o.maxHops = maxHops
+	}
+}
+
+// WithOriginLabel labels the DataFrames entering the zipper with its name by MetadataZipperKey,
+// so the stream functions know where the data come from, the frames labeled by the upstream zippers are not relabeled.
+func WithOriginLabel() ServerOption {
+	return func(o *serverOptions) {
+		o.originLabel = true
+	}
+}
+
+// WithQuota sets the default quota of the clients, the upstream zippers are not limited by it.
+func WithQuota(quota Quota) ServerOption {
+	return func(o *serverOptions) {
+		o.quotas.all = &quota
+	}
+}
+
+// WithClientQuota sets the quota of the clients of the names, it takes precedence over the default quota.
+func WithClientQuota(quota Quota, names ...string) ServerOption {
+	return func(o *serverOptions) {
+		if o.quotas.names == nil {
+			o.quotas.names = make(map[string]Quota)
+		}
+		for _, name := range names {
+			o.quotas.names[name] = quota
+		}
+	}
+}
+
+// WithCredentialQuota sets the quota of the clients of the credentials, e.g. "token:<CREDENTIAL>", in the format
+// of the credential of the client, see WithCredential. It takes precedence over the quota of the client name.
+func WithCredentialQuota(quota Quota, credentials ...string) ServerOption {
+	return func(o *serverOptions) {
+		if o.quotas.credentials == nil {
+			o.quotas.credentials = make(map[string]Quota)
+		}
+		for _, credential := range credentials {
+			o.quotas.credentials[credential] = quota
+		}
+	}
+}
+
+// WithReloadFunc sets the function that reloads the configuration of the server on SIGHUP
+// or by `POST /reload` of the admin, see Server.Reload.
+func WithReloadFunc(fn ReloadFunc) ServerOption {
+	return func(o *serverOptions) {
+		o.reloadFunc = fn
+	}
+}
+
+// WithConnectHook adds the hooks called after a client passes the handshake, the client is rejected
+// if a hook returns an error, see ConnectHook. A hook is the Plugin whose OnConnection is the hook,
+// so the hooks and the plugins are called in the order of the options.
+func WithConnectHook(hooks ...ConnectHook) ServerOption {
+	return func(o *serverOptions) {
+		for _, hook := range hooks {
+			o.plugins = append(o.plugins, connectHookPlugin{hook: hook})
+		}
+	}
+}
+
+// WithDisconnectHook adds the hooks called after the connection of a client is closed, see DisconnectHook.
+func WithDisconnectHook(hooks ...DisconnectHook) ServerOption {
+	return func(o *serverOptions) {
+		o.connHooks.disconnect = append(o.connHooks.disconnect, hooks...)
+	}
+}
+
+// WithServerDrainTimeout sets the timeout of draining the connections on Shutdown, see Server.Shutdown,
+// timeout <= 0 means DefaultServerDrainTimeout.
+func WithServerDrainTimeout(timeout time.Duration) ServerOption {
+	return func(o *serverOptions) {
+		o.drainTimeout = timeout
+	}
+}
+
+// WithDownstreamHealthCheck probes the downstream zippers every interval, a downstream is out of rotation
+// after threshold consecutive probes fail or time out, and it is back in rotation once a probe succeeds.
+// The DataFrames are not dispatched to the downstreams out of rotation. Only the downstreams implementing
+// DownstreamPinger are probed, threshold <= 0 means 3, timeout <= 0 means the interval.
+func WithDownstreamHealthCheck(interval, timeout time.Duration, threshold int) ServerOption {
+	return func(o *serverOptions) {
+		if timeout <= 0 {
+			timeout = interval
+		}
+		if threshold <= 0 {
+			threshold = 3
+		}
+		o.healthCheck = downstreamHealthCheck{interval: interval, timeout: timeout, threshold: threshold}
+	}
+}
+
+// WithSlowConsumerPolicy buffers up to buffer DataFrames to the stream functions of the names, and applies
+// the policy when the buffer is full, so a slow stream function does not backpressure the routing to the others.
+// The policy applies to all the stream functions if no name is given, the policy of a name takes precedence.
+func WithSlowConsumerPolicy(policy SlowConsumerPolicy, buffer int, names ...string) ServerOption {
+	return func(o *serverOptions) {
+		sc := slowConsumer{policy: policy, buffer: buffer}
+		if len(names) == 0 {
+			o.slowConsumers.all = &sc
+			return
+		}
+		if o.slowConsumers.names == nil {
+			o.slowConsumers.names = make(map[string]slowConsumer)
+		}
+		for _, name := range names {
+			o.slowConsumers.names[name] = sc
+		}
+	}
+}
+
+// WithWAL journals the DataFrames of the tags, or of all the tags if no tag is given, to the store before they
+// are routed, the stream functions replay them from an offset by WithReplayFrom. The store is not closed by the server.
+func WithWAL(store FrameStore, tags ...frame.Tag) ServerOption {
+	return func(o *serverOptions) {
+		o.wal = &wal{store: store, tags: tags}
+	}
+}
+
+// WithSpill spills the DataFrames of the tag to disk if a stream function observing the tag is slower
+// than the producer, the producer is backpressured only if the spilled bytes of the stream function exceed
+// the limit. The frames are buffered in memory first, and the spill files are removed once they are read,
+// so the disk used is bounded by the limit. It suits the bursty workloads like file transfer.
+// The frames of the tag keep their order, but they may be delivered after the frames of other tags written later.
+func WithSpill(tag frame.Tag, limit int64) ServerOption {
+	return func(o *serverOptions) {
+		if o.spillLimits == nil {
+			o.spillLimits = make(map[frame.Tag]int64)
+		}
+		o.spillLimits[tag] = limit
+	}
+}
+
+// WithSpillDir sets the directory of the spill files, the default is the temporary directory of the OS.
+func WithSpillDir(dir string) ServerOption {
+	return func(o *serverOptions) {
+		o.spillDir = dir
+	}
+}
+
+// WithAdminAddr sets the address of the admin http server, the topology of the server is exported
+// at `/topology` as JSON, or DOT with the query `format=dot`, and `POST /reload` reloads the server.
+// The endpoints are not authenticated unless WithAdminToken is set, so the address should be private,
+// e.g. "127.0.0.1:9000". It is ignored if the build tag `yomo_noadmin` is set.
+func WithAdminAddr(addr string) ServerOption {
+	return func(o *serverOptions) {
+		o.adminAddr = addr
+	}
+}
+
+// WithAdminToken makes the admin http server only serve the requests with the header
+// `Authorization: Bearer <token>`, the others are answered with 401, see WithAdminAddr.
+func WithAdminToken(token string) ServerOption {
+	return func(o *serverOptions) {
+		o.adminToken = token
+	}
+}
+
+// WithAdminHandler serves the handler at the pattern of the admin http server besides the builtin endpoints,
+// e.g. the metrics exporter, see WithAdminAddr.
+func WithAdminHandler(pattern string, handler http.Handler) ServerOption {
+	return func(o *serverOptions) {
+		if o.adminHandlers == nil {
+			o.adminHandlers = make(map[string]http.Handler)
+		}
+		o.adminHandlers[pattern] = handler
+	}
+}
+
+// WithSchemaConverter registers the converter that up-converts the payloads of the tag from a schema
+// version to another, the zipper routes the frames to the sfns supporting only the new version through it.
+func WithSchemaConverter(tag frame.Tag, from, to string, converter SchemaConverter) ServerOption {
+	return func(o *serverOptions) {
+		if o.schemaConverters == nil {
+			o.schemaConverters = make(map[schemaConverterKey]SchemaConverter)
+		}
+		o.schemaConverters[schemaConverterKey{tag: tag, from: from, to: to}] = converter
+	}
+}
+
+// WithPayloadValidator registers the validator of the payloads of the tag, zipper drops the invalid
+// DataFrames, including the chunked ones, and reports them to the sources by ErrorFrames,
+// see Server.SetPayloadValidator.
+func WithPayloadValidator(tag frame.Tag, validator PayloadValidator) ServerOption {
+	return func(o *serverOptions) {
+		if o.payloadValidators == nil {
+			o.payloadValidators = make(map[frame.Tag]PayloadValidator)
+		}
+		o.payloadValidators[tag] = validator
+	}
+}
+
+// WithDispatchPolicy sets the policy that dispatches the DataFrames of the tags to the sfns observing them,
+// the policy applies to all the tags if no tag is given. The default policy is DispatchBroadcast.
+func WithDispatchPolicy(policy DispatchPolicy, tags ...frame.Tag) ServerOption {
+	return func(o *serverOptions) {
+		if len(tags) == 0 {
+			o.dispatchPolicies.all = policy
+			return
+		}
+		if o.dispatchPolicies.tags == nil {
+			o.dispatchPolicies.tags = make(map[frame.Tag]DispatchPolicy)
+		}
+		for _, tag := range tags {
+			o.dispatchPolicies.tags[tag] = policy
+		}
+	}
+}
+
+// WithStickyRouting dispatches the DataFrames sharing a partition key or a TID of the tags to the same sfn,
+// so the stateful sfns, e.g. the session aggregations, work across the replicas. A key keeps its sfn until
+// it is idle for ttl, ttl <= 0 means DefaultStickyTTL, a TID is hashed to its sfn instead of being kept.
+// It applies to all the tags if no tag is given, see DispatchSticky and MetadataPartitionKey.
+func WithStickyRouting(ttl time.Duration, tags ...frame.Tag) ServerOption {
+	return func(o *serverOptions) {
+		o.dispatchPolicies.stickyTTL = ttl
+		WithDispatchPolicy(DispatchSticky, tags...)(o)
+	}
+}
+
+// HandshakeExtensionHandler answers the extensions piggybacked on the handshake, the returned extensions
+// are sent back in HandshakeAckFrame, and the handshake is rejected if it returns an error.
+type HandshakeExtensionHandler func(hf *frame.HandshakeFrame) (map[string][]byte, error)
+
+// WithHandshakeExtensionHandler sets the handler that answers the extensions of the handshakes,
+// it is called after the authentication.
+func WithHandshakeExtensionHandler(fn HandshakeExtensionHandler) ServerOption {
+	return func(o *serverOptions) {
+		o.handshakeExtFunc = fn
+	}
+}
+
+// WithServerCompression makes the server accept the payload compression offered by clients in handshake,
+// the payloads of DataFrames larger than minSize are compressed on the negotiated connections.
+// minSize <= 0 means DefaultCompressMinSize.
+func WithServerCompression(minSize int) ServerOption {
+	return func(o *serverOptions) {
+		if minSize <= 0 {
+			minSize = DefaultCompressMinSize
+		}
+		o.compressMinSize = minSize
+	}
+}
+
+// WithServerExtension registers the handler of the extension frame type, the frames of the unregistered
+// types are skipped. The builtin frame types cannot be extended.
+func WithServerExtension(t frame.Type, handler ExtensionHandler) ServerOption {
+	return func(o *serverOptions) {
+		o.extensions.register(t, handler)
+	}
+}
+
+// WithServerChecksum makes the server append the checksum to the DataFrames it writes, the corrupted
+// frames are dropped instead of breaking the connection. The checksums of the received frames are
+// verified whenever they are present.
+func WithServerChecksum() ServerOption {
+	return func(o *serverOptions) {
+		o.checksum = true
+	}
+}
+
+// WithServerCodecName selects the codec registered by the name to encode the frames, e.g. "cbor",
+// the clients must be configured with the same codec. The default is the y3 codec. The serving fails
+// if the codec is not registered, and the checksum and the frame limits apply to the y3 codec only.
+// The built-in codecs, "y3" and "cbor", are always registered.
+func WithServerCodecName(name string) ServerOption {
+	return func(o *serverOptions) {
+		o.codecName = name
+	}
+}
+
+// WithFrameLimits sets the limits of the frames read from the clients, the frame exceeding them is dropped
+// and the connection keeps working. The zero fields take the values of frame.DefaultLimits.
+func WithFrameLimits(limits frame.Limits) ServerOption {
+	return func(o *serverOptions) {
+		o.frameLimits = limits
+	}
+}
+
+// WithServerTagNamer sets the tag namer for the server, the tag names are displayed in logs and traces.
+func WithServerTagNamer(namer TagNamer) ServerOption {
+	return func(o *serverOptions) {
+		o.tagNamer = namer
+	}
+}
+
+// WithPlugin registers the plugins on the server, see Plugin.
+func WithPlugin(ps ...Plugin) ServerOption {
+	return func(o *serverOptions) {
+		o.plugins = append(o.plugins, ps...)
+	}
+}
+
+// WithLeakDetection enables the leak detection for debugging, the goroutines and buffers owned by
+// a connection, including its slow consumer queue and spill queues, are expected to return to zero within the grace period after the connection is closed,
+// otherwise the leak is logged and counted in Server.LeakedConnections.
+func WithLeakDetection(grace time.Duration) ServerOption {
+	return func(o *serverOptions) {
+		o.leakGrace = grace
+	}
+}
+
+// WithMinProtocolVersion sets the lowest protocol version of the clients that the server accepts,
+// the handshakes of the older clients are rejected with ErrVersionUnsupported.
+func WithMinProtocolVersion(version uint32) ServerOption {
+	return func(o *serverOptions) {
+		o.minProtocolVersion = version
+	}
+}
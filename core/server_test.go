@@ -1,12 +1,14 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
 	_ "github.com/yomorun/yomo/pkg/auth"
 )
 
@@ -111,3 +113,22 @@ func (s *mockConnectionInfo) Name() string                 { return s.name }
 func (s *mockConnectionInfo) Metadata() metadata.M         { return s.metadata }
 func (s *mockConnectionInfo) ClientType() ClientType       { return s.clientType }
 func (s *mockConnectionInfo) ObserveDataTags() []frame.Tag { return s.observed }
+
+func TestOriginLabel(t *testing.T) {
+	label := func(opts ...ServerOption) string {
+		server := NewServer("zipper", append(opts, WithServerLogger(discardingLogger))...)
+		server.ConfigRouter(router.Default())
+		server.connector = NewConnector(context.TODO())
+
+		md, _ := metadata.M{MetadataTIDKey: "tid"}.Encode()
+		source := newConnection("source", "source-id", ClientTypeSource, nil, nil, nil, discardingLogger)
+		c, err := newContext(source, &frame.DataFrame{Tag: 1, Metadata: md})
+		assert.NoError(t, err)
+		assert.NoError(t, server.routingDataFrame(c))
+
+		zipper, _ := c.FrameMetadata.Get(MetadataZipperKey)
+		return zipper
+	}
+	assert.Empty(t, label())
+	assert.Equal(t, "zipper", label(WithOriginLabel()))
+}
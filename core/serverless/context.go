@@ -3,12 +3,19 @@ package serverless
 
 import (
 	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
 )
 
+// frameScopedKeys are the metadata keys addressing or describing the data frame itself, the data written
+// by the context does not inherit them.
+var frameScopedKeys = []string{metadata.TargetKey, metadata.SchemaVersionKey, metadata.WALOffsetKey}
+
 // Context sfn handler context
 type Context struct {
 	writer    frame.Writer
 	dataFrame *frame.DataFrame
+	md        metadata.M
+	outMD     []byte // the metadata of the data written by the context
 }
 
 // NewContext creates a new serverless Context
@@ -24,22 +31,84 @@ func (c *Context) Tag() uint32 {
 	return c.dataFrame.Tag
 }
 
-// Data returns the data of the data frame
+// Channel returns the logical stream of the data frame, the data written by the context stays on it.
+func (c *Context) Channel() uint32 {
+	return c.dataFrame.Channel
+}
+
+// Data returns the data of the data frame, it refers to the packet read from zipper without copying,
+// and it is safe to retain after the handler returns.
 func (c *Context) Data() []byte {
 	return c.dataFrame.Payload
 }
 
-// Write writes the data
+// Metadata returns the value of the given key in the metadata of the data frame.
+func (c *Context) Metadata(key string) (string, bool) {
+	md, err := c.metadata()
+	if err != nil {
+		return "", false
+	}
+	return md.Get(key)
+}
+
+func (c *Context) metadata() (metadata.M, error) {
+	if c.md == nil {
+		md, err := metadata.Decode(c.dataFrame.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		c.md = md
+	}
+	return c.md, nil
+}
+
+// Write writes the data, it inherits the metadata of the data frame except the keys addressing
+// the data frame itself, e.g. the target.
 func (c *Context) Write(tag uint32, data []byte) error {
 	if data == nil {
 		return nil
 	}
 
+	md, err := c.outputMetadata()
+	if err != nil {
+		return err
+	}
 	dataFrame := &frame.DataFrame{
 		Tag:      tag,
-		Metadata: c.dataFrame.Metadata,
+		Metadata: md,
 		Payload:  data,
+		Channel:  c.dataFrame.Channel,
 	}
 
 	return c.writer.WriteFrame(dataFrame)
 }
+
+// outputMetadata returns the metadata of the data written by the context.
+func (c *Context) outputMetadata() ([]byte, error) {
+	if c.outMD != nil {
+		return c.outMD, nil
+	}
+	md, err := c.metadata()
+	if err != nil || !hasAny(md, frameScopedKeys) {
+		// the invalid metadata is passed through, the zipper handles it.
+		c.outMD = c.dataFrame.Metadata
+		return c.outMD, nil
+	}
+	md = md.Clone()
+	for _, k := range frameScopedKeys {
+		delete(md, k)
+	}
+	if c.outMD, err = md.Encode(); err != nil {
+		return nil, err
+	}
+	return c.outMD, nil
+}
+
+func hasAny(md metadata.M, keys []string) bool {
+	for _, k := range keys {
+		if _, ok := md[k]; ok {
+			return true
+		}
+	}
+	return false
+}
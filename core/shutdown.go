@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultServerDrainTimeout is the default timeout of draining the connections on Shutdown.
+const DefaultServerDrainTimeout = 10 * time.Second
+
+// drainPollInterval is the interval of checking whether the connections are drained.
+const drainPollInterval = 10 * time.Millisecond
+
+// Shutdown shuts down the server gracefully, the clients reconnect to another zipper or to this zipper
+// after it restarts, nothing in flight is lost if the draining finishes within the drain timeout:
+//
+//  1. the new connections are rejected with ReasonServerDraining.
+//  2. the sources and the upstream zippers are told to go away by a GoawayFrame, so no data comes in.
+//  3. the frames in flight to the stream functions are flushed, including the queued and spilled frames.
+//  4. the stream functions are told to go away, then the server is closed.
+//
+// It returns the error of ctx if ctx is done or the drain timeout is reached before the connections
+// are drained, the server is closed anyway, see WithServerDrainTimeout.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if !s.draining.CompareAndSwap(false, true) || s.connector == nil {
+		return s.Close()
+	}
+	defer s.Close()
+
+	timeout := s.opts.drainTimeout
+	if timeout <= 0 {
+		timeout = DefaultServerDrainTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	s.logger.Info("zipper is draining", "drain_timeout", timeout)
+
+	isProducer := func(conn ConnectionInfo) bool { return conn.ClientType() != ClientTypeStreamFunction }
+
+	s.goawayConnections(isProducer)
+	if err := s.waitDrained(ctx, func() bool { return !s.hasConnections(isProducer) && s.flushed() }); err != nil {
+		s.logger.Warn("zipper is closed before the frames in flight are flushed", "err", err)
+		return err
+	}
+
+	s.goawayConnections(func(ConnectionInfo) bool { return true })
+	if err := s.waitDrained(ctx, func() bool { return !s.hasConnections(func(ConnectionInfo) bool { return true }) }); err != nil {
+		s.logger.Warn("zipper is closed before the clients disconnect", "err", err)
+		return err
+	}
+
+	s.logger.Info("zipper is drained")
+	return nil
+}
+
+// Draining reports whether the server is shutting down, see Shutdown.
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+// goawayConnections tells the connections to go away.
+func (s *Server) goawayConnections(findFunc FindConnectionFunc) {
+	conns, _ := s.connector.Find(findFunc)
+	for _, conn := range conns {
+		conn.Logger.Info("tell client to go away, the zipper is draining")
+		goaway(conn.FrameConn(), "the zipper is draining", ReasonServerDraining)
+	}
+}
+
+// hasConnections reports whether any connection is found by the function.
+func (s *Server) hasConnections(findFunc FindConnectionFunc) bool {
+	conns, _ := s.connector.Find(findFunc)
+	return len(conns) > 0
+}
+
+// flushed reports whether all the frames routed to the connections are written.
+func (s *Server) flushed() bool {
+	conns, _ := s.connector.Find(func(ConnectionInfo) bool { return true })
+	for _, conn := range conns {
+		if conn.Outstanding() > 0 {
+			return false
+		}
+	}
+	flushed := true
+	s.spills.Range(func(_, value any) bool {
+		flushed = value.(*spillQueue).len() == 0
+		return flushed
+	})
+	return flushed
+}
+
+// waitDrained waits until drained returns true or ctx is done.
+func (s *Server) waitDrained(ctx context.Context, drained func() bool) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for !drained() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
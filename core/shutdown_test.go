@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/router"
+)
+
+func TestShutdown(t *testing.T) {
+	t.Parallel()
+
+	const shutdownAddr = "127.0.0.1:19966"
+
+	server := NewServer("zipper",
+		WithServerLogger(discardingLogger),
+		WithServerDrainTimeout(5*time.Second),
+		WithSlowConsumerPolicy(SlowConsumerBlock, 100),
+	)
+	server.ConfigRouter(router.Default())
+	served := make(chan error, 1)
+	go func() { served <- server.ListenAndServe(context.TODO(), shutdownAddr) }()
+
+	var received atomic.Int64
+	sfn := NewClient("sfn", shutdownAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	sfn.SetObserveDataTags(1)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) {
+		// the slow sfn keeps the frames in flight at the zipper.
+		time.Sleep(time.Millisecond)
+		received.Add(1)
+	})
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	errs := make(chan error, 10)
+	source := NewClient("source", shutdownAddr, ClientTypeSource, WithLogger(discardingLogger), WithReConnect())
+	source.SetErrorHandler(func(err error) { errs <- err })
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+	for i := 0; i < 50; i++ {
+		assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("yomo")}))
+	}
+	assert.Eventually(t, func() bool { return server.StatsCounter() == 50 }, 3*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, server.Shutdown(context.TODO()))
+	assert.True(t, server.Draining())
+	assert.ErrorIs(t, <-served, ErrServerClosed)
+
+	// the frames in flight are flushed before the sfn is told to go away.
+	assert.Eventually(t, func() bool { return received.Load() == 50 }, 3*time.Second, 10*time.Millisecond)
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, ErrServerDraining)
+	case <-time.After(time.Second):
+		t.Fatal("the source is not told to go away")
+	}
+}
+
+func TestShutdownRejectsConnections(t *testing.T) {
+	t.Parallel()
+
+	const drainingAddr = "127.0.0.1:19965"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	go server.ListenAndServe(context.TODO(), drainingAddr)
+	defer server.Close()
+
+	source := NewClient("source", drainingAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.NoError(t, source.Connect(context.TODO()))
+	source.Close()
+
+	server.draining.Store(true)
+
+	source = NewClient("source", drainingAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.ErrorIs(t, source.Connect(context.TODO()), ErrServerDraining)
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+// ErrSlowConsumer is returned if the DataFrame is routed to a stream function disconnected
+// by SlowConsumerDisconnect, or to a closed stream function.
+var ErrSlowConsumer = yerr.New(yerr.CodeOverload, "yomo: slow consumer")
+
+// SlowConsumerPolicy decides what the zipper does when the buffer of a stream function is full,
+// that is the stream function reads slower than the zipper routes to it. See WithSlowConsumerPolicy.
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerBlock blocks the routing until the stream function takes a frame from the buffer,
+	// this is the default policy, the sources behind the stream function are backpressured.
+	SlowConsumerBlock SlowConsumerPolicy = iota
+	// SlowConsumerDropOldest drops the oldest frame in the buffer to make room for the incoming one,
+	// the dropped frames are counted, see Server.StatsDropped.
+	SlowConsumerDropOldest
+	// SlowConsumerDisconnect disconnects the stream function, the frames in its buffer are abandoned,
+	// and it starts with an empty buffer if it reconnects.
+	SlowConsumerDisconnect
+)
+
+// String returns the name of the policy.
+func (p SlowConsumerPolicy) String() string {
+	switch p {
+	case SlowConsumerBlock:
+		return "block"
+	case SlowConsumerDropOldest:
+		return "drop-oldest"
+	case SlowConsumerDisconnect:
+		return "disconnect"
+	default:
+		return fmt.Sprintf("SlowConsumerPolicy(%d)", int(p))
+	}
+}
+
+// slowConsumer is the policy of the stream functions and the size of their buffers.
+type slowConsumer struct {
+	policy SlowConsumerPolicy
+	buffer int
+}
+
+// slowConsumers are the slow consumer policies of the server, the policy of the name takes
+// precedence over the policy of all the stream functions.
+type slowConsumers struct {
+	all   *slowConsumer
+	names map[string]slowConsumer
+}
+
+func (p slowConsumers) of(name string) (slowConsumer, bool) {
+	if sc, ok := p.names[name]; ok {
+		return sc, true
+	}
+	if p.all != nil {
+		return *p.all, true
+	}
+	return slowConsumer{}, false
+}
+
+// consumerQueue buffers the DataFrames to a stream function, a pump goroutine writes them to the
+// stream function in order, so the routing is not blocked by the stream function until the buffer is full.
+// The buffered frames are accounted to the resources of the connection.
+type consumerQueue struct {
+	conn    *Connection
+	policy  SlowConsumerPolicy
+	frames  chan *frame.DataFrame
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Int64
+}
+
+func newConsumerQueue(conn *Connection, sc slowConsumer) *consumerQueue {
+	buffer := sc.buffer
+	if buffer < 1 {
+		buffer = 1
+	}
+	q := &consumerQueue{
+		conn:   conn,
+		policy: sc.policy,
+		frames: make(chan *frame.DataFrame, buffer),
+		done:   make(chan struct{}),
+	}
+	conn.resources.Go(q.pump)
+
+	return q
+}
+
+// push puts the DataFrame into the buffer with the policy of the queue.
+func (q *consumerQueue) push(df *frame.DataFrame) error {
+	select {
+	case <-q.done:
+		return ErrSlowConsumer
+	default:
+	}
+	q.conn.outstanding.Add(1)
+	q.conn.resources.acquire(frameSize(df))
+	// the frame pushed after the queue is closed is abandoned here, the pump has stopped.
+	defer func() {
+		select {
+		case <-q.done:
+			q.drain()
+		default:
+		}
+	}()
+
+	switch q.policy {
+	case SlowConsumerDropOldest:
+		for {
+			select {
+			case q.frames <- df:
+				return nil
+			default:
+			}
+			select {
+			case dropped := <-q.frames:
+				q.conn.outstanding.Add(-1)
+				q.conn.resources.release(frameSize(dropped))
+				q.dropped.Add(1)
+				q.conn.Logger.Debug("slow consumer, drop the oldest frame", "tag", dropped.Tag, "dropped", q.dropped.Load())
+			default:
+			}
+		}
+	case SlowConsumerDisconnect:
+		select {
+		case q.frames <- df:
+			return nil
+		default:
+			q.conn.outstanding.Add(-1)
+			q.conn.resources.release(frameSize(df))
+			q.conn.Logger.Warn("disconnect slow consumer", "tag", df.Tag, "buffer", cap(q.frames))
+			q.close()
+			_ = q.conn.FrameConn().CloseWithError(ErrSlowConsumer.Error())
+			return ErrSlowConsumer
+		}
+	default:
+		select {
+		case q.frames <- df:
+			return nil
+		case <-q.done:
+			q.conn.outstanding.Add(-1)
+			q.conn.resources.release(frameSize(df))
+			return ErrSlowConsumer
+		}
+	}
+}
+
+// pump writes the buffered frames to the stream function until the queue is closed.
+func (q *consumerQueue) pump() {
+	for {
+		select {
+		case <-q.done:
+			q.drain()
+			return
+		case df := <-q.frames:
+			size := frameSize(df)
+			if err := q.conn.FrameConn().WriteFrame(df); err != nil {
+				q.conn.Logger.Info("failed to write frame to stream function", "err", err, "tag", df.Tag)
+			}
+			q.conn.outstanding.Add(-1)
+			q.conn.resources.release(size)
+		}
+	}
+}
+
+// drain abandons the frames in the buffer of the closed queue.
+func (q *consumerQueue) drain() {
+	for {
+		select {
+		case df := <-q.frames:
+			q.conn.outstanding.Add(-1)
+			q.conn.resources.release(frameSize(df))
+		default:
+			return
+		}
+	}
+}
+
+// close closes the queue, the frames not written are abandoned.
+func (q *consumerQueue) close() {
+	q.once.Do(func() { close(q.done) })
+}
+
+// StatsDropped returns the number of the DataFrames dropped by SlowConsumerDropOldest per stream function,
+// the key is the connection id.
+func (s *Server) StatsDropped() map[string]int64 {
+	result := make(map[string]int64)
+	if s.connector == nil {
+		return result
+	}
+	conns, _ := s.connector.Find(func(ConnectionInfo) bool { return true })
+	for _, conn := range conns {
+		if conn.consumer != nil {
+			result[conn.ID()] = conn.consumer.dropped.Load()
+		}
+	}
+	return result
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/ylog"
+)
+
+// gatedConn is a frame.Conn whose WriteFrame blocks until the gate is opened.
+type gatedConn struct {
+	*stalledConn
+	gate   chan struct{}
+	mu     sync.Mutex
+	frames []frame.Tag
+}
+
+func (c *gatedConn) WriteFrame(f frame.Frame) error {
+	select {
+	case <-c.gate:
+	case <-c.ctx.Done():
+		return frame.NewErrConnClosed(false, "closed")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frames = append(c.frames, f.(*frame.DataFrame).Tag)
+	return nil
+}
+
+func (c *gatedConn) written() []frame.Tag {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]frame.Tag(nil), c.frames...)
+}
+
+func newConsumerTest(policy SlowConsumerPolicy, buffer int) (*consumerQueue, *gatedConn) {
+	fconn := &gatedConn{stalledConn: newStalledConn(), gate: make(chan struct{})}
+	conn := newConnection("sfn", "sfn-id", ClientTypeStreamFunction, metadata.M{}, nil, fconn, ylog.Default())
+	q := newConsumerQueue(conn, slowConsumer{policy: policy, buffer: buffer})
+	return q, fconn
+}
+
+// pushTaken pushes the frame and waits for the pump to take it, the pump blocks in writing it then.
+func pushTaken(t *testing.T, q *consumerQueue, tag frame.Tag) {
+	assert.NoError(t, q.push(&frame.DataFrame{Tag: tag}))
+	assert.Eventually(t, func() bool { return len(q.frames) == 0 }, time.Second, time.Millisecond)
+}
+
+func TestSlowConsumerPolicy(t *testing.T) {
+	t.Run("drop oldest", func(t *testing.T) {
+		q, fconn := newConsumerTest(SlowConsumerDropOldest, 2)
+		defer q.close()
+
+		pushTaken(t, q, 1)
+		for tag := frame.Tag(2); tag <= 5; tag++ {
+			assert.NoError(t, q.push(&frame.DataFrame{Tag: tag}))
+		}
+		assert.Equal(t, int64(2), q.dropped.Load())
+		assert.Equal(t, int64(3), q.conn.Outstanding())
+
+		close(fconn.gate)
+		assert.Eventually(t, func() bool { return len(fconn.written()) == 3 }, time.Second, time.Millisecond)
+		assert.Equal(t, []frame.Tag{1, 4, 5}, fconn.written())
+		assert.Eventually(t, func() bool { return q.conn.Outstanding() == 0 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		q, fconn := newConsumerTest(SlowConsumerDisconnect, 1)
+
+		pushTaken(t, q, 1)
+		assert.NoError(t, q.push(&frame.DataFrame{Tag: 2}))
+		assert.ErrorIs(t, q.push(&frame.DataFrame{Tag: 3}), ErrSlowConsumer)
+		assert.Error(t, fconn.Err())
+
+		// the frames are not routed to the disconnected stream function anymore.
+		assert.ErrorIs(t, q.push(&frame.DataFrame{Tag: 4}), ErrSlowConsumer)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		q, _ := newConsumerTest(SlowConsumerBlock, 1)
+
+		pushTaken(t, q, 1)
+		assert.NoError(t, q.push(&frame.DataFrame{Tag: 2}))
+
+		done := make(chan error)
+		go func() { done <- q.push(&frame.DataFrame{Tag: 3}) }()
+		select {
+		case <-done:
+			t.Fatal("the routing is not blocked")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		q.close()
+		assert.ErrorIs(t, <-done, ErrSlowConsumer)
+	})
+}
+
+func TestSlowConsumers(t *testing.T) {
+	opts := defaultServerOptions()
+	_, ok := opts.slowConsumers.of("sfn")
+	assert.False(t, ok)
+
+	WithSlowConsumerPolicy(SlowConsumerDropOldest, 10)(opts)
+	WithSlowConsumerPolicy(SlowConsumerDisconnect, 5, "laggard")(opts)
+
+	sc, _ := opts.slowConsumers.of("sfn")
+	assert.Equal(t, slowConsumer{policy: SlowConsumerDropOldest, buffer: 10}, sc)
+	sc, _ = opts.slowConsumers.of("laggard")
+	assert.Equal(t, slowConsumer{policy: SlowConsumerDisconnect, buffer: 5}, sc)
+
+	assert.Equal(t, "drop-oldest", SlowConsumerDropOldest.String())
+}
@@ -0,0 +1,333 @@
+package core
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/yerr"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+// ErrSpillClosed is returned if the DataFrame is spilled to a closed spill queue.
+var ErrSpillClosed = yerr.New(yerr.CodeClosed, "yomo: spill queue closed")
+
+// spillHeaderSize is the size of the header of a spilled DataFrame:
+// tag(4) + priority(1) + length of metadata(4) + length of payload(4) + channel(4) + chunk(12) + length of extensions(4).
+const spillHeaderSize = 33
+
+// The sizes of the spill queue, they are capped by the limit of the queue.
+const (
+	// spillMemorySize is the bytes buffered in memory before the frames are spilled to disk,
+	// the frames to a consumer catching up never touch the disk.
+	spillMemorySize = 1 << 20
+	// spillSegmentSize is the size of a spill file, the file is removed once its frames are written,
+	// so the disk used is bounded by the limit of the queue plus a segment.
+	spillSegmentSize = 16 << 20
+)
+
+// spillQueue buffers the DataFrames to a slow consumer in memory and then in files, so the producer
+// is not backpressured by the consumer until the buffered bytes exceed the limit.
+type spillQueue struct {
+	w           frame.Writer
+	dir         string
+	limit       int64
+	memorySize  int64
+	segmentSize int64
+	logger      *slog.Logger
+	resources   *resourceTracker // owns the pump goroutine and the frames buffered in memory
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	frames   []*frame.DataFrame // buffered in memory, they are older than the spilled ones
+	memory   int64              // the bytes of the frames buffered in memory
+	segments []*spillSegment    // the spill files in order
+	size     int64              // the bytes buffered and not written to the consumer
+	closed   bool
+}
+
+// spillSegment is a spill file, the frames are appended at wr and read from rd.
+type spillSegment struct {
+	file *os.File
+	wr   int64
+	rd   int64
+}
+
+// newSpillQueue creates a spill queue in the dir, it writes the frames to w in another goroutine
+// until the queue is closed, the goroutine and the memory buffer are accounted to resources.
+func newSpillQueue(dir string, limit int64, w frame.Writer, resources *resourceTracker, logger *slog.Logger) (*spillQueue, error) {
+	// the files are created at spilling, the directory is checked at once.
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+	}
+	q := &spillQueue{
+		w:           w,
+		dir:         dir,
+		limit:       limit,
+		memorySize:  int64(math.Min(spillMemorySize, float64(limit/2))),
+		segmentSize: int64(math.Max(1, math.Min(spillSegmentSize, float64(limit/4)))),
+		logger:      logger,
+		resources:   resources,
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	resources.Go(q.pump)
+
+	return q, nil
+}
+
+// push buffers the DataFrame, it blocks if the buffered bytes exceed the limit,
+// a frame larger than the limit is buffered once the queue is empty.
+func (q *spillQueue) push(df *frame.DataFrame) error {
+	size := spillRecordSize(df)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && q.size > 0 && q.size+size > q.limit {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return ErrSpillClosed
+	}
+	// the frames are kept in memory until one is spilled, the later ones are spilled until the files are drained,
+	// so the frames are written in order.
+	if len(q.segments) == 0 && q.memory+size <= q.memorySize {
+		buffered := *df
+		buffered.Metadata = slices.Clone(df.Metadata)
+		q.frames = append(q.frames, &buffered)
+		q.memory += size
+		q.resources.acquire(int(size))
+	} else if err := q.spill(df); err != nil {
+		return err
+	}
+	q.size += size
+	q.cond.Broadcast()
+
+	return nil
+}
+
+// spill appends the DataFrame to the last spill file, a new file is created if the last one is full.
+func (q *spillQueue) spill(df *frame.DataFrame) error {
+	if len(q.segments) == 0 || q.segments[len(q.segments)-1].wr >= q.segmentSize {
+		file, err := os.CreateTemp(q.dir, "yomo-spill-*")
+		if err != nil {
+			return err
+		}
+		q.segments = append(q.segments, &spillSegment{file: file})
+	}
+	seg := q.segments[len(q.segments)-1]
+
+	record := encodeSpillRecord(df)
+	if _, err := seg.file.WriteAt(record, seg.wr); err != nil {
+		return err
+	}
+	seg.wr += int64(len(record))
+
+	return nil
+}
+
+// pump writes the buffered frames to the consumer in order.
+func (q *spillQueue) pump() {
+	for {
+		q.mu.Lock()
+		for !q.closed && q.size == 0 {
+			q.cond.Wait()
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return
+		}
+		var (
+			df   *frame.DataFrame
+			size int64
+			seg  *spillSegment
+		)
+		if len(q.frames) > 0 {
+			df = q.frames[0]
+			q.frames[0] = nil
+			q.frames = q.frames[1:]
+			size = spillRecordSize(df)
+			q.mu.Unlock()
+		} else {
+			seg = q.segments[0]
+			offset := seg.rd
+			q.mu.Unlock()
+
+			// the spilled bytes before seg.wr are never changed, so they are read without the lock.
+			var err error
+			if df, size, err = readSpillRecord(seg.file, offset); err != nil {
+				// the file is closed if the queue is closed while reading.
+				if q.len() > 0 {
+					q.logger.Error("failed to read spilled frame", "err", err)
+				}
+				q.close()
+				return
+			}
+		}
+
+		if err := q.w.WriteFrame(df); err != nil {
+			q.logger.Error("failed to write spilled frame", "err", err, "tag", df.Tag)
+		}
+
+		q.mu.Lock()
+		if seg == nil {
+			// the memory is released by close if the queue is closed while writing.
+			if !q.closed {
+				q.memory -= size
+				q.resources.release(int(size))
+			}
+		} else if seg.rd += size; seg.rd == seg.wr && !q.closed {
+			// the file is removed once all its frames are written.
+			q.segments = q.segments[1:]
+			seg.remove()
+		}
+		if !q.closed {
+			q.size -= size
+		}
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}
+}
+
+func (seg *spillSegment) remove() {
+	_ = seg.file.Close()
+	_ = os.Remove(seg.file.Name())
+}
+
+// len returns the bytes buffered and not written to the consumer.
+func (q *spillQueue) len() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.size
+}
+
+// close closes the queue and removes the files, the frames not written are abandoned.
+func (q *spillQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.resources.release(int(q.memory))
+	q.frames, q.memory, q.size = nil, 0, 0
+	q.cond.Broadcast()
+
+	for _, seg := range q.segments {
+		seg.remove()
+	}
+	q.segments = nil
+}
+
+// spillRecordSize returns the size of the DataFrame spilled.
+func spillRecordSize(df *frame.DataFrame) int64 {
+	size := spillHeaderSize + len(df.Metadata) + len(df.Payload)
+	if len(df.Extensions) > 0 {
+		size += len(frame.AppendExtensions(nil, df.Extensions))
+	}
+	return int64(size)
+}
+
+func encodeSpillRecord(df *frame.DataFrame) []byte {
+	extensions := frame.AppendExtensions(nil, df.Extensions)
+	record := make([]byte, spillHeaderSize+len(df.Metadata)+len(df.Payload)+len(extensions))
+	binary.BigEndian.PutUint32(record[0:], df.Tag)
+	record[4] = byte(df.Priority)
+	binary.BigEndian.PutUint32(record[5:], uint32(len(df.Metadata)))
+	binary.BigEndian.PutUint32(record[9:], uint32(len(df.Payload)))
+	binary.BigEndian.PutUint32(record[13:], df.Channel)
+	binary.BigEndian.PutUint32(record[17:], df.Chunk.ID)
+	binary.BigEndian.PutUint32(record[21:], df.Chunk.Seq)
+	binary.BigEndian.PutUint32(record[25:], df.Chunk.Total)
+	binary.BigEndian.PutUint32(record[29:], uint32(len(extensions)))
+	n := copy(record[spillHeaderSize:], df.Metadata)
+	n += copy(record[spillHeaderSize+n:], df.Payload)
+	copy(record[spillHeaderSize+n:], extensions)
+
+	return record
+}
+
+func readSpillRecord(r io.ReaderAt, offset int64) (*frame.DataFrame, int64, error) {
+	header := make([]byte, spillHeaderSize)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return nil, 0, err
+	}
+	mdLen := int64(binary.BigEndian.Uint32(header[5:]))
+	payloadLen := int64(binary.BigEndian.Uint32(header[9:]))
+	extLen := int64(binary.BigEndian.Uint32(header[29:]))
+
+	body := make([]byte, mdLen+payloadLen+extLen)
+	if _, err := r.ReadAt(body, offset+spillHeaderSize); err != nil {
+		return nil, 0, err
+	}
+
+	df := &frame.DataFrame{
+		Tag:      binary.BigEndian.Uint32(header[0:]),
+		Priority: frame.Priority(header[4]),
+		Channel:  binary.BigEndian.Uint32(header[13:]),
+		Chunk: frame.Chunk{
+			ID:    binary.BigEndian.Uint32(header[17:]),
+			Seq:   binary.BigEndian.Uint32(header[21:]),
+			Total: binary.BigEndian.Uint32(header[25:]),
+		},
+	}
+	if mdLen > 0 {
+		df.Metadata = body[:mdLen]
+	}
+	if payloadLen > 0 {
+		df.Payload = body[mdLen : mdLen+payloadLen]
+	}
+	if extLen > 0 {
+		var err error
+		if df.Extensions, err = frame.ParseExtensions(body[mdLen+payloadLen:]); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return df, spillHeaderSize + mdLen + payloadLen + extLen, nil
+}
+
+// spillKey is the key of the spill queue of a tag to a consumer.
+type spillKey struct {
+	connID string
+	tag    frame.Tag
+}
+
+// spillFrame writes the DataFrame to the conn through the spill queue of the tag.
+func (s *Server) spillFrame(conn *Connection, df *frame.DataFrame, limit int64) error {
+	key := spillKey{conn.ID(), df.Tag}
+
+	v, ok := s.spills.Load(key)
+	if !ok {
+		q, err := newSpillQueue(s.opts.spillDir, limit, conn.FrameConn(), &conn.resources, conn.Logger)
+		if err != nil {
+			conn.Logger.Error("failed to create spill queue, write the frame directly", "err", err, "tag", df.Tag)
+			return conn.FrameConn().WriteFrame(df)
+		}
+		var loaded bool
+		if v, loaded = s.spills.LoadOrStore(key, q); loaded {
+			q.close()
+		}
+	}
+	return v.(*spillQueue).push(df)
+}
+
+// closeSpills closes the spill queues to the conn.
+func (s *Server) closeSpills(connID string) {
+	s.spills.Range(func(key, value any) bool {
+		if key.(spillKey).connID == connID {
+			s.spills.Delete(key)
+			value.(*spillQueue).close()
+		}
+		return true
+	})
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// gatedWriter writes the frames to the channel after the gate is opened.
+type gatedWriter struct {
+	gate   chan struct{}
+	frames chan *frame.DataFrame
+}
+
+func (w *gatedWriter) WriteFrame(f frame.Frame) error {
+	<-w.gate
+	w.frames <- f.(*frame.DataFrame)
+	return nil
+}
+
+func TestSpillQueue(t *testing.T) {
+	dir := t.TempDir()
+	w := &gatedWriter{gate: make(chan struct{}), frames: make(chan *frame.DataFrame, 10)}
+
+	q, err := newSpillQueue(dir, 220, w, new(resourceTracker), discardingLogger)
+	assert.NoError(t, err)
+
+	// 5 frames of 43 bytes are spilled while the consumer is stalled.
+	for i := byte(0); i < 5; i++ {
+		assert.NoError(t, q.push(&frame.DataFrame{Tag: 0x21, Metadata: []byte("md"), Payload: []byte{i, 1, 2, 3, 4, 5, 6, 7}, Priority: frame.PriorityHigh, Channel: 7, Chunk: frame.Chunk{ID: 1, Seq: uint32(i), Total: 5}}))
+	}
+
+	// the next frame exceeds the limit, so it blocks until the consumer catches up.
+	pushed := make(chan error)
+	go func() { pushed <- q.push(&frame.DataFrame{Tag: 0x21, Payload: []byte{5}}) }()
+	select {
+	case <-pushed:
+		t.Fatal("the push over the limit does not block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(w.gate)
+	assert.NoError(t, <-pushed)
+	for i := byte(0); i < 5; i++ {
+		df := <-w.frames
+		assert.Equal(t, &frame.DataFrame{Tag: 0x21, Metadata: []byte("md"), Payload: []byte{i, 1, 2, 3, 4, 5, 6, 7}, Priority: frame.PriorityHigh, Channel: 7, Chunk: frame.Chunk{ID: 1, Seq: uint32(i), Total: 5}}, df)
+	}
+	assert.Equal(t, &frame.DataFrame{Tag: 0x21, Payload: []byte{5}}, <-w.frames)
+
+	// the extensions are spilled as well.
+	ext := &frame.DataFrame{Tag: 0x21, Payload: []byte{6}, Extensions: map[uint8][]byte{0x80: []byte("ext")}}
+	assert.NoError(t, q.push(ext))
+	assert.Equal(t, ext, <-w.frames)
+
+	// the files are removed once all frames are written.
+	assert.Eventually(t, func() bool { return q.len() == 0 }, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return countFiles(t, dir) == 0 }, time.Second, 10*time.Millisecond)
+
+	q.close()
+	assert.ErrorIs(t, q.push(&frame.DataFrame{Tag: 0x21}), ErrSpillClosed)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func countFiles(t *testing.T, dir string) int {
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	return len(entries)
+}
+
+func TestSpillQueueSegments(t *testing.T) {
+	dir := t.TempDir()
+	w := &gatedWriter{gate: make(chan struct{}), frames: make(chan *frame.DataFrame, 10)}
+
+	// 2 frames of 41 bytes are buffered in memory, the others are spilled to the files of 2 frames.
+	q, err := newSpillQueue(dir, 240, w, new(resourceTracker), discardingLogger)
+	assert.NoError(t, err)
+	defer q.close()
+
+	for i := byte(0); i < 5; i++ {
+		assert.NoError(t, q.push(&frame.DataFrame{Tag: 0x21, Payload: []byte{i, 1, 2, 3, 4, 5, 6, 7}}))
+	}
+	assert.Equal(t, 2, countFiles(t, dir))
+
+	// the files are removed as soon as their frames are written.
+	for i, files := range []int{2, 2, 2, 1, 0} {
+		w.gate <- struct{}{}
+		assert.Equal(t, byte(i), (<-w.frames).Payload[0])
+		assert.Eventually(t, func() bool { return countFiles(t, dir) == files }, time.Second, time.Millisecond)
+	}
+
+	// the queue keeps the frames in memory again once the files are drained.
+	assert.NoError(t, q.push(&frame.DataFrame{Tag: 0x21, Payload: []byte{5}}))
+	assert.Equal(t, 0, countFiles(t, dir))
+	w.gate <- struct{}{}
+	assert.Equal(t, byte(5), (<-w.frames).Payload[0])
+}
+
+func TestSpillQueueClose(t *testing.T) {
+	w := &gatedWriter{gate: make(chan struct{}), frames: make(chan *frame.DataFrame, 10)}
+
+	q, err := newSpillQueue(t.TempDir(), 10, w, new(resourceTracker), discardingLogger)
+	assert.NoError(t, err)
+
+	// a frame larger than the limit is spilled if the queue is empty.
+	assert.NoError(t, q.push(&frame.DataFrame{Tag: 1, Payload: make([]byte, 20)}))
+
+	pushed := make(chan error)
+	go func() { pushed <- q.push(&frame.DataFrame{Tag: 1}) }()
+
+	// closing the queue unblocks the producer.
+	time.Sleep(20 * time.Millisecond)
+	q.close()
+	assert.ErrorIs(t, <-pushed, ErrSpillClosed)
+	close(w.gate)
+}
+
+func TestServerSpill(t *testing.T) {
+	s := NewServer("zipper", WithSpill(0x21, 1024), WithSpillDir(t.TempDir()), WithServerLogger(discardingLogger))
+
+	rc := &recordConn{stalledConn: newStalledConn()}
+	conn := newConnection("sfn", "sfn-id", ClientTypeStreamFunction, nil, []uint32{0x21}, rc, discardingLogger)
+
+	for i := byte(0); i < 3; i++ {
+		assert.NoError(t, s.spillFrame(conn, &frame.DataFrame{Tag: 0x21, Payload: []byte{i}}, 1024))
+	}
+	assert.Eventually(t, func() bool {
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+		return len(rc.frames) == 3
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, &frame.DataFrame{Tag: 0x21, Payload: []byte{2}}, rc.frames[2])
+
+	s.closeSpills("sfn-id")
+	_, ok := s.spills.Load(spillKey{"sfn-id", 0x21})
+	assert.False(t, ok)
+}
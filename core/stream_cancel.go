@@ -0,0 +1,62 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+// ErrStreamCancelUnsupported is returned by CancelStream if the connection has no cancelable data streams.
+var ErrStreamCancelUnsupported = yerr.New(yerr.CodeInvalid, "yomo: the connection does not support canceling streams")
+
+// DataStreamIDs returns the IDs of the data streams of the current connection that can be canceled,
+// they are opened by WithStreamCount.
+func (c *Client) DataStreamIDs() []int64 {
+	if sc, ok := c.connection().(frame.StreamCanceler); ok {
+		return sc.DataStreamIDs()
+	}
+	return nil
+}
+
+// CancelStream cancels the data stream of the current connection with the application error code,
+// the peer gets frame.ErrStreamCanceled and the connection keeps working, the frames of the canceled
+// stream are written to the first stream instead, including the frames being written when it is canceled.
+func (c *Client) CancelStream(id int64, code uint64) error {
+	sc, ok := c.connection().(frame.StreamCanceler)
+	if !ok {
+		return ErrStreamCancelUnsupported
+	}
+	return sc.CancelStream(id, code)
+}
+
+// connection returns the current connection, it is nil if the client is not connected.
+func (c *Client) connection() frame.Conn {
+	holder, _ := c.conn.Load().(connHolder)
+	return holder.Conn
+}
+
+// isStreamCanceled reports whether the error is caused by a canceled data stream.
+func isStreamCanceled(err error) bool {
+	se := new(frame.ErrStreamCanceled)
+	return errors.As(err, &se)
+}
+
+// connErr returns nil if the error is caused by a canceled data stream or a corrupted or malformed frame, the error
+// is passed to the error handler and the connection keeps working. The other errors are returned as is.
+func (c *Client) connErr(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case isStreamCanceled(err):
+		c.Logger.Info("data stream canceled", "err", err)
+	case isCorrupted(err):
+		c.Logger.Warn("corrupted frame dropped", "err", err)
+	case isMalformed(err):
+		c.Logger.Warn("malformed frame dropped", "err", err)
+	default:
+		return err
+	}
+	c.reportError(err)
+	return nil
+}
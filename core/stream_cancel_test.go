@@ -0,0 +1,27 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestStreamCanceledErr(t *testing.T) {
+	client := NewClient("source", testaddr, ClientTypeSource, WithLogger(discardingLogger))
+
+	assert.Nil(t, client.DataStreamIDs())
+	assert.ErrorIs(t, client.CancelStream(4, 1), ErrStreamCancelUnsupported)
+
+	var reported error
+	client.SetErrorHandler(func(err error) { reported = err })
+
+	canceled := &frame.ErrStreamCanceled{StreamID: 4, Code: 1, Remote: true}
+	assert.NoError(t, client.connErr(canceled))
+	assert.Equal(t, canceled, reported)
+
+	broken := errors.New("broken")
+	assert.Equal(t, broken, client.connErr(broken))
+	assert.NoError(t, client.connErr(nil))
+}
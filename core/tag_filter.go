@@ -0,0 +1,37 @@
+package core
+
+import "github.com/yomorun/yomo/core/frame"
+
+// TagFilter filters tags by an allowlist and a denylist.
+// The denylist takes precedence over the allowlist, and an empty allowlist allows all tags.
+type TagFilter struct {
+	// Allow is the allowlist of tags.
+	Allow []frame.Tag
+	// Deny is the denylist of tags.
+	Deny []frame.Tag
+}
+
+// Allowed reports whether the tag passes the filter.
+func (f TagFilter) Allowed(tag frame.Tag) bool {
+	for _, t := range f.Deny {
+		if t == tag {
+			return false
+		}
+	}
+	if len(f.Allow) == 0 {
+		return true
+	}
+	for _, t := range f.Allow {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// DownstreamTagFilter can be implemented by a Downstream that only accepts some tags,
+// the DataFrames with the other tags will not be dispatched to the Downstream.
+type DownstreamTagFilter interface {
+	// AllowTag reports whether the DataFrame with the tag can be dispatched to the Downstream.
+	AllowTag(frame.Tag) bool
+}
@@ -0,0 +1,23 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagFilter(t *testing.T) {
+	assert.True(t, TagFilter{}.Allowed(0x33))
+
+	allow := TagFilter{Allow: []uint32{0x33, 0x34}}
+	assert.True(t, allow.Allowed(0x33))
+	assert.False(t, allow.Allowed(0x35))
+
+	deny := TagFilter{Deny: []uint32{0x33}}
+	assert.False(t, deny.Allowed(0x33))
+	assert.True(t, deny.Allowed(0x34))
+
+	both := TagFilter{Allow: []uint32{0x33, 0x34}, Deny: []uint32{0x34}}
+	assert.True(t, both.Allowed(0x33))
+	assert.False(t, both.Allowed(0x34))
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// TagNamer gives tags human-readable names, the name is displayed alongside
+// the numeric tag in logs and traces.
+type TagNamer interface {
+	// TagName returns the name of the tag, returns empty string if the tag has no name.
+	TagName(frame.Tag) string
+}
+
+// TagNamerFunc is an adapter to allow the use of ordinary functions as TagNamer.
+type TagNamerFunc func(frame.Tag) string
+
+// TagName calls f(tag).
+func (f TagNamerFunc) TagName(tag frame.Tag) string { return f(tag) }
+
+// TagNameMap is a TagNamer that looks up names from a map,
+// the map generated by `yomo tags gen` can be used directly.
+type TagNameMap map[frame.Tag]string
+
+// TagName returns the name of the tag from the map.
+func (m TagNameMap) TagName(tag frame.Tag) string { return m[tag] }
+
+// TagName returns the name of the tag, it returns empty string if the namer is nil.
+func TagName(namer TagNamer, tag frame.Tag) string {
+	if namer == nil {
+		return ""
+	}
+	return namer.TagName(tag)
+}
+
+// TagTraceAttrs returns the span attributes of the tag.
+func TagTraceAttrs(namer TagNamer, tag frame.Tag) map[string]string {
+	attrs := map[string]string{"yomo.tag": fmt.Sprintf("%#x", tag)}
+	if name := TagName(namer, tag); name != "" {
+		attrs["yomo.tag_name"] = name
+	}
+	return attrs
+}
@@ -0,0 +1,35 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestTagNamer(t *testing.T) {
+	t.Run("nil namer", func(t *testing.T) {
+		assert.Equal(t, "", TagName(nil, 0x33))
+		assert.Equal(t, map[string]string{"yomo.tag": "0x33"}, TagTraceAttrs(nil, 0x33))
+	})
+
+	t.Run("map namer", func(t *testing.T) {
+		namer := TagNameMap{0x33: "sensor.temperature"}
+		assert.Equal(t, "sensor.temperature", TagName(namer, 0x33))
+		assert.Equal(t, "", TagName(namer, 0x34))
+		assert.Equal(t,
+			map[string]string{"yomo.tag": "0x33", "yomo.tag_name": "sensor.temperature"},
+			TagTraceAttrs(namer, 0x33),
+		)
+	})
+
+	t.Run("func namer", func(t *testing.T) {
+		namer := TagNamerFunc(func(tag frame.Tag) string { return "func" })
+		assert.Equal(t, "func", TagName(namer, 0x33))
+	})
+
+	t.Run("client namer", func(t *testing.T) {
+		client := NewClient("source", "localhost:9000", ClientTypeSource, WithTagNamer(TagNameMap{0x33: "a"}), WithLogger(discardingLogger))
+		assert.Equal(t, "a", TagName(client.TagNamer(), 0x33))
+	})
+}
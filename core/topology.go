@@ -0,0 +1,168 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// Topology is a snapshot of the data flow graph of a zipper.
+type Topology struct {
+	// Zipper is the name of the zipper.
+	Zipper string `json:"zipper"`
+	// Downstreams is the downstream zippers of the zipper.
+	Downstreams []TopologyDownstream `json:"downstreams"`
+	// Clients is the clients connected to the zipper, including the upstream zippers.
+	Clients []TopologyClient `json:"clients"`
+	// Flows is the tag flows passing through the zipper.
+	Flows []TagFlow `json:"flows"`
+}
+
+// TopologyDownstream describes a downstream zipper.
+type TopologyDownstream struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	RemoteName string `json:"remote_name"`
+	// Unhealthy reports that the downstream is out of rotation, see WithDownstreamHealthCheck.
+	Unhealthy bool `json:"unhealthy,omitempty"`
+}
+
+// TopologyClient describes a client connected to the zipper.
+type TopologyClient struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Type            string   `json:"type"`
+	ObserveDataTags []uint32 `json:"observe_data_tags,omitempty"`
+}
+
+// TagFlow describes the DataFrames of a tag flowing from a client to a stream function or a downstream zipper.
+type TagFlow struct {
+	Tag    uint32 `json:"tag"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Frames int64  `json:"frames"`
+	// Rate is the average number of frames per second.
+	Rate float64 `json:"rate"`
+}
+
+// JSON returns the topology in JSON.
+func (t Topology) JSON() ([]byte, error) {
+	return json.MarshalIndent(t, "", "  ")
+}
+
+// DOT returns the topology in DOT language, it can be rendered by graphviz.
+func (t Topology) DOT() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %q {\n", t.Zipper)
+	fmt.Fprintf(&b, "  %q [shape=box, style=bold];\n", t.Zipper)
+	for _, c := range t.Clients {
+		fmt.Fprintf(&b, "  %q [label=%q];\n", c.Name, c.Name+"\n"+c.Type)
+	}
+	for _, d := range t.Downstreams {
+		fmt.Fprintf(&b, "  %q [shape=box];\n", d.Name)
+		fmt.Fprintf(&b, "  %q -> %q [style=dashed];\n", t.Zipper, d.Name)
+	}
+	for _, f := range t.Flows {
+		fmt.Fprintf(&b, "  %q -> %q [label=\"tag %d (%.2f/s)\"];\n", f.From, f.To, f.Tag, f.Rate)
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// tagFlowKey is the key of a tag flow.
+type tagFlowKey struct {
+	tag  frame.Tag
+	from string
+	to   string
+}
+
+type tagFlowCounter struct {
+	frames atomic.Int64
+	since  time.Time
+}
+
+// tagFlows counts the DataFrames of the tag flows.
+type tagFlows struct {
+	m sync.Map // tagFlowKey -> *tagFlowCounter
+}
+
+func (f *tagFlows) add(tag frame.Tag, from, to string) {
+	v, ok := f.m.Load(tagFlowKey{tag, from, to})
+	if !ok {
+		v, _ = f.m.LoadOrStore(tagFlowKey{tag, from, to}, &tagFlowCounter{since: time.Now()})
+	}
+	v.(*tagFlowCounter).frames.Add(1)
+}
+
+func (f *tagFlows) snapshot() []TagFlow {
+	result := make([]TagFlow, 0)
+
+	now := time.Now()
+	f.m.Range(func(key, value any) bool {
+		k, counter := key.(tagFlowKey), value.(*tagFlowCounter)
+
+		flow := TagFlow{Tag: k.tag, From: k.from, To: k.to, Frames: counter.frames.Load()}
+		if elapsed := now.Sub(counter.since).Seconds(); elapsed > 0 {
+			flow.Rate = float64(flow.Frames) / elapsed
+		}
+		result = append(result, flow)
+		return true
+	})
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Tag != result[j].Tag {
+			return result[i].Tag < result[j].Tag
+		}
+		if result[i].From != result[j].From {
+			return result[i].From < result[j].From
+		}
+		return result[i].To < result[j].To
+	})
+
+	return result
+}
+
+// Topology returns the current topology of the server.
+func (s *Server) Topology() Topology {
+	t := Topology{
+		Zipper:      s.name,
+		Downstreams: make([]TopologyDownstream, 0),
+		Clients:     make([]TopologyClient, 0),
+		Flows:       s.flows.snapshot(),
+	}
+
+	s.mu.Lock()
+	for _, ds := range s.downstreams {
+		t.Downstreams = append(t.Downstreams, TopologyDownstream{
+			ID:         ds.ID(),
+			Name:       ds.LocalName(),
+			RemoteName: ds.RemoteName(),
+			Unhealthy:  !s.downstreamHealthy(ds.ID()),
+		})
+	}
+	s.mu.Unlock()
+	sort.Slice(t.Downstreams, func(i, j int) bool { return t.Downstreams[i].Name < t.Downstreams[j].Name })
+
+	if s.connector != nil {
+		conns, _ := s.connector.Find(func(ConnectionInfo) bool { return true })
+		for _, conn := range conns {
+			t.Clients = append(t.Clients, TopologyClient{
+				ID:              conn.ID(),
+				Name:            conn.Name(),
+				Type:            conn.ClientType().String(),
+				ObserveDataTags: conn.ObserveDataTags(),
+			})
+		}
+		sort.Slice(t.Clients, func(i, j int) bool { return t.Clients[i].Name < t.Clients[j].Name })
+	}
+
+	return t
+}
@@ -0,0 +1,23 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopology(t *testing.T) {
+	s := NewServer("zipper")
+	s.AddDownstreamServer(&recordDownstream{})
+
+	s.flows.add(1, "source", "sfn")
+	s.flows.add(1, "source", "sfn")
+	s.flows.add(2, "source", "record")
+
+	topo := s.Topology()
+	assert.Equal(t, "zipper", topo.Zipper)
+	assert.Equal(t, []TopologyDownstream{{ID: "record", Name: "record", RemoteName: "record"}}, topo.Downstreams)
+	assert.Len(t, topo.Flows, 2)
+	assert.Equal(t, TagFlow{Tag: 1, From: "source", To: "sfn", Frames: 2, Rate: topo.Flows[0].Rate}, topo.Flows[0])
+	assert.Equal(t, "record", topo.Flows[1].To)
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"github.com/quic-go/quic-go"
+	"github.com/yomorun/yomo/core/frame"
+	// the built-in codecs are registered, so they are selected by name without importing them.
+	_ "github.com/yomorun/yomo/pkg/frame-codec/cborcodec"
+	"github.com/yomorun/yomo/pkg/frame-codec/y3codec"
+	yquic "github.com/yomorun/yomo/pkg/listener/quic"
+	ywebtransport "github.com/yomorun/yomo/pkg/listener/webtransport"
+)
+
+// Transport establishes the connection that carries the frames to zipper. The frame protocol is the
+// same on every transport, the built-in ones are the raw QUIC transport, see NewQUICTransport, and
+// the WebTransport one for the networks blocking the raw QUIC, see NewWebTransportTransport.
+type Transport interface {
+	// Name returns the name of the transport, e.g. "quic".
+	Name() string
+	// Dial connects to zipper at addr.
+	Dial(ctx context.Context, addr string, tlsConfig *tls.Config) (frame.Conn, error)
+}
+
+// The conns that support multiple streams implement these interfaces, the options that need them
+// are ignored on the transports not supporting them.
+type (
+	controlStreamOpener interface{ OpenControlStream() error }
+	dataStreamsOpener   interface {
+		OpenDataStreams(n int, selector yquic.StreamSelector) error
+	}
+)
+
+type quicTransport struct {
+	dialer     yquic.Dialer
+	quicConfig *quic.Config
+	codec      frame.Codec
+	prw        frame.PacketReadWriter
+}
+
+// NewQUICTransport returns the raw QUIC transport, which is the default transport of the client.
+// The nil dialer means quic.DialAddr, and the nil quicConfig means DefaultClientQuicConfig.
+func NewQUICTransport(dialer yquic.Dialer, quicConfig *quic.Config) Transport {
+	if dialer == nil {
+		dialer = quic.DialAddr
+	}
+	if quicConfig == nil {
+		quicConfig = DefaultClientQuicConfig
+	}
+	return &quicTransport{dialer: dialer, quicConfig: quicConfig, codec: y3codec.Codec(), prw: y3codec.PacketReadWriter()}
+}
+
+func (t *quicTransport) Name() string { return "quic" }
+
+func (t *quicTransport) Dial(ctx context.Context, addr string, tlsConfig *tls.Config) (frame.Conn, error) {
+	return yquic.DialAddrWith(ctx, t.dialer, addr, t.codec, t.prw, tlsConfig, t.quicConfig)
+}
+
+type webTransportTransport struct {
+	addr       string
+	path       string
+	quicConfig *quic.Config
+	codec      frame.Codec
+	prw        frame.PacketReadWriter
+}
+
+// NewWebTransportTransport returns the transport establishing a WebTransport session at the path of addr,
+// which is served by the zipper with WithWebTransport. The empty addr means the address of zipper,
+// and the nil quicConfig means DefaultClientQuicConfig. The frames are encoded with the y3 codec.
+func NewWebTransportTransport(addr, path string, quicConfig *quic.Config) Transport {
+	if quicConfig == nil {
+		quicConfig = DefaultClientQuicConfig
+	}
+	return &webTransportTransport{addr: addr, path: path, quicConfig: quicConfig, codec: y3codec.Codec(), prw: y3codec.PacketReadWriter()}
+}
+
+func (t *webTransportTransport) Name() string { return "webtransport" }
+
+func (t *webTransportTransport) Dial(ctx context.Context, addr string, tlsConfig *tls.Config) (frame.Conn, error) {
+	if t.addr != "" {
+		addr = t.addr
+	}
+	return ywebtransport.DialAddr(ctx, addr, t.path, t.codec, t.prw, tlsConfig, t.quicConfig)
+}
+
+// dial dials zipper with the transports in order, starting from the one that succeeded last time,
+// and falls back to the next transport if the dialing fails.
+func (c *Client) dial(ctx context.Context, addr string, tlsConfig *tls.Config) (frame.Conn, error) {
+	transports := c.opts.transports
+	if len(transports) == 0 {
+		t := NewQUICTransport(c.opts.dialer, c.opts.quicConfig).(*quicTransport)
+		t.codec = y3codec.Codec(codecOptions(c.opts.checksum)...)
+		if name := c.opts.codecName; name != "" && name != y3codec.Name {
+			codec, prw, ok := frame.LookupCodec(name)
+			if !ok {
+				return nil, fmt.Errorf("yomo: unknown codec %q, registered: %v", name, frame.CodecNames())
+			}
+			t.codec, t.prw = codec, prw
+		}
+		transports = []Transport{t}
+	}
+
+	var errs []error
+	start := int(c.transportIdx.Load())
+	for i := range transports {
+		idx := (start + i) % len(transports)
+		t := transports[idx]
+
+		dialCtx, cancel := withPhaseTimeout(ctx, c.opts.dialTimeout)
+		conn, err := t.Dial(dialCtx, addr, tlsConfig)
+		cancel()
+		if err == nil {
+			c.transportIdx.Store(int32(idx))
+			c.Logger.Debug("dial zipper", "transport", t.Name())
+			return conn, nil
+		}
+		c.Logger.Debug("failed to dial zipper", "transport", t.Name(), "err", err)
+		if len(transports) == 1 {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", t.Name(), err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
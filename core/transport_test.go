@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/pkg/frame-codec/cborcodec"
+)
+
+// blockedTransport is a Transport that is always blocked, it counts the dialings.
+type blockedTransport struct {
+	name  string
+	dials int
+}
+
+func (t *blockedTransport) Name() string { return t.name }
+
+func (t *blockedTransport) Dial(ctx context.Context, addr string, tlsConfig *tls.Config) (frame.Conn, error) {
+	t.dials++
+	return nil, errors.New("blocked by firewall")
+}
+
+func TestTransports(t *testing.T) {
+	const transportAddr = "127.0.0.1:19986"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger))
+	server.ConfigRouter(router.Default())
+	server.ConfigVersionNegotiateFunc(DefaultVersionNegotiateFunc)
+	go server.ListenAndServe(context.TODO(), transportAddr)
+	defer server.Close()
+
+	t.Run("fallback", func(t *testing.T) {
+		blocked := &blockedTransport{name: "blocked"}
+		client := NewClient("source", transportAddr, ClientTypeSource,
+			WithLogger(discardingLogger),
+			WithTransports(blocked, NewQUICTransport(nil, nil)),
+		)
+		assert.NoError(t, client.Connect(context.TODO()))
+		assert.Equal(t, 1, blocked.dials)
+		assert.Equal(t, int32(1), client.transportIdx.Load())
+		assert.NoError(t, client.Close())
+	})
+
+	t.Run("all failed", func(t *testing.T) {
+		client := NewClient("source", transportAddr, ClientTypeSource,
+			WithLogger(discardingLogger),
+			WithTransports(&blockedTransport{name: "quic-443"}, &blockedTransport{name: "quic"}),
+		)
+		err := client.Connect(context.TODO())
+
+		e := new(ConnectError)
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, PhaseDial, e.Phase)
+		assert.EqualError(t, err, "yomo: dial 127.0.0.1:19986: quic-443: blocked by firewall\nquic: blocked by firewall")
+	})
+}
+
+func TestWebTransport(t *testing.T) {
+	const (
+		zipperAddr       = "127.0.0.1:19955"
+		webTransportAddr = "127.0.0.1:19954"
+	)
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger), WithWebTransport(webTransportAddr, "/yomo"))
+	server.ConfigRouter(router.Default())
+	server.ConfigVersionNegotiateFunc(DefaultVersionNegotiateFunc)
+	go server.ListenAndServe(context.TODO(), zipperAddr)
+	defer server.Close()
+
+	received := make(chan *frame.DataFrame, 1)
+	sfn := NewClient("sfn", zipperAddr, ClientTypeStreamFunction,
+		WithLogger(discardingLogger),
+		WithTransports(&blockedTransport{name: "quic"}, NewWebTransportTransport(webTransportAddr, "/yomo", nil)),
+	)
+	sfn.SetObserveDataTags(1)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df })
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+	assert.Equal(t, int32(1), sfn.transportIdx.Load())
+
+	blocked := &blockedTransport{name: "quic"}
+	source := NewClient("source", zipperAddr, ClientTypeSource,
+		WithLogger(discardingLogger),
+		WithTransports(blocked, NewWebTransportTransport(webTransportAddr, "/yomo", nil)),
+	)
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+	assert.Equal(t, 1, blocked.dials)
+
+	md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("webtransport")}))
+
+	select {
+	case df := <-received:
+		assert.Equal(t, []byte("webtransport"), df.Payload)
+	case <-time.After(3 * time.Second):
+		t.Fatal("the sfn does not receive the data")
+	}
+
+	t.Run("wrong path", func(t *testing.T) {
+		client := NewClient("source", zipperAddr, ClientTypeSource,
+			WithLogger(discardingLogger),
+			WithTransports(NewWebTransportTransport(webTransportAddr, "/other", nil)),
+		)
+		assert.Error(t, client.Connect(context.TODO()))
+	})
+}
+
+func TestCodecName(t *testing.T) {
+	t.Parallel()
+
+	const codecAddr = "127.0.0.1:19970"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger), WithServerCodecName(cborcodec.Name))
+	server.ConfigRouter(router.Default())
+	go server.ListenAndServe(context.TODO(), codecAddr)
+	defer server.Close()
+
+	received := make(chan *frame.DataFrame, 1)
+	sfn := NewClient("sfn", codecAddr, ClientTypeStreamFunction, WithLogger(discardingLogger), WithCodecName(cborcodec.Name))
+	sfn.SetObserveDataTags(1)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df })
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	source := NewClient("source", codecAddr, ClientTypeSource, WithLogger(discardingLogger), WithCodecName(cborcodec.Name))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("cbor")}))
+
+	select {
+	case df := <-received:
+		assert.Equal(t, []byte("cbor"), df.Payload)
+	case <-time.After(3 * time.Second):
+		t.Fatal("the sfn does not receive the data")
+	}
+
+	t.Run("unknown codec", func(t *testing.T) {
+		client := NewClient("source", codecAddr, ClientTypeSource, WithLogger(discardingLogger), WithCodecName("unknown"))
+		assert.ErrorContains(t, client.Connect(context.TODO()), `unknown codec "unknown"`)
+
+		server := NewServer("zipper", WithServerLogger(discardingLogger), WithServerCodecName("unknown"))
+		assert.ErrorContains(t, server.ListenAndServe(context.TODO(), "127.0.0.1:0"), `unknown codec "unknown"`)
+	})
+}
@@ -1,11 +1,43 @@
 package core
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/yomorun/yomo/core/yerr"
+)
 
 // Version is the current yomo spec version.
 // if the spec version is changed, the client maybe cannot work well with server.
 const Version = "2024-01-03"
 
+// ProtocolVersion is the highest version of the frame protocol spoken by this build,
+// it is bumped once the format of the frames changes incompatibly.
+//
+//  1. the initial version.
+//  2. the compression of the DataFrame payload is flagged in the frame instead of the metadata.
+//  3. zipper acknowledges the DataFrames carrying an AckID.
+const ProtocolVersion uint32 = 3
+
+// negotiateProtocolVersion returns the protocol version that the server speaks with the client,
+// it is the lower one of the client's and the server's, and must not be lower than minVersion.
+func negotiateProtocolVersion(clientVersion, minVersion uint32) (uint32, error) {
+	// the clients older than the negotiation speak the version 1.
+	if clientVersion == 0 {
+		clientVersion = 1
+	}
+	version := clientVersion
+	if version > ProtocolVersion {
+		version = ProtocolVersion
+	}
+	if version < minVersion {
+		return 0, &ErrRejected{
+			Message: fmt.Sprintf("protocol version unsupported: client=%d, server=%d-%d", clientVersion, minVersion, ProtocolVersion),
+			Reason:  ReasonVersionUnsupported,
+		}
+	}
+	return version, nil
+}
+
 // DefaultVersionNegotiateFunc is default version negotiate function.
 // if cVersion != sVersion, return error and respond RejectedFrame.
 func DefaultVersionNegotiateFunc(cVersion, sVersion string) error {
@@ -33,12 +65,32 @@ func (e *ErrConnectTo) Error() string {
 	return fmt.Sprintf("connect to %s", e.Endpoint)
 }
 
+// ErrorCode implements the yerr.Coder interface.
+func (e *ErrConnectTo) ErrorCode() yerr.Code { return yerr.CodeRouting }
+
 // ErrRejected is returned by VersionNegotiateFunc if you want to reject the connection.
+// The client gets it if the zipper rejects the handshake or evicts the connection.
 type ErrRejected struct {
 	Message string
+	// Reason is the reason of the rejection, it is sent to the client and mapped to the typed error, e.g. ErrAuthExpired.
+	Reason RejectReason
 }
 
 // Error implements the error interface.
 func (e *ErrRejected) Error() string {
 	return e.Message
 }
+
+// Unwrap returns the typed error of the reason, it is nil if the reason is unknown.
+func (e *ErrRejected) Unwrap() error {
+	return reasonErrors[e.Reason]
+}
+
+// ErrorCode implements the yerr.Coder interface, it is the code of the reason, or CodeProtocol
+// if the reason is unknown, because the version negotiation rejects without a reason.
+func (e *ErrRejected) ErrorCode() yerr.Code {
+	if err, ok := reasonErrors[e.Reason]; ok {
+		return yerr.CodeOf(err)
+	}
+	return yerr.CodeProtocol
+}
@@ -1,6 +1,8 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -42,6 +44,60 @@ func TestNegotiateVersion(t *testing.T) {
 	}
 }
 
+func TestNegotiateProtocolVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		clientVersion uint32
+		minVersion    uint32
+		want          uint32
+		wantErr       bool
+	}{
+		{name: "same version", clientVersion: ProtocolVersion, want: ProtocolVersion},
+		{name: "older client", clientVersion: 0, want: 1},
+		{name: "newer client", clientVersion: ProtocolVersion + 1, want: ProtocolVersion},
+		{name: "unsupported client", clientVersion: 0, minVersion: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := negotiateProtocolVersion(tt.clientVersion, tt.minVersion)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrVersionUnsupported)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, version)
+		})
+	}
+}
+
+func TestProtocolVersionHandshake(t *testing.T) {
+	t.Run("negotiated", func(t *testing.T) {
+		server := NewServer("zipper", WithServerLogger(discardingLogger))
+		go server.ListenAndServe(context.TODO(), "127.0.0.1:19978")
+		defer server.Close()
+
+		source := NewClient("source", "127.0.0.1:19978", ClientTypeSource, WithLogger(discardingLogger))
+		assert.Zero(t, source.ProtocolVersion())
+		assert.NoError(t, source.Connect(context.TODO()))
+		defer source.Close()
+		assert.Equal(t, ProtocolVersion, source.ProtocolVersion())
+
+		conn, ok, _ := server.connector.Get(source.clientID + "-0")
+		assert.True(t, ok)
+		assert.Equal(t, ProtocolVersion, conn.ProtocolVersion())
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		server := NewServer("zipper", WithServerLogger(discardingLogger), WithMinProtocolVersion(ProtocolVersion+1))
+		go server.ListenAndServe(context.TODO(), "127.0.0.1:19977")
+		defer server.Close()
+
+		source := NewClient("source", "127.0.0.1:19977", ClientTypeSource, WithLogger(discardingLogger))
+		err := source.Connect(context.TODO())
+		assert.True(t, errors.Is(err, ErrVersionUnsupported))
+	})
+}
+
 type mockFrameWriter struct {
 	f frame.Frame
 }
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"strconv"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"golang.org/x/exp/slices"
+)
+
+// MetadataWALOffsetKey is the key of the offset of the DataFrame in the write-ahead log of the zipper,
+// the stream function keeps the offset it has handled, and replays from the next offset after it restarts.
+const MetadataWALOffsetKey = metadata.WALOffsetKey
+
+// replayExtensionKey is the handshake extension of the offset that the stream function replays from.
+const replayExtensionKey = "yomo-replay-from"
+
+// FrameStore is the write-ahead log of the DataFrames at zipper, the zipper appends the DataFrames before
+// routing them, and replays them to the stream functions that ask for them, so the stream functions get
+// the DataFrames at least once across the restarts of the zipper and themselves.
+// See WithWAL, and package pkg/wal for the store backed by BoltDB.
+type FrameStore interface {
+	// Append appends the DataFrame and returns its offset, the offsets start from 1 and increase.
+	Append(df *frame.DataFrame) (uint64, error)
+	// Replay calls fn with the DataFrames from the offset in order, until fn returns false
+	// or the end of the log. The DataFrames removed by the retention of the store are skipped.
+	Replay(from uint64, fn func(offset uint64, df *frame.DataFrame) bool) error
+	// LastOffset returns the offset of the last DataFrame appended, 0 if the log is empty.
+	LastOffset() (uint64, error)
+}
+
+// wal journals the DataFrames of the tags to the store.
+type wal struct {
+	store FrameStore
+	tags  []frame.Tag
+}
+
+func (w *wal) journaled(tag frame.Tag) bool {
+	return len(w.tags) == 0 || slices.Contains(w.tags, tag)
+}
+
+// journal appends the DataFrame to the WAL before it is routed, and sets its offset to the metadata.
+// The DataFrame is journaled with the metadata stamped by the zipper rather than the one the client sent,
+// so the tenant of a replayed DataFrame is the tenant of the client that wrote it.
+// The DataFrame is routed even if it fails to be appended.
+func (s *Server) journal(c *Context) {
+	// the offset is stamped by this zipper only, the one a client sends is not the offset of the DataFrame.
+	delete(c.FrameMetadata, MetadataWALOffsetKey)
+
+	w := s.opts.wal
+	if w == nil || !w.journaled(c.Frame.Tag) {
+		return
+	}
+	md, err := c.FrameMetadata.Encode()
+	if err != nil {
+		c.Logger.Error("failed to append frame to wal", "tag", c.Frame.Tag, "err", err)
+		return
+	}
+	journaled := *c.Frame
+	journaled.Metadata = md
+
+	offset, err := w.store.Append(&journaled)
+	if err != nil {
+		c.Logger.Error("failed to append frame to wal", "tag", c.Frame.Tag, "err", err)
+		return
+	}
+	c.FrameMetadata.Set(MetadataWALOffsetKey, strconv.FormatUint(offset, 10))
+}
+
+// GetWALOffsetFromMetadata returns the offset of the DataFrame in the write-ahead log of the zipper,
+// ok is false if the DataFrame is not journaled.
+func GetWALOffsetFromMetadata(m metadata.M) (uint64, bool) {
+	v, ok := m.Get(MetadataWALOffsetKey)
+	if !ok {
+		return 0, false
+	}
+	offset, err := strconv.ParseUint(v, 10, 64)
+	return offset, err == nil
+}
+
+// WithReplayFrom makes the stream function replay the DataFrames journaled by the zipper from the offset
+// once it connects, e.g. the offset after the last one it has handled, or 0 for all of them.
+// Once it reconnects, it replays from the offset after the last DataFrame it has handled instead.
+// The replayed DataFrames may interleave with the live ones, see WithWAL.
+func WithReplayFrom(offset uint64) ClientOption {
+	return func(o *clientOptions) {
+		o.replayFrom = &offset
+		o.replayFromFunc = nil
+	}
+}
+
+// WithReplayFromFunc makes the stream function replay the DataFrames journaled by the zipper from the offset
+// returned by fn every time it connects, e.g. the offset after the last one it has persisted.
+// See WithReplayFrom and GetWALOffsetFromMetadata.
+func WithReplayFromFunc(fn func() uint64) ClientOption {
+	return func(o *clientOptions) {
+		o.replayFromFunc = fn
+		o.replayFrom = nil
+	}
+}
+
+// handshakeExtensions returns the extensions of the handshake, the offset to replay from is
+// added to the static ones.
+func (c *Client) handshakeExtensions() map[string][]byte {
+	var from uint64
+	switch {
+	case c.opts.replayFromFunc != nil:
+		from = c.opts.replayFromFunc()
+	case c.opts.replayFrom != nil:
+		from = *c.opts.replayFrom
+		if handled := c.walOffset.Load(); handled > 0 {
+			from = handled + 1
+		}
+	default:
+		return c.opts.handshakeExts
+	}
+	exts := make(map[string][]byte, len(c.opts.handshakeExts)+1)
+	for k, v := range c.opts.handshakeExts {
+		exts[k] = v
+	}
+	exts[replayExtensionKey] = []byte(strconv.FormatUint(from, 10))
+	return exts
+}
+
+// walOffsetOf returns the offset of the journaled DataFrame, it is 0 if the DataFrame is not journaled
+// or the client does not replay.
+func (c *Client) walOffsetOf(df *frame.DataFrame) uint64 {
+	if c.opts.replayFrom == nil {
+		return 0
+	}
+	md, err := metadata.Decode(df.Metadata)
+	if err != nil {
+		return 0
+	}
+	offset, _ := GetWALOffsetFromMetadata(md)
+	return offset
+}
+
+// handled records the offset of the journaled DataFrame handled, the client replays after it once reconnecting.
+func (c *Client) handled(offset uint64) {
+	for {
+		last := c.walOffset.Load()
+		if offset <= last || c.walOffset.CompareAndSwap(last, offset) {
+			return
+		}
+	}
+}
+
+// replay replays the journaled DataFrames of the tags observed by the stream function, from the offset it
+// asks for to the last offset at the time it connects, the DataFrames appended later are routed as usual.
+func (s *Server) replay(conn *Connection) {
+	w := s.opts.wal
+	if w == nil || conn.ClientType() != ClientTypeStreamFunction {
+		return
+	}
+	v, ok := conn.HandshakeExtensions()[replayExtensionKey]
+	if !ok {
+		return
+	}
+	from, err := strconv.ParseUint(string(v), 10, 64)
+	if err != nil {
+		conn.Logger.Warn("invalid replay offset", "offset", string(v), "err", err)
+		return
+	}
+	last, err := w.store.LastOffset()
+	if err != nil {
+		conn.Logger.Error("failed to replay wal", "err", err)
+		return
+	}
+	conn.Logger.Info("replay wal", "from", from, "to", last)
+
+	var (
+		replayed int
+		werr     error
+	)
+	err = w.store.Replay(from, func(offset uint64, df *frame.DataFrame) bool {
+		if offset > last {
+			return false
+		}
+		if !slices.Contains(conn.ObserveDataTags(), df.Tag) {
+			return true
+		}
+		md, err := metadata.Decode(df.Metadata)
+		if err != nil {
+			conn.Logger.Warn("skip journaled frame of invalid metadata", "offset", offset, "err", err)
+			return true
+		}
+		// the frames of the other tenants are not replayed.
+		if GetTenantIDFromMetadata(md) != GetTenantIDFromMetadata(conn.Metadata()) {
+			return true
+		}
+		md.Set(MetadataWALOffsetKey, strconv.FormatUint(offset, 10))
+		if df.Metadata, err = md.Encode(); err != nil {
+			return true
+		}
+		if werr = s.writeDataFrame(conn, df); werr != nil {
+			return false
+		}
+		replayed++
+		return true
+	})
+	if err == nil {
+		err = werr
+	}
+	if err != nil {
+		conn.Logger.Error("failed to replay wal", "from", from, "replayed", replayed, "err", err)
+		return
+	}
+	conn.Logger.Info("wal replayed", "from", from, "replayed", replayed)
+}
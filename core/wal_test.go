@@ -0,0 +1,208 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/core/serverless"
+)
+
+// memoryStore is a FrameStore in memory.
+type memoryStore struct {
+	mu     sync.Mutex
+	frames []*frame.DataFrame
+}
+
+func (s *memoryStore) Append(df *frame.DataFrame) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frames = append(s.frames, &frame.DataFrame{Tag: df.Tag, Metadata: df.Metadata, Payload: df.Payload})
+	return uint64(len(s.frames)), nil
+}
+
+func (s *memoryStore) Replay(from uint64, fn func(offset uint64, df *frame.DataFrame) bool) error {
+	s.mu.Lock()
+	frames := s.frames
+	s.mu.Unlock()
+	for i, df := range frames {
+		if offset := uint64(i + 1); offset >= from && !fn(offset, df) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) LastOffset() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return uint64(len(s.frames)), nil
+}
+
+func TestWAL(t *testing.T) {
+	t.Parallel()
+
+	const walAddr = "127.0.0.1:19967"
+
+	store := &memoryStore{}
+	server := NewServer("zipper", WithServerLogger(discardingLogger), WithWAL(store, 1))
+	server.ConfigRouter(router.Default())
+	go server.ListenAndServe(context.TODO(), walAddr)
+	defer server.Close()
+
+	source := NewClient("source", walAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	// the frames are journaled before any sfn observes them.
+	md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+	for _, payload := range []string{"a", "b", "c"} {
+		assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte(payload)}))
+	}
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 2, Metadata: md, Payload: []byte("not journaled")}))
+	assert.Eventually(t, func() bool {
+		last, _ := store.LastOffset()
+		return last == 3
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// the late-joining sfn replays from the offset.
+	received := make(chan *frame.DataFrame, 3)
+	sfn := NewClient("sfn", walAddr, ClientTypeStreamFunction, WithLogger(discardingLogger), WithReplayFrom(2))
+	sfn.SetObserveDataTags(1)
+	sfn.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df })
+	assert.NoError(t, sfn.Connect(context.TODO()))
+	defer sfn.Close()
+
+	for i, want := range []string{"b", "c"} {
+		select {
+		case df := <-received:
+			assert.Equal(t, want, string(df.Payload))
+			md, err := metadata.Decode(df.Metadata)
+			assert.NoError(t, err)
+			offset, ok := GetWALOffsetFromMetadata(md)
+			assert.True(t, ok)
+			assert.Equal(t, uint64(i+2), offset)
+		case <-time.After(3 * time.Second):
+			t.Fatal("the sfn does not replay the data")
+		}
+	}
+
+	// the live frames carry their offsets too.
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("d")}))
+	select {
+	case df := <-received:
+		assert.Equal(t, "d", string(df.Payload))
+		md, _ := metadata.Decode(df.Metadata)
+		offset, _ := GetWALOffsetFromMetadata(md)
+		assert.Equal(t, uint64(4), offset)
+	case <-time.After(3 * time.Second):
+		t.Fatal("the sfn does not receive the data")
+	}
+}
+
+func TestWALTwoHops(t *testing.T) {
+	t.Parallel()
+
+	const walAddr = "127.0.0.1:19959"
+
+	server := NewServer("zipper", WithServerLogger(discardingLogger), WithWAL(&memoryStore{}, 1))
+	server.ConfigRouter(router.Default())
+	go server.ListenAndServe(context.TODO(), walAddr)
+	defer server.Close()
+
+	// the first sfn handles the journaled frames and writes its outputs to the second one.
+	first := NewClient("first", walAddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	first.SetObserveDataTags(1)
+	first.SetDataFrameObserver(func(df *frame.DataFrame) {
+		go func() { _ = serverless.NewContext(first, df).Write(2, df.Payload) }()
+	})
+	assert.NoError(t, first.Connect(context.TODO()))
+	defer first.Close()
+
+	received := make(chan *frame.DataFrame, 2)
+	second := NewClient("second", walAddr, ClientTypeStreamFunction, WithLogger(discardingLogger), WithReplayFrom(0))
+	second.SetObserveDataTags(2, 3)
+	second.SetDataFrameObserver(func(df *frame.DataFrame) { received <- df })
+	assert.NoError(t, second.Connect(context.TODO()))
+	defer second.Close()
+
+	source := NewClient("source", walAddr, ClientTypeSource, WithLogger(discardingLogger))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	md, _ := NewMetadata(source.clientID, "tid", "", "", false).Encode()
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Metadata: md, Payload: []byte("yomo")}))
+
+	// the client forwarding the metadata of a journaled frame does not pass its offset on either.
+	forged, _ := metadata.M{MetadataWALOffsetKey: "5"}.Encode()
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 3, Metadata: forged, Payload: []byte("yomo")}))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case df := <-received:
+			md, err := metadata.Decode(df.Metadata)
+			assert.NoError(t, err)
+			assert.NotContains(t, md, MetadataWALOffsetKey)
+		case <-time.After(3 * time.Second):
+			t.Fatal("the second sfn does not receive the data")
+		}
+	}
+	assert.Zero(t, second.walOffset.Load())
+}
+
+func TestWALTenant(t *testing.T) {
+	store := &memoryStore{}
+	server := NewServer("zipper", WithServerLogger(discardingLogger), WithWAL(store))
+
+	// the source of acme forges the tenant globex.
+	forged, err := metadata.M{MetadataTenantIDKey: "globex", MetadataTIDKey: "tid"}.Encode()
+	assert.NoError(t, err)
+	source := newConnection("source", "source-id", ClientTypeSource, metadata.M{MetadataTenantIDKey: "acme"}, nil, nil, discardingLogger)
+	c, err := newContext(source, &frame.DataFrame{Tag: 1, Metadata: forged, Payload: []byte("yomo")})
+	assert.NoError(t, err)
+	server.journal(c)
+
+	md, err := metadata.Decode(store.frames[0].Metadata)
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", GetTenantIDFromMetadata(md))
+
+	replayed := func(tenant string) []frame.Tag {
+		fconn := &gatedConn{stalledConn: newStalledConn(), gate: make(chan struct{})}
+		close(fconn.gate)
+		sfn := newConnection("sfn", "sfn-id", ClientTypeStreamFunction, metadata.M{MetadataTenantIDKey: tenant}, []uint32{1}, fconn, discardingLogger)
+		sfn.handshakeExts = map[string][]byte{replayExtensionKey: []byte("0")}
+		server.replay(sfn)
+		return fconn.written()
+	}
+	assert.Empty(t, replayed("globex"))
+	assert.Equal(t, []frame.Tag{1}, replayed("acme"))
+}
+
+func TestReplayFromReconnecting(t *testing.T) {
+	replayFrom := func(c *Client) string {
+		return string(c.handshakeExtensions()[replayExtensionKey])
+	}
+
+	sfn := NewClient("sfn", testaddr, ClientTypeStreamFunction, WithLogger(discardingLogger), WithReplayFrom(2))
+	assert.Equal(t, "2", replayFrom(sfn))
+
+	// the sfn replays after the last frame it has handled once reconnecting.
+	md, _ := metadata.M{MetadataWALOffsetKey: "5"}.Encode()
+	sfn.handled(sfn.walOffsetOf(&frame.DataFrame{Tag: 1, Metadata: md}))
+	sfn.handled(3)
+	assert.Equal(t, "6", replayFrom(sfn))
+
+	offset := uint64(7)
+	sfn = NewClient("sfn", testaddr, ClientTypeStreamFunction, WithLogger(discardingLogger), WithReplayFromFunc(func() uint64 { return offset }))
+	assert.Equal(t, "7", replayFrom(sfn))
+	offset = 9
+	assert.Equal(t, "9", replayFrom(sfn))
+
+	sfn = NewClient("sfn", testaddr, ClientTypeStreamFunction, WithLogger(discardingLogger))
+	assert.NotContains(t, sfn.handshakeExtensions(), replayExtensionKey)
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// deadlineConn bounds every write to the underlying conn with a timeout, so a stalled peer
+// cannot block the write loop of the client indefinitely.
+// If the conn implements frame.WriteDeadliner, the deadline is set to the stream, otherwise the
+// conn is closed once the timeout elapses. The conn is closed after a write timeout in both cases,
+// the client reconnects to zipper then.
+type deadlineConn struct {
+	frame.Conn
+	timeout time.Duration
+}
+
+func newDeadlineConn(conn frame.Conn, timeout time.Duration) frame.Conn {
+	if timeout <= 0 {
+		return conn
+	}
+	return &deadlineConn{Conn: conn, timeout: timeout}
+}
+
+// WriteFrame writes the frame within the timeout.
+func (c *deadlineConn) WriteFrame(f frame.Frame) error {
+	return c.write(func() error { return c.Conn.WriteFrame(f) })
+}
+
+// WriteFrames writes the frames within the timeout, the frames are written one by one
+// if the underlying conn is not a frame.BatchWriter.
+func (c *deadlineConn) WriteFrames(fs ...frame.Frame) error {
+	return c.write(func() error {
+		if bw, ok := c.Conn.(frame.BatchWriter); ok {
+			return bw.WriteFrames(fs...)
+		}
+		for _, f := range fs {
+			if err := c.Conn.WriteFrame(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (c *deadlineConn) write(fn func() error) error {
+	if wd, ok := c.Conn.(frame.WriteDeadliner); ok {
+		if err := wd.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+			return err
+		}
+		err := fn()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			_ = c.Conn.CloseWithError("yomo: write timeout")
+			return &ErrWriteTimeout{Cause: err}
+		}
+		return err
+	}
+
+	var expired atomic.Bool
+	timer := time.AfterFunc(c.timeout, func() {
+		expired.Store(true)
+		_ = c.Conn.CloseWithError("yomo: write timeout")
+	})
+	err := fn()
+	timer.Stop()
+	if err != nil && expired.Load() {
+		return &ErrWriteTimeout{Cause: os.ErrDeadlineExceeded}
+	}
+	return err
+}
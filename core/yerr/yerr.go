@@ -0,0 +1,104 @@
+// Package yerr defines the codes of the errors surfaced by yomo, so the applications can switch on
+// the code of an error instead of matching its message.
+package yerr
+
+import (
+	"errors"
+	"net"
+)
+
+// Code is the category of an error.
+type Code int
+
+const (
+	// CodeUnknown is the code of the errors that are not categorized.
+	CodeUnknown Code = iota
+	// CodeNetwork means the connection to the peer is broken, timeout or unreachable.
+	CodeNetwork
+	// CodeAuth means the credential is rejected, expired or the identity conflicts.
+	CodeAuth
+	// CodeProtocol means the peer violates the protocol, e.g. malformed frames or incompatible versions.
+	CodeProtocol
+	// CodeRouting means the data or the connection is routed to another place, e.g. another zipper.
+	CodeRouting
+	// CodeOverload means the frames are refused locally to protect the client, e.g. a full buffer or a rate limit.
+	CodeOverload
+	// CodeClosed means the client, the server or the stream has been closed.
+	CodeClosed
+	// CodeInvalid means the API is misused.
+	CodeInvalid
+)
+
+var codeStrings = map[Code]string{
+	CodeUnknown:  "unknown",
+	CodeNetwork:  "network",
+	CodeAuth:     "auth",
+	CodeProtocol: "protocol",
+	CodeRouting:  "routing",
+	CodeOverload: "overload",
+	CodeClosed:   "closed",
+	CodeInvalid:  "invalid",
+}
+
+// Codes returns all the codes.
+func Codes() []Code {
+	return []Code{CodeUnknown, CodeNetwork, CodeAuth, CodeProtocol, CodeRouting, CodeOverload, CodeClosed, CodeInvalid}
+}
+
+// String returns the name of the code.
+func (c Code) String() string {
+	if str, ok := codeStrings[c]; ok {
+		return str
+	}
+	return "unknown"
+}
+
+// Coder is implemented by the errors that carry a code.
+type Coder interface {
+	ErrorCode() Code
+}
+
+// Error is an error with a code.
+type Error struct {
+	code Code
+	err  error
+}
+
+// New returns an error with the code and the message, it is used to declare the sentinel errors.
+func New(code Code, message string) error {
+	return &Error{code: code, err: errors.New(message)}
+}
+
+// Wrap attaches the code to err, it returns nil if err is nil.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{code: code, err: err}
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string { return e.err.Error() }
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error { return e.err }
+
+// ErrorCode implements the Coder interface.
+func (e *Error) ErrorCode() Code { return e.code }
+
+// CodeOf returns the code of the first error carrying a code in the chain of err,
+// the other net.Error is CodeNetwork, and the rest is CodeUnknown.
+func CodeOf(err error) Code {
+	if err == nil {
+		return CodeUnknown
+	}
+	var coder Coder
+	if errors.As(err, &coder) {
+		return coder.ErrorCode()
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return CodeNetwork
+	}
+	return CodeUnknown
+}
@@ -0,0 +1,33 @@
+package yerr
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeOf(t *testing.T) {
+	errAuth := New(CodeAuth, "auth failed")
+	assert.Equal(t, "auth failed", errAuth.Error())
+	assert.Equal(t, CodeAuth, CodeOf(errAuth))
+	assert.Equal(t, CodeAuth, CodeOf(fmt.Errorf("connect: %w", errAuth)))
+
+	cause := errors.New("bad frame")
+	wrapped := Wrap(CodeProtocol, cause)
+	assert.ErrorIs(t, wrapped, cause)
+	assert.Equal(t, CodeProtocol, CodeOf(wrapped))
+	assert.Nil(t, Wrap(CodeProtocol, nil))
+
+	assert.Equal(t, CodeNetwork, CodeOf(&net.OpError{Op: "dial", Err: errors.New("refused")}))
+	assert.Equal(t, CodeUnknown, CodeOf(cause))
+	assert.Equal(t, CodeUnknown, CodeOf(nil))
+
+	for _, code := range Codes() {
+		assert.NotEmpty(t, code.String())
+	}
+	assert.Equal(t, "routing", CodeRouting.String())
+	assert.Equal(t, "unknown", Code(100).String())
+}
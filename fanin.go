@@ -0,0 +1,42 @@
+package yomo
+
+import (
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/serverless"
+)
+
+// Origin is where the data handled by the stream function comes from.
+type Origin struct {
+	// SourceID is the ID of the source that writes the data.
+	SourceID string
+	// Tag is the tag of the data.
+	Tag uint32
+	// Zipper is the name of the zipper that the data enters, it is empty unless the zipper labels it,
+	// see WithZipperOriginLabel.
+	Zipper string
+}
+
+// OriginOf returns the origin of the data of the context.
+func OriginOf(ctx serverless.Context) Origin {
+	origin := Origin{Tag: ctx.Tag()}
+	origin.SourceID, _ = ctx.Metadata(core.MetadataSourceIDKey)
+	origin.Zipper, _ = ctx.Metadata(core.MetadataZipperKey)
+	return origin
+}
+
+// FanInHandler handles the data of all the observed tags with its origin.
+type FanInHandler func(ctx serverless.Context, origin Origin)
+
+// FanIn merges the data of the observed tags from all the sources into one handler,
+// the origin of the data is passed to the handler instead of being decoded from the metadata.
+// It is the handler of the aggregator functions, e.g.
+//
+//	sfn.SetObserveDataTags(0x10, 0x11)
+//	sfn.SetHandler(yomo.FanIn(func(ctx serverless.Context, origin yomo.Origin) {
+//		aggregate(origin.SourceID, origin.Tag, ctx.Data())
+//	}))
+func FanIn(fn FanInHandler) core.AsyncHandler {
+	return func(ctx serverless.Context) {
+		fn(ctx, OriginOf(ctx))
+	}
+}
@@ -0,0 +1,29 @@
+package yomo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	coreserverless "github.com/yomorun/yomo/core/serverless"
+	"github.com/yomorun/yomo/serverless"
+)
+
+func TestFanIn(t *testing.T) {
+	var origins []Origin
+	handler := FanIn(func(ctx serverless.Context, origin Origin) {
+		origins = append(origins, origin)
+	})
+
+	md1, _ := metadata.M{core.MetadataSourceIDKey: "sensor-1", core.MetadataZipperKey: "zipper-1"}.Encode()
+	md2, _ := metadata.M{core.MetadataSourceIDKey: "sensor-2"}.Encode()
+	handler(coreserverless.NewContext(nil, &frame.DataFrame{Tag: 0x10, Metadata: md1, Payload: []byte("a")}))
+	handler(coreserverless.NewContext(nil, &frame.DataFrame{Tag: 0x11, Metadata: md2, Payload: []byte("b")}))
+
+	assert.Equal(t, []Origin{
+		{SourceID: "sensor-1", Tag: 0x10, Zipper: "zipper-1"},
+		{SourceID: "sensor-2", Tag: 0x11},
+	}, origins)
+}
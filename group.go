@@ -0,0 +1,108 @@
+package yomo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/ylog"
+	"golang.org/x/exp/slog"
+)
+
+// Component is a part of the app that can be connected and closed, e.g. Source and StreamFunction.
+type Component interface {
+	// Connect connects the component.
+	Connect() error
+	// Close closes the component.
+	Close() error
+}
+
+// Group supervises the sources, stream functions and zippers embedded in one process.
+// The members are started in the order they are added and closed in the reverse order,
+// so the zippers should be added before the sources and stream functions connecting to them.
+//
+// The zippers in the group should be created with `WithoutZipperSignalHandler()`,
+// the signals are handled by the group.
+type Group struct {
+	members []*groupMember
+	logger  *slog.Logger
+}
+
+type groupMember struct {
+	name  string
+	start func(ctx context.Context, errCh chan<- error) error
+	stop  func() error
+}
+
+// NewGroup returns a Group.
+func NewGroup() *Group {
+	return &Group{logger: ylog.Default()}
+}
+
+// Add adds a component to the group.
+func (g *Group) Add(name string, c Component) {
+	g.members = append(g.members, &groupMember{
+		name:  name,
+		start: func(context.Context, chan<- error) error { return c.Connect() },
+		stop:  c.Close,
+	})
+}
+
+// AddZipper adds a zipper listening on addr to the group.
+func (g *Group) AddZipper(name string, z Zipper, addr string) {
+	g.members = append(g.members, &groupMember{
+		name: name,
+		start: func(ctx context.Context, errCh chan<- error) error {
+			go func() {
+				if err := z.ListenAndServe(ctx, addr); err != nil && !errors.Is(err, core.ErrServerClosed) {
+					errCh <- fmt.Errorf("%s: %w", name, err)
+				}
+			}()
+			return nil
+		},
+		stop: z.Close,
+	})
+}
+
+// Run starts the members of the group in order, then it blocks until the ctx is done, SIGTERM/SIGINT is
+// received or a zipper fails, and closes the members in the reverse order. The errors are aggregated.
+func (g *Group) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	errCh := make(chan error, len(g.members))
+
+	for i, m := range g.members {
+		g.logger.Debug("group starting", "name", m.name)
+		if err := m.start(ctx, errCh); err != nil {
+			err = fmt.Errorf("%s: %w", m.name, err)
+			return errors.Join(err, g.close(g.members[:i]))
+		}
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		g.logger.Debug("group shutting down", "cause", context.Cause(ctx))
+	case runErr = <-errCh:
+		g.logger.Error("group member failed", "err", runErr)
+	}
+
+	return errors.Join(runErr, g.close(g.members))
+}
+
+// close closes the members in the reverse order.
+func (g *Group) close(members []*groupMember) error {
+	var errs []error
+	for i := len(members) - 1; i >= 0; i-- {
+		m := members[i]
+		g.logger.Debug("group closing", "name", m.name)
+		if err := m.stop(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", m.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
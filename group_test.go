@@ -0,0 +1,59 @@
+package yomo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordComponent struct {
+	name       string
+	events     *[]string
+	connectErr error
+	closeErr   error
+}
+
+func (c *recordComponent) Connect() error {
+	*c.events = append(*c.events, "connect "+c.name)
+	return c.connectErr
+}
+
+func (c *recordComponent) Close() error {
+	*c.events = append(*c.events, "close "+c.name)
+	return c.closeErr
+}
+
+func TestGroup(t *testing.T) {
+	t.Run("ordered", func(t *testing.T) {
+		var events []string
+		closeErr := errors.New("close error")
+
+		g := NewGroup()
+		g.Add("sfn", &recordComponent{name: "sfn", events: &events})
+		g.Add("source", &recordComponent{name: "source", events: &events, closeErr: closeErr})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := g.Run(ctx)
+		assert.ErrorIs(t, err, closeErr)
+		assert.Equal(t, []string{"connect sfn", "connect source", "close source", "close sfn"}, events)
+	})
+
+	t.Run("connect failed", func(t *testing.T) {
+		var events []string
+		connectErr := errors.New("connect error")
+
+		g := NewGroup()
+		g.Add("sfn", &recordComponent{name: "sfn", events: &events})
+		g.Add("source", &recordComponent{name: "source", events: &events, connectErr: connectErr})
+		g.Add("never", &recordComponent{name: "never", events: &events})
+
+		err := g.Run(context.Background())
+		assert.ErrorIs(t, err, connectErr)
+		assert.EqualError(t, err, "source: connect error")
+		assert.Equal(t, []string{"connect sfn", "connect source", "close sfn"}, events)
+	})
+}
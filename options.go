@@ -2,9 +2,16 @@ package yomo
 
 import (
 	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/quic-go/quic-go"
 	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/yerr"
+	yquic "github.com/yomorun/yomo/pkg/listener/quic"
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/slog"
 )
@@ -25,6 +32,16 @@ var (
 	// WithSourceTLSConfig sets tls config for the Source.
 	WithSourceTLSConfig = func(tc *tls.Config) SourceOption { return SourceOption(core.WithClientTLSConfig(tc)) }
 
+	// WithSourceFrameInterceptor appends the interceptor that is applied to every frame of the Source.
+	WithSourceFrameInterceptor = func(fn core.FrameInterceptor) SourceOption {
+		return SourceOption(core.WithFrameInterceptor(fn))
+	}
+
+	// WithSourceTLSConfigLoader sets the function that loads the tls config on every connecting of the Source.
+	WithSourceTLSConfigLoader = func(loader func() (*tls.Config, error)) SourceOption {
+		return SourceOption(core.WithTLSConfigLoader(loader))
+	}
+
 	// WithSourceQuicConfig sets quic config for the Source.
 	WithSourceQuicConfig = func(qc *quic.Config) SourceOption { return SourceOption(core.WithClientQuicConfig(qc)) }
 
@@ -34,8 +51,123 @@ var (
 	// WithSourceReConnect makes source Connect until success, unless authentication fails.
 	WithSourceReConnect = func() SourceOption { return SourceOption(core.WithReConnect()) }
 
+	// WithSourceFallbackZippers sets the fallback zipper addresses for the Source.
+	WithSourceFallbackZippers = func(addrs ...string) SourceOption { return SourceOption(core.WithFallbackZippers(addrs...)) }
+
+	// WithSourceDialer sets the dialer that establishes the QUIC connection for the Source.
+	WithSourceDialer = func(dialer yquic.Dialer) SourceOption { return SourceOption(core.WithDialer(dialer)) }
+
+	// WithSourceTransports sets the transports of the Source, the next one is used if the dialing fails.
+	WithSourceTransports = func(transports ...core.Transport) SourceOption {
+		return SourceOption(core.WithTransports(transports...))
+	}
+
+	// WithSourceReconnectBackoff sets the backoff policy of reconnection for the Source.
+	WithSourceReconnectBackoff = func(b core.ReconnectBackoff) SourceOption {
+		return SourceOption(core.WithReconnectBackoff(b))
+	}
+
+	// WithSourceIdleTimeout makes the Source reconnect if the zipper is silent in the idle timeout and a ping.
+	WithSourceIdleTimeout = func(timeout time.Duration) SourceOption { return SourceOption(core.WithIdleTimeout(timeout)) }
+
+	// WithSourceWriteBufferSize sets the size of write buffer for the Source.
+	WithSourceWriteBufferSize = func(n int) SourceOption { return SourceOption(core.WithWriteBufferSize(n)) }
+
+	// WithSourceWriteOverflowPolicy sets what the Source does when the write buffer is full.
+	WithSourceWriteOverflowPolicy = func(p core.WriteOverflowPolicy) SourceOption {
+		return SourceOption(core.WithWriteOverflowPolicy(p))
+	}
+
+	// WithSourceDropReportInterval reports the data dropped by the Source to the error handler every interval.
+	WithSourceDropReportInterval = func(interval time.Duration) SourceOption {
+		return SourceOption(core.WithDropReportInterval(interval))
+	}
+
+	// WithSourceWriteTimeout sets the timeout of writing data for the Source.
+	WithSourceWriteTimeout = func(timeout time.Duration) SourceOption { return SourceOption(core.WithWriteTimeout(timeout)) }
+
+	// WithSourceStreamWriteTimeout sets the timeout of writing to the stream for the Source.
+	WithSourceStreamWriteTimeout = func(timeout time.Duration) SourceOption {
+		return SourceOption(core.WithStreamWriteTimeout(timeout))
+	}
+
+	// WithSourceWriteRateLimit limits the rate of writing for the Source.
+	WithSourceWriteRateLimit = func(rps float64, burst int) SourceOption {
+		return SourceOption(core.WithWriteRateLimit(rps, burst))
+	}
+
+	// WithSourceDrainTimeout sets the timeout of flushing the queued frames on Close for the Source.
+	WithSourceDrainTimeout = func(timeout time.Duration) SourceOption { return SourceOption(core.WithDrainTimeout(timeout)) }
+
+	// WithSourceConnectTimeouts sets the timeouts of resolving, dialing and handshaking for the Source.
+	WithSourceConnectTimeouts = func(resolve, dial, handshake time.Duration) SourceOption {
+		return SourceOption(core.WithConnectTimeouts(resolve, dial, handshake))
+	}
+
+	// WithSourceResolver sets the resolver that discovers the zipper addresses for the Source.
+	WithSourceResolver = func(r core.Resolver) SourceOption { return SourceOption(core.WithResolver(r)) }
+
+	// WithSourceCircuitBreaker makes the Source fast-fail the writing for the cooldown after the threshold
+	// of consecutive write failures, the handler is called when the state of the breaker changes.
+	WithSourceCircuitBreaker = func(threshold int, cooldown time.Duration, handler core.BreakerHandler) SourceOption {
+		return SourceOption(core.WithCircuitBreaker(threshold, cooldown, handler))
+	}
+
+	// WithSourceConnectBreaker makes the Source probe the zipper once per probe interval after the threshold
+	// of consecutive connecting failures, see core.WithConnectBreaker.
+	WithSourceConnectBreaker = func(threshold int, probeInterval time.Duration) SourceOption {
+		return SourceOption(core.WithConnectBreaker(threshold, probeInterval))
+	}
+
+	// WithSourceMaxFrameSize makes the Source write the payload larger than size in chunks, see core.WithMaxFrameSize.
+	WithSourceMaxFrameSize = func(size int) SourceOption { return SourceOption(core.WithMaxFrameSize(size)) }
+
+	// WithSourceAck makes the Source retransmit the DataFrames not acknowledged by zipper in the timeout,
+	// see core.WithAck.
+	WithSourceAck = func(timeout time.Duration, retries int) SourceOption {
+		return SourceOption(core.WithAck(timeout, retries))
+	}
+
+	// WithSourceExtension registers the handler of the extension frame type for the Source.
+	WithSourceExtension = func(t frame.Type, handler core.ExtensionHandler) SourceOption {
+		return SourceOption(core.WithExtension(t, handler))
+	}
+
+	// WithSourceHandshakeExtension piggybacks the opaque data on the handshake of the Source.
+	WithSourceHandshakeExtension = func(key string, value []byte) SourceOption {
+		return SourceOption(core.WithHandshakeExtension(key, value))
+	}
+
+	// WithSourceChecksum makes the Source append the checksum to the data, see core.WithChecksum.
+	WithSourceChecksum = func() SourceOption { return SourceOption(core.WithChecksum()) }
+
+	// WithSourceStateHandler sets the handler that is called when the connection state of the Source changes.
+	WithSourceStateHandler = func(fn core.StateHandler) SourceOption { return SourceOption(core.WithStateHandler(fn)) }
+
+	// WithSourceStreamCount makes the Source write data on n streams, each tag is pinned to a stream by default.
+	WithSourceStreamCount = func(n int) SourceOption { return SourceOption(core.WithStreamCount(n)) }
+
+	// WithSourceStreamSelector sets the policy that chooses the stream of data for the Source.
+	WithSourceStreamSelector = func(selector yquic.StreamSelector) SourceOption {
+		return SourceOption(core.WithStreamSelector(selector))
+	}
+
+	// WithSourceCompression compresses the payloads larger than minSize if the zipper supports it.
+	WithSourceCompression = func(minSize int) SourceOption { return SourceOption(core.WithClientCompression(minSize)) }
+
+	// WithSourceCodecName selects the frame codec registered by the name, see core.WithCodecName.
+	WithSourceCodecName = func(name string) SourceOption { return SourceOption(core.WithCodecName(name)) }
+
+	// WithSourceWriteCoalescing coalesces multiple frames into a single write for the Source.
+	WithSourceWriteCoalescing = func(maxDelay time.Duration, maxBytes int) SourceOption {
+		return SourceOption(core.WithWriteCoalescing(maxDelay, maxBytes))
+	}
+
 	// WithTracerProvider sets tracer provider for the Source.
 	WithTracerProvider = func(tp trace.TracerProvider) SourceOption { return SourceOption(core.WithTracerProvider(tp)) }
+
+	// WithSourceTagNamer sets the tag namer for the Source, the tag names are displayed in logs and traces.
+	WithSourceTagNamer = func(namer core.TagNamer) SourceOption { return SourceOption(core.WithTagNamer(namer)) }
 )
 
 // Sfn Options.
@@ -46,6 +178,16 @@ var (
 	// WithSfnTLSConfig sets tls config for the Sfn.
 	WithSfnTLSConfig = func(tc *tls.Config) SfnOption { return SfnOption(core.WithClientTLSConfig(tc)) }
 
+	// WithSfnFrameInterceptor appends the interceptor that is applied to every frame of the Sfn.
+	WithSfnFrameInterceptor = func(fn core.FrameInterceptor) SfnOption {
+		return SfnOption(core.WithFrameInterceptor(fn))
+	}
+
+	// WithSfnTLSConfigLoader sets the function that loads the tls config on every connecting of the Sfn.
+	WithSfnTLSConfigLoader = func(loader func() (*tls.Config, error)) SfnOption {
+		return SfnOption(core.WithTLSConfigLoader(loader))
+	}
+
 	// WithSfnQuicConfig sets quic config for the Sfn.
 	WithSfnQuicConfig = func(qc *quic.Config) SfnOption { return SfnOption(core.WithClientQuicConfig(qc)) }
 
@@ -55,18 +197,244 @@ var (
 	// WithSfnReConnect makes sfn Connect until success, unless authentication fails.
 	WithSfnReConnect = func() SfnOption { return SfnOption(core.WithReConnect()) }
 
+	// WithSfnFallbackZippers sets the fallback zipper addresses for the Sfn.
+	WithSfnFallbackZippers = func(addrs ...string) SfnOption { return SfnOption(core.WithFallbackZippers(addrs...)) }
+
+	// WithSfnDrainTimeout sets the timeout of flushing the queued frames on Close for the Sfn.
+	WithSfnDrainTimeout = func(timeout time.Duration) SfnOption { return SfnOption(core.WithDrainTimeout(timeout)) }
+
+	// WithSfnDropReportInterval reports the data dropped by the Sfn to the error handler every interval.
+	WithSfnDropReportInterval = func(interval time.Duration) SfnOption {
+		return SfnOption(core.WithDropReportInterval(interval))
+	}
+
+	// WithSfnStreamWriteTimeout sets the timeout of writing to the stream for the Sfn.
+	WithSfnStreamWriteTimeout = func(timeout time.Duration) SfnOption { return SfnOption(core.WithStreamWriteTimeout(timeout)) }
+
+	// WithSfnConnectTimeouts sets the timeouts of resolving, dialing and handshaking for the Sfn.
+	WithSfnConnectTimeouts = func(resolve, dial, handshake time.Duration) SfnOption {
+		return SfnOption(core.WithConnectTimeouts(resolve, dial, handshake))
+	}
+
+	// WithSfnResolver sets the resolver that discovers the zipper addresses for the Sfn.
+	WithSfnResolver = func(r core.Resolver) SfnOption { return SfnOption(core.WithResolver(r)) }
+
+	// WithSfnCircuitBreaker makes the Sfn fast-fail the writing for the cooldown after the threshold
+	// of consecutive write failures, the handler is called when the state of the breaker changes.
+	WithSfnCircuitBreaker = func(threshold int, cooldown time.Duration, handler core.BreakerHandler) SfnOption {
+		return SfnOption(core.WithCircuitBreaker(threshold, cooldown, handler))
+	}
+
+	// WithSfnConnectBreaker makes the Sfn probe the zipper once per probe interval after the threshold
+	// of consecutive connecting failures, see core.WithConnectBreaker.
+	WithSfnConnectBreaker = func(threshold int, probeInterval time.Duration) SfnOption {
+		return SfnOption(core.WithConnectBreaker(threshold, probeInterval))
+	}
+
+	// WithSfnMaxFrameSize makes the Sfn write the payload larger than size in chunks, see core.WithMaxFrameSize.
+	WithSfnMaxFrameSize = func(size int) SfnOption { return SfnOption(core.WithMaxFrameSize(size)) }
+
+	// WithSfnAck makes the Sfn retransmit the DataFrames not acknowledged by zipper in the timeout,
+	// see core.WithAck.
+	WithSfnAck = func(timeout time.Duration, retries int) SfnOption {
+		return SfnOption(core.WithAck(timeout, retries))
+	}
+
+	// WithSfnExtension registers the handler of the extension frame type for the Sfn.
+	WithSfnExtension = func(t frame.Type, handler core.ExtensionHandler) SfnOption {
+		return SfnOption(core.WithExtension(t, handler))
+	}
+
+	// WithSfnHandshakeExtension piggybacks the opaque data on the handshake of the Sfn.
+	WithSfnHandshakeExtension = func(key string, value []byte) SfnOption {
+		return SfnOption(core.WithHandshakeExtension(key, value))
+	}
+
+	// WithSfnReplayFrom makes the Sfn replay the data journaled by the zipper from the offset, see core.WithReplayFrom.
+	WithSfnReplayFrom = func(offset uint64) SfnOption {
+		return SfnOption(core.WithReplayFrom(offset))
+	}
+
+	// WithSfnReplayFromFunc makes the Sfn replay the data journaled by the zipper from the offset returned by fn
+	// every time it connects, see core.WithReplayFromFunc.
+	WithSfnReplayFromFunc = func(fn func() uint64) SfnOption {
+		return SfnOption(core.WithReplayFromFunc(fn))
+	}
+
+	// WithSfnChecksum makes the Sfn append the checksum to the data, see core.WithChecksum.
+	WithSfnChecksum = func() SfnOption { return SfnOption(core.WithChecksum()) }
+
+	// WithSfnStateHandler sets the handler that is called when the connection state of the Sfn changes.
+	WithSfnStateHandler = func(fn core.StateHandler) SfnOption { return SfnOption(core.WithStateHandler(fn)) }
+
+	// WithSfnIdleTimeout makes the Sfn reconnect if the zipper is silent in the idle timeout and a ping.
+	WithSfnIdleTimeout = func(timeout time.Duration) SfnOption { return SfnOption(core.WithIdleTimeout(timeout)) }
+
+	// WithSfnReadQueue makes the Sfn queue the received data for the handler, the policy decides what to do
+	// when the queue is full, so a slow handler trades latency or loss instead of stalling the stream.
+	WithSfnReadQueue = func(size int, policy core.ReadQueuePolicy) SfnOption {
+		return SfnOption(core.WithReadQueue(size, policy))
+	}
+
+	// WithSfnSchemaVersions declares the payload schema versions of the tag supported by the Sfn.
+	WithSfnSchemaVersions = func(tag uint32, versions ...string) SfnOption {
+		return SfnOption(core.WithSchemaVersions(tag, versions...))
+	}
+
+	// WithSfnStreamCount makes the Sfn write data on n streams, each tag is pinned to a stream.
+	WithSfnStreamCount = func(n int) SfnOption { return SfnOption(core.WithStreamCount(n)) }
+
+	// WithSfnStreamSelector sets the policy that chooses the stream of data for the Sfn.
+	WithSfnStreamSelector = func(selector yquic.StreamSelector) SfnOption {
+		return SfnOption(core.WithStreamSelector(selector))
+	}
+
+	// WithSfnCompression compresses the payloads larger than minSize if the zipper supports it.
+	WithSfnCompression = func(minSize int) SfnOption { return SfnOption(core.WithClientCompression(minSize)) }
+
+	// WithSfnCodecName selects the frame codec registered by the name, see core.WithCodecName.
+	WithSfnCodecName = func(name string) SfnOption { return SfnOption(core.WithCodecName(name)) }
+
+	// WithSfnDialer sets the dialer that establishes the QUIC connection for the Sfn.
+	WithSfnDialer = func(dialer yquic.Dialer) SfnOption { return SfnOption(core.WithDialer(dialer)) }
+
+	// WithSfnTransports sets the transports of the Sfn, the next one is used if the dialing fails.
+	WithSfnTransports = func(transports ...core.Transport) SfnOption {
+		return SfnOption(core.WithTransports(transports...))
+	}
+
+	// WithSfnReconnectBackoff sets the backoff policy of reconnection for the Sfn.
+	WithSfnReconnectBackoff = func(b core.ReconnectBackoff) SfnOption { return SfnOption(core.WithReconnectBackoff(b)) }
+
 	// WithSfnTracerProvider sets tracer provider for the Sfn.
 	WithSfnTracerProvider = func(tp trace.TracerProvider) SfnOption { return SfnOption(core.WithTracerProvider(tp)) }
+
+	// WithSfnTagNamer sets the tag namer for the Sfn, the tag names are displayed in logs and traces.
+	WithSfnTagNamer = func(namer core.TagNamer) SfnOption { return SfnOption(core.WithTagNamer(namer)) }
 )
 
 // ClientOption is option for the upstream Zipper.
 type ClientOption = core.ClientOption
 
 type zipperOptions struct {
-	serverOption []core.ServerOption
-	clientOption []ClientOption
+	serverOption    []core.ServerOption
+	clientOption    []ClientOption
+	noSignalHandler bool
+	// configReloader returns the ReloadFunc of the config file, it is given the upstream options,
+	// so the downstreams added by reloading connect like the ones added at start.
+	configReloader func(upstreamOptions []ClientOption) core.ReloadFunc
+}
+
+// WriteOption is the per-call option for writing data.
+type WriteOption func(*writeOptions)
+
+// ErrReservedMetadataKey is returned by writing the message with the metadata of a reserved key, see WithMetadata.
+var ErrReservedMetadataKey = yerr.New(yerr.CodeInvalid, "yomo: reserved metadata key")
+
+type writeOptions struct {
+	tid       string
+	target    string
+	ttl       time.Duration
+	metadata  map[string]string
+	priority  frame.Priority
+	channel   uint32
+	schema    string
+	partition string
+	err       error // the error of the options, the message is not written if it is set
+}
+
+// apply sets the per-call properties to the metadata of the message.
+func (o *writeOptions) apply(md metadata.M) {
+	for k, v := range o.metadata {
+		md.Set(k, v)
+	}
+	if o.target != "" {
+		md.Set(core.MetadataTargetKey, o.target)
+	}
+	if o.ttl > 0 {
+		core.SetExpireToMetadata(md, time.Now().Add(o.ttl))
+	}
+	if o.schema != "" {
+		core.SetSchemaVersionToMetadata(md, o.schema)
+	}
+	if o.partition != "" {
+		md.Set(core.MetadataPartitionKey, o.partition)
+	}
 }
 
+var (
+	// WithTarget routes the message to the stream function with the name exclusively.
+	WithTarget = func(name string) WriteOption {
+		return func(o *writeOptions) {
+			o.target = name
+		}
+	}
+
+	// WithPartitionKey sets the partition key of the message, the zipper dispatching by DispatchSticky or
+	// DispatchConsistentHash sends the messages of a partition to the same stream function.
+	WithPartitionKey = func(key string) WriteOption {
+		return func(o *writeOptions) {
+			o.partition = key
+		}
+	}
+
+	// WithTID sets the transaction ID of the message, a new ID is generated if it is not set.
+	WithTID = func(tid string) WriteOption {
+		return func(o *writeOptions) {
+			o.tid = tid
+		}
+	}
+
+	// WithMetadata sets the metadata of the message, the metadata can be retrieved by `serverless.Context.Metadata()`.
+	// The keys prefixed by `yomo-` are reserved, the message is not written with them, see ErrReservedMetadataKey.
+	WithMetadata = func(md map[string]string) WriteOption {
+		return func(o *writeOptions) {
+			if o.metadata == nil {
+				o.metadata = make(map[string]string, len(md))
+			}
+			for k, v := range md {
+				if strings.HasPrefix(k, metadata.ReservedPrefix) {
+					o.err = fmt.Errorf("%w: %s", ErrReservedMetadataKey, k)
+					return
+				}
+				o.metadata[k] = v
+			}
+		}
+	}
+
+	// WithPriority sets the priority of the message, the messages with higher priority are written first.
+	// It takes effect when the write buffer is enabled by `WithSourceWriteBufferSize`.
+	WithPriority = func(priority frame.Priority) WriteOption {
+		return func(o *writeOptions) {
+			o.priority = priority
+		}
+	}
+
+	// WithChannel writes the message on the logical stream, e.g. a channel per device. The channels share
+	// the data streams of the Source, pin them with `WithSourceStreamSelector(yquic.ChannelAffinity)`
+	// to keep the order of each channel.
+	WithChannel = func(channel uint32) WriteOption {
+		return func(o *writeOptions) {
+			o.channel = channel
+		}
+	}
+
+	// WithTTL sets the time to live of the message, the zipper drops the message once it expires.
+	WithTTL = func(ttl time.Duration) WriteOption {
+		return func(o *writeOptions) {
+			o.ttl = ttl
+		}
+	}
+
+	// WithSchemaVersion sets the payload schema version of the message, the zipper routes the message
+	// only to the stream functions supporting the version, or through the registered schema converter.
+	WithSchemaVersion = func(version string) WriteOption {
+		return func(o *writeOptions) {
+			o.schema = version
+		}
+	}
+)
+
 // ZipperOption is option for the Zipper.
 type ZipperOption func(*zipperOptions)
 
@@ -92,6 +460,14 @@ var (
 		}
 	}
 
+	// WithZipperWebTransport makes the zipper accept the WebTransport sessions at the path of addr as well,
+	// see core.WithWebTransport.
+	WithZipperWebTransport = func(addr, path string) ZipperOption {
+		return func(zo *zipperOptions) {
+			zo.serverOption = append(zo.serverOption, core.WithWebTransport(addr, path))
+		}
+	}
+
 	// WithZipperLogger sets logger for the zipper.
 	WithZipperLogger = func(l *slog.Logger) ZipperOption {
 		return func(zo *zipperOptions) {
@@ -102,7 +478,7 @@ var (
 	// WithUpstreamOption provides upstream zipper options for Zipper.
 	WithUpstreamOption = func(opts ...ClientOption) ZipperOption {
 		return func(o *zipperOptions) {
-			o.clientOption = opts
+			o.clientOption = append(o.clientOption, opts...)
 		}
 	}
 
@@ -113,6 +489,254 @@ var (
 		}
 	}
 
+	// WithZipperTagNamer sets the tag namer for the zipper, the tag names are displayed in logs and traces.
+	WithZipperTagNamer = func(namer core.TagNamer) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithServerTagNamer(namer))
+		}
+	}
+
+	// WithZipperFrameDedup enables frame-ID based deduplication for the zipper, see core.WithFrameDedup.
+	WithZipperFrameDedup = func(window time.Duration) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithFrameDedup(window))
+		}
+	}
+
+	// WithZipperCompression makes the zipper accept the payload compression offered by clients,
+	// see core.WithServerCompression.
+	WithZipperCompression = func(minSize int) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithServerCompression(minSize))
+		}
+	}
+
+	// WithZipperCodecName selects the frame codec registered by the name, the links to the mesh zippers
+	// use it as well, see core.WithServerCodecName.
+	WithZipperCodecName = func(name string) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithServerCodecName(name))
+			o.clientOption = append(o.clientOption, core.WithCodecName(name))
+		}
+	}
+
+	// WithZipperSchemaConverter registers the converter that up-converts the payloads of the tag from
+	// a schema version to another, see core.WithSchemaConverter.
+	WithZipperSchemaConverter = func(tag uint32, from, to string, converter core.SchemaConverter) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithSchemaConverter(tag, from, to, converter))
+		}
+	}
+
+	// WithZipperPayloadValidator registers the validator of the payloads of the tag, see core.WithPayloadValidator.
+	WithZipperPayloadValidator = func(tag uint32, validator core.PayloadValidator) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithPayloadValidator(tag, validator))
+		}
+	}
+
+	// WithZipperDispatchPolicy sets the policy that dispatches the data of the tags to the sfns observing them,
+	// the policy applies to all the tags if no tag is given, see core.WithDispatchPolicy.
+	WithZipperDispatchPolicy = func(policy core.DispatchPolicy, tags ...uint32) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithDispatchPolicy(policy, tags...))
+		}
+	}
+
+	// WithZipperStickyRouting dispatches the data sharing a partition key or a TID of the tags to the same sfn,
+	// see core.WithStickyRouting.
+	WithZipperStickyRouting = func(ttl time.Duration, tags ...uint32) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithStickyRouting(ttl, tags...))
+		}
+	}
+
+	// WithZipperMeshRelay makes the zipper relay the data from the upstream zippers to its downstreams,
+	// the loops are broken by the zipper path in metadata, see core.WithMeshRelay.
+	WithZipperMeshRelay = func(maxHops int) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithMeshRelay(maxHops))
+		}
+	}
+
+	// WithZipperOriginLabel labels the data entering the zipper with its name, it is the zipper
+	// of yomo.Origin, see core.WithOriginLabel.
+	WithZipperOriginLabel = func() ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithOriginLabel())
+		}
+	}
+
+	// WithZipperDownstreamHealthCheck probes the downstream zippers every interval and takes the unhealthy ones
+	// out of rotation until they recover, see core.WithDownstreamHealthCheck.
+	WithZipperDownstreamHealthCheck = func(interval, timeout time.Duration, threshold int) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithDownstreamHealthCheck(interval, timeout, threshold))
+		}
+	}
+
+	// WithZipperQuota sets the default quota of the clients of the zipper, see core.WithQuota.
+	WithZipperQuota = func(quota core.Quota) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithQuota(quota))
+		}
+	}
+
+	// WithZipperClientQuota sets the quota of the clients of the names, see core.WithClientQuota.
+	WithZipperClientQuota = func(quota core.Quota, names ...string) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithClientQuota(quota, names...))
+		}
+	}
+
+	// WithZipperCredentialQuota sets the quota of the clients of the credentials, see core.WithCredentialQuota.
+	WithZipperCredentialQuota = func(quota core.Quota, credentials ...string) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithCredentialQuota(quota, credentials...))
+		}
+	}
+
+	// WithZipperSlowConsumerPolicy sets the policy of the slow stream functions of the names, see core.WithSlowConsumerPolicy.
+	WithZipperSlowConsumerPolicy = func(policy core.SlowConsumerPolicy, buffer int, names ...string) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithSlowConsumerPolicy(policy, buffer, names...))
+		}
+	}
+
+	// WithZipperWAL journals the data of the tags to the store, so the sfns replay them, see core.WithWAL and package pkg/wal.
+	WithZipperWAL = func(store core.FrameStore, tags ...frame.Tag) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithWAL(store, tags...))
+		}
+	}
+
+	// WithZipperConnectHook adds the hooks called after a client passes the handshake, the client is rejected
+	// if a hook returns an error, see core.WithConnectHook.
+	WithZipperConnectHook = func(hooks ...core.ConnectHook) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithConnectHook(hooks...))
+		}
+	}
+
+	// WithZipperDisconnectHook adds the hooks called after the connection of a client is closed, see core.WithDisconnectHook.
+	WithZipperDisconnectHook = func(hooks ...core.DisconnectHook) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithDisconnectHook(hooks...))
+		}
+	}
+
+	// WithZipperDrainTimeout sets the timeout of draining the connections on Shutdown, see core.WithServerDrainTimeout.
+	WithZipperDrainTimeout = func(timeout time.Duration) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithServerDrainTimeout(timeout))
+		}
+	}
+
+	// WithZipperReloadFunc sets the function that reloads the configuration of the zipper on SIGHUP
+	// or by `POST /reload` of the admin, see core.WithReloadFunc.
+	WithZipperReloadFunc = func(fn core.ReloadFunc) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithReloadFunc(fn))
+		}
+	}
+
+	// WithZipperServerOption appends the options of the core.Server of the zipper, e.g. the options
+	// installing the metrics of package pkg/metrics.
+	WithZipperServerOption = func(opts ...core.ServerOption) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, opts...)
+		}
+	}
+
+	// WithZipperAdminAddr sets the address of the admin http server for the zipper, see core.WithAdminAddr.
+	WithZipperAdminAddr = func(addr string) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithAdminAddr(addr))
+		}
+	}
+
+	// WithZipperAdminToken makes the admin http server of the zipper require the bearer token,
+	// see core.WithAdminToken.
+	WithZipperAdminToken = func(token string) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithAdminToken(token))
+		}
+	}
+
+	// WithZipperSpill spills the data of the tag to disk up to the limit in bytes for the slow stream functions,
+	// see core.WithSpill.
+	WithZipperSpill = func(tag uint32, limit int64) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithSpill(tag, limit))
+		}
+	}
+
+	// WithZipperSpillDir sets the directory of the spill files for the zipper.
+	WithZipperSpillDir = func(dir string) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithSpillDir(dir))
+		}
+	}
+
+	// WithZipperExtension registers the handler of the extension frame type for the zipper, see core.WithServerExtension.
+	WithZipperExtension = func(t frame.Type, handler core.ExtensionHandler) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithServerExtension(t, handler))
+		}
+	}
+
+	// WithZipperHandshakeExtensionHandler sets the handler that answers the extensions of the handshakes
+	// for the zipper, see core.WithHandshakeExtensionHandler.
+	WithZipperHandshakeExtensionHandler = func(fn core.HandshakeExtensionHandler) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithHandshakeExtensionHandler(fn))
+		}
+	}
+
+	// WithZipperChecksum makes the zipper append the checksum to the data, see core.WithServerChecksum.
+	WithZipperChecksum = func() ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithServerChecksum())
+		}
+	}
+
+	// WithZipperFrameLimits sets the limits of the frames read from the clients, see core.WithFrameLimits.
+	WithZipperFrameLimits = func(limits frame.Limits) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithFrameLimits(limits))
+		}
+	}
+
+	// WithZipperPlugin registers the plugins on the zipper, see core.Plugin.
+	WithZipperPlugin = func(ps ...core.Plugin) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithPlugin(ps...))
+		}
+	}
+
+	// WithZipperMinProtocolVersion sets the lowest protocol version of the clients accepted by the zipper,
+	// see core.WithMinProtocolVersion.
+	WithZipperMinProtocolVersion = func(version uint32) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithMinProtocolVersion(version))
+		}
+	}
+
+	// WithZipperLeakDetection enables the leak detection of the connections for the zipper, see core.WithLeakDetection.
+	WithZipperLeakDetection = func(grace time.Duration) ZipperOption {
+		return func(o *zipperOptions) {
+			o.serverOption = append(o.serverOption, core.WithLeakDetection(grace))
+		}
+	}
+
+	// WithoutZipperSignalHandler disables the signal handler of the zipper, which closes the zipper and
+	// exits the process on SIGTERM/SIGINT. It is useful if the signals are handled by a `Group`.
+	WithoutZipperSignalHandler = func() ZipperOption {
+		return func(o *zipperOptions) {
+			o.noSignalHandler = true
+		}
+	}
+
 	// WithConnMiddleware sets conn middleware for the zipper.
 	WithZipperConnMiddleware = func(mw ...core.ConnMiddleware) ZipperOption {
 		return func(o *zipperOptions) {
@@ -126,4 +750,13 @@ var (
 			o.serverOption = append(o.serverOption, core.WithFrameMiddleware(mw...))
 		}
 	}
+
+	// WithZipperDataFrameFunc mutates, enriches, filters or rejects the data before routing, see core.DataFrameFunc.
+	WithZipperDataFrameFunc = func(fns ...core.DataFrameFunc) ZipperOption {
+		return func(o *zipperOptions) {
+			for _, fn := range fns {
+				o.serverOption = append(o.serverOption, core.WithFrameMiddleware(core.DataFrameMiddleware(fn)))
+			}
+		}
+	}
 )
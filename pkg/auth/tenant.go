@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/metadata"
+)
+
+var _ auth.Authentication = (*TenantAuth)(nil)
+
+// TenantAuth token authentication of tenants, the tenant of the token is returned as the
+// metadata.TenantIDKey of the connection metadata, so the zipper isolates the tenants.
+type TenantAuth struct {
+	tenants map[string]string // token -> tenant ID
+}
+
+// NewTenantAuth create a tenant authentication
+func NewTenantAuth() *TenantAuth {
+	return &TenantAuth{tenants: map[string]string{}}
+}
+
+// Init authentication initialize arguments, every argument is a tenant and its token
+// in the form of "tenantID=token", the arguments in other forms are ignored.
+func (a *TenantAuth) Init(args ...string) {
+	tenants := make(map[string]string, len(args))
+	for _, arg := range args {
+		tenantID, token, ok := strings.Cut(arg, "=")
+		if !ok || tenantID == "" || token == "" {
+			continue
+		}
+		tenants[token] = tenantID
+	}
+	a.tenants = tenants
+}
+
+// Authenticate authentication client's credential
+func (a *TenantAuth) Authenticate(payload string) (metadata.M, bool) {
+	tenantID, ok := a.tenants[payload]
+	if !ok {
+		return nil, false
+	}
+	return metadata.M{metadata.TenantIDKey: tenantID}, true
+}
+
+// Name authentication name
+func (a *TenantAuth) Name() string {
+	return "tenant"
+}
+
+func init() {
+	auth.Register(NewTenantAuth())
+}
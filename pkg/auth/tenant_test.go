@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/metadata"
+)
+
+func TestTenant(t *testing.T) {
+	auth := NewTenantAuth()
+
+	auth.Init("acme=token-a", "globex=token-g", "invalid")
+
+	assert.Equal(t, "tenant", auth.Name())
+
+	md, authed := auth.Authenticate("token-a")
+	assert.True(t, authed)
+	assert.Equal(t, metadata.M{metadata.TenantIDKey: "acme"}, md)
+
+	md, authed = auth.Authenticate("token-g")
+	assert.True(t, authed)
+	assert.Equal(t, metadata.M{metadata.TenantIDKey: "globex"}, md)
+
+	_, authed = auth.Authenticate("invalid")
+	assert.False(t, authed)
+}
@@ -0,0 +1,303 @@
+// Package check validates the config and the environment of a zipper before it serves,
+// so the misconfigurations are caught before the traffic arrives, see `yomo serve --check`.
+package check
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/pkg/config"
+	"github.com/yomorun/yomo/pkg/log"
+	pkgtls "github.com/yomorun/yomo/pkg/tls"
+	"golang.org/x/exp/slog"
+)
+
+// Status is the status of a check.
+type Status int
+
+const (
+	// StatusOK means the check passes.
+	StatusOK Status = iota
+	// StatusWarn means the zipper works, but it may be not what you want.
+	StatusWarn
+	// StatusFail means the zipper does not work as configured.
+	StatusFail
+)
+
+// Result is the result of a check.
+type Result struct {
+	// Name is the name of the check, e.g. "tls".
+	Name string
+	// Status is the status of the check.
+	Status Status
+	// Message describes what is found.
+	Message string
+	// Hint tells how to fix it if the check does not pass.
+	Hint string
+}
+
+// Report is the results of the checks.
+type Report []Result
+
+// Failed reports whether any check fails.
+func (r Report) Failed() bool {
+	for _, result := range r {
+		if result.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Print prints the results to w.
+func (r Report) Print(w io.Writer) {
+	for _, result := range r {
+		msg := fmt.Sprintf("[%s] %s", result.Name, result.Message)
+		if result.Hint != "" {
+			msg += ", " + result.Hint
+		}
+		switch result.Status {
+		case StatusOK:
+			log.SuccessStatusEvent(w, msg)
+		case StatusWarn:
+			log.WarningStatusEvent(w, msg)
+		default:
+			log.FailureStatusEvent(w, msg)
+		}
+	}
+}
+
+// DefaultTimeout is the timeout of the network checks.
+const DefaultTimeout = 3 * time.Second
+
+// certExpiryWarning is how long before the certificate expires to warn.
+const certExpiryWarning = 30 * 24 * time.Hour
+
+// minSaneTime is the earliest time that a sane clock can be at.
+var minSaneTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Run checks the TLS certificates, the reachability of the UDP port, the auth config,
+// the connectivity of the downstream zippers and the clock of the zipper.
+func Run(ctx context.Context, conf config.Config) Report {
+	now := time.Now()
+
+	var report Report
+	report = append(report, checkTLS(conf, now)...)
+	report = append(report, checkUDP(conf, DefaultTimeout))
+	report = append(report, checkAuth(conf))
+	report = append(report, checkDownstreams(ctx, conf, DefaultTimeout)...)
+	report = append(report, checkClock(now))
+
+	return report
+}
+
+func checkTLS(conf config.Config, now time.Time) []Result {
+	if os.Getenv("YOMO_TLS_CERT_FILE") == "" || os.Getenv("YOMO_TLS_KEY_FILE") == "" {
+		return []Result{{
+			Name:    "tls",
+			Status:  StatusWarn,
+			Message: "no certificate is configured, a self-signed certificate is generated at startup",
+			Hint:    "set YOMO_TLS_CERT_FILE and YOMO_TLS_KEY_FILE in production",
+		}}
+	}
+
+	tc, err := pkgtls.CreateServerTLSConfig(conf.Host)
+	if err != nil {
+		return []Result{{
+			Name:    "tls",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("failed to load the certificate: %v", err),
+			Hint:    "check YOMO_TLS_CERT_FILE, YOMO_TLS_KEY_FILE and YOMO_TLS_CACERT_FILE",
+		}}
+	}
+	leaf, err := x509.ParseCertificate(tc.Certificates[0].Certificate[0])
+	if err != nil {
+		return []Result{{
+			Name:    "tls",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("failed to parse the certificate: %v", err),
+			Hint:    "check YOMO_TLS_CERT_FILE",
+		}}
+	}
+
+	return []Result{checkCert(leaf, now)}
+}
+
+func checkCert(leaf *x509.Certificate, now time.Time) Result {
+	result := Result{Name: "tls"}
+	switch {
+	case now.Before(leaf.NotBefore):
+		result.Status = StatusFail
+		result.Message = fmt.Sprintf("the certificate is not valid until %s", leaf.NotBefore.Format(time.RFC3339))
+		result.Hint = "check the clock of the host, or issue the certificate again"
+	case now.After(leaf.NotAfter):
+		result.Status = StatusFail
+		result.Message = fmt.Sprintf("the certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+		result.Hint = "renew the certificate"
+	case leaf.NotAfter.Sub(now) < certExpiryWarning:
+		result.Status = StatusWarn
+		result.Message = fmt.Sprintf("the certificate expires at %s", leaf.NotAfter.Format(time.RFC3339))
+		result.Hint = "renew the certificate soon"
+	default:
+		result.Message = fmt.Sprintf("the certificate is valid until %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+	return result
+}
+
+// checkUDP binds the UDP port of the zipper, and sends a datagram to it from the host itself.
+func checkUDP(conf config.Config, timeout time.Duration) Result {
+	addr := net.JoinHostPort(conf.Host, strconv.Itoa(conf.Port))
+
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return Result{
+			Name:    "udp",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("failed to listen on %s: %v", addr, err),
+			Hint:    "check if the port is used by another process or the host is an address of this machine",
+		}
+	}
+	defer pc.Close()
+
+	// the wildcard address is dialed by the loopback address.
+	host := conf.Host
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	conn, err := net.DialTimeout("udp", net.JoinHostPort(host, strconv.Itoa(pc.LocalAddr().(*net.UDPAddr).Port)), timeout)
+	if err != nil {
+		return Result{
+			Name:    "udp",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("failed to dial %s: %v", addr, err),
+			Hint:    "check the route to the host",
+		}
+	}
+	defer conn.Close()
+
+	probe := []byte("yomo-check")
+	if _, err := conn.Write(probe); err != nil {
+		return Result{Name: "udp", Status: StatusFail, Message: fmt.Sprintf("failed to send to %s: %v", addr, err)}
+	}
+	_ = pc.SetReadDeadline(time.Now().Add(timeout))
+	buf := make([]byte, len(probe))
+	if _, _, err := pc.ReadFrom(buf); err != nil {
+		return Result{
+			Name:    "udp",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("the datagram sent to %s is not received: %v", addr, err),
+			Hint:    "check the firewall rules of the UDP port",
+		}
+	}
+
+	return Result{Name: "udp", Message: fmt.Sprintf("%s is reachable", addr)}
+}
+
+func checkAuth(conf config.Config) Result {
+	authType, ok := conf.Auth["type"]
+	if !ok {
+		return Result{
+			Name:    "auth",
+			Status:  StatusWarn,
+			Message: "no authentication is configured, any client can connect",
+			Hint:    "set auth.type and auth.token in production",
+		}
+	}
+	// the zipper supports the token authentication only, see cli/serve.go.
+	if authType != "token" {
+		return Result{
+			Name:    "auth",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("the auth type %q is not supported", authType),
+			Hint:    `set auth.type to "token"`,
+		}
+	}
+	token := conf.Auth["token"]
+	if token == "" {
+		return Result{
+			Name:    "auth",
+			Status:  StatusFail,
+			Message: "the token is empty",
+			Hint:    "set auth.token",
+		}
+	}
+	if strings.HasPrefix(token, "<") && strings.HasSuffix(token, ">") {
+		return Result{
+			Name:    "auth",
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("the token %s looks like a placeholder", token),
+			Hint:    "set auth.token to a secret",
+		}
+	}
+	return Result{Name: "auth", Message: "token authentication is configured"}
+}
+
+// checkDownstreams connects to the downstream zippers with the credentials in the mesh config.
+func checkDownstreams(ctx context.Context, conf config.Config, timeout time.Duration) []Result {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	names := make([]string, 0, len(conf.Mesh))
+	for name := range conf.Mesh {
+		if name != "" && name != conf.Name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var results []Result
+	for _, name := range names {
+		mesh := conf.Mesh[name]
+		addr := net.JoinHostPort(mesh.Host, strconv.Itoa(mesh.Port))
+		checkName := "downstream " + name
+
+		client := core.NewClient(conf.Name, addr, core.ClientTypeUpstreamZipper,
+			core.WithCredential(mesh.Credential), core.WithLogger(logger))
+		err := connect(ctx, client, timeout)
+		_ = client.Close()
+
+		if err == nil {
+			results = append(results, Result{Name: checkName, Message: fmt.Sprintf("%s is connected", addr)})
+			continue
+		}
+		hint := "check the host, the port and the firewall of the downstream zipper"
+		if e := new(core.ErrRejected); errors.As(err, &e) {
+			hint = "check the credential of the mesh config"
+		}
+		results = append(results, Result{
+			Name:    checkName,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("failed to connect to %s: %v", addr, err),
+			Hint:    hint,
+		})
+	}
+	return results
+}
+
+func connect(ctx context.Context, client *core.Client, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return client.Connect(ctx)
+}
+
+func checkClock(now time.Time) Result {
+	if now.Before(minSaneTime) {
+		return Result{
+			Name:    "clock",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("the clock is at %s", now.Format(time.RFC3339)),
+			Hint:    "sync the clock of the host by NTP, the certificates and the frame expirations depend on it",
+		}
+	}
+	return Result{Name: "clock", Message: fmt.Sprintf("the clock is at %s", now.Format(time.RFC3339))}
+}
@@ -0,0 +1,93 @@
+package check
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/pkg/config"
+)
+
+func TestReport(t *testing.T) {
+	report := Report{{Name: "ok"}, {Name: "warn", Status: StatusWarn}}
+	assert.False(t, report.Failed())
+
+	report = append(report, Result{Name: "fail", Status: StatusFail})
+	assert.True(t, report.Failed())
+}
+
+func TestCheckCert(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		notBefore time.Time
+		notAfter  time.Time
+		want      Status
+	}{
+		{name: "valid", notBefore: now.Add(-time.Hour), notAfter: now.Add(365 * 24 * time.Hour), want: StatusOK},
+		{name: "expiring", notBefore: now.Add(-time.Hour), notAfter: now.Add(24 * time.Hour), want: StatusWarn},
+		{name: "expired", notBefore: now.Add(-2 * time.Hour), notAfter: now.Add(-time.Hour), want: StatusFail},
+		{name: "not yet valid", notBefore: now.Add(time.Hour), notAfter: now.Add(2 * time.Hour), want: StatusFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := checkCert(&x509.Certificate{NotBefore: tt.notBefore, NotAfter: tt.notAfter}, now)
+			assert.Equal(t, tt.want, result.Status, result.Message)
+		})
+	}
+}
+
+func TestCheckAuth(t *testing.T) {
+	tests := []struct {
+		name string
+		auth map[string]string
+		want Status
+	}{
+		{name: "no auth", auth: nil, want: StatusWarn},
+		{name: "unknown type", auth: map[string]string{"type": "jwt"}, want: StatusFail},
+		{name: "empty token", auth: map[string]string{"type": "token"}, want: StatusFail},
+		{name: "placeholder", auth: map[string]string{"type": "token", "token": "<CREDENTIAL>"}, want: StatusWarn},
+		{name: "token", auth: map[string]string{"type": "token", "token": "secret"}, want: StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := checkAuth(config.Config{Auth: tt.auth})
+			assert.Equal(t, tt.want, result.Status, result.Message)
+		})
+	}
+}
+
+func TestCheckUDP(t *testing.T) {
+	result := checkUDP(config.Config{Host: "127.0.0.1"}, time.Second)
+	assert.Equal(t, StatusOK, result.Status, result.Message)
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer pc.Close()
+
+	result = checkUDP(config.Config{Host: "127.0.0.1", Port: pc.LocalAddr().(*net.UDPAddr).Port}, time.Second)
+	assert.Equal(t, StatusFail, result.Status, result.Message)
+}
+
+func TestCheckDownstreams(t *testing.T) {
+	conf := config.Config{
+		Name: "zipper",
+		Mesh: map[string]config.Mesh{
+			"zipper":      {Host: "127.0.0.1", Port: 9000},
+			"unreachable": {Host: "127.0.0.1", Port: 1},
+		},
+	}
+	results := checkDownstreams(context.TODO(), conf, 500*time.Millisecond)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "downstream unreachable", results[0].Name)
+	assert.Equal(t, StatusFail, results[0].Status)
+}
+
+func TestCheckClock(t *testing.T) {
+	assert.Equal(t, StatusOK, checkClock(time.Now()).Status)
+	assert.Equal(t, StatusFail, checkClock(time.Unix(0, 0)).Status)
+}
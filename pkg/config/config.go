@@ -5,6 +5,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -26,9 +27,29 @@ type Config struct {
 	// Auth is the way for the source or SFN to be authenticated by the zipper.
 	// The token typed auth has two key-value pairs associated with it:
 	// a `type:token` key-value pair and a `token:<CREDENTIAL>` key-value pair.
+	// The tenant typed auth isolates the tenants, it has a `type:tenant` key-value pair and
+	// a `tenants:<TENANT>=<CREDENTIAL>,...` key-value pair.
 	Auth map[string]string `yaml:"auth"`
 	// Mesh holds all cascading zippers config. the map-key is mesh name.
 	Mesh map[string]Mesh `yaml:"mesh"`
+	// Codec is the name of the registered frame codec, e.g. "cbor", the clients and the mesh zippers
+	// must use the same codec. If Codec is empty, the y3 codec is used.
+	Codec string `yaml:"codec"`
+	// Dispatch configures how the data of the tags are dispatched to the stream functions observing them.
+	// If Dispatch is nil, the data are broadcast to all of them.
+	Dispatch *Dispatch `yaml:"dispatch"`
+}
+
+// Dispatch is the config of dispatching the data to the stream functions.
+type Dispatch struct {
+	// Policy is the policy of the tags not in Tags, it is one of "broadcast", "round_robin",
+	// "least_outstanding", "consistent_hash" and "sticky". If Policy is empty, it is "broadcast".
+	Policy string `yaml:"policy"`
+	// Tags are the policies of the tags, the map-key is the tag.
+	Tags map[uint32]string `yaml:"tags"`
+	// StickyTTL is the time that a partition key of the "sticky" policy keeps its stream function
+	// after its last data, e.g. "10m".
+	StickyTTL time.Duration `yaml:"sticky_ttl"`
 }
 
 // Mesh describes a cascading zipper config.
@@ -41,6 +62,30 @@ type Mesh struct {
 	// It is in the format of 'authType:authPayload', separated by a colon.
 	// If Credential is empty, it represents that mesh will not authenticate the current Zipper.
 	Credential string `yaml:"credential"`
+	// AllowTags is the allowlist of tags that can be forwarded to the mesh zipper.
+	// If AllowTags is empty, all tags are allowed except those in DenyTags.
+	AllowTags []uint32 `yaml:"allow_tags"`
+	// DenyTags is the denylist of tags that cannot be forwarded to the mesh zipper.
+	DenyTags []uint32 `yaml:"deny_tags"`
+	// Replication configures batching, compression and scheduling of the link to the mesh zipper.
+	// If Replication is nil, the frames are forwarded as they arrive.
+	Replication *Replication `yaml:"replication"`
+}
+
+// Replication is the config of the link between zippers.
+type Replication struct {
+	// BatchSize is the max number of frames in a batch.
+	BatchSize int `yaml:"batch_size"`
+	// BatchDelay is the max delay before a batch is flushed, e.g. "10ms".
+	BatchDelay time.Duration `yaml:"batch_delay"`
+	// Compress compresses the payload with gzip.
+	Compress bool `yaml:"compress"`
+	// LowPriorityTags are the tags that are deferred to the off-peak hours.
+	LowPriorityTags []uint32 `yaml:"low_priority_tags"`
+	// OffPeakHours are the hours (0-23, local time) that the low priority tags are replicated.
+	OffPeakHours []int `yaml:"off_peak_hours"`
+	// MaxDeferred bounds the number of frames waiting for the off-peak hours.
+	MaxDeferred int `yaml:"max_deferred"`
 }
 
 // ErrConfigExt represents the extension of config file is incorrect.
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -24,6 +26,32 @@ func TestParseConfigFile(t *testing.T) {
 		assert.Equal(t, "0.0.0.0", conf.Host)
 
 		assert.Equal(t, 9000, conf.Port)
+		assert.Empty(t, conf.Codec)
+	})
+	t.Run("codec", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("name: zipper\nhost: 0.0.0.0\nport: 9000\ncodec: cbor\n"), 0o644))
+
+		conf, err := ParseConfigFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, "cbor", conf.Codec)
+	})
+	t.Run("dispatch", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte(`
+name: zipper
+host: 0.0.0.0
+port: 9000
+dispatch:
+  policy: round_robin
+  tags:
+    0x33: sticky
+  sticky_ttl: 5m
+`), 0o644))
+
+		conf, err := ParseConfigFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, &Dispatch{Policy: "round_robin", Tags: map[uint32]string{0x33: "sticky"}, StickyTTL: 5 * time.Minute}, conf.Dispatch)
 	})
 }
 
@@ -0,0 +1,107 @@
+// Package envelope standardizes the metadata fields for request correlation,
+// so that independent sources and stream functions can build interoperable request/reply flows.
+//
+// In source:
+//
+//	env := envelope.New(0x34, "application/json")
+//	source.WriteWithEnvelope(0x33, data, env)
+//
+// In stream function:
+//
+//	func Handler(ctx serverless.Context) {
+//		envelope.Reply(ctx, result)
+//	}
+package envelope
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/pkg/id"
+	"github.com/yomorun/yomo/serverless"
+)
+
+const (
+	// CorrelationIDKey is the metadata key of correlation ID.
+	CorrelationIDKey = "yomo-correlation-id"
+	// ReplyToKey is the metadata key of the tag that the reply should be written to.
+	ReplyToKey = "yomo-reply-to"
+	// ContentTypeKey is the metadata key of the content type of payload.
+	ContentTypeKey = "yomo-content-type"
+)
+
+// ErrNoReplyTo is returned by Reply if the incoming data has no reply-to tag.
+var ErrNoReplyTo = errors.New("envelope: no reply-to tag")
+
+// Envelope holds the standardized fields for request correlation.
+type Envelope struct {
+	// CorrelationID correlates the request and the reply.
+	CorrelationID string
+	// ReplyTo is the tag that the reply should be written to, it is valid only if HasReplyTo is true.
+	ReplyTo uint32
+	// HasReplyTo reports whether the ReplyTo is set.
+	HasReplyTo bool
+	// ContentType is the content type of payload, eg. `application/json`.
+	ContentType string
+}
+
+// New returns an Envelope with a new correlation ID.
+func New(replyTo uint32, contentType string) Envelope {
+	return Envelope{
+		CorrelationID: id.New(),
+		ReplyTo:       replyTo,
+		HasReplyTo:    true,
+		ContentType:   contentType,
+	}
+}
+
+// Metadata returns the metadata that carries the envelope, the empty fields are omitted.
+func (e Envelope) Metadata() metadata.M {
+	md := metadata.M{}
+	if e.CorrelationID != "" {
+		md.Set(CorrelationIDKey, e.CorrelationID)
+	}
+	if e.HasReplyTo {
+		md.Set(ReplyToKey, strconv.FormatUint(uint64(e.ReplyTo), 10))
+	}
+	if e.ContentType != "" {
+		md.Set(ContentTypeKey, e.ContentType)
+	}
+	return md
+}
+
+// From returns the envelope carried by the metadata.
+func From(md metadata.M) Envelope {
+	return from(md.Get)
+}
+
+// FromContext returns the envelope of the incoming data of the sfn handler.
+func FromContext(ctx serverless.Context) Envelope {
+	return from(ctx.Metadata)
+}
+
+func from(get func(string) (string, bool)) Envelope {
+	var e Envelope
+
+	e.CorrelationID, _ = get(CorrelationIDKey)
+	e.ContentType, _ = get(ContentTypeKey)
+	if v, ok := get(ReplyToKey); ok {
+		if tag, err := strconv.ParseUint(v, 10, 32); err == nil {
+			e.ReplyTo = uint32(tag)
+			e.HasReplyTo = true
+		}
+	}
+
+	return e
+}
+
+// Reply writes the data to the reply-to tag of the incoming data, the correlation ID
+// is carried by the metadata of the reply.
+func Reply(ctx serverless.Context, data []byte) error {
+	e := FromContext(ctx)
+	if !e.HasReplyTo {
+		return ErrNoReplyTo
+	}
+	return ctx.Write(e.ReplyTo, data)
+}
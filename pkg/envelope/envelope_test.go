@@ -0,0 +1,41 @@
+package envelope
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/serverless/testkit"
+)
+
+func TestEnvelope(t *testing.T) {
+	env := New(0x34, "application/json")
+	assert.NotEmpty(t, env.CorrelationID)
+
+	md := env.Metadata()
+	assert.Equal(t, metadata.M{
+		CorrelationIDKey: env.CorrelationID,
+		ReplyToKey:       "52",
+		ContentTypeKey:   "application/json",
+	}, md)
+	assert.Equal(t, env, From(md))
+
+	assert.Equal(t, metadata.M{}, Envelope{}.Metadata())
+	assert.Equal(t, Envelope{}, From(metadata.M{ReplyToKey: "not-a-tag"}))
+}
+
+func TestReply(t *testing.T) {
+	env := New(0x34, "text/plain")
+
+	ctx := testkit.NewContext(0x33, []byte("ping"), testkit.WithMetadata(env.Metadata()))
+	assert.Equal(t, env, FromContext(ctx))
+
+	assert.NoError(t, Reply(ctx, []byte("pong")))
+	ctx.AssertWrote(t, 0x34, []byte("pong"))
+
+	written := ctx.Written()
+	assert.Equal(t, env, From(written[0].Metadata))
+
+	ctx = testkit.NewContext(0x33, []byte("ping"))
+	assert.Equal(t, ErrNoReplyTo, Reply(ctx, []byte("pong")))
+}
@@ -0,0 +1,408 @@
+package cborcodec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// the major types of CBOR, see RFC 8949 section 3.1.
+const (
+	majorUint   byte = 0
+	majorNegInt byte = 1
+	majorBytes  byte = 2
+	majorText   byte = 3
+	majorArray  byte = 4
+	majorMap    byte = 5
+	majorTag    byte = 6
+	majorSimple byte = 7
+)
+
+// maxNestingDepth is the max depth of the nested arrays and maps that are skipped,
+// the frames themselves nest two levels at most.
+const maxNestingDepth = 16
+
+var (
+	// errTruncated is returned if the data ends before the length declared in it.
+	errTruncated = errors.New("cborcodec: truncated frame")
+	// errMalformed is returned if the data is not a valid CBOR item of the expected type.
+	errMalformed = errors.New("cborcodec: malformed frame")
+)
+
+// appendHead appends the head of a CBOR item, the argument is encoded in the shortest form,
+// so the encoding is deterministic.
+func appendHead(dst []byte, major byte, n uint64) []byte {
+	m := major << 5
+	switch {
+	case n < 24:
+		return append(dst, m|byte(n))
+	case n <= math.MaxUint8:
+		return append(dst, m|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, m|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(dst, m|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(dst, m|27), n)
+	}
+}
+
+func appendUint(dst []byte, v uint64) []byte { return appendHead(dst, majorUint, v) }
+
+func appendInt(dst []byte, v int64) []byte {
+	if v >= 0 {
+		return appendHead(dst, majorUint, uint64(v))
+	}
+	return appendHead(dst, majorNegInt, uint64(-1-v))
+}
+
+func appendBytes(dst []byte, b []byte) []byte {
+	return append(appendHead(dst, majorBytes, uint64(len(b))), b...)
+}
+
+func appendText(dst []byte, s string) []byte {
+	return append(appendHead(dst, majorText, uint64(len(s))), s...)
+}
+
+func appendTags(dst []byte, tags []frame.Tag) []byte {
+	dst = appendHead(dst, majorArray, uint64(len(tags)))
+	for _, tag := range tags {
+		dst = appendUint(dst, uint64(tag))
+	}
+	return dst
+}
+
+func appendTexts(dst []byte, ss []string) []byte {
+	dst = appendHead(dst, majorArray, uint64(len(ss)))
+	for _, s := range ss {
+		dst = appendText(dst, s)
+	}
+	return dst
+}
+
+// appendExtensions appends the extensions as a map of text to bytes, the keys are in ascending order.
+func appendExtensions(dst []byte, extensions map[string][]byte) []byte {
+	keys := make([]string, 0, len(extensions))
+	for key := range extensions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	dst = appendHead(dst, majorMap, uint64(len(keys)))
+	for _, key := range keys {
+		dst = appendText(dst, key)
+		dst = appendBytes(dst, extensions[key])
+	}
+	return dst
+}
+
+// appendSchemaVersions appends the schema versions as a map of tag to texts, the tags are in ascending order.
+func appendSchemaVersions(dst []byte, versions map[frame.Tag][]string) []byte {
+	tags := make([]frame.Tag, 0, len(versions))
+	for tag := range versions {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+
+	dst = appendHead(dst, majorMap, uint64(len(tags)))
+	for _, tag := range tags {
+		dst = appendUint(dst, uint64(tag))
+		dst = appendTexts(dst, versions[tag])
+	}
+	return dst
+}
+
+// decoder decodes the CBOR items from the data, the decoded bytes refer to the data without copying.
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+// head decodes the head of the next item, the indefinite length items are not supported.
+func (d *decoder) head() (byte, uint64, error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, errTruncated
+	}
+	major, info := d.data[d.pos]>>5, d.data[d.pos]&0x1F
+	d.pos++
+
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("%w: additional info %d", errMalformed, info)
+	}
+	if d.pos+size > len(d.data) {
+		return 0, 0, errTruncated
+	}
+	var n uint64
+	for _, b := range d.data[d.pos : d.pos+size] {
+		n = n<<8 | uint64(b)
+	}
+	d.pos += size
+	return major, n, nil
+}
+
+// expect decodes the head of the next item, it must be of the major type.
+func (d *decoder) expect(major byte) (uint64, error) {
+	m, n, err := d.head()
+	if err != nil {
+		return 0, err
+	}
+	if m != major {
+		return 0, fmt.Errorf("%w: major type %d, want %d", errMalformed, m, major)
+	}
+	return n, nil
+}
+
+func (d *decoder) uint(max uint64) (uint64, error) {
+	n, err := d.expect(majorUint)
+	if err != nil {
+		return 0, err
+	}
+	if n > max {
+		return 0, fmt.Errorf("%w: %d overflows %d", errMalformed, n, max)
+	}
+	return n, nil
+}
+
+func (d *decoder) int(min, max int64) (int64, error) {
+	m, n, err := d.head()
+	if err != nil {
+		return 0, err
+	}
+	var v int64
+	switch {
+	case m == majorUint && n <= math.MaxInt64:
+		v = int64(n)
+	case m == majorNegInt && n <= math.MaxInt64:
+		v = -1 - int64(n)
+	default:
+		return 0, fmt.Errorf("%w: not an integer", errMalformed)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("%w: %d is out of range [%d, %d]", errMalformed, v, min, max)
+	}
+	return v, nil
+}
+
+// length decodes the head of a string, an array or a map, n is the min size of an element,
+// so a bogus length is rejected before anything is allocated for it.
+func (d *decoder) length(major byte, n int) (int, error) {
+	l, err := d.expect(major)
+	if err != nil {
+		return 0, err
+	}
+	if l > uint64(len(d.data)-d.pos)/uint64(n) {
+		return 0, errTruncated
+	}
+	return int(l), nil
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.length(majorBytes, 1)
+	if err != nil {
+		return nil, err
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	if n == 0 {
+		return nil, nil
+	}
+	return b, nil
+}
+
+func (d *decoder) text() (string, error) {
+	n, err := d.length(majorText, 1)
+	if err != nil {
+		return "", err
+	}
+	s := string(d.data[d.pos : d.pos+n])
+	d.pos += n
+	return s, nil
+}
+
+func (d *decoder) tags() ([]frame.Tag, error) {
+	n, err := d.length(majorArray, 1)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	tags := make([]frame.Tag, n)
+	for i := range tags {
+		tag, err := d.uint(math.MaxUint32)
+		if err != nil {
+			return nil, err
+		}
+		tags[i] = frame.Tag(tag)
+	}
+	return tags, nil
+}
+
+func (d *decoder) texts() ([]string, error) {
+	n, err := d.length(majorArray, 1)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	ss := make([]string, n)
+	for i := range ss {
+		if ss[i], err = d.text(); err != nil {
+			return nil, err
+		}
+	}
+	return ss, nil
+}
+
+func (d *decoder) extensions() (map[string][]byte, error) {
+	n, err := d.length(majorMap, 2)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	extensions := make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		key, err := d.text()
+		if err != nil {
+			return nil, err
+		}
+		if extensions[key], err = d.bytes(); err != nil {
+			return nil, err
+		}
+	}
+	return extensions, nil
+}
+
+func (d *decoder) schemaVersions() (map[frame.Tag][]string, error) {
+	n, err := d.length(majorMap, 2)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	versions := make(map[frame.Tag][]string, n)
+	for i := 0; i < n; i++ {
+		tag, err := d.uint(math.MaxUint32)
+		if err != nil {
+			return nil, err
+		}
+		if versions[frame.Tag(tag)], err = d.texts(); err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+// skip skips the next item, it is used to skip the fields that the decoder doesn't know,
+// so the newer peers can add fields without breaking the older ones.
+func (d *decoder) skip(depth int) error {
+	if depth > maxNestingDepth {
+		return fmt.Errorf("%w: nested too deep", errMalformed)
+	}
+	m, n, err := d.head()
+	if err != nil {
+		return err
+	}
+	switch m {
+	case majorBytes, majorText:
+		if n > uint64(len(d.data)-d.pos) {
+			return errTruncated
+		}
+		d.pos += int(n)
+	case majorArray, majorMap:
+		if m == majorMap {
+			if n > math.MaxUint64/2 {
+				return errTruncated
+			}
+			n *= 2
+		}
+		if n > uint64(len(d.data)-d.pos) {
+			return errTruncated
+		}
+		for i := uint64(0); i < n; i++ {
+			if err := d.skip(depth + 1); err != nil {
+				return err
+			}
+		}
+	case majorTag:
+		return d.skip(depth + 1)
+	}
+	return nil
+}
+
+// fields decodes a frame body, it is a map of the field keys to the values, fn decodes the value
+// of the key and reports whether it knows the key, the values of the unknown keys are skipped.
+func (d *decoder) fields(fn func(key uint64) (bool, error)) error {
+	n, err := d.length(majorMap, 2)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		key, err := d.uint(math.MaxUint64)
+		if err != nil {
+			return err
+		}
+		known, err := fn(key)
+		if err != nil {
+			return fmt.Errorf("field 0x%02x: %w", key, err)
+		}
+		if !known {
+			if err := d.skip(0); err != nil {
+				return err
+			}
+		}
+	}
+	if d.pos != len(d.data) {
+		return fmt.Errorf("%w: %d trailing bytes", errMalformed, len(d.data)-d.pos)
+	}
+	return nil
+}
+
+// readHead reads the head of a CBOR item from the reader, io.EOF is returned as is
+// if the reader ends before the head.
+func readHead(r io.Reader) (byte, uint64, error) {
+	var buf [9]byte
+	if _, err := io.ReadFull(r, buf[:1]); err != nil {
+		return 0, 0, err
+	}
+	size := 0
+	switch info := buf[0] & 0x1F; {
+	case info < 24:
+		return buf[0] >> 5, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("%w: additional info %d", errMalformed, info)
+	}
+	if _, err := io.ReadFull(r, buf[1:1+size]); err != nil {
+		return 0, 0, unexpectedEOF(err)
+	}
+	var n uint64
+	for _, b := range buf[1 : 1+size] {
+		n = n<<8 | uint64(b)
+	}
+	return buf[0] >> 5, n, nil
+}
+
+// unexpectedEOF reports the packet truncated by the end of stream as truncated.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return errTruncated
+	}
+	return err
+}
@@ -0,0 +1,210 @@
+// Package cborcodec provides the CBOR (RFC 8949) implement of frame.PacketReadWriter/frame.Codec,
+// it is for the devices that already speak CBOR, e.g. the embedded C clients built on tinycbor.
+//
+// A packet is a CBOR sequence (RFC 8742) of two items, the frame type as an unsigned integer and
+// the frame body as a byte string. The body is a map of the field keys to the values, the keys are
+// the same as the y3 tags of the fields, the zero values are omitted and the unknown keys are skipped.
+// For example, the GoodbyeFrame{Message: "bye"} is packed as:
+//
+//	18 2f             # unsigned(0x2f), TypeGoodbyeFrame
+//	46                # bytes(6), the body
+//	   a1             #   map(1)
+//	      01          #     unsigned(1), Message
+//	      63 627965   #     text("bye")
+//
+// The body of an ExtensionFrame is opaque, it is carried as is.
+package cborcodec
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+// ErrUnknownFrame is returned when unknown frame is received.
+var ErrUnknownFrame = yerr.New(yerr.CodeProtocol, "cborcodec: unknown frame")
+
+// Name is the name that the CBOR codec is registered by, the package is imported for its
+// registration only to select the codec by name, e.g. `core.WithCodecName(cborcodec.Name)`.
+const Name = "cbor"
+
+func init() {
+	frame.RegisterCodec(Name, Codec(), PacketReadWriter())
+}
+
+// Option is the option of the CBOR codec.
+type Option func(*cborCodec)
+
+// WithLimits sets the limits of the decoded frames, the zero fields take the values of frame.DefaultLimits.
+func WithLimits(limits frame.Limits) Option {
+	return func(c *cborCodec) {
+		c.limits = limits.OrDefault()
+	}
+}
+
+func newCodec(opts ...Option) *cborCodec {
+	c := &cborCodec{limits: frame.DefaultLimits}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+type packetReadWriter struct {
+	maxPacketSize int
+}
+
+// PacketReadWriter returns the CBOR implement of frame.PacketReadWriter, the packets are bounded by
+// the MaxPacketSize of the limits, see WithLimits.
+func PacketReadWriter(opts ...Option) frame.PacketReadWriter {
+	c := newCodec(opts...)
+	return &packetReadWriter{maxPacketSize: c.limits.MaxPacketSize}
+}
+
+// ReadPacket reads a packet from the stream, the returned body is owned by the caller.
+// The body larger than MaxPacketSize is discarded without being buffered, and *frame.ErrMalformed
+// is returned, the stream can be read further.
+func (pr *packetReadWriter) ReadPacket(stream io.Reader) (frame.Type, []byte, error) {
+	major, ftyp, err := readHead(stream)
+	if err != nil {
+		return 0, nil, err
+	}
+	if major != majorUint || ftyp > math.MaxUint8 {
+		return 0, nil, fmt.Errorf("%w: invalid frame type", errMalformed)
+	}
+
+	major, size, err := readHead(stream)
+	if err != nil {
+		return 0, nil, unexpectedEOF(err)
+	}
+	if major != majorBytes || size > math.MaxInt32 {
+		return 0, nil, fmt.Errorf("%w: invalid frame body", errMalformed)
+	}
+
+	if size > uint64(pr.maxPacketSize) {
+		if _, err := io.CopyN(io.Discard, stream, int64(size)); err != nil {
+			return 0, nil, unexpectedEOF(err)
+		}
+		return 0, nil, frame.NewErrMalformed(frame.Type(ftyp),
+			fmt.Errorf("cborcodec: packet is too large: %d > %d", size, pr.maxPacketSize))
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(stream, buf); err != nil {
+		return 0, nil, unexpectedEOF(err)
+	}
+	return frame.Type(ftyp), buf, nil
+}
+
+// WritePacket writes the frame type and the body encoded by the codec to the stream.
+func (pr *packetReadWriter) WritePacket(stream io.Writer, ftyp frame.Type, data []byte) error {
+	buf := make([]byte, 0, 2+9+len(data))
+	buf = appendUint(buf, uint64(ftyp))
+	buf = appendBytes(buf, data)
+
+	_, err := stream.Write(buf)
+	return err
+}
+
+type cborCodec struct {
+	limits frame.Limits
+}
+
+// Codec returns the CBOR implement of frame.Codec.
+func Codec(opts ...Option) frame.Codec {
+	return newCodec(opts...)
+}
+
+// Encode encodes the frame to its body, the frame type is written by the PacketReadWriter.
+func (c *cborCodec) Encode(f frame.Frame) ([]byte, error) {
+	switch ff := f.(type) {
+	case *frame.DataFrame:
+		return encodeDataFrame(ff), nil
+	case *frame.HandshakeFrame:
+		return encodeHandshakeFrame(ff), nil
+	case *frame.HandshakeAckFrame:
+		return encodeHandshakeAckFrame(ff), nil
+	case *frame.RejectedFrame:
+		return encodeRejectedFrame(ff), nil
+	case *frame.GoawayFrame:
+		return encodeGoawayFrame(ff), nil
+	case *frame.ConnectToFrame:
+		return encodeConnectToFrame(ff), nil
+	case *frame.PingFrame:
+		return encodePayloadFrame(ff.Payload), nil
+	case *frame.PongFrame:
+		return encodePayloadFrame(ff.Payload), nil
+	case *frame.ObserveUpdateFrame:
+		return encodeObserveUpdateFrame(ff), nil
+	case *frame.GoodbyeFrame:
+		return encodeGoodbyeFrame(ff), nil
+	case *frame.AckFrame:
+		return encodeAckFrame(ff), nil
+	case *frame.ErrorFrame:
+		return encodeErrorFrame(ff), nil
+	case *frame.RequestFrame:
+		return encodeRequestFrame(ff), nil
+	case *frame.ResponseFrame:
+		return encodeResponseFrame(ff), nil
+	case *frame.ExtensionFrame:
+		if frame.IsBuiltin(ff.FrameType) {
+			return nil, fmt.Errorf("cborcodec: invalid extension frame type: 0x%02x", byte(ff.FrameType))
+		}
+		return ff.Body, nil
+	default:
+		return nil, ErrUnknownFrame
+	}
+}
+
+// Decode decodes the body to the frame, the bytes of the frame refer to the data without copying.
+// It returns *frame.ErrMalformed if the body cannot be decoded, or if the frame exceeds the limits.
+func (c *cborCodec) Decode(data []byte, f frame.Frame) error {
+	if ff, ok := f.(*frame.ExtensionFrame); ok {
+		if len(data) > 0 {
+			ff.Body = data
+		}
+		return nil
+	}
+
+	d := &decoder{data: data}
+	var err error
+	switch ff := f.(type) {
+	case *frame.DataFrame:
+		err = decodeDataFrame(d, ff)
+	case *frame.HandshakeFrame:
+		err = decodeHandshakeFrame(d, ff)
+	case *frame.HandshakeAckFrame:
+		err = decodeHandshakeAckFrame(d, ff)
+	case *frame.RejectedFrame:
+		err = decodeRejectedFrame(d, ff)
+	case *frame.GoawayFrame:
+		err = decodeGoawayFrame(d, ff)
+	case *frame.ConnectToFrame:
+		err = decodeConnectToFrame(d, ff)
+	case *frame.PingFrame:
+		err = decodePayloadFrame(d, &ff.Payload)
+	case *frame.PongFrame:
+		err = decodePayloadFrame(d, &ff.Payload)
+	case *frame.ObserveUpdateFrame:
+		err = decodeObserveUpdateFrame(d, ff)
+	case *frame.GoodbyeFrame:
+		err = decodeGoodbyeFrame(d, ff)
+	case *frame.AckFrame:
+		err = decodeAckFrame(d, ff)
+	case *frame.ErrorFrame:
+		err = decodeErrorFrame(d, ff)
+	case *frame.RequestFrame:
+		err = decodeRequestFrame(d, ff)
+	case *frame.ResponseFrame:
+		err = decodeResponseFrame(d, ff)
+	default:
+		return ErrUnknownFrame
+	}
+	if err != nil {
+		return frame.NewErrMalformed(f.Type(), fmt.Errorf("cborcodec: decode %s: %w", f.Type(), err))
+	}
+	return c.limits.Check(f)
+}
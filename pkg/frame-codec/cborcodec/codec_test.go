@@ -0,0 +1,225 @@
+package cborcodec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+func TestCodec(t *testing.T) {
+	tests := []frame.Frame{
+		&frame.DataFrame{
+			Tag:         0x15,
+			Metadata:    []byte("metadata"),
+			Payload:     []byte("yomo"),
+			Priority:    frame.PriorityLow,
+			Channel:     7,
+			Chunk:       frame.Chunk{ID: 1, Seq: 2, Total: 3},
+			AckID:       math.MaxUint64,
+			Compression: frame.CompressionGzip,
+			Extensions:  map[uint8][]byte{0x01: []byte("ttl"), 0x80: nil},
+		},
+		&frame.DataFrame{Tag: 1},
+		&frame.HandshakeFrame{
+			Name:            "sfn",
+			ID:              "id",
+			ClientType:      0x5D,
+			ObserveDataTags: []frame.Tag{1, 300, math.MaxUint32},
+			AuthName:        "token",
+			AuthPayload:     "secret",
+			Version:         "2023-11-01",
+			ProtocolVersion: 3,
+			Compressions:    []string{"zstd", "gzip"},
+			SchemaVersions:  map[frame.Tag][]string{1: {"v1", "v2"}, 300: {"v3"}},
+			Extensions:      map[string][]byte{"group": []byte("a"), "caps": []byte("b")},
+		},
+		&frame.HandshakeAckFrame{Compression: "zstd", Extensions: map[string][]byte{"group": []byte("ok")}, ProtocolVersion: 2},
+		&frame.HandshakeAckFrame{},
+		&frame.RejectedFrame{Message: "rejected", Reason: "auth_expired"},
+		&frame.GoawayFrame{Message: "goaway", Reason: "server_draining"},
+		&frame.ConnectToFrame{Endpoint: "localhost:9001"},
+		&frame.PingFrame{Payload: []byte("ping")},
+		&frame.PongFrame{Payload: []byte("pong")},
+		&frame.ObserveUpdateFrame{Subscribe: []frame.Tag{1, 2}, Unsubscribe: []frame.Tag{3}},
+		&frame.GoodbyeFrame{Message: "bye"},
+		&frame.AckFrame{ID: 1 << 40},
+		&frame.ErrorFrame{SourceID: "source", TID: "tid", Tag: 0x33, Code: 1, Message: "no observer"},
+		&frame.RequestFrame{ID: "id", Tag: 0x33, ReplyTo: 0x34, Deadline: 1700000000000, Metadata: []byte("metadata"), Payload: []byte("yomo")},
+		&frame.RequestFrame{ID: "id", Deadline: -1},
+		&frame.ResponseFrame{ID: "id", SourceID: "source", Payload: []byte("result")},
+		&frame.ResponseFrame{ID: "id", Code: 2, Message: "panic"},
+		&frame.ExtensionFrame{FrameType: 0x50, Body: []byte("opaque")},
+	}
+
+	codec := Codec()
+	prw := PacketReadWriter()
+	for _, f := range tests {
+		t.Run(f.Type().String(), func(t *testing.T) {
+			data, err := codec.Encode(f)
+			assert.NoError(t, err)
+
+			var stream bytes.Buffer
+			assert.NoError(t, prw.WritePacket(&stream, f.Type(), data))
+
+			ftyp, body, err := prw.ReadPacket(&stream)
+			assert.NoError(t, err)
+			assert.Equal(t, f.Type(), ftyp)
+			assert.Equal(t, data, body)
+
+			decoded, _ := frame.NewFrame(ftyp)
+			assert.NoError(t, codec.Decode(body, decoded))
+			assert.Equal(t, f, decoded)
+		})
+	}
+}
+
+func TestPacket(t *testing.T) {
+	codec := Codec()
+	prw := PacketReadWriter()
+
+	data, err := codec.Encode(&frame.GoodbyeFrame{Message: "bye"})
+	assert.NoError(t, err)
+
+	var stream bytes.Buffer
+	assert.NoError(t, prw.WritePacket(&stream, frame.TypeGoodbyeFrame, data))
+	// the example in the package doc.
+	assert.Equal(t, []byte{0x18, 0x2f, 0x46, 0xa1, 0x01, 0x63, 'b', 'y', 'e'}, stream.Bytes())
+
+	packet := stream.Bytes()
+	for i := 1; i < len(packet); i++ {
+		_, _, err := prw.ReadPacket(bytes.NewReader(packet[:i]))
+		assert.ErrorIs(t, err, errTruncated, "truncated at %d", i)
+	}
+
+	_, _, err = prw.ReadPacket(bytes.NewReader(nil))
+	assert.Equal(t, io.EOF, err)
+
+	// the frame type must be an unsigned integer.
+	_, _, err = prw.ReadPacket(bytes.NewReader([]byte{0x63, 'b', 'y', 'e'}))
+	assert.ErrorIs(t, err, errMalformed)
+	// the body must be a byte string.
+	_, _, err = prw.ReadPacket(bytes.NewReader([]byte{0x18, 0x2f, 0x63, 'b', 'y', 'e'}))
+	assert.ErrorIs(t, err, errMalformed)
+	// the bogus length is rejected before allocating.
+	_, _, err = prw.ReadPacket(bytes.NewReader([]byte{0x18, 0x2f, 0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}))
+	assert.ErrorIs(t, err, errMalformed)
+}
+
+func TestDecode(t *testing.T) {
+	codec := Codec()
+
+	t.Run("unknown fields are skipped", func(t *testing.T) {
+		data := []byte{
+			0xa3,                      // map(3)
+			0x01, 0x63, 'b', 'y', 'e', // Message: "bye"
+			0x18, 0x20, 0x82, 0x01, 0xa1, 0x01, 0x40, // 0x20: [1, {1: h''}]
+			0x18, 0x21, 0xc1, 0x1a, 0x00, 0x00, 0x00, 0x01, // 0x21: tag 1(1)
+		}
+		f := new(frame.GoodbyeFrame)
+		assert.NoError(t, codec.Decode(data, f))
+		assert.Equal(t, "bye", f.Message)
+	})
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, errTruncated},
+		{"not a map", []byte{0x80}, errMalformed},
+		{"truncated map", []byte{0xa2, 0x01, 0x63, 'b', 'y', 'e'}, errTruncated},
+		{"truncated text", []byte{0xa1, 0x01, 0x63, 'b', 'y'}, errTruncated},
+		{"wrong type", []byte{0xa1, 0x01, 0x03}, errMalformed},
+		{"trailing bytes", []byte{0xa0, 0x00}, errMalformed},
+		{"indefinite length", []byte{0xbf, 0xff}, errMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := codec.Decode(tt.data, new(frame.GoodbyeFrame))
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, yerr.CodeProtocol, yerr.CodeOf(err))
+		})
+	}
+
+	t.Run("overflow", func(t *testing.T) {
+		// priority 200 overflows int8.
+		err := codec.Decode([]byte{0xa1, 0x04, 0x18, 0xc8}, new(frame.DataFrame))
+		assert.ErrorIs(t, err, errMalformed)
+		// client type 256 overflows byte.
+		err = codec.Decode([]byte{0xa1, 0x02, 0x19, 0x01, 0x00}, new(frame.HandshakeFrame))
+		assert.ErrorIs(t, err, errMalformed)
+	})
+
+	t.Run("nested too deep", func(t *testing.T) {
+		data := []byte{0xa1, 0x18, 0x20}
+		for i := 0; i < 2*maxNestingDepth; i++ {
+			data = append(data, 0x81)
+		}
+		data = append(data, 0x00)
+		assert.ErrorIs(t, codec.Decode(data, new(frame.GoodbyeFrame)), errMalformed)
+	})
+
+	t.Run("unknown frame", func(t *testing.T) {
+		_, err := codec.Encode(&frame.ExtensionFrame{FrameType: frame.TypeDataFrame})
+		assert.Error(t, err)
+		assert.True(t, errors.Is(codec.Decode([]byte{0xa0}, nil), ErrUnknownFrame))
+	})
+}
+
+func TestDecodeGarbage(t *testing.T) {
+	codec := Codec()
+	types := []frame.Type{
+		frame.TypeDataFrame, frame.TypeHandshakeFrame, frame.TypeHandshakeAckFrame, frame.TypeRejectedFrame,
+		frame.TypeGoawayFrame, frame.TypeConnectToFrame, frame.TypePingFrame, frame.TypePongFrame,
+		frame.TypeObserveUpdateFrame, frame.TypeGoodbyeFrame, frame.TypeAckFrame, frame.TypeErrorFrame,
+		frame.TypeRequestFrame, frame.TypeResponseFrame,
+	}
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 20000; i++ {
+		b := make([]byte, 1+r.Intn(30))
+		r.Read(b)
+		// the body is a small map mostly, so the garbage reaches the fields.
+		if r.Intn(2) == 0 {
+			b[0] = 0xa0 | byte(r.Intn(4))
+		}
+
+		f, _ := frame.NewFrame(types[r.Intn(len(types))])
+		if err := codec.Decode(b, f); err != nil {
+			me := new(frame.ErrMalformed)
+			assert.True(t, errors.As(err, &me), "%x: %v", b, err)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	limits := WithLimits(frame.Limits{MaxPacketSize: 16, MaxStringSize: 4})
+
+	data, err := Codec().Encode(&frame.GoodbyeFrame{Message: "goodbye"})
+	assert.NoError(t, err)
+	err = Codec(limits).Decode(data, new(frame.GoodbyeFrame))
+	me := new(frame.ErrMalformed)
+	assert.True(t, errors.As(err, &me))
+	assert.Equal(t, "frame: GoodbyeFrame: message is too large: 7 > 4", err.Error())
+
+	// the oversized packet is discarded, and the next one is read.
+	prw := PacketReadWriter(limits)
+	var stream bytes.Buffer
+	assert.NoError(t, prw.WritePacket(&stream, frame.TypeDataFrame, make([]byte, 17)))
+	assert.NoError(t, prw.WritePacket(&stream, frame.TypeGoodbyeFrame, data))
+
+	_, _, err = prw.ReadPacket(&stream)
+	assert.True(t, errors.As(err, &me))
+	assert.Equal(t, frame.TypeDataFrame, me.FrameType)
+	ftyp, body, err := prw.ReadPacket(&stream)
+	assert.NoError(t, err)
+	assert.Equal(t, frame.TypeGoodbyeFrame, ftyp)
+	assert.Equal(t, data, body)
+}
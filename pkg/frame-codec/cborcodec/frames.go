@@ -0,0 +1,503 @@
+package cborcodec
+
+import (
+	"math"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// the field keys of the frames, they are the same as the y3 tags.
+const (
+	keyDataFrameTag      = 0x01
+	keyDataFramePayload  = 0x02
+	keyDataFrameMetadata = 0x03
+	keyDataFramePriority = 0x04
+	keyDataFrameChannel  = 0x05
+	keyDataFrameChunk    = 0x07
+	keyDataFrameAckID    = 0x08
+
+	keyDataFrameCompression = 0x09
+	keyDataFrameExtensions  = 0x0A
+
+	keyHandshakeName            = 0x01
+	keyHandshakeClientType      = 0x02
+	keyHandshakeID              = 0x03
+	keyAuthenticationName       = 0x04
+	keyAuthenticationPayload    = 0x05
+	keyHandshakeObserveDataTags = 0x06
+	keyHandshakeVersion         = 0x07
+	keyHandshakeCompressions    = 0x08
+	keyHandshakeSchemaVersions  = 0x09
+	keyHandshakeExtensions      = 0x0A
+	keyHandshakeProtocolVersion = 0x0B
+
+	keyHandshakeAckCompression     = 0x01
+	keyHandshakeAckExtensions      = 0x02
+	keyHandshakeAckProtocolVersion = 0x03
+
+	keyMessage = 0x01
+	keyReason  = 0x02
+
+	keyConnectToEndpoint        = 0x01
+	keyPingPayload              = 0x01
+	keyObserveUpdateSubscribe   = 0x01
+	keyObserveUpdateUnsubscribe = 0x02
+	keyAckID                    = 0x01
+
+	keyErrorSourceID = 0x01
+	keyErrorTID      = 0x02
+	keyErrorTag      = 0x03
+	keyErrorCode     = 0x04
+	keyErrorMessage  = 0x05
+
+	keyRequestID       = 0x01
+	keyRequestTag      = 0x02
+	keyRequestReplyTo  = 0x03
+	keyRequestDeadline = 0x04
+	keyRequestMetadata = 0x05
+	keyRequestPayload  = 0x06
+
+	keyResponseID       = 0x01
+	keyResponseSourceID = 0x02
+	keyResponsePayload  = 0x03
+	keyResponseCode     = 0x04
+	keyResponseMessage  = 0x05
+)
+
+// body builds the map of a frame body, the fields are appended in ascending order of the keys.
+type body struct {
+	n      uint64
+	fields []byte
+}
+
+func (b *body) uint(key uint64, v uint64) {
+	if v != 0 {
+		b.n++
+		b.fields = appendUint(appendUint(b.fields, key), v)
+	}
+}
+
+func (b *body) int(key uint64, v int64) {
+	if v != 0 {
+		b.n++
+		b.fields = appendInt(appendUint(b.fields, key), v)
+	}
+}
+
+func (b *body) bytes(key uint64, v []byte) {
+	if len(v) != 0 {
+		b.n++
+		b.fields = appendBytes(appendUint(b.fields, key), v)
+	}
+}
+
+func (b *body) text(key uint64, v string) {
+	if v != "" {
+		b.n++
+		b.fields = appendText(appendUint(b.fields, key), v)
+	}
+}
+
+func (b *body) tags(key uint64, v []frame.Tag) {
+	if len(v) != 0 {
+		b.n++
+		b.fields = appendTags(appendUint(b.fields, key), v)
+	}
+}
+
+func (b *body) texts(key uint64, v []string) {
+	if len(v) != 0 {
+		b.n++
+		b.fields = appendTexts(appendUint(b.fields, key), v)
+	}
+}
+
+func (b *body) extensions(key uint64, v map[string][]byte) {
+	if len(v) != 0 {
+		b.n++
+		b.fields = appendExtensions(appendUint(b.fields, key), v)
+	}
+}
+
+func (b *body) bytesOf() []byte {
+	buf := make([]byte, 0, 9+len(b.fields))
+	return append(appendHead(buf, majorMap, b.n), b.fields...)
+}
+
+func encodeDataFrame(f *frame.DataFrame) []byte {
+	b := &body{fields: make([]byte, 0, 32+len(f.Metadata)+len(f.Payload))}
+	b.uint(keyDataFrameTag, uint64(f.Tag))
+	b.bytes(keyDataFramePayload, f.Payload)
+	b.bytes(keyDataFrameMetadata, f.Metadata)
+	b.int(keyDataFramePriority, int64(f.Priority))
+	b.uint(keyDataFrameChannel, uint64(f.Channel))
+	if f.IsChunked() {
+		b.n++
+		b.fields = appendHead(appendUint(b.fields, keyDataFrameChunk), majorArray, 3)
+		b.fields = appendUint(b.fields, uint64(f.Chunk.ID))
+		b.fields = appendUint(b.fields, uint64(f.Chunk.Seq))
+		b.fields = appendUint(b.fields, uint64(f.Chunk.Total))
+	}
+	b.uint(keyDataFrameAckID, f.AckID)
+	b.uint(keyDataFrameCompression, uint64(f.Compression))
+	if len(f.Extensions) > 0 {
+		b.bytes(keyDataFrameExtensions, frame.AppendExtensions(nil, f.Extensions))
+	}
+	return b.bytesOf()
+}
+
+func decodeDataFrame(d *decoder, f *frame.DataFrame) error {
+	return d.fields(func(key uint64) (known bool, err error) {
+		var v uint64
+		switch key {
+		case keyDataFrameTag:
+			v, err = d.uint(math.MaxUint32)
+			f.Tag = frame.Tag(v)
+		case keyDataFramePayload:
+			f.Payload, err = d.bytes()
+		case keyDataFrameMetadata:
+			f.Metadata, err = d.bytes()
+		case keyDataFramePriority:
+			var p int64
+			p, err = d.int(math.MinInt8, math.MaxInt8)
+			f.Priority = frame.Priority(p)
+		case keyDataFrameChannel:
+			v, err = d.uint(math.MaxUint32)
+			f.Channel = uint32(v)
+		case keyDataFrameChunk:
+			f.Chunk, err = decodeChunk(d)
+		case keyDataFrameAckID:
+			f.AckID, err = d.uint(math.MaxUint64)
+		case keyDataFrameCompression:
+			v, err = d.uint(math.MaxUint8)
+			f.Compression = frame.Compression(v)
+		case keyDataFrameExtensions:
+			var data []byte
+			if data, err = d.bytes(); err == nil {
+				f.Extensions, err = frame.ParseExtensions(data)
+			}
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+// decodeChunk decodes the chunk as an array of the ID, the Seq and the Total.
+func decodeChunk(d *decoder) (frame.Chunk, error) {
+	var chunk frame.Chunk
+	n, err := d.length(majorArray, 1)
+	if err != nil {
+		return chunk, err
+	}
+	if n != 3 {
+		return chunk, errMalformed
+	}
+	for _, v := range []*uint32{&chunk.ID, &chunk.Seq, &chunk.Total} {
+		u, err := d.uint(math.MaxUint32)
+		if err != nil {
+			return chunk, err
+		}
+		*v = uint32(u)
+	}
+	return chunk, nil
+}
+
+func encodeHandshakeFrame(f *frame.HandshakeFrame) []byte {
+	b := &body{}
+	b.text(keyHandshakeName, f.Name)
+	b.uint(keyHandshakeClientType, uint64(f.ClientType))
+	b.text(keyHandshakeID, f.ID)
+	b.text(keyAuthenticationName, f.AuthName)
+	b.text(keyAuthenticationPayload, f.AuthPayload)
+	b.tags(keyHandshakeObserveDataTags, f.ObserveDataTags)
+	b.text(keyHandshakeVersion, f.Version)
+	b.texts(keyHandshakeCompressions, f.Compressions)
+	if len(f.SchemaVersions) != 0 {
+		b.n++
+		b.fields = appendSchemaVersions(appendUint(b.fields, keyHandshakeSchemaVersions), f.SchemaVersions)
+	}
+	b.extensions(keyHandshakeExtensions, f.Extensions)
+	b.uint(keyHandshakeProtocolVersion, uint64(f.ProtocolVersion))
+	return b.bytesOf()
+}
+
+func decodeHandshakeFrame(d *decoder, f *frame.HandshakeFrame) error {
+	return d.fields(func(key uint64) (known bool, err error) {
+		var v uint64
+		switch key {
+		case keyHandshakeName:
+			f.Name, err = d.text()
+		case keyHandshakeClientType:
+			v, err = d.uint(math.MaxUint8)
+			f.ClientType = byte(v)
+		case keyHandshakeID:
+			f.ID, err = d.text()
+		case keyAuthenticationName:
+			f.AuthName, err = d.text()
+		case keyAuthenticationPayload:
+			f.AuthPayload, err = d.text()
+		case keyHandshakeObserveDataTags:
+			f.ObserveDataTags, err = d.tags()
+		case keyHandshakeVersion:
+			f.Version, err = d.text()
+		case keyHandshakeCompressions:
+			f.Compressions, err = d.texts()
+		case keyHandshakeSchemaVersions:
+			f.SchemaVersions, err = d.schemaVersions()
+		case keyHandshakeExtensions:
+			f.Extensions, err = d.extensions()
+		case keyHandshakeProtocolVersion:
+			v, err = d.uint(math.MaxUint32)
+			f.ProtocolVersion = uint32(v)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func encodeHandshakeAckFrame(f *frame.HandshakeAckFrame) []byte {
+	b := &body{}
+	b.text(keyHandshakeAckCompression, f.Compression)
+	b.extensions(keyHandshakeAckExtensions, f.Extensions)
+	b.uint(keyHandshakeAckProtocolVersion, uint64(f.ProtocolVersion))
+	return b.bytesOf()
+}
+
+func decodeHandshakeAckFrame(d *decoder, f *frame.HandshakeAckFrame) error {
+	return d.fields(func(key uint64) (known bool, err error) {
+		switch key {
+		case keyHandshakeAckCompression:
+			f.Compression, err = d.text()
+		case keyHandshakeAckExtensions:
+			f.Extensions, err = d.extensions()
+		case keyHandshakeAckProtocolVersion:
+			var v uint64
+			v, err = d.uint(math.MaxUint32)
+			f.ProtocolVersion = uint32(v)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func encodeRejectedFrame(f *frame.RejectedFrame) []byte {
+	b := &body{}
+	b.text(keyMessage, f.Message)
+	b.text(keyReason, f.Reason)
+	return b.bytesOf()
+}
+
+func decodeRejectedFrame(d *decoder, f *frame.RejectedFrame) error {
+	return decodeMessage(d, &f.Message, &f.Reason)
+}
+
+func encodeGoawayFrame(f *frame.GoawayFrame) []byte {
+	b := &body{}
+	b.text(keyMessage, f.Message)
+	b.text(keyReason, f.Reason)
+	return b.bytesOf()
+}
+
+func decodeGoawayFrame(d *decoder, f *frame.GoawayFrame) error {
+	return decodeMessage(d, &f.Message, &f.Reason)
+}
+
+func encodeGoodbyeFrame(f *frame.GoodbyeFrame) []byte {
+	b := &body{}
+	b.text(keyMessage, f.Message)
+	return b.bytesOf()
+}
+
+func decodeGoodbyeFrame(d *decoder, f *frame.GoodbyeFrame) error {
+	var reason string
+	return decodeMessage(d, &f.Message, &reason)
+}
+
+// decodeMessage decodes the body of the frames that carry a message and a reason.
+func decodeMessage(d *decoder, message, reason *string) error {
+	return d.fields(func(key uint64) (known bool, err error) {
+		switch key {
+		case keyMessage:
+			*message, err = d.text()
+		case keyReason:
+			*reason, err = d.text()
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func encodeConnectToFrame(f *frame.ConnectToFrame) []byte {
+	b := &body{}
+	b.text(keyConnectToEndpoint, f.Endpoint)
+	return b.bytesOf()
+}
+
+func decodeConnectToFrame(d *decoder, f *frame.ConnectToFrame) error {
+	return d.fields(func(key uint64) (known bool, err error) {
+		if key != keyConnectToEndpoint {
+			return false, nil
+		}
+		f.Endpoint, err = d.text()
+		return true, err
+	})
+}
+
+// encodePayloadFrame encodes the body of PingFrame and PongFrame.
+func encodePayloadFrame(payload []byte) []byte {
+	b := &body{}
+	b.bytes(keyPingPayload, payload)
+	return b.bytesOf()
+}
+
+func decodePayloadFrame(d *decoder, payload *[]byte) error {
+	return d.fields(func(key uint64) (known bool, err error) {
+		if key != keyPingPayload {
+			return false, nil
+		}
+		*payload, err = d.bytes()
+		return true, err
+	})
+}
+
+func encodeObserveUpdateFrame(f *frame.ObserveUpdateFrame) []byte {
+	b := &body{}
+	b.tags(keyObserveUpdateSubscribe, f.Subscribe)
+	b.tags(keyObserveUpdateUnsubscribe, f.Unsubscribe)
+	return b.bytesOf()
+}
+
+func decodeObserveUpdateFrame(d *decoder, f *frame.ObserveUpdateFrame) error {
+	return d.fields(func(key uint64) (known bool, err error) {
+		switch key {
+		case keyObserveUpdateSubscribe:
+			f.Subscribe, err = d.tags()
+		case keyObserveUpdateUnsubscribe:
+			f.Unsubscribe, err = d.tags()
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func encodeAckFrame(f *frame.AckFrame) []byte {
+	b := &body{}
+	b.uint(keyAckID, f.ID)
+	return b.bytesOf()
+}
+
+func decodeAckFrame(d *decoder, f *frame.AckFrame) error {
+	return d.fields(func(key uint64) (known bool, err error) {
+		if key != keyAckID {
+			return false, nil
+		}
+		f.ID, err = d.uint(math.MaxUint64)
+		return true, err
+	})
+}
+
+func encodeErrorFrame(f *frame.ErrorFrame) []byte {
+	b := &body{}
+	b.text(keyErrorSourceID, f.SourceID)
+	b.text(keyErrorTID, f.TID)
+	b.uint(keyErrorTag, uint64(f.Tag))
+	b.uint(keyErrorCode, uint64(f.Code))
+	b.text(keyErrorMessage, f.Message)
+	return b.bytesOf()
+}
+
+func decodeErrorFrame(d *decoder, f *frame.ErrorFrame) error {
+	return d.fields(func(key uint64) (known bool, err error) {
+		var v uint64
+		switch key {
+		case keyErrorSourceID:
+			f.SourceID, err = d.text()
+		case keyErrorTID:
+			f.TID, err = d.text()
+		case keyErrorTag:
+			v, err = d.uint(math.MaxUint32)
+			f.Tag = frame.Tag(v)
+		case keyErrorCode:
+			v, err = d.uint(math.MaxUint32)
+			f.Code = uint32(v)
+		case keyErrorMessage:
+			f.Message, err = d.text()
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func encodeRequestFrame(f *frame.RequestFrame) []byte {
+	b := &body{fields: make([]byte, 0, 32+len(f.ID)+len(f.Metadata)+len(f.Payload))}
+	b.text(keyRequestID, f.ID)
+	b.uint(keyRequestTag, uint64(f.Tag))
+	b.uint(keyRequestReplyTo, uint64(f.ReplyTo))
+	b.int(keyRequestDeadline, f.Deadline)
+	b.bytes(keyRequestMetadata, f.Metadata)
+	b.bytes(keyRequestPayload, f.Payload)
+	return b.bytesOf()
+}
+
+func decodeRequestFrame(d *decoder, f *frame.RequestFrame) error {
+	return d.fields(func(key uint64) (known bool, err error) {
+		var v uint64
+		switch key {
+		case keyRequestID:
+			f.ID, err = d.text()
+		case keyRequestTag:
+			v, err = d.uint(math.MaxUint32)
+			f.Tag = frame.Tag(v)
+		case keyRequestReplyTo:
+			v, err = d.uint(math.MaxUint32)
+			f.ReplyTo = frame.Tag(v)
+		case keyRequestDeadline:
+			f.Deadline, err = d.int(math.MinInt64, math.MaxInt64)
+		case keyRequestMetadata:
+			f.Metadata, err = d.bytes()
+		case keyRequestPayload:
+			f.Payload, err = d.bytes()
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func encodeResponseFrame(f *frame.ResponseFrame) []byte {
+	b := &body{fields: make([]byte, 0, 32+len(f.ID)+len(f.SourceID)+len(f.Payload)+len(f.Message))}
+	b.text(keyResponseID, f.ID)
+	b.text(keyResponseSourceID, f.SourceID)
+	b.bytes(keyResponsePayload, f.Payload)
+	b.uint(keyResponseCode, uint64(f.Code))
+	b.text(keyResponseMessage, f.Message)
+	return b.bytesOf()
+}
+
+func decodeResponseFrame(d *decoder, f *frame.ResponseFrame) error {
+	return d.fields(func(key uint64) (known bool, err error) {
+		var v uint64
+		switch key {
+		case keyResponseID:
+			f.ID, err = d.text()
+		case keyResponseSourceID:
+			f.SourceID, err = d.text()
+		case keyResponsePayload:
+			f.Payload, err = d.bytes()
+		case keyResponseCode:
+			v, err = d.uint(math.MaxUint32)
+			f.Code = uint32(v)
+		case keyResponseMessage:
+			f.Message, err = d.text()
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
@@ -0,0 +1,212 @@
+// Package conformance provides the golden vectors of the frame protocol, they are the canonical
+// packets of every builtin frame type encoded by the codecs of yomo, so the alternative codec
+// implementations and the SDKs in other languages verify the wire compatibility against them.
+//
+// The vectors are stored in the golden directory as JSON files named by the codecs, e.g. golden/y3.json,
+// each vector carries the frame in JSON and its packet in hex, so they are read without Go:
+//
+//	{"name": "GoodbyeFrame", "type": 47, "frame": {"Message": "bye"}, "packet": "af050103627965"}
+//
+// A Go codec is verified by Verify, e.g. `conformance.Verify(codec, prw, vectors)`. The golden files are
+// regenerated by `go test -update` after a reviewed change of the encoding.
+package conformance
+
+import (
+	"bytes"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+//go:embed golden/*.json
+var golden embed.FS
+
+// Case is a canonical frame of the conformance suite.
+type Case struct {
+	// Name identifies the case in the vectors.
+	Name string
+	// Frame is the frame to be encoded.
+	Frame frame.Frame
+}
+
+// Cases returns the canonical frames, every builtin frame type has at least one case,
+// the cases set all the fields of the frames so that every field is on the wire.
+func Cases() []Case {
+	return []Case{
+		{"DataFrame", &frame.DataFrame{Tag: 0x15, Metadata: []byte("metadata"), Payload: []byte("yomo")}},
+		{"SignalDataFrame", &frame.DataFrame{Tag: 0x15}},
+		{"FullDataFrame", &frame.DataFrame{
+			Tag:         0x15,
+			Metadata:    []byte("metadata"),
+			Payload:     []byte("yomo"),
+			Priority:    frame.PriorityHigh,
+			Channel:     0x100,
+			Chunk:       frame.Chunk{ID: 1, Seq: 1, Total: 2},
+			AckID:       0x1234,
+			Compression: frame.CompressionGzip,
+			Extensions:  map[uint8][]byte{0x01: []byte("ttl"), frame.ExtensionUserMin: []byte("user")},
+		}},
+		{"HandshakeFrame", &frame.HandshakeFrame{
+			Name:            "the-name",
+			ID:              "the-id",
+			ClientType:      0x5D,
+			ObserveDataTags: []frame.Tag{1, 2, 300},
+			AuthName:        "token",
+			AuthPayload:     "secret",
+			Version:         "2024-01-03",
+			ProtocolVersion: 2,
+			Compressions:    []string{"gzip"},
+			SchemaVersions:  map[frame.Tag][]string{1: {"v1", "v2"}, 300: {"v3"}},
+			Extensions:      map[string][]byte{"caps": []byte("b"), "group": []byte("a")},
+		}},
+		{"HandshakeAckFrame", &frame.HandshakeAckFrame{
+			Compression:     "gzip",
+			Extensions:      map[string][]byte{"group": []byte("ok")},
+			ProtocolVersion: 2,
+		}},
+		{"RejectedFrame", &frame.RejectedFrame{Message: "authentication failed", Reason: "auth_failed"}},
+		{"GoawayFrame", &frame.GoawayFrame{Message: "the zipper is draining", Reason: "server_draining"}},
+		{"ConnectToFrame", &frame.ConnectToFrame{Endpoint: "127.0.0.1:9000"}},
+		{"PingFrame", &frame.PingFrame{Payload: []byte("ping")}},
+		{"PongFrame", &frame.PongFrame{Payload: []byte("pong")}},
+		{"ObserveUpdateFrame", &frame.ObserveUpdateFrame{Subscribe: []frame.Tag{1, 300}, Unsubscribe: []frame.Tag{2}}},
+		{"GoodbyeFrame", &frame.GoodbyeFrame{Message: "bye"}},
+		{"AckFrame", &frame.AckFrame{ID: 0x1234}},
+		{"ErrorFrame", &frame.ErrorFrame{SourceID: "source", TID: "tid", Tag: 0x15, Code: 1, Message: "unroutable"}},
+		{"RequestFrame", &frame.RequestFrame{
+			ID:       "req-1",
+			Tag:      0x15,
+			ReplyTo:  0x16,
+			Deadline: 1700000000000,
+			Metadata: []byte("metadata"),
+			Payload:  []byte("yomo"),
+		}},
+		{"ResponseFrame", &frame.ResponseFrame{ID: "req-1", SourceID: "source", Payload: []byte("ok"), Code: 3, Message: "expired"}},
+		{"ExtensionFrame", &frame.ExtensionFrame{FrameType: 0x50, Body: []byte("yomo")}},
+	}
+}
+
+// Vector is the golden packet of a Case encoded by a codec.
+type Vector struct {
+	// Name is the name of the Case.
+	Name string `json:"name"`
+	// Type is the type of the frame.
+	Type frame.Type `json:"type"`
+	// Frame is the frame in JSON, it is informative for the implementations in other languages,
+	// Verify takes the frame from the Case of the Name.
+	Frame json.RawMessage `json:"frame"`
+	// Packet is the packet written by the PacketReadWriter of the codec, in hex.
+	Packet string `json:"packet"`
+}
+
+// Generate encodes the cases by the codec and returns the vectors, they are the golden vectors
+// once the encoding is reviewed.
+func Generate(codec frame.Codec, prw frame.PacketReadWriter) ([]Vector, error) {
+	cases := Cases()
+	vectors := make([]Vector, 0, len(cases))
+	for _, c := range cases {
+		packet, err := encodePacket(codec, prw, c.Frame)
+		if err != nil {
+			return nil, fmt.Errorf("conformance: %s: %w", c.Name, err)
+		}
+		f, err := json.Marshal(c.Frame)
+		if err != nil {
+			return nil, fmt.Errorf("conformance: %s: %w", c.Name, err)
+		}
+		vectors = append(vectors, Vector{
+			Name:   c.Name,
+			Type:   c.Frame.Type(),
+			Frame:  f,
+			Packet: hex.EncodeToString(packet),
+		})
+	}
+	return vectors, nil
+}
+
+// Verify verifies the codec against the vectors, encoding the frame of every vector must produce
+// the golden packet, and decoding the golden packet must produce a frame that is encoded to the same
+// packet again. All the mismatches are joined in the returned error.
+func Verify(codec frame.Codec, prw frame.PacketReadWriter, vectors []Vector) error {
+	cases := make(map[string]frame.Frame)
+	for _, c := range Cases() {
+		cases[c.Name] = c.Frame
+	}
+
+	var errs []error
+	for _, v := range vectors {
+		if err := verify(codec, prw, v, cases[v.Name]); err != nil {
+			errs = append(errs, fmt.Errorf("conformance: %s: %w", v.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func verify(codec frame.Codec, prw frame.PacketReadWriter, v Vector, f frame.Frame) error {
+	if f == nil {
+		return errors.New("unknown case")
+	}
+	want, err := hex.DecodeString(v.Packet)
+	if err != nil {
+		return err
+	}
+
+	got, err := encodePacket(codec, prw, f)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("encode: got %x, want %x", got, want)
+	}
+
+	ftyp, body, err := prw.ReadPacket(bytes.NewReader(want))
+	if err != nil {
+		return fmt.Errorf("read packet: %w", err)
+	}
+	if ftyp != v.Type {
+		return fmt.Errorf("read packet: got type %s, want %s", ftyp, v.Type)
+	}
+	decoded, err := frame.NewFrame(ftyp)
+	if err != nil {
+		return err
+	}
+	if err := codec.Decode(body, decoded); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	got, err = encodePacket(codec, prw, decoded)
+	if err != nil {
+		return fmt.Errorf("encode the decoded frame: %w", err)
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("decode: the decoded frame is encoded to %x, want %x", got, want)
+	}
+	return nil
+}
+
+// Load returns the golden vectors of the codec registered by the name, e.g. "y3" or "cbor".
+func Load(codecName string) ([]Vector, error) {
+	data, err := golden.ReadFile("golden/" + codecName + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("conformance: no golden vectors of codec %q", codecName)
+	}
+	var vectors []Vector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		return nil, fmt.Errorf("conformance: %w", err)
+	}
+	return vectors, nil
+}
+
+func encodePacket(codec frame.Codec, prw frame.PacketReadWriter, f frame.Frame) ([]byte, error) {
+	body, err := codec.Encode(f)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := prw.WritePacket(&buf, f.Type(), body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,64 @@
+package conformance
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/frame-codec/cborcodec"
+	"github.com/yomorun/yomo/pkg/frame-codec/y3codec"
+)
+
+var update = flag.Bool("update", false, "update the golden vectors")
+
+func TestCases(t *testing.T) {
+	covered := make(map[frame.Type]bool)
+	for _, c := range Cases() {
+		covered[c.Frame.Type()] = true
+	}
+	for i := 0; i <= 0xFF; i++ {
+		if ftyp := frame.Type(i); frame.IsBuiltin(ftyp) {
+			assert.True(t, covered[ftyp], "no case of %s", ftyp)
+		}
+	}
+}
+
+func TestGolden(t *testing.T) {
+	for _, name := range []string{y3codec.Name, cborcodec.Name} {
+		t.Run(name, func(t *testing.T) {
+			codec, prw, ok := frame.LookupCodec(name)
+			assert.True(t, ok)
+
+			if *update {
+				vectors, err := Generate(codec, prw)
+				assert.NoError(t, err)
+				data, err := json.MarshalIndent(vectors, "", "  ")
+				assert.NoError(t, err)
+				assert.NoError(t, os.WriteFile("golden/"+name+".json", append(data, '\n'), 0o644))
+				return
+			}
+
+			vectors, err := Load(name)
+			assert.NoError(t, err)
+			assert.Len(t, vectors, len(Cases()))
+			assert.NoError(t, Verify(codec, prw, vectors))
+		})
+	}
+}
+
+func TestVerifyMismatch(t *testing.T) {
+	vectors, err := Load(y3codec.Name)
+	assert.NoError(t, err)
+
+	err = Verify(cborcodec.Codec(), cborcodec.PacketReadWriter(), vectors)
+	assert.ErrorContains(t, err, "conformance: GoodbyeFrame: encode")
+
+	err = Verify(y3codec.Codec(), y3codec.PacketReadWriter(), []Vector{{Name: "NoSuchFrame"}})
+	assert.ErrorContains(t, err, "conformance: NoSuchFrame: unknown case")
+
+	_, err = Load("unknown")
+	assert.Error(t, err)
+}
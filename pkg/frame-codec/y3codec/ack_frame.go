@@ -0,0 +1,40 @@
+package y3codec
+
+import (
+	"github.com/yomorun/y3"
+	frame "github.com/yomorun/yomo/core/frame"
+)
+
+// encodeAckFrame encodes AckFrame to Y3 encoded bytes.
+func encodeAckFrame(f *frame.AckFrame) ([]byte, error) {
+	// id
+	idBlock := y3.NewPrimitivePacketEncoder(tagAckID)
+	idBlock.SetUInt64Value(f.ID)
+	// frame
+	ff := y3.NewNodePacketEncoder(byte(f.Type()))
+	ff.AddPrimitivePacket(idBlock)
+
+	return ff.Encode(), nil
+}
+
+// decodeAckFrame decodes Y3 encoded bytes to AckFrame.
+func decodeAckFrame(data []byte, f *frame.AckFrame) error {
+	node := y3.NodePacket{}
+	_, err := y3.DecodeToNodePacket(data, &node)
+	if err != nil {
+		return err
+	}
+
+	// id
+	if idBlock, ok := node.PrimitivePackets[tagAckID]; ok {
+		id, err := idBlock.ToUInt64()
+		if err != nil {
+			return err
+		}
+		f.ID = id
+	}
+
+	return nil
+}
+
+var tagAckID byte = 0x01
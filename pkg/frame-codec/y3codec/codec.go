@@ -2,25 +2,40 @@
 package y3codec
 
 import (
-	"errors"
+	"fmt"
 	"io"
+	"sync"
 
-	"github.com/yomorun/y3"
 	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/yerr"
 )
 
 // ErrUnknownFrame is returned when unknown frame is received.
-var ErrUnknownFrame = errors.New("y3codec: unknown frame")
+var ErrUnknownFrame = yerr.New(yerr.CodeProtocol, "y3codec: unknown frame")
 
-type packetReadWriter struct{}
+// Name is the name that the y3 codec is registered by, see frame.RegisterCodec.
+const Name = "y3"
 
-// PacketReadWriter returns the y3 implement of frame.PacketReadWriter.
-func PacketReadWriter() frame.PacketReadWriter {
-	return &packetReadWriter{}
+func init() {
+	frame.RegisterCodec(Name, Codec(), PacketReadWriter())
 }
 
+type packetReadWriter struct {
+	maxPacketSize int
+}
+
+var _ frame.PacketEncoder = &packetReadWriter{}
+
+// PacketReadWriter returns the y3 implement of frame.PacketReadWriter, the packets are bounded by
+// the MaxPacketSize of the limits, see WithLimits.
+func PacketReadWriter(opts ...Option) frame.PacketReadWriter {
+	c := newCodec(opts...)
+	return &packetReadWriter{maxPacketSize: c.limits.MaxPacketSize}
+}
+
+// ReadPacket reads a packet from the stream, the returned bytes are owned by the caller, see readPacket.
 func (pr *packetReadWriter) ReadPacket(stream io.Reader) (frame.Type, []byte, error) {
-	buf, err := y3.ReadPacket(stream)
+	buf, err := readPacket(stream, pr.maxPacketSize)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -32,10 +47,56 @@ func (pr *packetReadWriter) WritePacket(stream io.Writer, ftyp frame.Type, data
 	return err
 }
 
-type y3codec struct{}
+// EncodePacket implements frame.PacketEncoder, the y3 packet is the encoded frame as is,
+// so the frame is encoded into the stream directly if the codec supports it.
+func (pr *packetReadWriter) EncodePacket(stream io.Writer, codec frame.Codec, f frame.Frame) error {
+	if enc, ok := codec.(frame.EncoderTo); ok {
+		return enc.EncodeTo(stream, f)
+	}
+	data, err := codec.Encode(f)
+	if err != nil {
+		return err
+	}
+	return pr.WritePacket(stream, f.Type(), data)
+}
+
+type y3codec struct {
+	checksum bool
+	limits   frame.Limits
+}
+
+var _ frame.EncoderTo = &y3codec{}
+
+// Option is the option of the y3 codec.
+type Option func(*y3codec)
+
+// WithChecksum makes the codec append the CRC32 checksum to the encoded DataFrames, the checksum
+// is verified in decoding whenever it is present, regardless of this option.
+func WithChecksum() Option {
+	return func(c *y3codec) {
+		c.checksum = true
+	}
+}
+
+// WithLimits sets the limits of the decoded frames, the zero fields take the values of frame.DefaultLimits.
+func WithLimits(limits frame.Limits) Option {
+	return func(c *y3codec) {
+		c.limits = limits.OrDefault()
+	}
+}
 
 // Codec returns the y3 implement of frame.Codec.
-func Codec() frame.Codec { return &y3codec{} }
+func Codec(opts ...Option) frame.Codec {
+	return newCodec(opts...)
+}
+
+func newCodec(opts ...Option) *y3codec {
+	c := &y3codec{limits: frame.DefaultLimits}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
 
 func (c *y3codec) Encode(f frame.Frame) ([]byte, error) {
 	switch ff := f.(type) {
@@ -46,17 +107,93 @@ func (c *y3codec) Encode(f frame.Frame) ([]byte, error) {
 	case *frame.HandshakeAckFrame:
 		return encodeHandshakeAckFrame(ff)
 	case *frame.DataFrame:
-		return encodeDataFrame(ff)
+		return encodeDataFrame(ff, c.checksum)
 	case *frame.GoawayFrame:
 		return encodeGoawayFrame(ff)
 	case *frame.ConnectToFrame:
 		return encodeConnectToFrame(ff)
+	case *frame.PingFrame:
+		return encodePingFrame(ff)
+	case *frame.PongFrame:
+		return encodePongFrame(ff)
+	case *frame.ObserveUpdateFrame:
+		return encodeObserveUpdateFrame(ff)
+	case *frame.GoodbyeFrame:
+		return encodeGoodbyeFrame(ff)
+	case *frame.AckFrame:
+		return encodeAckFrame(ff)
+	case *frame.ErrorFrame:
+		return encodeErrorFrame(ff)
+	case *frame.RequestFrame:
+		return encodeRequestFrame(ff)
+	case *frame.ResponseFrame:
+		return encodeResponseFrame(ff)
+	case *frame.ExtensionFrame:
+		return encodeExtensionFrame(ff)
 	default:
 		return nil, ErrUnknownFrame
 	}
 }
 
+// encodeBufPool pools the buffers that the DataFrames are encoded into by EncodeTo.
+var encodeBufPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, 4<<10)
+		return &buf
+	},
+}
+
+// maxPooledBufSize is the max size of the pooled buffer, the larger buffers are not put back,
+// so a burst of large payloads does not pin the memory.
+const maxPooledBufSize = 64 << 10
+
+// EncodeTo implements frame.EncoderTo, the DataFrame is encoded into a pooled buffer,
+// the other frames are rare and encoded by Encode.
+func (c *y3codec) EncodeTo(w io.Writer, f frame.Frame) error {
+	df, ok := f.(*frame.DataFrame)
+	if !ok {
+		data, err := c.Encode(f)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	bufp := encodeBufPool.Get().(*[]byte)
+	buf, err := appendDataFrame((*bufp)[:0], df, c.checksum)
+	if err == nil {
+		_, err = w.Write(buf)
+	}
+	if cap(buf) <= maxPooledBufSize {
+		*bufp = buf
+		encodeBufPool.Put(bufp)
+	}
+	return err
+}
+
+// Decode decodes the data to the frame, it returns *frame.ErrMalformed if the data cannot be decoded,
+// whose cause is *DecodeError, or if the frame exceeds the limits.
 func (c *y3codec) Decode(data []byte, f frame.Frame) error {
+	err := decode(data, f)
+	if err == ErrUnknownFrame {
+		return err
+	}
+	if err != nil {
+		return frame.NewErrMalformed(f.Type(), newDecodeError(data, err))
+	}
+	return c.limits.Check(f)
+}
+
+// decode decodes the data to the frame, the y3 library panics on some malformed data, e.g. the length
+// of a primitive packet is beyond its node, so the panic is recovered as an error.
+func decode(data []byte, f frame.Frame) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("y3codec: malformed packet: %v", r)
+		}
+	}()
+
 	switch ff := f.(type) {
 	case *frame.RejectedFrame:
 		return decodeRejectedFrame(data, ff)
@@ -70,6 +207,24 @@ func (c *y3codec) Decode(data []byte, f frame.Frame) error {
 		return decodeGoawayFrame(data, ff)
 	case *frame.ConnectToFrame:
 		return decodeConnectToFrame(data, ff)
+	case *frame.PingFrame:
+		return decodePingFrame(data, ff)
+	case *frame.PongFrame:
+		return decodePongFrame(data, ff)
+	case *frame.ObserveUpdateFrame:
+		return decodeObserveUpdateFrame(data, ff)
+	case *frame.GoodbyeFrame:
+		return decodeGoodbyeFrame(data, ff)
+	case *frame.AckFrame:
+		return decodeAckFrame(data, ff)
+	case *frame.ErrorFrame:
+		return decodeErrorFrame(data, ff)
+	case *frame.RequestFrame:
+		return decodeRequestFrame(data, ff)
+	case *frame.ResponseFrame:
+		return decodeResponseFrame(data, ff)
+	case *frame.ExtensionFrame:
+		return decodeExtensionFrame(data, ff)
 	default:
 		return ErrUnknownFrame
 	}
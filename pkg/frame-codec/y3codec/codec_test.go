@@ -65,6 +65,56 @@ func TestCodec(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "SignalDataFrame",
+			args: args{
+				newF:  new(frame.DataFrame),
+				dataF: &frame.DataFrame{Tag: 0x15},
+				data:  []byte{0xbf, 0x3, 0x1, 0x1, 0x15},
+			},
+		},
+		{
+			name: "DataFrameWithPriority",
+			args: args{
+				newF: new(frame.DataFrame),
+				dataF: &frame.DataFrame{
+					Tag:      0x15,
+					Metadata: []byte("metadata"),
+					Payload:  []byte("yomo"),
+					Priority: frame.PriorityHigh,
+				},
+				data: []byte{
+					0xbf, 0x16, 0x1, 0x1, 0x15, 0x3, 0x8, 0x6d, 0x65, 0x74,
+					0x61, 0x64, 0x61, 0x74, 0x61, 0x2, 0x4, 0x79, 0x6f, 0x6d, 0x6f,
+					0x4, 0x1, 0x1,
+				},
+			},
+		},
+		{
+			name: "DataFrameWithChannel",
+			args: args{
+				newF:  new(frame.DataFrame),
+				dataF: &frame.DataFrame{Tag: 0x15, Payload: []byte("yomo"), Channel: 0x100},
+				data:  []byte{0xbf, 0xd, 0x1, 0x1, 0x15, 0x2, 0x4, 0x79, 0x6f, 0x6d, 0x6f, 0x5, 0x2, 0x1, 0x0},
+			},
+		},
+		{
+			name: "DataFrameWithChunk",
+			args: args{
+				newF:  new(frame.DataFrame),
+				dataF: &frame.DataFrame{Tag: 0x15, Payload: []byte("yomo"), Chunk: frame.Chunk{ID: 1, Seq: 0, Total: 2}},
+				data: []byte{0xbf, 0x17, 0x1, 0x1, 0x15, 0x2, 0x4, 0x79, 0x6f, 0x6d, 0x6f,
+					0x7, 0xc, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x2},
+			},
+		},
+		{
+			name: "ExtensionFrame",
+			args: args{
+				newF:  new(frame.ExtensionFrame),
+				dataF: &frame.ExtensionFrame{FrameType: 0x50, Body: []byte("yomo")},
+				data:  []byte{0xd0, 0x4, 0x79, 0x6f, 0x6d, 0x6f},
+			},
+		},
 		{
 			name: "HandshakeFrame",
 			args: args{
@@ -85,6 +135,50 @@ func TestCodec(t *testing.T) {
 					0x65, 0x65, 0x65, 0x7, 0x6, 0x31, 0x2e, 0x31, 0x36, 0x2e, 0x33},
 			},
 		},
+		{
+			name: "HandshakeFrameWithCompressions",
+			args: args{
+				newF: new(frame.HandshakeFrame),
+				dataF: &frame.HandshakeFrame{
+					Name:            "the-name",
+					ID:              "the-id",
+					ClientType:      104,
+					ObserveDataTags: []uint32{'a', 'b', 'c'},
+					AuthName:        "ddddd",
+					AuthPayload:     "eeeee",
+					Version:         "1.16.3",
+					Compressions:    []string{"gzip"},
+				},
+				data: []byte{0xb1, 0x3f, 0x1, 0x8, 0x74, 0x68, 0x65, 0x2d, 0x6e, 0x61,
+					0x6d, 0x65, 0x3, 0x6, 0x74, 0x68, 0x65, 0x2d, 0x69, 0x64, 0x2, 0x1,
+					0x68, 0x6, 0xc, 0x61, 0x0, 0x0, 0x0, 0x62, 0x0, 0x0, 0x0, 0x63, 0x0,
+					0x0, 0x0, 0x4, 0x5, 0x64, 0x64, 0x64, 0x64, 0x64, 0x5, 0x5, 0x65, 0x65,
+					0x65, 0x65, 0x65, 0x7, 0x6, 0x31, 0x2e, 0x31, 0x36, 0x2e, 0x33,
+					0x8, 0x4, 0x67, 0x7a, 0x69, 0x70},
+			},
+		},
+		{
+			name: "HandshakeFrameWithSchemaVersions",
+			args: args{
+				newF: new(frame.HandshakeFrame),
+				dataF: &frame.HandshakeFrame{
+					Name:            "the-name",
+					ID:              "the-id",
+					ClientType:      104,
+					ObserveDataTags: []uint32{'a', 'b', 'c'},
+					AuthName:        "ddddd",
+					AuthPayload:     "eeeee",
+					Version:         "1.16.3",
+					SchemaVersions:  map[frame.Tag][]string{0x21: {"1", "2"}, 0x22: {"3"}},
+				},
+				data: []byte{0xb1, 0x80, 0x46, 0x1, 0x8, 0x74, 0x68, 0x65, 0x2d, 0x6e,
+					0x61, 0x6d, 0x65, 0x3, 0x6, 0x74, 0x68, 0x65, 0x2d, 0x69, 0x64, 0x2,
+					0x1, 0x68, 0x6, 0xc, 0x61, 0x0, 0x0, 0x0, 0x62, 0x0, 0x0, 0x0, 0x63,
+					0x0, 0x0, 0x0, 0x4, 0x5, 0x64, 0x64, 0x64, 0x64, 0x64, 0x5, 0x5, 0x65,
+					0x65, 0x65, 0x65, 0x65, 0x7, 0x6, 0x31, 0x2e, 0x31, 0x36, 0x2e, 0x33,
+					0x9, 0xb, 0x33, 0x33, 0x3d, 0x31, 0x2c, 0x32, 0x3b, 0x33, 0x34, 0x3d, 0x33},
+			},
+		},
 		{
 			name: "HandshakeAckFrame",
 			args: args{
@@ -93,6 +187,22 @@ func TestCodec(t *testing.T) {
 				data:  []byte{0xa9, 0x0},
 			},
 		},
+		{
+			name: "HandshakeAckFrameWithCompression",
+			args: args{
+				newF:  new(frame.HandshakeAckFrame),
+				dataF: &frame.HandshakeAckFrame{Compression: "gzip"},
+				data:  []byte{0xa9, 0x6, 0x1, 0x4, 0x67, 0x7a, 0x69, 0x70},
+			},
+		},
+		{
+			name: "HandshakeAckFrameWithProtocolVersion",
+			args: args{
+				newF:  new(frame.HandshakeAckFrame),
+				dataF: &frame.HandshakeAckFrame{ProtocolVersion: 1},
+				data:  []byte{0xa9, 0x3, 0x3, 0x1, 0x1},
+			},
+		},
 		{
 			name: "RejectedFrame",
 			args: args{
@@ -106,6 +216,21 @@ func TestCodec(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "RejectedFrameWithReason",
+			args: args{
+				newF: new(frame.RejectedFrame),
+				dataF: &frame.RejectedFrame{
+					Message: "auth expired",
+					Reason:  "auth_expired",
+				},
+				data: []byte{
+					0xb9, 0x1c, 0x1, 0xc, 0x61, 0x75, 0x74, 0x68, 0x20, 0x65, 0x78,
+					0x70, 0x69, 0x72, 0x65, 0x64, 0x2, 0xc, 0x61, 0x75, 0x74, 0x68,
+					0x5f, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x64,
+				},
+			},
+		},
 		{
 			name: "GoawayFrame",
 			args: args{
@@ -119,6 +244,21 @@ func TestCodec(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "GoawayFrameWithReason",
+			args: args{
+				newF: new(frame.GoawayFrame),
+				dataF: &frame.GoawayFrame{
+					Message: "draining",
+					Reason:  "server_draining",
+				},
+				data: []byte{
+					0xae, 0x1b, 0x1, 0x8, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x69, 0x6e,
+					0x67, 0x2, 0xf, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x64,
+					0x72, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67,
+				},
+			},
+		},
 		{
 			name: "ConnectToFrame",
 			args: args{
@@ -132,6 +272,80 @@ func TestCodec(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ObserveUpdateFrame",
+			args: args{
+				newF: new(frame.ObserveUpdateFrame),
+				dataF: &frame.ObserveUpdateFrame{
+					Subscribe:   []uint32{'a', 'b'},
+					Unsubscribe: []uint32{'c'},
+				},
+				data: []byte{0xbb, 0x10, 0x1, 0x8, 0x61, 0x0, 0x0, 0x0, 0x62, 0x0, 0x0, 0x0,
+					0x2, 0x4, 0x63, 0x0, 0x0, 0x0},
+			},
+		},
+		{
+			name: "GoodbyeFrame",
+			args: args{
+				newF:  new(frame.GoodbyeFrame),
+				dataF: &frame.GoodbyeFrame{Message: "bye"},
+				data:  []byte{0xaf, 0x5, 0x1, 0x3, 0x62, 0x79, 0x65},
+			},
+		},
+		{
+			name: "AckFrame",
+			args: args{
+				newF:  new(frame.AckFrame),
+				dataF: &frame.AckFrame{ID: 1},
+				data:  []byte{0xba, 0x3, 0x1, 0x1, 0x1},
+			},
+		},
+		{
+			name: "ErrorFrame",
+			args: args{
+				newF:  new(frame.ErrorFrame),
+				dataF: &frame.ErrorFrame{SourceID: "s", TID: "t", Tag: 1, Code: 1, Message: "m"},
+				data:  []byte{0xb8, 0xf, 0x1, 0x1, 0x73, 0x2, 0x1, 0x74, 0x3, 0x1, 0x1, 0x4, 0x1, 0x1, 0x5, 0x1, 0x6d},
+			},
+		},
+		{
+			name: "RequestFrame",
+			args: args{
+				newF: new(frame.RequestFrame),
+				dataF: &frame.RequestFrame{
+					ID: "i", Tag: 1, ReplyTo: 2, Deadline: 3, Metadata: []byte("m"), Payload: []byte("p"),
+				},
+				data: []byte{0xb7, 0x12, 0x1, 0x1, 0x69, 0x2, 0x1, 0x1, 0x3, 0x1, 0x2, 0x4, 0x1, 0x3, 0x5, 0x1, 0x6d, 0x6, 0x1, 0x70},
+			},
+		},
+		{
+			name: "ResponseFrame",
+			args: args{
+				newF:  new(frame.ResponseFrame),
+				dataF: &frame.ResponseFrame{ID: "i", SourceID: "s", Payload: []byte("p"), Code: 1, Message: "m"},
+				data:  []byte{0xb6, 0xf, 0x1, 0x1, 0x69, 0x2, 0x1, 0x73, 0x3, 0x1, 0x70, 0x4, 0x1, 0x1, 0x5, 0x1, 0x6d},
+			},
+		},
+		{
+			name: "PingFrame",
+			args: args{
+				newF: new(frame.PingFrame),
+				dataF: &frame.PingFrame{
+					Payload: []byte("ping"),
+				},
+				data: []byte{0xbc, 0x6, 0x1, 0x4, 0x70, 0x69, 0x6e, 0x67},
+			},
+		},
+		{
+			name: "PongFrame",
+			args: args{
+				newF: new(frame.PongFrame),
+				dataF: &frame.PongFrame{
+					Payload: []byte("pong"),
+				},
+				data: []byte{0xbd, 0x6, 0x1, 0x4, 0x70, 0x6f, 0x6e, 0x67},
+			},
+		},
 		{
 			name: "error",
 			args: args{
@@ -0,0 +1,284 @@
+package y3codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/y3"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// y3EncodeDataFrame encodes the DataFrame by the y3 packet encoder, it is the reference of encodeDataFrame.
+func y3EncodeDataFrame(f *frame.DataFrame) []byte {
+	tagBlock := y3.NewPrimitivePacketEncoder(tagDataFrameTag)
+	tagBlock.SetUInt32Value(f.Tag)
+
+	data := y3.NewNodePacketEncoder(byte(f.Type()))
+	data.AddPrimitivePacket(tagBlock)
+
+	if len(f.Metadata) > 0 {
+		metadataBlock := y3.NewPrimitivePacketEncoder(tagDataFramesMetadata)
+		metadataBlock.SetBytesValue(f.Metadata)
+		data.AddPrimitivePacket(metadataBlock)
+	}
+	if len(f.Payload) > 0 {
+		payloadBlock := y3.NewPrimitivePacketEncoder(tagDataFramePayload)
+		payloadBlock.SetBytesValue(f.Payload)
+		data.AddPrimitivePacket(payloadBlock)
+	}
+	if f.Priority != frame.PriorityNormal {
+		priorityBlock := y3.NewPrimitivePacketEncoder(tagDataFramePriority)
+		priorityBlock.SetInt32Value(int32(f.Priority))
+		data.AddPrimitivePacket(priorityBlock)
+	}
+	if f.Channel != 0 {
+		channelBlock := y3.NewPrimitivePacketEncoder(tagDataFrameChannel)
+		channelBlock.SetUInt32Value(f.Channel)
+		data.AddPrimitivePacket(channelBlock)
+	}
+	if f.IsChunked() {
+		chunkBlock := y3.NewPrimitivePacketEncoder(tagDataFrameChunk)
+		chunkBlock.SetBytesValue(binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(
+			binary.BigEndian.AppendUint32(nil, f.Chunk.ID), f.Chunk.Seq), f.Chunk.Total))
+		data.AddPrimitivePacket(chunkBlock)
+	}
+	if f.AckID != 0 {
+		ackIDBlock := y3.NewPrimitivePacketEncoder(tagDataFrameAckID)
+		ackIDBlock.SetBytesValue(binary.BigEndian.AppendUint64(nil, f.AckID))
+		data.AddPrimitivePacket(ackIDBlock)
+	}
+	if f.Compression != frame.CompressionNone {
+		compressionBlock := y3.NewPrimitivePacketEncoder(tagDataFrameCompression)
+		compressionBlock.SetBytesValue([]byte{byte(f.Compression)})
+		data.AddPrimitivePacket(compressionBlock)
+	}
+	if len(f.Extensions) > 0 {
+		extensionsBlock := y3.NewPrimitivePacketEncoder(tagDataFrameExtensions)
+		extensionsBlock.SetBytesValue(frame.AppendExtensions(nil, f.Extensions))
+		data.AddPrimitivePacket(extensionsBlock)
+	}
+
+	return data.Encode()
+}
+
+// y3DecodeDataFrame decodes the DataFrame by the y3 packet decoder, it is the reference of decodeDataFrame.
+func y3DecodeDataFrame(data []byte, f *frame.DataFrame) error {
+	packet := y3.NodePacket{}
+	if _, err := y3.DecodeToNodePacket(data, &packet); err != nil {
+		return err
+	}
+	if tagBlock, ok := packet.PrimitivePackets[tagDataFrameTag]; ok {
+		tag, err := tagBlock.ToUInt32()
+		if err != nil {
+			return err
+		}
+		f.Tag = tag
+	}
+	if metadataBlock, ok := packet.PrimitivePackets[tagDataFramesMetadata]; ok {
+		f.Metadata = metadataBlock.ToBytes()
+	}
+	if payloadBlock, ok := packet.PrimitivePackets[tagDataFramePayload]; ok {
+		f.Payload = payloadBlock.ToBytes()
+	}
+	if priorityBlock, ok := packet.PrimitivePackets[tagDataFramePriority]; ok {
+		priority, err := priorityBlock.ToInt32()
+		if err != nil {
+			return err
+		}
+		f.Priority = frame.Priority(priority)
+	}
+	if channelBlock, ok := packet.PrimitivePackets[tagDataFrameChannel]; ok {
+		channel, err := channelBlock.ToUInt32()
+		if err != nil {
+			return err
+		}
+		f.Channel = channel
+	}
+	if chunkBlock, ok := packet.PrimitivePackets[tagDataFrameChunk]; ok {
+		chunk := chunkBlock.ToBytes()
+		f.Chunk = frame.Chunk{
+			ID:    binary.BigEndian.Uint32(chunk),
+			Seq:   binary.BigEndian.Uint32(chunk[4:]),
+			Total: binary.BigEndian.Uint32(chunk[8:]),
+		}
+	}
+	if ackIDBlock, ok := packet.PrimitivePackets[tagDataFrameAckID]; ok {
+		f.AckID = binary.BigEndian.Uint64(ackIDBlock.ToBytes())
+	}
+	if compressionBlock, ok := packet.PrimitivePackets[tagDataFrameCompression]; ok {
+		f.Compression = frame.Compression(compressionBlock.ToBytes()[0])
+	}
+	if extensionsBlock, ok := packet.PrimitivePackets[tagDataFrameExtensions]; ok {
+		extensions, err := frame.ParseExtensions(extensionsBlock.ToBytes())
+		if err != nil {
+			return err
+		}
+		f.Extensions = extensions
+	}
+	return nil
+}
+
+func randomBytes(r *rand.Rand, n int) []byte {
+	if n == 0 {
+		return nil
+	}
+	b := make([]byte, n)
+	r.Read(b)
+	return b
+}
+
+func TestDataFrameSameAsY3(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	tags := []uint32{0, 1, 0x3f, 0x7f, 0x80, 0xff, 0x100, 0xffff, 0x10000, math.MaxInt32, math.MaxUint32}
+	sizes := []int{0, 1, 63, 64, 127, 128, 8191, 8192, 1 << 16, 1 << 20}
+	priorities := []frame.Priority{frame.PriorityHigh, frame.PriorityNormal, frame.PriorityLow, 127, -128, -1}
+
+	for i := 0; i < 500; i++ {
+		f := &frame.DataFrame{
+			Tag:      tags[r.Intn(len(tags))],
+			Metadata: randomBytes(r, sizes[r.Intn(len(sizes))]),
+			Payload:  randomBytes(r, sizes[r.Intn(len(sizes))]),
+			Priority: priorities[r.Intn(len(priorities))],
+			Channel:  tags[r.Intn(len(tags))],
+		}
+		if r.Intn(2) == 0 {
+			f.Chunk = frame.Chunk{ID: r.Uint32(), Seq: r.Uint32(), Total: r.Uint32() | 1}
+		}
+		if r.Intn(2) == 0 {
+			f.AckID = r.Uint64() | 1
+		}
+		if r.Intn(2) == 0 {
+			f.Compression = frame.CompressionGzip
+		}
+		if r.Intn(2) == 0 {
+			f.Extensions = map[uint8][]byte{0x01: randomBytes(r, sizes[r.Intn(4)]), 0xff: randomBytes(r, sizes[r.Intn(4)])}
+		}
+
+		b, err := encodeDataFrame(f, false)
+		assert.NoError(t, err)
+		assert.Equal(t, y3EncodeDataFrame(f), b)
+
+		got, want := new(frame.DataFrame), new(frame.DataFrame)
+		assert.NoError(t, decodeDataFrame(b, got))
+		assert.NoError(t, y3DecodeDataFrame(b, want))
+		assert.Equal(t, want, got)
+		assert.Equal(t, f, got)
+	}
+}
+
+func TestDecodeMalformedDataFrame(t *testing.T) {
+	b, err := encodeDataFrame(&frame.DataFrame{Tag: 1, Payload: []byte("yomo")}, false)
+	assert.NoError(t, err)
+
+	for i := 0; i < len(b); i++ {
+		assert.Error(t, decodeDataFrame(b[:i], new(frame.DataFrame)), "truncated at %d", i)
+	}
+}
+
+var benchDataFrame = &frame.DataFrame{
+	Tag:      0x33,
+	Metadata: make([]byte, 64),
+	Payload:  make([]byte, 1024),
+	Priority: frame.PriorityHigh,
+}
+
+func BenchmarkEncodeDataFrame(b *testing.B) {
+	b.Run("fast", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = encodeDataFrame(benchDataFrame, false)
+		}
+	})
+	b.Run("y3", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = y3EncodeDataFrame(benchDataFrame)
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		codec := Codec().(frame.EncoderTo)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = codec.EncodeTo(io.Discard, benchDataFrame)
+		}
+	})
+}
+
+func TestEncodeTo(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	sizes := []int{0, 1, 127, 128, 8192, 1 << 16, 1 << 17}
+
+	for _, opts := range [][]Option{nil, {WithChecksum()}} {
+		codec := Codec(opts...)
+		// the pooled buffers are reused by the frames of random sizes, no stale byte is left.
+		for i := 0; i < 100; i++ {
+			f := &frame.DataFrame{
+				Tag:      r.Uint32(),
+				Metadata: randomBytes(r, sizes[r.Intn(len(sizes))]),
+				Payload:  randomBytes(r, sizes[r.Intn(len(sizes))]),
+				Channel:  r.Uint32(),
+				AckID:    r.Uint64(),
+			}
+			want, err := codec.Encode(f)
+			assert.NoError(t, err)
+
+			var buf bytes.Buffer
+			assert.NoError(t, codec.(frame.EncoderTo).EncodeTo(&buf, f))
+			assert.Equal(t, want, buf.Bytes())
+		}
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, PacketReadWriter().(frame.PacketEncoder).EncodePacket(&buf, Codec(), &frame.PingFrame{Payload: []byte("yomo")}))
+	want, _ := Codec().Encode(&frame.PingFrame{Payload: []byte("yomo")})
+	assert.Equal(t, want, buf.Bytes())
+}
+
+func BenchmarkDecodeDataFrame(b *testing.B) {
+	data, _ := encodeDataFrame(benchDataFrame, false)
+	f := new(frame.DataFrame)
+
+	b.Run("fast", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = decodeDataFrame(data, f)
+		}
+	})
+	b.Run("y3", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = y3DecodeDataFrame(data, f)
+		}
+	})
+}
+
+func TestDataFrameChecksum(t *testing.T) {
+	f := &frame.DataFrame{Tag: 1, Metadata: []byte("md"), Payload: []byte("yomo"), Priority: frame.PriorityHigh, Channel: 9}
+
+	b, err := Codec(WithChecksum()).Encode(f)
+	assert.NoError(t, err)
+	plain, err := Codec().Encode(f)
+	assert.NoError(t, err)
+	assert.Len(t, b, len(plain)+6)
+
+	// the checksum is verified by any codec.
+	got := new(frame.DataFrame)
+	assert.NoError(t, Codec().Decode(b, got))
+	assert.Equal(t, f, got)
+	assert.NoError(t, Codec(WithChecksum()).Decode(plain, new(frame.DataFrame)))
+
+	corrupted := append([]byte{}, b...)
+	corrupted[bytes.Index(corrupted, []byte("yomo"))] ^= 0x01
+	err = Codec().Decode(corrupted, new(frame.DataFrame))
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	de := new(DecodeError)
+	assert.True(t, errors.As(err, &de))
+	assert.Equal(t, DecodeCorrupted, de.Kind)
+}
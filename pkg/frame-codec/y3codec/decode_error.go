@@ -0,0 +1,101 @@
+package y3codec
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/yomorun/y3/encoding"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+// DecodeErrorKind classifies the failures of decoding.
+type DecodeErrorKind string
+
+const (
+	// DecodeEmpty means the data is empty.
+	DecodeEmpty DecodeErrorKind = "empty"
+	// DecodeTruncated means the data ends before the length declared in it.
+	DecodeTruncated DecodeErrorKind = "truncated"
+	// DecodeMalformed means the data is not a valid y3 packet of the frame.
+	DecodeMalformed DecodeErrorKind = "malformed"
+	// DecodeCorrupted means the checksum of the data mismatches.
+	DecodeCorrupted DecodeErrorKind = "corrupted"
+)
+
+// decodeErrorKinds are all the kinds, the counters are indexed by them.
+var decodeErrorKinds = []DecodeErrorKind{DecodeEmpty, DecodeTruncated, DecodeMalformed, DecodeCorrupted}
+
+// hexDumpSize is the max number of bytes dumped in DecodeError, the rest is elided.
+const hexDumpSize = 64
+
+// errTruncated is returned if the data ends before the length declared in it.
+var errTruncated = errors.New("y3codec: truncated frame")
+
+// DecodeError is returned if decoding fails, it carries the context of the data, so an interop bug
+// with the SDKs of other languages can be diagnosed from the logs alone.
+type DecodeError struct {
+	// Kind is the kind of the failure.
+	Kind DecodeErrorKind
+	// FrameType is the type byte of the data, it is 0 if the data is empty.
+	FrameType byte
+	// Length is the length of the data.
+	Length int
+	// Hex is the hex dump of the first bytes of the data.
+	Hex string
+	// Err is the cause.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("y3codec: decode %s: %s: %v (type=0x%02x, length=%d, hex=%s)",
+		frame.Type(e.FrameType&0x7F), e.Kind, e.Err, e.FrameType, e.Length, e.Hex)
+}
+
+// Unwrap returns the cause.
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// ErrorCode implements the yerr.Coder interface.
+func (e *DecodeError) ErrorCode() yerr.Code { return yerr.CodeProtocol }
+
+var decodeErrorCounters [4]atomic.Int64
+
+// DecodeErrorCounts returns the number of the decode failures of each kind since the process started.
+func DecodeErrorCounts() map[DecodeErrorKind]int64 {
+	counts := make(map[DecodeErrorKind]int64, len(decodeErrorKinds))
+	for i, kind := range decodeErrorKinds {
+		counts[kind] = decodeErrorCounters[i].Load()
+	}
+	return counts
+}
+
+// newDecodeError returns the DecodeError of the data, and counts it.
+func newDecodeError(data []byte, err error) *DecodeError {
+	e := &DecodeError{Kind: DecodeMalformed, Length: len(data), Err: err}
+	switch {
+	case len(data) == 0:
+		e.Kind = DecodeEmpty
+	case errors.Is(err, errTruncated), errors.Is(err, encoding.ErrBufferInsufficient):
+		e.Kind = DecodeTruncated
+	case errors.Is(err, ErrChecksumMismatch):
+		e.Kind = DecodeCorrupted
+	}
+	if len(data) > 0 {
+		e.FrameType = data[0]
+	}
+	if len(data) > hexDumpSize {
+		e.Hex = hex.EncodeToString(data[:hexDumpSize]) + "..."
+	} else {
+		e.Hex = hex.EncodeToString(data)
+	}
+
+	for i, kind := range decodeErrorKinds {
+		if kind == e.Kind {
+			decodeErrorCounters[i].Add(1)
+		}
+	}
+	return e
+}
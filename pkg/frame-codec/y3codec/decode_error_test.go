@@ -0,0 +1,93 @@
+package y3codec
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestDecodeError(t *testing.T) {
+	codec := Codec()
+	before := DecodeErrorCounts()
+
+	b, err := codec.Encode(&frame.DataFrame{Tag: 1, Payload: bytes.Repeat([]byte{0xab}, 100)})
+	assert.NoError(t, err)
+
+	err = codec.Decode(b[:50], new(frame.DataFrame))
+	de := new(DecodeError)
+	assert.True(t, errors.As(err, &de))
+	assert.Equal(t, DecodeTruncated, de.Kind)
+	assert.Equal(t, byte(0xbf), de.FrameType)
+	assert.Equal(t, 50, de.Length)
+	assert.Equal(t, 100, len(de.Hex))
+	assert.ErrorIs(t, err, errTruncated)
+
+	err = codec.Decode(b, new(frame.DataFrame))
+	assert.NoError(t, err)
+
+	err = codec.Decode(nil, new(frame.HandshakeFrame))
+	assert.True(t, errors.As(err, &de))
+	assert.Equal(t, DecodeEmpty, de.Kind)
+
+	// the length of the tag is beyond the frame.
+	err = codec.Decode([]byte{0xbf, 0x3, 0x1, 0x5, 0x15}, new(frame.DataFrame))
+	assert.True(t, errors.As(err, &de))
+	assert.Equal(t, DecodeMalformed, de.Kind)
+	assert.Equal(t, "y3codec: decode DataFrame: malformed: y3codec: malformed data frame, beyond the boundary, "+
+		"pos=4, end=9 (type=0xbf, length=5, hex=bf03010515)", err.Error())
+
+	after := DecodeErrorCounts()
+	for _, kind := range []DecodeErrorKind{DecodeEmpty, DecodeTruncated, DecodeMalformed} {
+		assert.Equal(t, before[kind]+1, after[kind], kind)
+	}
+}
+
+func TestDecodeGarbage(t *testing.T) {
+	codec := Codec()
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 20000; i++ {
+		ftyp := garbageTypes[r.Intn(len(garbageTypes))]
+		b := make([]byte, 2+r.Intn(30))
+		r.Read(b)
+		// the header is valid mostly, so the garbage reaches the fields.
+		b[0] = 0x80 | byte(ftyp)
+		if r.Intn(2) == 0 {
+			b[1] = byte(len(b) - 2)
+		}
+
+		f, _ := frame.NewFrame(ftyp)
+		if err := codec.Decode(b, f); err != nil {
+			me := new(frame.ErrMalformed)
+			assert.True(t, errors.As(err, &me), "%x: %v", b, err)
+		}
+	}
+
+	// the client type is a byte.
+	err := codec.Decode([]byte{0xb1, 0x2, 0x2, 0x0}, new(frame.HandshakeFrame))
+	assert.ErrorContains(t, err, "invalid client type size: 0")
+}
+
+var garbageTypes = []frame.Type{
+	frame.TypeDataFrame, frame.TypeHandshakeFrame, frame.TypeHandshakeAckFrame, frame.TypeRejectedFrame,
+	frame.TypeGoawayFrame, frame.TypeConnectToFrame, frame.TypePingFrame, frame.TypePongFrame,
+	frame.TypeObserveUpdateFrame, frame.TypeGoodbyeFrame, frame.TypeAckFrame, frame.TypeErrorFrame,
+	frame.TypeRequestFrame, frame.TypeResponseFrame,
+}
+
+func TestDecodeLimits(t *testing.T) {
+	b, err := Codec().Encode(&frame.HandshakeFrame{Name: "sfn", ObserveDataTags: []frame.Tag{1, 2, 3}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, Codec().Decode(b, new(frame.HandshakeFrame)))
+
+	err = Codec(WithLimits(frame.Limits{MaxListLength: 2})).Decode(b, new(frame.HandshakeFrame))
+	me := new(frame.ErrMalformed)
+	assert.True(t, errors.As(err, &me))
+	assert.Equal(t, frame.TypeHandshakeFrame, me.FrameType)
+	assert.Equal(t, "frame: HandshakeFrame: observed data tags is too large: 3 > 2", err.Error())
+}
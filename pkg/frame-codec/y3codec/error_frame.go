@@ -0,0 +1,84 @@
+package y3codec
+
+import (
+	"github.com/yomorun/y3"
+	frame "github.com/yomorun/yomo/core/frame"
+)
+
+// encodeErrorFrame encodes ErrorFrame to Y3 encoded bytes.
+func encodeErrorFrame(f *frame.ErrorFrame) ([]byte, error) {
+	// source id
+	sourceIDBlock := y3.NewPrimitivePacketEncoder(tagErrorSourceID)
+	sourceIDBlock.SetStringValue(f.SourceID)
+	// tid
+	tidBlock := y3.NewPrimitivePacketEncoder(tagErrorTID)
+	tidBlock.SetStringValue(f.TID)
+	// tag
+	tagBlock := y3.NewPrimitivePacketEncoder(tagErrorTag)
+	tagBlock.SetUInt32Value(f.Tag)
+	// code
+	codeBlock := y3.NewPrimitivePacketEncoder(tagErrorCode)
+	codeBlock.SetUInt32Value(f.Code)
+	// message
+	messageBlock := y3.NewPrimitivePacketEncoder(tagErrorMessage)
+	messageBlock.SetStringValue(f.Message)
+	// frame
+	ff := y3.NewNodePacketEncoder(byte(f.Type()))
+	ff.AddPrimitivePacket(sourceIDBlock)
+	ff.AddPrimitivePacket(tidBlock)
+	ff.AddPrimitivePacket(tagBlock)
+	ff.AddPrimitivePacket(codeBlock)
+	ff.AddPrimitivePacket(messageBlock)
+
+	return ff.Encode(), nil
+}
+
+// decodeErrorFrame decodes Y3 encoded bytes to ErrorFrame.
+func decodeErrorFrame(data []byte, f *frame.ErrorFrame) error {
+	node := y3.NodePacket{}
+	_, err := y3.DecodeToNodePacket(data, &node)
+	if err != nil {
+		return err
+	}
+
+	// source id
+	if sourceIDBlock, ok := node.PrimitivePackets[tagErrorSourceID]; ok {
+		if f.SourceID, err = sourceIDBlock.ToUTF8String(); err != nil {
+			return err
+		}
+	}
+	// tid
+	if tidBlock, ok := node.PrimitivePackets[tagErrorTID]; ok {
+		if f.TID, err = tidBlock.ToUTF8String(); err != nil {
+			return err
+		}
+	}
+	// tag
+	if tagBlock, ok := node.PrimitivePackets[tagErrorTag]; ok {
+		if f.Tag, err = tagBlock.ToUInt32(); err != nil {
+			return err
+		}
+	}
+	// code
+	if codeBlock, ok := node.PrimitivePackets[tagErrorCode]; ok {
+		if f.Code, err = codeBlock.ToUInt32(); err != nil {
+			return err
+		}
+	}
+	// message
+	if messageBlock, ok := node.PrimitivePackets[tagErrorMessage]; ok {
+		if f.Message, err = messageBlock.ToUTF8String(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var (
+	tagErrorSourceID byte = 0x01
+	tagErrorTID      byte = 0x02
+	tagErrorTag      byte = 0x03
+	tagErrorCode     byte = 0x04
+	tagErrorMessage  byte = 0x05
+)
@@ -0,0 +1,40 @@
+package y3codec
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yomorun/y3/encoding"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// encodeExtensionFrame encodes the ExtensionFrame to a y3 node packet whose body is the Body as is.
+func encodeExtensionFrame(f *frame.ExtensionFrame) ([]byte, error) {
+	if frame.IsBuiltin(f.FrameType) || f.FrameType > 0x7F {
+		return nil, fmt.Errorf("y3codec: invalid extension frame type: 0x%02x", byte(f.FrameType))
+	}
+	buf := make([]byte, 1+encoding.SizeOfPVarInt32(int32(len(f.Body)))+len(f.Body))
+	buf[0] = 0x80 | byte(f.FrameType)
+	pos := putLength(buf, 1, len(f.Body))
+	copy(buf[pos:], f.Body)
+
+	return buf, nil
+}
+
+// decodeExtensionFrame decodes the y3 node packet to ExtensionFrame, the Body refers to the data.
+func decodeExtensionFrame(data []byte, f *frame.ExtensionFrame) error {
+	if len(data) == 0 {
+		return errors.New("y3codec: empty extension frame")
+	}
+	pos, bodySize, err := readLength(data, 1)
+	if err != nil {
+		return err
+	}
+	if pos+bodySize > len(data) {
+		return fmt.Errorf("%w, pos=%d, end=%d", errTruncated, pos, pos+bodySize)
+	}
+	f.FrameType = frame.Type(data[0] & 0x7F)
+	f.Body = nilIfEmpty(data[pos : pos+bodySize])
+
+	return nil
+}
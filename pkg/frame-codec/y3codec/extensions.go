@@ -0,0 +1,65 @@
+package y3codec
+
+import (
+	"fmt"
+
+	"github.com/yomorun/y3"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// maxExtensions is the max number of the extensions, each extension is a y3 node keyed by its index.
+const maxExtensions = 0x3F
+
+// encodeExtensions encodes the extensions to a y3 node, each entry is a child node of the key and
+// the value, the entries are in ascending order of keys.
+func encodeExtensions(tag byte, extensions map[string][]byte) (*y3.NodePacketEncoder, error) {
+	if len(extensions) > maxExtensions {
+		return nil, fmt.Errorf("y3codec: too many extensions: %d > %d", len(extensions), maxExtensions)
+	}
+	keys := maps.Keys(extensions)
+	slices.Sort(keys)
+
+	node := y3.NewNodePacketEncoder(tag)
+	for i, key := range keys {
+		keyBlock := y3.NewPrimitivePacketEncoder(tagExtensionKey)
+		keyBlock.SetStringValue(key)
+		valueBlock := y3.NewPrimitivePacketEncoder(tagExtensionValue)
+		valueBlock.SetBytesValue(extensions[key])
+
+		entry := y3.NewNodePacketEncoder(byte(i))
+		entry.AddPrimitivePacket(keyBlock)
+		entry.AddPrimitivePacket(valueBlock)
+		node.AddNodePacket(entry)
+	}
+	return node, nil
+}
+
+// decodeExtensions decodes the extensions from the y3 node encoded by encodeExtensions.
+func decodeExtensions(node y3.NodePacket) (map[string][]byte, error) {
+	if len(node.NodePackets) == 0 {
+		return nil, nil
+	}
+	extensions := make(map[string][]byte, len(node.NodePackets))
+	for _, entry := range node.NodePackets {
+		keyBlock, ok := entry.PrimitivePackets[tagExtensionKey]
+		if !ok {
+			return nil, fmt.Errorf("y3codec: extension without key")
+		}
+		key, err := keyBlock.ToUTF8String()
+		if err != nil {
+			return nil, err
+		}
+		var value []byte
+		if valueBlock, ok := entry.PrimitivePackets[tagExtensionValue]; ok {
+			value = nilIfEmpty(valueBlock.ToBytes())
+		}
+		extensions[key] = value
+	}
+	return extensions, nil
+}
+
+const (
+	tagExtensionKey   byte = 0x01
+	tagExtensionValue byte = 0x02
+)
@@ -0,0 +1,39 @@
+package y3codec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestHandshakeExtensions(t *testing.T) {
+	codec := Codec()
+
+	hf := &frame.HandshakeFrame{
+		Name:       "source",
+		ID:         "source-id",
+		ClientType: 0x5F,
+		Extensions: map[string][]byte{"group": []byte("edge-1"), "capabilities": []byte("gzip,channel"), "empty": nil},
+	}
+	b, err := codec.Encode(hf)
+	assert.NoError(t, err)
+	got := new(frame.HandshakeFrame)
+	assert.NoError(t, codec.Decode(b, got))
+	assert.Equal(t, hf, got)
+
+	ack := &frame.HandshakeAckFrame{Compression: "gzip", Extensions: map[string][]byte{"group": []byte("accepted")}}
+	b, err = codec.Encode(ack)
+	assert.NoError(t, err)
+	gotAck := new(frame.HandshakeAckFrame)
+	assert.NoError(t, codec.Decode(b, gotAck))
+	assert.Equal(t, ack, gotAck)
+
+	tooMany := make(map[string][]byte)
+	for i := 0; i <= maxExtensions; i++ {
+		tooMany[fmt.Sprint(i)] = []byte{byte(i)}
+	}
+	_, err = codec.Encode(&frame.HandshakeAckFrame{Extensions: tooMany})
+	assert.Error(t, err)
+}
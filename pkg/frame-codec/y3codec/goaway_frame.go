@@ -13,6 +13,12 @@ func encodeGoawayFrame(f *frame.GoawayFrame) ([]byte, error) {
 	// frame
 	ff := y3.NewNodePacketEncoder(byte(f.Type()))
 	ff.AddPrimitivePacket(messageBlock)
+	// reason, it is omitted if empty to be compatible with the peers that don't know it.
+	if f.Reason != "" {
+		reasonBlock := y3.NewPrimitivePacketEncoder(tagGoawayReason)
+		reasonBlock.SetStringValue(f.Reason)
+		ff.AddPrimitivePacket(reasonBlock)
+	}
 
 	return ff.Encode(), nil
 }
@@ -33,10 +39,19 @@ func decodeGoawayFrame(data []byte, f *frame.GoawayFrame) error {
 		}
 		f.Message = message
 	}
+	// reason
+	if reasonBlock, ok := node.PrimitivePackets[tagGoawayReason]; ok {
+		reason, err := reasonBlock.ToUTF8String()
+		if err != nil {
+			return err
+		}
+		f.Reason = reason
+	}
 
 	return nil
 }
 
 var (
 	tagGoawayMessage byte = 0x01
+	tagGoawayReason  byte = 0x02
 )
@@ -0,0 +1,40 @@
+package y3codec
+
+import (
+	"github.com/yomorun/y3"
+	frame "github.com/yomorun/yomo/core/frame"
+)
+
+// encodeGoodbyeFrame encodes GoodbyeFrame to Y3 encoded bytes.
+func encodeGoodbyeFrame(f *frame.GoodbyeFrame) ([]byte, error) {
+	// message
+	messageBlock := y3.NewPrimitivePacketEncoder(tagGoodbyeMessage)
+	messageBlock.SetStringValue(f.Message)
+	// frame
+	ff := y3.NewNodePacketEncoder(byte(f.Type()))
+	ff.AddPrimitivePacket(messageBlock)
+
+	return ff.Encode(), nil
+}
+
+// decodeGoodbyeFrame decodes Y3 encoded bytes to GoodbyeFrame.
+func decodeGoodbyeFrame(data []byte, f *frame.GoodbyeFrame) error {
+	node := y3.NodePacket{}
+	_, err := y3.DecodeToNodePacket(data, &node)
+	if err != nil {
+		return err
+	}
+
+	// message
+	if messageBlock, ok := node.PrimitivePackets[tagGoodbyeMessage]; ok {
+		message, err := messageBlock.ToUTF8String()
+		if err != nil {
+			return err
+		}
+		f.Message = message
+	}
+
+	return nil
+}
+
+var tagGoodbyeMessage byte = 0x01
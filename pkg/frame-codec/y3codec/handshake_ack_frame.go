@@ -8,6 +8,26 @@ import (
 // encodeHandshakeAckFrame encodes HandshakeAckFrame to Y3 encoded bytes.
 func encodeHandshakeAckFrame(f *frame.HandshakeAckFrame) ([]byte, error) {
 	ack := y3.NewNodePacketEncoder(byte(f.Type()))
+	// compression
+	if f.Compression != "" {
+		compressionBlock := y3.NewPrimitivePacketEncoder(tagHandshakeAckCompression)
+		compressionBlock.SetStringValue(f.Compression)
+		ack.AddPrimitivePacket(compressionBlock)
+	}
+	// extensions
+	if len(f.Extensions) > 0 {
+		extensionsBlock, err := encodeExtensions(tagHandshakeAckExtensions, f.Extensions)
+		if err != nil {
+			return nil, err
+		}
+		ack.AddNodePacket(extensionsBlock)
+	}
+	// protocol version
+	if f.ProtocolVersion > 0 {
+		protocolVersionBlock := y3.NewPrimitivePacketEncoder(tagHandshakeAckProtocolVersion)
+		protocolVersionBlock.SetUInt32Value(f.ProtocolVersion)
+		ack.AddPrimitivePacket(protocolVersionBlock)
+	}
 
 	return ack.Encode(), nil
 }
@@ -19,5 +39,31 @@ func decodeHandshakeAckFrame(data []byte, f *frame.HandshakeAckFrame) error {
 	if err != nil {
 		return err
 	}
+	// compression
+	if compressionBlock, ok := node.PrimitivePackets[tagHandshakeAckCompression]; ok {
+		compression, err := compressionBlock.ToUTF8String()
+		if err != nil {
+			return err
+		}
+		f.Compression = compression
+	}
+	// extensions
+	if extensionsBlock, ok := node.NodePackets[tagHandshakeAckExtensions]; ok {
+		if f.Extensions, err = decodeExtensions(extensionsBlock); err != nil {
+			return err
+		}
+	}
+	// protocol version
+	if protocolVersionBlock, ok := node.PrimitivePackets[tagHandshakeAckProtocolVersion]; ok {
+		if f.ProtocolVersion, err = protocolVersionBlock.ToUInt32(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+const (
+	tagHandshakeAckCompression     byte = 0x01
+	tagHandshakeAckExtensions      byte = 0x02
+	tagHandshakeAckProtocolVersion byte = 0x03
+)
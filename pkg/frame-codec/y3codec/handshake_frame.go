@@ -2,9 +2,13 @@ package y3codec
 
 import (
 	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/yomorun/y3"
 	frame "github.com/yomorun/yomo/core/frame"
+	"golang.org/x/exp/slices"
 )
 
 // encodeHandshakeFrame encodes HandshakeFrame to bytes in Y3 codec.
@@ -44,6 +48,32 @@ func encodeHandshakeFrame(f *frame.HandshakeFrame) ([]byte, error) {
 	handshake.AddPrimitivePacket(authNameBlock)
 	handshake.AddPrimitivePacket(authPayloadBlock)
 	handshake.AddPrimitivePacket(versionBlock)
+	// protocol version
+	if f.ProtocolVersion > 0 {
+		protocolVersionBlock := y3.NewPrimitivePacketEncoder(tagHandshakeProtocolVersion)
+		protocolVersionBlock.SetUInt32Value(f.ProtocolVersion)
+		handshake.AddPrimitivePacket(protocolVersionBlock)
+	}
+	// compressions
+	if len(f.Compressions) > 0 {
+		compressionsBlock := y3.NewPrimitivePacketEncoder(tagHandshakeCompressions)
+		compressionsBlock.SetStringValue(strings.Join(f.Compressions, ","))
+		handshake.AddPrimitivePacket(compressionsBlock)
+	}
+	// schema versions
+	if len(f.SchemaVersions) > 0 {
+		schemaVersionsBlock := y3.NewPrimitivePacketEncoder(tagHandshakeSchemaVersions)
+		schemaVersionsBlock.SetStringValue(encodeSchemaVersions(f.SchemaVersions))
+		handshake.AddPrimitivePacket(schemaVersionsBlock)
+	}
+	// extensions
+	if len(f.Extensions) > 0 {
+		extensionsBlock, err := encodeExtensions(tagHandshakeExtensions, f.Extensions)
+		if err != nil {
+			return nil, err
+		}
+		handshake.AddNodePacket(extensionsBlock)
+	}
 
 	return handshake.Encode(), nil
 }
@@ -75,6 +105,9 @@ func decodeHandshakeFrame(data []byte, f *frame.HandshakeFrame) error {
 	// client type
 	if typeBlock, ok := node.PrimitivePackets[byte(tagHandshakeClientType)]; ok {
 		clientType := typeBlock.ToBytes()
+		if len(clientType) != 1 {
+			return fmt.Errorf("y3codec: invalid client type size: %d", len(clientType))
+		}
 		f.ClientType = clientType[0]
 	}
 	// observe data tag list
@@ -110,10 +143,79 @@ func decodeHandshakeFrame(data []byte, f *frame.HandshakeFrame) error {
 		}
 		f.Version = version
 	}
+	// protocol version
+	if protocolVersionBlock, ok := node.PrimitivePackets[tagHandshakeProtocolVersion]; ok {
+		protocolVersion, err := protocolVersionBlock.ToUInt32()
+		if err != nil {
+			return err
+		}
+		f.ProtocolVersion = protocolVersion
+	}
+	// compressions
+	if compressionsBlock, ok := node.PrimitivePackets[tagHandshakeCompressions]; ok {
+		compressions, err := compressionsBlock.ToUTF8String()
+		if err != nil {
+			return err
+		}
+		if compressions != "" {
+			f.Compressions = strings.Split(compressions, ",")
+		}
+	}
+	// schema versions
+	if schemaVersionsBlock, ok := node.PrimitivePackets[tagHandshakeSchemaVersions]; ok {
+		schemaVersions, err := schemaVersionsBlock.ToUTF8String()
+		if err != nil {
+			return err
+		}
+		if f.SchemaVersions, err = decodeSchemaVersions(schemaVersions); err != nil {
+			return err
+		}
+	}
+	// extensions
+	if extensionsBlock, ok := node.NodePackets[tagHandshakeExtensions]; ok {
+		if f.Extensions, err = decodeExtensions(extensionsBlock); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// encodeSchemaVersions encodes the schema versions as `tag=v1,v2;tag=v3`, the tags are in ascending order.
+func encodeSchemaVersions(versions map[frame.Tag][]string) string {
+	tags := make([]frame.Tag, 0, len(versions))
+	for tag := range versions {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+
+	items := make([]string, len(tags))
+	for i, tag := range tags {
+		items[i] = strconv.FormatUint(uint64(tag), 10) + "=" + strings.Join(versions[tag], ",")
+	}
+	return strings.Join(items, ";")
+}
+
+// decodeSchemaVersions decodes the schema versions encoded by encodeSchemaVersions.
+func decodeSchemaVersions(s string) (map[frame.Tag][]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	versions := make(map[frame.Tag][]string)
+	for _, item := range strings.Split(s, ";") {
+		tagStr, vs, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("y3codec: invalid schema versions: %s", item)
+		}
+		tag, err := strconv.ParseUint(tagStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("y3codec: invalid schema versions: %s", item)
+		}
+		versions[frame.Tag(tag)] = strings.Split(vs, ",")
+	}
+	return versions, nil
+}
+
 const (
 	tagHandshakeName            byte = 0x01
 	tagHandshakeClientType      byte = 0x02
@@ -122,4 +224,8 @@ const (
 	tagAuthenticationPayload    byte = 0x05
 	tagHandshakeObserveDataTags byte = 0x06
 	tagHandshakeVersion         byte = 0x07
+	tagHandshakeCompressions    byte = 0x08
+	tagHandshakeSchemaVersions  byte = 0x09
+	tagHandshakeExtensions      byte = 0x0A
+	tagHandshakeProtocolVersion byte = 0x0B
 )
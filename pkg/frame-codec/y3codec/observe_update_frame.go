@@ -0,0 +1,66 @@
+package y3codec
+
+import (
+	"encoding/binary"
+
+	"github.com/yomorun/y3"
+	frame "github.com/yomorun/yomo/core/frame"
+)
+
+// encodeObserveUpdateFrame encodes ObserveUpdateFrame to Y3 encoded bytes.
+func encodeObserveUpdateFrame(f *frame.ObserveUpdateFrame) ([]byte, error) {
+	// subscribe
+	subscribeBlock := y3.NewPrimitivePacketEncoder(tagObserveUpdateSubscribe)
+	subscribeBlock.SetBytesValue(encodeTags(f.Subscribe))
+	// unsubscribe
+	unsubscribeBlock := y3.NewPrimitivePacketEncoder(tagObserveUpdateUnsubscribe)
+	unsubscribeBlock.SetBytesValue(encodeTags(f.Unsubscribe))
+	// frame
+	ff := y3.NewNodePacketEncoder(byte(f.Type()))
+	ff.AddPrimitivePacket(subscribeBlock)
+	ff.AddPrimitivePacket(unsubscribeBlock)
+
+	return ff.Encode(), nil
+}
+
+// decodeObserveUpdateFrame decodes Y3 encoded bytes to ObserveUpdateFrame.
+func decodeObserveUpdateFrame(data []byte, f *frame.ObserveUpdateFrame) error {
+	node := y3.NodePacket{}
+	_, err := y3.DecodeToNodePacket(data, &node)
+	if err != nil {
+		return err
+	}
+
+	// subscribe
+	if subscribeBlock, ok := node.PrimitivePackets[tagObserveUpdateSubscribe]; ok {
+		f.Subscribe = decodeTags(subscribeBlock.GetValBuf())
+	}
+	// unsubscribe
+	if unsubscribeBlock, ok := node.PrimitivePackets[tagObserveUpdateUnsubscribe]; ok {
+		f.Unsubscribe = decodeTags(unsubscribeBlock.GetValBuf())
+	}
+
+	return nil
+}
+
+// encodeTags encodes the tags as the little endian uint32 list.
+func encodeTags(tags []frame.Tag) []byte {
+	buf := make([]byte, 4*len(tags))
+	for i, tag := range tags {
+		binary.LittleEndian.PutUint32(buf[i*4:], tag)
+	}
+	return buf
+}
+
+func decodeTags(buf []byte) []frame.Tag {
+	var tags []frame.Tag
+	for i := 0; i+4 <= len(buf); i += 4 {
+		tags = append(tags, binary.LittleEndian.Uint32(buf[i:i+4]))
+	}
+	return tags
+}
+
+const (
+	tagObserveUpdateSubscribe   byte = 0x01
+	tagObserveUpdateUnsubscribe byte = 0x02
+)
@@ -0,0 +1,72 @@
+package y3codec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/yomorun/y3"
+	"github.com/yomorun/y3/encoding"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// maxLengthSize is the max size of the y3 length, it is a varint of int32.
+const maxLengthSize = 5
+
+// readPacket reads a y3 packet from the reader into a single buffer of the exact size, so the value
+// is copied once from the stream. The buffer is owned by the caller and never reused, the frames
+// decoded from it refer to it instead of copying.
+// The packet larger than maxSize is discarded without being buffered, and *frame.ErrMalformed is returned,
+// the stream can be read further.
+func readPacket(r io.Reader, maxSize int) ([]byte, error) {
+	var header [1 + maxLengthSize]byte
+
+	// the first byte is the y3 tag, io.EOF is returned as is if the stream ends between the packets.
+	if _, err := io.ReadFull(r, header[:1]); err != nil {
+		return nil, err
+	}
+
+	// the length is a varint, the highest bit of the bytes except the last one is set.
+	n := 1
+	for {
+		if n == len(header) {
+			return nil, y3.ErrMalformed
+		}
+		if _, err := io.ReadFull(r, header[n:n+1]); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		n++
+		if header[n-1]&0x80 == 0 {
+			break
+		}
+	}
+
+	var length int32
+	codec := encoding.VarCodec{}
+	if err := codec.DecodePVarInt32(header[1:n], &length); err != nil || length < 0 {
+		return nil, y3.ErrMalformed
+	}
+
+	if int(length) > maxSize {
+		if _, err := io.CopyN(io.Discard, r, int64(length)); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return nil, frame.NewErrMalformed(frame.Type(header[0]&0x7F),
+			fmt.Errorf("y3codec: packet is too large: %d > %d", length, maxSize))
+	}
+
+	buf := make([]byte, n+int(length))
+	copy(buf, header[:n])
+	if _, err := io.ReadFull(r, buf[n:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return buf, nil
+}
+
+// unexpectedEOF reports the packet truncated by the end of stream as malformed.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return y3.ErrMalformed
+	}
+	return err
+}
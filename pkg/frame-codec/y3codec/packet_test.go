@@ -0,0 +1,90 @@
+package y3codec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/y3"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestReadPacketSameAsY3(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	sizes := []int{0, 1, 127, 128, 16383, 16384, 1 << 20, 3 << 20}
+
+	for i := 0; i < 30; i++ {
+		b, err := encodeDataFrame(&frame.DataFrame{
+			Tag:     r.Uint32(),
+			Payload: randomBytes(r, sizes[r.Intn(len(sizes))]),
+		}, false)
+		assert.NoError(t, err)
+
+		want, err := y3.ReadPacket(bytes.NewReader(b))
+		assert.NoError(t, err)
+		got, err := readPacket(iotest.HalfReader(bytes.NewReader(b)), frame.DefaultLimits.MaxPacketSize)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestReadTruncatedPacket(t *testing.T) {
+	b, err := encodeDataFrame(&frame.DataFrame{Tag: 1, Payload: make([]byte, 200)}, false)
+	assert.NoError(t, err)
+
+	_, err = readPacket(bytes.NewReader(nil), frame.DefaultLimits.MaxPacketSize)
+	assert.Equal(t, io.EOF, err)
+
+	for _, n := range []int{1, 2, 3, len(b) - 1} {
+		_, err = readPacket(bytes.NewReader(b[:n]), frame.DefaultLimits.MaxPacketSize)
+		assert.Equal(t, y3.ErrMalformed, err, "truncated at %d", n)
+	}
+
+	// the length is longer than a varint of int32.
+	_, err = readPacket(bytes.NewReader([]byte{0xbf, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}), frame.DefaultLimits.MaxPacketSize)
+	assert.Equal(t, y3.ErrMalformed, err)
+}
+
+func TestReadOversizedPacket(t *testing.T) {
+	large, err := encodeDataFrame(&frame.DataFrame{Tag: 1, Payload: make([]byte, 200)}, false)
+	assert.NoError(t, err)
+	small, err := encodeDataFrame(&frame.DataFrame{Tag: 2, Payload: []byte("yomo")}, false)
+	assert.NoError(t, err)
+
+	// the oversized packet is discarded, and the next one is read.
+	stream := bytes.NewReader(append(append([]byte{}, large...), small...))
+	_, err = readPacket(stream, 100)
+	me := new(frame.ErrMalformed)
+	assert.True(t, errors.As(err, &me))
+	assert.Equal(t, frame.TypeDataFrame, me.FrameType)
+	assert.Equal(t, "y3codec: packet is too large: 206 > 100", err.Error())
+
+	got, err := readPacket(stream, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, small, got)
+
+	// the oversized packet is truncated.
+	_, err = readPacket(bytes.NewReader(large[:100]), 100)
+	assert.Equal(t, y3.ErrMalformed, err)
+}
+
+func BenchmarkReadPacket(b *testing.B) {
+	data, _ := encodeDataFrame(&frame.DataFrame{Tag: 1, Payload: make([]byte, 4<<20)}, false)
+
+	b.Run("fast", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = readPacket(bytes.NewReader(data), frame.DefaultLimits.MaxPacketSize)
+		}
+	})
+	b.Run("y3", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = y3.ReadPacket(bytes.NewReader(data))
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package y3codec
+
+import (
+	"github.com/yomorun/y3"
+	frame "github.com/yomorun/yomo/core/frame"
+)
+
+// encodePingFrame encodes PingFrame to Y3 encoded bytes.
+func encodePingFrame(f *frame.PingFrame) ([]byte, error) {
+	return encodePingPayload(byte(f.Type()), f.Payload), nil
+}
+
+// decodePingFrame decodes Y3 encoded bytes to PingFrame.
+func decodePingFrame(data []byte, f *frame.PingFrame) error {
+	payload, err := decodePingPayload(data)
+	if err != nil {
+		return err
+	}
+	f.Payload = payload
+	return nil
+}
+
+// encodePongFrame encodes PongFrame to Y3 encoded bytes.
+func encodePongFrame(f *frame.PongFrame) ([]byte, error) {
+	return encodePingPayload(byte(f.Type()), f.Payload), nil
+}
+
+// decodePongFrame decodes Y3 encoded bytes to PongFrame.
+func decodePongFrame(data []byte, f *frame.PongFrame) error {
+	payload, err := decodePingPayload(data)
+	if err != nil {
+		return err
+	}
+	f.Payload = payload
+	return nil
+}
+
+// PingFrame and PongFrame share the same layout.
+func encodePingPayload(ftyp byte, payload []byte) []byte {
+	// payload
+	payloadBlock := y3.NewPrimitivePacketEncoder(tagPingPayload)
+	payloadBlock.SetBytesValue(payload)
+	// frame
+	ff := y3.NewNodePacketEncoder(ftyp)
+	ff.AddPrimitivePacket(payloadBlock)
+
+	return ff.Encode()
+}
+
+func decodePingPayload(data []byte) ([]byte, error) {
+	node := y3.NodePacket{}
+	_, err := y3.DecodeToNodePacket(data, &node)
+	if err != nil {
+		return nil, err
+	}
+
+	// payload
+	if payloadBlock, ok := node.PrimitivePackets[tagPingPayload]; ok {
+		return payloadBlock.ToBytes(), nil
+	}
+
+	return nil, nil
+}
+
+var (
+	tagPingPayload byte = 0x01
+)
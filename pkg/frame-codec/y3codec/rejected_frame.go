@@ -13,6 +13,12 @@ func encodeRejectedFrame(f *frame.RejectedFrame) ([]byte, error) {
 	// frame
 	ff := y3.NewNodePacketEncoder(byte(f.Type()))
 	ff.AddPrimitivePacket(messageBlock)
+	// reason, it is omitted if empty to be compatible with the peers that don't know it.
+	if f.Reason != "" {
+		reasonBlock := y3.NewPrimitivePacketEncoder(tagRejectedReason)
+		reasonBlock.SetStringValue(f.Reason)
+		ff.AddPrimitivePacket(reasonBlock)
+	}
 
 	return ff.Encode(), nil
 }
@@ -32,10 +38,19 @@ func decodeRejectedFrame(data []byte, f *frame.RejectedFrame) error {
 		}
 		f.Message = message
 	}
+	// reason
+	if reasonBlock, ok := node.PrimitivePackets[tagRejectedReason]; ok {
+		reason, err := reasonBlock.ToUTF8String()
+		if err != nil {
+			return err
+		}
+		f.Reason = reason
+	}
 
 	return nil
 }
 
 var (
 	tagRejectedMessage byte = 0x01
+	tagRejectedReason  byte = 0x02
 )
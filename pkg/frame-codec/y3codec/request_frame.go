@@ -0,0 +1,165 @@
+package y3codec
+
+import (
+	"github.com/yomorun/y3"
+	frame "github.com/yomorun/yomo/core/frame"
+)
+
+// encodeRequestFrame encodes RequestFrame to Y3 encoded bytes.
+func encodeRequestFrame(f *frame.RequestFrame) ([]byte, error) {
+	// id
+	idBlock := y3.NewPrimitivePacketEncoder(tagRequestID)
+	idBlock.SetStringValue(f.ID)
+	// tag
+	tagBlock := y3.NewPrimitivePacketEncoder(tagRequestTag)
+	tagBlock.SetUInt32Value(f.Tag)
+	// reply to
+	replyToBlock := y3.NewPrimitivePacketEncoder(tagRequestReplyTo)
+	replyToBlock.SetUInt32Value(f.ReplyTo)
+	// deadline
+	deadlineBlock := y3.NewPrimitivePacketEncoder(tagRequestDeadline)
+	deadlineBlock.SetInt64Value(f.Deadline)
+	// metadata
+	metadataBlock := y3.NewPrimitivePacketEncoder(tagRequestMetadata)
+	metadataBlock.SetBytesValue(f.Metadata)
+	// payload
+	payloadBlock := y3.NewPrimitivePacketEncoder(tagRequestPayload)
+	payloadBlock.SetBytesValue(f.Payload)
+	// frame
+	ff := y3.NewNodePacketEncoder(byte(f.Type()))
+	ff.AddPrimitivePacket(idBlock)
+	ff.AddPrimitivePacket(tagBlock)
+	ff.AddPrimitivePacket(replyToBlock)
+	ff.AddPrimitivePacket(deadlineBlock)
+	ff.AddPrimitivePacket(metadataBlock)
+	ff.AddPrimitivePacket(payloadBlock)
+
+	return ff.Encode(), nil
+}
+
+// decodeRequestFrame decodes Y3 encoded bytes to RequestFrame.
+func decodeRequestFrame(data []byte, f *frame.RequestFrame) error {
+	node := y3.NodePacket{}
+	_, err := y3.DecodeToNodePacket(data, &node)
+	if err != nil {
+		return err
+	}
+
+	// id
+	if idBlock, ok := node.PrimitivePackets[tagRequestID]; ok {
+		if f.ID, err = idBlock.ToUTF8String(); err != nil {
+			return err
+		}
+	}
+	// tag
+	if tagBlock, ok := node.PrimitivePackets[tagRequestTag]; ok {
+		if f.Tag, err = tagBlock.ToUInt32(); err != nil {
+			return err
+		}
+	}
+	// reply to
+	if replyToBlock, ok := node.PrimitivePackets[tagRequestReplyTo]; ok {
+		if f.ReplyTo, err = replyToBlock.ToUInt32(); err != nil {
+			return err
+		}
+	}
+	// deadline
+	if deadlineBlock, ok := node.PrimitivePackets[tagRequestDeadline]; ok {
+		if f.Deadline, err = deadlineBlock.ToInt64(); err != nil {
+			return err
+		}
+	}
+	// metadata
+	if metadataBlock, ok := node.PrimitivePackets[tagRequestMetadata]; ok {
+		f.Metadata = metadataBlock.ToBytes()
+	}
+	// payload
+	if payloadBlock, ok := node.PrimitivePackets[tagRequestPayload]; ok {
+		f.Payload = payloadBlock.ToBytes()
+	}
+
+	return nil
+}
+
+// encodeResponseFrame encodes ResponseFrame to Y3 encoded bytes.
+func encodeResponseFrame(f *frame.ResponseFrame) ([]byte, error) {
+	// id
+	idBlock := y3.NewPrimitivePacketEncoder(tagResponseID)
+	idBlock.SetStringValue(f.ID)
+	// source id
+	sourceIDBlock := y3.NewPrimitivePacketEncoder(tagResponseSourceID)
+	sourceIDBlock.SetStringValue(f.SourceID)
+	// payload
+	payloadBlock := y3.NewPrimitivePacketEncoder(tagResponsePayload)
+	payloadBlock.SetBytesValue(f.Payload)
+	// code
+	codeBlock := y3.NewPrimitivePacketEncoder(tagResponseCode)
+	codeBlock.SetUInt32Value(f.Code)
+	// message
+	messageBlock := y3.NewPrimitivePacketEncoder(tagResponseMessage)
+	messageBlock.SetStringValue(f.Message)
+	// frame
+	ff := y3.NewNodePacketEncoder(byte(f.Type()))
+	ff.AddPrimitivePacket(idBlock)
+	ff.AddPrimitivePacket(sourceIDBlock)
+	ff.AddPrimitivePacket(payloadBlock)
+	ff.AddPrimitivePacket(codeBlock)
+	ff.AddPrimitivePacket(messageBlock)
+
+	return ff.Encode(), nil
+}
+
+// decodeResponseFrame decodes Y3 encoded bytes to ResponseFrame.
+func decodeResponseFrame(data []byte, f *frame.ResponseFrame) error {
+	node := y3.NodePacket{}
+	_, err := y3.DecodeToNodePacket(data, &node)
+	if err != nil {
+		return err
+	}
+
+	// id
+	if idBlock, ok := node.PrimitivePackets[tagResponseID]; ok {
+		if f.ID, err = idBlock.ToUTF8String(); err != nil {
+			return err
+		}
+	}
+	// source id
+	if sourceIDBlock, ok := node.PrimitivePackets[tagResponseSourceID]; ok {
+		if f.SourceID, err = sourceIDBlock.ToUTF8String(); err != nil {
+			return err
+		}
+	}
+	// payload
+	if payloadBlock, ok := node.PrimitivePackets[tagResponsePayload]; ok {
+		f.Payload = payloadBlock.ToBytes()
+	}
+	// code
+	if codeBlock, ok := node.PrimitivePackets[tagResponseCode]; ok {
+		if f.Code, err = codeBlock.ToUInt32(); err != nil {
+			return err
+		}
+	}
+	// message
+	if messageBlock, ok := node.PrimitivePackets[tagResponseMessage]; ok {
+		if f.Message, err = messageBlock.ToUTF8String(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var (
+	tagRequestID       byte = 0x01
+	tagRequestTag      byte = 0x02
+	tagRequestReplyTo  byte = 0x03
+	tagRequestDeadline byte = 0x04
+	tagRequestMetadata byte = 0x05
+	tagRequestPayload  byte = 0x06
+
+	tagResponseID       byte = 0x01
+	tagResponseSourceID byte = 0x02
+	tagResponsePayload  byte = 0x03
+	tagResponseCode     byte = 0x04
+	tagResponseMessage  byte = 0x05
+)
@@ -1,10 +1,14 @@
 package yquic
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
+	"io"
 	"net"
+	"sync"
+	"time"
 
 	"github.com/quic-go/quic-go"
 	"github.com/yomorun/yomo/core/frame"
@@ -18,6 +22,44 @@ type FrameConn struct {
 	stream  quic.Stream
 	codec   frame.Codec
 	prw     frame.PacketReadWriter
+
+	// readCh merges the frames read from all data streams on the listener side, it is nil on the dialer side.
+	readCh chan readResult
+	// streams are the data streams opened by the dialer side, streams[0] is the first stream.
+	streams  []quic.Stream
+	selector StreamSelector
+	// canceled is the IDs of the data streams canceled by either endpoint, the frames are written to the first stream instead.
+	canceled sync.Map // quic.StreamID -> struct{}
+	// accepted is the data streams accepted on the listener side.
+	accepted sync.Map // quic.StreamID -> quic.Stream
+
+	// ctrl is the dedicated stream for control frames, it can be used after ctrlReady is closed.
+	ctrl      quic.Stream
+	ctrlReady chan struct{}
+	ctrlMu    sync.Mutex
+}
+
+var (
+	_ frame.BatchWriter    = &FrameConn{}
+	_ frame.WriteDeadliner = &FrameConn{}
+	_ frame.ControlConn    = &FrameConn{}
+	_ frame.StreamCanceler = &FrameConn{}
+)
+
+// Dialer dials the given address and returns a QUIC connection.
+// It allows to control how the QUIC connection is established, e.g. through a proxy or a custom PacketConn.
+type Dialer func(ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config) (quic.Connection, error)
+
+// PacketConnDialer returns a Dialer that dials over the given net.PacketConn,
+// the PacketConn can be a custom UDP socket, e.g. a VPN tunnel.
+func PacketConnDialer(pconn net.PacketConn) Dialer {
+	return func(ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config) (quic.Connection, error) {
+		udpAddr, err := net.ResolveUDPAddr("udp", addr)
+		if err != nil {
+			return nil, err
+		}
+		return quic.Dial(ctx, pconn, udpAddr, tlsConfig, quicConfig)
+	}
 }
 
 // DialAddr dials the given address and returns a new FrameConn.
@@ -27,7 +69,18 @@ func DialAddr(
 	codec frame.Codec, prw frame.PacketReadWriter,
 	tlsConfig *tls.Config, quicConfig *quic.Config,
 ) (*FrameConn, error) {
-	qconn, err := quic.DialAddr(ctx, addr, tlsConfig, quicConfig)
+	return DialAddrWith(ctx, quic.DialAddr, addr, codec, prw, tlsConfig, quicConfig)
+}
+
+// DialAddrWith dials the given address with the dialer and returns a new FrameConn.
+func DialAddrWith(
+	ctx context.Context,
+	dialer Dialer,
+	addr string,
+	codec frame.Codec, prw frame.PacketReadWriter,
+	tlsConfig *tls.Config, quicConfig *quic.Config,
+) (*FrameConn, error) {
+	qconn, err := dialer(ctx, addr, tlsConfig, quicConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -46,11 +99,12 @@ func newFrameConn(
 ) *FrameConn {
 
 	conn := &FrameConn{
-		frameCh: make(chan frame.Frame),
-		conn:    qconn,
-		stream:  stream,
-		codec:   codec,
-		prw:     prw,
+		frameCh:   make(chan frame.Frame),
+		conn:      qconn,
+		stream:    stream,
+		codec:     codec,
+		prw:       prw,
+		ctrlReady: make(chan struct{}),
 	}
 
 	return conn
@@ -99,27 +153,160 @@ func handleError(err error) error {
 
 // ReadFrame reads a frame. it usually be called in a for-loop.
 func (p *FrameConn) ReadFrame() (frame.Frame, error) {
-	fType, b, err := p.prw.ReadPacket(p.stream)
+	if p.readCh != nil {
+		select {
+		case r := <-p.readCh:
+			return r.frame, r.err
+		case <-p.conn.Context().Done():
+			return nil, handleError(context.Cause(p.conn.Context()))
+		}
+	}
+	f, _, err := p.readFrom(p.stream)
+	return f, err
+}
+
+// readFrom reads a frame from the stream, broken reports whether the stream cannot be read anymore.
+// The stream is not broken by a frame that fails to be decoded or a packet that exceeds the limits,
+// because the packet is read or discarded as a whole.
+func (p *FrameConn) readFrom(stream quic.Stream) (f frame.Frame, broken bool, err error) {
+	fType, b, err := p.prw.ReadPacket(stream)
 	if err != nil {
-		return nil, handleError(err)
+		if me := new(frame.ErrMalformed); errors.As(err, &me) {
+			return nil, false, err
+		}
+		return nil, true, handleError(err)
 	}
-	f, err := frame.NewFrame(fType)
+	f, err = frame.NewFrame(fType)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	if err := p.codec.Decode(b, f); err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	return f, nil
+	return f, false, nil
 }
 
-// WriteFrame writes a frame to connection.
+// WriteFrame writes a frame to connection, the DataFrame is written to the data stream chosen
+// by the StreamSelector if multiple data streams are opened. If the data stream is canceled,
+// the frame is written to the first stream and frame.ErrStreamCanceled is returned.
 func (p *FrameConn) WriteFrame(f frame.Frame) error {
+	stream := p.streamFor(f)
+	if err := p.writePacket(stream, f); err != nil {
+		if err = p.handleStreamError(stream, err); isStreamCanceled(err) {
+			if err := p.writePacket(p.stream, f); err != nil {
+				return handleError(err)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// writePacket encodes the frame and writes it to w as a packet, the frame is encoded into w
+// directly if the PacketReadWriter supports it.
+func (p *FrameConn) writePacket(w io.Writer, f frame.Frame) error {
+	if pe, ok := p.prw.(frame.PacketEncoder); ok {
+		return pe.EncodePacket(w, p.codec, f)
+	}
 	b, err := p.codec.Encode(f)
 	if err != nil {
 		return err
 	}
-	if err := p.prw.WritePacket(p.stream, f.Type(), b); err != nil {
+	return p.prw.WritePacket(w, f.Type(), b)
+}
+
+// SetWriteDeadline sets the write deadline of the underlying data streams.
+func (p *FrameConn) SetWriteDeadline(t time.Time) error {
+	for _, stream := range p.streams {
+		if err := stream.SetWriteDeadline(t); err != nil {
+			return err
+		}
+	}
+	return p.stream.SetWriteDeadline(t)
+}
+
+// WriteFrames writes frames to connection in a single write per data stream. If a data stream is canceled,
+// its frames are written to the first stream, the other frames are written as well, and frame.ErrStreamCanceled
+// is returned.
+func (p *FrameConn) WriteFrames(fs ...frame.Frame) error {
+	var (
+		order  []quic.Stream
+		bufs   = make(map[quic.Stream]*bytes.Buffer)
+		frames = make(map[quic.Stream][]frame.Frame)
+	)
+	for _, f := range fs {
+		stream := p.streamFor(f)
+		buf, ok := bufs[stream]
+		if !ok {
+			buf = new(bytes.Buffer)
+			bufs[stream] = buf
+			order = append(order, stream)
+		}
+		if err := p.writePacket(buf, f); err != nil {
+			return err
+		}
+		frames[stream] = append(frames[stream], f)
+	}
+	var canceled error
+	for _, stream := range order {
+		if _, err := stream.Write(bufs[stream].Bytes()); err != nil {
+			if err = p.handleStreamError(stream, err); !isStreamCanceled(err) {
+				return err
+			}
+			// the stream is marked canceled, so the frames go to the first stream.
+			if err := p.WriteFrames(frames[stream]...); err != nil {
+				return err
+			}
+			canceled = err
+		}
+	}
+	return canceled
+}
+
+// OpenControlStream opens the dedicated stream for control frames, it is accepted by the listener side.
+func (p *FrameConn) OpenControlStream() error {
+	stream, err := p.openStream(streamKindControl)
+	if err != nil {
+		return err
+	}
+	p.ctrl = stream
+	close(p.ctrlReady)
+	return nil
+}
+
+// controlStream waits for the control stream being opened or accepted.
+func (p *FrameConn) controlStream() (quic.Stream, error) {
+	select {
+	case <-p.ctrlReady:
+		return p.ctrl, nil
+	case <-p.conn.Context().Done():
+		return nil, handleError(context.Cause(p.conn.Context()))
+	}
+}
+
+// ReadControlFrame reads a frame from the control stream, it blocks until the control stream is ready.
+func (p *FrameConn) ReadControlFrame() (frame.Frame, error) {
+	stream, err := p.controlStream()
+	if err != nil {
+		return nil, err
+	}
+	f, _, err := p.readFrom(stream)
+	return f, err
+}
+
+// WriteControlFrame writes a frame to the control stream, it blocks until the control stream is ready.
+func (p *FrameConn) WriteControlFrame(f frame.Frame) error {
+	stream, err := p.controlStream()
+	if err != nil {
+		return err
+	}
+	b, err := p.codec.Encode(f)
+	if err != nil {
+		return err
+	}
+	p.ctrlMu.Lock()
+	defer p.ctrlMu.Unlock()
+	if err := p.prw.WritePacket(stream, f.Type(), b); err != nil {
 		return handleError(err)
 	}
 	return nil
@@ -181,7 +368,12 @@ func (listener *Listener) Accept(ctx context.Context) (frame.Conn, error) {
 		return nil, err
 	}
 
-	return newFrameConn(qconn, stream, listener.codec, listener.prw), nil
+	fconn := newFrameConn(qconn, stream, listener.codec, listener.prw)
+	fconn.readCh = make(chan readResult)
+	go fconn.readStream(stream, true)
+	go fconn.acceptStreams()
+
+	return fconn, nil
 }
 
 // Close closes listener.
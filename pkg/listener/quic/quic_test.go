@@ -36,6 +36,12 @@ func TestFrameConnection(t *testing.T) {
 	err = fconn.WriteFrame(&frame.HandshakeAckFrame{})
 	assert.NoError(t, err)
 
+	err = fconn.WriteFrames(
+		&frame.DataFrame{Tag: 1, Payload: []byte("a")},
+		&frame.DataFrame{Tag: 2, Payload: []byte("b")},
+	)
+	assert.NoError(t, err)
+
 	for {
 		f, err := fconn.ReadFrame()
 		if err != nil {
@@ -64,6 +70,15 @@ func runListener(t *testing.T) error {
 	assert.NoError(t, err)
 	assert.Equal(t, f.Type(), frame.TypeHandshakeAckFrame)
 
+	// the frames written by WriteFrames.
+	for _, want := range []*frame.DataFrame{{Tag: 1, Payload: []byte("a")}, {Tag: 2, Payload: []byte("b")}} {
+		f, err := fconn.ReadFrame()
+		assert.NoError(t, err)
+		df := f.(*frame.DataFrame)
+		assert.Equal(t, want.Tag, df.Tag)
+		assert.Equal(t, want.Payload, df.Payload)
+	}
+
 	if err := fconn.WriteFrame(&frame.HandshakeFrame{Name: handshakeName}); err != nil {
 		return err
 	}
@@ -84,3 +99,131 @@ func runListener(t *testing.T) error {
 
 	return nil
 }
+
+func TestControlStream(t *testing.T) {
+	const controlHost = "localhost:9009"
+
+	listener, err := ListenAddr(controlHost, y3codec.Codec(), y3codec.PacketReadWriter(), pkgtls.MustCreateServerTLSConfig(controlHost), nil)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		fconn, err := listener.Accept(context.TODO())
+		if err != nil {
+			return
+		}
+		cc := fconn.(frame.ControlConn)
+		f, err := cc.ReadControlFrame()
+		assert.NoError(t, err)
+		_ = cc.WriteControlFrame(&frame.PongFrame{Payload: f.(*frame.PingFrame).Payload})
+	}()
+
+	fconn, err := DialAddr(context.TODO(), controlHost,
+		y3codec.Codec(), y3codec.PacketReadWriter(),
+		pkgtls.MustCreateClientTLSConfig(), nil,
+	)
+	assert.NoError(t, err)
+	defer fconn.CloseWithError("bye")
+
+	assert.NoError(t, fconn.OpenControlStream())
+
+	// the data stream is not touched, the control frames are transmitted on the control stream.
+	assert.NoError(t, fconn.WriteControlFrame(&frame.PingFrame{Payload: []byte("ping")}))
+
+	f, err := fconn.ReadControlFrame()
+	assert.NoError(t, err)
+	assert.Equal(t, &frame.PongFrame{Payload: []byte("ping")}, f)
+}
+
+// corruptingCodec flips the last byte of the encoded DataFrames with the payload "bad".
+type corruptingCodec struct{ frame.Codec }
+
+func (c corruptingCodec) Encode(f frame.Frame) ([]byte, error) {
+	b, err := c.Codec.Encode(f)
+	if df, ok := f.(*frame.DataFrame); ok && err == nil && string(df.Payload) == "bad" {
+		b[len(b)-1] ^= 0xFF
+	}
+	return b, err
+}
+
+func TestCorruptedFrame(t *testing.T) {
+	const corruptedHost = "localhost:9012"
+
+	listener, err := ListenAddr(corruptedHost, y3codec.Codec(), y3codec.PacketReadWriter(), pkgtls.MustCreateServerTLSConfig(corruptedHost), nil)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	results := make(chan readResult, 3)
+	go func() {
+		fconn, err := listener.Accept(context.TODO())
+		if err != nil {
+			return
+		}
+		for i := 0; i < 3; i++ {
+			f, err := fconn.ReadFrame()
+			results <- readResult{frame: f, err: err}
+		}
+	}()
+
+	fconn, err := DialAddr(context.TODO(), corruptedHost,
+		corruptingCodec{y3codec.Codec(y3codec.WithChecksum())}, y3codec.PacketReadWriter(),
+		pkgtls.MustCreateClientTLSConfig(), nil,
+	)
+	assert.NoError(t, err)
+	defer fconn.CloseWithError("bye")
+
+	for _, payload := range []string{"good", "bad", "next"} {
+		assert.NoError(t, fconn.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte(payload)}))
+	}
+
+	// the corrupted frame is reported, and the stream keeps being read.
+	r := <-results
+	assert.NoError(t, r.err)
+	assert.Equal(t, []byte("good"), r.frame.(*frame.DataFrame).Payload)
+	r = <-results
+	assert.ErrorIs(t, r.err, y3codec.ErrChecksumMismatch)
+	r = <-results
+	assert.NoError(t, r.err)
+	assert.Equal(t, []byte("next"), r.frame.(*frame.DataFrame).Payload)
+}
+
+func TestOversizedPacket(t *testing.T) {
+	const oversizedHost = "localhost:9013"
+
+	limits := y3codec.WithLimits(frame.Limits{MaxPacketSize: 64})
+	listener, err := ListenAddr(oversizedHost, y3codec.Codec(limits), y3codec.PacketReadWriter(limits), pkgtls.MustCreateServerTLSConfig(oversizedHost), nil)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	results := make(chan readResult, 3)
+	go func() {
+		fconn, err := listener.Accept(context.TODO())
+		if err != nil {
+			return
+		}
+		for i := 0; i < 3; i++ {
+			f, err := fconn.ReadFrame()
+			results <- readResult{frame: f, err: err}
+		}
+	}()
+
+	fconn, err := DialAddr(context.TODO(), oversizedHost, y3codec.Codec(), y3codec.PacketReadWriter(), pkgtls.MustCreateClientTLSConfig(), nil)
+	assert.NoError(t, err)
+	defer fconn.CloseWithError("bye")
+
+	for _, payload := range [][]byte{[]byte("good"), make([]byte, 100), []byte("next")} {
+		assert.NoError(t, fconn.WriteFrame(&frame.DataFrame{Tag: 1, Payload: payload}))
+	}
+
+	// the oversized packet is discarded, and the stream keeps being read.
+	r := <-results
+	assert.NoError(t, r.err)
+	assert.Equal(t, []byte("good"), r.frame.(*frame.DataFrame).Payload)
+	r = <-results
+	me := new(frame.ErrMalformed)
+	assert.True(t, errors.As(r.err, &me))
+	assert.Equal(t, frame.TypeDataFrame, me.FrameType)
+	r = <-results
+	assert.NoError(t, r.err)
+	assert.Equal(t, []byte("next"), r.frame.(*frame.DataFrame).Payload)
+}
@@ -0,0 +1,225 @@
+package yquic
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/quic-go/quic-go"
+	"github.com/yomorun/yomo/core/frame"
+	"golang.org/x/exp/slices"
+)
+
+// The first byte of the streams opened after the first one declares the kind of the stream.
+const (
+	streamKindControl byte = 0x01
+	streamKindData    byte = 0x02
+)
+
+// StreamSelector chooses the data stream of the DataFrame, it returns an index in [0, n).
+// The frames chosen to the same stream keep the order, the streams do not block each other.
+type StreamSelector func(f *frame.DataFrame, n int) int
+
+// TagAffinity pins each tag to a data stream, so a heavy tag doesn't delay the others.
+func TagAffinity(f *frame.DataFrame, n int) int {
+	return int(f.Tag % uint32(n))
+}
+
+// PriorityAffinity pins each priority level to a data stream, the high priority frames are
+// written to the first stream.
+func PriorityAffinity(f *frame.DataFrame, n int) int {
+	return int(frame.PriorityHigh-f.Priority) % n
+}
+
+// ChannelAffinity pins each channel to a data stream, so thousands of logical streams share a bounded
+// number of QUIC streams, and the frames of a channel keep the order.
+func ChannelAffinity(f *frame.DataFrame, n int) int {
+	return int(f.Channel % uint32(n))
+}
+
+// RoundRobin returns a StreamSelector that spreads the DataFrames over the data streams in turn,
+// it balances the streams best, but the frames of a tag are no longer ordered.
+func RoundRobin() StreamSelector {
+	var next atomic.Uint64
+	return func(_ *frame.DataFrame, n int) int {
+		return int((next.Add(1) - 1) % uint64(n))
+	}
+}
+
+type readResult struct {
+	frame frame.Frame
+	err   error
+}
+
+// OpenDataStreams opens the data streams in addition to the first one, n is the total number of
+// data streams. The DataFrames are written to the stream chosen by the selector, TagAffinity is
+// used if selector is nil. The other frames are always written to the first stream.
+func (p *FrameConn) OpenDataStreams(n int, selector StreamSelector) error {
+	if selector == nil {
+		selector = TagAffinity
+	}
+	streams := []quic.Stream{p.stream}
+	for i := 1; i < n; i++ {
+		stream, err := p.openStream(streamKindData)
+		if err != nil {
+			return err
+		}
+		streams = append(streams, stream)
+	}
+	p.streams = streams
+	p.selector = selector
+	return nil
+}
+
+// openStream opens a stream and declares its kind, the peer accepts the stream at once.
+func (p *FrameConn) openStream(kind byte) (quic.Stream, error) {
+	stream, err := p.conn.OpenStream()
+	if err != nil {
+		return nil, handleError(err)
+	}
+	if _, err := stream.Write([]byte{kind}); err != nil {
+		return nil, handleError(err)
+	}
+	return stream, nil
+}
+
+// streamFor returns the stream to which the frame is written.
+func (p *FrameConn) streamFor(f frame.Frame) quic.Stream {
+	if len(p.streams) <= 1 {
+		return p.stream
+	}
+	df, ok := f.(*frame.DataFrame)
+	if !ok {
+		return p.stream
+	}
+	i := p.selector(df, len(p.streams))
+	if i < 0 || i >= len(p.streams) {
+		i = 0
+	}
+	if _, ok := p.canceled.Load(p.streams[i].StreamID()); ok {
+		return p.stream
+	}
+	return p.streams[i]
+}
+
+// DataStreamIDs returns the IDs of the data streams except the first one, they are the streams
+// opened by OpenDataStreams on the dialer side, or the streams accepted on the listener side.
+func (p *FrameConn) DataStreamIDs() []int64 {
+	var ids []int64
+	if p.readCh == nil {
+		for _, stream := range p.openedStreams() {
+			if _, ok := p.canceled.Load(stream.StreamID()); !ok {
+				ids = append(ids, int64(stream.StreamID()))
+			}
+		}
+		return ids
+	}
+	p.accepted.Range(func(key, _ any) bool {
+		ids = append(ids, int64(key.(quic.StreamID)))
+		return true
+	})
+	slices.Sort(ids)
+	return ids
+}
+
+// CancelStream cancels the data stream with the application error code, the frames chosen to the stream
+// are written to the first stream after that. The first stream cannot be canceled, cancel the connection instead.
+// The peer gets frame.ErrStreamCanceled from reading the stream if the dialer side cancels it,
+// or from writing the stream if the listener side cancels it.
+func (p *FrameConn) CancelStream(id int64, code uint64) error {
+	sid := quic.StreamID(id)
+	for _, stream := range p.openedStreams() {
+		if stream.StreamID() == sid {
+			p.canceled.Store(sid, struct{}{})
+			stream.CancelWrite(quic.StreamErrorCode(code))
+			return nil
+		}
+	}
+	if v, ok := p.accepted.LoadAndDelete(sid); ok {
+		v.(quic.Stream).CancelRead(quic.StreamErrorCode(code))
+		return nil
+	}
+	return fmt.Errorf("yquic: no data stream %d", id)
+}
+
+// openedStreams returns the data streams opened by OpenDataStreams except the first one.
+func (p *FrameConn) openedStreams() []quic.Stream {
+	if len(p.streams) <= 1 {
+		return nil
+	}
+	return p.streams[1:]
+}
+
+// handleStreamError converts the error of canceled stream to frame.ErrStreamCanceled,
+// the stream is not written anymore.
+func (p *FrameConn) handleStreamError(stream quic.Stream, err error) error {
+	if se := new(quic.StreamError); errors.As(err, &se) && stream != p.stream {
+		p.canceled.Store(stream.StreamID(), struct{}{})
+		return &frame.ErrStreamCanceled{StreamID: int64(se.StreamID), Code: uint64(se.ErrorCode), Remote: se.Remote}
+	}
+	return handleError(err)
+}
+
+// isStreamCanceled reports whether the error is caused by a canceled data stream.
+func isStreamCanceled(err error) bool {
+	se := new(frame.ErrStreamCanceled)
+	return errors.As(err, &se)
+}
+
+// acceptStreams accepts the streams opened by the dialer side after the first one.
+func (p *FrameConn) acceptStreams() {
+	for {
+		stream, err := p.conn.AcceptStream(p.conn.Context())
+		if err != nil {
+			return
+		}
+		kind := make([]byte, 1)
+		if _, err := io.ReadFull(stream, kind); err != nil {
+			continue
+		}
+		switch kind[0] {
+		case streamKindControl:
+			select {
+			case <-p.ctrlReady:
+				stream.CancelRead(0)
+			default:
+				p.ctrl = stream
+				close(p.ctrlReady)
+			}
+		case streamKindData:
+			p.accepted.Store(stream.StreamID(), stream)
+			go p.readStream(stream, false)
+		default:
+			stream.CancelRead(0)
+		}
+	}
+}
+
+// readStream reads the frames from the data stream to readCh, the error of broken stream is delivered only if
+// the stream is the first one, because the connection is broken after that, or if the stream is canceled by remote.
+// The errors of decoding are always delivered, and the stream keeps being read.
+func (p *FrameConn) readStream(stream quic.Stream, first bool) {
+	for {
+		f, broken, err := p.readFrom(stream)
+		if broken && !first {
+			p.accepted.Delete(stream.StreamID())
+			if se := new(quic.StreamError); errors.As(err, &se) && se.Remote {
+				err = &frame.ErrStreamCanceled{StreamID: int64(se.StreamID), Code: uint64(se.ErrorCode), Remote: true}
+				select {
+				case p.readCh <- readResult{err: err}:
+				case <-p.conn.Context().Done():
+				}
+			}
+			return
+		}
+		select {
+		case p.readCh <- readResult{frame: f, err: err}:
+		case <-p.conn.Context().Done():
+			return
+		}
+		if broken {
+			return
+		}
+	}
+}
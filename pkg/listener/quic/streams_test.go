@@ -0,0 +1,177 @@
+package yquic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/frame-codec/y3codec"
+	pkgtls "github.com/yomorun/yomo/pkg/tls"
+)
+
+func TestStreamSelector(t *testing.T) {
+	assert.Equal(t, 0, TagAffinity(&frame.DataFrame{Tag: 3}, 3))
+	assert.Equal(t, 1, TagAffinity(&frame.DataFrame{Tag: 4}, 3))
+
+	assert.Equal(t, 0, PriorityAffinity(&frame.DataFrame{Priority: frame.PriorityHigh}, 3))
+	assert.Equal(t, 1, PriorityAffinity(&frame.DataFrame{Priority: frame.PriorityNormal}, 3))
+	assert.Equal(t, 2, PriorityAffinity(&frame.DataFrame{Priority: frame.PriorityLow}, 3))
+	assert.Equal(t, 0, PriorityAffinity(&frame.DataFrame{Priority: frame.PriorityLow}, 2))
+
+	assert.Equal(t, 2, ChannelAffinity(&frame.DataFrame{Tag: 1, Channel: 1001}, 3))
+	assert.Equal(t, 0, ChannelAffinity(&frame.DataFrame{Tag: 1}, 3))
+
+	rr := RoundRobin()
+	for _, want := range []int{0, 1, 2, 0, 1} {
+		assert.Equal(t, want, rr(&frame.DataFrame{Tag: 1}, 3))
+	}
+}
+
+func TestDataStreams(t *testing.T) {
+	const streamsHost = "localhost:9010"
+
+	listener, err := ListenAddr(streamsHost, y3codec.Codec(), y3codec.PacketReadWriter(), pkgtls.MustCreateServerTLSConfig(streamsHost), nil)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan []frame.Frame)
+	go func() {
+		fconn, err := listener.Accept(context.TODO())
+		if err != nil {
+			return
+		}
+		var frames []frame.Frame
+		for i := 0; i < 7; i++ {
+			f, err := fconn.ReadFrame()
+			if err != nil {
+				break
+			}
+			frames = append(frames, f)
+		}
+		received <- frames
+	}()
+
+	fconn, err := DialAddr(context.TODO(), streamsHost,
+		y3codec.Codec(), y3codec.PacketReadWriter(),
+		pkgtls.MustCreateClientTLSConfig(), nil,
+	)
+	assert.NoError(t, err)
+	defer fconn.CloseWithError("bye")
+
+	assert.NoError(t, fconn.WriteFrame(&frame.HandshakeFrame{Name: "multi-streams"}))
+	assert.NoError(t, fconn.OpenDataStreams(3, nil))
+	assert.Len(t, fconn.streams, 3)
+
+	for tag := frame.Tag(0); tag < 3; tag++ {
+		assert.Equal(t, fconn.streams[tag], fconn.streamFor(&frame.DataFrame{Tag: tag}))
+		assert.NoError(t, fconn.WriteFrame(&frame.DataFrame{Tag: tag, Payload: []byte{0}}))
+	}
+	assert.NoError(t, fconn.WriteFrames(
+		&frame.DataFrame{Tag: 1, Payload: []byte{1}},
+		&frame.DataFrame{Tag: 2, Payload: []byte{1}},
+		&frame.DataFrame{Tag: 1, Payload: []byte{2}},
+	))
+
+	frames := <-received
+	assert.Len(t, frames, 7)
+
+	// the frames of a tag keep the order, the frames on different streams are not ordered.
+	payloads := map[frame.Tag][]byte{}
+	for _, f := range frames {
+		switch ff := f.(type) {
+		case *frame.HandshakeFrame:
+			assert.Equal(t, "multi-streams", ff.Name)
+		case *frame.DataFrame:
+			payloads[ff.Tag] = append(payloads[ff.Tag], ff.Payload...)
+		}
+	}
+	assert.Equal(t, map[frame.Tag][]byte{0: {0}, 1: {0, 1, 2}, 2: {0, 1}}, payloads)
+}
+
+func TestCancelStream(t *testing.T) {
+	const cancelHost = "localhost:9011"
+
+	listener, err := ListenAddr(cancelHost, y3codec.Codec(), y3codec.PacketReadWriter(), pkgtls.MustCreateServerTLSConfig(cancelHost), nil)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	accepted := make(chan *FrameConn)
+	go func() {
+		fconn, err := listener.Accept(context.TODO())
+		if err != nil {
+			return
+		}
+		accepted <- fconn.(*FrameConn)
+	}()
+
+	fconn, err := DialAddr(context.TODO(), cancelHost,
+		y3codec.Codec(), y3codec.PacketReadWriter(),
+		pkgtls.MustCreateClientTLSConfig(), nil,
+	)
+	assert.NoError(t, err)
+	defer fconn.CloseWithError("bye")
+
+	assert.NoError(t, fconn.WriteFrame(&frame.HandshakeFrame{Name: "cancel-streams"}))
+	assert.NoError(t, fconn.OpenDataStreams(3, nil))
+	ids := fconn.DataStreamIDs()
+	assert.Len(t, ids, 2)
+
+	// the streams are accepted once they are written.
+	for tag := frame.Tag(0); tag < 3; tag++ {
+		assert.NoError(t, fconn.WriteFrame(&frame.DataFrame{Tag: tag, Payload: []byte{0}}))
+	}
+	peer := <-accepted
+	for i := 0; i < 4; i++ {
+		_, err := peer.ReadFrame()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, ids, peer.DataStreamIDs())
+
+	t.Run("dialer cancels", func(t *testing.T) {
+		assert.NoError(t, fconn.CancelStream(ids[0], 7))
+		assert.Equal(t, ids[1:], fconn.DataStreamIDs())
+
+		_, err := peer.ReadFrame()
+		assert.Equal(t, &frame.ErrStreamCanceled{StreamID: ids[0], Code: 7, Remote: true}, err)
+
+		// the frames of the canceled stream go to the first stream.
+		assert.NoError(t, fconn.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte{1}}))
+		f, err := peer.ReadFrame()
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{1}, f.(*frame.DataFrame).Payload)
+	})
+
+	t.Run("listener cancels", func(t *testing.T) {
+		assert.NoError(t, peer.CancelStream(ids[1], 9))
+		assert.Empty(t, peer.DataStreamIDs())
+
+		var (
+			err error
+			n   byte = 10
+		)
+		assert.Eventually(t, func() bool {
+			n++
+			err = fconn.WriteFrames(
+				&frame.DataFrame{Tag: 2, Payload: []byte{n}},
+				&frame.DataFrame{Tag: 0, Payload: []byte{0}},
+			)
+			return err != nil
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, &frame.ErrStreamCanceled{StreamID: ids[1], Code: 9, Remote: true}, err)
+		assert.Empty(t, fconn.DataStreamIDs())
+
+		// the frame of the canceled stream is written to the first stream.
+		for {
+			f, err := peer.ReadFrame()
+			assert.NoError(t, err)
+			if df := f.(*frame.DataFrame); df.Tag == 2 && df.Payload[0] == n {
+				break
+			}
+		}
+	})
+
+	// the first stream cannot be canceled.
+	assert.Error(t, fconn.CancelStream(int64(fconn.stream.StreamID()), 0))
+}
@@ -0,0 +1,309 @@
+// Package ywebtransport transmits frames over WebTransport, it is the fallback of the raw QUIC transport
+// for the networks that only let HTTP/3 through, e.g. the firewalls blocking UDP except on port 443.
+package ywebtransport
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// YomoCloseErrorCode is the error code for closing the WebTransport session for yomo,
+// it is the same as the code of the raw QUIC connection.
+const YomoCloseErrorCode = webtransport.SessionErrorCode(0x13)
+
+// closeGrace bounds the waiting for the session being closed after a stream fails,
+// so the close error of the session is reported instead of the stream error.
+const closeGrace = time.Second
+
+// FrameConn is an implements of FrameConn,
+// It transmits frames upon the first stream of a WebTransport session.
+type FrameConn struct {
+	session *webtransport.Session
+	stream  webtransport.Stream
+	codec   frame.Codec
+	prw     frame.PacketReadWriter
+	writeMu sync.Mutex
+}
+
+var _ frame.WriteDeadliner = &FrameConn{}
+
+// DialAddr establishes a WebTransport session at the path of the address and returns a new FrameConn.
+func DialAddr(
+	ctx context.Context,
+	addr, path string,
+	codec frame.Codec, prw frame.PacketReadWriter,
+	tlsConfig *tls.Config, quicConfig *quic.Config,
+) (*FrameConn, error) {
+	// the dialer sets the defaults of WebTransport to the quic config, and HTTP/3 is dialed
+	// with a single QUIC version, which is the preferred one.
+	if quicConfig != nil {
+		quicConfig = quicConfig.Clone()
+		if len(quicConfig.Versions) > 1 {
+			quicConfig.Versions = quicConfig.Versions[:1]
+		}
+	}
+	rt := &http3.RoundTripper{TLSClientConfig: tlsConfig, QuicConfig: quicConfig}
+	dialer := &webtransport.Dialer{RoundTripper: rt}
+
+	u := url.URL{Scheme: "https", Host: addr, Path: path}
+	_, session, err := dialer.Dial(ctx, u.String(), nil)
+	if err != nil {
+		rt.Close()
+		return nil, err
+	}
+	// every session has its own QUIC connection, which is closed with the session.
+	go func() {
+		<-session.Context().Done()
+		dialer.Close()
+		rt.Close()
+	}()
+
+	stream, err := session.OpenStreamSync(ctx)
+	if err != nil {
+		_ = session.CloseWithError(0, "")
+		return nil, err
+	}
+
+	return newFrameConn(session, stream, codec, prw), nil
+}
+
+func newFrameConn(
+	session *webtransport.Session, stream webtransport.Stream,
+	codec frame.Codec, prw frame.PacketReadWriter,
+) *FrameConn {
+	return &FrameConn{
+		session: session,
+		stream:  stream,
+		codec:   codec,
+		prw:     prw,
+	}
+}
+
+// Context returns the context of the connection.
+func (p *FrameConn) Context() context.Context {
+	return p.session.Context()
+}
+
+// RemoteAddr returns the remote address of connection.
+func (p *FrameConn) RemoteAddr() net.Addr {
+	return p.session.RemoteAddr()
+}
+
+// LocalAddr returns the local address of connection.
+func (p *FrameConn) LocalAddr() net.Addr {
+	return p.session.LocalAddr()
+}
+
+// CloseWithError closes the connection.
+// After calling CloseWithError, ReadFrame and WriteFrame will return frame.ErrConnClosed error.
+func (p *FrameConn) CloseWithError(errString string) error {
+	return p.session.CloseWithError(YomoCloseErrorCode, errString)
+}
+
+// handleError reports the close error of the session if the stream fails because the session is closed,
+// the other errors are returned directly, e.g. the QUIC connection is broken.
+func (p *FrameConn) handleError(err error) error {
+	select {
+	case <-p.session.Context().Done():
+	case <-time.After(closeGrace):
+		return err
+	}
+	// the close error is returned once the session is closed.
+	_, closeErr := p.session.OpenStream()
+	if ce := new(webtransport.ConnectionError); errors.As(closeErr, &ce) && ce.ErrorCode == YomoCloseErrorCode {
+		return frame.NewErrConnClosed(ce.Remote, ce.Message)
+	}
+	return err
+}
+
+// ReadFrame reads a frame. it usually be called in a for-loop.
+func (p *FrameConn) ReadFrame() (frame.Frame, error) {
+	fType, b, err := p.prw.ReadPacket(p.stream)
+	if err != nil {
+		if me := new(frame.ErrMalformed); errors.As(err, &me) {
+			return nil, err
+		}
+		return nil, p.handleError(err)
+	}
+	f, err := frame.NewFrame(fType)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.codec.Decode(b, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// WriteFrame writes a frame to connection.
+func (p *FrameConn) WriteFrame(f frame.Frame) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	if err := p.writePacket(p.stream, f); err != nil {
+		return p.handleError(err)
+	}
+	return nil
+}
+
+// writePacket encodes the frame and writes it to w as a packet, the frame is encoded into w
+// directly if the PacketReadWriter supports it.
+func (p *FrameConn) writePacket(w io.Writer, f frame.Frame) error {
+	if pe, ok := p.prw.(frame.PacketEncoder); ok {
+		return pe.EncodePacket(w, p.codec, f)
+	}
+	b, err := p.codec.Encode(f)
+	if err != nil {
+		return err
+	}
+	return p.prw.WritePacket(w, f.Type(), b)
+}
+
+// SetWriteDeadline sets the write deadline of the stream.
+func (p *FrameConn) SetWriteDeadline(t time.Time) error {
+	return p.stream.SetWriteDeadline(t)
+}
+
+// Listener accepts the WebTransport sessions established at the path of a net.PacketConn.
+type Listener struct {
+	underlying *quic.EarlyListener
+	server     *webtransport.Server
+	codec      frame.Codec
+	prw        frame.PacketReadWriter
+	connCh     chan *FrameConn
+	done       chan struct{}
+	closeOnce  sync.Once
+	// sessions are the sessions upgraded, they are closed with the listener.
+	sessions sync.Map // *webtransport.Session -> struct{}
+}
+
+// Listen returns a Listener that accepts the sessions established at the path.
+// The origin of the requests is not checked, the clients are authenticated by the handshake of yomo.
+func Listen(
+	conn net.PacketConn,
+	path string,
+	codec frame.Codec, prw frame.PacketReadWriter,
+	tlsConfig *tls.Config, quicConfig *quic.Config,
+) (*Listener, error) {
+	if quicConfig == nil {
+		quicConfig = &quic.Config{}
+	}
+	quicConfig = quicConfig.Clone()
+	quicConfig.EnableDatagrams = true
+
+	ql, err := quic.ListenEarly(conn, http3.ConfigureTLSConfig(tlsConfig), quicConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	listener := &Listener{
+		underlying: ql,
+		codec:      codec,
+		prw:        prw,
+		connCh:     make(chan *FrameConn),
+		done:       make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, listener.upgrade)
+	listener.server = &webtransport.Server{
+		H3:          http3.Server{Handler: mux},
+		CheckOrigin: func(*http.Request) bool { return true },
+	}
+
+	go listener.serve()
+
+	return listener, nil
+}
+
+// ListenAddr listens an address and returns a new Listener.
+func ListenAddr(
+	addr, path string,
+	codec frame.Codec, prw frame.PacketReadWriter,
+	tlsConfig *tls.Config, quicConfig *quic.Config,
+) (*Listener, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return Listen(conn, path, codec, prw, tlsConfig, quicConfig)
+}
+
+func (listener *Listener) serve() {
+	for {
+		qconn, err := listener.underlying.Accept(context.Background())
+		if err != nil {
+			return
+		}
+		go listener.server.ServeQUICConn(qconn)
+	}
+}
+
+// upgrade upgrades the request to a session, the session is accepted once the client opens the stream.
+func (listener *Listener) upgrade(w http.ResponseWriter, r *http.Request) {
+	session, err := listener.server.Upgrade(w, r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	listener.sessions.Store(session, struct{}{})
+	go func() {
+		<-session.Context().Done()
+		listener.sessions.Delete(session)
+	}()
+
+	stream, err := session.AcceptStream(session.Context())
+	if err != nil {
+		return
+	}
+	select {
+	case listener.connCh <- newFrameConn(session, stream, listener.codec, listener.prw):
+	case <-listener.done:
+		_ = session.CloseWithError(YomoCloseErrorCode, "yomo: listener closed")
+	}
+}
+
+// Accept accepts FrameConns.
+func (listener *Listener) Accept(ctx context.Context) (frame.Conn, error) {
+	select {
+	case fconn := <-listener.connCh:
+		return fconn, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-listener.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close closes listener.
+// If listener be closed, all connections are closed with the message "yomo: listener closed".
+func (listener *Listener) Close() error {
+	var err error
+	listener.closeOnce.Do(func() {
+		close(listener.done)
+		listener.sessions.Range(func(key, _ any) bool {
+			_ = key.(*webtransport.Session).CloseWithError(YomoCloseErrorCode, "yomo: listener closed")
+			return true
+		})
+		err = listener.underlying.Close()
+		_ = listener.server.Close()
+	})
+	return err
+}
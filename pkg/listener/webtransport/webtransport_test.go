@@ -0,0 +1,103 @@
+package ywebtransport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/frame-codec/y3codec"
+	pkgtls "github.com/yomorun/yomo/pkg/tls"
+)
+
+const testPath = "/yomo"
+
+func TestFrameConnection(t *testing.T) {
+	const testHost = "localhost:9014"
+
+	listener, err := ListenAddr(testHost, testPath, y3codec.Codec(), y3codec.PacketReadWriter(), pkgtls.MustCreateServerTLSConfig(testHost), nil)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		fconn, err := listener.Accept(context.TODO())
+		if err != nil {
+			return
+		}
+		f, err := fconn.ReadFrame()
+		assert.NoError(t, err)
+		assert.Equal(t, &frame.DataFrame{Tag: 1, Payload: []byte("a")}, f)
+
+		assert.NoError(t, fconn.WriteFrame(&frame.HandshakeAckFrame{}))
+
+		time.AfterFunc(100*time.Millisecond, func() {
+			assert.NoError(t, fconn.CloseWithError("bye!"))
+			// close twice has no effect.
+			assert.NoError(t, fconn.CloseWithError("bye!"))
+
+			err := fconn.WriteFrame(&frame.DataFrame{Payload: []byte("aaaa")})
+			assert.Equal(t, frame.NewErrConnClosed(false, "bye!"), err)
+		})
+	}()
+
+	fconn, err := DialAddr(context.TODO(), testHost, testPath,
+		y3codec.Codec(), y3codec.PacketReadWriter(),
+		pkgtls.MustCreateClientTLSConfig(), nil,
+	)
+	assert.NoError(t, err)
+
+	assert.NoError(t, fconn.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("a")}))
+
+	f, err := fconn.ReadFrame()
+	assert.NoError(t, err)
+	assert.Equal(t, frame.TypeHandshakeAckFrame, f.Type())
+
+	_, err = fconn.ReadFrame()
+	assert.Equal(t, frame.NewErrConnClosed(true, "bye!"), err)
+}
+
+func TestListenerClose(t *testing.T) {
+	const closeHost = "localhost:9015"
+
+	listener, err := ListenAddr(closeHost, testPath, y3codec.Codec(), y3codec.PacketReadWriter(), pkgtls.MustCreateServerTLSConfig(closeHost), nil)
+	assert.NoError(t, err)
+
+	accepted := make(chan struct{})
+	go func() {
+		if _, err := listener.Accept(context.TODO()); err == nil {
+			close(accepted)
+		}
+	}()
+
+	fconn, err := DialAddr(context.TODO(), closeHost, testPath,
+		y3codec.Codec(), y3codec.PacketReadWriter(),
+		pkgtls.MustCreateClientTLSConfig(), nil,
+	)
+	assert.NoError(t, err)
+	// the stream is announced to the listener by the first write.
+	assert.NoError(t, fconn.WriteFrame(&frame.PingFrame{}))
+	<-accepted
+
+	assert.NoError(t, listener.Close())
+
+	_, err = fconn.ReadFrame()
+	assert.Equal(t, frame.NewErrConnClosed(true, "yomo: listener closed"), err)
+
+	_, err = listener.Accept(context.TODO())
+	assert.Error(t, err)
+}
+
+func TestDialWrongPath(t *testing.T) {
+	const pathHost = "localhost:9016"
+
+	listener, err := ListenAddr(pathHost, testPath, y3codec.Codec(), y3codec.PacketReadWriter(), pkgtls.MustCreateServerTLSConfig(pathHost), nil)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	_, err = DialAddr(context.TODO(), pathHost, "/other",
+		y3codec.Codec(), y3codec.PacketReadWriter(),
+		pkgtls.MustCreateClientTLSConfig(), nil,
+	)
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+// Package metrics exports the metrics of the zipper in the Prometheus format at `/metrics` of the admin
+// http server:
+//
+//	m := metrics.New()
+//	zipper, err := yomo.NewZipper(name, router, vgfn, mesh, yomo.WithZipperAdminAddr(":9000"),
+//		yomo.WithZipperServerOption(m.ServerOptions()...))
+//
+// The tags named by the tag namer of the zipper are labeled by their numbers, the others are labeled
+// "other", so the number of the series is bounded, see core.WithServerTagNamer. The metrics are:
+//
+//	yomo_zipper_connections{type}                  the connections by the client type.
+//	yomo_zipper_frames_total{tag}                  the DataFrames routed, rate() of it is the frames per second.
+//	yomo_zipper_bytes_total{tag}                   the payload bytes routed, rate() of it is the bytes per second.
+//	yomo_zipper_routing_duration_seconds{tag}      the histogram of the time routing a DataFrame.
+//	yomo_zipper_auth_failures_total                the handshakes failing the authentication.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// Path is the path of the metrics at the admin http server.
+const Path = "/metrics"
+
+// otherTag is the label of the tags that are not named.
+const otherTag = "other"
+
+var _ core.Plugin = (*Metrics)(nil)
+
+// Metrics collects the metrics of a zipper, it is a core.Plugin that watches the server
+// and a core.FrameMiddleware that measures the routing.
+type Metrics struct {
+	registry *prometheus.Registry
+	frames   *prometheus.CounterVec
+	bytes    *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	server   atomic.Pointer[core.Server]
+}
+
+// New returns the Metrics, the metrics are registered to its own registry, see Registry.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		frames: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "yomo_zipper_frames_total",
+			Help: "The number of the DataFrames routed by the zipper.",
+		}, []string{"tag"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "yomo_zipper_bytes_total",
+			Help: "The payload bytes of the DataFrames routed by the zipper.",
+		}, []string{"tag"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "yomo_zipper_routing_duration_seconds",
+			Help: "The time routing a DataFrame to the stream functions and the downstream zippers.",
+			// from 10us to about 2.6s.
+			Buckets: prometheus.ExponentialBuckets(0.00001, 4, 10),
+		}, []string{"tag"}),
+	}
+	m.registry.MustRegister(m.frames, m.bytes, m.latency, &serverCollector{m: m})
+
+	return m
+}
+
+// Registry returns the registry of the metrics, the custom metrics registered to it are exported as well.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler returns the http.Handler exporting the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ServerOptions returns the options that install the metrics to the server
+// and export them at Path of the admin http server.
+func (m *Metrics) ServerOptions() []core.ServerOption {
+	return []core.ServerOption{
+		core.WithPlugin(m),
+		core.WithFrameMiddleware(m.FrameMiddleware()),
+		core.WithAdminHandler(Path, m.Handler()),
+	}
+}
+
+// FrameMiddleware returns the middleware that counts the DataFrames routed and measures the time routing them,
+// the DataFrames dropped by the zipper are not counted.
+func (m *Metrics) FrameMiddleware() core.FrameMiddleware {
+	return func(next core.FrameHandler) core.FrameHandler {
+		return func(c *core.Context) {
+			// the context is reused after handling, so the frame is read before.
+			tag := m.tagLabel(c.Frame.Tag)
+			size := len(c.Frame.Payload)
+
+			start := time.Now()
+			next(c)
+			if !c.Routed() {
+				return
+			}
+			m.latency.WithLabelValues(tag).Observe(time.Since(start).Seconds())
+
+			m.frames.WithLabelValues(tag).Inc()
+			m.bytes.WithLabelValues(tag).Add(float64(size))
+		}
+	}
+}
+
+// tagLabel returns the label of the tag, it is otherTag if the tag is not named by the server.
+func (m *Metrics) tagLabel(tag frame.Tag) string {
+	s := m.server.Load()
+	if s == nil || core.TagName(s.TagNamer(), tag) == "" {
+		return otherTag
+	}
+	return strconv.FormatUint(uint64(tag), 10)
+}
+
+// Name implements core.Plugin.
+func (m *Metrics) Name() string { return "metrics" }
+
+// OnStart implements core.Plugin, it watches the server.
+func (m *Metrics) OnStart(s *core.Server) error {
+	m.server.Store(s)
+	return nil
+}
+
+// OnConnection implements core.Plugin.
+func (m *Metrics) OnConnection(*core.Connection) error { return nil }
+
+// OnFrame implements core.Plugin.
+func (m *Metrics) OnFrame(*core.Context) error { return nil }
+
+// OnClose implements core.Plugin.
+func (m *Metrics) OnClose(*core.Server) {}
+
+var (
+	connectionsDesc = prometheus.NewDesc(
+		"yomo_zipper_connections", "The number of the connections by the client type.", []string{"type"}, nil,
+	)
+	authFailuresDesc = prometheus.NewDesc(
+		"yomo_zipper_auth_failures_total", "The number of the handshakes failing the authentication.", nil, nil,
+	)
+)
+
+// serverCollector collects the stats of the server at scraping.
+type serverCollector struct {
+	m *Metrics
+}
+
+func (c *serverCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- connectionsDesc
+	ch <- authFailuresDesc
+}
+
+func (c *serverCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.m.server.Load()
+	if s == nil {
+		return
+	}
+	for typ, n := range s.StatsConnections() {
+		ch <- prometheus.MustNewConstMetric(connectionsDesc, prometheus.GaugeValue, float64(n), typ)
+	}
+	ch <- prometheus.MustNewConstMetric(authFailuresDesc, prometheus.CounterValue, float64(s.StatsAuthFailures()))
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/ylog"
+	"golang.org/x/exp/slices"
+)
+
+var discardingLogger = ylog.NewFromConfig(ylog.Config{Output: "/dev/null", ErrorOutput: "/dev/null"})
+
+func TestMetrics(t *testing.T) {
+	const metricsAddr = "127.0.0.1:19964"
+
+	m := New()
+	server := core.NewServer("zipper", append(m.ServerOptions(),
+		core.WithAuth("token", "secret"),
+		core.WithServerTagNamer(core.TagNameMap{1: "temperature"}),
+		core.WithQuota(core.Quota{BytesPerSecond: 0.01, ByteBurst: 12}),
+		core.WithServerLogger(discardingLogger),
+	)...)
+	go server.ListenAndServe(context.TODO(), metricsAddr)
+	defer server.Close()
+
+	source := core.NewClient("source", metricsAddr, core.ClientTypeSource,
+		core.WithCredential("token:secret"), core.WithLogger(discardingLogger))
+	assert.NoError(t, source.Connect(context.TODO()))
+	defer source.Close()
+
+	intruder := core.NewClient("intruder", metricsAddr, core.ClientTypeSource,
+		core.WithCredential("token:wrong"), core.WithLogger(discardingLogger))
+	assert.Error(t, intruder.Connect(context.TODO()))
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 1, Payload: []byte("yomo")}))
+	}
+	// the tag is not named, and the large frame exceeds the quota.
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 2, Payload: make([]byte, 100)}))
+	assert.NoError(t, source.WriteFrame(&frame.DataFrame{Tag: 2, Payload: []byte("yomo")}))
+
+	want := []string{
+		`yomo_zipper_connections{type="Source"} 1`,
+		`yomo_zipper_frames_total{tag="1"} 2`,
+		`yomo_zipper_frames_total{tag="other"} 1`,
+		`yomo_zipper_bytes_total{tag="1"} 8`,
+		`yomo_zipper_bytes_total{tag="other"} 4`,
+		`yomo_zipper_routing_duration_seconds_count{tag="1"} 2`,
+		`yomo_zipper_auth_failures_total 1`,
+	}
+	assert.Eventually(t, func() bool {
+		w := httptest.NewRecorder()
+		m.Handler().ServeHTTP(w, httptest.NewRequest("GET", Path, nil))
+		lines := strings.Split(w.Body.String(), "\n")
+		for _, line := range want {
+			if !slices.Contains(lines, line) {
+				return false
+			}
+		}
+		return true
+	}, 3*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,138 @@
+// Package payload provides a content-type based codec registry for payloads,
+// the content type is carried by the `yomo-content-type` metadata which is set by writers.
+//
+// In source:
+//
+//	data, _ := payload.Marshal(payload.ContentTypeJSON, v)
+//	source.WriteWithEnvelope(0x33, data, envelope.Envelope{ContentType: payload.ContentTypeJSON})
+//
+// In stream function:
+//
+//	func Handler(ctx serverless.Context) {
+//		var v T
+//		payload.Decode(ctx, &v)
+//	}
+package payload
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/yomorun/yomo/pkg/envelope"
+	"github.com/yomorun/yomo/serverless"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// ContentTypeJSON is the content type of json.
+	ContentTypeJSON = "application/json"
+	// ContentTypeProtobuf is the content type of protocol buffers.
+	ContentTypeProtobuf = "application/x-protobuf"
+	// ContentTypeMsgpack is the content type of msgpack.
+	ContentTypeMsgpack = "application/msgpack"
+)
+
+// DefaultContentType is used when the incoming data has no content type.
+var DefaultContentType = ContentTypeJSON
+
+// ErrNotProtoMessage is returned by the protobuf codec if the value is not a proto.Message.
+var ErrNotProtoMessage = errors.New("payload: value is not a proto.Message")
+
+// Codec marshals and unmarshals payloads of a content type.
+type Codec interface {
+	// ContentType returns the content type that the codec handles.
+	ContentType() string
+	// Marshal encodes v to payload.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes payload to v.
+	Unmarshal(data []byte, v any) error
+}
+
+var codecs sync.Map
+
+func init() {
+	Register(jsonCodec{})
+	Register(protobufCodec{})
+	Register(msgpackCodec{})
+}
+
+// Register registers a codec, the codec with the same content type will be replaced.
+func Register(c Codec) {
+	codecs.Store(c.ContentType(), c)
+}
+
+// Get returns the codec of the content type.
+func Get(contentType string) (Codec, bool) {
+	v, ok := codecs.Load(contentType)
+	if !ok {
+		return nil, false
+	}
+	return v.(Codec), true
+}
+
+// Marshal encodes v with the codec of the content type.
+func Marshal(contentType string, v any) ([]byte, error) {
+	c, ok := Get(contentType)
+	if !ok {
+		return nil, fmt.Errorf("payload: no codec for content type %s", contentType)
+	}
+	return c.Marshal(v)
+}
+
+// Unmarshal decodes data with the codec of the content type.
+func Unmarshal(contentType string, data []byte, v any) error {
+	c, ok := Get(contentType)
+	if !ok {
+		return fmt.Errorf("payload: no codec for content type %s", contentType)
+	}
+	return c.Unmarshal(data, v)
+}
+
+// ContentType returns the content type of the incoming data of the sfn handler,
+// it returns DefaultContentType if the data has no content type.
+func ContentType(ctx serverless.Context) string {
+	if ct, ok := ctx.Metadata(envelope.ContentTypeKey); ok && ct != "" {
+		return ct
+	}
+	return DefaultContentType
+}
+
+// Decode decodes the incoming data of the sfn handler according to its content type.
+func Decode(ctx serverless.Context, v any) error {
+	return Unmarshal(ContentType(ctx), ctx.Data(), v)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string                { return ContentTypeJSON }
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string                { return ContentTypeMsgpack }
+func (msgpackCodec) Marshal(v any) ([]byte, error)      { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
+
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string { return ContentTypeProtobuf }
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, ErrNotProtoMessage
+	}
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	return proto.Unmarshal(data, m)
+}
@@ -0,0 +1,60 @@
+package payload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/pkg/envelope"
+	"github.com/yomorun/yomo/serverless/testkit"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type noise struct {
+	Noise float32 `json:"noise" msgpack:"noise"`
+	From  string  `json:"from" msgpack:"from"`
+}
+
+func TestDecode(t *testing.T) {
+	v := noise{Noise: 1.5, From: "yomo"}
+
+	for _, ct := range []string{ContentTypeJSON, ContentTypeMsgpack} {
+		t.Run(ct, func(t *testing.T) {
+			data, err := Marshal(ct, v)
+			assert.NoError(t, err)
+
+			ctx := testkit.NewContext(0x33, data, testkit.WithMetadataKV(envelope.ContentTypeKey, ct))
+			assert.Equal(t, ct, ContentType(ctx))
+
+			var got noise
+			assert.NoError(t, Decode(ctx, &got))
+			assert.Equal(t, v, got)
+		})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		ctx := testkit.NewContext(0x33, []byte(`{"noise":1.5,"from":"yomo"}`))
+		assert.Equal(t, ContentTypeJSON, ContentType(ctx))
+
+		var got noise
+		assert.NoError(t, Decode(ctx, &got))
+		assert.Equal(t, v, got)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		ctx := testkit.NewContext(0x33, nil, testkit.WithMetadataKV(envelope.ContentTypeKey, "text/unknown"))
+		assert.EqualError(t, Decode(ctx, &noise{}), "payload: no codec for content type text/unknown")
+	})
+}
+
+func TestProtobuf(t *testing.T) {
+	data, err := Marshal(ContentTypeProtobuf, wrapperspb.String("yomo"))
+	assert.NoError(t, err)
+
+	got := &wrapperspb.StringValue{}
+	assert.NoError(t, Unmarshal(ContentTypeProtobuf, data, got))
+	assert.Equal(t, "yomo", got.GetValue())
+
+	_, err = Marshal(ContentTypeProtobuf, noise{})
+	assert.Equal(t, ErrNotProtoMessage, err)
+	assert.Equal(t, ErrNotProtoMessage, Unmarshal(ContentTypeProtobuf, data, &noise{}))
+}
@@ -0,0 +1,247 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/yomorun/yomo/core"
+)
+
+// NewJSONValidator returns the validator of the JSON payloads by the JSON Schema. It supports the subset of
+// the validation keywords that are checked locally: type, enum, const, properties, required,
+// additionalProperties (boolean), items (a single schema), minItems, maxItems, minLength, maxLength,
+// pattern, minimum, maximum, exclusiveMinimum and exclusiveMaximum (numbers). The annotations, e.g. title,
+// are ignored, and the schema using the other keywords, e.g. $ref or anyOf, is rejected.
+func NewJSONValidator(schema []byte) (core.PayloadValidator, error) {
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, fmt.Errorf("schema: invalid JSON Schema: %w", err)
+	}
+	return core.PayloadValidatorFunc(func(payload []byte) error {
+		var v any
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+		return s.validate("$", v)
+	}), nil
+}
+
+// unsupportedKeywords are the keywords that change the validation but are not supported,
+// the schema using them is rejected instead of being validated partially.
+var unsupportedKeywords = []string{
+	"$ref", "$defs", "definitions", "allOf", "anyOf", "oneOf", "not", "if", "then", "else",
+	"patternProperties", "propertyNames", "dependentRequired", "dependentSchemas", "dependencies",
+	"prefixItems", "contains", "uniqueItems", "multipleOf", "minProperties", "maxProperties", "format",
+}
+
+type jsonSchema struct {
+	Type                 jsonTypes              `json:"type"`
+	Enum                 []any                  `json:"enum"`
+	Const                json.RawMessage        `json:"const"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Pattern              string                 `json:"pattern"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum"`
+
+	constValue any
+	pattern    *regexp.Regexp
+}
+
+func (s *jsonSchema) UnmarshalJSON(data []byte) error {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return err
+	}
+	for _, k := range unsupportedKeywords {
+		if _, ok := keywords[k]; ok {
+			return fmt.Errorf("unsupported keyword %s", k)
+		}
+	}
+
+	type plain jsonSchema
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+	if s.Const != nil {
+		if err := json.Unmarshal(s.Const, &s.constValue); err != nil {
+			return err
+		}
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		s.pattern = re
+	}
+	return nil
+}
+
+// jsonTypes is the type keyword, it is a type name or an array of the names.
+type jsonTypes []string
+
+func (t *jsonTypes) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return json.Unmarshal(data, (*[]string)(t))
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	*t = jsonTypes{name}
+	return nil
+}
+
+func (s *jsonSchema) validate(path string, v any) error {
+	if len(s.Type) > 0 && !s.matchType(v) {
+		return fmt.Errorf("%s: expected %v, got %s", path, s.typeNames(), jsonTypeOf(v))
+	}
+	if s.Enum != nil && !containsValue(s.Enum, v) {
+		return fmt.Errorf("%s: value is not one of the enum", path)
+	}
+	if s.Const != nil && !reflect.DeepEqual(s.constValue, v) {
+		return fmt.Errorf("%s: value is not the const", path)
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		return s.validateObject(path, v)
+	case []any:
+		return s.validateArray(path, v)
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			return fmt.Errorf("%s: length %d is less than %d", path, n, *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return fmt.Errorf("%s: length %d is greater than %d", path, n, *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return fmt.Errorf("%s: value does not match the pattern %s", path, s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			return fmt.Errorf("%s: %v is less than %v", path, v, *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			return fmt.Errorf("%s: %v is greater than %v", path, v, *s.Maximum)
+		}
+		if s.ExclusiveMinimum != nil && v <= *s.ExclusiveMinimum {
+			return fmt.Errorf("%s: %v is not greater than %v", path, v, *s.ExclusiveMinimum)
+		}
+		if s.ExclusiveMaximum != nil && v >= *s.ExclusiveMaximum {
+			return fmt.Errorf("%s: %v is not less than %v", path, v, *s.ExclusiveMaximum)
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) validateObject(path string, v map[string]any) error {
+	for _, name := range s.Required {
+		if _, ok := v[name]; !ok {
+			return fmt.Errorf("%s: missing required property %s", path, name)
+		}
+	}
+	// the properties are validated in order, so the error is deterministic.
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ps, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				return fmt.Errorf("%s: additional property %s is not allowed", path, name)
+			}
+			continue
+		}
+		if err := ps.validate(path+"."+name, v[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) validateArray(path string, v []any) error {
+	if s.MinItems != nil && len(v) < *s.MinItems {
+		return fmt.Errorf("%s: %d items are less than %d", path, len(v), *s.MinItems)
+	}
+	if s.MaxItems != nil && len(v) > *s.MaxItems {
+		return fmt.Errorf("%s: %d items are more than %d", path, len(v), *s.MaxItems)
+	}
+	if s.Items == nil {
+		return nil
+	}
+	for i, item := range v {
+		if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) matchType(v any) bool {
+	got := jsonTypeOf(v)
+	for _, want := range s.Type {
+		if want == got || (want == "number" && got == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *jsonSchema) typeNames() any {
+	if len(s.Type) == 1 {
+		return s.Type[0]
+	}
+	return []string(s.Type)
+}
+
+// jsonTypeOf returns the JSON Schema type of the value decoded by encoding/json,
+// the numbers without fractional part are integers.
+func jsonTypeOf(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+func containsValue(values []any, v any) bool {
+	for _, value := range values {
+		if reflect.DeepEqual(value, v) {
+			return true
+		}
+	}
+	return false
+}
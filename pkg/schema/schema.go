@@ -0,0 +1,98 @@
+// Package schema provides the payload validators of zipper, the payloads of a tag are validated against
+// a JSON Schema or a protobuf message descriptor where they enter, see core.WithPayloadValidator.
+//
+//	validator, err := schema.NewJSONValidator([]byte(`{"type": "object", "required": ["noise"]}`))
+//	zipper := yomo.NewZipper("zipper", router.Default(), nil, nil, yomo.WithZipperPayloadValidator(0x33, validator))
+package schema
+
+import (
+	"fmt"
+
+	"github.com/yomorun/yomo/core"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// NewProtoValidator returns the validator of the payloads encoded from the message named messageName,
+// e.g. "noise.v1.Noise", the message is found in descriptorSet, which is a FileDescriptorSet serialized
+// in the binary format, e.g. the output of `protoc --include_imports --descriptor_set_out`.
+func NewProtoValidator(descriptorSet []byte, messageName string) (core.PayloadValidator, error) {
+	var fds descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(descriptorSet, &fds); err != nil {
+		return nil, fmt.Errorf("schema: invalid descriptor set: %w", err)
+	}
+	files, err := protodesc.NewFiles(&fds)
+	if err != nil {
+		return nil, fmt.Errorf("schema: invalid descriptor set: %w", err)
+	}
+	d, err := files.FindDescriptorByName(protoreflect.FullName(messageName))
+	if err != nil {
+		return nil, fmt.Errorf("schema: message %s: %w", messageName, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("schema: %s is not a message", messageName)
+	}
+	return NewProtoMessageValidator(md), nil
+}
+
+// NewProtoMessageValidator returns the validator of the payloads encoded from the message of the descriptor.
+// The payload is valid if it is decoded as the message, the required fields are set and the known fields
+// are encoded in their wire types. The unknown fields are accepted, so the writers are free to add fields.
+func NewProtoMessageValidator(md protoreflect.MessageDescriptor) core.PayloadValidator {
+	return core.PayloadValidatorFunc(func(payload []byte) error {
+		m := dynamicpb.NewMessage(md)
+		if err := proto.Unmarshal(payload, m); err != nil {
+			return err
+		}
+		return checkWireTypes(m)
+	})
+}
+
+// checkWireTypes reports the known fields encoded in wrong wire types, proto.Unmarshal keeps them as
+// the unknown fields silently.
+func checkWireTypes(m protoreflect.Message) error {
+	unknown := m.GetUnknown()
+	for len(unknown) > 0 {
+		num, typ, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if fd := m.Descriptor().Fields().ByNumber(num); fd != nil {
+			return fmt.Errorf("field %s is encoded in wrong wire type %d", fd.FullName(), typ)
+		}
+		skip := protowire.ConsumeFieldValue(num, typ, unknown[n:])
+		if skip < 0 {
+			return protowire.ParseError(skip)
+		}
+		unknown = unknown[n+skip:]
+	}
+
+	var err error
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Message() == nil {
+			return true
+		}
+		switch {
+		case fd.IsList():
+			for i := 0; i < v.List().Len() && err == nil; i++ {
+				err = checkWireTypes(v.List().Get(i).Message())
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					err = checkWireTypes(mv.Message())
+					return err == nil
+				})
+			}
+		default:
+			err = checkWireTypes(v.Message())
+		}
+		return err == nil
+	})
+	return err
+}
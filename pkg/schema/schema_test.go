@@ -0,0 +1,92 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestJSONValidator(t *testing.T) {
+	validator, err := NewJSONValidator([]byte(`{
+		"title": "noise",
+		"type": "object",
+		"required": ["noise", "from"],
+		"additionalProperties": false,
+		"properties": {
+			"noise": {"type": "number", "minimum": 0, "exclusiveMaximum": 100},
+			"from": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+			"level": {"enum": ["low", "high"]},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "integer"}},
+			"note": {"type": ["string", "null"]}
+		}
+	}`))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		payload string
+		err     string
+	}{
+		{`{"noise": 1.5, "from": "yomo"}`, ""},
+		{`{"noise": 1, "from": "yomo", "level": "high", "tags": [1, 2], "note": null}`, ""},
+		{`{"noise": 1.5`, "invalid JSON"},
+		{`[]`, "$: expected object, got array"},
+		{`{"noise": 1.5}`, "$: missing required property from"},
+		{`{"noise": "1.5", "from": "yomo"}`, "$.noise: expected number, got string"},
+		{`{"noise": -1, "from": "yomo"}`, "$.noise: -1 is less than 0"},
+		{`{"noise": 100, "from": "yomo"}`, "$.noise: 100 is not less than 100"},
+		{`{"noise": 1, "from": ""}`, "$.from: length 0 is less than 1"},
+		{`{"noise": 1, "from": "YoMo"}`, "$.from: value does not match the pattern ^[a-z]+$"},
+		{`{"noise": 1, "from": "yomo", "level": "mid"}`, "$.level: value is not one of the enum"},
+		{`{"noise": 1, "from": "yomo", "tags": [1, 2, 3]}`, "$.tags: 3 items are more than 2"},
+		{`{"noise": 1, "from": "yomo", "tags": [1.5]}`, "$.tags[0]: expected integer, got number"},
+		{`{"noise": 1, "from": "yomo", "note": 1}`, "$.note: expected [string null], got integer"},
+		{`{"noise": 1, "from": "yomo", "extra": 1}`, "$: additional property extra is not allowed"},
+	}
+	for _, tt := range tests {
+		err := validator.Validate([]byte(tt.payload))
+		if tt.err == "" {
+			assert.NoError(t, err, tt.payload)
+		} else {
+			assert.ErrorContains(t, err, tt.err, tt.payload)
+		}
+	}
+
+	t.Run("invalid schema", func(t *testing.T) {
+		_, err := NewJSONValidator([]byte(`{"anyOf": [{"type": "string"}]}`))
+		assert.ErrorContains(t, err, "unsupported keyword anyOf")
+
+		_, err = NewJSONValidator([]byte(`{"properties": {"a": {"$ref": "#/a"}}}`))
+		assert.ErrorContains(t, err, "unsupported keyword $ref")
+
+		_, err = NewJSONValidator([]byte(`{"pattern": "("}`))
+		assert.ErrorContains(t, err, "invalid pattern")
+	})
+}
+
+func TestProtoValidator(t *testing.T) {
+	fds := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(wrapperspb.File_google_protobuf_wrappers_proto)},
+	}
+	descriptorSet, err := proto.Marshal(fds)
+	assert.NoError(t, err)
+
+	validator, err := NewProtoValidator(descriptorSet, "google.protobuf.StringValue")
+	assert.NoError(t, err)
+
+	payload, err := proto.Marshal(wrapperspb.String("yomo"))
+	assert.NoError(t, err)
+	assert.NoError(t, validator.Validate(payload))
+
+	// the field 1 is a string, the varint does not match.
+	assert.Error(t, validator.Validate([]byte{0x08, 0x01}))
+	assert.Error(t, validator.Validate([]byte{0xff}))
+
+	_, err = NewProtoValidator(descriptorSet, "google.protobuf.NoSuchValue")
+	assert.Error(t, err)
+	_, err = NewProtoValidator([]byte{0xff}, "google.protobuf.StringValue")
+	assert.Error(t, err)
+}
@@ -0,0 +1,440 @@
+// Package simulator models the queue depths, bandwidth and latency of a yomo deployment,
+// it helps to size the deployment before going to production.
+//
+// The simulation is described by a yaml file like:
+//
+//	duration: 60s
+//	step: 100ms
+//	zippers:
+//	  - name: zipper-us
+//	  - name: zipper-eu
+//	links:
+//	  - from: zipper-us
+//	    to: zipper-eu
+//	    bandwidth: 1000000 # bytes per second
+//	    latency: 80ms
+//	sources:
+//	  - name: sensor
+//	    zipper: zipper-us
+//	    tag: 0x33
+//	    rate: 1000 # frames per second
+//	    size: 256  # bytes
+//	functions:
+//	  - name: noise
+//	    zipper: zipper-eu
+//	    observe: [0x33]
+//	    throughput: 800 # frames per second
+//	    latency: 2ms
+//	    output: { tag: 0x34, size: 64 }
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec describes the topology and the traffic profiles to be simulated.
+type Spec struct {
+	// Duration is the simulated duration, the default is 60s.
+	Duration time.Duration `yaml:"duration"`
+	// Step is the simulation step, the default is 100ms.
+	Step time.Duration `yaml:"step"`
+	// Zippers is the zippers in the mesh.
+	Zippers []Zipper `yaml:"zippers"`
+	// Links is the links between zippers, the frames are dispatched from the zipper to the downstream zipper.
+	Links []Link `yaml:"links"`
+	// Sources is the sources and their rate/size profiles.
+	Sources []Source `yaml:"sources"`
+	// Functions is the stream functions and their capacities.
+	Functions []Function `yaml:"functions"`
+}
+
+// Zipper is a zipper in the mesh.
+type Zipper struct {
+	Name string `yaml:"name"`
+}
+
+// Link is a link from a zipper to its downstream zipper.
+type Link struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+	// Bandwidth is the bytes per second of the link, 0 means unlimited.
+	Bandwidth float64 `yaml:"bandwidth"`
+	// Latency is the propagation latency of the link.
+	Latency time.Duration `yaml:"latency"`
+}
+
+// Source is a source writing frames of a tag to a zipper.
+type Source struct {
+	Name   string `yaml:"name"`
+	Zipper string `yaml:"zipper"`
+	Tag    uint32 `yaml:"tag"`
+	// Rate is the frames per second.
+	Rate float64 `yaml:"rate"`
+	// Size is the payload size of the frames in bytes.
+	Size float64 `yaml:"size"`
+}
+
+// Function is a stream function connected to a zipper.
+type Function struct {
+	Name    string   `yaml:"name"`
+	Zipper  string   `yaml:"zipper"`
+	Observe []uint32 `yaml:"observe"`
+	// Throughput is the frames per second the function can process, 0 means unlimited.
+	Throughput float64 `yaml:"throughput"`
+	// Latency is the processing latency of a frame.
+	Latency time.Duration `yaml:"latency"`
+	// Output is the frames written by the function, it is optional.
+	Output *Output `yaml:"output"`
+}
+
+// Output is the frames written by a function for every frame processed.
+type Output struct {
+	Tag  uint32  `yaml:"tag"`
+	Size float64 `yaml:"size"`
+}
+
+// ParseSpecFile parses the simulation spec from a yaml file.
+func ParseSpecFile(path string) (*Spec, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSpec(buf)
+}
+
+// ParseSpec parses the simulation spec from yaml bytes.
+func ParseSpec(buf []byte) (*Spec, error) {
+	spec := &Spec{}
+	if err := yaml.Unmarshal(buf, spec); err != nil {
+		return nil, err
+	}
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func (s *Spec) validate() error {
+	if s.Duration <= 0 {
+		s.Duration = time.Minute
+	}
+	if s.Step <= 0 {
+		s.Step = 100 * time.Millisecond
+	}
+	if s.Step > s.Duration {
+		return errors.New("simulator: step should not be greater than duration")
+	}
+
+	zippers := make(map[string]struct{}, len(s.Zippers))
+	for _, z := range s.Zippers {
+		if _, ok := zippers[z.Name]; ok {
+			return fmt.Errorf("simulator: duplicate zipper %q", z.Name)
+		}
+		zippers[z.Name] = struct{}{}
+	}
+	check := func(kind, name, zipper string) error {
+		if _, ok := zippers[zipper]; !ok {
+			return fmt.Errorf("simulator: %s %q refers to unknown zipper %q", kind, name, zipper)
+		}
+		return nil
+	}
+	for _, l := range s.Links {
+		if err := check("link", l.From+"->"+l.To, l.From); err != nil {
+			return err
+		}
+		if err := check("link", l.From+"->"+l.To, l.To); err != nil {
+			return err
+		}
+	}
+	for _, src := range s.Sources {
+		if err := check("source", src.Name, src.Zipper); err != nil {
+			return err
+		}
+	}
+	for _, fn := range s.Functions {
+		if err := check("function", fn.Name, fn.Zipper); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Report is the result of a simulation.
+type Report struct {
+	Zippers   []ZipperReport
+	Links     []LinkReport
+	Functions []FunctionReport
+}
+
+// ZipperReport is the simulated load of a zipper.
+type ZipperReport struct {
+	Name string
+	// FramesPerSecond is the average frames received per second, including the frames from upstream zippers.
+	FramesPerSecond float64
+	// BytesPerSecond is the average bytes received per second.
+	BytesPerSecond float64
+}
+
+// LinkReport is the simulated load of a link.
+type LinkReport struct {
+	From string
+	To   string
+	// BytesPerSecond is the average bytes offered to the link per second.
+	BytesPerSecond float64
+	// Utilization is the offered bytes divided by the bandwidth, it is saturated if it is >= 1.
+	Utilization float64
+	// MaxQueueBytes is the max bytes waiting in the link.
+	MaxQueueBytes float64
+	// Latency is the average latency of the link, including the queueing delay.
+	Latency time.Duration
+}
+
+// FunctionReport is the simulated load of a function.
+type FunctionReport struct {
+	Name   string
+	Zipper string
+	// ArrivalRate is the average frames arrived per second.
+	ArrivalRate float64
+	// Utilization is the arrival rate divided by the throughput, it is saturated if it is >= 1.
+	Utilization float64
+	// MaxQueue is the max frames waiting in the function.
+	MaxQueue float64
+	// AvgQueue is the average frames waiting in the function.
+	AvgQueue float64
+	// Latency is the average latency of the function, including the queueing delay.
+	Latency time.Duration
+}
+
+// Saturated reports whether the function cannot keep up with the arrival rate.
+func (r FunctionReport) Saturated() bool { return r.Utilization >= 1 }
+
+// Saturated reports whether the link cannot keep up with the offered bytes.
+func (r LinkReport) Saturated() bool { return r.Utilization >= 1 }
+
+// traffic is the frames of each tag.
+type traffic map[uint32]float64
+
+// Run simulates the spec and returns the report.
+func Run(spec *Spec) *Report {
+	var (
+		dt      = spec.Step.Seconds()
+		steps   = int(spec.Duration / spec.Step)
+		seconds = float64(steps) * dt
+		sizes   = make(map[uint32]float64)
+
+		// the frames written by the functions in the previous step.
+		outputs = make(map[string]traffic)
+
+		linkQueues   = make([]traffic, len(spec.Links))
+		linkOffered  = make([]float64, len(spec.Links))
+		linkMaxQueue = make([]float64, len(spec.Links))
+		linkSumQueue = make([]float64, len(spec.Links))
+
+		fnQueues   = make([]float64, len(spec.Functions))
+		fnArrived  = make([]float64, len(spec.Functions))
+		fnMaxQueue = make([]float64, len(spec.Functions))
+		fnSumQueue = make([]float64, len(spec.Functions))
+
+		zipperFrames = make(map[string]float64)
+		zipperBytes  = make(map[string]float64)
+	)
+
+	for _, src := range spec.Sources {
+		sizes[src.Tag] = src.Size
+	}
+	for _, fn := range spec.Functions {
+		if fn.Output != nil {
+			sizes[fn.Output.Tag] = fn.Output.Size
+		}
+	}
+	for i := range linkQueues {
+		linkQueues[i] = make(traffic)
+	}
+
+	bytesOf := func(t traffic) float64 {
+		var b float64
+		for tag, n := range t {
+			b += n * sizes[tag]
+		}
+		return b
+	}
+
+	for step := 0; step < steps; step++ {
+		// the frames written to each zipper by local clients.
+		local := make(map[string]traffic)
+		for _, z := range spec.Zippers {
+			local[z.Name] = make(traffic)
+		}
+		for _, src := range spec.Sources {
+			local[src.Zipper][src.Tag] += src.Rate * dt
+		}
+		for zipper, out := range outputs {
+			for tag, n := range out {
+				local[zipper][tag] += n
+			}
+		}
+		outputs = make(map[string]traffic)
+
+		// the frames received by each zipper.
+		arrived := make(map[string]traffic)
+		for zipper, t := range local {
+			arrived[zipper] = make(traffic)
+			for tag, n := range t {
+				arrived[zipper][tag] += n
+			}
+		}
+
+		// the frames from upstream zippers are not dispatched again, it is the loop protection of zipper.
+		for i, l := range spec.Links {
+			q := linkQueues[i]
+			for tag, n := range local[l.From] {
+				q[tag] += n
+			}
+			linkOffered[i] += bytesOf(local[l.From])
+
+			ratio := 1.0
+			if l.Bandwidth > 0 {
+				if queued := bytesOf(q); queued > l.Bandwidth*dt {
+					ratio = l.Bandwidth * dt / queued
+				}
+			}
+			for tag, n := range q {
+				arrived[l.To][tag] += n * ratio
+				q[tag] = n * (1 - ratio)
+			}
+
+			queued := bytesOf(q)
+			linkSumQueue[i] += queued
+			linkMaxQueue[i] = math.Max(linkMaxQueue[i], queued)
+		}
+
+		for zipper, t := range arrived {
+			for _, n := range t {
+				zipperFrames[zipper] += n
+			}
+			zipperBytes[zipper] += bytesOf(t)
+		}
+
+		for i, fn := range spec.Functions {
+			var in float64
+			for _, tag := range fn.Observe {
+				in += arrived[fn.Zipper][tag]
+			}
+			fnArrived[i] += in
+			fnQueues[i] += in
+
+			processed := fnQueues[i]
+			if fn.Throughput > 0 {
+				processed = math.Min(processed, fn.Throughput*dt)
+			}
+			fnQueues[i] -= processed
+
+			fnSumQueue[i] += fnQueues[i]
+			fnMaxQueue[i] = math.Max(fnMaxQueue[i], fnQueues[i])
+
+			if fn.Output != nil && processed > 0 {
+				if outputs[fn.Zipper] == nil {
+					outputs[fn.Zipper] = make(traffic)
+				}
+				outputs[fn.Zipper][fn.Output.Tag] += processed
+			}
+		}
+	}
+
+	report := &Report{}
+	for _, z := range spec.Zippers {
+		report.Zippers = append(report.Zippers, ZipperReport{
+			Name:            z.Name,
+			FramesPerSecond: zipperFrames[z.Name] / seconds,
+			BytesPerSecond:  zipperBytes[z.Name] / seconds,
+		})
+	}
+	for i, l := range spec.Links {
+		r := LinkReport{
+			From:           l.From,
+			To:             l.To,
+			BytesPerSecond: linkOffered[i] / seconds,
+			MaxQueueBytes:  linkMaxQueue[i],
+			Latency:        l.Latency,
+		}
+		if l.Bandwidth > 0 {
+			r.Utilization = r.BytesPerSecond / l.Bandwidth
+			r.Latency += seconds2Duration(linkSumQueue[i] / float64(steps) / l.Bandwidth)
+		}
+		report.Links = append(report.Links, r)
+	}
+	for i, fn := range spec.Functions {
+		r := FunctionReport{
+			Name:        fn.Name,
+			Zipper:      fn.Zipper,
+			ArrivalRate: fnArrived[i] / seconds,
+			MaxQueue:    fnMaxQueue[i],
+			AvgQueue:    fnSumQueue[i] / float64(steps),
+			Latency:     fn.Latency,
+		}
+		if fn.Throughput > 0 {
+			r.Utilization = r.ArrivalRate / fn.Throughput
+			// Little's law, the queueing delay is the queue length divided by the service rate.
+			r.Latency += seconds2Duration(r.AvgQueue / fn.Throughput)
+		}
+		report.Functions = append(report.Functions, r)
+	}
+
+	return report
+}
+
+func seconds2Duration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// String returns the report as tables.
+func (r *Report) String() string {
+	var b strings.Builder
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ZIPPER\tFRAMES/S\tBYTES/S")
+	for _, z := range r.Zippers {
+		fmt.Fprintf(w, "%s\t%.1f\t%.1f\n", z.Name, z.FramesPerSecond, z.BytesPerSecond)
+	}
+	w.Flush()
+
+	if len(r.Links) > 0 {
+		b.WriteString("\n")
+		w = tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "LINK\tBYTES/S\tUTILIZATION\tMAX QUEUE BYTES\tLATENCY")
+		for _, l := range r.Links {
+			fmt.Fprintf(w, "%s -> %s\t%.1f\t%s\t%.1f\t%s\n",
+				l.From, l.To, l.BytesPerSecond, utilization(l.Utilization, l.Saturated()), l.MaxQueueBytes, l.Latency)
+		}
+		w.Flush()
+	}
+
+	if len(r.Functions) > 0 {
+		b.WriteString("\n")
+		w = tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FUNCTION\tZIPPER\tARRIVAL/S\tUTILIZATION\tAVG QUEUE\tMAX QUEUE\tLATENCY")
+		for _, f := range r.Functions {
+			fmt.Fprintf(w, "%s\t%s\t%.1f\t%s\t%.1f\t%.1f\t%s\n",
+				f.Name, f.Zipper, f.ArrivalRate, utilization(f.Utilization, f.Saturated()), f.AvgQueue, f.MaxQueue, f.Latency)
+		}
+		w.Flush()
+	}
+
+	return b.String()
+}
+
+func utilization(u float64, saturated bool) string {
+	s := fmt.Sprintf("%.0f%%", u*100)
+	if saturated {
+		s += " (saturated)"
+	}
+	return s
+}
@@ -0,0 +1,91 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const spec = `
+duration: 10s
+step: 100ms
+zippers:
+  - name: us
+  - name: eu
+links:
+  - from: us
+    to: eu
+    bandwidth: 100000
+    latency: 80ms
+sources:
+  - name: sensor
+    zipper: us
+    tag: 0x33
+    rate: 1000
+    size: 200
+functions:
+  - name: noise
+    zipper: eu
+    observe: [0x33]
+    throughput: 800
+    latency: 2ms
+    output: { tag: 0x34, size: 10 }
+  - name: sink
+    zipper: eu
+    observe: [0x34]
+`
+
+func TestRun(t *testing.T) {
+	s, err := ParseSpec([]byte(spec))
+	assert.NoError(t, err)
+
+	report := Run(s)
+
+	// the link is saturated, 1000 frames * 200 bytes offered to a 100000 bytes/s link.
+	assert.Len(t, report.Links, 1)
+	assert.InDelta(t, 200000, report.Links[0].BytesPerSecond, 1)
+	assert.InDelta(t, 2, report.Links[0].Utilization, 0.01)
+	assert.True(t, report.Links[0].Saturated())
+	assert.Greater(t, report.Links[0].Latency, 80*time.Millisecond)
+
+	// the link delivers 500 frames per second to noise, it keeps up with them.
+	noise := report.Functions[0]
+	assert.InDelta(t, 500, noise.ArrivalRate, 1)
+	assert.False(t, noise.Saturated())
+	assert.Equal(t, 2*time.Millisecond, noise.Latency)
+
+	// sink receives the outputs of noise in the next step.
+	sink := report.Functions[1]
+	assert.InDelta(t, 495, sink.ArrivalRate, 1)
+	assert.Equal(t, float64(0), sink.Utilization)
+
+	assert.Contains(t, report.String(), "us -> eu")
+	assert.Contains(t, report.String(), "(saturated)")
+}
+
+func TestFunctionSaturated(t *testing.T) {
+	s := &Spec{
+		Duration:  time.Second,
+		Zippers:   []Zipper{{Name: "zipper"}},
+		Sources:   []Source{{Name: "source", Zipper: "zipper", Tag: 1, Rate: 200, Size: 1}},
+		Functions: []Function{{Name: "sfn", Zipper: "zipper", Observe: []uint32{1}, Throughput: 100}},
+	}
+	assert.NoError(t, s.validate())
+
+	fn := Run(s).Functions[0]
+	assert.InDelta(t, 2, fn.Utilization, 0.01)
+	assert.True(t, fn.Saturated())
+	assert.InDelta(t, 100, fn.MaxQueue, 0.01)
+}
+
+func TestParseSpecError(t *testing.T) {
+	_, err := ParseSpec([]byte("zippers: [{name: a}]\nsources: [{name: s, zipper: b}]"))
+	assert.EqualError(t, err, `simulator: source "s" refers to unknown zipper "b"`)
+
+	_, err = ParseSpec([]byte("zippers: [{name: a}, {name: a}]"))
+	assert.EqualError(t, err, `simulator: duplicate zipper "a"`)
+
+	_, err = ParseSpec([]byte("duration: 1s\nstep: 2s"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+// Package tags generates Go constants from a tag registry file.
+//
+// The registry file is a yaml file like:
+//
+//	package: tags
+//	tags:
+//	  sensor.temperature: 0x33
+//	  sensor.humidity: 0x34
+package tags
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Registry holds the tag names and their values.
+type Registry struct {
+	// Package is the package name of the generated code, the default is "tags".
+	Package string `yaml:"package"`
+	// Prefix is the prefix of the generated constants, the default is "Tag".
+	Prefix string `yaml:"prefix"`
+	// Tags maps the tag names to their values.
+	Tags map[string]uint32 `yaml:"tags"`
+}
+
+// ParseRegistryFile parses the tag registry from a yaml file.
+func ParseRegistryFile(path string) (*Registry, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRegistry(buf)
+}
+
+// ParseRegistry parses the tag registry from yaml bytes.
+func ParseRegistry(buf []byte) (*Registry, error) {
+	reg := &Registry{}
+	if err := yaml.Unmarshal(buf, reg); err != nil {
+		return nil, err
+	}
+	if reg.Package == "" {
+		reg.Package = "tags"
+	}
+	if reg.Prefix == "" {
+		reg.Prefix = "Tag"
+	}
+	if err := reg.validate(); err != nil {
+		return nil, err
+	}
+	return reg, nil
+}
+
+func (reg *Registry) validate() error {
+	if len(reg.Tags) == 0 {
+		return errors.New("tags: no tag in registry")
+	}
+	var (
+		names  = make(map[uint32]string, len(reg.Tags))
+		idents = make(map[string]string, len(reg.Tags))
+	)
+	for name, tag := range reg.Tags {
+		if other, ok := names[tag]; ok {
+			return fmt.Errorf("tags: %s and %s have the same tag %#x", other, name, tag)
+		}
+		names[tag] = name
+
+		ident := reg.Prefix + identifier(name)
+		if other, ok := idents[ident]; ok {
+			return fmt.Errorf("tags: %s and %s generate the same constant %s", other, name, ident)
+		}
+		idents[ident] = name
+	}
+	return nil
+}
+
+type entry struct {
+	Ident string
+	Name  string
+	Tag   uint32
+}
+
+// Generate generates go source code that contains the tag constants and a reverse-lookup map.
+func (reg *Registry) Generate() ([]byte, error) {
+	entries := make([]entry, 0, len(reg.Tags))
+	for name, tag := range reg.Tags {
+		entries = append(entries, entry{Ident: reg.Prefix + identifier(name), Name: name, Tag: tag})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Tag < entries[j].Tag })
+
+	buf := new(bytes.Buffer)
+	err := codeTmpl.Execute(buf, map[string]any{
+		"Package": reg.Package,
+		"Entries": entries,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// identifier converts a tag name like `sensor.temperature` to `SensorTemperature`.
+func identifier(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var codeTmpl = template.Must(template.New("tags").Parse(`// Code generated by "yomo tags gen"; DO NOT EDIT.
+
+package {{ .Package }}
+
+const (
+{{- range .Entries }}
+	// {{ .Ident }} is the tag of {{ printf "%q" .Name }}.
+	{{ .Ident }} uint32 = {{ printf "%#x" .Tag }}
+{{- end }}
+)
+
+// Names maps the tags to their names.
+var Names = map[uint32]string{
+{{- range .Entries }}
+	{{ .Ident }}: {{ printf "%q" .Name }},
+{{- end }}
+}
+`))
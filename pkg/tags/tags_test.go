@@ -0,0 +1,74 @@
+package tags
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	reg, err := ParseRegistry([]byte(`
+tags:
+  sensor.temperature: 0x33
+  sensor-humidity: 0x21
+`))
+	assert.NoError(t, err)
+
+	code, err := reg.Generate()
+	assert.NoError(t, err)
+
+	want := `// Code generated by "yomo tags gen"; DO NOT EDIT.
+
+package tags
+
+const (
+	// TagSensorHumidity is the tag of "sensor-humidity".
+	TagSensorHumidity uint32 = 0x21
+	// TagSensorTemperature is the tag of "sensor.temperature".
+	TagSensorTemperature uint32 = 0x33
+)
+
+// Names maps the tags to their names.
+var Names = map[uint32]string{
+	TagSensorHumidity:    "sensor-humidity",
+	TagSensorTemperature: "sensor.temperature",
+}
+`
+	assert.Equal(t, want, string(code))
+}
+
+func TestGenerateEscapesNames(t *testing.T) {
+	reg, err := ParseRegistry([]byte(`
+tags:
+  'sensor "a"': 0x33
+  'sensor\b': 0x34
+`))
+	assert.NoError(t, err)
+
+	code, err := reg.Generate()
+	assert.NoError(t, err)
+
+	assert.Contains(t, string(code), `// TagSensorA is the tag of "sensor \"a\"".`)
+	assert.Contains(t, string(code), `TagSensorA: "sensor \"a\"",`)
+	assert.Contains(t, string(code), `TagSensorB: "sensor\\b",`)
+}
+
+func TestParseRegistry(t *testing.T) {
+	t.Run("file not exist", func(t *testing.T) {
+		_, err := ParseRegistryFile(filepath.Join(t.TempDir(), "tags.yaml"))
+		assert.Error(t, err)
+	})
+	t.Run("empty", func(t *testing.T) {
+		_, err := ParseRegistry([]byte(`package: mytags`))
+		assert.EqualError(t, err, "tags: no tag in registry")
+	})
+	t.Run("duplicate tag", func(t *testing.T) {
+		_, err := ParseRegistry([]byte("tags:\n  a: 1\n  b: 1\n"))
+		assert.Error(t, err)
+	})
+	t.Run("duplicate constant", func(t *testing.T) {
+		_, err := ParseRegistry([]byte("tags:\n  a.b: 1\n  a-b: 2\n"))
+		assert.Error(t, err)
+	})
+}
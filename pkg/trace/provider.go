@@ -0,0 +1,50 @@
+//go:build !yomo_nootel
+
+package trace
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// NewTracerProvider creates a new tracer provider used by OTLP.
+func NewTracerProvider(service string) (*tracesdk.TracerProvider, func(ctx context.Context), error) {
+	// tracer provider
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		return nil, func(context.Context) {}, errors.New("tracing disabled")
+	}
+	// Create the OTLP exporter
+	client := otlptracehttp.NewClient()
+	exp, err := otlptrace.New(context.Background(), client)
+	if err != nil {
+		return nil, func(context.Context) {}, err
+	}
+	// tracer provider
+	tp := tracerProvider(service, exp)
+	// shutdown
+	shutdown := func(ctx context.Context) {
+		// Do not make the application hang when it is shutdown.
+		ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+		defer cancel()
+		if err := tp.Shutdown(ctx); err != nil {
+			log.Printf("[trace] shutdown err: %v\n", err)
+		}
+	}
+	// Register our TracerProvider as the global so any imported
+	// instrumentation in the future will default to using it.
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	// otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return tp, shutdown, nil
+}
@@ -0,0 +1,16 @@
+//go:build yomo_nootel
+
+package trace
+
+import (
+	"context"
+	"errors"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// NewTracerProvider always returns the error `tracing disabled`, the OTLP exporter is excluded
+// by the build tag `yomo_nootel` to slim the binary.
+func NewTracerProvider(service string) (*tracesdk.TracerProvider, func(ctx context.Context), error) {
+	return nil, func(context.Context) {}, errors.New("tracing disabled")
+}
@@ -4,15 +4,9 @@ package trace
 import (
 	"context"
 	"errors"
-	"log"
-	"os"
-	"time"
+	"sync/atomic"
 
-	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
-	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
@@ -24,53 +18,62 @@ import (
 // TracerProvider will also use a Resource configured with all the information
 // about the application.
 func tracerProvider(service string, exp tracesdk.SpanExporter) *tracesdk.TracerProvider {
+	attrs := []attribute.KeyValue{semconv.ServiceNameKey.String(service)}
+	for k, v := range getConvention().Resource {
+		attrs = append(attrs, attribute.Key(k).String(v))
+	}
 	tp := tracesdk.NewTracerProvider(
 		// Always be sure to batch in production.
 		tracesdk.WithBatcher(exp),
 		tracesdk.WithSampler(tracesdk.AlwaysSample()),
 		// tracesdk.WithSyncer(exp),
 		// Record information about this application in an Resource.
-		tracesdk.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(service),
-			// attribute.String("environment", environment),
-			// attribute.Int64("ID", id),
-		)),
+		tracesdk.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attrs...)),
 	)
 	return tp
 }
 
-// NewTracerProvider creates a new tracer provider used by OTLP.
-func NewTracerProvider(service string) (*tracesdk.TracerProvider, func(ctx context.Context), error) {
-	// tracer provider
-	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
-	if endpoint == "" {
-		return nil, func(context.Context) {}, errors.New("tracing disabled")
+// Convention customizes the spans created by yomo, so the traces conform to the semantic conventions
+// of an organization. The nil fields keep the default behavior.
+type Convention struct {
+	// SpanName returns the name of the span, the tracerName is `Source`, `StreamFunction` or `Zipper`,
+	// the spanName and attrs are the default name and attributes, e.g. the attrs has `yomo.tag`.
+	SpanName func(tracerName, spanName string, attrs map[string]string) string
+	// Attributes returns the attributes of the span, the arguments are the same as SpanName.
+	Attributes func(tracerName, spanName string, attrs map[string]string) map[string]string
+	// Resource is the extra resource attributes of the tracer provider, e.g. service.namespace.
+	Resource map[string]string
+}
+
+var convention atomic.Pointer[Convention]
+
+// SetConvention sets the convention of the spans, it should be called before creating the tracer provider.
+func SetConvention(c Convention) {
+	convention.Store(&c)
+}
+
+func getConvention() Convention {
+	if c := convention.Load(); c != nil {
+		return *c
 	}
-	// Create the OTLP exporter
-	client := otlptracehttp.NewClient()
-	exp, err := otlptrace.New(context.Background(), client)
-	if err != nil {
-		return nil, func(context.Context) {}, err
+	return Convention{}
+}
+
+// applyConvention returns the span name and attributes customized by the convention.
+func applyConvention(tracerName, spanName string, attrs []map[string]string) (string, map[string]string) {
+	var defaults map[string]string
+	if len(attrs) > 0 {
+		defaults = attrs[0]
 	}
-	// tracer provider
-	tp := tracerProvider(service, exp)
-	// shutdown
-	shutdown := func(ctx context.Context) {
-		// Do not make the application hang when it is shutdown.
-		ctx, cancel := context.WithTimeout(ctx, time.Second*5)
-		defer cancel()
-		if err := tp.Shutdown(ctx); err != nil {
-			log.Printf("[trace] shutdown err: %v\n", err)
-		}
+	c := getConvention()
+	name := spanName
+	if c.SpanName != nil {
+		name = c.SpanName(tracerName, spanName, defaults)
 	}
-	// Register our TracerProvider as the global so any imported
-	// instrumentation in the future will default to using it.
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.TraceContext{})
-	// otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
-
-	return tp, shutdown, nil
+	if c.Attributes != nil {
+		defaults = c.Attributes(tracerName, spanName, defaults)
+	}
+	return name, defaults
 }
 
 // NewSpan creates a new span of OpenTelemetry.
@@ -88,15 +91,14 @@ func NewSpanWithAttrs(tp trace.TracerProvider, tracerName string, spanName strin
 	if tp == nil {
 		return nil, errors.New("tracer provider is nil")
 	}
+	spanName, spanAttrs := applyConvention(tracerName, spanName, attrs)
 	ctx := context.Background()
 	// root span
 	if traceID == "" && spanID == "" {
 		tr := tp.Tracer(tracerName)
 		_, span := tr.Start(ctx, spanName)
-		if len(attrs) > 0 {
-			for k, v := range attrs[0] {
-				span.SetAttributes(attribute.Key(k).String(v))
-			}
+		for k, v := range spanAttrs {
+			span.SetAttributes(attribute.Key(k).String(v))
 		}
 		return span, nil
 	}
@@ -117,10 +119,8 @@ func NewSpanWithAttrs(tp trace.TracerProvider, tracerName string, spanName strin
 	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(scc))
 	tr := tp.Tracer(tracerName)
 	_, span := tr.Start(ctx, spanName)
-	if len(attrs) > 0 {
-		for k, v := range attrs[0] {
-			span.SetAttributes(attribute.Key(k).String(v))
-		}
+	for k, v := range spanAttrs {
+		span.SetAttributes(attribute.Key(k).String(v))
 	}
 	return span, nil
 }
@@ -0,0 +1,46 @@
+package trace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestConvention(t *testing.T) {
+	defer SetConvention(Convention{})
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+
+	// the default span.
+	span, err := NewSpanWithAttrs(tp, "Source", "my-source", "", "", false, map[string]string{"yomo.tag": "0x21"})
+	assert.NoError(t, err)
+	span.End()
+
+	SetConvention(Convention{
+		SpanName: func(tracerName, spanName string, attrs map[string]string) string {
+			return "publish " + attrs["yomo.tag"]
+		},
+		Attributes: func(tracerName, spanName string, attrs map[string]string) map[string]string {
+			return map[string]string{"messaging.destination.name": attrs["yomo.tag"], "yomo.component": tracerName}
+		},
+	})
+	span, err = NewSpanWithAttrs(tp, "Source", "my-source", "", "", false, map[string]string{"yomo.tag": "0x21"})
+	assert.NoError(t, err)
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+
+	assert.Equal(t, "my-source", spans[0].Name())
+	assert.Equal(t, []attribute.KeyValue{attribute.String("yomo.tag", "0x21")}, spans[0].Attributes())
+
+	assert.Equal(t, "publish 0x21", spans[1].Name())
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("messaging.destination.name", "0x21"),
+		attribute.String("yomo.component", "Source"),
+	}, spans[1].Attributes())
+}
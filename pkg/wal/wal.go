@@ -0,0 +1,239 @@
+// Package wal provides the write-ahead log of the DataFrames at zipper backed by BoltDB,
+// it is the default core.FrameStore:
+//
+//	store, err := wal.Open("/var/lib/yomo/wal.db", wal.WithMaxFrames(1_000_000))
+//	if err != nil {
+//		return err
+//	}
+//	defer store.Close()
+//
+//	zipper, err := yomo.NewZipper(name, router, vgfn, mesh, yomo.WithZipperWAL(store))
+//
+// The stream functions replay the DataFrames by yomo.WithSfnReplayFrom.
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/frame-codec/y3codec"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucket is the bucket of the DataFrames, the keys are the offsets in big endian.
+var bucket = []byte("frames")
+
+// replayBatchSize bounds the DataFrames read in a transaction, so a slow replay does not hold the transaction.
+const replayBatchSize = 256
+
+// appendBatchSize bounds the DataFrames committed in a transaction.
+const appendBatchSize = 256
+
+// ErrClosed is returned by appending to the closed Store.
+var ErrClosed = errors.New("wal: store closed")
+
+var _ core.FrameStore = (*Store)(nil)
+
+// Store is a core.FrameStore backed by a BoltDB file.
+//
+// The DataFrames appended concurrently are committed in a transaction by a writer goroutine, so the
+// throughput of appending is not bounded by the fsync of every DataFrame.
+type Store struct {
+	db        *bolt.DB
+	codec     frame.Codec
+	maxFrames uint64
+
+	appends chan *appendRequest
+	quit    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// appendRequest is a DataFrame waiting for the commit of the writer.
+type appendRequest struct {
+	data   []byte
+	offset uint64
+	done   chan error
+}
+
+// Option is the option of the Store.
+type Option func(*Store)
+
+// WithMaxFrames keeps the last n DataFrames, the older ones are removed as the new ones are appended.
+// The DataFrames are kept forever if n is 0, which is the default.
+func WithMaxFrames(n uint64) Option {
+	return func(s *Store) {
+		s.maxFrames = n
+	}
+}
+
+// Open opens the Store of the BoltDB file at path, the file is created if it does not exist.
+func Open(path string, opts ...Option) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	s := &Store{
+		db:      db,
+		codec:   y3codec.Codec(),
+		appends: make(chan *appendRequest),
+		quit:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+
+	go s.write()
+
+	return s, nil
+}
+
+// Append appends the DataFrame and returns its offset, it returns once the DataFrame is committed.
+func (s *Store) Append(df *frame.DataFrame) (uint64, error) {
+	data, err := s.codec.Encode(df)
+	if err != nil {
+		return 0, err
+	}
+
+	req := &appendRequest{data: data, done: make(chan error, 1)}
+	select {
+	case s.appends <- req:
+	case <-s.quit:
+		return 0, ErrClosed
+	}
+	select {
+	case err := <-req.done:
+		return req.offset, err
+	case <-s.stopped:
+		return 0, ErrClosed
+	}
+}
+
+// write commits the DataFrames appended, the ones appended while committing are committed together next time.
+func (s *Store) write() {
+	defer close(s.stopped)
+
+	batch := make([]*appendRequest, 0, appendBatchSize)
+	for {
+		select {
+		case req := <-s.appends:
+			batch = append(batch[:0], req)
+		case <-s.quit:
+			return
+		}
+	collect:
+		for len(batch) < appendBatchSize {
+			select {
+			case req := <-s.appends:
+				batch = append(batch, req)
+			default:
+				break collect
+			}
+		}
+
+		err := s.db.Update(func(tx *bolt.Tx) error { return s.commit(tx, batch) })
+		for _, req := range batch {
+			req.done <- err
+		}
+	}
+}
+
+// commit puts the DataFrames in the transaction and removes the oldest ones beyond the retention.
+func (s *Store) commit(tx *bolt.Tx, batch []*appendRequest) error {
+	b := tx.Bucket(bucket)
+
+	var (
+		offset uint64
+		err    error
+	)
+	for _, req := range batch {
+		if offset, err = b.NextSequence(); err != nil {
+			return err
+		}
+		if err := b.Put(key(offset), req.data); err != nil {
+			return err
+		}
+		req.offset = offset
+	}
+	if s.maxFrames == 0 || offset <= s.maxFrames {
+		return nil
+	}
+	// the frames appended before the retention is set are removed too,
+	// the cursor is moved to the first again after a deletion, Next skips a key after Delete.
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil && binary.BigEndian.Uint64(k) <= offset-s.maxFrames; k, _ = c.First() {
+		if err := c.Delete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replay calls fn with the DataFrames from the offset in order, until fn returns false or the end of the log.
+func (s *Store) Replay(from uint64, fn func(offset uint64, df *frame.DataFrame) bool) error {
+	type record struct {
+		offset uint64
+		df     *frame.DataFrame
+	}
+	for {
+		batch := make([]record, 0, replayBatchSize)
+		err := s.db.View(func(tx *bolt.Tx) error {
+			c := tx.Bucket(bucket).Cursor()
+			for k, v := c.Seek(key(from)); k != nil && len(batch) < replayBatchSize; k, v = c.Next() {
+				df := new(frame.DataFrame)
+				// the value is valid in the transaction only, the decoded frame does not refer to it.
+				if err := s.codec.Decode(append([]byte(nil), v...), df); err != nil {
+					return err
+				}
+				batch = append(batch, record{binary.BigEndian.Uint64(k), df})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, r := range batch {
+			if !fn(r.offset, r.df) {
+				return nil
+			}
+		}
+		if len(batch) < replayBatchSize {
+			return nil
+		}
+		from = batch[len(batch)-1].offset + 1
+	}
+}
+
+// LastOffset returns the offset of the last DataFrame appended.
+func (s *Store) LastOffset() (uint64, error) {
+	var offset uint64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		offset = tx.Bucket(bucket).Sequence()
+		return nil
+	})
+	return offset, err
+}
+
+// Close stops appending and closes the BoltDB file.
+func (s *Store) Close() error {
+	s.once.Do(func() { close(s.quit) })
+	<-s.stopped
+	return s.db.Close()
+}
+
+func key(offset uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, offset)
+}
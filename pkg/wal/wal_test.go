@@ -0,0 +1,109 @@
+package wal
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal.db")
+
+	store, err := Open(path)
+	assert.NoError(t, err)
+
+	last, err := store.LastOffset()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), last)
+
+	for i := 1; i <= 3; i++ {
+		offset, err := store.Append(&frame.DataFrame{Tag: frame.Tag(i), Metadata: []byte("md"), Payload: []byte("yomo")})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(i), offset)
+	}
+	assert.NoError(t, store.Close())
+
+	// the frames survive the restart.
+	store, err = Open(path, WithMaxFrames(2))
+	assert.NoError(t, err)
+	defer store.Close()
+
+	var tags []frame.Tag
+	assert.NoError(t, store.Replay(2, func(offset uint64, df *frame.DataFrame) bool {
+		assert.Equal(t, frame.Tag(offset), df.Tag)
+		assert.Equal(t, []byte("yomo"), df.Payload)
+		tags = append(tags, df.Tag)
+		return true
+	}))
+	assert.Equal(t, []frame.Tag{2, 3}, tags)
+
+	// the retention removes the oldest frames.
+	offset, err := store.Append(&frame.DataFrame{Tag: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), offset)
+
+	tags = tags[:0]
+	assert.NoError(t, store.Replay(0, func(offset uint64, df *frame.DataFrame) bool {
+		tags = append(tags, df.Tag)
+		return len(tags) < 1
+	}))
+	assert.Equal(t, []frame.Tag{3}, tags)
+}
+
+func TestReplayBatches(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "wal.db"))
+	assert.NoError(t, err)
+	defer store.Close()
+
+	const n = replayBatchSize*2 + 10
+	for i := 0; i < n; i++ {
+		_, err := store.Append(&frame.DataFrame{Tag: 1})
+		assert.NoError(t, err)
+	}
+
+	var offsets []uint64
+	assert.NoError(t, store.Replay(1, func(offset uint64, df *frame.DataFrame) bool {
+		offsets = append(offsets, offset)
+		return true
+	}))
+	assert.Len(t, offsets, n)
+	assert.Equal(t, uint64(n), offsets[n-1])
+}
+
+func TestConcurrentAppend(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "wal.db"))
+	assert.NoError(t, err)
+
+	const n = 100
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		offsets []int
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			offset, err := store.Append(&frame.DataFrame{Tag: 1})
+			assert.NoError(t, err)
+			mu.Lock()
+			offsets = append(offsets, int(offset))
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// the frames committed together get their own offsets.
+	sort.Ints(offsets)
+	for i, offset := range offsets {
+		assert.Equal(t, i+1, offset)
+	}
+
+	assert.NoError(t, store.Close())
+	_, err = store.Append(&frame.DataFrame{Tag: 1})
+	assert.ErrorIs(t, err, ErrClosed)
+}
@@ -0,0 +1,113 @@
+package yomo
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ResponseCache caches the responses of the request/reply calls at the source side, the responses are
+// keyed by the tag and the hash of the request, and expire after the TTL. The edge devices that ask the
+// same question repeatedly get the answer locally instead of traversing the mesh.
+// The concurrent calls of the same request share one fetching.
+type ResponseCache struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	entries   map[responseKey]*responseEntry
+	lastSweep time.Time
+}
+
+type responseKey struct {
+	tag  uint32
+	hash [sha256.Size]byte
+}
+
+type responseEntry struct {
+	done    chan struct{}
+	data    []byte
+	err     error
+	expires time.Time
+}
+
+// NewResponseCache returns a ResponseCache that keeps the responses for ttl.
+func NewResponseCache(ttl time.Duration) *ResponseCache {
+	return &ResponseCache{
+		ttl:       ttl,
+		entries:   make(map[responseKey]*responseEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Do returns the cached response of the request to the tag, fetch is called if the response is not
+// cached or expired. The errors are not cached, and the panic of fetch is returned as an error.
+func (c *ResponseCache) Do(tag uint32, request []byte, fetch func() ([]byte, error)) ([]byte, error) {
+	key := responseKey{tag: tag, hash: sha256.Sum256(request)}
+	now := time.Now()
+
+	c.mu.Lock()
+	c.sweep(now)
+	if e, ok := c.entries[key]; ok && (e.expires.IsZero() || now.Before(e.expires)) {
+		c.mu.Unlock()
+		<-e.done
+		return e.data, e.err
+	}
+	e := &responseEntry{done: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	c.fetch(key, e, fetch)
+
+	return e.data, e.err
+}
+
+// fetch fills the entry by fetch, the panic of fetch is recovered as the error of the entry,
+// so the concurrent calls waiting for the entry are not blocked forever.
+func (c *ResponseCache) fetch(key responseKey, e *responseEntry, fetch func() ([]byte, error)) {
+	defer close(e.done)
+	defer func() {
+		if r := recover(); r != nil {
+			e.data, e.err = nil, fmt.Errorf("yomo: response fetching panics: %v", r)
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if e.err != nil {
+			delete(c.entries, key)
+		} else {
+			e.expires = time.Now().Add(c.ttl)
+		}
+	}()
+
+	e.data, e.err = fetch()
+}
+
+// Invalidate removes the cached response of the request to the tag.
+func (c *ResponseCache) Invalidate(tag uint32, request []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, responseKey{tag: tag, hash: sha256.Sum256(request)})
+}
+
+// Len returns the number of the cached responses, including the ones being fetched.
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// sweep removes the expired responses once per TTL, the caller must hold the lock.
+func (c *ResponseCache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) <= c.ttl {
+		return
+	}
+	for k, e := range c.entries {
+		if !e.expires.IsZero() && !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.lastSweep = now
+}
@@ -0,0 +1,111 @@
+package yomo
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseCache(t *testing.T) {
+	cache := NewResponseCache(50 * time.Millisecond)
+
+	var calls atomic.Int32
+	fetch := func() ([]byte, error) {
+		calls.Add(1)
+		return []byte("sunny"), nil
+	}
+
+	for i := 0; i < 3; i++ {
+		data, err := cache.Do(0x21, []byte("weather"), fetch)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("sunny"), data)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	// the other tag or request is fetched.
+	_, _ = cache.Do(0x22, []byte("weather"), fetch)
+	_, _ = cache.Do(0x21, []byte("traffic"), fetch)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, 3, cache.Len())
+
+	cache.Invalidate(0x21, []byte("traffic"))
+	assert.Equal(t, 2, cache.Len())
+
+	// the response expires after the ttl.
+	time.Sleep(60 * time.Millisecond)
+	_, _ = cache.Do(0x21, []byte("weather"), fetch)
+	assert.Equal(t, int32(4), calls.Load())
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestResponseCacheError(t *testing.T) {
+	cache := NewResponseCache(time.Minute)
+
+	_, err := cache.Do(0x21, []byte("weather"), func() ([]byte, error) { return nil, errors.New("timeout") })
+	assert.EqualError(t, err, "timeout")
+	assert.Equal(t, 0, cache.Len())
+
+	data, err := cache.Do(0x21, []byte("weather"), func() ([]byte, error) { return []byte("sunny"), nil })
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("sunny"), data)
+}
+
+func TestResponseCachePanic(t *testing.T) {
+	cache := NewResponseCache(time.Minute)
+
+	fetching := make(chan struct{})
+	waited := make(chan error)
+	go func() {
+		<-fetching
+		_, err := cache.Do(0x21, []byte("weather"), func() ([]byte, error) { panic("boom") })
+		waited <- err
+	}()
+
+	_, err := cache.Do(0x21, []byte("weather"), func() ([]byte, error) {
+		close(fetching)
+		time.Sleep(50 * time.Millisecond)
+		panic("boom")
+	})
+	assert.EqualError(t, err, "yomo: response fetching panics: boom")
+	assert.Equal(t, 0, cache.Len())
+
+	// the concurrent call waiting for the fetching is not blocked.
+	select {
+	case err := <-waited:
+		assert.EqualError(t, err, "yomo: response fetching panics: boom")
+	case <-time.After(3 * time.Second):
+		t.Fatal("the concurrent call is blocked")
+	}
+}
+
+func TestResponseCacheConcurrent(t *testing.T) {
+	cache := NewResponseCache(time.Minute)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func() ([]byte, error) {
+		calls.Add(1)
+		<-release
+		return []byte("sunny"), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := cache.Do(0x21, []byte("weather"), fetch)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("sunny"), data)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -54,11 +54,11 @@ func (r *Runtime) RawByteHandler(ctx serverless.Context) {
 }
 
 // PipeHandler processes data sequentially.
-func (r *Runtime) PipeHandler(in <-chan []byte, out chan<- *frame.DataFrame) {
+func (r *Runtime) PipeHandler(in <-chan *frame.DataFrame, out chan<- *frame.DataFrame) {
 	for {
 		select {
 		case req := <-in:
-			r.rawBytesChan <- req
+			r.rawBytesChan <- req.Payload
 		case item := <-r.stream.Observe():
 			if item.Error() {
 				ylog.Error("[rx PipeHandler] Handler got an error", item.E)
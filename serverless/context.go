@@ -9,6 +9,8 @@ type Context interface {
 	Tag() uint32
 	// Write write data to zipper
 	Write(tag uint32, data []byte) error
+	// Metadata returns the value of the given key in the metadata of incoming data
+	Metadata(key string) (string, bool)
 	// HTTP http interface
 	HTTP() HTTP
 }
@@ -30,6 +30,12 @@ func (c *GuestContext) Data() []byte {
 	return GetBytes(ContextData)
 }
 
+// Metadata returns the value of the given key in the metadata, the metadata is not
+// passed to the wasm guest yet, so it always returns false.
+func (c *GuestContext) Metadata(_ string) (string, bool) {
+	return "", false
+}
+
 // Write writes data to the context
 func (c *GuestContext) Write(tag uint32, data []byte) error {
 	if data == nil {
@@ -0,0 +1,143 @@
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	coreserverless "github.com/yomorun/yomo/core/serverless"
+	"github.com/yomorun/yomo/serverless"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrFixtureExt is returned when the extension of fixture file is not supported.
+var ErrFixtureExt = errors.New(`mock: the extension of fixture is incorrect, it should be ".json|.yaml|.yml"`)
+
+// Fixture is a recorded DataFrame, it can be replayed to a sfn handler.
+type Fixture struct {
+	// Name describes the fixture, it is helpful for naming sub-tests.
+	Name string `json:"name" yaml:"name"`
+	// Tag is the tag of the recorded DataFrame.
+	Tag uint32 `json:"tag" yaml:"tag"`
+	// Data is the payload of the recorded DataFrame.
+	Data string `json:"data" yaml:"data"`
+	// Metadata is the metadata of the recorded DataFrame.
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	// Want is the golden outputs that the handler is expected to write.
+	Want []FixtureOutput `json:"want,omitempty" yaml:"want,omitempty"`
+}
+
+// FixtureOutput is an output written by the handler.
+type FixtureOutput struct {
+	Tag  uint32 `json:"tag" yaml:"tag"`
+	Data string `json:"data" yaml:"data"`
+}
+
+// LoadFixtures loads fixtures from a json or yaml file.
+func LoadFixtures(path string) ([]Fixture, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures []Fixture
+	switch filepath.Ext(path) {
+	case ".json":
+		err = json.Unmarshal(buf, &fixtures)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(buf, &fixtures)
+	default:
+		return nil, ErrFixtureExt
+	}
+
+	return fixtures, err
+}
+
+// ReplayResult is the result of replaying a fixture.
+type ReplayResult struct {
+	// Fixture is the fixture be replayed.
+	Fixture Fixture
+	// Written is the outputs written by the handler.
+	Written []FixtureOutput
+}
+
+// Matched reports whether the outputs written by the handler equal to the golden outputs.
+func (r ReplayResult) Matched() bool {
+	if len(r.Written) != len(r.Fixture.Want) {
+		return false
+	}
+	for i, w := range r.Written {
+		if w != r.Fixture.Want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Replay feeds fixtures through a real serverless context one by one,
+// the outputs written by the handler are captured in memory.
+func Replay(handler func(serverless.Context), fixtures ...Fixture) ([]ReplayResult, error) {
+	results := make([]ReplayResult, 0, len(fixtures))
+
+	for _, fixture := range fixtures {
+		md, err := metadata.New(fixture.Metadata).Encode()
+		if err != nil {
+			return results, err
+		}
+		df := &frame.DataFrame{
+			Tag:      fixture.Tag,
+			Metadata: md,
+			Payload:  []byte(fixture.Data),
+		}
+
+		w := &frameRecorder{}
+		handler(coreserverless.NewContext(w, df))
+
+		results = append(results, ReplayResult{
+			Fixture: fixture,
+			Written: w.outputs(),
+		})
+	}
+
+	return results, nil
+}
+
+// ReplayFile loads fixtures from the file and replays them.
+func ReplayFile(path string, handler func(serverless.Context)) ([]ReplayResult, error) {
+	fixtures, err := LoadFixtures(path)
+	if err != nil {
+		return nil, err
+	}
+	return Replay(handler, fixtures...)
+}
+
+// frameRecorder is an in-memory frame.Writer that records the DataFrames be written.
+type frameRecorder struct {
+	mu     sync.Mutex
+	frames []*frame.DataFrame
+}
+
+func (w *frameRecorder) WriteFrame(f frame.Frame) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if df, ok := f.(*frame.DataFrame); ok {
+		w.frames = append(w.frames, df)
+	}
+	return nil
+}
+
+func (w *frameRecorder) outputs() []FixtureOutput {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	result := make([]FixtureOutput, len(w.frames))
+	for i, df := range w.frames {
+		result[i] = FixtureOutput{Tag: df.Tag, Data: string(df.Payload)}
+	}
+	return result
+}
@@ -0,0 +1,41 @@
+package mock
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/serverless"
+)
+
+func uppercaseHandler(ctx serverless.Context) {
+	if len(ctx.Data()) == 0 {
+		return
+	}
+	_ = ctx.Write(ctx.Tag()+1, bytes.ToUpper(ctx.Data()))
+}
+
+func TestReplayFile(t *testing.T) {
+	for _, path := range []string{"testdata/fixtures.yaml", "testdata/fixtures.json"} {
+		results, err := ReplayFile(path, uppercaseHandler)
+		assert.NoError(t, err)
+
+		for _, result := range results {
+			t.Run(result.Fixture.Name, func(t *testing.T) {
+				assert.True(t, result.Matched(), "written: %v, want: %v", result.Written, result.Fixture.Want)
+			})
+		}
+	}
+}
+
+func TestReplayNotMatched(t *testing.T) {
+	results, err := Replay(uppercaseHandler, Fixture{Tag: 0x33, Data: "yomo"})
+	assert.NoError(t, err)
+	assert.Equal(t, []FixtureOutput{{Tag: 0x34, Data: "YOMO"}}, results[0].Written)
+	assert.False(t, results[0].Matched())
+}
+
+func TestLoadFixturesExt(t *testing.T) {
+	_, err := LoadFixtures("fixture.go")
+	assert.ErrorIs(t, err, ErrFixtureExt)
+}
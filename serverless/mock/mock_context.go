@@ -17,6 +17,7 @@ type DataAndTag struct {
 type MockContext struct {
 	data []byte
 	tag  uint32
+	md   map[string]string
 
 	mu      sync.Mutex
 	wrSlice []DataAndTag
@@ -37,6 +38,22 @@ func (c *MockContext) Data() []byte {
 func (c *MockContext) Tag() uint32 {
 	return c.tag
 }
+
+// SetMetadata sets the metadata that is returned by ctx.Metadata().
+func (c *MockContext) SetMetadata(key, value string) *MockContext {
+	if c.md == nil {
+		c.md = make(map[string]string)
+	}
+	c.md[key] = value
+	return c
+}
+
+// Metadata returns the metadata set by SetMetadata.
+func (c *MockContext) Metadata(key string) (string, bool) {
+	v, ok := c.md[key]
+	return v, ok
+}
+
 func (m *MockContext) HTTP() serverless.HTTP {
 	return &guest.GuestHTTP{}
 }
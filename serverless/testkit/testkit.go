@@ -0,0 +1,173 @@
+// Package testkit provides helpers for testing sfn handlers.
+//
+//	ctx := testkit.NewContext(0x33, []byte("yomo"))
+//	handler(ctx)
+//	ctx.AssertWrote(t, 0x34, []byte("YOMO"))
+package testkit
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	coreserverless "github.com/yomorun/yomo/core/serverless"
+)
+
+// Written is a record written by the handler.
+type Written struct {
+	Tag      uint32
+	Payload  []byte
+	Metadata metadata.M
+	Target   string
+}
+
+// Context is a serverless.Context for testing, it records all the data written by the handler.
+type Context struct {
+	*coreserverless.Context
+	recorder *recorder
+}
+
+// Option is the option for NewContext.
+type Option func(*frame.DataFrame, metadata.M)
+
+// WithMetadata sets the metadata of the incoming DataFrame.
+func WithMetadata(md map[string]string) Option {
+	return func(_ *frame.DataFrame, m metadata.M) {
+		for k, v := range md {
+			m.Set(k, v)
+		}
+	}
+}
+
+// WithMetadataKV sets a key-value pair to the metadata of the incoming DataFrame.
+func WithMetadataKV(k, v string) Option {
+	return func(_ *frame.DataFrame, m metadata.M) {
+		m.Set(k, v)
+	}
+}
+
+// NewContext returns a Context whose Tag() and Data() return the given tag and data.
+func NewContext(tag uint32, data []byte, opts ...Option) *Context {
+	df := &frame.DataFrame{
+		Tag:     tag,
+		Payload: data,
+	}
+	md := metadata.M{}
+	for _, o := range opts {
+		o(df, md)
+	}
+	// metadata.M is a map[string]string, encoding it never fails.
+	df.Metadata, _ = md.Encode()
+
+	r := &recorder{}
+
+	return &Context{
+		Context:  coreserverless.NewContext(r, df),
+		recorder: r,
+	}
+}
+
+// Written returns all the records written by the handler.
+func (c *Context) Written() []Written {
+	return c.recorder.records()
+}
+
+// AssertWrote asserts that the handler wrote the payload with the tag.
+func (c *Context) AssertWrote(t testing.TB, tag uint32, payload []byte) bool {
+	t.Helper()
+
+	written := c.Written()
+	for _, w := range written {
+		if w.Tag == tag && bytes.Equal(w.Payload, payload) {
+			return true
+		}
+	}
+	t.Errorf("testkit: handler did not write tag=%#x payload=%q, written: %s", tag, payload, formatWritten(written))
+	return false
+}
+
+// AssertWroteTag asserts that the handler wrote data with the tag.
+func (c *Context) AssertWroteTag(t testing.TB, tag uint32) bool {
+	t.Helper()
+
+	written := c.Written()
+	for _, w := range written {
+		if w.Tag == tag {
+			return true
+		}
+	}
+	t.Errorf("testkit: handler did not write tag=%#x, written: %s", tag, formatWritten(written))
+	return false
+}
+
+// AssertTarget asserts that the handler wrote data with the tag to the target stream function,
+// an empty target asserts that the data is not targeted.
+func (c *Context) AssertTarget(t testing.TB, tag uint32, target string) bool {
+	t.Helper()
+
+	written := c.Written()
+	for _, w := range written {
+		if w.Tag == tag && w.Target == target {
+			return true
+		}
+	}
+	t.Errorf("testkit: handler did not write tag=%#x target=%q, written: %s", tag, target, formatWritten(written))
+	return false
+}
+
+// AssertWroteNothing asserts that the handler wrote nothing.
+func (c *Context) AssertWroteNothing(t testing.TB) bool {
+	t.Helper()
+
+	if written := c.Written(); len(written) != 0 {
+		t.Errorf("testkit: handler should write nothing, written: %s", formatWritten(written))
+		return false
+	}
+	return true
+}
+
+type recorder struct {
+	mu      sync.Mutex
+	written []Written
+}
+
+func (r *recorder) WriteFrame(f frame.Frame) error {
+	df, ok := f.(*frame.DataFrame)
+	if !ok {
+		return nil
+	}
+	md, err := metadata.Decode(df.Metadata)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	target, _ := md.Get(metadata.TargetKey)
+	r.written = append(r.written, Written{Tag: df.Tag, Payload: df.Payload, Metadata: md, Target: target})
+	return nil
+}
+
+func (r *recorder) records() []Written {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Written(nil), r.written...)
+}
+
+func formatWritten(written []Written) string {
+	items := make([]string, len(written))
+	for i, w := range written {
+		if w.Target != "" {
+			items[i] = fmt.Sprintf("{tag=%#x target=%q payload=%q}", w.Tag, w.Target, w.Payload)
+			continue
+		}
+		items[i] = fmt.Sprintf("{tag=%#x payload=%q}", w.Tag, w.Payload)
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
@@ -0,0 +1,70 @@
+package testkit
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/serverless"
+)
+
+func TestContext(t *testing.T) {
+	ctx := NewContext(0x33, []byte("yomo"), WithMetadata(map[string]string{"foo": "bar"}), WithMetadataKV("hello", "yomo"))
+
+	assert.Equal(t, uint32(0x33), ctx.Tag())
+	assert.Equal(t, []byte("yomo"), ctx.Data())
+	ctx.AssertWroteNothing(t)
+
+	func(ctx serverless.Context) {
+		_ = ctx.Write(0x34, bytes.ToUpper(ctx.Data()))
+	}(ctx)
+
+	ctx.AssertWrote(t, 0x34, []byte("YOMO"))
+	ctx.AssertWroteTag(t, 0x34)
+
+	written := ctx.Written()
+	assert.Len(t, written, 1)
+	assert.Equal(t, "bar", written[0].Metadata["foo"])
+	assert.Equal(t, "yomo", written[0].Metadata["hello"])
+
+	mt := &testing.T{}
+	assert.False(t, ctx.AssertWrote(mt, 0x35, []byte("YOMO")))
+	assert.False(t, ctx.AssertWroteTag(mt, 0x35))
+	assert.False(t, ctx.AssertWroteNothing(mt))
+}
+
+func TestContextFrameScopedMetadata(t *testing.T) {
+	ctx := NewContext(0x33, []byte("yomo"),
+		WithMetadataKV("foo", "bar"),
+		WithMetadataKV(core.MetadataTargetKey, "sfn"),
+		WithMetadataKV(core.MetadataSchemaVersionKey, "2"),
+		WithMetadataKV(core.MetadataWALOffsetKey, "5"),
+	)
+
+	_ = ctx.Write(0x34, ctx.Data())
+
+	written := ctx.Written()
+	assert.Len(t, written, 1)
+	assert.Equal(t, "bar", written[0].Metadata["foo"])
+	assert.NotContains(t, written[0].Metadata, core.MetadataTargetKey)
+	assert.NotContains(t, written[0].Metadata, core.MetadataSchemaVersionKey)
+	assert.NotContains(t, written[0].Metadata, core.MetadataWALOffsetKey)
+	ctx.AssertTarget(t, 0x34, "")
+}
+
+func TestContextAssertTarget(t *testing.T) {
+	ctx := NewContext(0x33, []byte("yomo"))
+
+	md, _ := metadata.M{core.MetadataTargetKey: "sfn"}.Encode()
+	assert.NoError(t, ctx.recorder.WriteFrame(&frame.DataFrame{Tag: 0x34, Metadata: md, Payload: []byte("yomo")}))
+
+	ctx.AssertTarget(t, 0x34, "sfn")
+
+	mt := &testing.T{}
+	assert.False(t, ctx.AssertTarget(mt, 0x34, "other"))
+	assert.False(t, ctx.AssertTarget(mt, 0x34, ""))
+	assert.False(t, ctx.AssertTarget(mt, 0x35, "sfn"))
+}
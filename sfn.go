@@ -3,11 +3,15 @@ package yomo
 import (
 	"context"
 	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
 
 	"github.com/yomorun/yomo/core"
 	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/metadata"
 	"github.com/yomorun/yomo/core/serverless"
+	yserverless "github.com/yomorun/yomo/serverless"
 	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
@@ -15,20 +19,38 @@ import (
 type StreamFunction interface {
 	// SetObserveDataTags set the data tag list that will be observed
 	SetObserveDataTags(tag ...uint32)
+	// Subscribe observes more data tags, it takes effect at runtime without reconnecting.
+	Subscribe(tag ...uint32) error
+	// Unsubscribe stops observing the data tags, it takes effect at runtime without reconnecting.
+	Unsubscribe(tag ...uint32) error
 	// Init will initialize the stream function
 	Init(fn func() error) error
 	// SetHandler set the handler function, which accept the raw bytes data and return the tag & response
 	SetHandler(fn core.AsyncHandler) error
+	// SetSignalHandler set the handler function of the signals, which are the frames without payload.
+	// The signals are passed to the handler set by SetHandler if it is not set.
+	SetSignalHandler(fn func(tag uint32))
 	// SetErrorHandler set the error handler function when server error occurs
 	SetErrorHandler(fn func(err error))
-	// SetPipeHandler set the pipe handler function
+	// SetPipeHandler set the pipe handler function, which is a long-lived, channel-based processing loop.
 	SetPipeHandler(fn core.PipeHandler) error
+	// Next waits for the next data and returns its context, it is the polling alternative of the handlers,
+	// so the data can be consumed in the user's own select loop or actor runtime.
+	// It returns ErrSfnClosed once the sfn is closed, and cannot be used if a handler is set.
+	// The data not polled is queued up to a limit, then reading from zipper is paused.
+	Next(ctx context.Context) (yserverless.Context, error)
+	// UseWriteInterceptor appends interceptors that are applied to every outgoing frame.
+	UseWriteInterceptor(fns ...core.WriteInterceptor)
+	// UseReadInterceptor appends interceptors that are applied to every incoming frame before the handler runs.
+	UseReadInterceptor(fns ...core.ReadInterceptor)
 	// Connect create a connection to the zipper
 	Connect() error
 	// Close will close the connection
 	Close() error
 	// Wait waits sfn to finish.
 	Wait()
+	// Ping measures the round-trip time to the zipper at the application layer.
+	Ping(ctx context.Context) (time.Duration, error)
 }
 
 // NewStreamFunction create a stream function.
@@ -52,6 +74,8 @@ func NewStreamFunction(name, zipperAddr string, opts ...SfnOption) StreamFunctio
 		zipperAddr:      zipperAddr,
 		client:          client,
 		observeDataTags: make([]uint32, 0),
+		polled:          newPollQueue(maxPolledFrames),
+		requests:        make(chan struct{}, maxConcurrentRequests),
 	}
 
 	return sfn
@@ -66,9 +90,12 @@ type streamFunction struct {
 	client          *core.Client
 	observeDataTags []uint32          // tag list that will be observed
 	fn              core.AsyncHandler // user's function which will be invoked when data arrived
+	sigfn           func(tag uint32)  // user's function which will be invoked when signal arrived
 	pfn             core.PipeHandler
-	pIn             chan []byte
+	pIn             chan *frame.DataFrame
 	pOut            chan *frame.DataFrame
+	polled          *pollQueue    // the data waiting for Next if no handler is set
+	requests        chan struct{} // a slot is taken by every request being handled
 }
 
 // SetObserveDataTags set the data tag list that will be observed.
@@ -78,6 +105,18 @@ func (s *streamFunction) SetObserveDataTags(tag ...uint32) {
 	s.client.Logger.Debug("set sfn observe data tasg", "tags", s.observeDataTags)
 }
 
+// Subscribe observes more data tags, it takes effect at runtime without reconnecting.
+func (s *streamFunction) Subscribe(tag ...uint32) error {
+	s.client.Logger.Debug("subscribe sfn data tags", "tags", tag)
+	return s.client.UpdateObserveDataTags(tag, nil)
+}
+
+// Unsubscribe stops observing the data tags, it takes effect at runtime without reconnecting.
+func (s *streamFunction) Unsubscribe(tag ...uint32) error {
+	s.client.Logger.Debug("unsubscribe sfn data tags", "tags", tag)
+	return s.client.UpdateObserveDataTags(nil, tag)
+}
+
 // SetHandler set the handler function, which accept the raw bytes data and return the tag & response.
 func (s *streamFunction) SetHandler(fn core.AsyncHandler) error {
 	s.fn = fn
@@ -101,12 +140,15 @@ func (s *streamFunction) Connect() error {
 	s.client.Logger.Debug("sfn connecting to zipper ...")
 	// notify underlying network operations, when data with tag we observed arrived, invoke the func
 	s.client.SetDataFrameObserver(func(data *frame.DataFrame) {
-		s.client.Logger.Debug("received data frame")
+		s.client.Logger.Debug("received data frame", "tag", data.Tag, "tag_name", core.TagName(s.client.TagNamer(), data.Tag))
 		s.onDataFrame(data)
 	})
+	if s.fn != nil {
+		s.client.SetRequestObserver(s.onRequestFrame)
+	}
 
 	if s.pfn != nil {
-		s.pIn = make(chan []byte)
+		s.pIn = make(chan *frame.DataFrame)
 		s.pOut = make(chan *frame.DataFrame)
 
 		// handle user's pipe function
@@ -116,33 +158,9 @@ func (s *streamFunction) Connect() error {
 
 		// send user's pipe function outputs to zipper
 		go func() {
-			for {
-				data := <-s.pOut
+			for data := range s.pOut {
 				if data != nil {
-					s.client.Logger.Debug("pipe fn send", "payload_frame", data)
-					md, err := metadata.Decode(data.Metadata)
-					if err != nil {
-						s.client.Logger.Error("sfn decode metadata error", "err", err)
-						break
-					}
-
-					newMd, endFn := core.SfnTraceMetadata(md, s.client.Name(), s.client.TracerProvider(), s.client.Logger)
-					defer endFn()
-
-					newMetadata, err := newMd.Encode()
-					if err != nil {
-						s.client.Logger.Error("sfn encode metadata error", "err", err)
-						break
-					}
-					data.Metadata = newMetadata
-
-					frame := &frame.DataFrame{
-						Tag:      data.Tag,
-						Metadata: data.Metadata,
-						Payload:  data.Payload,
-					}
-
-					s.client.WriteFrame(frame)
+					s.writePipeFrame(data)
 				}
 			}
 		}()
@@ -152,8 +170,44 @@ func (s *streamFunction) Connect() error {
 	return err
 }
 
+func (s *streamFunction) writePipeFrame(data *frame.DataFrame) {
+	s.client.Logger.Debug("pipe fn send", "payload_frame", data)
+	md, err := metadata.Decode(data.Metadata)
+	if err != nil {
+		s.client.Logger.Error("sfn decode metadata error", "err", err)
+		return
+	}
+
+	newMd, endFn := core.SfnTraceMetadata(
+		md, s.client.Name(), s.client.TracerProvider(), s.client.Logger,
+		core.TagTraceAttrs(s.client.TagNamer(), data.Tag),
+	)
+	defer endFn()
+
+	newMetadata, err := newMd.Encode()
+	if err != nil {
+		s.client.Logger.Error("sfn encode metadata error", "err", err)
+		return
+	}
+
+	frame := &frame.DataFrame{
+		Tag:      data.Tag,
+		Metadata: newMetadata,
+		Payload:  data.Payload,
+	}
+
+	s.client.WriteFrame(frame)
+}
+
+// Ping measures the round-trip time to the zipper at the application layer.
+func (s *streamFunction) Ping(ctx context.Context) (time.Duration, error) {
+	return s.client.Ping(ctx)
+}
+
 // Close will close the connection.
 func (s *streamFunction) Close() error {
+	s.polled.close()
+
 	if s.pIn != nil {
 		close(s.pIn)
 	}
@@ -180,42 +234,100 @@ func (s *streamFunction) Wait() {
 // when DataFrame we observed arrived, invoke the user's function
 // func (s *streamFunction) onDataFrame(data []byte, metaFrame *frame.MetaFrame) {
 func (s *streamFunction) onDataFrame(dataFrame *frame.DataFrame) {
+	if s.sigfn != nil && len(dataFrame.Payload) == 0 {
+		s.sigfn(dataFrame.Tag)
+		return
+	}
 	if s.fn != nil {
 		tp := s.client.TracerProvider()
 		go func(tp oteltrace.TracerProvider, dataFrame *frame.DataFrame) {
-			md, err := metadata.Decode(dataFrame.Metadata)
+			endFn, err := s.traceDataFrame(dataFrame)
 			if err != nil {
-				s.client.Logger.Error("sfn decode metadata error", "err", err)
 				return
 			}
-
-			newMd, endFn := core.SfnTraceMetadata(md, s.client.Name(), s.client.TracerProvider(), s.client.Logger)
 			defer endFn()
-
-			newMetadata, err := newMd.Encode()
-			if err != nil {
-				s.client.Logger.Error("sfn encode metadata error", "err", err)
-				return
-			}
-			dataFrame.Metadata = newMetadata
+			defer s.recoverHandler(dataFrame)
 
 			serverlessCtx := serverless.NewContext(s.client, dataFrame)
 			s.fn(serverlessCtx)
 		}(tp, dataFrame)
 	} else if s.pfn != nil {
-		data := dataFrame.Payload
-		s.client.Logger.Debug("pipe sfn receive", "data_len", len(data), "data", data)
-		s.pIn <- data
+		s.client.Logger.Debug("pipe sfn receive", "tag", dataFrame.Tag, "data_len", len(dataFrame.Payload))
+		s.pIn <- dataFrame
 	} else {
-		s.client.Logger.Warn("sfn does not have a handler")
+		s.client.Logger.Debug("sfn queue data for polling", "tag", dataFrame.Tag, "data_len", len(dataFrame.Payload))
+		s.polled.push(dataFrame)
+	}
+}
+
+// recoverHandler recovers the panic of the handler, the failure is reported back to the source
+// that writes the data by an ErrorFrame.
+func (s *streamFunction) recoverHandler(dataFrame *frame.DataFrame) {
+	e := recover()
+	if e == nil {
+		return
+	}
+	s.client.Logger.Error("sfn handler panic", "tag", dataFrame.Tag, "err", e, "stack", string(debug.Stack()))
+
+	md, err := metadata.Decode(dataFrame.Metadata)
+	if err != nil {
+		return
+	}
+	ef := core.NewErrorFrame(dataFrame, md, core.ErrorCodeHandlerFailed, fmt.Sprintf("sfn %s panic: %v", s.name, e))
+	if ef == nil {
+		return
+	}
+	if err := s.client.WriteFrame(ef); err != nil {
+		s.client.Logger.Error("failed to write error frame", "err", err)
 	}
 }
 
+// traceDataFrame sets the trace metadata of the sfn to the DataFrame, the returned function ends the span.
+func (s *streamFunction) traceDataFrame(dataFrame *frame.DataFrame) (func(), error) {
+	md, err := metadata.Decode(dataFrame.Metadata)
+	if err != nil {
+		s.client.Logger.Error("sfn decode metadata error", "err", err)
+		return nil, err
+	}
+
+	newMd, endFn := core.SfnTraceMetadata(
+		md, s.client.Name(), s.client.TracerProvider(), s.client.Logger,
+		core.TagTraceAttrs(s.client.TagNamer(), dataFrame.Tag),
+	)
+
+	newMetadata, err := newMd.Encode()
+	if err != nil {
+		endFn()
+		s.client.Logger.Error("sfn encode metadata error", "err", err)
+		return nil, err
+	}
+	dataFrame.Metadata = newMetadata
+
+	return endFn, nil
+}
+
+// SetSignalHandler set the handler function of the signals, it is invoked in the reading goroutine
+// without decoding metadata and tracing, so it should not block.
+func (s *streamFunction) SetSignalHandler(fn func(tag uint32)) {
+	s.sigfn = fn
+	s.client.Logger.Debug("set signal handler")
+}
+
 // SetErrorHandler set the error handler function when server error occurs
 func (s *streamFunction) SetErrorHandler(fn func(err error)) {
 	s.client.SetErrorHandler(fn)
 }
 
+// UseWriteInterceptor appends interceptors that are applied to every outgoing frame.
+func (s *streamFunction) UseWriteInterceptor(fns ...core.WriteInterceptor) {
+	s.client.UseWriteInterceptor(fns...)
+}
+
+// UseReadInterceptor appends interceptors that are applied to every incoming frame before the handler runs.
+func (s *streamFunction) UseReadInterceptor(fns ...core.ReadInterceptor) {
+	s.client.UseReadInterceptor(fns...)
+}
+
 // Init will initialize the stream function
 func (s *streamFunction) Init(fn func() error) error {
 	return fn()
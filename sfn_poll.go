@@ -0,0 +1,86 @@
+package yomo
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/serverless"
+	"github.com/yomorun/yomo/core/yerr"
+	yserverless "github.com/yomorun/yomo/serverless"
+)
+
+// ErrSfnClosed is returned by Next if the stream function is closed.
+var ErrSfnClosed = yerr.New(yerr.CodeClosed, "yomo: stream function closed")
+
+// Next waits for the next data and returns its context.
+func (s *streamFunction) Next(ctx context.Context) (yserverless.Context, error) {
+	if s.fn != nil || s.pfn != nil {
+		return nil, errors.New("yomo: Next cannot be used with the handler")
+	}
+
+	dataFrame, err := s.polled.pop(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the span ends once the data is polled, because the processing is out of sight.
+	endFn, err := s.traceDataFrame(dataFrame)
+	if err != nil {
+		return nil, err
+	}
+	endFn()
+
+	return serverless.NewContext(s.client, dataFrame), nil
+}
+
+// maxPolledFrames is the max number of the DataFrames waiting for Next,
+// reading from zipper is paused once it is reached.
+const maxPolledFrames = 1024
+
+// pollQueue queues the DataFrames for Next, pushing blocks while the queue is full,
+// so the sfn polling slowly applies backpressure to zipper instead of buffering without bound.
+type pollQueue struct {
+	frames    chan *frame.DataFrame
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newPollQueue(size int) *pollQueue {
+	return &pollQueue{
+		frames: make(chan *frame.DataFrame, size),
+		closed: make(chan struct{}),
+	}
+}
+
+// push queues the DataFrame, it waits while the queue is full, the DataFrame is dropped if the queue is closed.
+func (q *pollQueue) push(df *frame.DataFrame) {
+	select {
+	case q.frames <- df:
+	case <-q.closed:
+	}
+}
+
+// pop returns the first DataFrame, it waits until a DataFrame is pushed, the ctx is done or the queue is closed.
+func (q *pollQueue) pop(ctx context.Context) (*frame.DataFrame, error) {
+	// the queued DataFrames are returned even if ctx is done.
+	select {
+	case df := <-q.frames:
+		return df, nil
+	default:
+	}
+
+	select {
+	case df := <-q.frames:
+		return df, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-q.closed:
+		return nil, ErrSfnClosed
+	}
+}
+
+func (q *pollQueue) close() {
+	q.closeOnce.Do(func() { close(q.closed) })
+}
@@ -0,0 +1,73 @@
+package yomo
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/serverless"
+)
+
+// maxConcurrentRequests is the max number of the requests handled concurrently by the sfn,
+// reading from zipper is paused once it is reached.
+const maxConcurrentRequests = 1024
+
+// onRequestFrame invokes the handler with the RequestFrame, the data that the handler writes to the
+// ReplyTo tag of the request is the response, the data written to the other tags flows as usual.
+func (s *streamFunction) onRequestFrame(rf *frame.RequestFrame) {
+	s.requests <- struct{}{}
+	go func() {
+		defer func() { <-s.requests }()
+
+		md, err := metadata.Decode(rf.Metadata)
+		if err != nil {
+			s.client.Logger.Error("sfn decode request metadata error", "request_id", rf.ID, "err", err)
+			return
+		}
+		if core.RequestExpired(rf, time.Now()) {
+			s.writeResponse(core.NewResponseFrame(rf, md, nil, core.ErrorCodeDeadlineExceeded, "the request is expired"))
+			return
+		}
+
+		w := &responseWriter{writer: s.client, replyTo: rf.ReplyTo}
+		defer func() {
+			if e := recover(); e != nil {
+				s.client.Logger.Error("sfn handler panic", "request_id", rf.ID, "err", e, "stack", string(debug.Stack()))
+				s.writeResponse(core.NewResponseFrame(rf, md, nil, core.ErrorCodeHandlerFailed, fmt.Sprintf("sfn %s panic: %v", s.name, e)))
+				return
+			}
+			s.writeResponse(core.NewResponseFrame(rf, md, w.payload, 0, ""))
+		}()
+
+		dataFrame := &frame.DataFrame{Tag: rf.Tag, Metadata: rf.Metadata, Payload: rf.Payload}
+		s.fn(serverless.NewContext(w, dataFrame))
+	}()
+}
+
+func (s *streamFunction) writeResponse(res *frame.ResponseFrame) {
+	if res == nil {
+		return
+	}
+	if err := s.client.WriteFrame(res); err != nil {
+		s.client.Logger.Error("failed to write response frame", "request_id", res.ID, "err", err)
+	}
+}
+
+// responseWriter keeps the payload written to the replyTo tag as the response,
+// and writes the other frames to the underlying writer.
+type responseWriter struct {
+	writer  frame.Writer
+	replyTo frame.Tag
+	payload []byte
+}
+
+func (w *responseWriter) WriteFrame(f frame.Frame) error {
+	if df, ok := f.(*frame.DataFrame); ok && df.Tag == w.replyTo {
+		w.payload = df.Payload
+		return nil
+	}
+	return w.writer.WriteFrame(f)
+}
@@ -1,11 +1,13 @@
 package yomo
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/ylog"
 	"github.com/yomorun/yomo/serverless"
 )
@@ -58,3 +60,201 @@ func TestSfnInit(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, int64(1), total)
 }
+
+func TestSfnPipeHandler(t *testing.T) {
+	pipe := NewStreamFunction("sfn-pipe", "localhost:9000", WithSfnCredential("token:<CREDENTIAL>"))
+	pipe.SetObserveDataTags(0x31)
+
+	// the pipe handler batches every two frames into one.
+	pipe.SetPipeHandler(func(in <-chan *frame.DataFrame, out chan<- *frame.DataFrame) {
+		var batch []byte
+		for df := range in {
+			batch = append(batch, df.Payload...)
+			if len(batch) == 2 {
+				out <- &frame.DataFrame{Tag: 0x32, Metadata: df.Metadata, Payload: batch}
+				batch = nil
+			}
+		}
+	})
+	assert.NoError(t, pipe.Connect())
+	defer pipe.Close()
+
+	result := make(chan []byte)
+	sink := NewStreamFunction("sfn-pipe-sink", "localhost:9000", WithSfnCredential("token:<CREDENTIAL>"))
+	sink.SetObserveDataTags(0x32)
+	sink.SetHandler(func(ctx serverless.Context) {
+		result <- ctx.Data()
+	})
+	assert.NoError(t, sink.Connect())
+	defer sink.Close()
+
+	source := NewSource("source-pipe", "localhost:9000", WithCredential("token:<CREDENTIAL>"))
+	assert.NoError(t, source.Connect())
+	defer source.Close()
+
+	assert.NoError(t, source.Write(0x31, []byte("a")))
+	assert.NoError(t, source.Write(0x31, []byte("b")))
+
+	select {
+	case data := <-result:
+		assert.Equal(t, []byte("ab"), data)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the pipe handler")
+	}
+}
+
+func TestSfnSignal(t *testing.T) {
+	t.Parallel()
+
+	signals := make(chan uint32, 1)
+	sfn := NewStreamFunction("sfn-signal", "localhost:9000", WithSfnCredential("token:<CREDENTIAL>"))
+	sfn.SetObserveDataTags(0x35)
+	sfn.SetSignalHandler(func(tag uint32) { signals <- tag })
+	sfn.SetHandler(func(ctx serverless.Context) { t.Errorf("unexpected data: %s", ctx.Data()) })
+	assert.Nil(t, sfn.Connect())
+	defer sfn.Close()
+
+	source := NewSource("source-signal", "localhost:9000", WithCredential("token:<CREDENTIAL>"))
+	assert.Nil(t, source.Connect())
+	defer source.Close()
+
+	assert.Nil(t, source.Signal(0x35))
+
+	select {
+	case tag := <-signals:
+		assert.Equal(t, uint32(0x35), tag)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the sfn does not receive the signal")
+	}
+}
+
+func TestSfnNext(t *testing.T) {
+	t.Parallel()
+
+	sfn := NewStreamFunction("sfn-next", "localhost:9000", WithSfnCredential("token:<CREDENTIAL>"))
+	sfn.SetObserveDataTags(0x36)
+	assert.Nil(t, sfn.Connect())
+
+	source := NewSource("source-next", "localhost:9000", WithCredential("token:<CREDENTIAL>"))
+	assert.Nil(t, source.Connect())
+	defer source.Close()
+
+	assert.Nil(t, source.Write(0x36, []byte("first")))
+	assert.Nil(t, source.Write(0x36, []byte("second")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, want := range []string{"first", "second"} {
+		c, err := sfn.Next(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(0x36), c.Tag())
+		assert.Equal(t, want, string(c.Data()))
+	}
+
+	// no more data.
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer shortCancel()
+	_, err := sfn.Next(shortCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.Nil(t, sfn.Close())
+	_, err = sfn.Next(context.Background())
+	assert.ErrorIs(t, err, ErrSfnClosed)
+}
+
+func TestPollQueue(t *testing.T) {
+	q := newPollQueue(1)
+	q.push(&frame.DataFrame{Tag: 1})
+
+	// pushing waits while the queue is full.
+	pushed := make(chan struct{})
+	go func() {
+		q.push(&frame.DataFrame{Tag: 2})
+		close(pushed)
+	}()
+	select {
+	case <-pushed:
+		t.Fatal("pushing does not wait for the full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	df, err := q.pop(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, frame.Tag(1), df.Tag)
+	<-pushed
+
+	// the queued frame is popped even if the ctx is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	df, err = q.pop(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, frame.Tag(2), df.Tag)
+
+	// pushing returns once the queue is closed.
+	q.push(&frame.DataFrame{Tag: 3})
+	go q.close()
+	q.push(&frame.DataFrame{Tag: 4})
+}
+
+func TestSfnHandlerPanic(t *testing.T) {
+	t.Parallel()
+
+	sfn := NewStreamFunction("sfn-panic", "localhost:9000", WithSfnCredential("token:<CREDENTIAL>"))
+	sfn.SetObserveDataTags(0x37)
+	sfn.SetHandler(func(ctx serverless.Context) { panic("bad input") })
+	assert.Nil(t, sfn.Connect())
+	defer sfn.Close()
+
+	errorFrames := make(chan *frame.ErrorFrame, 1)
+	source := NewSource("source-panic", "localhost:9000", WithCredential("token:<CREDENTIAL>"))
+	source.SetErrorFrameHandler(func(ef *frame.ErrorFrame) { errorFrames <- ef })
+	assert.Nil(t, source.Connect())
+	defer source.Close()
+
+	assert.Nil(t, source.Write(0x37, []byte("data")))
+
+	select {
+	case ef := <-errorFrames:
+		assert.Equal(t, frame.Tag(0x37), ef.Tag)
+		assert.Equal(t, core.ErrorCodeHandlerFailed, ef.Code)
+		assert.Contains(t, ef.Message, "bad input")
+	case <-time.After(10 * time.Second):
+		t.Fatal("the source does not receive the error frame")
+	}
+}
+
+func TestSfnRequest(t *testing.T) {
+	t.Parallel()
+
+	sfn := NewStreamFunction("sfn-request", "localhost:9000", WithSfnCredential("token:<CREDENTIAL>"))
+	sfn.SetObserveDataTags(0x38)
+	sfn.SetHandler(func(ctx serverless.Context) {
+		if string(ctx.Data()) == "panic" {
+			panic("bad request")
+		}
+		ctx.Write(0x39, append([]byte("echo: "), ctx.Data()...))
+	})
+	assert.Nil(t, sfn.Connect())
+	defer sfn.Close()
+
+	source := NewSource("source-request", "localhost:9000", WithCredential("token:<CREDENTIAL>"))
+	assert.Nil(t, source.Connect())
+	defer source.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := source.Request(ctx, 0x38, 0x39, []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("echo: hello"), res)
+
+	_, err = source.Request(ctx, 0x38, 0x39, []byte("panic"))
+	var failed *core.ErrRequestFailed
+	assert.ErrorAs(t, err, &failed)
+	assert.Equal(t, core.ErrorCodeHandlerFailed, failed.Code)
+
+	_, err = source.Request(ctx, 0x3a, 0x39, []byte("hello"))
+	assert.ErrorAs(t, err, &failed)
+	assert.Equal(t, core.ErrorCodeUnroutable, failed.Code)
+}
@@ -0,0 +1,100 @@
+package yomo
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/yomorun/yomo/serverless"
+)
+
+// EchoFunctionName is the name of the stream function created by NewEchoFunction.
+const EchoFunctionName = "yomo-echo"
+
+// NewEchoFunction returns a stream function that writes the data of tagIn back to zipper with tagOut,
+// it is used to smoke test a zipper deployment together with the TickerSource, e.g.
+//
+//	echo := yomo.NewEchoFunction("localhost:9000", 0x10, 0x11)
+//	err := echo.Connect()
+func NewEchoFunction(zipperAddr string, tagIn, tagOut uint32, opts ...SfnOption) StreamFunction {
+	sfn := NewStreamFunction(EchoFunctionName, zipperAddr, opts...)
+	sfn.SetObserveDataTags(tagIn)
+	_ = sfn.SetHandler(func(ctx serverless.Context) {
+		_ = ctx.Write(tagOut, ctx.Data())
+	})
+	return sfn
+}
+
+// TickerSourceName is the name of the source created by NewTickerSource.
+const TickerSourceName = "yomo-ticker"
+
+// TickerSource is a source that writes the data to zipper every interval, it is used to smoke test
+// a zipper deployment, e.g. the connectivity, the authentication, the routing and the latency.
+type TickerSource struct {
+	Source
+	tag       uint32
+	interval  time.Duration
+	payloadFn func(seq uint64) []byte
+}
+
+// NewTickerSource returns a TickerSource that writes the payload returned by payloadFn with the tag every
+// interval, the seq starts from 0. TickPayload is used if payloadFn is nil, e.g.
+//
+//	ticker := yomo.NewTickerSource("localhost:9000", 0x10, time.Second, nil)
+//	err := ticker.Run(ctx)
+func NewTickerSource(zipperAddr string, tag uint32, interval time.Duration, payloadFn func(seq uint64) []byte, opts ...SourceOption) *TickerSource {
+	if payloadFn == nil {
+		payloadFn = TickPayload
+	}
+	return &TickerSource{
+		Source:    NewSource(TickerSourceName, zipperAddr, opts...),
+		tag:       tag,
+		interval:  interval,
+		payloadFn: payloadFn,
+	}
+}
+
+// Run connects to zipper and writes the data every interval, until the ctx is done or the writing fails.
+// The source is closed when Run returns.
+func (s *TickerSource) Run(ctx context.Context) error {
+	if err := s.ConnectContext(ctx); err != nil {
+		return err
+	}
+	defer s.Close()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for seq := uint64(0); ; seq++ {
+		if err := s.WriteContext(ctx, s.tag, s.payloadFn(seq)); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// TickPayload returns the default payload of the TickerSource, it carries the seq and the time of writing,
+// which are parsed by ParseTickPayload to measure the latency.
+func TickPayload(seq uint64) []byte {
+	payload := make([]byte, 16)
+	binary.BigEndian.PutUint64(payload, seq)
+	binary.BigEndian.PutUint64(payload[8:], uint64(time.Now().UnixNano()))
+	return payload
+}
+
+// ParseTickPayload parses the payload returned by TickPayload, it returns false if the data is not a TickPayload.
+func ParseTickPayload(data []byte) (seq uint64, sent time.Time, ok bool) {
+	if len(data) != 16 {
+		return 0, time.Time{}, false
+	}
+	seq = binary.BigEndian.Uint64(data)
+	sent = time.Unix(0, int64(binary.BigEndian.Uint64(data[8:])))
+	return seq, sent, true
+}
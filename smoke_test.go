@@ -0,0 +1,50 @@
+package yomo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/serverless"
+)
+
+func TestSmoke(t *testing.T) {
+	echo := NewEchoFunction("localhost:9000", 0x41, 0x42, WithSfnCredential("token:<CREDENTIAL>"))
+	assert.NoError(t, echo.Connect())
+	defer echo.Close()
+
+	received := make(chan []byte, 10)
+	sink := NewStreamFunction("smoke-sink", "localhost:9000", WithSfnCredential("token:<CREDENTIAL>"))
+	sink.SetObserveDataTags(0x42)
+	sink.SetHandler(func(ctx serverless.Context) { received <- ctx.Data() })
+	assert.NoError(t, sink.Connect())
+	defer sink.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := NewTickerSource("localhost:9000", 0x41, 10*time.Millisecond, nil, WithCredential("token:<CREDENTIAL>"))
+	done := make(chan error)
+	go func() { done <- ticker.Run(ctx) }()
+
+	select {
+	case data := <-received:
+		_, sent, ok := ParseTickPayload(data)
+		assert.True(t, ok)
+		assert.True(t, time.Since(sent) < 3*time.Second)
+	case <-time.After(3 * time.Second):
+		t.Fatal("the echoed data is not received")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestTickPayload(t *testing.T) {
+	seq, sent, ok := ParseTickPayload(TickPayload(7))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(7), seq)
+	assert.WithinDuration(t, time.Now(), sent, time.Second)
+
+	_, _, ok = ParseTickPayload([]byte("yomo"))
+	assert.False(t, ok)
+}
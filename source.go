@@ -2,9 +2,12 @@ package yomo
 
 import (
 	"context"
+	"time"
 
 	"github.com/yomorun/yomo/core"
 	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/pkg/envelope"
 	"github.com/yomorun/yomo/pkg/id"
 )
 
@@ -14,10 +17,30 @@ type Source interface {
 	Close() error
 	// Connect to YoMo-Zipper.
 	Connect() error
-	// Write the data to directed downstream.
-	Write(tag uint32, data []byte) error
+	// ConnectContext connects to YoMo-Zipper, the connecting is canceled if the ctx is done.
+	ConnectContext(ctx context.Context) error
+	// Write the data to directed downstream, the opts override the properties of this message.
+	Write(tag uint32, data []byte, opts ...WriteOption) error
+	// WriteContext writes the data to directed downstream, the ctx controls the cancellation and deadline of writing.
+	WriteContext(ctx context.Context, tag uint32, data []byte, opts ...WriteOption) error
+	// WriteWithEnvelope writes the data with the envelope that is carried by metadata.
+	WriteWithEnvelope(tag uint32, data []byte, env envelope.Envelope, opts ...WriteOption) error
+	// WriteWithEnvelopeContext writes the data with the envelope, the ctx controls the cancellation and deadline of writing.
+	WriteWithEnvelopeContext(ctx context.Context, tag uint32, data []byte, env envelope.Envelope, opts ...WriteOption) error
+	// Signal writes a signal with the tag, the signal is a frame without payload and metadata, e.g. a trigger.
+	Signal(tag uint32) error
 	// SetErrorHandler set the error handler function when server error occurs
 	SetErrorHandler(fn func(err error))
+	// SetErrorFrameHandler sets the handler of the ErrorFrames, they report the data written by the source
+	// that fails to be processed, e.g. the handler of the sfn panics or zipper finds no sfn observing the tag.
+	SetErrorFrameHandler(fn func(ef *frame.ErrorFrame))
+	// Request calls the sfn observing the tag and waits for the data that it writes to the replyTo tag,
+	// the deadline of the ctx is carried to the sfn, so the expired request is not handled.
+	Request(ctx context.Context, tag, replyTo uint32, data []byte) ([]byte, error)
+	// UseWriteInterceptor appends interceptors that are applied to every outgoing frame.
+	UseWriteInterceptor(fns ...core.WriteInterceptor)
+	// Ping measures the round-trip time to YoMo-Zipper at the application layer.
+	Ping(ctx context.Context) (time.Duration, error)
 }
 
 // YoMo-Source
@@ -64,14 +87,59 @@ func (s *yomoSource) Close() error {
 
 // Connect to YoMo-Zipper.
 func (s *yomoSource) Connect() error {
-	return s.client.Connect(context.Background())
+	return s.ConnectContext(context.Background())
+}
+
+// ConnectContext connects to YoMo-Zipper with the ctx.
+func (s *yomoSource) ConnectContext(ctx context.Context) error {
+	return s.client.Connect(ctx)
 }
 
 // Write writes data with specified tag.
-func (s *yomoSource) Write(tag uint32, data []byte) error {
-	md, deferFunc := core.SourceMetadata(s.client.ClientID(), id.New(), s.name, s.client.TracerProvider(), s.client.Logger)
+func (s *yomoSource) Write(tag uint32, data []byte, opts ...WriteOption) error {
+	return s.WriteContext(context.Background(), tag, data, opts...)
+}
+
+// WriteContext writes data with specified tag and the ctx.
+func (s *yomoSource) WriteContext(ctx context.Context, tag uint32, data []byte, opts ...WriteOption) error {
+	return s.write(ctx, tag, data, nil, opts...)
+}
+
+// WriteWithEnvelope writes data with specified tag and envelope.
+func (s *yomoSource) WriteWithEnvelope(tag uint32, data []byte, env envelope.Envelope, opts ...WriteOption) error {
+	return s.WriteWithEnvelopeContext(context.Background(), tag, data, env, opts...)
+}
+
+// WriteWithEnvelopeContext writes data with specified tag, envelope and the ctx.
+func (s *yomoSource) WriteWithEnvelopeContext(ctx context.Context, tag uint32, data []byte, env envelope.Envelope, opts ...WriteOption) error {
+	return s.write(ctx, tag, data, env.Metadata(), opts...)
+}
+
+func (s *yomoSource) write(ctx context.Context, tag uint32, data []byte, extra metadata.M, opts ...WriteOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	wo := &writeOptions{tid: id.New()}
+	for _, o := range opts {
+		o(wo)
+	}
+	if wo.err != nil {
+		return wo.err
+	}
+
+	md, deferFunc := core.SourceMetadata(
+		s.client.ClientID(), wo.tid, s.name, s.client.TracerProvider(), s.client.Logger,
+		core.TagTraceAttrs(s.client.TagNamer(), tag),
+	)
 	defer deferFunc()
 
+	extra.Range(func(k, v string) bool {
+		md.Set(k, v)
+		return true
+	})
+	wo.apply(md)
+
 	mdBytes, err := md.Encode()
 	// metadata
 	if err != nil {
@@ -81,12 +149,62 @@ func (s *yomoSource) Write(tag uint32, data []byte) error {
 		Tag:      tag,
 		Metadata: mdBytes,
 		Payload:  data,
+		Priority: wo.priority,
+		Channel:  wo.channel,
 	}
-	s.client.Logger.Debug("source write", "tag", tag, "data", data)
-	return s.client.WriteFrame(f)
+	s.client.Logger.Debug("source write", "tag", tag, "tag_name", core.TagName(s.client.TagNamer(), tag), "data", data)
+	return s.client.WriteFrameContext(ctx, f)
+}
+
+// Signal writes a signal with the tag, it is not traced and carries no metadata.
+func (s *yomoSource) Signal(tag uint32) error {
+	s.client.Logger.Debug("source signal", "tag", tag, "tag_name", core.TagName(s.client.TagNamer(), tag))
+	return s.client.WriteFrame(&frame.DataFrame{Tag: tag})
 }
 
 // SetErrorHandler set the error handler function when server error occurs
 func (s *yomoSource) SetErrorHandler(fn func(err error)) {
 	s.client.SetErrorHandler(fn)
 }
+
+// SetErrorFrameHandler sets the handler of the ErrorFrames reporting the failed data written by the source.
+func (s *yomoSource) SetErrorFrameHandler(fn func(ef *frame.ErrorFrame)) {
+	s.client.SetErrorFrameObserver(fn)
+}
+
+// Request calls the sfn observing the tag and waits for the response written to the replyTo tag.
+func (s *yomoSource) Request(ctx context.Context, tag, replyTo uint32, data []byte) ([]byte, error) {
+	md, deferFunc := core.SourceMetadata(
+		s.client.ClientID(), id.New(), s.name, s.client.TracerProvider(), s.client.Logger,
+		core.TagTraceAttrs(s.client.TagNamer(), tag),
+	)
+	defer deferFunc()
+
+	mdBytes, err := md.Encode()
+	if err != nil {
+		return nil, err
+	}
+	rf := &frame.RequestFrame{
+		Tag:      tag,
+		ReplyTo:  replyTo,
+		Metadata: mdBytes,
+		Payload:  data,
+	}
+	s.client.Logger.Debug("source request", "tag", tag, "tag_name", core.TagName(s.client.TagNamer(), tag))
+
+	res, err := s.client.Request(ctx, rf)
+	if err != nil {
+		return nil, err
+	}
+	return res.Payload, nil
+}
+
+// UseWriteInterceptor appends interceptors that are applied to every outgoing frame.
+func (s *yomoSource) UseWriteInterceptor(fns ...core.WriteInterceptor) {
+	s.client.UseWriteInterceptor(fns...)
+}
+
+// Ping measures the round-trip time to YoMo-Zipper at the application layer.
+func (s *yomoSource) Ping(ctx context.Context) (time.Duration, error) {
+	return s.client.Ping(ctx)
+}
@@ -1,11 +1,14 @@
 package yomo
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
 	"github.com/yomorun/yomo/core/ylog"
 	"github.com/yomorun/yomo/serverless"
 )
@@ -55,3 +58,45 @@ func TestSource(t *testing.T) {
 
 	<-exit
 }
+
+func TestSourceWriteContext(t *testing.T) {
+	source := NewSource("test-source-ctx", "localhost:9000")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := source.WriteContext(ctx, 0x21, []byte("test"))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = source.ConnectContext(ctx)
+	assert.Error(t, err)
+}
+
+func TestWriteOptions(t *testing.T) {
+	wo := &writeOptions{}
+	for _, o := range []WriteOption{
+		WithTarget("sfn-1"),
+		WithTID("tid"),
+		WithMetadata(map[string]string{"foo": "bar"}),
+		WithTTL(time.Minute),
+		WithPriority(frame.PriorityHigh),
+	} {
+		o(wo)
+	}
+	assert.Equal(t, "tid", wo.tid)
+	assert.Equal(t, frame.PriorityHigh, wo.priority)
+
+	md := metadata.M{}
+	wo.apply(md)
+
+	assert.Equal(t, "sfn-1", core.GetTargetFromMetadata(md))
+	foo, _ := md.Get("foo")
+	assert.Equal(t, "bar", foo)
+	expire, ok := core.GetExpireFromMetadata(md)
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expire, time.Second)
+
+	source := NewSource("test-source-md", "localhost:9000")
+	err := source.Write(0x21, []byte("test"), WithMetadata(map[string]string{core.MetadataTargetKey: "sfn-2"}))
+	assert.ErrorIs(t, err, ErrReservedMetadataKey)
+}
@@ -3,6 +3,9 @@ package yomo
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/yomorun/yomo/core"
 	"github.com/yomorun/yomo/core/frame"
@@ -22,11 +25,15 @@ type Zipper interface {
 	// ListenAndServe start zipper as server.
 	ListenAndServe(context.Context, string) error
 
+	// Shutdown drains the connections and closes the zipper, see core.Server.Shutdown.
+	Shutdown(context.Context) error
+
 	// Close will close the zipper.
 	Close() error
 }
 
-// RunZipper run a zipper from a config file.
+// RunZipper run a zipper from a config file. The auth, the mesh and the dispatch of the config file are reloaded
+// on SIGHUP or by `POST /reload` of the admin, without disconnecting the clients.
 func RunZipper(ctx context.Context, configPath string) error {
 	conf, err := config.ParseConfigFile(configPath)
 	if err != nil {
@@ -36,12 +43,18 @@ func RunZipper(ctx context.Context, configPath string) error {
 	// listening address.
 	listenAddr := fmt.Sprintf("%s:%d", conf.Host, conf.Port)
 
-	options := []ZipperOption{}
-	if _, ok := conf.Auth["type"]; ok {
-		if tokenString, ok := conf.Auth["token"]; ok {
-			options = append(options, WithAuth("token", tokenString))
-		}
+	options := []ZipperOption{withConfigFileReloader(configPath, conf)}
+	for name, args := range configAuths(conf) {
+		options = append(options, WithAuth(name, args...))
+	}
+	if conf.Codec != "" {
+		options = append(options, WithZipperCodecName(conf.Codec))
+	}
+	policy, tags, stickyTTL, err := configDispatch(conf)
+	if err != nil {
+		return err
 	}
+	options = append(options, dispatchOptions(policy, tags, stickyTTL)...)
 
 	zipper, err := NewZipper(conf.Name, router.Default(), core.DefaultVersionNegotiateFunc, conf.Mesh, options...)
 	if err != nil {
@@ -63,6 +76,9 @@ func NewZipper(
 		o(opts)
 	}
 
+	if opts.configReloader != nil {
+		opts.serverOption = append(opts.serverOption, core.WithReloadFunc(opts.configReloader(opts.clientOption)))
+	}
 	server := core.NewServer(name, opts.serverOption...)
 
 	// add downstreams to server.
@@ -70,34 +86,174 @@ func NewZipper(
 		if meshName == "" || meshName == name {
 			continue
 		}
-		addr := fmt.Sprintf("%s:%d", meshConf.Host, meshConf.Port)
+		server.AddDownstreamServer(newDownstream(server, meshName, meshConf, opts.clientOption))
+	}
+
+	server.ConfigRouter(router)
+
+	server.ConfigVersionNegotiateFunc(vgfn)
+
+	// watch signal.
+	if !opts.noSignalHandler {
+		go waitSignalForShutdownServer(server)
+	}
+
+	return server, nil
+}
+
+// configDispatch returns the dispatch policies of the config, see core.Server.SetDispatchPolicies.
+func configDispatch(conf config.Config) (core.DispatchPolicy, map[frame.Tag]core.DispatchPolicy, time.Duration, error) {
+	if conf.Dispatch == nil {
+		return core.DispatchBroadcast, nil, 0, nil
+	}
+	parse := func(name string) (core.DispatchPolicy, error) {
+		if name == "" {
+			return core.DispatchBroadcast, nil
+		}
+		return core.ParseDispatchPolicy(name)
+	}
 
-		clientOptions := []core.ClientOption{
-			core.WithCredential(meshConf.Credential),
-			core.WithNonBlockWrite(),
-			core.WithReConnect(),
-			core.WithLogger(server.Logger().With("downstream_name", meshName, "downstream_addr", addr)),
+	policy, err := parse(conf.Dispatch.Policy)
+	if err != nil {
+		return policy, nil, 0, err
+	}
+	tags := make(map[frame.Tag]core.DispatchPolicy, len(conf.Dispatch.Tags))
+	for tag, name := range conf.Dispatch.Tags {
+		if tags[tag], err = parse(name); err != nil {
+			return policy, nil, 0, err
 		}
-		clientOptions = append(clientOptions, opts.clientOption...)
+	}
+	return policy, tags, conf.Dispatch.StickyTTL, nil
+}
 
-		downstream := &downstream{
-			localName: meshName,
-			client:    core.NewClient(name, addr, core.ClientTypeUpstreamZipper, clientOptions...),
+// dispatchOptions returns the options setting the dispatch policies, see configDispatch.
+func dispatchOptions(policy core.DispatchPolicy, tags map[frame.Tag]core.DispatchPolicy, stickyTTL time.Duration) []ZipperOption {
+	options := []ZipperOption{WithZipperDispatchPolicy(policy)}
+	if policy == core.DispatchSticky {
+		options = append(options, WithZipperStickyRouting(stickyTTL))
+	}
+	for tag, p := range tags {
+		if p == core.DispatchSticky {
+			options = append(options, WithZipperStickyRouting(stickyTTL, tag))
+			continue
 		}
+		options = append(options, WithZipperDispatchPolicy(p, tag))
+	}
+	return options
+}
+
+// replicationCoalesceDelay is the max delay of coalescing the frames of a replicated batch,
+// the frames of a batch are queued back to back, so it is short.
+const replicationCoalesceDelay = time.Millisecond
 
-		server.Logger().Info("add downstream", "downstream_id", downstream.ID(), "downstream_name", downstream.LocalName(), "downstream_addr", addr)
+// newDownstream returns the downstream of the mesh zipper.
+func newDownstream(server *core.Server, meshName string, meshConf config.Mesh, options []ClientOption) core.Downstream {
+	addr := fmt.Sprintf("%s:%d", meshConf.Host, meshConf.Port)
 
-		server.AddDownstreamServer(downstream)
+	clientOptions := []core.ClientOption{
+		core.WithCredential(meshConf.Credential),
+		core.WithNonBlockWrite(),
+		core.WithReConnect(),
+		core.WithLogger(server.Logger().With("downstream_name", meshName, "downstream_addr", addr)),
+	}
+	if meshConf.Replication != nil && meshConf.Replication.BatchSize > 1 {
+		// the batch flushed by the replication link goes to the wire in a single write.
+		clientOptions = append(clientOptions, core.WithWriteCoalescing(replicationCoalesceDelay, 0))
 	}
+	clientOptions = append(clientOptions, options...)
 
-	server.ConfigRouter(router)
+	downstream := &downstream{
+		localName: meshName,
+		client:    core.NewClient(server.Name(), addr, core.ClientTypeUpstreamZipper, clientOptions...),
+		filter:    core.TagFilter{Allow: meshConf.AllowTags, Deny: meshConf.DenyTags},
+	}
 
-	server.ConfigVersionNegotiateFunc(vgfn)
+	server.Logger().Info("add downstream", "downstream_id", downstream.ID(), "downstream_name", downstream.LocalName(), "downstream_addr", addr)
 
-	// watch signal.
-	go waitSignalForShutdownServer(server)
+	if meshConf.Replication != nil {
+		return core.NewReplicationLink(downstream, replicationOptions(meshConf.Replication), server.Logger())
+	}
+	return downstream
+}
 
-	return server, nil
+// configAuths returns the authentication methods of the config, see core.Server.SetAuths.
+func configAuths(conf config.Config) map[string][]string {
+	auths := map[string][]string{}
+	typ, ok := conf.Auth["type"]
+	if !ok {
+		return auths
+	}
+	// the tenant typed auth has the tokens of the tenants, e.g. `tenants: acme=<CREDENTIAL>,globex=<CREDENTIAL>`.
+	if typ == "tenant" {
+		if tenants, ok := conf.Auth["tenants"]; ok {
+			auths["tenant"] = strings.Split(tenants, ",")
+		}
+		return auths
+	}
+	if tokenString, ok := conf.Auth["token"]; ok {
+		auths["token"] = []string{tokenString}
+	}
+	return auths
+}
+
+// withConfigFileReloader reloads the config file on SIGHUP or by `POST /reload` of the admin, see configFileReloader.
+func withConfigFileReloader(configPath string, conf config.Config) ZipperOption {
+	return func(o *zipperOptions) {
+		o.configReloader = func(upstreamOptions []ClientOption) core.ReloadFunc {
+			return configFileReloader(configPath, conf, upstreamOptions)
+		}
+	}
+}
+
+// configFileReloader returns the function that parses the config file again and applies the changes of the
+// auth, the mesh and the dispatch, the downstreams whose mesh config is changed are reconnected, the others
+// stay connected. The name, host, port and codec are not reloaded, and the downstreams are created with
+// upstreamOptions, e.g. the tls config and the codec of the zipper, see WithUpstreamOption.
+func configFileReloader(configPath string, conf config.Config, upstreamOptions []ClientOption) core.ReloadFunc {
+	return func(server *core.Server) error {
+		next, err := config.ParseConfigFile(configPath)
+		if err != nil {
+			return err
+		}
+		policy, tags, stickyTTL, err := configDispatch(next)
+		if err != nil {
+			return err
+		}
+		if next.Name != conf.Name || next.Host != conf.Host || next.Port != conf.Port || next.Codec != conf.Codec {
+			server.Logger().Warn("the name, host, port and codec are not reloaded, restart the zipper to change them", "file_path", configPath)
+		}
+
+		server.SetAuths(configAuths(next))
+		server.SetDispatchPolicies(policy, tags, stickyTTL)
+		reloadMesh(server, conf.Mesh, next.Mesh, upstreamOptions)
+
+		conf.Auth, conf.Mesh = next.Auth, next.Mesh
+		return nil
+	}
+}
+
+// reloadMesh removes the downstreams that are removed or changed from prev to next, and adds the new ones
+// with the options.
+func reloadMesh(server *core.Server, prev, next map[string]config.Mesh, options []ClientOption) {
+	ids := server.Downstreams()
+
+	for meshName, meshConf := range prev {
+		if nextConf, ok := next[meshName]; !ok || !reflect.DeepEqual(meshConf, nextConf) {
+			if id, ok := ids[meshName]; ok {
+				server.Logger().Info("remove downstream", "downstream_id", id, "downstream_name", meshName)
+				server.RemoveDownstreamServer(id)
+			}
+		}
+	}
+	for meshName, meshConf := range next {
+		if meshName == "" || meshName == server.Name() {
+			continue
+		}
+		if prevConf, ok := prev[meshName]; ok && reflect.DeepEqual(meshConf, prevConf) {
+			continue
+		}
+		server.AddDownstreamServer(newDownstream(server, meshName, meshConf, options))
+	}
 }
 
 func statsToLogger(server *core.Server) {
@@ -108,18 +264,54 @@ func statsToLogger(server *core.Server) {
 		"zipper_name", server.Name(),
 		"connector", server.StatsFunctions(),
 		"downstreams", server.Downstreams(),
+		"replication", server.ReplicationStats(),
+		"slow_consumer_dropped", server.StatsDropped(),
 		"data_frame_received_num", server.StatsCounter(),
 	)
 }
 
+func replicationOptions(conf *config.Replication) core.ReplicationOptions {
+	opts := core.ReplicationOptions{
+		BatchSize:       conf.BatchSize,
+		BatchDelay:      conf.BatchDelay,
+		Compress:        conf.Compress,
+		LowPriorityTags: conf.LowPriorityTags,
+		MaxDeferred:     conf.MaxDeferred,
+	}
+	if len(conf.OffPeakHours) > 0 {
+		hours := make(map[int]struct{}, len(conf.OffPeakHours))
+		for _, h := range conf.OffPeakHours {
+			hours[h] = struct{}{}
+		}
+		opts.OffPeak = func(t time.Time) bool {
+			_, ok := hours[t.Hour()]
+			return ok
+		}
+	}
+	return opts
+}
+
 type downstream struct {
 	localName string
 	client    *core.Client
+	filter    core.TagFilter
 }
 
+var (
+	_ core.DownstreamTagFilter          = &downstream{}
+	_ core.DownstreamErrorFrameObserver = &downstream{}
+)
+
 func (d *downstream) Close() error                      { return d.client.Close() }
 func (d *downstream) Connect(ctx context.Context) error { return d.client.Connect(ctx) }
 func (d *downstream) ID() string                        { return d.client.ClientID() }
 func (d *downstream) LocalName() string                 { return d.localName }
 func (d *downstream) RemoteName() string                { return d.client.Name() }
 func (d *downstream) WriteFrame(f frame.Frame) error    { return d.client.WriteFrame(f) }
+func (d *downstream) AllowTag(tag frame.Tag) bool       { return d.filter.Allowed(tag) }
+func (d *downstream) SetErrorFrameObserver(fn func(*frame.ErrorFrame)) {
+	d.client.SetErrorFrameObserver(fn)
+}
+func (d *downstream) Ping(ctx context.Context) (time.Duration, error) {
+	return d.client.Ping(ctx)
+}
@@ -4,6 +4,7 @@
 package yomo
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"runtime"
@@ -17,16 +18,17 @@ import (
 // - `kill -SIGUSR1 <pid>` inspect state()
 // - `kill -SIGTERM <pid>` graceful shutdown
 // - `kill -SIGUSR2 <pid>` inspect golang GC
+// - `kill -SIGHUP <pid>` reload the config
 func waitSignalForShutdownServer(server *core.Server) {
 	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGTERM, syscall.SIGUSR2, syscall.SIGUSR1, syscall.SIGINT)
-	ylog.Info("Listening SIGUSR1, SIGUSR2, SIGTERM/SIGINT...")
+	signal.Notify(c, syscall.SIGTERM, syscall.SIGUSR2, syscall.SIGUSR1, syscall.SIGINT, syscall.SIGHUP)
+	ylog.Info("Listening SIGUSR1, SIGUSR2, SIGHUP, SIGTERM/SIGINT...")
 	for p1 := range c {
 		ylog.Debug("Received signal", "signal", p1)
 		if p1 == syscall.SIGTERM || p1 == syscall.SIGINT {
 			ylog.Debug("graceful shutting down ...", "sign", p1)
-			// waiting for the server to finish processing the current request
-			server.Close()
+			// the clients are drained within the drain timeout, the error is logged by the server.
+			_ = server.Shutdown(context.Background())
 			os.Exit(0)
 		} else if p1 == syscall.SIGUSR2 {
 			var m runtime.MemStats
@@ -34,6 +36,9 @@ func waitSignalForShutdownServer(server *core.Server) {
 			ylog.Debug("runtime stats", "gc_nums", m.NumGC)
 		} else if p1 == syscall.SIGUSR1 {
 			statsToLogger(server)
+		} else if p1 == syscall.SIGHUP {
+			// the error is logged by the server.
+			_ = server.Reload()
 		}
 	}
 }
@@ -1,14 +1,21 @@
 package yomo
 
 import (
+	"context"
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/router"
 	"github.com/yomorun/yomo/core/ylog"
 	"github.com/yomorun/yomo/pkg/config"
+	"github.com/yomorun/yomo/pkg/frame-codec/cborcodec"
 )
 
 func TestZipperRun(t *testing.T) {
@@ -30,3 +37,164 @@ func TestZipperRun(t *testing.T) {
 	time.Sleep(time.Second)
 	assert.Nil(t, err)
 }
+
+func TestConfigFileReloader(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "zipper.yaml")
+	writeConfig := func(conf string) {
+		assert.NoError(t, os.WriteFile(configPath, []byte(conf), 0o644))
+	}
+	writeConfig(`
+name: zipper
+host: 127.0.0.1
+port: 9000
+mesh:
+  a:
+    host: 127.0.0.1
+    port: 9001
+  b:
+    host: 127.0.0.1
+    port: 9002
+`)
+	conf, err := config.ParseConfigFile(configPath)
+	assert.NoError(t, err)
+
+	zipper, err := NewZipper(
+		conf.Name, router.Default(), core.DefaultVersionNegotiateFunc, conf.Mesh,
+		withConfigFileReloader(configPath, conf),
+		WithoutZipperSignalHandler(),
+	)
+	assert.NoError(t, err)
+	server := zipper.(*core.Server)
+	defer server.Close()
+
+	before := server.Downstreams()
+	assert.Len(t, before, 2)
+
+	// a is unchanged, b is changed and c is added.
+	writeConfig(`
+name: zipper
+host: 127.0.0.1
+port: 9000
+mesh:
+  a:
+    host: 127.0.0.1
+    port: 9001
+  b:
+    host: 127.0.0.1
+    port: 9012
+  c:
+    host: 127.0.0.1
+    port: 9003
+`)
+	assert.NoError(t, server.Reload())
+
+	after := server.Downstreams()
+	assert.Len(t, after, 3)
+	assert.Equal(t, before["a"], after["a"])
+	assert.NotEqual(t, before["b"], after["b"])
+	assert.Contains(t, after, "c")
+
+	// the invalid config is not applied.
+	writeConfig(`name: zipper`)
+	assert.Error(t, server.Reload())
+	assert.Equal(t, after, server.Downstreams())
+
+	writeConfig(`
+name: zipper
+host: 127.0.0.1
+port: 9000
+dispatch:
+  policy: random
+`)
+	assert.EqualError(t, server.Reload(), `yomo: unknown dispatch policy "random"`)
+	assert.Equal(t, after, server.Downstreams())
+}
+
+func TestConfigFileReloaderUpstreamOptions(t *testing.T) {
+	const (
+		zipperAddr     = "127.0.0.1:19957"
+		downstreamAddr = "127.0.0.1:19956"
+	)
+
+	// the downstream zipper only speaks cbor.
+	downstream := core.NewServer("downstream", core.WithServerCodecName(cborcodec.Name), core.WithServerLogger(ylog.Default()))
+	go downstream.ListenAndServe(context.TODO(), downstreamAddr)
+	defer downstream.Close()
+
+	configPath := filepath.Join(t.TempDir(), "zipper.yaml")
+	writeConfig := func(conf string) {
+		assert.NoError(t, os.WriteFile(configPath, []byte(conf), 0o644))
+	}
+	writeConfig(`
+name: zipper
+host: 127.0.0.1
+port: 19957
+`)
+	conf, err := config.ParseConfigFile(configPath)
+	assert.NoError(t, err)
+
+	var tlsLoaded atomic.Bool
+	zipper, err := NewZipper(
+		conf.Name, router.Default(), core.DefaultVersionNegotiateFunc, conf.Mesh,
+		withConfigFileReloader(configPath, conf),
+		WithZipperCodecName(cborcodec.Name),
+		WithUpstreamOption(core.WithTLSConfigLoader(func() (*tls.Config, error) {
+			tlsLoaded.Store(true)
+			return nil, nil
+		})),
+		WithZipperLogger(ylog.Default()),
+		WithoutZipperSignalHandler(),
+	)
+	assert.NoError(t, err)
+	server := zipper.(*core.Server)
+	go server.ListenAndServe(context.TODO(), zipperAddr)
+	defer server.Close()
+
+	// the downstream added by reloading is created with the codec and the tls config of the zipper.
+	writeConfig(`
+name: zipper
+host: 127.0.0.1
+port: 19957
+mesh:
+  downstream:
+    host: 127.0.0.1
+    port: 19956
+`)
+	assert.NoError(t, server.Reload())
+	assert.Eventually(t, func() bool {
+		return downstream.StatsConnections()[core.ClientTypeUpstreamZipper.String()] == 1
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.True(t, tlsLoaded.Load())
+}
+
+func TestConfigDispatch(t *testing.T) {
+	policy, tags, ttl, err := configDispatch(config.Config{})
+	assert.NoError(t, err)
+	assert.Equal(t, core.DispatchBroadcast, policy)
+	assert.Empty(t, tags)
+	assert.Zero(t, ttl)
+
+	policy, tags, ttl, err = configDispatch(config.Config{Dispatch: &config.Dispatch{
+		Tags:      map[uint32]string{1: "sticky", 2: "round_robin"},
+		StickyTTL: time.Minute,
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, core.DispatchBroadcast, policy)
+	assert.Equal(t, map[frame.Tag]core.DispatchPolicy{1: core.DispatchSticky, 2: core.DispatchRoundRobin}, tags)
+	assert.Equal(t, time.Minute, ttl)
+
+	_, _, _, err = configDispatch(config.Config{Dispatch: &config.Dispatch{Tags: map[uint32]string{1: "random"}}})
+	assert.Error(t, err)
+}
+
+func TestConfigAuths(t *testing.T) {
+	assert.Equal(t, map[string][]string{}, configAuths(config.Config{}))
+	assert.Equal(t,
+		map[string][]string{"token": {"<CREDENTIAL>"}},
+		configAuths(config.Config{Auth: map[string]string{"type": "token", "token": "<CREDENTIAL>"}}),
+	)
+	assert.Equal(t,
+		map[string][]string{"tenant": {"acme=token-a", "globex=token-g"}},
+		configAuths(config.Config{Auth: map[string]string{"type": "tenant", "tenants": "acme=token-a,globex=token-g"}}),
+	)
+}
@@ -4,6 +4,7 @@
 package yomo
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
@@ -21,8 +22,9 @@ func waitSignalForShutdownServer(server *core.Server) {
 	for p1 := range c {
 		ylog.Debug("Received signal", "signal", p1)
 		if p1 == syscall.SIGTERM || p1 == syscall.SIGINT {
-			server.Close()
 			ylog.Debug("graceful shutting down ...", "sign", p1)
+			// the clients are drained within the drain timeout, the error is logged by the server.
+			_ = server.Shutdown(context.Background())
 			os.Exit(0)
 		}
 	}